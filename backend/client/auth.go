@@ -0,0 +1,62 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Authenticator adds credentials to an outgoing request.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to Authenticator.
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate calls f.
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// DefaultSessionCookie is the name of the Kratos session cookie.
+const DefaultSessionCookie = "ory_kratos_session"
+
+// SessionCookie authenticates through Oathkeeper with a Kratos session cookie.
+func SessionCookie(value string) Authenticator {
+	return NamedSessionCookie(DefaultSessionCookie, value)
+}
+
+// NamedSessionCookie is SessionCookie for a Kratos deployment with a custom cookie name.
+func NamedSessionCookie(name, value string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+		return nil
+	})
+}
+
+// Identity is the caller that Oathkeeper would forward to the backend.
+type Identity struct {
+	Subject  string
+	Roles    []string
+	UserType string
+	TenantID string
+}
+
+// IdentityHeaders sends the identity headers that Oathkeeper normally sets. It is only
+// meant for services that reach the backend directly inside the trusted network, since
+// the backend accepts these headers as they are.
+func IdentityHeaders(identity Identity) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("X-Session-Subject", identity.Subject)
+		if len(identity.Roles) > 0 {
+			req.Header.Set("X-Session-Roles", strings.Join(identity.Roles, ","))
+		}
+		if identity.UserType != "" {
+			req.Header.Set("X-Session-User-Type", identity.UserType)
+		}
+		if identity.TenantID != "" {
+			req.Header.Set("X-Tenant-Id", identity.TenantID)
+		}
+		return nil
+	})
+}
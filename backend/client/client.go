@@ -0,0 +1,205 @@
+// Package client is a typed Go client for the portal HTTP API. It covers the management
+// endpoints below /api/v1, except member imports and SCIM, and decodes failures into
+// *Error.
+//
+//	c, err := client.New(client.Options{
+//		BaseURL: "https://portal.example.com",
+//		Auth:    client.SessionCookie(token),
+//	})
+//	tenant, err := c.CreateTenant(ctx, client.TenantInput{Code: "acme", Name: "Acme"})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const apiPrefix = "/api/v1"
+
+// Client calls the portal API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	auth       Authenticator
+	language   string
+	userAgent  string
+}
+
+// Options configures a Client.
+type Options struct {
+	// BaseURL is the public portal address, or the backend address when calling it from
+	// inside the cluster. The client appends /api/v1.
+	BaseURL string
+	// Auth adds credentials to every request. It may be nil.
+	Auth Authenticator
+	// HTTPClient sends the requests. Timeout is ignored when it is set.
+	HTTPClient *http.Client
+	Timeout    time.Duration
+	// Language is sent as Accept-Language and selects the language of error messages.
+	Language  string
+	UserAgent string
+}
+
+// New creates a client for the portal at opts.BaseURL.
+func New(opts Options) (*Client, error) {
+	if strings.TrimSpace(opts.BaseURL) == "" {
+		return nil, fmt.Errorf("portal base url is required")
+	}
+	baseURL, err := url.Parse(strings.TrimSpace(opts.BaseURL))
+	if err != nil {
+		return nil, fmt.Errorf("parse portal base url: %w", err)
+	}
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("portal base url must be absolute: %s", opts.BaseURL)
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		timeout := opts.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		httpClient = &http.Client{Timeout: timeout}
+	}
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = "portal-go-client"
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		auth:       opts.Auth,
+		language:   opts.Language,
+		userAgent:  userAgent,
+	}, nil
+}
+
+// RequestOption adjusts a single request.
+type RequestOption func(*http.Request)
+
+// WithIfMatch makes a write conditional on the resource still having the given entity
+// tag. A stale tag fails with 412 and an *Error carrying the current representation.
+func WithIfMatch(etag string) RequestOption {
+	return func(req *http.Request) {
+		if etag != "" {
+			req.Header.Set("If-Match", etag)
+		}
+	}
+}
+
+// WithIdempotencyKey makes a write safe to retry: the server replays the stored response
+// for a repeated key instead of applying the write again.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(req *http.Request) {
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+	}
+}
+
+// WithImpersonation runs the request inside an impersonation session.
+func WithImpersonation(sessionID string) RequestOption {
+	return func(req *http.Request) {
+		if sessionID != "" {
+			req.Header.Set("X-Impersonation-Session", sessionID)
+		}
+	}
+}
+
+// WithHeader sets an arbitrary request header.
+func WithHeader(key, value string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// do sends a request to the API path below /api/v1 and decodes the JSON response into
+// out, which may be nil.
+func (c *Client) do(ctx context.Context, method, apiPath string, query url.Values, body, out any, opts []RequestOption) error {
+	resp, err := c.send(ctx, method, apiPath, query, body, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, apiPath, err)
+	}
+	return nil
+}
+
+// send performs the request and returns the response of a successful call; the caller
+// closes its body. Failed calls are returned as *Error.
+func (c *Client) send(ctx context.Context, method, apiPath string, query url.Values, body any, opts []RequestOption) (*http.Response, error) {
+	reqURL := *c.baseURL
+	// apiPath carries escaped IDs, so it is joined onto the escaped base path and both
+	// forms are kept to send the escapes unchanged.
+	reqURL.RawPath = path.Join(reqURL.EscapedPath(), apiPrefix, apiPath)
+	unescaped, err := url.PathUnescape(reqURL.RawPath)
+	if err != nil {
+		return nil, fmt.Errorf("build %s %s request: %w", method, apiPath, err)
+	}
+	reqURL.Path = unescaped
+	if len(query) > 0 {
+		reqURL.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s %s request: %w", method, apiPath, err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("build %s %s request: %w", method, apiPath, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("authenticate request: %w", err)
+		}
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("exec %s %s: %w", method, apiPath, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// escape quotes an ID for use as a path segment.
+func escape(id string) string {
+	return url.PathEscape(id)
+}
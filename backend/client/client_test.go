@@ -0,0 +1,422 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+	"github.com/laofa009/next-agent-portal/backend/internal/config"
+	"github.com/laofa009/next-agent-portal/backend/internal/keto"
+	"github.com/laofa009/next-agent-portal/backend/internal/ketosync"
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/server"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// databaseEnv names a Postgres database the tests may migrate and write to. Tests that
+// need stored data are skipped when it is not set.
+const databaseEnv = "PORTAL_TEST_DATABASE_DSN"
+
+// portal is the backend served in-process. Keto and Kratos are replaced by oryStub.
+type portal struct {
+	handler http.Handler
+	ory     *oryStub
+}
+
+// newPortal builds the backend from configs/app.yaml. pool may be nil for tests that
+// never reach the database.
+func newPortal(t *testing.T, pool *pgxpool.Pool) *portal {
+	t.Helper()
+
+	ory := newOryStub(t)
+	cfg, err := config.Load("../configs/app.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Keto.ReadRemote = ory.url
+	cfg.Keto.WriteRemote = ory.url
+	cfg.Kratos.AdminURL = ory.url
+	cfg.Kratos.PublicURL = ory.url
+
+	logger := zap.NewNop()
+	ketoClient, err := keto.NewClient(keto.Options{
+		ReadRemote:         cfg.Keto.ReadRemote,
+		WriteRemote:        cfg.Keto.WriteRemote,
+		PermissionRelation: cfg.Keto.PermissionRelation,
+		MembershipRelation: cfg.Keto.MembershipRelation,
+		NamespacePrefix:    cfg.Keto.NamespacePrefix,
+		Timeout:            cfg.Keto.RequestTimeout,
+	}, logger)
+	if err != nil {
+		t.Fatalf("init keto client: %v", err)
+	}
+	kratosClient, err := kratos.NewClient(kratos.Options{
+		AdminURL:  cfg.Kratos.AdminURL,
+		PublicURL: cfg.Kratos.PublicURL,
+		SchemaID:  cfg.Kratos.SchemaID,
+		Timeout:   cfg.Kratos.Timeout,
+	}, logger)
+	if err != nil {
+		t.Fatalf("init kratos client: %v", err)
+	}
+
+	queries := sqldb.New(pool)
+	groupRepo := storage.NewGroupRepository(pool, queries)
+	roleRepo := storage.NewRoleRepository(pool, queries)
+	srv := server.New(cfg, logger, server.Deps{
+		Keto:           ketoClient,
+		KetoSync:       ketosync.New(storage.NewKetoSyncRepository(queries), groupRepo, roleRepo, ketoClient, kratosClient, ketosync.Options{}, logger),
+		Kratos:         kratosClient,
+		Tenants:        storage.NewTenantRepository(pool, queries),
+		Groups:         groupRepo,
+		Roles:          roleRepo,
+		Permissions:    storage.NewPermissionRepository(queries),
+		Impersonations: storage.NewImpersonationRepository(queries),
+		Audit:          storage.NewAuditRepository(queries),
+		Webhooks:       storage.NewWebhookRepository(queries),
+		Scim:           storage.NewScimRepository(pool, queries),
+		MemberImports:  storage.NewMemberImportRepository(pool, queries),
+		Users:          storage.NewUserRepository(pool, queries),
+		Invitations:    storage.NewInvitationRepository(pool, queries),
+		Idempotency:    storage.NewIdempotencyRepository(queries),
+		Pool:           pool,
+	})
+	return &portal{handler: srv.Handler(), ory: ory}
+}
+
+// client serves the portal and returns a client for it.
+func (p *portal) client(t *testing.T, opts client.Options) *client.Client {
+	t.Helper()
+	return serve(t, p.handler, opts)
+}
+
+// serve starts handler, usually the portal wrapped by a stand-in proxy, and returns a
+// client for it.
+func serve(t *testing.T, handler http.Handler, opts client.Options) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	opts.BaseURL = srv.URL
+	c, err := client.New(opts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+// testDatabase connects to the database named by databaseEnv and applies the migrations,
+// or skips the test when the variable is not set.
+func testDatabase(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv(databaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", databaseEnv)
+	}
+
+	ctx := context.Background()
+	pool, err := storage.NewPool(ctx, storage.PoolConfig{DSN: dsn, MaxOpenConns: 4})
+	if err != nil {
+		t.Fatalf("connect database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := storage.NewMigrator(pool)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	return pool
+}
+
+// oryStub answers the Keto read and write APIs and records the tuples written. Checks
+// are allowed unless denied is set; failing makes every call fail with 500.
+type oryStub struct {
+	url string
+
+	mu      sync.Mutex
+	denied  bool
+	failing bool
+	writes  []keto.RelationTuple
+}
+
+func newOryStub(t *testing.T) *oryStub {
+	stub := &oryStub{}
+	srv := httptest.NewServer(http.HandlerFunc(stub.serveHTTP))
+	t.Cleanup(srv.Close)
+	stub.url = srv.URL
+	return stub
+}
+
+func (s *oryStub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failing {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case r.URL.Path == "/relation-tuples/check":
+		writeJSON(w, http.StatusOK, map[string]bool{"allowed": !s.denied})
+	case r.URL.Path == "/relation-tuples" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"relation_tuples": []keto.RelationTuple{}})
+	case r.URL.Path == "/admin/relation-tuples" && r.Method == http.MethodPut:
+		var tuple keto.RelationTuple
+		if err := json.NewDecoder(r.Body).Decode(&tuple); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.writes = append(s.writes, tuple)
+		writeJSON(w, http.StatusCreated, tuple)
+	case r.URL.Path == "/admin/relation-tuples" && r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *oryStub) set(denied, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.denied = denied
+	s.failing = failing
+}
+
+// written returns the tuples written so far with the given relation.
+func (s *oryStub) written(relation string) []keto.RelationTuple {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tuples []keto.RelationTuple
+	for _, tuple := range s.writes {
+		if tuple.Relation == relation {
+			tuples = append(tuples, tuple)
+		}
+	}
+	return tuples
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func platformAdmin() client.Authenticator {
+	return client.IdentityHeaders(client.Identity{
+		Subject: uuid.NewString(),
+		Roles:   []string{"platform_admin"},
+	})
+}
+
+func tenantAdmin(tenantID string) client.Authenticator {
+	return client.IdentityHeaders(client.Identity{
+		Subject:  uuid.NewString(),
+		Roles:    []string{"tenant_admin"},
+		UserType: "tenant",
+		TenantID: tenantID,
+	})
+}
+
+// apiError asserts that err is an API error with the given status and code.
+func apiError(t *testing.T, err error, status int, code string) *client.Error {
+	t.Helper()
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want *client.Error", err)
+	}
+	if apiErr.StatusCode != status || apiErr.Code != code {
+		t.Fatalf("error = %d %q, want %d %q", apiErr.StatusCode, apiErr.Code, status, code)
+	}
+	return apiErr
+}
+
+func TestIdentityHeaders(t *testing.T) {
+	p := newPortal(t, nil)
+	tenantID := uuid.NewString()
+	c := p.client(t, client.Options{Auth: client.IdentityHeaders(client.Identity{
+		Subject:  "identity-1",
+		Roles:    []string{"tenant_admin", "auditor"},
+		UserType: "tenant",
+		TenantID: tenantID,
+	})})
+
+	me, err := c.Me(context.Background())
+	if err != nil {
+		t.Fatalf("me: %v", err)
+	}
+	if me.Subject != "identity-1" || me.UserType != "tenant" || me.TenantID != tenantID {
+		t.Fatalf("me = %+v", me)
+	}
+	if strings.Join(me.Roles, ",") != "tenant_admin,auditor" {
+		t.Fatalf("roles = %v", me.Roles)
+	}
+}
+
+// oathkeeper stands in for the proxy in front of the backend: it resolves the session
+// cookie to the identity headers and drops any identity headers sent by the caller.
+func oathkeeper(cookie string, sessions map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del("X-Session-Subject")
+		if session, err := r.Cookie(cookie); err == nil {
+			if subject, ok := sessions[session.Value]; ok {
+				r.Header.Set("X-Session-Subject", subject)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestSessionCookie(t *testing.T) {
+	p := newPortal(t, nil)
+	sessions := map[string]string{"token-1": "identity-1"}
+
+	tests := []struct {
+		name    string
+		cookie  string
+		auth    client.Authenticator
+		subject string
+	}{
+		{"default cookie", client.DefaultSessionCookie, client.SessionCookie("token-1"), "identity-1"},
+		{"named cookie", "portal_session", client.NamedSessionCookie("portal_session", "token-1"), "identity-1"},
+		{"unknown session", client.DefaultSessionCookie, client.SessionCookie("token-2"), ""},
+		{"no credentials", client.DefaultSessionCookie, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := serve(t, oathkeeper(tt.cookie, sessions, p.handler), client.Options{Auth: tt.auth})
+			me, err := c.Me(context.Background())
+			if err != nil {
+				t.Fatalf("me: %v", err)
+			}
+			if me.Subject != tt.subject {
+				t.Fatalf("subject = %q, want %q", me.Subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorFunc(t *testing.T) {
+	p := newPortal(t, nil)
+
+	c := p.client(t, client.Options{Auth: client.AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("X-Session-Subject", "identity-1")
+		req.Header.Set("X-Session-Roles", "platform_admin")
+		return nil
+	})})
+	allowed, err := c.Authorize(context.Background(), client.AuthorizeRequest{Object: "/api/v1/tenants", Action: http.MethodGet})
+	if err != nil || !allowed {
+		t.Fatalf("authorize = %v, %v; want allowed", allowed, err)
+	}
+
+	failure := errors.New("token expired")
+	c = p.client(t, client.Options{Auth: client.AuthenticatorFunc(func(*http.Request) error {
+		return failure
+	})})
+	if _, err := c.Me(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("error = %v, want %v", err, failure)
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	p := newPortal(t, nil)
+	ctx := context.Background()
+
+	t.Run("localized error", func(t *testing.T) {
+		member := client.IdentityHeaders(client.Identity{Subject: uuid.NewString()})
+		_, err := p.client(t, client.Options{Auth: member}).CreateTenant(ctx, client.TenantInput{Code: "acme", Name: "Acme"})
+		apiErr := apiError(t, err, http.StatusForbidden, "forbidden")
+		if apiErr.Message != "权限不足" || apiErr.RequestID == "" {
+			t.Fatalf("error = %+v", apiErr)
+		}
+
+		_, err = p.client(t, client.Options{Auth: member, Language: "en"}).CreateTenant(ctx, client.TenantInput{Code: "acme", Name: "Acme"})
+		if apiErr := apiError(t, err, http.StatusForbidden, "forbidden"); apiErr.Message != "Insufficient permissions" {
+			t.Fatalf("message = %q", apiErr.Message)
+		}
+		if client.ErrorCode(err) != "forbidden" || client.IsNotFound(err) {
+			t.Fatalf("helpers disagree with %v", err)
+		}
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		c := p.client(t, client.Options{Auth: platformAdmin(), Language: "en"})
+		_, err := c.CreateTenant(ctx, client.TenantInput{Code: "not valid", Name: ""})
+		apiErr := apiError(t, err, http.StatusBadRequest, "request_invalid")
+		fields := map[string]string{}
+		for _, field := range apiErr.Fields {
+			fields[field.Field] = field.Code
+		}
+		if fields["code"] != "invalid" || fields["name"] != "invalid" {
+			t.Fatalf("fields = %+v", apiErr.Fields)
+		}
+
+		_, err = c.GetTenant(ctx, "not-a-uuid")
+		apiErr = apiError(t, err, http.StatusBadRequest, "invalid_parameter")
+		if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "tenant_id" || apiErr.Fields[0].Code != "invalid" {
+			t.Fatalf("fields = %+v", apiErr.Fields)
+		}
+	})
+
+	t.Run("upstream failure", func(t *testing.T) {
+		p.ory.set(false, true)
+		defer p.ory.set(false, false)
+
+		c := p.client(t, client.Options{Auth: client.IdentityHeaders(client.Identity{Subject: uuid.NewString()})})
+		_, err := c.Authorize(ctx, client.AuthorizeRequest{Object: "/api/v1/groups", Action: http.MethodGet})
+		apiError(t, err, http.StatusInternalServerError, "authorization_unavailable")
+	})
+
+	t.Run("body without envelope", func(t *testing.T) {
+		_, err := p.client(t, client.Options{}).Authorize(ctx, client.AuthorizeRequest{Object: "/api/v1/groups", Action: http.MethodGet})
+		apiErr := apiError(t, err, http.StatusForbidden, "")
+		if apiErr.Message != "403 Forbidden" {
+			t.Fatalf("message = %q", apiErr.Message)
+		}
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	p := newPortal(t, nil)
+	c := p.client(t, client.Options{Auth: client.IdentityHeaders(client.Identity{Subject: uuid.NewString()})})
+	input := client.AuthorizeRequest{Object: "/api/v1/groups", Action: http.MethodGet}
+
+	if allowed, err := c.Authorize(context.Background(), input); err != nil || !allowed {
+		t.Fatalf("authorize = %v, %v; want allowed", allowed, err)
+	}
+	p.ory.set(true, false)
+	if allowed, err := c.Authorize(context.Background(), input); err != nil || allowed {
+		t.Fatalf("authorize = %v, %v; want denied", allowed, err)
+	}
+}
+
+func TestPaginationStopsAtError(t *testing.T) {
+	p := newPortal(t, nil)
+	c := p.client(t, client.Options{Auth: client.IdentityHeaders(client.Identity{Subject: uuid.NewString()})})
+
+	var yielded int
+	for tenant, err := range c.AllTenants(context.Background(), client.TenantListParams{}) {
+		yielded++
+		if tenant.ID != "" {
+			t.Fatalf("tenant = %+v, want zero value with the error", tenant)
+		}
+		apiError(t, err, http.StatusForbidden, "forbidden")
+	}
+	if yielded != 1 {
+		t.Fatalf("yielded %d times, want 1", yielded)
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error is a failed API call, decoded from the error envelope of the portal. Code is
+// stable and meant for branching; Message is localized for display.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Fields     []FieldError
+	RequestID  string
+	// ETag and Current describe the current revision of the resource when a conditional
+	// write failed with 412.
+	ETag    string
+	Current json.RawMessage
+}
+
+// FieldError points at one invalid or missing request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("portal: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("portal: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is a 409 from the API.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsPreconditionFailed reports whether err is a conditional write that lost against a
+// concurrent change.
+func IsPreconditionFailed(err error) bool {
+	return hasStatus(err, http.StatusPreconditionFailed)
+}
+
+// ErrorCode returns the API error code of err, or an empty string when err did not come
+// from the API.
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		ETag:       resp.Header.Get("ETag"),
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		apiErr.Message = resp.Status
+		return apiErr
+	}
+
+	var payload struct {
+		Error struct {
+			Code      string       `json:"code"`
+			Message   string       `json:"message"`
+			Fields    []FieldError `json:"fields"`
+			RequestID string       `json:"request_id"`
+		} `json:"error"`
+		Current json.RawMessage `json:"current"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Error.Message == "" {
+		apiErr.Message = resp.Status
+		return apiErr
+	}
+	apiErr.Code = payload.Error.Code
+	apiErr.Message = payload.Error.Message
+	apiErr.Fields = payload.Error.Fields
+	apiErr.RequestID = payload.Error.RequestID
+	apiErr.Current = payload.Current
+	return apiErr
+}
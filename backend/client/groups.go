@@ -0,0 +1,359 @@
+package client
+
+import (
+	"context"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Group is a node of the organization chart of a tenant.
+type Group struct {
+	ID          string         `json:"id"`
+	TenantID    string         `json:"tenant_id"`
+	Code        string         `json:"code"`
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	ParentID    *string        `json:"parent_id,omitempty"`
+	SortOrder   int32          `json:"sort_order"`
+	MemberCount int64          `json:"member_count"`
+	Metadata    map[string]any `json:"metadata"`
+	Version     int64          `json:"version"`
+	ETag        string         `json:"etag"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	// Children is only filled by listings that return a tree.
+	Children []Group `json:"children,omitempty"`
+}
+
+// GroupInput creates or updates a group. TenantID is only honoured for platform
+// administrators; everyone else acts in their own tenant. On update, nil fields keep
+// their value and a ParentID pointing at an empty string moves the group to the top level.
+type GroupInput struct {
+	TenantID    string         `json:"tenant_id,omitempty"`
+	Code        string         `json:"code"`
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	ParentID    *string        `json:"parent_id,omitempty"`
+	SortOrder   *int32         `json:"sort_order,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// GroupMove moves a group under ParentID, or to the top level when ParentID is nil.
+type GroupMove struct {
+	ParentID  *string `json:"parent_id"`
+	SortOrder *int32  `json:"sort_order,omitempty"`
+}
+
+// GroupTreeChange places one group in a batch tree update. Version must be the version
+// the change was based on.
+type GroupTreeChange struct {
+	ID        string  `json:"id"`
+	ParentID  *string `json:"parent_id"`
+	SortOrder int32   `json:"sort_order"`
+	Version   int64   `json:"version"`
+}
+
+// GroupTreeUpdate moves and reorders several groups of a tenant atomically.
+type GroupTreeUpdate struct {
+	TenantID string            `json:"tenant_id,omitempty"`
+	Changes  []GroupTreeChange `json:"changes"`
+}
+
+// Group deletion modes.
+const (
+	GroupDeleteRestrict = "restrict"
+	GroupDeleteReassign = "reassign"
+	GroupDeleteCascade  = "cascade"
+)
+
+// GroupDeleteOptions selects what happens to the children and members of a deleted
+// group: restrict refuses non-empty groups, reassign moves them to TargetGroupID and
+// cascade deletes the subtree.
+type GroupDeleteOptions struct {
+	Mode          string
+	TargetGroupID string
+}
+
+func (o GroupDeleteOptions) values() url.Values {
+	query := url.Values{}
+	if o.Mode != "" {
+		query.Set("mode", o.Mode)
+	}
+	if o.TargetGroupID != "" {
+		query.Set("target_group_id", o.TargetGroupID)
+	}
+	return query
+}
+
+// GroupDeletion describes what deleting a group changes.
+type GroupDeletion struct {
+	Mode               string        `json:"mode"`
+	Group              Group         `json:"group"`
+	Target             *Group        `json:"target,omitempty"`
+	DeletedGroups      []Group       `json:"deleted_groups"`
+	MovedGroups        []Group       `json:"moved_groups"`
+	MovedMembers       []GroupMember `json:"moved_members"`
+	RemovedMembers     []GroupMember `json:"removed_members"`
+	ReleasedIdentities []string      `json:"released_identities"`
+}
+
+// GroupSubtree is the tree below a group with its group and distinct member counts.
+type GroupSubtree struct {
+	Items       []Group `json:"items"`
+	GroupCount  int     `json:"group_count"`
+	MemberCount int64   `json:"member_count"`
+}
+
+// GroupMember is the membership of an identity in a group.
+type GroupMember struct {
+	GroupID     string    `json:"group_id"`
+	IdentityID  string    `json:"identity_id"`
+	DisplayName string    `json:"display_name"`
+	Phone       string    `json:"phone"`
+	Title       *string   `json:"title,omitempty"`
+	IsPrimary   bool      `json:"is_primary"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GroupMemberInput adds an identity to a group, by IdentityID or by Phone. When the phone
+// is not registered yet a new identity is created, which needs DisplayName and Password.
+type GroupMemberInput struct {
+	IdentityID  string  `json:"identity_id,omitempty"`
+	DisplayName string  `json:"display_name,omitempty"`
+	Phone       string  `json:"phone,omitempty"`
+	Password    string  `json:"password,omitempty"`
+	Title       *string `json:"title,omitempty"`
+	IsPrimary   *bool   `json:"is_primary,omitempty"`
+}
+
+// GroupMemberUpdate changes a membership. TargetGroupID transfers it to another group.
+type GroupMemberUpdate struct {
+	TargetGroupID string  `json:"target_group_id,omitempty"`
+	DisplayName   *string `json:"display_name,omitempty"`
+	Title         *string `json:"title,omitempty"`
+	IsPrimary     *bool   `json:"is_primary,omitempty"`
+}
+
+// GroupMemberListParams filters the direct members of a group.
+type GroupMemberListParams struct {
+	Search string
+	PageParams
+}
+
+// SubtreeMemberListParams selects a page of the members of a group and its descendants.
+type SubtreeMemberListParams struct {
+	Search   string
+	Page     int
+	PageSize int
+}
+
+// MemberGroup is a group an identity belongs to.
+type MemberGroup struct {
+	GroupID   string  `json:"group_id"`
+	GroupCode string  `json:"group_code"`
+	GroupName string  `json:"group_name"`
+	Title     *string `json:"title,omitempty"`
+	IsPrimary bool    `json:"is_primary"`
+}
+
+// OrgChartExportParams selects what ExportOrgChart writes. Format is csv (default) or xlsx.
+type OrgChartExportParams struct {
+	TenantID     string
+	Format       string
+	RootGroupID  string
+	IncludeRoles bool
+}
+
+// ListGroups returns the group tree of a tenant. tenantID is only honoured for platform
+// administrators and may be empty.
+func (c *Client) ListGroups(ctx context.Context, tenantID string, opts ...RequestOption) ([]Group, error) {
+	var result struct {
+		Items []Group `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/groups", tenantQuery(tenantID), nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// CreateGroup creates a group.
+func (c *Client) CreateGroup(ctx context.Context, input GroupInput, opts ...RequestOption) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodPost, "/groups", nil, input, &group, opts); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// UpdateGroup updates a group.
+func (c *Client) UpdateGroup(ctx context.Context, id string, input GroupInput, opts ...RequestOption) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodPut, "/groups/"+escape(id), nil, input, &group, opts); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// DeleteGroup deletes a group.
+func (c *Client) DeleteGroup(ctx context.Context, id string, options GroupDeleteOptions, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodDelete, "/groups/"+escape(id), options.values(), nil, nil, opts)
+}
+
+// PreviewGroupDeletion reports what DeleteGroup with the same options would change.
+func (c *Client) PreviewGroupDeletion(ctx context.Context, id string, options GroupDeleteOptions, opts ...RequestOption) (*GroupDeletion, error) {
+	var deletion GroupDeletion
+	if err := c.do(ctx, http.MethodGet, "/groups/"+escape(id)+"/deletion-preview", options.values(), nil, &deletion, opts); err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// MoveGroup moves a group under another parent.
+func (c *Client) MoveGroup(ctx context.Context, id string, move GroupMove, opts ...RequestOption) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodPost, "/groups/"+escape(id)+"/move", nil, move, &group, opts); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// UpdateGroupTree applies several moves at once and returns the changed groups.
+func (c *Client) UpdateGroupTree(ctx context.Context, update GroupTreeUpdate, opts ...RequestOption) ([]Group, error) {
+	var result struct {
+		Items []Group `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodPatch, "/groups/tree", nil, update, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// GroupSubtree returns the tree below a group, limited to maxDepth levels when it is not
+// negative.
+func (c *Client) GroupSubtree(ctx context.Context, id string, maxDepth int, opts ...RequestOption) (*GroupSubtree, error) {
+	query := url.Values{}
+	if maxDepth >= 0 {
+		query.Set("max_depth", strconv.Itoa(maxDepth))
+	}
+	var subtree GroupSubtree
+	if err := c.do(ctx, http.MethodGet, "/groups/"+escape(id)+"/subtree", query, nil, &subtree, opts); err != nil {
+		return nil, err
+	}
+	return &subtree, nil
+}
+
+// GroupAncestors returns the path from the top-level group down to the group.
+func (c *Client) GroupAncestors(ctx context.Context, id string, opts ...RequestOption) ([]Group, error) {
+	var result struct {
+		Items []Group `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/groups/"+escape(id)+"/ancestors", nil, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// ExportOrgChart writes the organization chart to w and returns its content type.
+func (c *Client) ExportOrgChart(ctx context.Context, params OrgChartExportParams, w io.Writer, opts ...RequestOption) (string, error) {
+	query := tenantQuery(params.TenantID)
+	if params.Format != "" {
+		query.Set("format", params.Format)
+	}
+	if params.RootGroupID != "" {
+		query.Set("root", params.RootGroupID)
+	}
+	if params.IncludeRoles {
+		query.Set("include_roles", "true")
+	}
+	resp, err := c.send(ctx, http.MethodGet, "/groups/export", query, nil, opts)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("Content-Type"), nil
+}
+
+// ListGroupMembers returns one page of the direct members of a group.
+func (c *Client) ListGroupMembers(ctx context.Context, groupID string, params GroupMemberListParams, opts ...RequestOption) (*Page[GroupMember], error) {
+	query := params.values()
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	var page Page[GroupMember]
+	if err := c.do(ctx, http.MethodGet, "/groups/"+escape(groupID)+"/members", query, nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AllGroupMembers iterates over every direct member of a group matching params.
+func (c *Client) AllGroupMembers(ctx context.Context, groupID string, params GroupMemberListParams, opts ...RequestOption) iter.Seq2[GroupMember, error] {
+	return paginate(ctx, params.PageParams, func(ctx context.Context, page PageParams) (*Page[GroupMember], error) {
+		params.PageParams = page
+		return c.ListGroupMembers(ctx, groupID, params, opts...)
+	})
+}
+
+// ListSubtreeMembers returns one page of the members of a group and all groups below it.
+func (c *Client) ListSubtreeMembers(ctx context.Context, groupID string, params SubtreeMemberListParams, opts ...RequestOption) (*OffsetPage[GroupMember], error) {
+	query := offsetQuery(params.Page, params.PageSize)
+	query.Set("recursive", "true")
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	var page OffsetPage[GroupMember]
+	if err := c.do(ctx, http.MethodGet, "/groups/"+escape(groupID)+"/members", query, nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AddGroupMember adds an identity to a group.
+func (c *Client) AddGroupMember(ctx context.Context, groupID string, input GroupMemberInput, opts ...RequestOption) (*GroupMember, error) {
+	var member GroupMember
+	if err := c.do(ctx, http.MethodPost, "/groups/"+escape(groupID)+"/members", nil, input, &member, opts); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// UpdateGroupMember changes or transfers a membership.
+func (c *Client) UpdateGroupMember(ctx context.Context, groupID, identityID string, update GroupMemberUpdate, opts ...RequestOption) (*GroupMember, error) {
+	var member GroupMember
+	if err := c.do(ctx, http.MethodPatch, "/groups/"+escape(groupID)+"/members/"+escape(identityID), nil, update, &member, opts); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveGroupMember removes an identity from a group.
+func (c *Client) RemoveGroupMember(ctx context.Context, groupID, identityID string, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodDelete, "/groups/"+escape(groupID)+"/members/"+escape(identityID), nil, nil, nil, opts)
+}
+
+// ListMemberGroups returns the groups an identity belongs to in a tenant.
+func (c *Client) ListMemberGroups(ctx context.Context, identityID, tenantID string, opts ...RequestOption) ([]MemberGroup, error) {
+	var result struct {
+		Items []MemberGroup `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/members/"+escape(identityID)+"/groups", tenantQuery(tenantID), nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+func tenantQuery(tenantID string) url.Values {
+	query := url.Values{}
+	if tenantID != "" {
+		query.Set("tenant_id", tenantID)
+	}
+	return query
+}
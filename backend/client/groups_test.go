@@ -0,0 +1,128 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+)
+
+func TestGroupCRUD(t *testing.T) {
+	p := newPortal(t, testDatabase(t))
+	tenant := createTenant(t, p.client(t, client.Options{Auth: platformAdmin()}), client.TenantInput{Code: uniqueCode("g"), Name: "Groups"})
+	c := p.client(t, client.Options{Auth: tenantAdmin(tenant.ID)})
+	ctx := context.Background()
+
+	sales, err := c.CreateGroup(ctx, client.GroupInput{Code: "sales", Name: "Sales"})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	support, err := c.CreateGroup(ctx, client.GroupInput{Code: "support", Name: "Support"})
+	if err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	emea, err := c.CreateGroup(ctx, client.GroupInput{Code: "emea", Name: "EMEA", ParentID: &sales.ID})
+	if err != nil {
+		t.Fatalf("create child group: %v", err)
+	}
+	if emea.TenantID != tenant.ID || emea.ParentID == nil || *emea.ParentID != sales.ID || emea.ETag == "" {
+		t.Fatalf("created = %+v", emea)
+	}
+	assertParentTuple(t, p.ory, tenant.ID, emea.ID, sales.ID)
+
+	_, err = c.CreateGroup(ctx, client.GroupInput{Code: "emea", Name: "Duplicate"})
+	apiError(t, err, http.StatusBadRequest, "group_code_exists")
+
+	groups, err := c.ListGroups(ctx, "")
+	if err != nil {
+		t.Fatalf("list groups: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("listed %d top-level groups, want 2", len(groups))
+	}
+	for _, group := range groups {
+		if group.ID == sales.ID && (len(group.Children) != 1 || group.Children[0].ID != emea.ID) {
+			t.Fatalf("sales children = %+v", group.Children)
+		}
+	}
+
+	name := "Europe"
+	updated, err := c.UpdateGroup(ctx, emea.ID, client.GroupInput{Code: "emea", Name: name}, client.WithIfMatch(emea.ETag))
+	if err != nil {
+		t.Fatalf("update group: %v", err)
+	}
+	if updated.Name != name || updated.Version <= emea.Version {
+		t.Fatalf("updated = %+v", updated)
+	}
+	_, err = c.UpdateGroup(ctx, emea.ID, client.GroupInput{Code: "emea", Name: "Stale"}, client.WithIfMatch(emea.ETag))
+	if !client.IsPreconditionFailed(err) {
+		t.Fatalf("stale update error = %v, want 412", err)
+	}
+
+	moved, err := c.MoveGroup(ctx, emea.ID, client.GroupMove{ParentID: &support.ID})
+	if err != nil {
+		t.Fatalf("move group: %v", err)
+	}
+	if moved.ParentID == nil || *moved.ParentID != support.ID {
+		t.Fatalf("moved = %+v", moved)
+	}
+	assertParentTuple(t, p.ory, tenant.ID, emea.ID, support.ID)
+
+	ancestors, err := c.GroupAncestors(ctx, emea.ID)
+	if err != nil {
+		t.Fatalf("group ancestors: %v", err)
+	}
+	if !containsGroup(ancestors, support.ID) || containsGroup(ancestors, sales.ID) {
+		t.Fatalf("ancestors = %+v", ancestors)
+	}
+
+	err = c.DeleteGroup(ctx, support.ID, client.GroupDeleteOptions{})
+	apiError(t, err, http.StatusBadRequest, "group_has_children")
+
+	options := client.GroupDeleteOptions{Mode: client.GroupDeleteReassign, TargetGroupID: sales.ID}
+	preview, err := c.PreviewGroupDeletion(ctx, support.ID, options)
+	if err != nil {
+		t.Fatalf("preview group deletion: %v", err)
+	}
+	if !containsGroup(preview.MovedGroups, emea.ID) || preview.Target == nil || preview.Target.ID != sales.ID {
+		t.Fatalf("preview = %+v", preview)
+	}
+	if err := c.DeleteGroup(ctx, support.ID, options); err != nil {
+		t.Fatalf("delete group: %v", err)
+	}
+	assertParentTuple(t, p.ory, tenant.ID, emea.ID, sales.ID)
+
+	if err := c.DeleteGroup(ctx, sales.ID, client.GroupDeleteOptions{Mode: client.GroupDeleteCascade}); err != nil {
+		t.Fatalf("cascade delete group: %v", err)
+	}
+	_, err = c.GroupAncestors(ctx, emea.ID)
+	if !client.IsNotFound(err) || client.ErrorCode(err) != "group_not_found" {
+		t.Fatalf("ancestors of deleted group error = %v, want group_not_found", err)
+	}
+}
+
+// assertParentTuple checks that the last parent link Keto received for groupID points
+// at parentID.
+func assertParentTuple(t *testing.T, ory *oryStub, tenantID, groupID, parentID string) {
+	t.Helper()
+	object := tenantID + ":group:" + groupID
+	var last string
+	for _, tuple := range ory.written("parents") {
+		if tuple.Object == object && tuple.SubjectSet != nil {
+			last = tuple.SubjectSet.Object
+		}
+	}
+	if want := tenantID + ":group:" + parentID; last != want {
+		t.Fatalf("keto parent of %s = %q, want %q", groupID, last, want)
+	}
+}
+
+func containsGroup(groups []client.Group, id string) bool {
+	for _, group := range groups {
+		if group.ID == id {
+			return true
+		}
+	}
+	return false
+}
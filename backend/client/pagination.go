@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// Page is one page of a cursor paginated listing.
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// NextCursor fetches the following page; it is empty on the last page.
+	NextCursor string `json:"next_cursor"`
+	// Total counts all matches. It is only set when the listing asked for it.
+	Total    *int64 `json:"total"`
+	PageSize int    `json:"page_size"`
+}
+
+// OffsetPage is one page of a listing that only supports page numbers.
+type OffsetPage[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+}
+
+// PageParams selects a page of a cursor paginated listing. The zero value asks for the
+// first page with the server's default size.
+type PageParams struct {
+	Cursor       string
+	PageSize     int
+	IncludeTotal bool
+}
+
+// values encodes the page parameters. The cursor is always sent because its presence
+// selects cursor pagination.
+func (p PageParams) values() url.Values {
+	query := url.Values{}
+	query.Set("cursor", p.Cursor)
+	if p.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+	if p.IncludeTotal {
+		query.Set("include_total", "true")
+	}
+	return query
+}
+
+// offsetQuery encodes page number parameters, leaving zero values to the server defaults.
+func offsetQuery(page, pageSize int) url.Values {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	if pageSize > 0 {
+		query.Set("page_size", strconv.Itoa(pageSize))
+	}
+	return query
+}
+
+// paginate walks a cursor paginated listing page by page, starting at the cursor of
+// first. Iteration stops at the first error, which is yielded with a zero item.
+func paginate[T any](ctx context.Context, first PageParams, fetch func(context.Context, PageParams) (*Page[T], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		params := first
+		for {
+			page, err := fetch(ctx, params)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			params.Cursor = page.NextCursor
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// Role scopes.
+const (
+	RoleScopeGlobal = "global"
+	RoleScopeTenant = "tenant"
+)
+
+// Role bundles permissions that can be assigned to identities.
+type Role struct {
+	ID            string         `json:"id"`
+	TenantID      *string        `json:"tenantId,omitempty"`
+	Scope         string         `json:"scope"`
+	Code          string         `json:"code"`
+	Name          string         `json:"name"`
+	Description   *string        `json:"description,omitempty"`
+	Metadata      map[string]any `json:"metadata"`
+	Permissions   []string       `json:"permissions,omitempty"`
+	AssignedCount int64          `json:"assignedCount"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	Version       int32          `json:"version"`
+	ETag          string         `json:"etag"`
+}
+
+// RoleInput creates or updates a role. Scope is required on create. Permissions replaces
+// the granted permission codes.
+type RoleInput struct {
+	TenantID    *string        `json:"tenant_id,omitempty"`
+	Scope       string         `json:"scope,omitempty"`
+	Code        string         `json:"code,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	Description *string        `json:"description,omitempty"`
+	Permissions []string       `json:"permissions,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// RoleListParams filters a role listing.
+type RoleListParams struct {
+	// Scope is RoleScopeGlobal or RoleScopeTenant; empty lists both.
+	Scope  string
+	Search string
+	// TenantID is only honoured for platform administrators.
+	TenantID string
+	// IncludePermissions fills Role.Permissions.
+	IncludePermissions bool
+	PageParams
+}
+
+// RoleMember is the assignment of a role to an identity.
+type RoleMember struct {
+	IdentityID string    `json:"identityId"`
+	TenantID   *string   `json:"tenantId,omitempty"`
+	AssignedAt time.Time `json:"assignedAt"`
+}
+
+// ListRoles returns one page of roles.
+func (c *Client) ListRoles(ctx context.Context, params RoleListParams, opts ...RequestOption) (*Page[Role], error) {
+	query := params.values()
+	if params.Scope != "" {
+		query.Set("scope", params.Scope)
+	}
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	if params.TenantID != "" {
+		query.Set("tenant_id", params.TenantID)
+	}
+	if params.IncludePermissions {
+		query.Set("include", "permissions")
+	}
+	var page Page[Role]
+	if err := c.do(ctx, http.MethodGet, "/roles", query, nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AllRoles iterates over every role matching params.
+func (c *Client) AllRoles(ctx context.Context, params RoleListParams, opts ...RequestOption) iter.Seq2[Role, error] {
+	return paginate(ctx, params.PageParams, func(ctx context.Context, page PageParams) (*Page[Role], error) {
+		params.PageParams = page
+		return c.ListRoles(ctx, params, opts...)
+	})
+}
+
+// CreateRole creates a role.
+func (c *Client) CreateRole(ctx context.Context, input RoleInput, opts ...RequestOption) (*Role, error) {
+	var role Role
+	if err := c.do(ctx, http.MethodPost, "/roles", nil, input, &role, opts); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// UpdateRole updates a role.
+func (c *Client) UpdateRole(ctx context.Context, id string, input RoleInput, opts ...RequestOption) (*Role, error) {
+	var role Role
+	if err := c.do(ctx, http.MethodPut, "/roles/"+escape(id), nil, input, &role, opts); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// DeleteRole deletes a role and its assignments.
+func (c *Client) DeleteRole(ctx context.Context, id string, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodDelete, "/roles/"+escape(id), nil, nil, nil, opts)
+}
+
+// ListRoleMembers returns one page of the identities assigned a role.
+func (c *Client) ListRoleMembers(ctx context.Context, roleID string, params PageParams, opts ...RequestOption) (*Page[RoleMember], error) {
+	var page Page[RoleMember]
+	if err := c.do(ctx, http.MethodGet, "/roles/"+escape(roleID)+"/members", params.values(), nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AllRoleMembers iterates over every identity assigned a role.
+func (c *Client) AllRoleMembers(ctx context.Context, roleID string, params PageParams, opts ...RequestOption) iter.Seq2[RoleMember, error] {
+	return paginate(ctx, params, func(ctx context.Context, page PageParams) (*Page[RoleMember], error) {
+		return c.ListRoleMembers(ctx, roleID, page, opts...)
+	})
+}
+
+// AssignRole assigns a role to identities and returns how many were assigned.
+func (c *Client) AssignRole(ctx context.Context, roleID string, identityIDs []string, opts ...RequestOption) (int, error) {
+	payload := struct {
+		Identities []string `json:"identities"`
+	}{Identities: identityIDs}
+	var result struct {
+		Assigned int `json:"assigned"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/roles/"+escape(roleID)+"/members", nil, payload, &result, opts); err != nil {
+		return 0, err
+	}
+	return result.Assigned, nil
+}
+
+// UnassignRole removes a role from an identity.
+func (c *Client) UnassignRole(ctx context.Context, roleID, identityID string, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodDelete, "/roles/"+escape(roleID)+"/members/"+escape(identityID), nil, nil, nil, opts)
+}
@@ -0,0 +1,94 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+)
+
+func TestRoleCRUD(t *testing.T) {
+	p := newPortal(t, testDatabase(t))
+	tenant := createTenant(t, p.client(t, client.Options{Auth: platformAdmin()}), client.TenantInput{Code: uniqueCode("r"), Name: "Roles"})
+	c := p.client(t, client.Options{Auth: tenantAdmin(tenant.ID)})
+	ctx := context.Background()
+
+	created, err := c.CreateRole(ctx, client.RoleInput{Scope: client.RoleScopeTenant, Code: "auditor", Name: "Auditor"})
+	if err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	if created.TenantID == nil || *created.TenantID != tenant.ID || created.Scope != client.RoleScopeTenant || created.ETag == "" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	_, err = c.CreateRole(ctx, client.RoleInput{Scope: client.RoleScopeTenant, Code: "auditor", Name: "Again"})
+	apiError(t, err, http.StatusBadRequest, "role_code_exists")
+
+	description := "Reads the audit log"
+	updated, err := c.UpdateRole(ctx, created.ID, client.RoleInput{Name: "Auditors", Description: &description}, client.WithIfMatch(created.ETag))
+	if err != nil {
+		t.Fatalf("update role: %v", err)
+	}
+	if updated.Name != "Auditors" || updated.Description == nil || *updated.Description != description || updated.Version <= created.Version {
+		t.Fatalf("updated = %+v", updated)
+	}
+	_, err = c.UpdateRole(ctx, created.ID, client.RoleInput{Name: "Stale"}, client.WithIfMatch(created.ETag))
+	if !client.IsPreconditionFailed(err) {
+		t.Fatalf("stale update error = %v, want 412", err)
+	}
+
+	var found bool
+	for role, err := range c.AllRoles(ctx, client.RoleListParams{Scope: client.RoleScopeTenant, PageParams: client.PageParams{PageSize: 1}}) {
+		if err != nil {
+			t.Fatalf("iterate roles: %v", err)
+		}
+		found = found || role.ID == created.ID
+	}
+	if !found {
+		t.Fatalf("role %s was not iterated", created.ID)
+	}
+
+	identities := make([]string, 5)
+	for i := range identities {
+		identities[i] = uuid.NewString()
+	}
+	assigned, err := c.AssignRole(ctx, created.ID, identities)
+	if err != nil || assigned != len(identities) {
+		t.Fatalf("assign role = %d, %v; want %d", assigned, err, len(identities))
+	}
+	if got := len(p.ory.written("members")); got != len(identities) {
+		t.Fatalf("keto received %d role tuples, want %d", got, len(identities))
+	}
+
+	members := map[string]bool{}
+	for member, err := range c.AllRoleMembers(ctx, created.ID, client.PageParams{PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("iterate role members: %v", err)
+		}
+		members[member.IdentityID] = true
+	}
+	for _, id := range identities {
+		if !members[id] {
+			t.Fatalf("member %s was not iterated; got %v", id, members)
+		}
+	}
+
+	if err := c.UnassignRole(ctx, created.ID, identities[0]); err != nil {
+		t.Fatalf("unassign role: %v", err)
+	}
+	page, err := c.ListRoleMembers(ctx, created.ID, client.PageParams{IncludeTotal: true})
+	if err != nil || page.Total == nil || *page.Total != int64(len(identities)-1) {
+		t.Fatalf("list role members = %+v, %v", page, err)
+	}
+
+	if err := c.DeleteRole(ctx, created.ID); err != nil {
+		t.Fatalf("delete role: %v", err)
+	}
+	err = c.DeleteRole(ctx, created.ID)
+	if !client.IsNotFound(err) || client.ErrorCode(err) != "role_not_found" {
+		t.Fatalf("delete deleted role error = %v, want role_not_found", err)
+	}
+}
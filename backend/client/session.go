@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Me is the caller as seen by the backend.
+type Me struct {
+	Subject       string         `json:"subject"`
+	UserType      string         `json:"user_type"`
+	TenantID      string         `json:"tenant_id"`
+	Roles         []string       `json:"roles"`
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
+}
+
+// Impersonation is the impersonation session a request runs in.
+type Impersonation struct {
+	SessionID string `json:"session_id"`
+	TenantID  string `json:"tenant_id"`
+}
+
+// AuthorizeRequest asks whether the caller, or the given subject, may perform Action on
+// Object, usually an API path and HTTP method.
+type AuthorizeRequest struct {
+	Namespace string `json:"namespace,omitempty"`
+	Object    string `json:"object"`
+	Action    string `json:"action"`
+	Subject   string `json:"subject,omitempty"`
+	UserType  string `json:"user_type,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	// Roles is a comma-separated list of role names.
+	Roles string `json:"roles,omitempty"`
+}
+
+// Permission is an entry of the permission catalog that roles grant.
+type Permission struct {
+	Code        string `json:"code"`
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+}
+
+// Me describes the calling identity.
+func (c *Client) Me(ctx context.Context, opts ...RequestOption) (*Me, error) {
+	var me Me
+	if err := c.do(ctx, http.MethodGet, "/me", nil, nil, &me, opts); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// Authorize reports whether the request is allowed.
+func (c *Client) Authorize(ctx context.Context, input AuthorizeRequest, opts ...RequestOption) (bool, error) {
+	var result struct {
+		Allowed bool `json:"allowed"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/authorize", nil, input, &result, opts); err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// ListPermissions returns the permission catalog.
+func (c *Client) ListPermissions(ctx context.Context, opts ...RequestOption) ([]Permission, error) {
+	var result struct {
+		Items []Permission `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/permissions", nil, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
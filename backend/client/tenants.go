@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// Tenant statuses.
+const (
+	TenantActive   = "active"
+	TenantInactive = "inactive"
+)
+
+// Tenant is an organization using the portal.
+type Tenant struct {
+	ID           string         `json:"id"`
+	Code         string         `json:"code"`
+	Name         string         `json:"name"`
+	Status       string         `json:"status"`
+	ContactName  *string        `json:"contactName,omitempty"`
+	ContactPhone *string        `json:"contactPhone,omitempty"`
+	Metadata     map[string]any `json:"metadata"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	Version      int64          `json:"version"`
+	// ETag identifies this revision for WithIfMatch.
+	ETag string `json:"etag"`
+}
+
+// TenantInput creates a tenant or replaces its fields. Code is 1-10 letters or digits.
+type TenantInput struct {
+	Code         string         `json:"code"`
+	Name         string         `json:"name"`
+	Status       string         `json:"status,omitempty"`
+	ContactName  *string        `json:"contactName,omitempty"`
+	ContactPhone *string        `json:"contactPhone,omitempty"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+// TenantListParams filters a tenant listing.
+type TenantListParams struct {
+	Search string
+	// Status is TenantActive or TenantInactive; empty lists both.
+	Status string
+	PageParams
+}
+
+// ListTenants returns one page of tenants. It requires an administrator.
+func (c *Client) ListTenants(ctx context.Context, params TenantListParams, opts ...RequestOption) (*Page[Tenant], error) {
+	query := params.values()
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	if params.Status != "" {
+		query.Set("status", params.Status)
+	}
+	var page Page[Tenant]
+	if err := c.do(ctx, http.MethodGet, "/tenants", query, nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AllTenants iterates over every tenant matching params, fetching pages as needed.
+func (c *Client) AllTenants(ctx context.Context, params TenantListParams, opts ...RequestOption) iter.Seq2[Tenant, error] {
+	return paginate(ctx, params.PageParams, func(ctx context.Context, page PageParams) (*Page[Tenant], error) {
+		params.PageParams = page
+		return c.ListTenants(ctx, params, opts...)
+	})
+}
+
+// GetTenant loads a tenant.
+func (c *Client) GetTenant(ctx context.Context, id string, opts ...RequestOption) (*Tenant, error) {
+	var tenant Tenant
+	if err := c.do(ctx, http.MethodGet, "/tenants/"+escape(id), nil, nil, &tenant, opts); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// CreateTenant creates a tenant.
+func (c *Client) CreateTenant(ctx context.Context, input TenantInput, opts ...RequestOption) (*Tenant, error) {
+	var tenant Tenant
+	if err := c.do(ctx, http.MethodPost, "/tenants", nil, input, &tenant, opts); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// UpdateTenant replaces the fields of a tenant.
+func (c *Client) UpdateTenant(ctx context.Context, id string, input TenantInput, opts ...RequestOption) (*Tenant, error) {
+	var tenant Tenant
+	if err := c.do(ctx, http.MethodPut, "/tenants/"+escape(id), nil, input, &tenant, opts); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// DeleteTenant deletes a tenant.
+func (c *Client) DeleteTenant(ctx context.Context, id string, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodDelete, "/tenants/"+escape(id), nil, nil, nil, opts)
+}
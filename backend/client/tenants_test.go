@@ -0,0 +1,161 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+)
+
+// uniqueCode returns a tenant code that does not collide with earlier test runs.
+func uniqueCode(prefix string) string {
+	return prefix + strings.ReplaceAll(uuid.NewString(), "-", "")[:10-len(prefix)]
+}
+
+// createTenant creates a tenant for a test and deletes it afterwards.
+func createTenant(t *testing.T, c *client.Client, input client.TenantInput) *client.Tenant {
+	t.Helper()
+	tenant, err := c.CreateTenant(context.Background(), input)
+	if err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.DeleteTenant(context.Background(), tenant.ID)
+	})
+	return tenant
+}
+
+func TestTenantCRUD(t *testing.T) {
+	p := newPortal(t, testDatabase(t))
+	c := p.client(t, client.Options{Auth: platformAdmin(), Language: "en"})
+	ctx := context.Background()
+
+	contact := "Ada"
+	created := createTenant(t, c, client.TenantInput{
+		Code:        uniqueCode("t"),
+		Name:        "Acme",
+		ContactName: &contact,
+		Metadata:    map[string]any{"region": "eu"},
+	})
+	if created.ID == "" || created.Status != client.TenantActive || created.ETag == "" {
+		t.Fatalf("created = %+v", created)
+	}
+	if created.ContactName == nil || *created.ContactName != contact || created.Metadata["region"] != "eu" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	got, err := c.GetTenant(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("get tenant: %v", err)
+	}
+	if got.Code != created.Code || got.Version != created.Version {
+		t.Fatalf("got = %+v, want %+v", got, created)
+	}
+
+	updated, err := c.UpdateTenant(ctx, created.ID, client.TenantInput{
+		Code:   created.Code,
+		Name:   "Acme Corp",
+		Status: client.TenantInactive,
+	}, client.WithIfMatch(created.ETag))
+	if err != nil {
+		t.Fatalf("update tenant: %v", err)
+	}
+	if updated.Name != "Acme Corp" || updated.Status != client.TenantInactive || updated.Version <= created.Version {
+		t.Fatalf("updated = %+v", updated)
+	}
+
+	_, err = c.UpdateTenant(ctx, created.ID, client.TenantInput{Code: created.Code, Name: "Stale"}, client.WithIfMatch(created.ETag))
+	if !client.IsPreconditionFailed(err) {
+		t.Fatalf("stale update error = %v, want 412", err)
+	}
+	apiErr := apiError(t, err, http.StatusPreconditionFailed, "precondition_failed")
+	var current client.Tenant
+	if err := json.Unmarshal(apiErr.Current, &current); err != nil {
+		t.Fatalf("decode current: %v", err)
+	}
+	if apiErr.ETag != updated.ETag || current.Name != "Acme Corp" {
+		t.Fatalf("412 carries %q %+v, want %q", apiErr.ETag, current, updated.ETag)
+	}
+
+	_, err = c.CreateTenant(ctx, client.TenantInput{Code: created.Code, Name: "Duplicate"})
+	apiError(t, err, http.StatusBadRequest, "tenant_code_exists")
+
+	if err := c.DeleteTenant(ctx, created.ID, client.WithIfMatch(updated.ETag)); err != nil {
+		t.Fatalf("delete tenant: %v", err)
+	}
+	_, err = c.GetTenant(ctx, created.ID)
+	if !client.IsNotFound(err) || client.ErrorCode(err) != "tenant_not_found" {
+		t.Fatalf("get deleted tenant error = %v, want tenant_not_found", err)
+	}
+}
+
+func TestAllTenants(t *testing.T) {
+	p := newPortal(t, testDatabase(t))
+
+	var requests atomic.Int32
+	counted := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requests.Add(1)
+		}
+		p.handler.ServeHTTP(w, r)
+	})
+	c := serve(t, counted, client.Options{Auth: platformAdmin()})
+	ctx := context.Background()
+
+	// The search term is unique to this run, so only the tenants below match.
+	search := uniqueCode("s")
+	want := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		tenant := createTenant(t, c, client.TenantInput{Code: uniqueCode("p"), Name: search + " tenant"})
+		want[tenant.ID] = true
+	}
+
+	page, err := c.ListTenants(ctx, client.TenantListParams{
+		Search:     search,
+		PageParams: client.PageParams{PageSize: 2, IncludeTotal: true},
+	})
+	if err != nil {
+		t.Fatalf("list tenants: %v", err)
+	}
+	if len(page.Items) != 2 || page.NextCursor == "" || page.Total == nil || *page.Total != 5 {
+		t.Fatalf("first page = %d items, cursor %q, total %v", len(page.Items), page.NextCursor, page.Total)
+	}
+
+	requests.Store(0)
+	seen := map[string]bool{}
+	for tenant, err := range c.AllTenants(ctx, client.TenantListParams{Search: search, PageParams: client.PageParams{PageSize: 2}}) {
+		if err != nil {
+			t.Fatalf("iterate tenants: %v", err)
+		}
+		if seen[tenant.ID] {
+			t.Fatalf("tenant %s yielded twice", tenant.ID)
+		}
+		seen[tenant.ID] = true
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("iterated %d tenants, want %d", len(seen), len(want))
+	}
+	for id := range want {
+		if !seen[id] {
+			t.Fatalf("tenant %s was not iterated", id)
+		}
+	}
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("fetched %d pages, want 3", got)
+	}
+
+	// Breaking out of the loop stops fetching further pages.
+	requests.Store(0)
+	for range c.AllTenants(ctx, client.TenantListParams{Search: search, PageParams: client.PageParams{PageSize: 2}}) {
+		break
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("fetched %d pages after break, want 1", got)
+	}
+}
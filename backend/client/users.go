@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// User states.
+const (
+	UserActive   = "active"
+	UserInactive = "inactive"
+)
+
+// User is an identity of a tenant with its groups and roles.
+type User struct {
+	ID        string        `json:"id"`
+	TenantID  string        `json:"tenant_id"`
+	Phone     string        `json:"phone"`
+	Nickname  string        `json:"nickname"`
+	Username  string        `json:"username,omitempty"`
+	UserType  string        `json:"user_type"`
+	State     string        `json:"state"`
+	Groups    []MemberGroup `json:"groups"`
+	Roles     []UserRole    `json:"roles"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// UserRole is a role assigned to a user.
+type UserRole struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// UserUpdate changes the traits of a user; nil fields are left unchanged.
+type UserUpdate struct {
+	Nickname *string `json:"nickname,omitempty"`
+	Phone    *string `json:"phone,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// UserListParams filters a user listing. TenantID is only honoured for platform
+// administrators.
+type UserListParams struct {
+	TenantID string
+	// State is UserActive or UserInactive; empty lists both.
+	State    string
+	Search   string
+	Page     int
+	PageSize int
+}
+
+// DirectoryEntry is a tenant member found by directory search.
+type DirectoryEntry struct {
+	IdentityID  string                `json:"identity_id"`
+	DisplayName string                `json:"display_name"`
+	Phone       string                `json:"phone"`
+	Title       *string               `json:"title,omitempty"`
+	Departments []DirectoryDepartment `json:"departments"`
+	Roles       []UserRole            `json:"roles"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// DirectoryDepartment is a group of a directory entry with its path from the top level.
+type DirectoryDepartment struct {
+	GroupID   string          `json:"group_id"`
+	GroupName string          `json:"group_name"`
+	Path      []GroupPathNode `json:"path"`
+	PathName  string          `json:"path_name"`
+	Title     *string         `json:"title,omitempty"`
+	IsPrimary bool            `json:"is_primary"`
+}
+
+// GroupPathNode is one group on the path to a department.
+type GroupPathNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// DirectorySearchParams selects a page of directory search results.
+type DirectorySearchParams struct {
+	TenantID string
+	Search   string
+	Page     int
+	PageSize int
+}
+
+// ListUsers returns one page of the users of a tenant.
+func (c *Client) ListUsers(ctx context.Context, params UserListParams, opts ...RequestOption) (*OffsetPage[User], error) {
+	query := offsetQuery(params.Page, params.PageSize)
+	if params.TenantID != "" {
+		query.Set("tenant_id", params.TenantID)
+	}
+	if params.State != "" {
+		query.Set("state", params.State)
+	}
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	var page OffsetPage[User]
+	if err := c.do(ctx, http.MethodGet, "/users", query, nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetUser loads a user.
+func (c *Client) GetUser(ctx context.Context, id string, opts ...RequestOption) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/users/"+escape(id), nil, nil, &user, opts); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the traits of a user.
+func (c *Client) UpdateUser(ctx context.Context, id string, input UserUpdate, opts ...RequestOption) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPatch, "/users/"+escape(id), nil, input, &user, opts); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DisableUser deactivates a user and revokes their sessions.
+func (c *Client) DisableUser(ctx context.Context, id string, opts ...RequestOption) (*User, error) {
+	return c.setUserState(ctx, id, "disable", opts)
+}
+
+// EnableUser reactivates a disabled user.
+func (c *Client) EnableUser(ctx context.Context, id string, opts ...RequestOption) (*User, error) {
+	return c.setUserState(ctx, id, "enable", opts)
+}
+
+func (c *Client) setUserState(ctx context.Context, id, action string, opts []RequestOption) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPost, "/users/"+escape(id)+"/"+action, nil, nil, &user, opts); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SearchDirectory searches the members of a tenant across all of its groups.
+func (c *Client) SearchDirectory(ctx context.Context, params DirectorySearchParams, opts ...RequestOption) (*OffsetPage[DirectoryEntry], error) {
+	query := offsetQuery(params.Page, params.PageSize)
+	if params.TenantID != "" {
+		query.Set("tenant_id", params.TenantID)
+	}
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	var page OffsetPage[DirectoryEntry]
+	if err := c.do(ctx, http.MethodGet, "/directory", query, nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription that receives signed domain events of a tenant.
+type Webhook struct {
+	ID          string   `json:"id"`
+	TenantID    string   `json:"tenant_id"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Active      bool     `json:"active"`
+	Description *string  `json:"description,omitempty"`
+	// Secret signs deliveries. It is only returned on creation and rotation.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookInput creates or updates a subscription. An empty EventTypes subscribes to every
+// event. TenantID is only honoured for platform administrators.
+type WebhookInput struct {
+	TenantID    string   `json:"tenant_id,omitempty"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Active      *bool    `json:"active,omitempty"`
+	Description *string  `json:"description,omitempty"`
+}
+
+// WebhookDelivery is one attempt series to deliver an event to a subscription.
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int32     `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	// AttemptLog is only filled by GetWebhookDelivery.
+	AttemptLog []WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+// WebhookDeliveryAttempt is a single HTTP call of a delivery.
+type WebhookDeliveryAttempt struct {
+	StatusCode *int32    `json:"status_code,omitempty"`
+	Error      *string   `json:"error,omitempty"`
+	DurationMS int32     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryListParams filters the deliveries of a subscription.
+type WebhookDeliveryListParams struct {
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// ListWebhookEventTypes returns the event types a subscription can filter on.
+func (c *Client) ListWebhookEventTypes(ctx context.Context, opts ...RequestOption) ([]string, error) {
+	var result struct {
+		Items []string `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/webhooks/event-types", nil, nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// ListWebhooks returns the subscriptions of a tenant. tenantID is only honoured for
+// platform administrators and may be empty.
+func (c *Client) ListWebhooks(ctx context.Context, tenantID string, opts ...RequestOption) ([]Webhook, error) {
+	var result struct {
+		Items []Webhook `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/webhooks", tenantQuery(tenantID), nil, &result, opts); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// GetWebhook loads a subscription.
+func (c *Client) GetWebhook(ctx context.Context, id string, opts ...RequestOption) (*Webhook, error) {
+	return c.webhook(ctx, http.MethodGet, "/webhooks/"+escape(id), nil, opts)
+}
+
+// CreateWebhook creates a subscription. The returned Secret is not shown again.
+func (c *Client) CreateWebhook(ctx context.Context, input WebhookInput, opts ...RequestOption) (*Webhook, error) {
+	return c.webhook(ctx, http.MethodPost, "/webhooks", input, opts)
+}
+
+// UpdateWebhook updates a subscription.
+func (c *Client) UpdateWebhook(ctx context.Context, id string, input WebhookInput, opts ...RequestOption) (*Webhook, error) {
+	return c.webhook(ctx, http.MethodPut, "/webhooks/"+escape(id), input, opts)
+}
+
+// DeleteWebhook deletes a subscription.
+func (c *Client) DeleteWebhook(ctx context.Context, id string, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+escape(id), nil, nil, nil, opts)
+}
+
+// RotateWebhookSecret replaces the signing secret of a subscription and returns it.
+func (c *Client) RotateWebhookSecret(ctx context.Context, id string, opts ...RequestOption) (*Webhook, error) {
+	return c.webhook(ctx, http.MethodPost, "/webhooks/"+escape(id)+"/rotate-secret", nil, opts)
+}
+
+// ListWebhookDeliveries returns one page of the deliveries of a subscription.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, params WebhookDeliveryListParams, opts ...RequestOption) (*OffsetPage[WebhookDelivery], error) {
+	query := offsetQuery(params.Page, params.PageSize)
+	if params.Status != "" {
+		query.Set("status", params.Status)
+	}
+	var page OffsetPage[WebhookDelivery]
+	if err := c.do(ctx, http.MethodGet, "/webhooks/"+escape(id)+"/deliveries", query, nil, &page, opts); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetWebhookDelivery loads a delivery with its attempt log.
+func (c *Client) GetWebhookDelivery(ctx context.Context, id, deliveryID string, opts ...RequestOption) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	if err := c.do(ctx, http.MethodGet, "/webhooks/"+escape(id)+"/deliveries/"+escape(deliveryID), nil, nil, &delivery, opts); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ReplayWebhookDelivery schedules a delivery to be sent again.
+func (c *Client) ReplayWebhookDelivery(ctx context.Context, id, deliveryID string, opts ...RequestOption) error {
+	return c.do(ctx, http.MethodPost, "/webhooks/"+escape(id)+"/deliveries/"+escape(deliveryID)+"/replay", nil, nil, nil, opts)
+}
+
+func (c *Client) webhook(ctx context.Context, method, apiPath string, body any, opts []RequestOption) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.do(ctx, method, apiPath, nil, body, &webhook, opts); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
@@ -4,19 +4,36 @@ import (
 	"context"
 	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/laofa009/next-agent-portal/backend/internal/config"
+	"github.com/laofa009/next-agent-portal/backend/internal/importer"
 	"github.com/laofa009/next-agent-portal/backend/internal/keto"
+	"github.com/laofa009/next-agent-portal/backend/internal/ketosync"
 	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
 	"github.com/laofa009/next-agent-portal/backend/internal/logging"
 	"github.com/laofa009/next-agent-portal/backend/internal/server"
+	"github.com/laofa009/next-agent-portal/backend/internal/sms"
 	"github.com/laofa009/next-agent-portal/backend/internal/storage"
 	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+	"github.com/laofa009/next-agent-portal/backend/internal/webhook"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "seed":
+			os.Exit(runSeed(os.Args[2:]))
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("load config: %v", err)
@@ -54,7 +71,20 @@ func main() {
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
+	smsSender, err := sms.NewSender(sms.Options{
+		Endpoint: cfg.SMS.Endpoint,
+		Timeout:  cfg.SMS.Timeout,
+	}, logger)
+	if err != nil {
+		logger.Fatal("init sms sender", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// SIGTERM or SIGINT cancels ctx, which drains the HTTP server and stops the
+	// background workers.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	pool, err := storage.NewPool(ctx, storage.PoolConfig{
 		DSN:             cfg.Database.DSN,
 		MaxOpenConns:    cfg.Database.MaxOpenConns,
@@ -67,15 +97,126 @@ func main() {
 	}
 	defer pool.Close()
 
+	migrator, err := storage.NewMigrator(pool)
+	if err != nil {
+		logger.Fatal("load migrations", zap.Error(err))
+		os.Exit(1)
+	}
+	if cfg.Database.MigrateOnStartup {
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			logger.Info("applied migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		}
+		if err != nil {
+			logger.Fatal("apply migrations", zap.Error(err))
+			os.Exit(1)
+		}
+	}
+
 	queries := sqldb.New(pool)
-	tenantRepo := storage.NewTenantRepository(queries)
-	groupRepo := storage.NewGroupRepository(queries)
+	tenantRepo := storage.NewTenantRepository(pool, queries)
+	groupRepo := storage.NewGroupRepository(pool, queries)
 	roleRepo := storage.NewRoleRepository(pool, queries)
 	permissionRepo := storage.NewPermissionRepository(queries)
+	impersonationRepo := storage.NewImpersonationRepository(queries)
+	auditRepo := storage.NewAuditRepository(queries)
+	webhookRepo := storage.NewWebhookRepository(queries)
+	scimRepo := storage.NewScimRepository(pool, queries)
+	importRepo := storage.NewMemberImportRepository(pool, queries)
+	userRepo := storage.NewUserRepository(pool, queries)
+	invitationRepo := storage.NewInvitationRepository(pool, queries)
+	idempotencyRepo := storage.NewIdempotencyRepository(queries)
+	ketoSyncRepo := storage.NewKetoSyncRepository(queries)
 
-	srv := server.New(cfg, logger, ketoClient, kratosClient, tenantRepo, groupRepo, roleRepo, permissionRepo)
+	var workers sync.WaitGroup
+	runWorker := func(run func()) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run()
+		}()
+	}
+
+	runWorker(func() {
+		updated, err := groupRepo.BackfillNameInitials(ctx)
+		if err != nil {
+			logger.Warn("backfill member name initials failed", zap.Error(err))
+			return
+		}
+		if updated > 0 {
+			logger.Info("backfilled member name initials", zap.Int("rows", updated))
+		}
+	})
+
+	runWorker(func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			removed, err := idempotencyRepo.PurgeExpired(ctx)
+			if err != nil {
+				logger.Warn("purge idempotency keys failed", zap.Error(err))
+				continue
+			}
+			if removed > 0 {
+				logger.Info("purged expired idempotency keys", zap.Int64("rows", removed))
+			}
+		}
+	})
+
+	ketoSyncer := ketosync.New(ketoSyncRepo, groupRepo, roleRepo, ketoClient, kratosClient, ketosync.Options{
+		PollInterval: cfg.Keto.SyncInterval,
+	}, logger)
+	runWorker(func() { ketoSyncer.Run(ctx) })
+
+	if cfg.Webhooks.Enabled {
+		dispatcher := webhook.NewDispatcher(webhookRepo, webhook.Options{
+			PollInterval:          cfg.Webhooks.PollInterval,
+			BatchSize:             cfg.Webhooks.BatchSize,
+			MaxAttempts:           cfg.Webhooks.MaxAttempts,
+			Timeout:               cfg.Webhooks.Timeout,
+			AllowPrivateEndpoints: cfg.Webhooks.AllowPrivateEndpoints,
+		}, logger)
+		runWorker(func() { dispatcher.Run(ctx) })
+	}
+
+	memberImporter := importer.New(importRepo, groupRepo, roleRepo, kratosClient, ketoClient, importer.Options{
+		PollInterval: cfg.Imports.PollInterval,
+		MaxRows:      cfg.Imports.MaxRows,
+	}, logger)
+	if cfg.Imports.Enabled {
+		runWorker(func() { memberImporter.Run(ctx) })
+	}
+
+	srv := server.New(cfg, logger, server.Deps{
+		Keto:           ketoClient,
+		KetoSync:       ketoSyncer,
+		Kratos:         kratosClient,
+		Tenants:        tenantRepo,
+		Groups:         groupRepo,
+		Roles:          roleRepo,
+		Permissions:    permissionRepo,
+		Impersonations: impersonationRepo,
+		Audit:          auditRepo,
+		Webhooks:       webhookRepo,
+		Scim:           scimRepo,
+		MemberImports:  importRepo,
+		Importer:       memberImporter,
+		Users:          userRepo,
+		Invitations:    invitationRepo,
+		Idempotency:    idempotencyRepo,
+		SMS:            smsSender,
+		Migrator:       migrator,
+		Pool:           pool,
+	})
 
-	if err := srv.Run(); err != nil {
+	if err := srv.Run(ctx); err != nil {
 		logger.Fatal("server stopped with error", zap.Error(err))
 	}
+	workers.Wait()
+	logger.Info("portal stopped")
 }
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/config"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const migrateUsage = `usage: portal migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [N|-all]   revert the last N migrations (default 1), or all of them
+  status          show the schema version and the embedded migrations
+  force VERSION   record VERSION as applied and clear the dirty flag; -1 marks the
+                  database as empty
+`
+
+// runMigrate implements the migrate subcommand and returns the process exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := migrate(ctx, os.Stdout, args[0], args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "portal migrate %s: %v\n", args[0], err)
+		if errors.Is(err, errMigrateUsage) {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+var errMigrateUsage = errors.New("invalid arguments")
+
+func migrate(ctx context.Context, out io.Writer, command string, args []string) error {
+	// Only down takes a flag; force has to accept -1 as its argument.
+	var all bool
+	switch command {
+	case "up", "status":
+		if len(args) != 0 {
+			return errMigrateUsage
+		}
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.BoolVar(&all, "all", false, "revert every migration")
+		if err := fs.Parse(args); err != nil {
+			return fmt.Errorf("%w: %v", errMigrateUsage, err)
+		}
+		args = fs.Args()
+		if len(args) > 1 || (all && len(args) != 0) {
+			return errMigrateUsage
+		}
+	case "force":
+		if len(args) != 1 {
+			return errMigrateUsage
+		}
+	default:
+		return fmt.Errorf("%w: unknown command %q", errMigrateUsage, command)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	pool, err := storage.NewPool(ctx, storage.PoolConfig{DSN: cfg.Database.DSN, MaxOpenConns: 2})
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := storage.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "no change")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if all {
+			steps = len(migrator.Migrations())
+		} else if len(args) == 1 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				return fmt.Errorf("%w: N must be a positive number", errMigrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Fprintln(out, "no change")
+		}
+		return nil
+
+	case "force":
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: VERSION must be a number", errMigrateUsage)
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "schema version set to %d\n", version)
+		return nil
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	state := "clean"
+	if version.Dirty {
+		state = "dirty"
+	}
+	fmt.Fprintf(out, "version %d (%s), latest %d\n\n", version.Version, state, version.Latest)
+	for _, migration := range migrator.Migrations() {
+		mark := "pending"
+		if migration.Version <= version.Version {
+			mark = "applied"
+		}
+		fmt.Fprintf(out, "%-8s %06d_%s\n", mark, migration.Version, migration.Name)
+	}
+	return nil
+}
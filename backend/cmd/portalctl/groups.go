@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+)
+
+var groupCommands = map[string]command{
+	"list": {
+		usage:   "[-tenant TENANT_ID]",
+		summary: "show the group tree of a tenant",
+		run:     runGroupsList,
+	},
+	"create": {
+		usage:   "-code CODE -name NAME [-parent GROUP_ID] [-tenant TENANT_ID] [-description TEXT] [-sort N]",
+		summary: "create a group",
+		run:     runGroupsCreate,
+	},
+	"update": {
+		usage:   "GROUP_ID [-code CODE] [-name NAME] [-description TEXT] [-sort N] [-if-match ETAG]",
+		summary: "change the given fields of a group",
+		run:     runGroupsUpdate,
+	},
+	"move": {
+		usage:   "GROUP_ID [-parent GROUP_ID] [-sort N] [-if-match ETAG]",
+		summary: "move a group under another group, or to the top level without -parent",
+		run:     runGroupsMove,
+	},
+	"delete": {
+		usage:   "GROUP_ID [-mode restrict|reassign|cascade] [-target GROUP_ID] [-dry-run] [-if-match ETAG]",
+		summary: "delete a group",
+		run:     runGroupsDelete,
+	},
+}
+
+// treeGroup is a group with its depth in the tree, for indented table output.
+type treeGroup struct {
+	client.Group
+	depth int
+}
+
+var groupColumns = []column[client.Group]{
+	{"ID", func(g client.Group) string { return g.ID }},
+	{"CODE", func(g client.Group) string { return g.Code }},
+	{"NAME", func(g client.Group) string { return g.Name }},
+	{"PARENT", func(g client.Group) string { return deref(g.ParentID) }},
+	{"MEMBERS", func(g client.Group) string { return formatCount(g.MemberCount) }},
+	{"ETAG", func(g client.Group) string { return g.ETag }},
+}
+
+func runGroupsList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("groups list")
+	tenantID := fs.String("tenant", "", "tenant, for platform administrators")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := a.requireAPI("groups list"); err != nil {
+		return err
+	}
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	groups, err := api.ListGroups(ctx, *tenantID)
+	if err != nil {
+		return err
+	}
+	if a.output == outputJSON {
+		return a.printJSON(groups)
+	}
+
+	var rows []treeGroup
+	var walk func(groups []client.Group, depth int)
+	walk = func(groups []client.Group, depth int) {
+		for _, group := range groups {
+			rows = append(rows, treeGroup{Group: group, depth: depth})
+			walk(group.Children, depth+1)
+		}
+	}
+	walk(groups, 0)
+
+	return printList(a, rows, []column[treeGroup]{
+		{"ID", func(g treeGroup) string { return g.ID }},
+		{"NAME", func(g treeGroup) string { return strings.Repeat("  ", g.depth) + g.Name }},
+		{"CODE", func(g treeGroup) string { return g.Code }},
+		{"SORT", func(g treeGroup) string { return strconv.Itoa(int(g.SortOrder)) }},
+		{"MEMBERS", func(g treeGroup) string { return formatCount(g.MemberCount) }},
+	})
+}
+
+func runGroupsCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("groups create")
+	code := fs.String("code", "", "group code")
+	name := fs.String("name", "", "display name")
+	parentID := fs.String("parent", "", "parent group (top level when empty)")
+	tenantID := fs.String("tenant", "", "tenant, for platform administrators")
+	var description, sortOrder optionalString
+	fs.Var(&description, "description", "description")
+	fs.Var(&sortOrder, "sort", "position among siblings")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || *code == "" || *name == "" {
+		return usageError("-code and -name are required")
+	}
+	if err := a.requireAPI("groups create"); err != nil {
+		return err
+	}
+
+	input := client.GroupInput{
+		TenantID:    *tenantID,
+		Code:        *code,
+		Name:        *name,
+		Description: description.ptr(),
+	}
+	if *parentID != "" {
+		input.ParentID = parentID
+	}
+	if input.SortOrder, err = parseSortOrder(sortOrder); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	group, err := api.CreateGroup(ctx, input)
+	if err != nil {
+		return err
+	}
+	return printItem(a, *group, groupColumns)
+}
+
+func runGroupsUpdate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("groups update")
+	code := fs.String("code", "", "group code")
+	name := fs.String("name", "", "display name")
+	ifMatch := fs.String("if-match", "", "only update if the group still has this ETag")
+	var description, sortOrder optionalString
+	fs.Var(&description, "description", "description")
+	fs.Var(&sortOrder, "sort", "position among siblings")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a group id")
+	}
+	if err := a.requireAPI("groups update"); err != nil {
+		return err
+	}
+
+	// Empty code and name keep the current values; the parent is changed with move.
+	input := client.GroupInput{
+		Code:        *code,
+		Name:        *name,
+		Description: description.ptr(),
+	}
+	if input.SortOrder, err = parseSortOrder(sortOrder); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	group, err := api.UpdateGroup(ctx, positional[0], input, client.WithIfMatch(*ifMatch))
+	if err != nil {
+		return err
+	}
+	return printItem(a, *group, groupColumns)
+}
+
+func runGroupsMove(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("groups move")
+	parentID := fs.String("parent", "", "new parent group (top level when empty)")
+	ifMatch := fs.String("if-match", "", "only move if the group still has this ETag")
+	var sortOrder optionalString
+	fs.Var(&sortOrder, "sort", "position among the new siblings")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a group id")
+	}
+	if err := a.requireAPI("groups move"); err != nil {
+		return err
+	}
+
+	var move client.GroupMove
+	if *parentID != "" {
+		move.ParentID = parentID
+	}
+	if move.SortOrder, err = parseSortOrder(sortOrder); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	group, err := api.MoveGroup(ctx, positional[0], move, client.WithIfMatch(*ifMatch))
+	if err != nil {
+		return err
+	}
+	return printItem(a, *group, groupColumns)
+}
+
+func runGroupsDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("groups delete")
+	mode := fs.String("mode", "", "restrict (default), reassign or cascade")
+	target := fs.String("target", "", "group receiving children and members with -mode reassign")
+	dryRun := fs.Bool("dry-run", false, "only show what would change")
+	ifMatch := fs.String("if-match", "", "only delete if the group still has this ETag")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a group id")
+	}
+	if err := a.requireAPI("groups delete"); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	options := client.GroupDeleteOptions{Mode: *mode, TargetGroupID: *target}
+	if *dryRun {
+		preview, err := api.PreviewGroupDeletion(ctx, positional[0], options)
+		if err != nil {
+			return err
+		}
+		if a.output == outputJSON {
+			return a.printJSON(preview)
+		}
+		return a.printMessage("deleting %s (%s) would delete %d groups, move %d groups and %d members, and remove %d members",
+			preview.Group.Name, preview.Mode, len(preview.DeletedGroups), len(preview.MovedGroups),
+			len(preview.MovedMembers), len(preview.RemovedMembers))
+	}
+
+	if err := api.DeleteGroup(ctx, positional[0], options, client.WithIfMatch(*ifMatch)); err != nil {
+		return err
+	}
+	return a.printMessage("deleted group %s", positional[0])
+}
+
+func parseSortOrder(flag optionalString) (*int32, error) {
+	if !flag.set {
+		return nil, nil
+	}
+	value, err := strconv.ParseInt(flag.value, 10, 32)
+	if err != nil {
+		return nil, usageError("-sort must be an integer")
+	}
+	sortOrder := int32(value)
+	return &sortOrder, nil
+}
@@ -0,0 +1,221 @@
+// Command portalctl administers the portal from the command line. It manages tenants,
+// groups, members, roles and Keto relation tuples through the portal API, and can fall
+// back to the database for break-glass operations when the API is unavailable.
+//
+//	portalctl [global flags] <resource> <verb> [flags] [args]
+//	portalctl -profile prod tenants list -status active
+//	portalctl roles assign 6f1c... 0b4e... -o json
+//	portalctl -direct roles assign <role-id> <identity-id>
+//
+// Connection settings come from profiles in $PORTALCTL_CONFIG, by default
+// ~/.config/portalctl/config.yaml; see profiles.go for the format.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// command is a verb of a resource, e.g. "tenants list".
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, app *app, args []string) error
+}
+
+// errUsage makes main print the usage of the command that failed.
+var errUsage = errors.New("invalid usage")
+
+var resources = map[string]map[string]command{
+	"tenants":     tenantCommands,
+	"groups":      groupCommands,
+	"members":     memberCommands,
+	"roles":       roleCommands,
+	"permissions": permissionCommands,
+	"tuples":      tupleCommands,
+	"profiles":    profileCommands,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("portalctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	var opts globalOptions
+	global.StringVar(&opts.configPath, "config", "", "profiles file (defaults to $PORTALCTL_CONFIG or ~/.config/portalctl/config.yaml)")
+	global.StringVar(&opts.profile, "profile", "", "profile to use (defaults to $PORTALCTL_PROFILE or the current profile of the file)")
+	global.StringVar(&opts.output, "o", "", "output format: table or json")
+	global.StringVar(&opts.apiURL, "api-url", "", "portal address, overriding the profile")
+	global.BoolVar(&opts.direct, "direct", false, "talk to the database and Keto instead of the API (break-glass)")
+	global.Usage = func() { printUsage(stderr) }
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	rest := global.Args()
+	if len(rest) < 2 {
+		printUsage(stderr)
+		return 2
+	}
+	verbs, ok := resources[rest[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "portalctl: unknown resource %q\n\n", rest[0])
+		printUsage(stderr)
+		return 2
+	}
+	cmd, ok := verbs[rest[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "portalctl: unknown command %q for %s\n\n", rest[1], rest[0])
+		printResourceUsage(stderr, rest[0], verbs)
+		return 2
+	}
+
+	application, err := newApp(opts, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "portalctl: %v\n", err)
+		return 1
+	}
+	defer application.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, application, rest[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(stdout, commandUsage(rest[0], rest[1], cmd))
+			return 0
+		}
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "portalctl: %v\n%s\n", err, commandUsage(rest[0], rest[1], cmd))
+			return 2
+		}
+		fmt.Fprintf(stderr, "portalctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func commandUsage(resource, verb string, cmd command) string {
+	return strings.TrimSpace(fmt.Sprintf("usage: portalctl %s %s %s", resource, verb, cmd.usage))
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: portalctl [global flags] <resource> <verb> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "global flags:")
+	fmt.Fprintln(w, "  -config FILE    profiles file")
+	fmt.Fprintln(w, "  -profile NAME   profile to use")
+	fmt.Fprintln(w, "  -o FORMAT       output format: table (default) or json")
+	fmt.Fprintln(w, "  -api-url URL    portal address, overriding the profile")
+	fmt.Fprintln(w, "  -direct         use the database and Keto instead of the API")
+	fmt.Fprintln(w)
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printResourceUsage(w, name, resources[name])
+	}
+}
+
+func printResourceUsage(w io.Writer, resource string, verbs map[string]command) {
+	names := make([]string, 0, len(verbs))
+	for name := range verbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "%s:\n", resource)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s %s\n      %s\n", name, verbs[name].usage, verbs[name].summary)
+	}
+	fmt.Fprintln(w)
+}
+
+// parseArgs parses flags that may appear before, between or after the positional
+// arguments and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError("%v", err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// usageError reports a command line the command cannot run.
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// flagSet returns a silent flag set for a command; parse errors are reported through the
+// usage line. Every command also accepts -o so it can follow the arguments.
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("o", "output format: table or json", func(value string) error {
+		if value != outputTable && value != outputJSON {
+			return fmt.Errorf("unknown output format %q", value)
+		}
+		a.output = value
+		return nil
+	})
+	return fs
+}
+
+// splitList splits a comma-separated flag value, dropping blanks.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// optionalString is a string flag that remembers whether it was given, so updates only
+// touch the fields named on the command line.
+type optionalString struct {
+	value string
+	set   bool
+}
+
+func (o *optionalString) String() string { return o.value }
+
+func (o *optionalString) Set(value string) error {
+	o.value, o.set = value, true
+	return nil
+}
+
+// ptr returns the flag value, or nil when the flag was not given.
+func (o *optionalString) ptr() *string {
+	if !o.set {
+		return nil
+	}
+	value := o.value
+	return &value
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+)
+
+var memberCommands = map[string]command{
+	"list": {
+		usage:   "GROUP_ID [-search TEXT] [-recursive] [-limit N]",
+		summary: "list the members of a group",
+		run:     runMembersList,
+	},
+	"add": {
+		usage:   "GROUP_ID (-identity IDENTITY_ID | -phone PHONE -name NAME [-password PASSWORD]) [-title TITLE] [-primary]",
+		summary: "add an identity to a group, registering the phone if needed",
+		run:     runMembersAdd,
+	},
+	"update": {
+		usage:   "GROUP_ID IDENTITY_ID [-title TITLE] [-primary true|false] [-move-to GROUP_ID]",
+		summary: "change or transfer a membership",
+		run:     runMembersUpdate,
+	},
+	"remove": {
+		usage:   "GROUP_ID IDENTITY_ID",
+		summary: "remove an identity from a group",
+		run:     runMembersRemove,
+	},
+	"groups": {
+		usage:   "IDENTITY_ID [-tenant TENANT_ID]",
+		summary: "list the groups of an identity",
+		run:     runMembersGroups,
+	},
+}
+
+var memberColumns = []column[client.GroupMember]{
+	{"IDENTITY", func(m client.GroupMember) string { return m.IdentityID }},
+	{"NAME", func(m client.GroupMember) string { return m.DisplayName }},
+	{"PHONE", func(m client.GroupMember) string { return m.Phone }},
+	{"TITLE", func(m client.GroupMember) string { return deref(m.Title) }},
+	{"PRIMARY", func(m client.GroupMember) string { return yesNo(m.IsPrimary) }},
+	{"GROUP", func(m client.GroupMember) string { return m.GroupID }},
+}
+
+// subtreePageSize is the page size used to walk recursive member listings.
+const subtreePageSize = 100
+
+func runMembersList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("members list")
+	search := fs.String("search", "", "match name or phone")
+	recursive := fs.Bool("recursive", false, "include the members of descendant groups")
+	limit := fs.Int("limit", 0, "stop after N members (0 lists all)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a group id")
+	}
+	if err := a.requireAPI("members list"); err != nil {
+		return err
+	}
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	var members []client.GroupMember
+	full := func() bool { return *limit > 0 && len(members) >= *limit }
+	if *recursive {
+		for page := 1; ; page++ {
+			result, err := api.ListSubtreeMembers(ctx, positional[0], client.SubtreeMemberListParams{
+				Search:   *search,
+				Page:     page,
+				PageSize: subtreePageSize,
+			})
+			if err != nil {
+				return err
+			}
+			members = append(members, result.Items...)
+			if len(result.Items) == 0 || int64(len(members)) >= result.Total || full() {
+				break
+			}
+		}
+		if full() {
+			members = members[:*limit]
+		}
+	} else {
+		for member, err := range api.AllGroupMembers(ctx, positional[0], client.GroupMemberListParams{Search: *search}) {
+			if err != nil {
+				return err
+			}
+			members = append(members, member)
+			if full() {
+				break
+			}
+		}
+	}
+	return printList(a, members, memberColumns)
+}
+
+func runMembersAdd(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("members add")
+	identityID := fs.String("identity", "", "existing identity")
+	phone := fs.String("phone", "", "phone of the member")
+	name := fs.String("name", "", "display name for a new identity")
+	password := fs.String("password", "", "initial password for a new identity")
+	primary := fs.Bool("primary", false, "make this the primary group of the member")
+	var title optionalString
+	fs.Var(&title, "title", "job title in this group")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a group id")
+	}
+	if (*identityID == "") == (*phone == "") {
+		return usageError("pass either -identity or -phone")
+	}
+	if err := a.requireAPI("members add"); err != nil {
+		return err
+	}
+
+	input := client.GroupMemberInput{
+		IdentityID:  *identityID,
+		Phone:       *phone,
+		DisplayName: *name,
+		Password:    *password,
+		Title:       title.ptr(),
+	}
+	if *primary {
+		input.IsPrimary = primary
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	member, err := api.AddGroupMember(ctx, positional[0], input)
+	if err != nil {
+		return err
+	}
+	return printItem(a, *member, memberColumns)
+}
+
+func runMembersUpdate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("members update")
+	moveTo := fs.String("move-to", "", "transfer the membership to this group")
+	var title, primary optionalString
+	fs.Var(&title, "title", "job title in the group")
+	fs.Var(&primary, "primary", "whether this is the primary group of the member")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageError("expected a group id and an identity id")
+	}
+	if err := a.requireAPI("members update"); err != nil {
+		return err
+	}
+
+	update := client.GroupMemberUpdate{
+		TargetGroupID: *moveTo,
+		Title:         title.ptr(),
+	}
+	if primary.set {
+		isPrimary, err := strconv.ParseBool(primary.value)
+		if err != nil {
+			return usageError("-primary must be true or false")
+		}
+		update.IsPrimary = &isPrimary
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	member, err := api.UpdateGroupMember(ctx, positional[0], positional[1], update)
+	if err != nil {
+		return err
+	}
+	return printItem(a, *member, memberColumns)
+}
+
+func runMembersRemove(ctx context.Context, a *app, args []string) error {
+	positional, err := parseArgs(a.flagSet("members remove"), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageError("expected a group id and an identity id")
+	}
+	if err := a.requireAPI("members remove"); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	if err := api.RemoveGroupMember(ctx, positional[0], positional[1]); err != nil {
+		return err
+	}
+	return a.printMessage("removed %s from group %s", positional[1], positional[0])
+}
+
+func runMembersGroups(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("members groups")
+	tenantID := fs.String("tenant", "", "tenant, for platform administrators")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected an identity id")
+	}
+	if err := a.requireAPI("members groups"); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	groups, err := api.ListMemberGroups(ctx, positional[0], *tenantID)
+	if err != nil {
+		return err
+	}
+	return printList(a, groups, []column[client.MemberGroup]{
+		{"GROUP", func(g client.MemberGroup) string { return g.GroupID }},
+		{"CODE", func(g client.MemberGroup) string { return g.GroupCode }},
+		{"NAME", func(g client.MemberGroup) string { return g.GroupName }},
+		{"TITLE", func(g client.MemberGroup) string { return deref(g.Title) }},
+		{"PRIMARY", func(g client.MemberGroup) string { return yesNo(g.IsPrimary) }},
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// column renders one field of T in table output.
+type column[T any] struct {
+	header string
+	value  func(T) string
+}
+
+// printList writes items as an aligned table, or as a JSON array.
+func printList[T any](a *app, items []T, columns []column[T]) error {
+	if a.output == outputJSON {
+		if items == nil {
+			items = []T{}
+		}
+		return a.printJSON(items)
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, item := range items {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = sanitize(col.value(item))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printItem writes a single item as FIELD value lines, or as a JSON object.
+func printItem[T any](a *app, item T, columns []column[T]) error {
+	if a.output == outputJSON {
+		return a.printJSON(item)
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	for _, col := range columns {
+		fmt.Fprintf(tw, "%s\t%s\n", col.header, sanitize(col.value(item)))
+	}
+	return tw.Flush()
+}
+
+// printMessage reports the outcome of a command without a result body.
+func (a *app) printMessage(format string, args ...any) error {
+	message := fmt.Sprintf(format, args...)
+	if a.output == outputJSON {
+		return a.printJSON(map[string]string{"message": message})
+	}
+	_, err := fmt.Fprintln(a.stdout, message)
+	return err
+}
+
+func (a *app) printJSON(value any) error {
+	encoder := json.NewEncoder(a.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// sanitize keeps tabs and newlines in values from breaking the table layout.
+func sanitize(value string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(value)
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.Local().Format("2006-01-02 15:04")
+}
+
+func formatCount(value int64) string {
+	return strconv.FormatInt(value, 10)
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func mark(value bool) string {
+	if value {
+		return "*"
+	}
+	return ""
+}
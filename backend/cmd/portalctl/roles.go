@@ -0,0 +1,529 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+var roleCommands = map[string]command{
+	"list": {
+		usage:   "[-scope global|tenant] [-search TEXT] [-tenant TENANT_ID] [-permissions]",
+		summary: "list roles",
+		run:     runRolesList,
+	},
+	"create": {
+		usage:   "-scope global|tenant -code CODE -name NAME [-tenant TENANT_ID] [-permissions a,b] [-description TEXT]",
+		summary: "create a role",
+		run:     runRolesCreate,
+	},
+	"update": {
+		usage:   "ROLE_ID [-code CODE] [-name NAME] [-description TEXT] [-permissions a,b] [-tenant TENANT_ID]",
+		summary: "change the given fields of a role",
+		run:     runRolesUpdate,
+	},
+	"delete": {
+		usage:   "ROLE_ID [-if-match ETAG]",
+		summary: "delete a role and its assignments",
+		run:     runRolesDelete,
+	},
+	"members": {
+		usage:   "ROLE_ID",
+		summary: "list the identities assigned a role",
+		run:     runRolesMembers,
+	},
+	"assign": {
+		usage:   "ROLE_ID IDENTITY_ID...",
+		summary: "assign a role to identities",
+		run:     runRolesAssign,
+	},
+	"unassign": {
+		usage:   "ROLE_ID IDENTITY_ID",
+		summary: "remove a role from an identity",
+		run:     runRolesUnassign,
+	},
+}
+
+var permissionCommands = map[string]command{
+	"list": {
+		usage:   "",
+		summary: "list the permission catalog",
+		run:     runPermissionsList,
+	},
+}
+
+var roleColumns = []column[client.Role]{
+	{"ID", func(r client.Role) string { return r.ID }},
+	{"SCOPE", func(r client.Role) string { return r.Scope }},
+	{"CODE", func(r client.Role) string { return r.Code }},
+	{"NAME", func(r client.Role) string { return r.Name }},
+	{"TENANT", func(r client.Role) string { return deref(r.TenantID) }},
+	{"ASSIGNED", func(r client.Role) string { return formatCount(r.AssignedCount) }},
+	{"PERMISSIONS", func(r client.Role) string { return strings.Join(r.Permissions, ",") }},
+}
+
+func runRolesList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("roles list")
+	scope := fs.String("scope", "", "global or tenant")
+	search := fs.String("search", "", "match code or name")
+	tenantID := fs.String("tenant", "", "tenant, for platform administrators")
+	withPermissions := fs.Bool("permissions", false, "include granted permissions")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	var roles []client.Role
+	if a.direct {
+		params := storage.RoleListParams{Scope: *scope, Search: *search, Limit: directPageSize}
+		if *tenantID != "" {
+			id, err := uuid.Parse(*tenantID)
+			if err != nil {
+				return fmt.Errorf("invalid tenant id %q", *tenantID)
+			}
+			params.TenantID = &id
+		}
+		repo, err := a.roleRepository(ctx)
+		if err != nil {
+			return err
+		}
+		for {
+			page, total, err := repo.ListRoles(ctx, params)
+			if err != nil {
+				return fmt.Errorf("list roles: %w", err)
+			}
+			for _, role := range page {
+				if *withPermissions {
+					if role.Permissions, err = repo.ListPermissions(ctx, role.ID); err != nil {
+						return fmt.Errorf("list role permissions: %w", err)
+					}
+				}
+				roles = append(roles, roleFromStorage(role))
+			}
+			params.Offset += int32(len(page))
+			if len(page) == 0 || int64(params.Offset) >= total {
+				break
+			}
+		}
+		return printList(a, roles, roleColumns)
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	params := client.RoleListParams{
+		Scope:              *scope,
+		Search:             *search,
+		TenantID:           *tenantID,
+		IncludePermissions: *withPermissions,
+	}
+	for role, err := range api.AllRoles(ctx, params) {
+		if err != nil {
+			return err
+		}
+		roles = append(roles, role)
+	}
+	return printList(a, roles, roleColumns)
+}
+
+func runRolesCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("roles create")
+	scope := fs.String("scope", "", "global or tenant")
+	code := fs.String("code", "", "role code")
+	name := fs.String("name", "", "display name")
+	tenantID := fs.String("tenant", "", "tenant of a tenant role, for platform administrators")
+	permissions := fs.String("permissions", "", "comma-separated permission codes")
+	var description optionalString
+	fs.Var(&description, "description", "description")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || *scope == "" || *code == "" || *name == "" {
+		return usageError("-scope, -code and -name are required")
+	}
+	// Creating a role also binds its permissions in Keto, which only the API does.
+	if err := a.requireAPI("roles create"); err != nil {
+		return err
+	}
+
+	input := client.RoleInput{
+		Scope:       *scope,
+		Code:        *code,
+		Name:        *name,
+		Description: description.ptr(),
+		Permissions: splitList(*permissions),
+	}
+	if *tenantID != "" {
+		input.TenantID = tenantID
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	role, err := api.CreateRole(ctx, input)
+	if err != nil {
+		return err
+	}
+	return printItem(a, *role, roleColumns)
+}
+
+func runRolesUpdate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("roles update")
+	code := fs.String("code", "", "role code")
+	name := fs.String("name", "", "display name")
+	tenantID := fs.String("tenant", "", "tenant of the role, for platform administrators")
+	var description, permissions optionalString
+	fs.Var(&description, "description", "description")
+	fs.Var(&permissions, "permissions", "comma-separated permission codes, replacing the current ones")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a role id")
+	}
+	if err := a.requireAPI("roles update"); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	// The API replaces permissions, description and metadata on update, so start from
+	// the current role and only change what was asked for.
+	current, err := findRole(ctx, api, positional[0], *tenantID)
+	if err != nil {
+		return err
+	}
+	input := client.RoleInput{
+		Code:        current.Code,
+		Name:        current.Name,
+		Description: current.Description,
+		Permissions: current.Permissions,
+		Metadata:    current.Metadata,
+	}
+	if *code != "" {
+		input.Code = *code
+	}
+	if *name != "" {
+		input.Name = *name
+	}
+	if description.set {
+		input.Description = description.ptr()
+	}
+	if permissions.set {
+		input.Permissions = splitList(permissions.value)
+	}
+
+	role, err := api.UpdateRole(ctx, current.ID, input, client.WithIfMatch(current.ETag))
+	if err != nil {
+		return err
+	}
+	return printItem(a, *role, roleColumns)
+}
+
+func runRolesDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("roles delete")
+	ifMatch := fs.String("if-match", "", "only delete if the role still has this ETag")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a role id")
+	}
+	// Deleting a role also unbinds its permissions in Keto, which only the API does.
+	if err := a.requireAPI("roles delete"); err != nil {
+		return err
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	if err := api.DeleteRole(ctx, positional[0], client.WithIfMatch(*ifMatch)); err != nil {
+		return err
+	}
+	return a.printMessage("deleted role %s", positional[0])
+}
+
+func runRolesMembers(ctx context.Context, a *app, args []string) error {
+	positional, err := parseArgs(a.flagSet("roles members"), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a role id")
+	}
+
+	var members []client.RoleMember
+	if a.direct {
+		roleID, err := uuid.Parse(positional[0])
+		if err != nil {
+			return fmt.Errorf("invalid role id %q", positional[0])
+		}
+		repo, err := a.roleRepository(ctx)
+		if err != nil {
+			return err
+		}
+		for offset := int32(0); ; {
+			page, total, err := repo.ListAssignments(ctx, roleID, directPageSize, offset)
+			if err != nil {
+				return fmt.Errorf("list role assignments: %w", err)
+			}
+			for _, assignment := range page {
+				member := client.RoleMember{
+					IdentityID: assignment.IdentityID.String(),
+					AssignedAt: assignment.CreatedAt,
+				}
+				if assignment.TenantID != nil {
+					tenant := assignment.TenantID.String()
+					member.TenantID = &tenant
+				}
+				members = append(members, member)
+			}
+			offset += int32(len(page))
+			if len(page) == 0 || int64(offset) >= total {
+				break
+			}
+		}
+	} else {
+		api, err := a.client()
+		if err != nil {
+			return err
+		}
+		for member, err := range api.AllRoleMembers(ctx, positional[0], client.PageParams{}) {
+			if err != nil {
+				return err
+			}
+			members = append(members, member)
+		}
+	}
+
+	return printList(a, members, []column[client.RoleMember]{
+		{"IDENTITY", func(m client.RoleMember) string { return m.IdentityID }},
+		{"TENANT", func(m client.RoleMember) string { return deref(m.TenantID) }},
+		{"ASSIGNED", func(m client.RoleMember) string { return formatTime(m.AssignedAt) }},
+	})
+}
+
+func runRolesAssign(ctx context.Context, a *app, args []string) error {
+	positional, err := parseArgs(a.flagSet("roles assign"), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return usageError("expected a role id and at least one identity id")
+	}
+	roleID, identities := positional[0], positional[1:]
+
+	if !a.direct {
+		api, err := a.client()
+		if err != nil {
+			return err
+		}
+		assigned, err := api.AssignRole(ctx, roleID, identities)
+		if err != nil {
+			return err
+		}
+		return a.printMessage("assigned role %s to %d identities", roleID, assigned)
+	}
+
+	role, err := a.directRole(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	repo, err := a.roleRepository(ctx)
+	if err != nil {
+		return err
+	}
+	ketoClient, err := a.ketoClient()
+	if err != nil {
+		return err
+	}
+
+	ctx = a.directContext(ctx)
+	for _, raw := range identities {
+		identityID, err := uuid.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid identity id %q", raw)
+		}
+		// Same order as the API: the database row first, undone if Keto rejects the tuple.
+		if err := repo.UpsertAssignment(ctx, role.ID, identityID, role.TenantID); err != nil {
+			return fmt.Errorf("assign role to %s: %w", identityID, err)
+		}
+		if err := ketoClient.AssignRole(ctx, roleTenant(role), role.Code, identityID.String()); err != nil {
+			if rollbackErr := repo.DeleteAssignment(ctx, role.ID, identityID); rollbackErr != nil {
+				err = errors.Join(err, fmt.Errorf("rollback assignment: %w", rollbackErr))
+			}
+			return fmt.Errorf("assign role to %s in keto: %w", identityID, err)
+		}
+	}
+	return a.printMessage("assigned role %s to %d identities", role.Code, len(identities))
+}
+
+func runRolesUnassign(ctx context.Context, a *app, args []string) error {
+	positional, err := parseArgs(a.flagSet("roles unassign"), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageError("expected a role id and an identity id")
+	}
+
+	if !a.direct {
+		api, err := a.client()
+		if err != nil {
+			return err
+		}
+		if err := api.UnassignRole(ctx, positional[0], positional[1]); err != nil {
+			return err
+		}
+		return a.printMessage("removed role %s from %s", positional[0], positional[1])
+	}
+
+	role, err := a.directRole(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	identityID, err := uuid.Parse(positional[1])
+	if err != nil {
+		return fmt.Errorf("invalid identity id %q", positional[1])
+	}
+	repo, err := a.roleRepository(ctx)
+	if err != nil {
+		return err
+	}
+	ketoClient, err := a.ketoClient()
+	if err != nil {
+		return err
+	}
+
+	ctx = a.directContext(ctx)
+	if err := repo.DeleteAssignment(ctx, role.ID, identityID); err != nil {
+		return fmt.Errorf("remove role assignment: %w", err)
+	}
+	if err := ketoClient.RemoveRole(ctx, roleTenant(role), role.Code, identityID.String()); err != nil {
+		if rollbackErr := repo.UpsertAssignment(ctx, role.ID, identityID, role.TenantID); rollbackErr != nil {
+			err = errors.Join(err, fmt.Errorf("rollback assignment: %w", rollbackErr))
+		}
+		return fmt.Errorf("remove role in keto: %w", err)
+	}
+	return a.printMessage("removed role %s from %s", role.Code, identityID)
+}
+
+func runPermissionsList(ctx context.Context, a *app, args []string) error {
+	if _, err := parseArgs(a.flagSet("permissions list"), args); err != nil {
+		return err
+	}
+
+	var permissions []client.Permission
+	if a.direct {
+		_, queries, err := a.database(ctx)
+		if err != nil {
+			return err
+		}
+		rows, err := storage.NewPermissionRepository(queries).ListPermissions(ctx)
+		if err != nil {
+			return fmt.Errorf("list permissions: %w", err)
+		}
+		for _, row := range rows {
+			permissions = append(permissions, client.Permission{
+				Code:        row.Code,
+				Scope:       row.Scope,
+				Description: row.Description,
+			})
+		}
+	} else {
+		api, err := a.client()
+		if err != nil {
+			return err
+		}
+		if permissions, err = api.ListPermissions(ctx); err != nil {
+			return err
+		}
+	}
+
+	return printList(a, permissions, []column[client.Permission]{
+		{"CODE", func(p client.Permission) string { return p.Code }},
+		{"SCOPE", func(p client.Permission) string { return p.Scope }},
+		{"DESCRIPTION", func(p client.Permission) string { return p.Description }},
+	})
+}
+
+// findRole looks a role up by ID. The API has no endpoint for a single role, so this
+// walks the listing.
+func findRole(ctx context.Context, api *client.Client, id, tenantID string) (*client.Role, error) {
+	params := client.RoleListParams{TenantID: tenantID, IncludePermissions: true}
+	for role, err := range api.AllRoles(ctx, params) {
+		if err != nil {
+			return nil, err
+		}
+		if role.ID == id {
+			return &role, nil
+		}
+	}
+	return nil, fmt.Errorf("role %s not found", id)
+}
+
+func (a *app) roleRepository(ctx context.Context) (*storage.RoleRepository, error) {
+	pool, queries, err := a.database(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewRoleRepository(pool, queries), nil
+}
+
+func (a *app) directRole(ctx context.Context, rawID string) (storage.Role, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return storage.Role{}, fmt.Errorf("invalid role id %q", rawID)
+	}
+	repo, err := a.roleRepository(ctx)
+	if err != nil {
+		return storage.Role{}, err
+	}
+	role, err := repo.GetRole(ctx, id)
+	if err != nil {
+		return storage.Role{}, fmt.Errorf("get role: %w", err)
+	}
+	return role, nil
+}
+
+// roleTenant is the Keto scope of a role: its tenant, or empty for global roles.
+func roleTenant(role storage.Role) string {
+	if role.TenantID == nil {
+		return ""
+	}
+	return role.TenantID.String()
+}
+
+func roleFromStorage(role storage.Role) client.Role {
+	result := client.Role{
+		ID:            role.ID.String(),
+		Scope:         role.Scope,
+		Code:          role.Code,
+		Name:          role.Name,
+		Description:   role.Description,
+		Metadata:      decodeMetadata(role.Metadata),
+		Permissions:   role.Permissions,
+		AssignedCount: role.AssignedCount,
+		CreatedAt:     role.CreatedAt,
+		UpdatedAt:     role.UpdatedAt,
+		Version:       role.Version,
+	}
+	if role.TenantID != nil {
+		tenant := role.TenantID.String()
+		result.TenantID = &tenant
+	}
+	return result
+}
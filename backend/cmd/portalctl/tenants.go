@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/client"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+var tenantCommands = map[string]command{
+	"list": {
+		usage:   "[-search TEXT] [-status active|inactive] [-limit N]",
+		summary: "list tenants",
+		run:     runTenantsList,
+	},
+	"get": {
+		usage:   "TENANT_ID",
+		summary: "show a tenant",
+		run:     runTenantsGet,
+	},
+	"create": {
+		usage:   "-code CODE -name NAME [-status STATUS] [-contact-name NAME] [-contact-phone PHONE]",
+		summary: "create a tenant",
+		run:     runTenantsCreate,
+	},
+	"update": {
+		usage:   "TENANT_ID [-name NAME] [-status STATUS] [-contact-name NAME] [-contact-phone PHONE]",
+		summary: "change the given fields of a tenant",
+		run:     runTenantsUpdate,
+	},
+	"delete": {
+		usage:   "TENANT_ID",
+		summary: "delete a tenant",
+		run:     runTenantsDelete,
+	},
+}
+
+var tenantColumns = []column[client.Tenant]{
+	{"ID", func(t client.Tenant) string { return t.ID }},
+	{"CODE", func(t client.Tenant) string { return t.Code }},
+	{"NAME", func(t client.Tenant) string { return t.Name }},
+	{"STATUS", func(t client.Tenant) string { return t.Status }},
+	{"CONTACT", func(t client.Tenant) string { return deref(t.ContactName) }},
+	{"PHONE", func(t client.Tenant) string { return deref(t.ContactPhone) }},
+	{"UPDATED", func(t client.Tenant) string { return formatTime(t.UpdatedAt) }},
+}
+
+// directPageSize is the batch size for listings read straight from the database.
+const directPageSize = 200
+
+func runTenantsList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("tenants list")
+	search := fs.String("search", "", "match code or name")
+	status := fs.String("status", "", "active or inactive")
+	limit := fs.Int("limit", 0, "stop after N tenants (0 lists all)")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	var tenants []client.Tenant
+	if a.direct {
+		pool, queries, err := a.database(ctx)
+		if err != nil {
+			return err
+		}
+		repo := storage.NewTenantRepository(pool, queries)
+		for offset := 0; ; offset += directPageSize {
+			page, total, err := repo.ListTenants(ctx, *search, *status, directPageSize, int32(offset))
+			if err != nil {
+				return fmt.Errorf("list tenants: %w", err)
+			}
+			for _, tenant := range page {
+				tenants = append(tenants, tenantFromStorage(tenant))
+			}
+			if int64(offset+len(page)) >= total || len(page) == 0 || (*limit > 0 && len(tenants) >= *limit) {
+				break
+			}
+		}
+		if *limit > 0 && len(tenants) > *limit {
+			tenants = tenants[:*limit]
+		}
+		return printList(a, tenants, tenantColumns)
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	for tenant, err := range api.AllTenants(ctx, client.TenantListParams{Search: *search, Status: *status}) {
+		if err != nil {
+			return err
+		}
+		tenants = append(tenants, tenant)
+		if *limit > 0 && len(tenants) >= *limit {
+			break
+		}
+	}
+	return printList(a, tenants, tenantColumns)
+}
+
+func runTenantsGet(ctx context.Context, a *app, args []string) error {
+	positional, err := parseArgs(a.flagSet("tenants get"), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a tenant id")
+	}
+
+	tenant, err := a.getTenant(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return printItem(a, *tenant, tenantColumns)
+}
+
+func runTenantsCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("tenants create")
+	code := fs.String("code", "", "tenant code, 1-10 letters or digits")
+	name := fs.String("name", "", "display name")
+	status := fs.String("status", client.TenantActive, "active or inactive")
+	var contactName, contactPhone optionalString
+	fs.Var(&contactName, "contact-name", "contact person")
+	fs.Var(&contactPhone, "contact-phone", "contact phone")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || *code == "" || *name == "" {
+		return usageError("-code and -name are required")
+	}
+
+	var tenant client.Tenant
+	if a.direct {
+		pool, queries, err := a.database(ctx)
+		if err != nil {
+			return err
+		}
+		created, err := storage.NewTenantRepository(pool, queries).CreateTenant(a.directContext(ctx), storage.Tenant{
+			Code:         *code,
+			Name:         *name,
+			Status:       *status,
+			ContactName:  contactName.ptr(),
+			ContactPhone: contactPhone.ptr(),
+		})
+		if err != nil {
+			return fmt.Errorf("create tenant: %w", err)
+		}
+		tenant = tenantFromStorage(created)
+	} else {
+		api, err := a.client()
+		if err != nil {
+			return err
+		}
+		created, err := api.CreateTenant(ctx, client.TenantInput{
+			Code:         *code,
+			Name:         *name,
+			Status:       *status,
+			ContactName:  contactName.ptr(),
+			ContactPhone: contactPhone.ptr(),
+		})
+		if err != nil {
+			return err
+		}
+		tenant = *created
+	}
+	return printItem(a, tenant, tenantColumns)
+}
+
+func runTenantsUpdate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("tenants update")
+	var name, status, contactName, contactPhone optionalString
+	fs.Var(&name, "name", "display name")
+	fs.Var(&status, "status", "active or inactive")
+	fs.Var(&contactName, "contact-name", "contact person")
+	fs.Var(&contactPhone, "contact-phone", "contact phone")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a tenant id")
+	}
+
+	if a.direct {
+		id, err := uuid.Parse(positional[0])
+		if err != nil {
+			return fmt.Errorf("invalid tenant id %q", positional[0])
+		}
+		pool, queries, err := a.database(ctx)
+		if err != nil {
+			return err
+		}
+		repo := storage.NewTenantRepository(pool, queries)
+		current, err := repo.GetTenant(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get tenant: %w", err)
+		}
+		if name.set {
+			current.Name = name.value
+		}
+		if status.set {
+			current.Status = status.value
+		}
+		if contactName.set {
+			current.ContactName = contactName.ptr()
+		}
+		if contactPhone.set {
+			current.ContactPhone = contactPhone.ptr()
+		}
+		version := current.Version
+		updated, err := repo.UpdateTenant(a.directContext(ctx), current, &version)
+		if err != nil {
+			return fmt.Errorf("update tenant: %w", err)
+		}
+		return printItem(a, tenantFromStorage(updated), tenantColumns)
+	}
+
+	api, err := a.client()
+	if err != nil {
+		return err
+	}
+	current, err := api.GetTenant(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	input := client.TenantInput{
+		Code:         current.Code,
+		Name:         current.Name,
+		Status:       current.Status,
+		ContactName:  current.ContactName,
+		ContactPhone: current.ContactPhone,
+		Metadata:     current.Metadata,
+	}
+	if name.set {
+		input.Name = name.value
+	}
+	if status.set {
+		input.Status = status.value
+	}
+	if contactName.set {
+		input.ContactName = contactName.ptr()
+	}
+	if contactPhone.set {
+		input.ContactPhone = contactPhone.ptr()
+	}
+	updated, err := api.UpdateTenant(ctx, current.ID, input, client.WithIfMatch(current.ETag))
+	if err != nil {
+		return err
+	}
+	return printItem(a, *updated, tenantColumns)
+}
+
+func runTenantsDelete(ctx context.Context, a *app, args []string) error {
+	positional, err := parseArgs(a.flagSet("tenants delete"), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected a tenant id")
+	}
+
+	if a.direct {
+		id, err := uuid.Parse(positional[0])
+		if err != nil {
+			return fmt.Errorf("invalid tenant id %q", positional[0])
+		}
+		pool, queries, err := a.database(ctx)
+		if err != nil {
+			return err
+		}
+		if err := storage.NewTenantRepository(pool, queries).DeleteTenant(a.directContext(ctx), id, nil); err != nil {
+			return fmt.Errorf("delete tenant: %w", err)
+		}
+	} else {
+		api, err := a.client()
+		if err != nil {
+			return err
+		}
+		if err := api.DeleteTenant(ctx, positional[0]); err != nil {
+			return err
+		}
+	}
+	return a.printMessage("deleted tenant %s", positional[0])
+}
+
+func (a *app) getTenant(ctx context.Context, rawID string) (*client.Tenant, error) {
+	if !a.direct {
+		api, err := a.client()
+		if err != nil {
+			return nil, err
+		}
+		return api.GetTenant(ctx, rawID)
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant id %q", rawID)
+	}
+	pool, queries, err := a.database(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := storage.NewTenantRepository(pool, queries).GetTenant(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+	result := tenantFromStorage(tenant)
+	return &result, nil
+}
+
+// tenantFromStorage presents a database row like the API would, so both modes print
+// the same shape.
+func tenantFromStorage(tenant storage.Tenant) client.Tenant {
+	return client.Tenant{
+		ID:           tenant.ID.String(),
+		Code:         tenant.Code,
+		Name:         tenant.Name,
+		Status:       tenant.Status,
+		ContactName:  tenant.ContactName,
+		ContactPhone: tenant.ContactPhone,
+		Metadata:     decodeMetadata(tenant.Metadata),
+		CreatedAt:    tenant.CreatedAt,
+		UpdatedAt:    tenant.UpdatedAt,
+		Version:      tenant.Version,
+	}
+}
+
+func decodeMetadata(raw json.RawMessage) map[string]any {
+	metadata := map[string]any{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &metadata)
+	}
+	return metadata
+}
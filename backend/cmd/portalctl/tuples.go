@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/keto"
+)
+
+// Tuple commands always talk to Keto directly; the API has no endpoints for raw tuples.
+// A subject is an identity ID or a subject set written as Namespace:object#relation.
+var tupleCommands = map[string]command{
+	"list": {
+		usage:   "[-namespace NS] [-object OBJECT] [-relation RELATION] [-subject SUBJECT]",
+		summary: "list relation tuples",
+		run:     runTuplesList,
+	},
+	"create": {
+		usage:   "NAMESPACE OBJECT RELATION SUBJECT",
+		summary: "write a relation tuple",
+		run:     runTuplesCreate,
+	},
+	"delete": {
+		usage:   "NAMESPACE OBJECT RELATION SUBJECT",
+		summary: "delete a relation tuple",
+		run:     runTuplesDelete,
+	},
+	"check": {
+		usage:   "NAMESPACE OBJECT RELATION SUBJECT_ID",
+		summary: "check whether a subject holds a relation, following subject sets",
+		run:     runTuplesCheck,
+	},
+}
+
+type tupleRow struct {
+	Namespace string `json:"namespace"`
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+	Subject   string `json:"subject"`
+}
+
+func runTuplesList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("tuples list")
+	var filter keto.RelationTuple
+	fs.StringVar(&filter.Namespace, "namespace", "", "namespace, e.g. Tenant or Group")
+	fs.StringVar(&filter.Object, "object", "", "object")
+	fs.StringVar(&filter.Relation, "relation", "", "relation")
+	subject := fs.String("subject", "", "identity ID or Namespace:object#relation")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *subject != "" {
+		parsed, err := parseSubject(*subject)
+		if err != nil {
+			return err
+		}
+		filter.SubjectID, filter.SubjectSet = parsed.SubjectID, parsed.SubjectSet
+	}
+
+	ketoClient, err := a.ketoClient()
+	if err != nil {
+		return err
+	}
+
+	var rows []tupleRow
+	for pageToken := ""; ; {
+		tuples, next, err := ketoClient.ListRelationTuples(ctx, filter, pageToken)
+		if err != nil {
+			return err
+		}
+		for _, tuple := range tuples {
+			rows = append(rows, tupleRow{
+				Namespace: tuple.Namespace,
+				Object:    tuple.Object,
+				Relation:  tuple.Relation,
+				Subject:   formatSubject(tuple),
+			})
+		}
+		if next == "" {
+			break
+		}
+		pageToken = next
+	}
+
+	return printList(a, rows, []column[tupleRow]{
+		{"NAMESPACE", func(t tupleRow) string { return t.Namespace }},
+		{"OBJECT", func(t tupleRow) string { return t.Object }},
+		{"RELATION", func(t tupleRow) string { return t.Relation }},
+		{"SUBJECT", func(t tupleRow) string { return t.Subject }},
+	})
+}
+
+func runTuplesCreate(ctx context.Context, a *app, args []string) error {
+	tuple, err := parseTupleArgs(a, "tuples create", args)
+	if err != nil {
+		return err
+	}
+	ketoClient, err := a.ketoClient()
+	if err != nil {
+		return err
+	}
+	if err := ketoClient.WriteRelationTuple(ctx, tuple); err != nil {
+		return err
+	}
+	return a.printMessage("wrote %s", formatTuple(tuple))
+}
+
+func runTuplesDelete(ctx context.Context, a *app, args []string) error {
+	tuple, err := parseTupleArgs(a, "tuples delete", args)
+	if err != nil {
+		return err
+	}
+	ketoClient, err := a.ketoClient()
+	if err != nil {
+		return err
+	}
+	if err := ketoClient.DeleteRelationTuples(ctx, tuple); err != nil {
+		return err
+	}
+	return a.printMessage("deleted %s", formatTuple(tuple))
+}
+
+func runTuplesCheck(ctx context.Context, a *app, args []string) error {
+	positional, err := parseArgs(a.flagSet("tuples check"), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 4 {
+		return usageError("expected namespace, object, relation and subject id")
+	}
+	ketoClient, err := a.ketoClient()
+	if err != nil {
+		return err
+	}
+
+	allowed, err := ketoClient.Check(ctx, positional[0], positional[1], positional[2], positional[3])
+	if err != nil {
+		return err
+	}
+	if a.output == outputJSON {
+		return a.printJSON(map[string]bool{"allowed": allowed})
+	}
+	return a.printMessage("%t", allowed)
+}
+
+// parseTupleArgs reads a complete tuple from the positional arguments. Every part is
+// required so a typo cannot turn a delete into a wildcard.
+func parseTupleArgs(a *app, name string, args []string) (keto.RelationTuple, error) {
+	positional, err := parseArgs(a.flagSet(name), args)
+	if err != nil {
+		return keto.RelationTuple{}, err
+	}
+	if len(positional) != 4 {
+		return keto.RelationTuple{}, usageError("expected namespace, object, relation and subject")
+	}
+	for _, part := range positional {
+		if strings.TrimSpace(part) == "" {
+			return keto.RelationTuple{}, usageError("tuple parts must not be empty")
+		}
+	}
+
+	tuple, err := parseSubject(positional[3])
+	if err != nil {
+		return keto.RelationTuple{}, err
+	}
+	tuple.Namespace = positional[0]
+	tuple.Object = positional[1]
+	tuple.Relation = positional[2]
+	return tuple, nil
+}
+
+// parseSubject returns a tuple holding only the subject of value.
+func parseSubject(value string) (keto.RelationTuple, error) {
+	hash := strings.LastIndex(value, "#")
+	if hash < 0 {
+		return keto.RelationTuple{SubjectID: value}, nil
+	}
+	namespace, object, ok := strings.Cut(value[:hash], ":")
+	if !ok || namespace == "" || object == "" {
+		return keto.RelationTuple{}, fmt.Errorf("subject set %q must look like Namespace:object#relation", value)
+	}
+	return keto.RelationTuple{SubjectSet: &keto.SubjectSet{
+		Namespace: namespace,
+		Object:    object,
+		Relation:  value[hash+1:],
+	}}, nil
+}
+
+func formatSubject(tuple keto.RelationTuple) string {
+	if tuple.SubjectSet == nil {
+		return tuple.SubjectID
+	}
+	set := tuple.SubjectSet
+	return set.Namespace + ":" + set.Object + "#" + set.Relation
+}
+
+func formatTuple(tuple keto.RelationTuple) string {
+	return fmt.Sprintf("%s:%s#%s@%s", tuple.Namespace, tuple.Object, tuple.Relation, formatSubject(tuple))
+}
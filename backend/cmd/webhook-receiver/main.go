@@ -0,0 +1,66 @@
+// Command webhook-receiver is a small HTTP endpoint for testing portal webhooks locally.
+// It verifies the signature of every delivery and prints the event to stdout.
+//
+//	go run ./cmd/webhook-receiver -addr :9090 -secret whsec_...
+//
+// Use -status to force a response code, e.g. -status 500 to exercise retries.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/webhook"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "subscription signing secret (defaults to $WEBHOOK_SECRET)")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "maximum accepted clock skew of the timestamp header")
+	status := flag.Int("status", http.StatusNoContent, "status code returned for valid deliveries")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "read body", http.StatusBadRequest)
+			return
+		}
+
+		if *secret != "" {
+			if err := webhook.Verify(*secret, r.Header.Get(webhook.HeaderSignature), r.Header.Get(webhook.HeaderTimestamp), body, *tolerance); err != nil {
+				log.Printf("rejected delivery %s: %v", r.Header.Get(webhook.HeaderDelivery), err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Write(body)
+		}
+		log.Printf("event=%s delivery=%s verified=%t\n%s",
+			r.Header.Get(webhook.HeaderEvent),
+			r.Header.Get(webhook.HeaderDelivery),
+			*secret != "",
+			pretty.String(),
+		)
+		w.WriteHeader(*status)
+	})
+
+	log.Printf("webhook receiver listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -22,6 +22,19 @@ const (
 type Config struct {
 	Server struct {
 		Address string `koanf:"address"`
+		// RequireIfMatch rejects updates and deletions of tenants, roles and groups that
+		// carry no If-Match header.
+		RequireIfMatch bool `koanf:"require_if_match"`
+		// Timeouts of the HTTP server; zero leaves the net/http default of no limit.
+		ReadHeaderTimeout time.Duration `koanf:"read_header_timeout"`
+		ReadTimeout       time.Duration `koanf:"read_timeout"`
+		WriteTimeout      time.Duration `koanf:"write_timeout"`
+		IdleTimeout       time.Duration `koanf:"idle_timeout"`
+		// ShutdownTimeout bounds how long in-flight requests may take to finish after
+		// SIGTERM before connections are closed.
+		ShutdownTimeout time.Duration `koanf:"shutdown_timeout"`
+		// HealthTimeout bounds each dependency check of the readiness probe.
+		HealthTimeout time.Duration `koanf:"health_timeout"`
 	} `koanf:"server"`
 
 	Platform struct {
@@ -41,11 +54,58 @@ type Config struct {
 		TenantHeader   string `koanf:"tenant_header"`
 	} `koanf:"oathkeeper"`
 
+	Impersonation struct {
+		Header      string        `koanf:"header"`
+		MaxDuration time.Duration `koanf:"max_duration"`
+	} `koanf:"impersonation"`
+
+	Webhooks struct {
+		Enabled      bool          `koanf:"enabled"`
+		PollInterval time.Duration `koanf:"poll_interval"`
+		BatchSize    int           `koanf:"batch_size"`
+		MaxAttempts  int           `koanf:"max_attempts"`
+		Timeout      time.Duration `koanf:"timeout"`
+		// AllowPrivateEndpoints accepts subscriber URLs on loopback, private and
+		// link-local addresses. Only meant for local development.
+		AllowPrivateEndpoints bool `koanf:"allow_private_endpoints"`
+	} `koanf:"webhooks"`
+
+	Imports struct {
+		Enabled      bool          `koanf:"enabled"`
+		PollInterval time.Duration `koanf:"poll_interval"`
+		MaxRows      int           `koanf:"max_rows"`
+	} `koanf:"imports"`
+
+	SMS struct {
+		Endpoint string        `koanf:"endpoint"`
+		Timeout  time.Duration `koanf:"timeout"`
+	} `koanf:"sms"`
+
+	Invitations struct {
+		TTL time.Duration `koanf:"ttl"`
+	} `koanf:"invitations"`
+
+	OpenAPI struct {
+		// ValidateRequests rejects requests that do not match the OpenAPI document.
+		ValidateRequests bool `koanf:"validate_requests"`
+		// ValidateResponses logs responses that do not match the OpenAPI document. It
+		// buffers every response and is meant for development.
+		ValidateResponses bool `koanf:"validate_responses"`
+	} `koanf:"openapi"`
+
+	Idempotency struct {
+		TTL         time.Duration `koanf:"ttl"`
+		LockTimeout time.Duration `koanf:"lock_timeout"`
+	} `koanf:"idempotency"`
+
 	Database struct {
 		DSN             string        `koanf:"dsn"`
 		MaxOpenConns    int           `koanf:"max_open_conns"`
 		MaxIdleConns    int           `koanf:"max_idle_conns"`
 		ConnMaxLifetime time.Duration `koanf:"conn_max_lifetime"`
+		// MigrateOnStartup applies pending migrations before serving. Replicas take an
+		// advisory lock, so only one of them migrates at a time.
+		MigrateOnStartup bool `koanf:"migrate_on_startup"`
 	} `koanf:"database"`
 
 	Keto struct {
@@ -55,6 +115,9 @@ type Config struct {
 		PermissionRelation string        `koanf:"permission_relation"`
 		MembershipRelation string        `koanf:"membership_relation"`
 		RequestTimeout     time.Duration `koanf:"request_timeout"`
+		// SyncInterval is how often the Keto sync queue is polled for changes that
+		// still have to be applied.
+		SyncInterval time.Duration `koanf:"sync_interval"`
 	} `koanf:"keto"`
 
 	Kratos struct {
@@ -79,6 +142,14 @@ func Load(paths ...string) (*Config, error) {
 		return nil, fmt.Errorf("load config file: %w", err)
 	}
 
+	// An overlay file, such as configs/app.dev.yaml, overrides individual keys of the
+	// main file.
+	if overlay := os.Getenv(envPrefix + envDelimiter + "CONFIG" + envDelimiter + "OVERLAY"); overlay != "" {
+		if err := k.Load(file.Provider(overlay), yaml.Parser()); err != nil {
+			return nil, fmt.Errorf("load config overlay: %w", err)
+		}
+	}
+
 	envProvider := env.Provider(envPrefix, envDelimiter, func(s string) string {
 		path := strings.TrimPrefix(s, envPrefix+envDelimiter)
 		path = strings.ReplaceAll(path, envDelimiter, ".")
@@ -0,0 +1,364 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/keto"
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultMaxRows      = 5000
+	batchSize           = 50
+	leaseDuration       = 2 * time.Minute
+	maxDisplayName      = 32
+	maxTenantRoles      = 1000
+)
+
+var phonePattern = regexp.MustCompile(`^\+[0-9]{8,15}$`)
+
+// Options configures the importer.
+type Options struct {
+	PollInterval time.Duration
+	MaxRows      int
+}
+
+// Importer validates uploaded member files and processes committed imports in the
+// background, creating identities, memberships and role assignments row by row.
+type Importer struct {
+	imports *storage.MemberImportRepository
+	groups  *storage.GroupRepository
+	roles   *storage.RoleRepository
+	kratos  *kratos.Client
+	keto    *keto.Client
+	logger  *zap.Logger
+	opts    Options
+}
+
+// New constructs an importer, filling unset options with defaults.
+func New(imports *storage.MemberImportRepository, groups *storage.GroupRepository, roles *storage.RoleRepository, kratosClient *kratos.Client, ketoClient *keto.Client, opts Options, logger *zap.Logger) *Importer {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = defaultMaxRows
+	}
+
+	return &Importer{
+		imports: imports,
+		groups:  groups,
+		roles:   roles,
+		kratos:  kratosClient,
+		keto:    ketoClient,
+		logger:  logger.Named("importer"),
+		opts:    opts,
+	}
+}
+
+// MaxRows reports the maximum number of member rows accepted per file.
+func (i *Importer) MaxRows() int {
+	return i.opts.MaxRows
+}
+
+// Preview validates rows against the tenant directory and stores them as a previewed
+// import. Nothing is provisioned until the import is committed.
+func (i *Importer) Preview(ctx context.Context, tenantID uuid.UUID, filename string, rows []Row, createdBy *string) (storage.MemberImport, error) {
+	groups, err := i.tenantGroups(ctx, tenantID)
+	if err != nil {
+		return storage.MemberImport{}, err
+	}
+	roles, err := i.tenantRoles(ctx, tenantID)
+	if err != nil {
+		return storage.MemberImport{}, err
+	}
+
+	phones := make(map[string]int32, len(rows))
+	result := make([]storage.MemberImportRow, 0, len(rows))
+	for _, row := range rows {
+		var problems []string
+
+		displayName := strings.TrimSpace(row.DisplayName)
+		switch {
+		case displayName == "":
+			problems = append(problems, "name is required")
+		case len([]rune(displayName)) > maxDisplayName:
+			problems = append(problems, fmt.Sprintf("name must be at most %d characters", maxDisplayName))
+		}
+
+		phone := kratos.NormalizePhone(row.Phone)
+		switch {
+		case phone == "":
+			problems = append(problems, "phone is required")
+		case !phonePattern.MatchString(phone):
+			problems = append(problems, "phone is not a valid number")
+		default:
+			if first, dup := phones[phone]; dup {
+				problems = append(problems, fmt.Sprintf("phone duplicates row %d", first))
+				break
+			}
+			phones[phone] = row.Number
+
+			existing, err := i.kratos.FindIdentityByIdentifier(ctx, phone)
+			if err != nil {
+				return storage.MemberImport{}, fmt.Errorf("check phone of row %d: %w", row.Number, err)
+			}
+			if existing != nil {
+				problems = append(problems, "phone is already registered")
+			}
+		}
+
+		groupCode := strings.TrimSpace(row.GroupCode)
+		if groupCode == "" {
+			problems = append(problems, "group code is required")
+		} else if _, ok := groups[groupCode]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown group code %s", groupCode))
+		}
+
+		for _, code := range row.Roles {
+			if _, ok := roles[code]; !ok {
+				problems = append(problems, fmt.Sprintf("unknown role %s", code))
+			}
+		}
+
+		status := storage.ImportRowPending
+		if len(problems) > 0 {
+			status = storage.ImportRowInvalid
+		}
+
+		var title *string
+		if trimmed := strings.TrimSpace(row.Title); trimmed != "" {
+			title = &trimmed
+		}
+
+		result = append(result, storage.MemberImportRow{
+			RowNumber:   row.Number,
+			DisplayName: displayName,
+			Phone:       phone,
+			GroupCode:   groupCode,
+			Title:       title,
+			Roles:       row.Roles,
+			Status:      status,
+			Errors:      problems,
+		})
+	}
+
+	return i.imports.CreateImport(ctx, storage.MemberImport{
+		TenantID:  tenantID,
+		Filename:  filename,
+		CreatedBy: createdBy,
+	}, result)
+}
+
+// Run processes committed imports until ctx is cancelled.
+func (i *Importer) Run(ctx context.Context) {
+	ticker := time.NewTicker(i.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		i.processNext(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *Importer) processNext(ctx context.Context) {
+	memberImport, err := i.imports.ClaimImport(ctx, leaseDuration)
+	if err != nil {
+		if ctx.Err() == nil {
+			i.logger.Error("claim member import failed", zap.Error(err))
+		}
+		return
+	}
+	if memberImport == nil {
+		return
+	}
+
+	logger := i.logger.With(zap.String("import", memberImport.ID.String()))
+	if err := i.process(ctx, *memberImport, logger); err != nil {
+		// The lease expires on its own, after which the import is claimed again and
+		// continues with the rows that are still pending.
+		if ctx.Err() == nil {
+			logger.Error("process member import failed", zap.Error(err))
+		}
+	}
+}
+
+func (i *Importer) process(ctx context.Context, memberImport storage.MemberImport, logger *zap.Logger) error {
+	// Attribute audit entries and events to the administrator who committed the import.
+	if memberImport.CommittedBy != nil {
+		ctx = storage.ContextWithActor(ctx, storage.Actor{Subject: *memberImport.CommittedBy})
+	}
+
+	groups, err := i.tenantGroups(ctx, memberImport.TenantID)
+	if err != nil {
+		return err
+	}
+	roles, err := i.tenantRoles(ctx, memberImport.TenantID)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rows, err := i.imports.PendingRows(ctx, memberImport.ID, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			status := storage.ImportRowSucceeded
+			var rowErrors []string
+			identityID, err := i.processRow(ctx, memberImport.TenantID, row, groups, roles)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				logger.Warn("member import row failed", zap.Int32("row", row.RowNumber), zap.Error(err))
+				status = storage.ImportRowFailed
+				rowErrors = []string{err.Error()}
+			}
+			if err := i.imports.CompleteRow(ctx, memberImport.ID, row.RowNumber, status, rowErrors, identityID); err != nil {
+				return err
+			}
+		}
+
+		if err := i.imports.ExtendLease(ctx, memberImport.ID, leaseDuration); err != nil {
+			return err
+		}
+	}
+
+	current, err := i.imports.GetImport(ctx, memberImport.ID)
+	if err != nil {
+		return err
+	}
+	status := storage.ImportCompleted
+	if current.Counts.Failed > 0 {
+		status = storage.ImportFailed
+	}
+	if err := i.imports.FinishImport(ctx, memberImport.ID, status); err != nil {
+		return err
+	}
+	logger.Info("member import finished", zap.String("status", status),
+		zap.Int64("succeeded", current.Counts.Succeeded), zap.Int64("failed", current.Counts.Failed))
+	return nil
+}
+
+// processRow provisions one member. It returns the identity ID whenever an identity
+// exists for the row, so a resumed import reuses it instead of reporting a conflict.
+func (i *Importer) processRow(ctx context.Context, tenantID uuid.UUID, row storage.MemberImportRow, groups map[string]storage.Group, roles map[string]storage.Role) (*uuid.UUID, error) {
+	identityID := row.IdentityID
+
+	group, ok := groups[row.GroupCode]
+	if !ok {
+		return identityID, fmt.Errorf("unknown group code %s", row.GroupCode)
+	}
+
+	existing, err := i.kratos.FindIdentityByIdentifier(ctx, row.Phone)
+	if err != nil {
+		return identityID, fmt.Errorf("check phone: %w", err)
+	}
+	switch {
+	case existing == nil:
+		identity, err := i.kratos.CreateIdentity(ctx, kratos.CreateIdentityInput{
+			Phone:    row.Phone,
+			Nickname: row.DisplayName,
+			UserType: "internal",
+			TenantID: tenantID.String(),
+			Roles:    []string{},
+		})
+		if err != nil {
+			return identityID, fmt.Errorf("create identity: %w", err)
+		}
+		created, err := uuid.Parse(identity.ID)
+		if err != nil {
+			return identityID, fmt.Errorf("parse identity id: %w", err)
+		}
+		identityID = &created
+	case identityID == nil || existing.ID != identityID.String():
+		return identityID, fmt.Errorf("phone is already registered")
+	}
+
+	memberships, err := i.groups.ListGroupsForIdentity(ctx, tenantID, *identityID)
+	if err != nil {
+		return identityID, err
+	}
+	isMember := false
+	for _, membership := range memberships {
+		if membership.GroupID == group.ID {
+			isMember = true
+			break
+		}
+	}
+	if !isMember {
+		if _, err := i.groups.CreateMember(ctx, storage.GroupMember{
+			GroupID:     group.ID,
+			IdentityID:  *identityID,
+			TenantID:    tenantID,
+			DisplayName: row.DisplayName,
+			Phone:       row.Phone,
+			Title:       row.Title,
+		}); err != nil {
+			return identityID, fmt.Errorf("add member: %w", err)
+		}
+	}
+	if err := i.keto.AssignGroupMember(ctx, tenantID.String(), group.ID.String(), identityID.String()); err != nil {
+		return identityID, fmt.Errorf("assign group membership: %w", err)
+	}
+
+	for _, code := range row.Roles {
+		role, ok := roles[code]
+		if !ok {
+			return identityID, fmt.Errorf("unknown role %s", code)
+		}
+		if err := i.roles.UpsertAssignment(ctx, role.ID, *identityID, role.TenantID); err != nil {
+			return identityID, fmt.Errorf("assign role %s: %w", code, err)
+		}
+		if err := i.keto.AssignRole(ctx, tenantID.String(), role.Code, identityID.String()); err != nil {
+			return identityID, fmt.Errorf("assign role %s: %w", code, err)
+		}
+	}
+	return identityID, nil
+}
+
+func (i *Importer) tenantGroups(ctx context.Context, tenantID uuid.UUID) (map[string]storage.Group, error) {
+	groups, err := i.groups.ListGroups(ctx, &tenantID)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]storage.Group, len(groups))
+	for _, group := range groups {
+		byCode[group.Code] = group
+	}
+	return byCode, nil
+}
+
+func (i *Importer) tenantRoles(ctx context.Context, tenantID uuid.UUID) (map[string]storage.Role, error) {
+	roles, _, err := i.roles.ListRoles(ctx, storage.RoleListParams{
+		Scope:    "tenant",
+		TenantID: &tenantID,
+		Limit:    maxTenantRoles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]storage.Role, len(roles))
+	for _, role := range roles {
+		byCode[role.Code] = role
+	}
+	return byCode, nil
+}
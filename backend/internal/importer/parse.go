@@ -0,0 +1,222 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	// ErrUnsupportedFormat indicates the uploaded file is neither CSV nor XLSX.
+	ErrUnsupportedFormat = errors.New("unsupported file format, expected .csv or .xlsx")
+	// ErrMissingColumns indicates the header row lacks a required column.
+	ErrMissingColumns = errors.New("missing required columns")
+	// ErrTooManyRows indicates the file exceeds the configured row limit.
+	ErrTooManyRows = errors.New("too many rows")
+	// ErrEmptyFile indicates the file has no data rows.
+	ErrEmptyFile = errors.New("file contains no member rows")
+)
+
+// MissingColumnsError lists the required columns absent from the header row. It matches
+// ErrMissingColumns with errors.Is.
+type MissingColumnsError struct {
+	Columns []string
+}
+
+func (e *MissingColumnsError) Error() string {
+	return ErrMissingColumns.Error() + ": " + strings.Join(e.Columns, ", ")
+}
+
+func (e *MissingColumnsError) Is(target error) bool {
+	return target == ErrMissingColumns
+}
+
+// Row is one member parsed from a spreadsheet. Number is the 1-based line in the file,
+// so the header is line 1 and the first member is line 2.
+type Row struct {
+	Number      int32
+	DisplayName string
+	Phone       string
+	GroupCode   string
+	Title       string
+	Roles       []string
+}
+
+const (
+	columnName  = "name"
+	columnPhone = "phone"
+	columnGroup = "group_code"
+	columnTitle = "title"
+	columnRoles = "roles"
+)
+
+// headerAliases maps accepted header spellings to columns.
+var headerAliases = map[string]string{
+	"name":         columnName,
+	"display_name": columnName,
+	"姓名":           columnName,
+	"phone":        columnPhone,
+	"mobile":       columnPhone,
+	"手机号":          columnPhone,
+	"手机":           columnPhone,
+	"group_code":   columnGroup,
+	"group":        columnGroup,
+	"部门编码":         columnGroup,
+	"title":        columnTitle,
+	"职位":           columnTitle,
+	"roles":        columnRoles,
+	"role":         columnRoles,
+	"角色":           columnRoles,
+}
+
+var requiredColumns = []string{columnName, columnPhone, columnGroup}
+
+// ParseFile reads member rows from a CSV or XLSX file, chosen by the filename extension.
+// Blank lines are skipped. maxRows limits the number of member rows when positive.
+func ParseFile(filename string, r io.Reader, maxRows int) ([]Row, error) {
+	var (
+		records [][]string
+		err     error
+	)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		records, err = readCSV(r)
+	case ".xlsx":
+		records, err = readXLSX(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	columns := make(map[string]int)
+	for idx, header := range records[0] {
+		key := strings.ToLower(strings.TrimSpace(header))
+		key = strings.ReplaceAll(key, " ", "_")
+		if column, ok := headerAliases[key]; ok {
+			if _, seen := columns[column]; !seen {
+				columns[column] = idx
+			}
+		}
+	}
+	var missing []string
+	for _, column := range requiredColumns {
+		if _, ok := columns[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &MissingColumnsError{Columns: missing}
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for idx, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		if maxRows > 0 && len(rows) >= maxRows {
+			return nil, fmt.Errorf("%w: at most %d members per import", ErrTooManyRows, maxRows)
+		}
+		rows = append(rows, Row{
+			Number:      int32(idx + 2),
+			DisplayName: cell(record, columns, columnName),
+			Phone:       cell(record, columns, columnPhone),
+			GroupCode:   cell(record, columns, columnGroup),
+			Title:       cell(record, columns, columnTitle),
+			Roles:       splitRoles(cell(record, columns, columnRoles)),
+		})
+	}
+	if len(rows) == 0 {
+		return nil, ErrEmptyFile
+	}
+	return rows, nil
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+	// Spreadsheet applications commonly prepend a UTF-8 byte order mark.
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv: %w", err)
+	}
+	return records, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrEmptyFile
+	}
+	records, err := file.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("read xlsx: %w", err)
+	}
+	return records, nil
+}
+
+func cell(record []string, columns map[string]int, column string) string {
+	idx, ok := columns[column]
+	if !ok || idx >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRoles accepts role codes separated by commas, semicolons, pipes or the
+// Chinese enumeration comma, dropping blanks and duplicates.
+func splitRoles(raw string) []string {
+	parts := strings.FieldsFunc(raw, func(r rune) bool {
+		switch r {
+		case ',', ';', '|', '、', '，', '；':
+			return true
+		}
+		return false
+	})
+	roles := make([]string, 0, len(parts))
+	seen := make(map[string]struct{}, len(parts))
+	for _, part := range parts {
+		code := strings.TrimSpace(part)
+		if code == "" {
+			continue
+		}
+		if _, dup := seen[code]; dup {
+			continue
+		}
+		seen[code] = struct{}{}
+		roles = append(roles, code)
+	}
+	return roles
+}
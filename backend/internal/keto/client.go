@@ -87,7 +87,8 @@ type checkResponse struct {
 	Allowed bool `json:"allowed"`
 }
 
-type relationTuple struct {
+// RelationTuple is a Keto relation tuple. Exactly one of SubjectID and SubjectSet is set.
+type RelationTuple struct {
 	Namespace  string      `json:"namespace"`
 	Object     string      `json:"object"`
 	Relation   string      `json:"relation"`
@@ -95,6 +96,7 @@ type relationTuple struct {
 	SubjectSet *SubjectSet `json:"subject_set,omitempty"`
 }
 
+// SubjectSet references the subjects holding Relation on Object.
 type SubjectSet struct {
 	Namespace string `json:"namespace"`
 	Object    string `json:"object"`
@@ -164,7 +166,7 @@ func (c *Client) AssignRole(ctx context.Context, tenantID, role, subject string)
 
 	object := fmt.Sprintf("%s:%s", scope, role)
 
-	payload := relationTuple{
+	payload := RelationTuple{
 		Namespace: ns,
 		Object:    object,
 		Relation:  c.membershipRelation(),
@@ -249,7 +251,7 @@ func (c *Client) AssignGroupMember(ctx context.Context, tenantID, groupID, subje
 		return fmt.Errorf("write endpoint not configured")
 	}
 
-	payload := relationTuple{
+	payload := RelationTuple{
 		Namespace: "Group",
 		Object:    groupObject(tenantID, groupID),
 		Relation:  c.membershipRelation(),
@@ -316,6 +318,259 @@ func (c *Client) RemoveGroupMember(ctx context.Context, tenantID, groupID, subje
 	return nil
 }
 
+// groupParentsRelation links a group to its parent so view and manage permits are
+// inherited down the tree.
+const groupParentsRelation = "parents"
+
+// AssignGroupParent records parentID as the parent of groupID.
+func (c *Client) AssignGroupParent(ctx context.Context, tenantID, groupID, parentID string) error {
+	return c.UpsertSubjectSetRelation(ctx, "Group", groupObject(tenantID, groupID), groupParentsRelation, SubjectSet{
+		Namespace: "Group",
+		Object:    groupObject(tenantID, parentID),
+	})
+}
+
+// RemoveGroupParent deletes the parent link between groupID and parentID.
+func (c *Client) RemoveGroupParent(ctx context.Context, tenantID, groupID, parentID string) error {
+	return c.DeleteSubjectSetRelation(ctx, "Group", groupObject(tenantID, groupID), groupParentsRelation, SubjectSet{
+		Namespace: "Group",
+		Object:    groupObject(tenantID, parentID),
+	})
+}
+
+// SetGroupParent makes parentID the only parent of groupID, removing any other parent
+// link. An empty parentID leaves the group without a parent.
+func (c *Client) SetGroupParent(ctx context.Context, tenantID, groupID, parentID string) error {
+	filter := RelationTuple{
+		Namespace: "Group",
+		Object:    groupObject(tenantID, groupID),
+		Relation:  groupParentsRelation,
+	}
+	var want string
+	if parentID != "" {
+		want = groupObject(tenantID, parentID)
+	}
+
+	var (
+		stale     []SubjectSet
+		found     bool
+		pageToken string
+	)
+	for {
+		tuples, next, err := c.ListRelationTuples(ctx, filter, pageToken)
+		if err != nil {
+			return err
+		}
+		for _, tuple := range tuples {
+			if tuple.SubjectSet == nil {
+				continue
+			}
+			if tuple.SubjectSet.Namespace == "Group" && tuple.SubjectSet.Object == want {
+				found = true
+				continue
+			}
+			stale = append(stale, *tuple.SubjectSet)
+		}
+		if next == "" {
+			break
+		}
+		pageToken = next
+	}
+
+	for _, subject := range stale {
+		if err := c.DeleteSubjectSetRelation(ctx, filter.Namespace, filter.Object, groupParentsRelation, subject); err != nil {
+			return err
+		}
+	}
+	if want != "" && !found {
+		return c.AssignGroupParent(ctx, tenantID, groupID, parentID)
+	}
+	return nil
+}
+
+// DeleteGroupTuples removes every relation tuple of a group, including its members,
+// managers and parent link.
+func (c *Client) DeleteGroupTuples(ctx context.Context, tenantID, groupID string) error {
+	if c.writeEndpoint == nil {
+		return fmt.Errorf("write endpoint not configured")
+	}
+
+	reqURL := *c.writeEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/relation-tuples")
+
+	query := reqURL.Query()
+	query.Set("namespace", "Group")
+	query.Set("object", groupObject(tenantID, groupID))
+	reqURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build group tuples delete request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("call keto delete api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("keto delete error: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// ListGroupManagers returns the subjects directly related to a group as managers.
+// Managers granted through subject sets are not expanded.
+func (c *Client) ListGroupManagers(ctx context.Context, tenantID, groupID string) ([]string, error) {
+	filter := RelationTuple{
+		Namespace: "Group",
+		Object:    groupObject(tenantID, groupID),
+		Relation:  "managers",
+	}
+
+	var (
+		subjects  []string
+		pageToken string
+	)
+	for {
+		tuples, next, err := c.ListRelationTuples(ctx, filter, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, tuple := range tuples {
+			if tuple.SubjectID != "" {
+				subjects = append(subjects, tuple.SubjectID)
+			}
+		}
+		if next == "" {
+			return subjects, nil
+		}
+		pageToken = next
+	}
+}
+
+// ListRelationTuples returns one page of the tuples matching the non-empty fields of
+// filter, and the token of the next page, which is empty on the last page.
+func (c *Client) ListRelationTuples(ctx context.Context, filter RelationTuple, pageToken string) ([]RelationTuple, string, error) {
+	reqURL := *c.readEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/relation-tuples")
+	query := tupleQuery(filter)
+	if pageToken != "" {
+		query.Set("page_token", pageToken)
+	}
+	reqURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("build relation list request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("call keto read api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("keto read error: %s", resp.Status)
+	}
+
+	var result struct {
+		RelationTuples []RelationTuple `json:"relation_tuples"`
+		NextPageToken  string          `json:"next_page_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", fmt.Errorf("decode keto relation tuples: %w", err)
+	}
+	return result.RelationTuples, result.NextPageToken, nil
+}
+
+// WriteRelationTuple creates a relation tuple. Writing an existing tuple is a no-op.
+func (c *Client) WriteRelationTuple(ctx context.Context, tuple RelationTuple) error {
+	if c.writeEndpoint == nil {
+		return fmt.Errorf("write endpoint not configured")
+	}
+
+	body, err := json.Marshal(tuple)
+	if err != nil {
+		return fmt.Errorf("marshal tuple payload: %w", err)
+	}
+
+	reqURL := *c.writeEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/relation-tuples")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build relation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("call keto write api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("keto write error: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// DeleteRelationTuples removes every tuple matching the non-empty fields of filter.
+// Namespace is required so a blank filter cannot wipe the whole store.
+func (c *Client) DeleteRelationTuples(ctx context.Context, filter RelationTuple) error {
+	if c.writeEndpoint == nil {
+		return fmt.Errorf("write endpoint not configured")
+	}
+	if filter.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+
+	reqURL := *c.writeEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/relation-tuples")
+	reqURL.RawQuery = tupleQuery(filter).Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build relation delete request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("call keto delete api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("keto delete error: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func tupleQuery(filter RelationTuple) url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("namespace", filter.Namespace)
+	set("object", filter.Object)
+	set("relation", filter.Relation)
+	set("subject_id", filter.SubjectID)
+	if filter.SubjectSet != nil {
+		query.Set("subject_set.namespace", filter.SubjectSet.Namespace)
+		query.Set("subject_set.object", filter.SubjectSet.Object)
+		query.Set("subject_set.relation", filter.SubjectSet.Relation)
+	}
+	return query
+}
+
 func (c *Client) resolveRelation(action string) string {
 	if action != "" {
 		return action
@@ -344,7 +599,7 @@ func (c *Client) UpsertSubjectSetRelation(ctx context.Context, namespace, object
 		return fmt.Errorf("write endpoint not configured")
 	}
 
-	payload := relationTuple{
+	payload := RelationTuple{
 		Namespace:  namespace,
 		Object:     object,
 		Relation:   relation,
@@ -414,6 +669,40 @@ func (c *Client) DeleteSubjectSetRelation(ctx context.Context, namespace, object
 	return nil
 }
 
+// ReadReady reports whether the Keto read API answers its readiness check.
+func (c *Client) ReadReady(ctx context.Context) error {
+	return c.ready(ctx, c.readEndpoint, "read")
+}
+
+// WriteReady reports whether the Keto write API answers its readiness check.
+func (c *Client) WriteReady(ctx context.Context) error {
+	if c.writeEndpoint == nil {
+		return fmt.Errorf("write endpoint not configured")
+	}
+	return c.ready(ctx, c.writeEndpoint, "write")
+}
+
+func (c *Client) ready(ctx context.Context, endpoint *url.URL, api string) error {
+	reqURL := *endpoint
+	reqURL.Path = path.Join(reqURL.Path, "/health/ready")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build keto %s health request: %w", api, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("call keto %s health api: %w", api, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("keto %s not ready: %s", api, resp.Status)
+	}
+	return nil
+}
+
 func groupObject(tenantID, groupID string) string {
 	scope := tenantID
 	if strings.TrimSpace(scope) == "" {
@@ -0,0 +1,236 @@
+// Package ketosync applies the Keto sync queue. Group, membership and role assignment
+// changes enqueue an entry in the same transaction as the database write; the syncer then
+// makes Keto match the database and retries with backoff until it succeeds, so a failed
+// Keto call cannot leave access granted that the database already revoked. Identities
+// disabled in Kratos keep their memberships and role assignments in the database but hold
+// no tuples.
+package ketosync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/keto"
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultBatchSize    = 50
+	defaultLease        = time.Minute
+	baseBackoff         = 5 * time.Second
+	maxBackoff          = 5 * time.Minute
+)
+
+// Options configures the syncer.
+type Options struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease is how long a claimed entry stays hidden from other syncers. It must outlive
+	// the Keto calls of one entry.
+	Lease time.Duration
+}
+
+// Syncer reconciles queued groups, memberships and role assignments with Keto.
+type Syncer struct {
+	repo   *storage.KetoSyncRepository
+	groups *storage.GroupRepository
+	roles  *storage.RoleRepository
+	keto   *keto.Client
+	kratos *kratos.Client
+	logger *zap.Logger
+	opts   Options
+}
+
+// New constructs a syncer, filling unset options with defaults.
+func New(repo *storage.KetoSyncRepository, groups *storage.GroupRepository, roles *storage.RoleRepository, ketoClient *keto.Client, kratosClient *kratos.Client, opts Options, logger *zap.Logger) *Syncer {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+
+	return &Syncer{
+		repo:   repo,
+		groups: groups,
+		roles:  roles,
+		keto:   ketoClient,
+		kratos: kratosClient,
+		logger: logger.Named("ketosync"),
+		opts:   opts,
+	}
+}
+
+// Run applies due entries until ctx is cancelled.
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		entries, err := s.repo.Claim(ctx, int32(s.opts.BatchSize), s.opts.Lease, nil)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("claim keto sync failed", zap.Error(err))
+			}
+		} else {
+			_ = s.applyAll(ctx, entries)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync applies the entries with the given keys right away, so the caller's change is
+// visible in Keto when the request returns. Entries that fail, or that another syncer
+// holds, stay queued and are retried by Run; the returned error only reports that Keto
+// is not up to date yet.
+func (s *Syncer) Sync(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	entries, err := s.repo.Claim(ctx, int32(len(keys)), s.opts.Lease, keys)
+	if err != nil {
+		return err
+	}
+	return s.applyAll(ctx, entries)
+}
+
+func (s *Syncer) applyAll(ctx context.Context, entries []storage.KetoSync) error {
+	var errs []error
+	for _, entry := range entries {
+		applyErr := s.apply(ctx, entry)
+
+		// Persist the outcome even if the syncer is shutting down.
+		ctx := context.WithoutCancel(ctx)
+		if applyErr == nil {
+			if err := s.repo.Complete(ctx, entry); err != nil {
+				s.logger.Error("complete keto sync failed", zap.Int64("entry", entry.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		errs = append(errs, applyErr)
+		s.logger.Error("keto sync failed",
+			zap.Int64("entry", entry.ID),
+			zap.String("kind", entry.Kind),
+			zap.String("group", entry.GroupID.String()),
+			zap.String("role", entry.RoleID.String()),
+			zap.Int32("attempt", entry.Attempts),
+			zap.Error(applyErr),
+		)
+		if err := s.repo.Fail(ctx, entry, time.Now().Add(backoff(int(entry.Attempts))), applyErr); err != nil {
+			s.logger.Error("record keto sync failure failed", zap.Int64("entry", entry.ID), zap.Error(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// apply makes Keto match the current database state of the entry.
+func (s *Syncer) apply(ctx context.Context, entry storage.KetoSync) error {
+	tenantID := entry.TenantID.String()
+	groupID := entry.GroupID.String()
+
+	switch entry.Kind {
+	case storage.KetoSyncGroup:
+		group, err := s.groups.GetGroup(ctx, entry.GroupID)
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			return s.keto.DeleteGroupTuples(ctx, tenantID, groupID)
+		}
+		if err != nil {
+			return err
+		}
+		var parentID string
+		if group.ParentID != nil {
+			parentID = group.ParentID.String()
+		}
+		return s.keto.SetGroupParent(ctx, tenantID, groupID, parentID)
+
+	case storage.KetoSyncGroupMember:
+		if entry.IdentityID == nil {
+			return fmt.Errorf("keto sync entry %d has no identity", entry.ID)
+		}
+		identityID := entry.IdentityID.String()
+		_, err := s.groups.GetMember(ctx, entry.GroupID, *entry.IdentityID)
+		if err != nil && !errors.Is(err, storage.ErrGroupMemberNotFound) {
+			return err
+		}
+		grant := err == nil
+		if grant {
+			if grant, err = s.identityActive(ctx, *entry.IdentityID); err != nil {
+				return err
+			}
+		}
+		if !grant {
+			return s.keto.RemoveGroupMember(ctx, tenantID, groupID, identityID)
+		}
+		return s.keto.AssignGroupMember(ctx, tenantID, groupID, identityID)
+
+	case storage.KetoSyncRoleMember:
+		if entry.IdentityID == nil {
+			return fmt.Errorf("keto sync entry %d has no identity", entry.ID)
+		}
+		role, err := s.roles.GetRole(ctx, entry.RoleID)
+		if errors.Is(err, storage.ErrRoleNotFound) {
+			// Deleting a role removes the tuples of all its members.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var scope string
+		if role.TenantID != nil {
+			scope = role.TenantID.String()
+		}
+		grant, err := s.roles.HasAssignment(ctx, role.ID, *entry.IdentityID)
+		if err != nil {
+			return err
+		}
+		if grant {
+			if grant, err = s.identityActive(ctx, *entry.IdentityID); err != nil {
+				return err
+			}
+		}
+		if !grant {
+			return s.keto.RemoveRole(ctx, scope, role.Code, entry.IdentityID.String())
+		}
+		return s.keto.AssignRole(ctx, scope, role.Code, entry.IdentityID.String())
+	}
+	return fmt.Errorf("unknown keto sync kind %q", entry.Kind)
+}
+
+// identityActive reports whether the identity may hold tuples. Only identities that
+// Kratos reports as inactive are refused.
+func (s *Syncer) identityActive(ctx context.Context, identityID uuid.UUID) (bool, error) {
+	identity, err := s.kratos.GetIdentity(ctx, identityID.String())
+	if err != nil {
+		return false, fmt.Errorf("load kratos identity: %w", err)
+	}
+	return identity == nil || identity.State != kratos.StateInactive, nil
+}
+
+// backoff returns the wait before retry number attempts, doubling from baseBackoff.
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
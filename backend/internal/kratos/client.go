@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -70,8 +71,84 @@ func NewClient(opts Options, logger *zap.Logger) (*Client, error) {
 }
 
 type Identity struct {
-	ID     string         `json:"id"`
-	Traits map[string]any `json:"traits"`
+	ID          string                `json:"id"`
+	SchemaID    string                `json:"schema_id,omitempty"`
+	State       string                `json:"state,omitempty"`
+	Traits      map[string]any        `json:"traits"`
+	Credentials map[string]Credential `json:"credentials,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// Credential describes one login method of an identity. Secrets are never returned.
+type Credential struct {
+	Type        string   `json:"type"`
+	Identifiers []string `json:"identifiers"`
+}
+
+// Credential types understood by Kratos.
+const (
+	CredentialPassword = "password"
+)
+
+// Identity states understood by Kratos.
+const (
+	StateActive   = "active"
+	StateInactive = "inactive"
+)
+
+// TraitString returns a string trait or an empty string when missing.
+func (i *Identity) TraitString(key string) string {
+	if i == nil || i.Traits == nil {
+		return ""
+	}
+	value, _ := i.Traits[key].(string)
+	return value
+}
+
+// TraitStrings returns the strings of a list trait, or nil when missing.
+func (i *Identity) TraitStrings(key string) []string {
+	if i == nil || i.Traits == nil {
+		return nil
+	}
+	switch values := i.Traits[key].(type) {
+	case []string:
+		return values
+	case []any:
+		result := make([]string, 0, len(values))
+		for _, value := range values {
+			if str, ok := value.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// HasCredential reports whether the identity has set up the given login method.
+func (i *Identity) HasCredential(kind string) bool {
+	if i == nil {
+		return false
+	}
+	_, ok := i.Credentials[kind]
+	return ok
+}
+
+// NormalizePhone converts a phone number to the E.164 form stored in the phone trait,
+// assuming mainland China numbers when no country code is given.
+func NormalizePhone(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return raw
+	}
+	if strings.HasPrefix(raw, "+") {
+		return raw
+	}
+	if strings.HasPrefix(raw, "86") {
+		return "+" + raw
+	}
+	return "+86" + raw
 }
 
 func (c *Client) FindIdentityByIdentifier(ctx context.Context, identifier string) (*Identity, error) {
@@ -184,6 +261,273 @@ func (c *Client) CreateIdentity(ctx context.Context, input CreateIdentityInput)
 	return &result, nil
 }
 
+// GetIdentity loads an identity by ID. It returns nil without error when the identity does not exist.
+func (c *Client) GetIdentity(ctx context.Context, id string) (*Identity, error) {
+	reqURL := *c.adminEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/identities", id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build kratos request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("exec kratos request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, c.decodeError(resp)
+	}
+
+	var result Identity
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode kratos identity: %w", err)
+	}
+	return &result, nil
+}
+
+// UpdateIdentity replaces the traits and state of an identity. Credentials are left untouched.
+func (c *Client) UpdateIdentity(ctx context.Context, identity Identity) (*Identity, error) {
+	reqURL := *c.adminEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/identities", identity.ID)
+
+	schemaID := identity.SchemaID
+	if schemaID == "" {
+		schemaID = c.schemaID
+	}
+	state := identity.State
+	if state == "" {
+		state = StateActive
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"schema_id": schemaID,
+		"state":     state,
+		"traits":    identity.Traits,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal kratos payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build kratos request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("exec kratos request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, c.decodeError(resp)
+	}
+
+	var result Identity
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode kratos identity: %w", err)
+	}
+	return &result, nil
+}
+
+// ListIdentities returns one page of identities and the token of the next page, which is
+// empty on the last page.
+func (c *Client) ListIdentities(ctx context.Context, pageSize int, pageToken string) ([]Identity, string, error) {
+	reqURL := *c.adminEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/identities")
+
+	q := reqURL.Query()
+	if pageSize > 0 {
+		q.Set("page_size", strconv.Itoa(pageSize))
+	}
+	if pageToken != "" {
+		q.Set("page_token", pageToken)
+	}
+	reqURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("build kratos request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("exec kratos request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, "", c.decodeError(resp)
+	}
+
+	var result []Identity
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", fmt.Errorf("decode kratos identities: %w", err)
+	}
+	return result, nextPageToken(resp.Header.Get("Link")), nil
+}
+
+// nextPageToken extracts page_token from the rel="next" entry of a Link header.
+func nextPageToken(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		isNext := false
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				isNext = true
+			}
+		}
+		if !isNext {
+			continue
+		}
+		target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return ""
+		}
+		return parsed.Query().Get("page_token")
+	}
+	return ""
+}
+
+// DeleteSessions revokes every session of an identity. Missing identities are ignored.
+func (c *Client) DeleteSessions(ctx context.Context, id string) error {
+	reqURL := *c.adminEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/identities", id, "sessions")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build kratos request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("exec kratos request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return c.decodeError(resp)
+	}
+	return nil
+}
+
+// DeleteIdentity permanently removes an identity. Missing identities are ignored.
+func (c *Client) DeleteIdentity(ctx context.Context, id string) error {
+	reqURL := *c.adminEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/identities", id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build kratos request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("exec kratos request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return c.decodeError(resp)
+	}
+	return nil
+}
+
+// RecoveryCode is a one-time code that lets an identity recover the account and set a password.
+type RecoveryCode struct {
+	Link      string    `json:"recovery_link"`
+	Code      string    `json:"recovery_code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateRecoveryCode issues a recovery code for an identity, valid for expiresIn.
+func (c *Client) CreateRecoveryCode(ctx context.Context, id string, expiresIn time.Duration) (*RecoveryCode, error) {
+	reqURL := *c.adminEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/admin/recovery/code")
+
+	payload := map[string]any{"identity_id": id}
+	if expiresIn > 0 {
+		payload["expires_in"] = expiresIn.String()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal kratos payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build kratos request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("exec kratos request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, c.decodeError(resp)
+	}
+
+	var result RecoveryCode
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode kratos recovery code: %w", err)
+	}
+	return &result, nil
+}
+
+// Error is a failed Kratos admin API call. Message is built from the error payload the
+// way Kratos reports it, and StatusCode is the HTTP status of the response.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// IsConflict reports whether err is a Kratos conflict, which Kratos returns when an
+// identity with the same identifier already exists.
+func IsConflict(err error) bool {
+	var kratosErr *Error
+	return errors.As(err, &kratosErr) && kratosErr.StatusCode == http.StatusConflict
+}
+
+// AdminReady reports whether the Kratos admin API answers its readiness check.
+func (c *Client) AdminReady(ctx context.Context) error {
+	reqURL := *c.adminEndpoint
+	reqURL.Path = path.Join(reqURL.Path, "/health/ready")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build kratos request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("exec kratos request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kratos admin not ready: %s", resp.Status)
+	}
+	return nil
+}
+
 func (c *Client) decodeError(resp *http.Response) error {
 	var payload struct {
 		Error struct {
@@ -193,7 +537,7 @@ func (c *Client) decodeError(resp *http.Response) error {
 		} `json:"error"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return fmt.Errorf("kratos error: %s", resp.Status)
+		return &Error{StatusCode: resp.StatusCode, Message: fmt.Sprintf("kratos error: %s", resp.Status)}
 	}
 	if payload.Error.Message != "" {
 		msg := payload.Error.Message
@@ -203,7 +547,7 @@ func (c *Client) decodeError(resp *http.Response) error {
 		if payload.Error.Debug != "" {
 			msg = fmt.Sprintf("%s (%s)", msg, payload.Error.Debug)
 		}
-		return errors.New(msg)
+		return &Error{StatusCode: resp.StatusCode, Message: msg}
 	}
-	return fmt.Errorf("kratos error: %s", resp.Status)
+	return &Error{StatusCode: resp.StatusCode, Message: fmt.Sprintf("kratos error: %s", resp.Status)}
 }
@@ -19,6 +19,16 @@ type IdentityContext struct {
 	UserType string
 	TenantID string
 	Roles    []string
+
+	// ImpersonationID and ActingTenantID are set when a platform admin
+	// operates inside a tenant through an impersonation session.
+	ImpersonationID string
+	ActingTenantID  string
+}
+
+// Impersonating reports whether the request runs under an impersonation session.
+func (ctx *IdentityContext) Impersonating() bool {
+	return ctx != nil && ctx.ImpersonationID != ""
 }
 
 // WithIdentity captures Oathkeeper session headers and exposes them to downstream handlers.
@@ -66,4 +76,3 @@ func normalizeRoles(raw string) []string {
 	}
 	return out
 }
-
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header used to propagate request identifiers.
+const RequestIDHeader = "X-Request-Id"
+
+const contextRequestIDKey = "portal.request_id"
+
+// RequestID reuses an incoming X-Request-Id or generates one, echoing it on the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.GetHeader(RequestIDHeader))
+		if id == "" {
+			id = uuid.NewString()
+		}
+		c.Set(contextRequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the request identifier assigned by RequestID.
+func RequestIDFromContext(c *gin.Context) string {
+	return c.GetString(contextRequestIDKey)
+}
@@ -0,0 +1,32 @@
+// Package openapi embeds the OpenAPI 3 document that describes the portal API.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+// Document returns the OpenAPI document as YAML.
+func Document() []byte {
+	return document
+}
+
+// Load parses the embedded document and checks that it is a valid OpenAPI 3 document.
+func Load(ctx context.Context) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	spec, err := loader.LoadFromData(document)
+	if err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+	if err := spec.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("validate openapi document: %w", err)
+	}
+	return spec, nil
+}
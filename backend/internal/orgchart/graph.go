@@ -0,0 +1,219 @@
+package orgchart
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Edge kinds shared by the graph formats.
+const (
+	edgeContains = "contains"
+	edgeMember   = "member"
+	edgeManages  = "manages"
+)
+
+func groupNodeID(id uuid.UUID) string {
+	return "g_" + strings.ReplaceAll(id.String(), "-", "")
+}
+
+func personNodeID(id uuid.UUID) string {
+	return "p_" + strings.ReplaceAll(id.String(), "-", "")
+}
+
+type graphEdge struct {
+	source, target, kind string
+}
+
+// graph is the node and edge list shared by GraphML and DOT. People appear once even
+// when they belong to or manage several groups.
+type graph struct {
+	groups []Node
+	people []Person
+	edges  []graphEdge
+}
+
+func buildGraph(chart Chart) graph {
+	var g graph
+	g.groups = chart.Nodes
+
+	inChart := make(map[uuid.UUID]struct{}, len(chart.Nodes))
+	for _, node := range chart.Nodes {
+		inChart[node.ID] = struct{}{}
+	}
+	seen := make(map[uuid.UUID]struct{})
+	addPerson := func(person Person) {
+		if _, ok := seen[person.IdentityID]; ok {
+			return
+		}
+		seen[person.IdentityID] = struct{}{}
+		g.people = append(g.people, person)
+	}
+
+	for _, node := range chart.Nodes {
+		if node.ParentID != nil {
+			if _, ok := inChart[*node.ParentID]; ok {
+				g.edges = append(g.edges, graphEdge{groupNodeID(*node.ParentID), groupNodeID(node.ID), edgeContains})
+			}
+		}
+		for _, manager := range node.Managers {
+			addPerson(manager)
+			g.edges = append(g.edges, graphEdge{personNodeID(manager.IdentityID), groupNodeID(node.ID), edgeManages})
+		}
+		for _, member := range node.Members {
+			addPerson(member)
+			g.edges = append(g.edges, graphEdge{groupNodeID(node.ID), personNodeID(member.IdentityID), edgeMember})
+		}
+	}
+	return g
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML renders the chart as a directed GraphML graph with group and person nodes.
+func WriteGraphML(w io.Writer, chart Chart) error {
+	g := buildGraph(chart)
+
+	keys := []graphMLKey{
+		{ID: "kind", For: "node", AttrName: "kind", AttrType: "string"},
+		{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+		{ID: "code", For: "node", AttrName: "code", AttrType: "string"},
+		{ID: "path", For: "node", AttrName: "path", AttrType: "string"},
+		{ID: "phone", For: "node", AttrName: "phone", AttrType: "string"},
+		{ID: "title", For: "node", AttrName: "title", AttrType: "string"},
+		{ID: "relation", For: "edge", AttrName: "relation", AttrType: "string"},
+	}
+	if chart.IncludeRoles {
+		keys = append(keys, graphMLKey{ID: "roles", For: "node", AttrName: "roles", AttrType: "string"})
+	}
+
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  keys,
+		Graph: graphMLGraph{
+			ID:          "tenant_" + strings.ReplaceAll(chart.TenantID.String(), "-", ""),
+			EdgeDefault: "directed",
+		},
+	}
+	for _, node := range g.groups {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: groupNodeID(node.ID),
+			Data: []graphMLData{
+				{Key: "kind", Value: "group"},
+				{Key: "label", Value: node.Name},
+				{Key: "code", Value: node.Code},
+				{Key: "path", Value: node.PathString()},
+			},
+		})
+	}
+	for _, person := range g.people {
+		data := []graphMLData{
+			{Key: "kind", Value: "person"},
+			{Key: "label", Value: displayName(person)},
+			{Key: "phone", Value: person.Phone},
+			{Key: "title", Value: person.Title},
+		}
+		if chart.IncludeRoles {
+			data = append(data, graphMLData{Key: "roles", Value: strings.Join(person.Roles, ";")})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: personNodeID(person.IdentityID), Data: data})
+	}
+	for _, edge := range g.edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: edge.source,
+			Target: edge.target,
+			Data:   []graphMLData{{Key: "relation", Value: edge.kind}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encode graphml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteDOT renders the chart as a Graphviz digraph. Groups are boxes, people are
+// ellipses and manager edges are dashed.
+func WriteDOT(w io.Writer, chart Chart) error {
+	g := buildGraph(chart)
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "digraph orgchart {")
+	fmt.Fprintln(out, "  rankdir=LR;")
+	fmt.Fprintln(out, `  node [fontname="Helvetica"];`)
+	for _, node := range g.groups {
+		fmt.Fprintf(out, "  %s [shape=box, label=%s];\n", groupNodeID(node.ID), dotQuote(node.Name+"\n"+node.Code))
+	}
+	for _, person := range g.people {
+		label := displayName(person)
+		if person.Title != "" {
+			label += "\n" + person.Title
+		}
+		if chart.IncludeRoles && len(person.Roles) > 0 {
+			label += "\n[" + strings.Join(person.Roles, ", ") + "]"
+		}
+		fmt.Fprintf(out, "  %s [shape=ellipse, label=%s];\n", personNodeID(person.IdentityID), dotQuote(label))
+	}
+	for _, edge := range g.edges {
+		switch edge.kind {
+		case edgeManages:
+			fmt.Fprintf(out, "  %s -> %s [style=dashed, label=%s];\n", edge.source, edge.target, strconv.Quote("manager"))
+		default:
+			fmt.Fprintf(out, "  %s -> %s;\n", edge.source, edge.target)
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// dotQuote produces a DOT string literal, keeping line breaks as \n escapes.
+func dotQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,225 @@
+// Package orgchart flattens a tenant's group tree with its managers and members and
+// renders it in formats suited to auditors and graph tooling.
+package orgchart
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+// PathSeparator joins group names into the flattened path used by tabular formats.
+const PathSeparator = " / "
+
+// ErrRootNotFound indicates the requested subtree root is not a group of the tenant.
+var ErrRootNotFound = errors.New("root group not found")
+
+// Person is an identity shown on the chart.
+type Person struct {
+	IdentityID  uuid.UUID
+	DisplayName string
+	Phone       string
+	Title       string
+	IsPrimary   bool
+	Roles       []string
+}
+
+// Node is a group on the chart. Nodes are ordered depth first, so a parent always
+// precedes its children.
+type Node struct {
+	ID          uuid.UUID
+	ParentID    *uuid.UUID
+	Code        string
+	Name        string
+	Description string
+	Path        []string
+	Depth       int
+	Managers    []Person
+	Members     []Person
+}
+
+// PathString returns the group path joined with PathSeparator.
+func (n Node) PathString() string {
+	return strings.Join(n.Path, PathSeparator)
+}
+
+// Chart is the exported organization structure.
+type Chart struct {
+	TenantID     uuid.UUID
+	IncludeRoles bool
+	Nodes        []Node
+}
+
+// Input carries the directory data a chart is built from.
+type Input struct {
+	TenantID uuid.UUID
+	Groups   []storage.Group
+	Members  []storage.GroupMember
+	// Managers maps group IDs to the identity IDs managing the group.
+	Managers map[uuid.UUID][]string
+	// Roles maps identity IDs to assigned role codes. A nil map omits roles from the chart.
+	Roles map[uuid.UUID][]string
+	// RootID limits the chart to the subtree below this group when set.
+	RootID *uuid.UUID
+}
+
+// Build assembles a chart, keeping the sibling order of Groups.
+func Build(in Input) (Chart, error) {
+	children := make(map[uuid.UUID][]storage.Group)
+	roots := make([]storage.Group, 0)
+	known := make(map[uuid.UUID]storage.Group, len(in.Groups))
+	for _, group := range in.Groups {
+		known[group.ID] = group
+	}
+	for _, group := range in.Groups {
+		// Groups whose parent is outside the list are treated as roots.
+		if group.ParentID == nil {
+			roots = append(roots, group)
+			continue
+		}
+		if _, ok := known[*group.ParentID]; !ok {
+			roots = append(roots, group)
+			continue
+		}
+		children[*group.ParentID] = append(children[*group.ParentID], group)
+	}
+
+	var prefix []string
+	if in.RootID != nil {
+		root, ok := known[*in.RootID]
+		if !ok {
+			return Chart{}, ErrRootNotFound
+		}
+		roots = []storage.Group{root}
+		for parent := root.ParentID; parent != nil; {
+			group, ok := known[*parent]
+			if !ok {
+				break
+			}
+			prefix = append([]string{group.Name}, prefix...)
+			parent = group.ParentID
+		}
+	}
+
+	includeRoles := in.Roles != nil
+	membersByGroup := make(map[uuid.UUID][]Person)
+	people := make(map[uuid.UUID]Person)
+	for _, member := range in.Members {
+		person := Person{
+			IdentityID:  member.IdentityID,
+			DisplayName: member.DisplayName,
+			Phone:       member.Phone,
+			IsPrimary:   member.IsPrimary,
+		}
+		if member.Title != nil {
+			person.Title = *member.Title
+		}
+		if includeRoles {
+			person.Roles = in.Roles[member.IdentityID]
+		}
+		membersByGroup[member.GroupID] = append(membersByGroup[member.GroupID], person)
+		if _, seen := people[member.IdentityID]; !seen || member.IsPrimary {
+			people[member.IdentityID] = person
+		}
+	}
+
+	chart := Chart{
+		TenantID:     in.TenantID,
+		IncludeRoles: includeRoles,
+	}
+
+	var walk func(group storage.Group, path []string, depth int)
+	walk = func(group storage.Group, path []string, depth int) {
+		path = append(append([]string(nil), path...), group.Name)
+		node := Node{
+			ID:       group.ID,
+			ParentID: group.ParentID,
+			Code:     group.Code,
+			Name:     group.Name,
+			Path:     path,
+			Depth:    depth,
+			Members:  membersByGroup[group.ID],
+		}
+		if group.Description != nil {
+			node.Description = *group.Description
+		}
+		for _, subject := range in.Managers[group.ID] {
+			identityID, err := uuid.Parse(subject)
+			if err != nil {
+				continue
+			}
+			manager, ok := people[identityID]
+			if !ok {
+				manager = Person{IdentityID: identityID}
+				if includeRoles {
+					manager.Roles = in.Roles[identityID]
+				}
+			}
+			node.Managers = append(node.Managers, manager)
+		}
+		chart.Nodes = append(chart.Nodes, node)
+
+		for _, child := range children[group.ID] {
+			walk(child, path, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, prefix, 0)
+	}
+	return chart, nil
+}
+
+// Format renders a chart into one file type.
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	Write       func(w io.Writer, chart Chart) error
+}
+
+// Formats lists the supported export formats by name.
+var Formats = map[string]Format{
+	"csv": {
+		Name:        "csv",
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   ".csv",
+		Write:       WriteCSV,
+	},
+	"xlsx": {
+		Name:        "xlsx",
+		ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		Extension:   ".xlsx",
+		Write:       WriteXLSX,
+	},
+	"graphml": {
+		Name:        "graphml",
+		ContentType: "application/graphml+xml",
+		Extension:   ".graphml",
+		Write:       WriteGraphML,
+	},
+	"dot": {
+		Name:        "dot",
+		ContentType: "text/vnd.graphviz; charset=utf-8",
+		Extension:   ".dot",
+		Write:       WriteDOT,
+	},
+}
+
+func displayName(person Person) string {
+	if person.DisplayName != "" {
+		return person.DisplayName
+	}
+	return person.IdentityID.String()
+}
+
+func managerNames(node Node) string {
+	names := make([]string, 0, len(node.Managers))
+	for _, manager := range node.Managers {
+		names = append(names, displayName(manager))
+	}
+	return strings.Join(names, "; ")
+}
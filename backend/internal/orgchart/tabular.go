@@ -0,0 +1,141 @@
+package orgchart
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func memberHeader(chart Chart) []string {
+	header := []string{"path", "group_code", "group_name", "managers", "identity_id", "display_name", "phone", "title", "is_primary"}
+	if chart.IncludeRoles {
+		header = append(header, "roles")
+	}
+	return header
+}
+
+// memberRecords flattens the chart into one record per membership. Groups without
+// members still produce a record so the full structure survives the export.
+func memberRecords(chart Chart) [][]string {
+	var records [][]string
+	for _, node := range chart.Nodes {
+		base := []string{node.PathString(), node.Code, node.Name, managerNames(node)}
+		if len(node.Members) == 0 {
+			record := append(append([]string(nil), base...), "", "", "", "", "")
+			if chart.IncludeRoles {
+				record = append(record, "")
+			}
+			records = append(records, record)
+			continue
+		}
+		for _, member := range node.Members {
+			record := append(append([]string(nil), base...),
+				member.IdentityID.String(),
+				member.DisplayName,
+				member.Phone,
+				member.Title,
+				strconv.FormatBool(member.IsPrimary),
+			)
+			if chart.IncludeRoles {
+				record = append(record, strings.Join(member.Roles, ";"))
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// WriteCSV renders one row per membership with the group path flattened into a column.
+func WriteCSV(w io.Writer, chart Chart) error {
+	// The byte order mark lets spreadsheet applications detect UTF-8 group names.
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(memberHeader(chart)); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(memberRecords(chart)); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteXLSX renders a workbook with a Groups sheet describing the tree and a Members
+// sheet in the same layout as the CSV export.
+func WriteXLSX(w io.Writer, chart Chart) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	const groupsSheet, membersSheet = "Groups", "Members"
+	if err := file.SetSheetName("Sheet1", groupsSheet); err != nil {
+		return err
+	}
+	if _, err := file.NewSheet(membersSheet); err != nil {
+		return err
+	}
+
+	codes := make(map[string]string, len(chart.Nodes))
+	for _, node := range chart.Nodes {
+		codes[node.ID.String()] = node.Code
+	}
+
+	groupRows := [][]string{{"path", "code", "name", "parent_code", "depth", "managers", "member_count", "description"}}
+	for _, node := range chart.Nodes {
+		parentCode := ""
+		if node.ParentID != nil {
+			parentCode = codes[node.ParentID.String()]
+		}
+		groupRows = append(groupRows, []string{
+			node.PathString(),
+			node.Code,
+			node.Name,
+			parentCode,
+			strconv.Itoa(node.Depth),
+			managerNames(node),
+			strconv.Itoa(len(node.Members)),
+			node.Description,
+		})
+	}
+	if err := writeSheet(file, groupsSheet, groupRows); err != nil {
+		return err
+	}
+
+	memberRows := append([][]string{memberHeader(chart)}, memberRecords(chart)...)
+	if err := writeSheet(file, membersSheet, memberRows); err != nil {
+		return err
+	}
+
+	_, err := file.WriteTo(w)
+	return err
+}
+
+func writeSheet(file *excelize.File, sheet string, rows [][]string) error {
+	for idx, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, idx+1)
+		if err != nil {
+			return err
+		}
+		values := make([]any, len(row))
+		for i, value := range row {
+			values[i] = value
+		}
+		if err := file.SetSheetRow(sheet, cell, &values); err != nil {
+			return fmt.Errorf("write %s sheet: %w", sheet, err)
+		}
+	}
+	if len(rows) > 0 {
+		last, err := excelize.CoordinatesToCellName(len(rows[0]), 1)
+		if err != nil {
+			return err
+		}
+		if err := file.AutoFilter(sheet, "A1:"+last, nil); err != nil {
+			return fmt.Errorf("filter %s sheet: %w", sheet, err)
+		}
+	}
+	return nil
+}
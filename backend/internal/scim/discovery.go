@@ -0,0 +1,149 @@
+package scim
+
+// Limits advertised in the service provider configuration.
+const (
+	MaxBulkOperations  = 100
+	MaxBulkPayloadSize = 1 << 20
+	MaxResults         = 200
+)
+
+// ServiceProviderConfig describes the protocol features the endpoint supports.
+func ServiceProviderConfig(baseURL string) map[string]any {
+	return map[string]any{
+		"schemas":          []string{SchemaServiceProviderConfig},
+		"documentationUri": "https://datatracker.ietf.org/doc/html/rfc7644",
+		"patch":            map[string]any{"supported": true},
+		"bulk": map[string]any{
+			"supported":      true,
+			"maxOperations":  MaxBulkOperations,
+			"maxPayloadSize": MaxBulkPayloadSize,
+		},
+		"filter": map[string]any{
+			"supported":  true,
+			"maxResults": MaxResults,
+		},
+		"changePassword": map[string]any{"supported": false},
+		"sort":           map[string]any{"supported": false},
+		"etag":           map[string]any{"supported": false},
+		"authenticationSchemes": []map[string]any{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "Bearer Token",
+				"description": "Per-tenant SCIM token sent as Authorization: Bearer <token>",
+				"primary":     true,
+			},
+		},
+		"meta": map[string]any{
+			"resourceType": "ServiceProviderConfig",
+			"location":     baseURL + "/ServiceProviderConfig",
+		},
+	}
+}
+
+// ResourceTypes lists the resource endpoints exposed by the provider.
+func ResourceTypes(baseURL string) []any {
+	return []any{
+		map[string]any{
+			"schemas":     []string{SchemaResourceType},
+			"id":          "User",
+			"name":        "User",
+			"endpoint":    "/Users",
+			"description": "Tenant member identity",
+			"schema":      SchemaUser,
+			"meta": map[string]any{
+				"resourceType": "ResourceType",
+				"location":     baseURL + "/ResourceTypes/User",
+			},
+		},
+		map[string]any{
+			"schemas":     []string{SchemaResourceType},
+			"id":          "Group",
+			"name":        "Group",
+			"endpoint":    "/Groups",
+			"description": "Organization group",
+			"schema":      SchemaGroup,
+			"meta": map[string]any{
+				"resourceType": "ResourceType",
+				"location":     baseURL + "/ResourceTypes/Group",
+			},
+		},
+	}
+}
+
+// Schemas returns the attribute definitions of the supported resources.
+func Schemas(baseURL string) []any {
+	return []any{
+		map[string]any{
+			"schemas":     []string{SchemaSchema},
+			"id":          SchemaUser,
+			"name":        "User",
+			"description": "User Account",
+			"attributes": []any{
+				attribute("userName", "string", true, false, "server"),
+				attribute("displayName", "string", false, false, "none"),
+				complexAttribute("name", false, []any{
+					attribute("formatted", "string", false, false, "none"),
+					attribute("familyName", "string", false, false, "none"),
+					attribute("givenName", "string", false, false, "none"),
+				}),
+				attribute("title", "string", false, false, "none"),
+				attribute("active", "boolean", false, false, "none"),
+				complexAttribute("phoneNumbers", true, []any{
+					attribute("value", "string", false, false, "none"),
+					attribute("type", "string", false, false, "none"),
+					attribute("primary", "boolean", false, false, "none"),
+				}),
+				complexAttribute("groups", true, []any{
+					attribute("value", "string", false, false, "none"),
+					attribute("display", "string", false, false, "none"),
+				}),
+			},
+			"meta": map[string]any{
+				"resourceType": "Schema",
+				"location":     baseURL + "/Schemas/" + SchemaUser,
+			},
+		},
+		map[string]any{
+			"schemas":     []string{SchemaSchema},
+			"id":          SchemaGroup,
+			"name":        "Group",
+			"description": "Group",
+			"attributes": []any{
+				attribute("displayName", "string", true, false, "none"),
+				complexAttribute("members", true, []any{
+					attribute("value", "string", false, false, "none"),
+					attribute("display", "string", false, false, "none"),
+				}),
+			},
+			"meta": map[string]any{
+				"resourceType": "Schema",
+				"location":     baseURL + "/Schemas/" + SchemaGroup,
+			},
+		},
+	}
+}
+
+func attribute(name, kind string, required, caseExact bool, uniqueness string) map[string]any {
+	return map[string]any{
+		"name":        name,
+		"type":        kind,
+		"multiValued": false,
+		"required":    required,
+		"caseExact":   caseExact,
+		"mutability":  "readWrite",
+		"returned":    "default",
+		"uniqueness":  uniqueness,
+	}
+}
+
+func complexAttribute(name string, multiValued bool, subAttributes []any) map[string]any {
+	return map[string]any{
+		"name":          name,
+		"type":          "complex",
+		"multiValued":   multiValued,
+		"required":      false,
+		"mutability":    "readWrite",
+		"returned":      "default",
+		"subAttributes": subAttributes,
+	}
+}
@@ -0,0 +1,448 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Filter is a parsed SCIM filter expression evaluated against a JSON-decoded resource.
+type Filter interface {
+	Match(resource map[string]any) bool
+}
+
+// ParseFilter parses a filter such as `userName eq "alice" and active eq true`.
+// Supported operators are eq, ne, co, sw, ew, gt, ge, lt, le and pr, combined with
+// and, or, not and parentheses, plus value paths like `emails[type eq "work"]`.
+func ParseFilter(expression string) (Filter, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, invalidFilter("unexpected %q", p.peek().text)
+	}
+	return filter, nil
+}
+
+func invalidFilter(format string, args ...any) *Error {
+	return NewError(http.StatusBadRequest, ErrInvalidFilter, fmt.Sprintf(format, args...))
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpen
+	tokenClose
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+}
+
+func tokenizeFilter(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(input); {
+		ch := input[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(':
+			tokens = append(tokens, filterToken{kind: tokenOpen, text: "("})
+			i++
+		case ch == ')':
+			tokens = append(tokens, filterToken{kind: tokenClose, text: ")"})
+			i++
+		case ch == '[':
+			tokens = append(tokens, filterToken{kind: tokenOpenBracket, text: "["})
+			i++
+		case ch == ']':
+			tokens = append(tokens, filterToken{kind: tokenCloseBracket, text: "]"})
+			i++
+		case ch == '"':
+			var sb strings.Builder
+			j := i + 1
+			closed := false
+			for j < len(input) {
+				if input[j] == '\\' && j+1 < len(input) {
+					sb.WriteByte(input[j+1])
+					j += 2
+					continue
+				}
+				if input[j] == '"' {
+					closed = true
+					break
+				}
+				sb.WriteByte(input[j])
+				j++
+			}
+			if !closed {
+				return nil, invalidFilter("unterminated string")
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, text: sb.String()})
+			i = j + 1
+		default:
+			j := i
+			for j < len(input) && !strings.ContainsRune(" \t\n\r()[]\"", rune(input[j])) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: tokenWord, text: input[i:j]})
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, invalidFilter("empty filter")
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.done() {
+		return filterToken{kind: -1}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *filterParser) peekKeyword(keyword string) bool {
+	token := p.peek()
+	return token.kind == tokenWord && strings.EqualFold(token.text, keyword)
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.peekKeyword("not") {
+		p.next()
+		if p.peek().kind != tokenOpen {
+			return nil, invalidFilter("expected ( after not")
+		}
+		inner, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{inner: inner}, nil
+	}
+	if p.peek().kind == tokenOpen {
+		return p.parseGroup()
+	}
+	return p.parseAttribute()
+}
+
+func (p *filterParser) parseGroup() (Filter, error) {
+	p.next()
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.next().kind != tokenClose {
+		return nil, invalidFilter("missing )")
+	}
+	return inner, nil
+}
+
+func (p *filterParser) parseAttribute() (Filter, error) {
+	token := p.next()
+	if token.kind != tokenWord {
+		return nil, invalidFilter("expected attribute path")
+	}
+	path := splitAttributePath(token.text)
+
+	if p.peek().kind == tokenOpenBracket {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseBracket {
+			return nil, invalidFilter("missing ]")
+		}
+		return valuePathFilter{path: path, inner: inner}, nil
+	}
+
+	operator := p.next()
+	if operator.kind != tokenWord {
+		return nil, invalidFilter("expected operator after %q", token.text)
+	}
+	op := strings.ToLower(operator.text)
+	if op == "pr" {
+		return presentFilter{path: path}, nil
+	}
+	switch op {
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, invalidFilter("unsupported operator %q", operator.text)
+	}
+
+	valueToken := p.next()
+	var value any
+	switch valueToken.kind {
+	case tokenString:
+		value = valueToken.text
+	case tokenWord:
+		switch strings.ToLower(valueToken.text) {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			number, err := strconv.ParseFloat(valueToken.text, 64)
+			if err != nil {
+				return nil, invalidFilter("invalid comparison value %q", valueToken.text)
+			}
+			value = number
+		}
+	default:
+		return nil, invalidFilter("expected comparison value after %q", operator.text)
+	}
+	return compareFilter{path: path, op: op, value: value}, nil
+}
+
+// splitAttributePath strips an optional schema URN prefix and splits sub-attributes.
+func splitAttributePath(raw string) []string {
+	if strings.HasPrefix(strings.ToLower(raw), "urn:") {
+		if idx := strings.LastIndex(raw, ":"); idx >= 0 {
+			raw = raw[idx+1:]
+		}
+	}
+	return strings.Split(raw, ".")
+}
+
+type andFilter struct{ left, right Filter }
+
+func (f andFilter) Match(resource map[string]any) bool {
+	return f.left.Match(resource) && f.right.Match(resource)
+}
+
+type orFilter struct{ left, right Filter }
+
+func (f orFilter) Match(resource map[string]any) bool {
+	return f.left.Match(resource) || f.right.Match(resource)
+}
+
+type notFilter struct{ inner Filter }
+
+func (f notFilter) Match(resource map[string]any) bool {
+	return !f.inner.Match(resource)
+}
+
+type presentFilter struct{ path []string }
+
+func (f presentFilter) Match(resource map[string]any) bool {
+	for _, value := range lookupValues(resource, f.path) {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			if v != "" {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+type valuePathFilter struct {
+	path  []string
+	inner Filter
+}
+
+func (f valuePathFilter) Match(resource map[string]any) bool {
+	for _, node := range lookupNodes(resource, f.path) {
+		if element, ok := node.(map[string]any); ok && f.inner.Match(element) {
+			return true
+		}
+	}
+	return false
+}
+
+type compareFilter struct {
+	path  []string
+	op    string
+	value any
+}
+
+func (f compareFilter) Match(resource map[string]any) bool {
+	values := lookupValues(resource, f.path)
+	if f.op == "ne" {
+		for _, actual := range values {
+			if compareValue(actual, "eq", f.value) {
+				return false
+			}
+		}
+		return len(values) > 0 || f.value != nil
+	}
+	if f.value == nil && f.op == "eq" && len(values) == 0 {
+		return true
+	}
+	for _, actual := range values {
+		if compareValue(actual, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupNodes resolves path against resource, flattening multi-valued attributes.
+func lookupNodes(resource any, path []string) []any {
+	switch node := resource.(type) {
+	case []any:
+		var out []any
+		for _, element := range node {
+			out = append(out, lookupNodes(element, path)...)
+		}
+		return out
+	case map[string]any:
+		if len(path) == 0 {
+			return []any{node}
+		}
+		key, ok := findKey(node, path[0])
+		if !ok {
+			return nil
+		}
+		return lookupNodes(node[key], path[1:])
+	default:
+		if len(path) > 0 {
+			return nil
+		}
+		return []any{node}
+	}
+}
+
+// lookupValues is lookupNodes for comparisons: complex multi-valued entries addressed
+// without a sub-attribute compare by their "value" member.
+func lookupValues(resource any, path []string) []any {
+	nodes := lookupNodes(resource, path)
+	for i, node := range nodes {
+		if element, ok := node.(map[string]any); ok {
+			if value, ok := element["value"]; ok {
+				nodes[i] = value
+			}
+		}
+	}
+	return nodes
+}
+
+func compareValue(actual any, op string, expected any) bool {
+	switch want := expected.(type) {
+	case nil:
+		return op == "eq" && actual == nil
+	case bool:
+		got, ok := actual.(bool)
+		return ok && op == "eq" && got == want
+	case float64:
+		got, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return got == want
+		case "gt":
+			return got > want
+		case "ge":
+			return got >= want
+		case "lt":
+			return got < want
+		case "le":
+			return got <= want
+		}
+		return false
+	case string:
+		got, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		got = strings.ToLower(got)
+		want = strings.ToLower(want)
+		switch op {
+		case "eq":
+			return got == want
+		case "co":
+			return strings.Contains(got, want)
+		case "sw":
+			return strings.HasPrefix(got, want)
+		case "ew":
+			return strings.HasSuffix(got, want)
+		case "gt":
+			return got > want
+		case "ge":
+			return got >= want
+		case "lt":
+			return got < want
+		case "le":
+			return got <= want
+		}
+	}
+	return false
+}
+
+func findKey(node map[string]any, name string) (string, bool) {
+	if _, ok := node[name]; ok {
+		return name, true
+	}
+	for key := range node {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return name, false
+}
@@ -0,0 +1,280 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PatchOperation is one entry of a PATCH request.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// patchPath is a parsed PATCH target: attribute, optional value filter and sub-attribute.
+type patchPath struct {
+	attribute    string
+	filter       Filter
+	subAttribute string
+}
+
+// ApplyPatch applies operations to a JSON-decoded resource in place. Callers decode the
+// patched document back into the typed resource and persist it like a PUT.
+func ApplyPatch(resource map[string]any, operations []PatchOperation) error {
+	if len(operations) == 0 {
+		return NewError(http.StatusBadRequest, ErrInvalidSyntax, "no operations supplied")
+	}
+	for _, operation := range operations {
+		if err := applyOperation(resource, operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyOperation(resource map[string]any, operation PatchOperation) error {
+	op := strings.ToLower(strings.TrimSpace(operation.Op))
+
+	var value any
+	if len(operation.Value) > 0 {
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return NewError(http.StatusBadRequest, ErrInvalidValue, "operation value is not valid JSON")
+		}
+	}
+
+	path := strings.TrimSpace(operation.Path)
+	if path == "" {
+		switch op {
+		case "add", "replace":
+			attributes, ok := value.(map[string]any)
+			if !ok {
+				return NewError(http.StatusBadRequest, ErrInvalidValue, "operation without path requires an object value")
+			}
+			for key, attributeValue := range attributes {
+				target, err := parsePatchPath(key)
+				if err != nil {
+					return err
+				}
+				if err := applyAt(resource, op, target, attributeValue); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "remove":
+			return NewError(http.StatusBadRequest, ErrNoTarget, "remove requires a path")
+		default:
+			return NewError(http.StatusBadRequest, ErrInvalidSyntax, fmt.Sprintf("unsupported operation %q", operation.Op))
+		}
+	}
+
+	target, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case "add", "replace", "remove":
+		return applyAt(resource, op, target, value)
+	default:
+		return NewError(http.StatusBadRequest, ErrInvalidSyntax, fmt.Sprintf("unsupported operation %q", operation.Op))
+	}
+}
+
+func parsePatchPath(raw string) (patchPath, error) {
+	prefixEnd := len(raw)
+	if idx := strings.Index(raw, "["); idx >= 0 {
+		prefixEnd = idx
+	}
+	if strings.HasPrefix(strings.ToLower(raw), "urn:") {
+		if idx := strings.LastIndex(raw[:prefixEnd], ":"); idx >= 0 {
+			raw = raw[idx+1:]
+			prefixEnd -= idx + 1
+		}
+	}
+
+	if prefixEnd < len(raw) {
+		closing := strings.LastIndex(raw, "]")
+		if closing < prefixEnd {
+			return patchPath{}, NewError(http.StatusBadRequest, ErrInvalidPath, "missing ] in path")
+		}
+		filter, err := ParseFilter(raw[prefixEnd+1 : closing])
+		if err != nil {
+			return patchPath{}, NewError(http.StatusBadRequest, ErrInvalidPath, err.Error())
+		}
+		target := patchPath{attribute: raw[:prefixEnd], filter: filter}
+		if rest := raw[closing+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return patchPath{}, NewError(http.StatusBadRequest, ErrInvalidPath, "invalid sub-attribute in path")
+			}
+			target.subAttribute = rest[1:]
+		}
+		return target, nil
+	}
+
+	parts := strings.SplitN(raw, ".", 2)
+	target := patchPath{attribute: parts[0]}
+	if len(parts) == 2 {
+		target.subAttribute = parts[1]
+	}
+	if target.attribute == "" {
+		return patchPath{}, NewError(http.StatusBadRequest, ErrInvalidPath, "empty attribute in path")
+	}
+	return target, nil
+}
+
+func applyAt(resource map[string]any, op string, target patchPath, value any) error {
+	key, exists := findKey(resource, target.attribute)
+
+	if target.filter != nil {
+		elements, _ := resource[key].([]any)
+		matched := false
+		kept := make([]any, 0, len(elements))
+		for _, element := range elements {
+			item, ok := element.(map[string]any)
+			if !ok || !target.filter.Match(item) {
+				kept = append(kept, element)
+				continue
+			}
+			matched = true
+			switch {
+			case op == "remove" && target.subAttribute == "":
+				continue
+			case op == "remove":
+				subKey, _ := findKey(item, target.subAttribute)
+				delete(item, subKey)
+			case target.subAttribute != "":
+				subKey, _ := findKey(item, target.subAttribute)
+				item[subKey] = value
+			default:
+				replacement, ok := value.(map[string]any)
+				if !ok {
+					return NewError(http.StatusBadRequest, ErrInvalidValue, "filtered value must be an object")
+				}
+				for k, v := range replacement {
+					subKey, _ := findKey(item, k)
+					item[subKey] = v
+				}
+			}
+			kept = append(kept, item)
+		}
+		if !matched {
+			if op == "remove" {
+				return nil
+			}
+			return NewError(http.StatusBadRequest, ErrNoTarget, fmt.Sprintf("no values match %q", target.attribute))
+		}
+		resource[key] = kept
+		return nil
+	}
+
+	if target.subAttribute != "" {
+		parent, ok := resource[key].(map[string]any)
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			parent = map[string]any{}
+			resource[key] = parent
+		}
+		subKey, _ := findKey(parent, target.subAttribute)
+		if op == "remove" {
+			delete(parent, subKey)
+		} else {
+			parent[subKey] = value
+		}
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		// Some providers remove specific members by passing them as the value instead of
+		// a filtered path.
+		if existing, ok := resource[key].([]any); ok && value != nil {
+			resource[key] = removeByValue(existing, value)
+			return nil
+		}
+		delete(resource, key)
+	case "add":
+		existing, isList := resource[key].([]any)
+		if exists && isList {
+			resource[key] = appendUnique(existing, value)
+			return nil
+		}
+		if incoming, ok := value.(map[string]any); ok {
+			if current, ok := resource[key].(map[string]any); ok {
+				for k, v := range incoming {
+					subKey, _ := findKey(current, k)
+					current[subKey] = v
+				}
+				return nil
+			}
+		}
+		resource[key] = value
+	default:
+		resource[key] = value
+	}
+	return nil
+}
+
+func appendUnique(existing []any, value any) []any {
+	incoming, ok := value.([]any)
+	if !ok {
+		incoming = []any{value}
+	}
+	seen := make(map[string]struct{}, len(existing))
+	for _, element := range existing {
+		if id, ok := elementValue(element); ok {
+			seen[id] = struct{}{}
+		}
+	}
+	for _, element := range incoming {
+		if id, ok := elementValue(element); ok {
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+		}
+		existing = append(existing, element)
+	}
+	return existing
+}
+
+func removeByValue(existing []any, value any) []any {
+	targets, ok := value.([]any)
+	if !ok {
+		targets = []any{value}
+	}
+	remove := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		if id, ok := elementValue(target); ok {
+			remove[id] = struct{}{}
+		}
+	}
+	kept := existing[:0]
+	for _, element := range existing {
+		if id, ok := elementValue(element); ok {
+			if _, drop := remove[id]; drop {
+				continue
+			}
+		}
+		kept = append(kept, element)
+	}
+	return kept
+}
+
+func elementValue(element any) (string, bool) {
+	switch v := element.(type) {
+	case string:
+		return v, true
+	case map[string]any:
+		key, ok := findKey(v, "value")
+		if !ok {
+			return "", false
+		}
+		id, ok := v[key].(string)
+		return id, ok
+	}
+	return "", false
+}
@@ -0,0 +1,254 @@
+// Package scim implements the protocol pieces of SCIM 2.0 (RFC 7643/7644) used by the
+// provisioning endpoints: resource representations, errors, filters and PATCH operations.
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema URNs used in requests and responses.
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaBulkRequest           = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	SchemaBulkResponse          = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// ContentType is the media type of every SCIM response.
+const ContentType = "application/scim+json"
+
+// Detail error types defined in RFC 7644 section 3.12.
+const (
+	ErrInvalidFilter = "invalidFilter"
+	ErrTooMany       = "tooMany"
+	ErrUniqueness    = "uniqueness"
+	ErrMutability    = "mutability"
+	ErrInvalidSyntax = "invalidSyntax"
+	ErrInvalidPath   = "invalidPath"
+	ErrNoTarget      = "noTarget"
+	ErrInvalidValue  = "invalidValue"
+)
+
+// Error is a SCIM error response. It doubles as a Go error so protocol helpers can
+// report the exact status and scimType to return.
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+// NewError constructs a SCIM error.
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{Status: status, ScimType: scimType, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	if e.ScimType != "" {
+		return fmt.Sprintf("scim %d %s: %s", e.Status, e.ScimType, e.Detail)
+	}
+	return fmt.Sprintf("scim %d: %s", e.Status, e.Detail)
+}
+
+// MarshalJSON renders the error using the SCIM error schema, where status is a string.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		ScimType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail,omitempty"`
+	}{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(e.Status),
+		ScimType: e.ScimType,
+		Detail:   e.Detail,
+	})
+}
+
+// Meta is the common resource metadata.
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+// Name is the components of a user's name.
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue is an entry of a multi-valued attribute such as phoneNumbers.
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Display string `json:"display,omitempty"`
+	Primary Bool   `json:"primary,omitempty"`
+}
+
+// Reference points at another resource, e.g. a group member or a user's group.
+type Reference struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// User is the SCIM representation of a provisioned identity.
+type User struct {
+	Schemas      []string     `json:"schemas"`
+	ID           string       `json:"id,omitempty"`
+	ExternalID   string       `json:"externalId,omitempty"`
+	UserName     string       `json:"userName"`
+	Name         *Name        `json:"name,omitempty"`
+	DisplayName  string       `json:"displayName,omitempty"`
+	Title        string       `json:"title,omitempty"`
+	Active       *Bool        `json:"active,omitempty"`
+	PhoneNumbers []MultiValue `json:"phoneNumbers,omitempty"`
+	Groups       []Reference  `json:"groups,omitempty"`
+	Meta         *Meta        `json:"meta,omitempty"`
+}
+
+// PrimaryPhone returns the primary phone number, falling back to the first one.
+func (u User) PrimaryPhone() string {
+	for _, phone := range u.PhoneNumbers {
+		if bool(phone.Primary) && strings.TrimSpace(phone.Value) != "" {
+			return strings.TrimSpace(phone.Value)
+		}
+	}
+	for _, phone := range u.PhoneNumbers {
+		if strings.TrimSpace(phone.Value) != "" {
+			return strings.TrimSpace(phone.Value)
+		}
+	}
+	return ""
+}
+
+// ResolvedDisplayName picks the best available human readable name.
+func (u User) ResolvedDisplayName() string {
+	if name := strings.TrimSpace(u.DisplayName); name != "" {
+		return name
+	}
+	if u.Name != nil {
+		if formatted := strings.TrimSpace(u.Name.Formatted); formatted != "" {
+			return formatted
+		}
+		// Family name first matches the Chinese naming order used across the portal.
+		if joined := strings.TrimSpace(u.Name.FamilyName + u.Name.GivenName); joined != "" {
+			return joined
+		}
+	}
+	return strings.TrimSpace(u.UserName)
+}
+
+// Group is the SCIM representation of an organization group.
+type Group struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []Reference `json:"members,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// ListResponse wraps a page of query results.
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// NewListResponse builds a list response for one page of resources.
+func NewListResponse(resources []any, total, startIndex int) ListResponse {
+	if resources == nil {
+		resources = []any{}
+	}
+	return ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// PatchRequest is the body of a PATCH request.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// BulkRequest is the body of a POST /Bulk request.
+type BulkRequest struct {
+	Schemas      []string        `json:"schemas"`
+	FailOnErrors int             `json:"failOnErrors"`
+	Operations   []BulkOperation `json:"Operations"`
+}
+
+// BulkOperation is a single operation within a bulk request.
+type BulkOperation struct {
+	Method string          `json:"method"`
+	BulkID string          `json:"bulkId,omitempty"`
+	Path   string          `json:"path"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// BulkResponse is the body returned for a bulk request.
+type BulkResponse struct {
+	Schemas    []string     `json:"schemas"`
+	Operations []BulkResult `json:"Operations"`
+}
+
+// BulkResult reports the outcome of one bulk operation.
+type BulkResult struct {
+	Method   string `json:"method"`
+	BulkID   string `json:"bulkId,omitempty"`
+	Location string `json:"location,omitempty"`
+	Status   string `json:"status"`
+	Response any    `json:"response,omitempty"`
+}
+
+// Bool accepts both JSON booleans and the "True"/"False" strings some identity
+// providers send for boolean attributes.
+type Bool bool
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch value := raw.(type) {
+	case nil:
+		*b = false
+	case bool:
+		*b = Bool(value)
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*b = Bool(parsed)
+	default:
+		return fmt.Errorf("invalid boolean %s", string(data))
+	}
+	return nil
+}
+
+// BoolPtr returns a pointer to a Bool holding value.
+func BoolPtr(value bool) *Bool {
+	b := Bool(value)
+	return &b
+}
@@ -0,0 +1,219 @@
+package seed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+)
+
+// Fixture is the document applied by `portal seed`. Everything is matched by code, or
+// by phone for identities, and only created or updated: entries missing from the fixture
+// are left alone.
+type Fixture struct {
+	// Roles are global roles.
+	Roles []Role `yaml:"roles"`
+	// Tenants are created in order, each with its roles, group tree and assignments.
+	Tenants []Tenant `yaml:"tenants"`
+	// Users are identities outside any org chart, such as platform administrators.
+	Users []User `yaml:"users"`
+	// Assignments grant global roles to users or members by phone.
+	Assignments []Assignment `yaml:"assignments"`
+}
+
+// Tenant describes a tenant and everything seeded inside it.
+type Tenant struct {
+	Code         string         `yaml:"code"`
+	Name         string         `yaml:"name"`
+	Status       string         `yaml:"status"`
+	ContactName  *string        `yaml:"contact_name"`
+	ContactPhone *string        `yaml:"contact_phone"`
+	Metadata     map[string]any `yaml:"metadata"`
+	Roles        []Role         `yaml:"roles"`
+	Groups       []Group        `yaml:"groups"`
+	Assignments  []Assignment   `yaml:"assignments"`
+}
+
+// Role describes a role defined in the portal.
+type Role struct {
+	Code        string         `yaml:"code"`
+	Name        string         `yaml:"name"`
+	Description *string        `yaml:"description"`
+	Permissions []string       `yaml:"permissions"`
+	Metadata    map[string]any `yaml:"metadata"`
+}
+
+// Group describes a group with its members and child groups. Without sort_order a group
+// is ordered by its position in the fixture.
+type Group struct {
+	Code        string   `yaml:"code"`
+	Name        string   `yaml:"name"`
+	Description *string  `yaml:"description"`
+	SortOrder   *int32   `yaml:"sort_order"`
+	Members     []Member `yaml:"members"`
+	Children    []Group  `yaml:"children"`
+}
+
+// Member places an identity in a group, registering the phone when it is new. The
+// password only applies to new identities. Roles are identity roles such as
+// tenant_admin, stored on the Kratos identity; portal roles are granted with
+// assignments.
+type Member struct {
+	Phone    string   `yaml:"phone"`
+	Name     string   `yaml:"name"`
+	Password string   `yaml:"password"`
+	Title    *string  `yaml:"title"`
+	Primary  bool     `yaml:"primary"`
+	Roles    []string `yaml:"roles"`
+}
+
+// User is an identity that belongs to no group. Tenant is the code of the tenant the
+// identity is attached to, if any.
+type User struct {
+	Phone    string   `yaml:"phone"`
+	Name     string   `yaml:"name"`
+	Password string   `yaml:"password"`
+	UserType string   `yaml:"user_type"`
+	Tenant   string   `yaml:"tenant"`
+	Roles    []string `yaml:"roles"`
+}
+
+// Assignment grants a role to the identities registered with the given phones.
+type Assignment struct {
+	Role    string   `yaml:"role"`
+	Members []string `yaml:"members"`
+}
+
+// Load reads a fixture file. ${VAR} references are replaced with environment variables
+// before parsing, so secrets such as passwords can stay out of the file.
+func Load(path string) (Fixture, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("read fixture: %w", err)
+	}
+	return Parse(bytes.NewReader(raw))
+}
+
+// Parse decodes and validates a fixture, expanding environment variables first.
+func Parse(r io.Reader) (Fixture, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("read fixture: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(os.ExpandEnv(string(raw))))
+	decoder.KnownFields(true)
+	var fixture Fixture
+	if err := decoder.Decode(&fixture); err != nil && !errors.Is(err, io.EOF) {
+		return Fixture{}, fmt.Errorf("parse fixture: %w", err)
+	}
+	if err := fixture.normalize(); err != nil {
+		return Fixture{}, err
+	}
+	return fixture, nil
+}
+
+// normalize trims codes, normalises phones and rejects incomplete or duplicate entries.
+func (f *Fixture) normalize() error {
+	var problems []error
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	normalizeRoles := func(where string, roles []Role) {
+		seen := map[string]bool{}
+		for i := range roles {
+			role := &roles[i]
+			role.Code = strings.TrimSpace(role.Code)
+			role.Name = strings.TrimSpace(role.Name)
+			if role.Code == "" || role.Name == "" {
+				fail("%s: role %d needs a code and a name", where, i+1)
+				continue
+			}
+			if seen[role.Code] {
+				fail("%s: role %s is listed twice", where, role.Code)
+			}
+			seen[role.Code] = true
+		}
+	}
+	normalizeAssignments := func(where string, assignments []Assignment) {
+		for i := range assignments {
+			assignment := &assignments[i]
+			assignment.Role = strings.TrimSpace(assignment.Role)
+			if assignment.Role == "" {
+				fail("%s: assignment %d needs a role", where, i+1)
+			}
+			for j, phone := range assignment.Members {
+				assignment.Members[j] = kratos.NormalizePhone(phone)
+			}
+		}
+	}
+
+	normalizeRoles("roles", f.Roles)
+	normalizeAssignments("assignments", f.Assignments)
+
+	tenantCodes := map[string]bool{}
+	for i := range f.Tenants {
+		tenant := &f.Tenants[i]
+		tenant.Code = strings.TrimSpace(tenant.Code)
+		tenant.Name = strings.TrimSpace(tenant.Name)
+		if tenant.Code == "" || tenant.Name == "" {
+			fail("tenant %d needs a code and a name", i+1)
+			continue
+		}
+		if tenantCodes[tenant.Code] {
+			fail("tenant %s is listed twice", tenant.Code)
+		}
+		tenantCodes[tenant.Code] = true
+
+		where := "tenant " + tenant.Code
+		normalizeRoles(where, tenant.Roles)
+		normalizeAssignments(where, tenant.Assignments)
+
+		groupCodes := map[string]bool{}
+		var walk func(groups []Group)
+		walk = func(groups []Group) {
+			for j := range groups {
+				group := &groups[j]
+				group.Code = strings.TrimSpace(group.Code)
+				group.Name = strings.TrimSpace(group.Name)
+				if group.Code == "" || group.Name == "" {
+					fail("%s: every group needs a code and a name", where)
+					continue
+				}
+				if groupCodes[group.Code] {
+					fail("%s: group %s is listed twice", where, group.Code)
+				}
+				groupCodes[group.Code] = true
+				for k := range group.Members {
+					member := &group.Members[k]
+					member.Phone = kratos.NormalizePhone(member.Phone)
+					member.Name = strings.TrimSpace(member.Name)
+					if member.Phone == "" || member.Name == "" {
+						fail("%s: members of group %s need a phone and a name", where, group.Code)
+					}
+				}
+				walk(group.Children)
+			}
+		}
+		walk(tenant.Groups)
+	}
+
+	for i := range f.Users {
+		user := &f.Users[i]
+		user.Phone = kratos.NormalizePhone(user.Phone)
+		user.Name = strings.TrimSpace(user.Name)
+		user.Tenant = strings.TrimSpace(user.Tenant)
+		if user.Phone == "" || user.Name == "" {
+			fail("user %d needs a phone and a name", i+1)
+		}
+	}
+
+	return errors.Join(problems...)
+}
@@ -0,0 +1,731 @@
+// Package seed applies declarative fixtures of tenants, org charts, members and roles.
+// Fixtures are applied through the same repositories and Ory clients as the API, so the
+// database, Kratos and Keto end up as if an administrator had created everything by hand.
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/keto"
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/server"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	defaultTenantStatus = "active"
+	defaultUserType     = "internal"
+	listPageSize        = 100
+)
+
+// Kinds of entries counted in a Report.
+const (
+	KindTenant     = "tenant"
+	KindRole       = "role"
+	KindGroup      = "group"
+	KindIdentity   = "identity"
+	KindMember     = "member"
+	KindAssignment = "assignment"
+)
+
+// Kinds lists the entry kinds in the order they are applied.
+var Kinds = []string{KindTenant, KindRole, KindGroup, KindIdentity, KindMember, KindAssignment}
+
+// Counts tallies what happened to the entries of one kind.
+type Counts struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Report summarises an applied fixture by entry kind.
+type Report map[string]*Counts
+
+func (r Report) count(kind string, created, updated bool) {
+	counts, ok := r[kind]
+	if !ok {
+		counts = &Counts{}
+		r[kind] = counts
+	}
+	switch {
+	case created:
+		counts.Created++
+	case updated:
+		counts.Updated++
+	default:
+		counts.Unchanged++
+	}
+}
+
+// Seeder applies fixtures.
+type Seeder struct {
+	tenants         *storage.TenantRepository
+	groups          *storage.GroupRepository
+	roles           *storage.RoleRepository
+	permissions     *storage.PermissionRepository
+	kratos          *kratos.Client
+	keto            *keto.Client
+	namespacePrefix string
+	logger          *zap.Logger
+}
+
+// New constructs a seeder. namespacePrefix is the Keto namespace of role bindings, as
+// configured for the server.
+func New(tenants *storage.TenantRepository, groups *storage.GroupRepository, roles *storage.RoleRepository, permissions *storage.PermissionRepository, kratosClient *kratos.Client, ketoClient *keto.Client, namespacePrefix string, logger *zap.Logger) *Seeder {
+	return &Seeder{
+		tenants:         tenants,
+		groups:          groups,
+		roles:           roles,
+		permissions:     permissions,
+		kratos:          kratosClient,
+		keto:            ketoClient,
+		namespacePrefix: namespacePrefix,
+		logger:          logger.Named("seed"),
+	}
+}
+
+// run holds the state of one Apply call.
+type run struct {
+	*Seeder
+	report     Report
+	catalog    map[string]string
+	identities map[string]uuid.UUID
+}
+
+// Apply creates or updates everything in fixture. It stops at the first error; entries
+// applied before it stay in place, and applying the fixture again continues from there.
+func (s *Seeder) Apply(ctx context.Context, fixture Fixture) (Report, error) {
+	r := &run{
+		Seeder:     s,
+		report:     Report{},
+		identities: map[string]uuid.UUID{},
+	}
+
+	permissions, err := s.permissions.ListPermissions(ctx)
+	if err != nil {
+		return r.report, err
+	}
+	r.catalog = make(map[string]string, len(permissions))
+	for _, permission := range permissions {
+		r.catalog[strings.ToLower(strings.TrimSpace(permission.Code))] = permission.Scope
+	}
+
+	globalRoles := map[string]storage.Role{}
+	for _, role := range fixture.Roles {
+		applied, err := r.applyRole(ctx, nil, role)
+		if err != nil {
+			return r.report, fmt.Errorf("role %s: %w", role.Code, err)
+		}
+		globalRoles[applied.Code] = applied
+	}
+
+	tenantIDs := map[string]uuid.UUID{}
+	for _, tenant := range fixture.Tenants {
+		tenantID, err := r.applyTenant(ctx, tenant)
+		if err != nil {
+			return r.report, fmt.Errorf("tenant %s: %w", tenant.Code, err)
+		}
+		tenantIDs[tenant.Code] = tenantID
+	}
+
+	for _, user := range fixture.Users {
+		var tenantID *uuid.UUID
+		if user.Tenant != "" {
+			id, ok := tenantIDs[user.Tenant]
+			if !ok {
+				tenant, err := r.findTenant(ctx, user.Tenant)
+				if err != nil {
+					return r.report, fmt.Errorf("user %s: %w", user.Phone, err)
+				}
+				if tenant == nil {
+					return r.report, fmt.Errorf("user %s: unknown tenant %s", user.Phone, user.Tenant)
+				}
+				id = tenant.ID
+			}
+			tenantID = &id
+		}
+		userType := user.UserType
+		if userType == "" {
+			userType = defaultUserType
+		}
+		// Identity roles of users are global, like the platform administrator role.
+		if _, err := r.ensureIdentity(ctx, user.Phone, user.Name, user.Password, userType, tenantID, "", user.Roles); err != nil {
+			return r.report, fmt.Errorf("user %s: %w", user.Phone, err)
+		}
+	}
+
+	if err := r.applyAssignments(ctx, nil, globalRoles, fixture.Assignments); err != nil {
+		return r.report, err
+	}
+	return r.report, nil
+}
+
+func (r *run) applyTenant(ctx context.Context, spec Tenant) (uuid.UUID, error) {
+	status := spec.Status
+	if status == "" {
+		status = defaultTenantStatus
+	}
+	metadata, err := encodeMetadata(spec.Metadata)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	existing, err := r.findTenant(ctx, spec.Code)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var tenant storage.Tenant
+	switch {
+	case existing == nil:
+		tenant, err = r.tenants.CreateTenant(ctx, storage.Tenant{
+			Code:         spec.Code,
+			Name:         spec.Name,
+			Status:       status,
+			ContactName:  spec.ContactName,
+			ContactPhone: spec.ContactPhone,
+			Metadata:     metadata,
+		})
+		if err != nil {
+			return uuid.Nil, err
+		}
+		r.logger.Info("created tenant", zap.String("code", tenant.Code), zap.String("id", tenant.ID.String()))
+		r.report.count(KindTenant, true, false)
+
+	default:
+		tenant = *existing
+		changed := tenant.Name != spec.Name || tenant.Status != status
+		tenant.Name, tenant.Status = spec.Name, status
+		if spec.ContactName != nil && !equalString(tenant.ContactName, spec.ContactName) {
+			tenant.ContactName, changed = spec.ContactName, true
+		}
+		if spec.ContactPhone != nil && !equalString(tenant.ContactPhone, spec.ContactPhone) {
+			tenant.ContactPhone, changed = spec.ContactPhone, true
+		}
+		if spec.Metadata != nil && !equalJSON(tenant.Metadata, metadata) {
+			tenant.Metadata, changed = metadata, true
+		}
+		if changed {
+			if tenant, err = r.tenants.UpdateTenant(ctx, tenant, nil); err != nil {
+				return uuid.Nil, err
+			}
+			r.logger.Info("updated tenant", zap.String("code", tenant.Code))
+		}
+		r.report.count(KindTenant, false, changed)
+	}
+
+	roles := map[string]storage.Role{}
+	for _, spec := range spec.Roles {
+		role, err := r.applyRole(ctx, &tenant.ID, spec)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("role %s: %w", spec.Code, err)
+		}
+		roles[role.Code] = role
+	}
+
+	groups, err := r.groups.ListGroups(ctx, &tenant.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	byCode := make(map[string]storage.Group, len(groups))
+	for _, group := range groups {
+		byCode[group.Code] = group
+	}
+	if err := r.applyGroups(ctx, tenant.ID, nil, spec.Groups, byCode); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := r.applyAssignments(ctx, &tenant.ID, roles, spec.Assignments); err != nil {
+		return uuid.Nil, err
+	}
+	return tenant.ID, nil
+}
+
+// findTenant looks a tenant up by its exact code. It returns nil when there is none.
+func (r *run) findTenant(ctx context.Context, code string) (*storage.Tenant, error) {
+	for offset := int32(0); ; offset += listPageSize {
+		tenants, total, err := r.tenants.ListTenants(ctx, code, "", listPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, tenant := range tenants {
+			if tenant.Code == code {
+				return &tenant, nil
+			}
+		}
+		if len(tenants) == 0 || int64(offset)+int64(len(tenants)) >= total {
+			return nil, nil
+		}
+	}
+}
+
+// applyRole creates or updates a role and writes its permission bindings to Keto. A nil
+// tenantID makes a global role.
+func (r *run) applyRole(ctx context.Context, tenantID *uuid.UUID, spec Role) (storage.Role, error) {
+	scope := "global"
+	if tenantID != nil {
+		scope = "tenant"
+	}
+	permissions, err := r.normalizePermissions(spec.Permissions, scope)
+	if err != nil {
+		return storage.Role{}, err
+	}
+	metadata, err := encodeMetadata(spec.Metadata)
+	if err != nil {
+		return storage.Role{}, err
+	}
+
+	existing, err := r.findRole(ctx, tenantID, scope, spec.Code)
+	if err != nil {
+		return storage.Role{}, err
+	}
+
+	if existing == nil {
+		created, err := r.roles.CreateRole(ctx, storage.Role{
+			TenantID:    tenantID,
+			Scope:       scope,
+			Code:        spec.Code,
+			Name:        spec.Name,
+			Description: spec.Description,
+			Metadata:    metadata,
+			Permissions: permissions,
+		})
+		if err != nil {
+			return storage.Role{}, err
+		}
+		if err := server.SyncRolePermissionBindings(ctx, r.keto, r.namespacePrefix, created, nil); err != nil {
+			if delErr := r.roles.DeleteRole(ctx, created.ID, nil); delErr != nil {
+				return storage.Role{}, errors.Join(err, fmt.Errorf("roll back role: %w", delErr))
+			}
+			return storage.Role{}, err
+		}
+		r.logger.Info("created role", zap.String("code", created.Code), zap.String("scope", scope))
+		r.report.count(KindRole, true, false)
+		return created, nil
+	}
+
+	role := *existing
+	previous := role.Permissions
+	changed := role.Name != spec.Name || !samePermissions(previous, permissions)
+	role.Name, role.Permissions = spec.Name, permissions
+	if spec.Description != nil && !equalString(role.Description, spec.Description) {
+		role.Description, changed = spec.Description, true
+	}
+	if spec.Metadata != nil && !equalJSON(role.Metadata, metadata) {
+		role.Metadata, changed = metadata, true
+	}
+	if changed {
+		if role, err = r.roles.UpdateRole(ctx, role, true, nil); err != nil {
+			return storage.Role{}, err
+		}
+		role.Permissions = permissions
+		r.logger.Info("updated role", zap.String("code", role.Code), zap.String("scope", scope))
+	}
+	// Every binding is written even when nothing changed so Keto catches up with the
+	// database; a changed role then drops the bindings it no longer needs.
+	if err := server.SyncRolePermissionBindings(ctx, r.keto, r.namespacePrefix, role, nil); err != nil {
+		return storage.Role{}, err
+	}
+	if changed {
+		if err := server.SyncRolePermissionBindings(ctx, r.keto, r.namespacePrefix, role, previous); err != nil {
+			return storage.Role{}, err
+		}
+	}
+	r.report.count(KindRole, false, changed)
+	return role, nil
+}
+
+// findRole looks a role up by its exact code within a tenant, or among global roles when
+// tenantID is nil. The returned role includes its permissions.
+func (r *run) findRole(ctx context.Context, tenantID *uuid.UUID, scope, code string) (*storage.Role, error) {
+	for offset := int32(0); ; offset += listPageSize {
+		roles, total, err := r.roles.ListRoles(ctx, storage.RoleListParams{
+			Scope:    scope,
+			TenantID: tenantID,
+			Search:   code,
+			Limit:    listPageSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			if role.Code == code && role.Scope == scope {
+				found, err := r.roles.GetRole(ctx, role.ID)
+				if err != nil {
+					return nil, err
+				}
+				return &found, nil
+			}
+		}
+		if len(roles) == 0 || int64(offset)+int64(len(roles)) >= total {
+			return nil, nil
+		}
+	}
+}
+
+// normalizePermissions checks permission codes against the catalog, like the API does
+// for roles created by administrators.
+func (r *run) normalizePermissions(requested []string, scope string) ([]string, error) {
+	result := make([]string, 0, len(requested))
+	for _, code := range requested {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || slices.Contains(result, code) {
+			continue
+		}
+		permissionScope, ok := r.catalog[code]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("unknown permission %s", code)
+		case scope == "global" && permissionScope == "tenant":
+			return nil, fmt.Errorf("permission %s only applies to tenant roles", code)
+		case scope == "tenant" && permissionScope == "global":
+			return nil, fmt.Errorf("permission %s only applies to global roles", code)
+		}
+		result = append(result, code)
+	}
+	return result, nil
+}
+
+// applyGroups creates, updates or moves the groups of one level of the tree, then
+// descends into their children and members.
+func (r *run) applyGroups(ctx context.Context, tenantID uuid.UUID, parentID *uuid.UUID, specs []Group, existing map[string]storage.Group) error {
+	for i, spec := range specs {
+		sortOrder := int32(i)
+		if spec.SortOrder != nil {
+			sortOrder = *spec.SortOrder
+		}
+
+		group, found := existing[spec.Code]
+		switch {
+		case !found:
+			created, err := r.groups.CreateGroup(ctx, storage.Group{
+				TenantID:    tenantID,
+				Code:        spec.Code,
+				Name:        spec.Name,
+				Description: spec.Description,
+				ParentID:    parentID,
+				SortOrder:   sortOrder,
+			})
+			if err != nil {
+				return fmt.Errorf("group %s: %w", spec.Code, err)
+			}
+			group = created
+			r.logger.Info("created group", zap.String("code", group.Code), zap.String("id", group.ID.String()))
+			r.report.count(KindGroup, true, false)
+
+		default:
+			oldParent := group.ParentID
+			changed := group.Name != spec.Name || group.SortOrder != sortOrder || !equalUUID(oldParent, parentID)
+			if spec.Description != nil && !equalString(group.Description, spec.Description) {
+				group.Description, changed = spec.Description, true
+			}
+			if changed {
+				group.Name, group.SortOrder, group.ParentID = spec.Name, sortOrder, parentID
+				// Nil metadata keeps what is stored.
+				group.Metadata = nil
+				updated, err := r.groups.UpdateGroup(ctx, group, nil)
+				if err != nil {
+					return fmt.Errorf("group %s: %w", spec.Code, err)
+				}
+				group = updated
+				if oldParent != nil && !equalUUID(oldParent, parentID) {
+					if err := r.keto.RemoveGroupParent(ctx, tenantID.String(), group.ID.String(), oldParent.String()); err != nil {
+						return fmt.Errorf("group %s: %w", spec.Code, err)
+					}
+				}
+				r.logger.Info("updated group", zap.String("code", group.Code))
+			}
+			r.report.count(KindGroup, false, changed)
+		}
+		existing[group.Code] = group
+
+		if parentID != nil {
+			if err := r.keto.AssignGroupParent(ctx, tenantID.String(), group.ID.String(), parentID.String()); err != nil {
+				return fmt.Errorf("group %s: %w", spec.Code, err)
+			}
+		}
+		for _, member := range spec.Members {
+			if err := r.applyMember(ctx, group, member); err != nil {
+				return fmt.Errorf("group %s: member %s: %w", spec.Code, member.Phone, err)
+			}
+		}
+		if err := r.applyGroups(ctx, tenantID, &group.ID, spec.Children, existing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *run) applyMember(ctx context.Context, group storage.Group, spec Member) error {
+	identityID, err := r.ensureIdentity(ctx, spec.Phone, spec.Name, spec.Password, defaultUserType, &group.TenantID, group.TenantID.String(), spec.Roles)
+	if err != nil {
+		return err
+	}
+
+	member, err := r.groups.GetMember(ctx, group.ID, identityID)
+	switch {
+	case errors.Is(err, storage.ErrGroupMemberNotFound):
+		created, err := r.groups.CreateMember(ctx, storage.GroupMember{
+			GroupID:     group.ID,
+			IdentityID:  identityID,
+			TenantID:    group.TenantID,
+			DisplayName: spec.Name,
+			Phone:       spec.Phone,
+			Title:       spec.Title,
+			IsPrimary:   spec.Primary,
+		})
+		if err != nil {
+			return err
+		}
+		r.logger.Info("added member", zap.String("group", group.Code), zap.String("identity", created.IdentityID.String()))
+		r.report.count(KindMember, true, false)
+
+	case err != nil:
+		return err
+
+	default:
+		// A membership stops being primary only when another one is marked primary.
+		changed := member.DisplayName != spec.Name || (spec.Primary && !member.IsPrimary)
+		if spec.Title != nil && !equalString(member.Title, spec.Title) {
+			member.Title, changed = spec.Title, true
+		}
+		if changed {
+			member.DisplayName = spec.Name
+			member.IsPrimary = member.IsPrimary || spec.Primary
+			if _, err := r.groups.UpdateMember(ctx, member); err != nil {
+				return err
+			}
+			r.logger.Info("updated member", zap.String("group", group.Code), zap.String("identity", identityID.String()))
+		}
+		r.report.count(KindMember, false, changed)
+	}
+
+	return r.keto.AssignGroupMember(ctx, group.TenantID.String(), group.ID.String(), identityID.String())
+}
+
+// ensureIdentity returns the identity registered with phone, creating it when missing.
+// Identity roles missing from an existing identity are added; its other traits and
+// credentials are left alone. Each identity role is also written to Keto within
+// roleScope, the tenant ID or "" for global roles.
+func (r *run) ensureIdentity(ctx context.Context, phone, name, password, userType string, tenantID *uuid.UUID, roleScope string, roles []string) (uuid.UUID, error) {
+	id, seen := r.identities[phone]
+	if !seen {
+		identity, err := r.kratos.FindIdentityByIdentifier(ctx, phone)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("look up identity: %w", err)
+		}
+
+		if identity == nil {
+			input := kratos.CreateIdentityInput{
+				Phone:    phone,
+				Nickname: name,
+				UserType: userType,
+				Roles:    roles,
+				Password: password,
+			}
+			if input.Roles == nil {
+				input.Roles = []string{}
+			}
+			if tenantID != nil {
+				input.TenantID = tenantID.String()
+			}
+			if identity, err = r.kratos.CreateIdentity(ctx, input); err != nil {
+				return uuid.Nil, fmt.Errorf("create identity: %w", err)
+			}
+			r.logger.Info("created identity", zap.String("phone", phone), zap.String("id", identity.ID))
+			r.report.count(KindIdentity, true, false)
+		} else {
+			updated, err := r.addIdentityRoles(ctx, identity, roles)
+			if err != nil {
+				return uuid.Nil, err
+			}
+			r.report.count(KindIdentity, false, updated)
+		}
+
+		if id, err = uuid.Parse(identity.ID); err != nil {
+			return uuid.Nil, fmt.Errorf("parse identity id: %w", err)
+		}
+		r.identities[phone] = id
+	}
+
+	for _, role := range roles {
+		if err := r.keto.AssignRole(ctx, roleScope, role, id.String()); err != nil {
+			return uuid.Nil, fmt.Errorf("assign identity role %s: %w", role, err)
+		}
+	}
+	return id, nil
+}
+
+func (r *run) addIdentityRoles(ctx context.Context, identity *kratos.Identity, roles []string) (bool, error) {
+	var current []string
+	if raw, ok := identity.Traits["roles"].([]any); ok {
+		for _, value := range raw {
+			if role, ok := value.(string); ok {
+				current = append(current, role)
+			}
+		}
+	}
+	merged := current
+	for _, role := range roles {
+		if !slices.Contains(merged, role) {
+			merged = append(merged, role)
+		}
+	}
+	if len(merged) == len(current) {
+		return false, nil
+	}
+
+	identity.Traits["roles"] = merged
+	if _, err := r.kratos.UpdateIdentity(ctx, *identity); err != nil {
+		return false, fmt.Errorf("update identity roles: %w", err)
+	}
+	r.logger.Info("added identity roles", zap.String("id", identity.ID), zap.Strings("roles", merged))
+	return true, nil
+}
+
+// applyAssignments grants roles to identities by phone. Roles not defined in the fixture
+// are looked up by code, in the tenant or among global roles when tenantID is nil.
+func (r *run) applyAssignments(ctx context.Context, tenantID *uuid.UUID, roles map[string]storage.Role, assignments []Assignment) error {
+	scope := "global"
+	if tenantID != nil {
+		scope = "tenant"
+	}
+
+	for _, assignment := range assignments {
+		role, ok := roles[assignment.Role]
+		if !ok {
+			found, err := r.findRole(ctx, tenantID, scope, assignment.Role)
+			if err != nil {
+				return fmt.Errorf("assignment of %s: %w", assignment.Role, err)
+			}
+			if found == nil {
+				return fmt.Errorf("assignment of %s: unknown %s role", assignment.Role, scope)
+			}
+			role = *found
+			roles[role.Code] = role
+		}
+
+		assigned, err := r.assignedIdentities(ctx, role.ID)
+		if err != nil {
+			return fmt.Errorf("assignment of %s: %w", role.Code, err)
+		}
+
+		var scopeID string
+		if role.TenantID != nil {
+			scopeID = role.TenantID.String()
+		}
+		for _, phone := range assignment.Members {
+			identityID, err := r.lookupIdentity(ctx, phone)
+			if err != nil {
+				return fmt.Errorf("assignment of %s to %s: %w", role.Code, phone, err)
+			}
+
+			created := !assigned[identityID]
+			if created {
+				if err := r.roles.UpsertAssignment(ctx, role.ID, identityID, role.TenantID); err != nil {
+					return fmt.Errorf("assignment of %s to %s: %w", role.Code, phone, err)
+				}
+			}
+			if err := r.keto.AssignRole(ctx, scopeID, role.Code, identityID.String()); err != nil {
+				if created {
+					if delErr := r.roles.DeleteAssignment(ctx, role.ID, identityID); delErr != nil {
+						err = errors.Join(err, fmt.Errorf("roll back assignment: %w", delErr))
+					}
+				}
+				return fmt.Errorf("assignment of %s to %s: %w", role.Code, phone, err)
+			}
+			if created {
+				r.logger.Info("assigned role", zap.String("role", role.Code), zap.String("identity", identityID.String()))
+			}
+			r.report.count(KindAssignment, created, false)
+		}
+	}
+	return nil
+}
+
+func (r *run) assignedIdentities(ctx context.Context, roleID uuid.UUID) (map[uuid.UUID]bool, error) {
+	assigned := map[uuid.UUID]bool{}
+	for offset := int32(0); ; offset += listPageSize {
+		assignments, total, err := r.roles.ListAssignments(ctx, roleID, listPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, assignment := range assignments {
+			assigned[assignment.IdentityID] = true
+		}
+		if len(assignments) == 0 || int64(offset)+int64(len(assignments)) >= total {
+			return assigned, nil
+		}
+	}
+}
+
+// lookupIdentity resolves a phone to an identity seeded earlier in the run or already
+// registered in Kratos.
+func (r *run) lookupIdentity(ctx context.Context, phone string) (uuid.UUID, error) {
+	if id, ok := r.identities[phone]; ok {
+		return id, nil
+	}
+	identity, err := r.kratos.FindIdentityByIdentifier(ctx, phone)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("look up identity: %w", err)
+	}
+	if identity == nil {
+		return uuid.Nil, fmt.Errorf("no identity is registered with this phone")
+	}
+	id, err := uuid.Parse(identity.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("parse identity id: %w", err)
+	}
+	r.identities[phone] = id
+	return id, nil
+}
+
+func encodeMetadata(metadata map[string]any) (json.RawMessage, error) {
+	if metadata == nil {
+		return json.RawMessage(`{}`), nil
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encode metadata: %w", err)
+	}
+	return raw, nil
+}
+
+func equalString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalUUID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalJSON(a, b json.RawMessage) bool {
+	var left, right any
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func samePermissions(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+func (s *Server) registerAuditRoutes(group *gin.RouterGroup) {
+	group.GET("/audit-logs", s.handleListAuditLogs)
+}
+
+type auditLogResponse struct {
+	ID              int64           `json:"id"`
+	TenantID        *uuid.UUID      `json:"tenant_id,omitempty"`
+	ActorSubject    *string         `json:"actor_subject,omitempty"`
+	ImpersonationID *uuid.UUID      `json:"impersonation_id,omitempty"`
+	Action          string          `json:"action"`
+	TargetType      string          `json:"target_type"`
+	TargetID        string          `json:"target_id"`
+	Before          json.RawMessage `json:"before,omitempty"`
+	After           json.RawMessage `json:"after,omitempty"`
+	RequestID       *string         `json:"request_id,omitempty"`
+	CreatedAt       string          `json:"created_at"`
+}
+
+type listAuditLogsResponse struct {
+	Items      []auditLogResponse `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) handleListAuditLogs(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+	identity := middleware.IdentityFromContext(c)
+
+	limit := parsePositiveInt(c.Query("limit"), defaultPageSize)
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	params := storage.AuditListParams{
+		ActorSubject: c.Query("actor"),
+		Action:       c.Query("action"),
+		TargetType:   c.Query("target_type"),
+		TargetID:     c.Query("target_id"),
+		Cursor:       c.Query("cursor"),
+		Limit:        int32(limit),
+	}
+
+	var ok bool
+	if params.From, ok = parseTimeQuery(c, "from"); !ok {
+		return
+	}
+	if params.To, ok = parseTimeQuery(c, "to"); !ok {
+		return
+	}
+
+	if isPlatformAdmin(identity) && !identity.Impersonating() {
+		if tenantParam := strings.TrimSpace(c.Query("tenant_id")); tenantParam != "" {
+			tenantUUID, err := uuid.Parse(tenantParam)
+			if err != nil {
+				respondInvalidParam(c, "tenant_id")
+				return
+			}
+			params.TenantID = &tenantUUID
+		}
+	} else {
+		tenantID, ok := s.resolveTenantID(c, identity, false)
+		if !ok {
+			return
+		}
+		params.TenantID = &tenantID
+	}
+
+	entries, next, err := s.auditRepo.ListAuditLogs(c.Request.Context(), params)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			respondInvalidParam(c, "cursor")
+			return
+		}
+		s.logger.Error("list audit logs failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]auditLogResponse, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, mapAuditLog(entry))
+	}
+
+	c.JSON(http.StatusOK, listAuditLogsResponse{
+		Items:      items,
+		NextCursor: next,
+	})
+}
+
+// withAuditActor copies the caller identity onto the request context so repositories can
+// attribute the audit entries they write alongside each mutation.
+func (s *Server) withAuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := middleware.IdentityFromContext(c)
+		actor := storage.Actor{RequestID: middleware.RequestIDFromContext(c)}
+		if identity != nil {
+			actor.Subject = identity.Subject
+			if identity.Impersonating() {
+				if sessionID, err := uuid.Parse(identity.ImpersonationID); err == nil {
+					actor.ImpersonationID = &sessionID
+				}
+			}
+		}
+		c.Request = c.Request.WithContext(storage.ContextWithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, bool) {
+	raw := strings.TrimSpace(c.Query(key))
+	if raw == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidTimestamp, key)
+		return nil, false
+	}
+	return &parsed, true
+}
+
+func mapAuditLog(entry storage.AuditLog) auditLogResponse {
+	return auditLogResponse{
+		ID:              entry.ID,
+		TenantID:        entry.TenantID,
+		ActorSubject:    entry.ActorSubject,
+		ImpersonationID: entry.ImpersonationID,
+		Action:          entry.Action,
+		TargetType:      entry.TargetType,
+		TargetID:        entry.TargetID,
+		Before:          entry.Before,
+		After:           entry.After,
+		RequestID:       entry.RequestID,
+		CreatedAt:       entry.CreatedAt.Format(time.RFC3339),
+	}
+}
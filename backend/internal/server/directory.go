@@ -0,0 +1,136 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+func (s *Server) registerDirectoryRoutes(group *gin.RouterGroup) {
+	group.GET("/directory", s.handleSearchDirectory)
+}
+
+type directoryDepartmentResponse struct {
+	GroupID   uuid.UUID               `json:"group_id"`
+	GroupName string                  `json:"group_name"`
+	Path      []storage.GroupPathNode `json:"path"`
+	PathName  string                  `json:"path_name"`
+	Title     *string                 `json:"title,omitempty"`
+	IsPrimary bool                    `json:"is_primary"`
+}
+
+type directoryEntryResponse struct {
+	IdentityID  uuid.UUID                     `json:"identity_id"`
+	DisplayName string                        `json:"display_name"`
+	Phone       string                        `json:"phone"`
+	Title       *string                       `json:"title,omitempty"`
+	Departments []directoryDepartmentResponse `json:"departments"`
+	Roles       []userRoleResponse            `json:"roles"`
+	UpdatedAt   string                        `json:"updated_at"`
+}
+
+type directoryResponse struct {
+	Items    []directoryEntryResponse `json:"items"`
+	Total    int64                    `json:"total"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+}
+
+// handleSearchDirectory searches the members of a tenant across all of its groups by name,
+// phone, title or pinyin initials, returning each member's departments and roles.
+func (s *Server) handleSearchDirectory(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, ctx, true)
+	if !ok {
+		return
+	}
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	search := strings.TrimSpace(c.Query("search"))
+	limit := int32(pageSize)
+	offset := int32((page - 1) * pageSize)
+
+	requestCtx := c.Request.Context()
+	entries, total, err := s.groupRepo.SearchDirectory(requestCtx, tenantID, search, limit, offset)
+	if err != nil {
+		s.logger.Error("search directory failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	roles, err := s.roleRepo.ListTenantAssignments(requestCtx, tenantID)
+	if err != nil {
+		s.logger.Error("list tenant role assignments failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]directoryEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, mapDirectoryEntry(entry, roles[entry.Member.IdentityID]))
+	}
+
+	c.JSON(http.StatusOK, directoryResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+func mapDirectoryEntry(entry storage.DirectoryEntry, roles []storage.IdentityRole) directoryEntryResponse {
+	resp := directoryEntryResponse{
+		IdentityID:  entry.Member.IdentityID,
+		DisplayName: entry.Member.DisplayName,
+		Phone:       entry.Member.Phone,
+		Title:       entry.Member.Title,
+		Departments: make([]directoryDepartmentResponse, 0, len(entry.Memberships)),
+		Roles:       make([]userRoleResponse, 0, len(roles)),
+		UpdatedAt:   entry.Member.UpdatedAt.Format(time.RFC3339),
+	}
+
+	for _, membership := range entry.Memberships {
+		path := entry.Paths[membership.GroupID]
+		if path == nil {
+			path = []storage.GroupPathNode{}
+		}
+		names := make([]string, 0, len(path))
+		for _, node := range path {
+			names = append(names, node.Name)
+		}
+		department := directoryDepartmentResponse{
+			GroupID:   membership.GroupID,
+			Path:      path,
+			PathName:  strings.Join(names, " / "),
+			Title:     membership.Title,
+			IsPrimary: membership.IsPrimary,
+		}
+		if len(path) > 0 {
+			department.GroupName = path[len(path)-1].Name
+		}
+		resp.Departments = append(resp.Departments, department)
+	}
+
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, userRoleResponse{
+			ID:   role.RoleID,
+			Code: role.Code,
+			Name: role.Name,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+)
+
+// errorResponse is the body of every error response. Clients branch on Error.Code, which
+// is stable; Error.Message is localized for display and may change.
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []fieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// fieldError points at one invalid or missing request field.
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Field error codes.
+const (
+	fieldInvalid  = "invalid"
+	fieldRequired = "required"
+)
+
+// respondError writes the error envelope for code, with its message in the language of
+// the request. args fill the verbs of the catalog message.
+func respondError(c *gin.Context, status int, code string, args ...any) {
+	c.JSON(status, newErrorResponse(c, code, args...))
+}
+
+// respondErrorWith writes the error envelope together with extra top-level members, such
+// as the current representation of a resource.
+func respondErrorWith(c *gin.Context, status int, extra gin.H, code string, args ...any) {
+	body := gin.H{"error": newErrorResponse(c, code, args...).Error}
+	for key, value := range extra {
+		body[key] = value
+	}
+	c.JSON(status, body)
+}
+
+// abortWithError writes the error envelope and stops the remaining handlers.
+func abortWithError(c *gin.Context, status int, code string, args ...any) {
+	respondError(c, status, code, args...)
+	c.Abort()
+}
+
+// respondInternalError answers unexpected failures. The cause is logged by the caller and
+// never exposed to the client.
+func respondInternalError(c *gin.Context) {
+	respondError(c, http.StatusInternalServerError, codeInternalError)
+}
+
+// respondInvalidParam answers a malformed path, query or body parameter.
+func respondInvalidParam(c *gin.Context, field string) {
+	resp := newErrorResponse(c, codeInvalidParameter, field)
+	resp.Error.Fields = []fieldError{{
+		Field:   field,
+		Code:    fieldInvalid,
+		Message: resp.Error.Message,
+	}}
+	c.JSON(http.StatusBadRequest, resp)
+}
+
+// respondMissingFields answers a request that lacks required fields.
+func respondMissingFields(c *gin.Context, fields ...string) {
+	lang := requestLanguage(c)
+	resp := newErrorResponse(c, codeMissingFields, strings.Join(fields, ", "))
+	for _, field := range fields {
+		resp.Error.Fields = append(resp.Error.Fields, fieldError{
+			Field:   field,
+			Code:    fieldRequired,
+			Message: localize(lang, codeFieldRequired, field),
+		})
+	}
+	c.JSON(http.StatusBadRequest, resp)
+}
+
+func newErrorResponse(c *gin.Context, code string, args ...any) errorResponse {
+	return errorResponse{Error: apiError{
+		Code:      code,
+		Message:   localize(requestLanguage(c), code, args...),
+		RequestID: middleware.RequestIDFromContext(c),
+	}}
+}
+
+// isUniqueViolation reports whether err, possibly wrapped, is a Postgres unique violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// respondKratosError maps a failed Kratos identity call to a stable code: duplicate
+// identifiers become phone_already_registered, rejected traits identity_rejected, and
+// anything else identity_service_error.
+func (s *Server) respondKratosError(c *gin.Context, err error, logMessage string) {
+	var kratosErr *kratos.Error
+	switch {
+	case kratos.IsConflict(err):
+		respondError(c, http.StatusConflict, codePhoneAlreadyRegistered)
+	case errors.As(err, &kratosErr) && kratosErr.StatusCode < http.StatusInternalServerError:
+		s.logger.Warn(logMessage, zapError(err))
+		respondError(c, http.StatusBadRequest, codeIdentityRejected)
+	default:
+		s.logger.Error(logMessage, zapError(err))
+		respondError(c, http.StatusBadGateway, codeIdentityServiceError)
+	}
+}
+
+// requestLanguage picks the catalog for the Accept-Language header, preferring the
+// entries with the highest quality value and falling back to Chinese.
+func requestLanguage(c *gin.Context) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if lang := catalogLanguage(tag); lang != "" && quality > 0 {
+			candidates = append(candidates, candidate{lang: lang, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	if len(candidates) > 0 {
+		return candidates[0].lang
+	}
+	return langZH
+}
+
+func catalogLanguage(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch primary {
+	case "zh":
+		return langZH
+	case "en":
+		return langEN
+	}
+	return ""
+}
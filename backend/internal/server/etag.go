@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Entity tags of tenants, roles and groups are derived from their version column, which is
+// incremented on every change, so a tag identifies one revision of one resource.
+
+// versionETag formats a resource version as a strong entity tag.
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag adds the ETag header for the given resource version to the response.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", versionETag(version))
+}
+
+// ifMatch holds the parsed If-Match request header.
+type ifMatch struct {
+	present bool
+	any     bool
+	tags    []string
+}
+
+func parseIfMatch(c *gin.Context) ifMatch {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return ifMatch{}
+	}
+	if header == "*" {
+		return ifMatch{present: true, any: true}
+	}
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		// Proxies that compress responses weaken entity tags, so weak tags are compared
+		// like strong ones instead of never matching.
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return ifMatch{present: true, tags: tags}
+}
+
+func (m ifMatch) matches(version int64) bool {
+	if !m.present || m.any {
+		return true
+	}
+	current := versionETag(version)
+	for _, tag := range m.tags {
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch evaluates the If-Match precondition of a write against the version of the
+// resource loaded by the handler. It returns the version the write must still find in
+// storage, or nil when the request carries no tag to compare. A failed precondition is
+// answered with 412 and the current representation; a missing header is answered with 428
+// when server.require_if_match is enabled.
+func (s *Server) checkIfMatch(c *gin.Context, version int64, current any) (*int64, bool) {
+	precondition := parseIfMatch(c)
+	if !precondition.present {
+		if s.cfg.Server.RequireIfMatch {
+			respondError(c, http.StatusPreconditionRequired, codePreconditionRequired)
+			return nil, false
+		}
+		return nil, true
+	}
+	if !precondition.matches(version) {
+		respondPreconditionFailed(c, version, current)
+		return nil, false
+	}
+	if precondition.any {
+		return nil, true
+	}
+	return &version, true
+}
+
+// respondPreconditionFailed answers a stale write with the current representation.
+func respondPreconditionFailed(c *gin.Context, version int64, current any) {
+	setETag(c, version)
+	respondErrorWith(c, http.StatusPreconditionFailed, gin.H{"current": current}, codePreconditionFailed)
+}
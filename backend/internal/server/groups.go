@@ -1,16 +1,18 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 
 	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
@@ -20,13 +22,20 @@ import (
 
 func (s *Server) registerGroupRoutes(group *gin.RouterGroup) {
 	group.GET("/groups", s.handleListGroups)
+	group.GET("/groups/export", s.handleExportOrgChart)
 	group.POST("/groups", s.handleCreateGroup)
+	group.PATCH("/groups/tree", s.handleUpdateGroupTree)
 	group.PUT("/groups/:id", s.handleUpdateGroup)
 	group.DELETE("/groups/:id", s.handleDeleteGroup)
+	group.GET("/groups/:id/deletion-preview", s.handlePreviewGroupDeletion)
+	group.POST("/groups/:id/move", s.handleMoveGroup)
+	group.GET("/groups/:id/subtree", s.handleGroupSubtree)
+	group.GET("/groups/:id/ancestors", s.handleGroupAncestors)
 	group.GET("/groups/:id/members", s.handleListGroupMembers)
 	group.POST("/groups/:id/members", s.handleCreateGroupMember)
 	group.PATCH("/groups/:id/members/:member", s.handleUpdateGroupMember)
 	group.DELETE("/groups/:id/members/:member", s.handleDeleteGroupMember)
+	group.GET("/members/:id/groups", s.handleListMemberGroups)
 }
 
 type groupResponse struct {
@@ -39,6 +48,8 @@ type groupResponse struct {
 	SortOrder   int32           `json:"sort_order"`
 	MemberCount int64           `json:"member_count"`
 	Metadata    map[string]any  `json:"metadata"`
+	Version     int64           `json:"version"`
+	ETag        string          `json:"etag"`
 	CreatedAt   string          `json:"created_at"`
 	UpdatedAt   string          `json:"updated_at"`
 	Children    []groupResponse `json:"children,omitempty"`
@@ -59,6 +70,7 @@ type listGroupsResponse struct {
 }
 
 type groupMemberResponse struct {
+	GroupID     uuid.UUID `json:"group_id"`
 	IdentityID  uuid.UUID `json:"identity_id"`
 	DisplayName string    `json:"display_name"`
 	Phone       string    `json:"phone"`
@@ -68,6 +80,12 @@ type groupMemberResponse struct {
 	UpdatedAt   string    `json:"updated_at"`
 }
 
+type groupSubtreeResponse struct {
+	Items       []groupResponse `json:"items"`
+	GroupCount  int             `json:"group_count"`
+	MemberCount int64           `json:"member_count"`
+}
+
 type listGroupMembersResponse struct {
 	Items    []groupMemberResponse `json:"items"`
 	Total    int64                 `json:"total"`
@@ -77,6 +95,9 @@ type listGroupMembersResponse struct {
 
 var groupCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
 
+// minPasswordLength is the shortest password accepted for members created with a password.
+const minPasswordLength = 6
+
 func (s *Server) handleListGroups(c *gin.Context) {
 	ctx, ok := s.requireOrgManager(c)
 	if !ok {
@@ -84,11 +105,14 @@ func (s *Server) handleListGroups(c *gin.Context) {
 	}
 
 	var tenantFilter *uuid.UUID
-	if isPlatformAdmin(ctx) {
+	if isPlatformAdmin(ctx) && ctx.Impersonating() {
+		tenantID := s.adminTenantScope(ctx)
+		tenantFilter = &tenantID
+	} else if isPlatformAdmin(ctx) {
 		if tenantParam := strings.TrimSpace(c.Query("tenant_id")); tenantParam != "" {
 			tenantUUID, err := uuid.Parse(tenantParam)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+				respondInvalidParam(c, "tenant_id")
 				return
 			}
 			tenantFilter = &tenantUUID
@@ -104,7 +128,7 @@ func (s *Server) handleListGroups(c *gin.Context) {
 	groups, err := s.groupRepo.ListGroups(c.Request.Context(), tenantFilter)
 	if err != nil {
 		s.logger.Error("list groups failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load groups"})
+		respondInternalError(c)
 		return
 	}
 
@@ -120,17 +144,17 @@ func (s *Server) handleCreateGroup(c *gin.Context) {
 
 	var payload groupPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
 	if strings.TrimSpace(payload.Code) == "" || strings.TrimSpace(payload.Name) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "code and name are required"})
+		respondMissingFields(c, "code", "name")
 		return
 	}
 
 	if !groupCodePattern.MatchString(payload.Code) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "group code must be 1-32 alphanumeric or -/_"})
+		respondError(c, http.StatusBadRequest, codeGroupCodeInvalid)
 		return
 	}
 
@@ -143,21 +167,21 @@ func (s *Server) handleCreateGroup(c *gin.Context) {
 	if payload.ParentID != nil && strings.TrimSpace(*payload.ParentID) != "" {
 		parentUUID, err := uuid.Parse(strings.TrimSpace(*payload.ParentID))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent_id"})
+			respondInvalidParam(c, "parent_id")
 			return
 		}
 		parent, err := s.groupRepo.GetGroup(c.Request.Context(), parentUUID)
 		if err != nil {
 			if errors.Is(err, storage.ErrGroupNotFound) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "parent group not found"})
+				respondError(c, http.StatusBadRequest, codeParentGroupNotFound)
 				return
 			}
 			s.logger.Error("load parent group failed", zapError(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load parent group"})
+			respondInternalError(c)
 			return
 		}
 		if parent.TenantID != tenantID {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "parent group belongs to another tenant"})
+			respondError(c, http.StatusBadRequest, codeGroupParentTenant)
 			return
 		}
 		parentID = &parentUUID
@@ -172,7 +196,7 @@ func (s *Server) handleCreateGroup(c *gin.Context) {
 	if payload.Metadata != nil {
 		raw, err := json.Marshal(payload.Metadata)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "metadata must be valid JSON"})
+			respondError(c, http.StatusBadRequest, codeMetadataInvalid)
 			return
 		}
 		metadataBytes = raw
@@ -190,15 +214,18 @@ func (s *Server) handleCreateGroup(c *gin.Context) {
 
 	created, err := s.groupRepo.CreateGroup(c.Request.Context(), group)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "group code already exists"})
+		if isUniqueViolation(err) {
+			respondError(c, http.StatusBadRequest, codeGroupCodeExists)
 			return
 		}
 		s.logger.Error("create group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create group"})
+		respondInternalError(c)
 		return
 	}
 
+	s.syncGroupParents(c.Request.Context(), created.ID)
+
+	setETag(c, created.Version)
 	c.JSON(http.StatusCreated, mapGroupResponse(created))
 }
 
@@ -210,18 +237,18 @@ func (s *Server) handleUpdateGroup(c *gin.Context) {
 
 	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		respondInvalidParam(c, "group_id")
 		return
 	}
 
 	existing, err := s.groupRepo.GetGroup(c.Request.Context(), groupID)
 	if err != nil {
 		if errors.Is(err, storage.ErrGroupNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
 			return
 		}
 		s.logger.Error("load group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load group"})
+		respondInternalError(c)
 		return
 	}
 
@@ -231,7 +258,7 @@ func (s *Server) handleUpdateGroup(c *gin.Context) {
 
 	var payload groupPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
@@ -240,7 +267,7 @@ func (s *Server) handleUpdateGroup(c *gin.Context) {
 		code = existing.Code
 	}
 	if !groupCodePattern.MatchString(code) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "group code must be 1-32 alphanumeric or -/_"})
+		respondError(c, http.StatusBadRequest, codeGroupCodeInvalid)
 		return
 	}
 
@@ -257,25 +284,25 @@ func (s *Server) handleUpdateGroup(c *gin.Context) {
 		} else {
 			parentUUID, err := uuid.Parse(trimmed)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent_id"})
+				respondInvalidParam(c, "parent_id")
 				return
 			}
 			if parentUUID == existing.ID {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "group cannot be its own parent"})
+				respondError(c, http.StatusBadRequest, codeGroupSelfParent)
 				return
 			}
 			parent, err := s.groupRepo.GetGroup(c.Request.Context(), parentUUID)
 			if err != nil {
 				if errors.Is(err, storage.ErrGroupNotFound) {
-					c.JSON(http.StatusBadRequest, gin.H{"error": "parent group not found"})
+					respondError(c, http.StatusBadRequest, codeParentGroupNotFound)
 					return
 				}
 				s.logger.Error("load parent group failed", zapError(err))
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load parent group"})
+				respondInternalError(c)
 				return
 			}
 			if parent.TenantID != existing.TenantID {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "parent group belongs to another tenant"})
+				respondError(c, http.StatusBadRequest, codeGroupParentTenant)
 				return
 			}
 			parentID = &parentUUID
@@ -293,12 +320,17 @@ func (s *Server) handleUpdateGroup(c *gin.Context) {
 	if payload.Metadata != nil {
 		raw, err := json.Marshal(payload.Metadata)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "metadata must be valid JSON"})
+			respondError(c, http.StatusBadRequest, codeMetadataInvalid)
 			return
 		}
 		metadataBytes = raw
 	}
 
+	expectedVersion, ok := s.checkIfMatch(c, existing.Version, mapGroupResponse(existing))
+	if !ok {
+		return
+	}
+
 	updated, err := s.groupRepo.UpdateGroup(c.Request.Context(), storage.Group{
 		ID:          existing.ID,
 		TenantID:    existing.TenantID,
@@ -308,20 +340,256 @@ func (s *Server) handleUpdateGroup(c *gin.Context) {
 		ParentID:    parentID,
 		SortOrder:   sortOrder,
 		Metadata:    metadataBytes,
-	})
+	}, expectedVersion)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "group code already exists"})
+		if isUniqueViolation(err) {
+			respondError(c, http.StatusBadRequest, codeGroupCodeExists)
+			return
+		}
+		if errors.Is(err, storage.ErrGroupVersionConflict) {
+			s.respondGroupConflict(c, existing.ID)
+			return
+		}
+		if errors.Is(err, storage.ErrGroupCycle) {
+			respondError(c, http.StatusBadRequest, codeGroupCycle)
+			return
+		}
+		if errors.Is(err, storage.ErrGroupParentTenant) {
+			respondError(c, http.StatusBadRequest, codeGroupParentTenant)
 			return
 		}
 		s.logger.Error("update group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update group"})
+		respondInternalError(c)
 		return
 	}
 
+	s.syncGroupParents(c.Request.Context(), updated.ID)
+
+	setETag(c, updated.Version)
 	c.JSON(http.StatusOK, mapGroupResponse(updated))
 }
 
+type moveGroupPayload struct {
+	ParentID  *string `json:"parent_id"`
+	SortOrder *int32  `json:"sort_order"`
+}
+
+// handleMoveGroup re-parents a group and its subtree. An empty or missing parent_id moves
+// the group to the top level; without sort_order it is placed after its new siblings.
+func (s *Server) handleMoveGroup(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "group_id")
+		return
+	}
+
+	existing, err := s.groupRepo.GetGroup(c.Request.Context(), groupID)
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
+			return
+		}
+		s.logger.Error("load group failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	if !s.ensureTenantAccess(c, ctx, existing.TenantID) {
+		return
+	}
+
+	var payload moveGroupPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	var parentID *uuid.UUID
+	if payload.ParentID != nil && strings.TrimSpace(*payload.ParentID) != "" {
+		parentUUID, err := uuid.Parse(strings.TrimSpace(*payload.ParentID))
+		if err != nil {
+			respondInvalidParam(c, "parent_id")
+			return
+		}
+		parentID = &parentUUID
+	}
+
+	expectedVersion, ok := s.checkIfMatch(c, existing.Version, mapGroupResponse(existing))
+	if !ok {
+		return
+	}
+
+	_, moved, err := s.groupRepo.MoveGroup(c.Request.Context(), groupID, parentID, payload.SortOrder, expectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrGroupVersionConflict):
+			s.respondGroupConflict(c, groupID)
+		case errors.Is(err, storage.ErrGroupCycle):
+			respondError(c, http.StatusBadRequest, codeGroupCycle)
+		case errors.Is(err, storage.ErrGroupParentTenant):
+			respondError(c, http.StatusBadRequest, codeGroupParentTenant)
+		case errors.Is(err, storage.ErrGroupNotFound):
+			respondError(c, http.StatusBadRequest, codeParentGroupNotFound)
+		default:
+			s.logger.Error("move group failed", zapError(err))
+			respondInternalError(c)
+		}
+		return
+	}
+
+	s.syncGroupParents(c.Request.Context(), moved.ID)
+
+	setETag(c, moved.Version)
+	c.JSON(http.StatusOK, mapGroupResponse(moved))
+}
+
+// maxGroupTreeChanges bounds the number of changes accepted by one batch tree update.
+const maxGroupTreeChanges = 1000
+
+type groupTreeChangePayload struct {
+	ID        string  `json:"id"`
+	ParentID  *string `json:"parent_id"`
+	SortOrder int32   `json:"sort_order"`
+	Version   int64   `json:"version"`
+}
+
+type groupTreePayload struct {
+	TenantID string                   `json:"tenant_id"`
+	Changes  []groupTreeChangePayload `json:"changes"`
+}
+
+// handleUpdateGroupTree applies a batch of (id, parent_id, sort_order) placements, such as
+// the result of a drag and drop, atomically. Each change carries the version of the group
+// it was computed from; a stale version fails the whole batch with 409.
+func (s *Server) handleUpdateGroupTree(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	var payload groupTreePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+	if len(payload.Changes) == 0 {
+		respondMissingFields(c, "changes")
+		return
+	}
+	if len(payload.Changes) > maxGroupTreeChanges {
+		respondError(c, http.StatusBadRequest, codeGroupTreeTooLarge, maxGroupTreeChanges)
+		return
+	}
+
+	tenantID, ok := s.resolveTenantIDForPayload(c, ctx, payload.TenantID)
+	if !ok {
+		return
+	}
+
+	changes := make([]storage.GroupTreeChange, 0, len(payload.Changes))
+	for _, item := range payload.Changes {
+		groupID, err := uuid.Parse(strings.TrimSpace(item.ID))
+		if err != nil {
+			respondInvalidParam(c, "group_id")
+			return
+		}
+		if item.Version <= 0 {
+			respondErrorWith(c, http.StatusBadRequest, gin.H{"group_id": groupID}, codeMissingFields, "version")
+			return
+		}
+		change := storage.GroupTreeChange{
+			ID:        groupID,
+			SortOrder: item.SortOrder,
+			Version:   item.Version,
+		}
+		if item.ParentID != nil && strings.TrimSpace(*item.ParentID) != "" {
+			parentID, err := uuid.Parse(strings.TrimSpace(*item.ParentID))
+			if err != nil {
+				respondErrorWith(c, http.StatusBadRequest, gin.H{"group_id": groupID}, codeInvalidParameter, "parent_id")
+				return
+			}
+			change.ParentID = &parentID
+		}
+		changes = append(changes, change)
+	}
+
+	_, after, err := s.groupRepo.ApplyTreeChanges(c.Request.Context(), tenantID, changes)
+	if err != nil {
+		var changeErr *storage.GroupTreeChangeError
+		if !errors.As(err, &changeErr) {
+			s.logger.Error("update group tree failed", zapError(err))
+			respondInternalError(c)
+			return
+		}
+		switch {
+		case errors.Is(err, storage.ErrGroupVersionConflict):
+			respondErrorWith(c, http.StatusConflict, gin.H{"group_id": changeErr.GroupID}, codeGroupVersionConflict)
+		case errors.Is(err, storage.ErrGroupNotFound):
+			respondErrorWith(c, http.StatusBadRequest, gin.H{"group_id": changeErr.GroupID}, codeGroupNotFound)
+		case errors.Is(err, storage.ErrGroupParentTenant):
+			respondErrorWith(c, http.StatusBadRequest, gin.H{"group_id": changeErr.GroupID}, codeParentGroupNotFound)
+		case errors.Is(err, storage.ErrGroupCycle):
+			respondErrorWith(c, http.StatusBadRequest, gin.H{"group_id": changeErr.GroupID}, codeGroupTreeCycle)
+		default:
+			respondErrorWith(c, http.StatusBadRequest, gin.H{"group_id": changeErr.GroupID}, codeGroupTreeDuplicate)
+		}
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(after))
+	for _, group := range after {
+		ids = append(ids, group.ID)
+	}
+	s.syncGroupParents(c.Request.Context(), ids...)
+
+	c.JSON(http.StatusOK, listGroupsResponse{Items: mapGroupResponses(after)})
+}
+
+// syncGroupParents applies the parent link changes that the group repository queued for
+// the given groups. Groups whose parent did not change have nothing queued. Entries that
+// fail stay queued and are retried by the Keto sync worker.
+func (s *Server) syncGroupParents(ctx context.Context, groupIDs ...uuid.UUID) {
+	keys := make([]string, 0, len(groupIDs))
+	for _, id := range groupIDs {
+		keys = append(keys, storage.GroupSyncKey(id))
+	}
+	if err := s.ketoSyncer.Sync(ctx, keys...); err != nil {
+		s.logger.Warn("keto sync of group parents deferred", zapError(err))
+	}
+}
+
+// syncGroupMember applies the membership changes that the group repository queued for
+// identityID in the given groups. Entries that fail stay queued and are retried by the
+// Keto sync worker.
+func (s *Server) syncGroupMember(ctx context.Context, identityID uuid.UUID, groupIDs ...uuid.UUID) {
+	keys := make([]string, 0, len(groupIDs))
+	for _, id := range groupIDs {
+		keys = append(keys, storage.GroupMemberSyncKey(id, identityID))
+	}
+	if err := s.ketoSyncer.Sync(ctx, keys...); err != nil {
+		s.logger.Warn("keto sync of group membership deferred", zapError(err))
+	}
+}
+
+type groupDeletionResponse struct {
+	Mode               string                `json:"mode"`
+	Group              groupResponse         `json:"group"`
+	Target             *groupResponse        `json:"target,omitempty"`
+	DeletedGroups      []groupResponse       `json:"deleted_groups"`
+	MovedGroups        []groupResponse       `json:"moved_groups"`
+	MovedMembers       []groupMemberResponse `json:"moved_members"`
+	RemovedMembers     []groupMemberResponse `json:"removed_members"`
+	ReleasedIdentities []uuid.UUID           `json:"released_identities"`
+}
+
+// handleDeleteGroup deletes a group. The mode query parameter selects what happens to its
+// children and members: restrict (default) refuses non-empty groups, reassign moves them to
+// target_group_id and cascade deletes the subtree and releases its members.
 func (s *Server) handleDeleteGroup(c *gin.Context) {
 	ctx, ok := s.requireOrgManager(c)
 	if !ok {
@@ -330,7 +598,7 @@ func (s *Server) handleDeleteGroup(c *gin.Context) {
 
 	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		respondInvalidParam(c, "group_id")
 		return
 	}
 
@@ -341,7 +609,7 @@ func (s *Server) handleDeleteGroup(c *gin.Context) {
 			return
 		}
 		s.logger.Error("load group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load group"})
+		respondInternalError(c)
 		return
 	}
 
@@ -349,23 +617,151 @@ func (s *Server) handleDeleteGroup(c *gin.Context) {
 		return
 	}
 
-	if err := s.groupRepo.DeleteGroup(c.Request.Context(), groupID); err != nil {
-		if errors.Is(err, storage.ErrGroupHasChildren) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "group still has child groups"})
-			return
-		}
-		if errors.Is(err, storage.ErrGroupHasMembers) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "group still has members"})
+	opts, ok := parseDeleteGroupOptions(c)
+	if !ok {
+		return
+	}
+	if opts.ExpectedVersion, ok = s.checkIfMatch(c, group.Version, mapGroupResponse(group)); !ok {
+		return
+	}
+
+	plan, err := s.groupRepo.DeleteGroup(c.Request.Context(), groupID, opts)
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupVersionConflict) {
+			s.respondGroupConflict(c, groupID)
 			return
 		}
-		s.logger.Error("delete group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete group"})
+		s.respondGroupDeletionError(c, err, "delete group failed")
 		return
 	}
 
+	s.syncGroupDeletion(c.Request.Context(), plan)
+
 	c.Status(http.StatusNoContent)
 }
 
+// handlePreviewGroupDeletion reports what deleting a group with the given mode and target
+// would change, without applying it.
+func (s *Server) handlePreviewGroupDeletion(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	group, ok := s.loadAccessibleGroup(c, ctx)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseDeleteGroupOptions(c)
+	if !ok {
+		return
+	}
+
+	plan, err := s.groupRepo.PreviewGroupDeletion(c.Request.Context(), group.ID, opts)
+	if err != nil {
+		s.respondGroupDeletionError(c, err, "preview group deletion failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, mapGroupDeletion(plan))
+}
+
+func parseDeleteGroupOptions(c *gin.Context) (storage.DeleteGroupOptions, bool) {
+	opts := storage.DeleteGroupOptions{
+		Mode: strings.ToLower(strings.TrimSpace(c.Query("mode"))),
+	}
+	switch opts.Mode {
+	case "", storage.GroupDeleteRestrict, storage.GroupDeleteCascade:
+	case storage.GroupDeleteReassign:
+		targetID, err := uuid.Parse(strings.TrimSpace(c.Query("target_group_id")))
+		if err != nil {
+			respondInvalidParam(c, "target_group_id")
+			return storage.DeleteGroupOptions{}, false
+		}
+		opts.TargetID = &targetID
+	default:
+		respondInvalidParam(c, "mode")
+		return storage.DeleteGroupOptions{}, false
+	}
+	return opts, true
+}
+
+func (s *Server) respondGroupDeletionError(c *gin.Context, err error, logMessage string) {
+	switch {
+	case errors.Is(err, storage.ErrGroupNotFound):
+		respondError(c, http.StatusNotFound, codeGroupNotFound)
+	case errors.Is(err, storage.ErrGroupHasChildren):
+		respondError(c, http.StatusBadRequest, codeGroupHasChildren)
+	case errors.Is(err, storage.ErrGroupHasMembers):
+		respondError(c, http.StatusBadRequest, codeGroupHasMembers)
+	case errors.Is(err, storage.ErrGroupDeleteMode):
+		respondInvalidParam(c, "mode")
+	case errors.Is(err, storage.ErrGroupDeleteTarget):
+		respondError(c, http.StatusBadRequest, codeTargetGroupNotFound)
+	case errors.Is(err, storage.ErrGroupParentTenant):
+		respondError(c, http.StatusBadRequest, codeTargetGroupTenant)
+	case errors.Is(err, storage.ErrGroupCycle):
+		respondError(c, http.StatusBadRequest, codeTargetGroupInSubtree)
+	default:
+		s.logger.Error(logMessage, zapError(err))
+		respondInternalError(c)
+	}
+}
+
+// respondGroupConflict answers a write that lost a race with a concurrent change.
+func (s *Server) respondGroupConflict(c *gin.Context, id uuid.UUID) {
+	group, err := s.groupRepo.GetGroup(c.Request.Context(), id)
+	if err != nil {
+		s.logger.Error("load group failed", zapError(err))
+		respondError(c, http.StatusPreconditionFailed, codePreconditionFailed)
+		return
+	}
+	respondPreconditionFailed(c, group.Version, mapGroupResponse(group))
+}
+
+// syncGroupDeletion applies the Keto changes that DeleteGroup queued for plan: moved
+// children and members are linked to the target, and every tuple of the deleted groups
+// is removed. Entries that fail stay queued and are retried by the Keto sync worker.
+func (s *Server) syncGroupDeletion(ctx context.Context, plan storage.GroupDeletionPlan) {
+	if err := s.ketoSyncer.Sync(ctx, storage.DeletionSyncKeys(plan)...); err != nil {
+		s.logger.Warn("keto sync of group deletion deferred", zap.String("group", plan.Group.ID.String()), zapError(err))
+	}
+}
+
+func mapGroupDeletion(plan storage.GroupDeletionPlan) groupDeletionResponse {
+	resp := groupDeletionResponse{
+		Mode:               plan.Mode,
+		Group:              mapGroupResponse(plan.Group),
+		DeletedGroups:      mapGroupResponses(plan.DeletedGroups),
+		MovedGroups:        mapGroupResponses(plan.MovedGroups),
+		MovedMembers:       mapGroupMembers(plan.MovedMembers),
+		RemovedMembers:     mapGroupMembers(plan.RemovedMembers),
+		ReleasedIdentities: plan.ReleasedIdentities,
+	}
+	if plan.Target != nil {
+		target := mapGroupResponse(*plan.Target)
+		resp.Target = &target
+	}
+	return resp
+}
+
+func mapGroupResponses(groups []storage.Group) []groupResponse {
+	items := make([]groupResponse, 0, len(groups))
+	for _, group := range groups {
+		items = append(items, mapGroupResponse(group))
+	}
+	return items
+}
+
+func mapGroupMembers(members []storage.GroupMember) []groupMemberResponse {
+	items := make([]groupMemberResponse, 0, len(members))
+	for _, member := range members {
+		items = append(items, mapGroupMember(member))
+	}
+	return items
+}
+
 func (s *Server) handleListGroupMembers(c *gin.Context) {
 	ctx, ok := s.requireOrgManager(c)
 	if !ok {
@@ -374,18 +770,18 @@ func (s *Server) handleListGroupMembers(c *gin.Context) {
 
 	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		respondInvalidParam(c, "group_id")
 		return
 	}
 
 	group, err := s.groupRepo.GetGroup(c.Request.Context(), groupID)
 	if err != nil {
 		if errors.Is(err, storage.ErrGroupNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
 			return
 		}
 		s.logger.Error("load group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load group"})
+		respondInternalError(c)
 		return
 	}
 
@@ -406,10 +802,34 @@ func (s *Server) handleListGroupMembers(c *gin.Context) {
 	limit := int32(pageSize)
 	offset := int32((page - 1) * pageSize)
 
-	members, total, err := s.groupRepo.ListMembers(c.Request.Context(), groupID, search, limit, offset)
+	// recursive=true lists the members of the group and of every group below it.
+	recursive := strings.EqualFold(strings.TrimSpace(c.Query("recursive")), "true")
+
+	if keyset, ok := parseKeysetPage(c, defaultPageSize, maxPageSize); ok && !recursive {
+		members, result, err := s.groupRepo.ListMembersKeyset(c.Request.Context(), groupID, search, keyset)
+		if err != nil {
+			s.respondKeysetError(c, err, "list group members failed")
+			return
+		}
+		c.JSON(http.StatusOK, cursorPageResponse{
+			Items:      mapGroupMembers(members),
+			NextCursor: result.NextCursor,
+			Total:      result.Total,
+			PageSize:   int(keyset.Limit),
+		})
+		return
+	}
+
+	var members []storage.GroupMember
+	var total int64
+	if recursive {
+		members, total, err = s.groupRepo.ListSubtreeMembers(c.Request.Context(), groupID, search, limit, offset)
+	} else {
+		members, total, err = s.groupRepo.ListMembers(c.Request.Context(), groupID, search, limit, offset)
+	}
 	if err != nil {
 		s.logger.Error("list group members failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load members"})
+		respondInternalError(c)
 		return
 	}
 
@@ -426,11 +846,118 @@ func (s *Server) handleListGroupMembers(c *gin.Context) {
 	})
 }
 
+// handleGroupSubtree returns the tree below a group, limited to max_depth levels when given,
+// together with the number of groups and of distinct members it contains.
+func (s *Server) handleGroupSubtree(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	group, ok := s.loadAccessibleGroup(c, ctx)
+	if !ok {
+		return
+	}
+
+	var maxDepth *int32
+	if raw := strings.TrimSpace(c.Query("max_depth")); raw != "" {
+		depth, err := strconv.Atoi(raw)
+		if err != nil || depth < 0 {
+			respondInvalidParam(c, "max_depth")
+			return
+		}
+		value := int32(depth)
+		maxDepth = &value
+	}
+
+	groups, err := s.groupRepo.ListSubtree(c.Request.Context(), group.ID, maxDepth)
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
+			return
+		}
+		s.logger.Error("list group subtree failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	memberCount, err := s.groupRepo.CountSubtreeMembers(c.Request.Context(), group.ID)
+	if err != nil {
+		s.logger.Error("count subtree members failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, groupSubtreeResponse{
+		Items:       buildGroupTree(groups),
+		GroupCount:  len(groups),
+		MemberCount: memberCount,
+	})
+}
+
+// handleGroupAncestors returns the breadcrumb path from the top-level group to the group.
+func (s *Server) handleGroupAncestors(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	group, ok := s.loadAccessibleGroup(c, ctx)
+	if !ok {
+		return
+	}
+
+	ancestors, err := s.groupRepo.ListAncestors(c.Request.Context(), group.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
+			return
+		}
+		s.logger.Error("list group ancestors failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]groupResponse, 0, len(ancestors))
+	for _, ancestor := range ancestors {
+		items = append(items, mapGroupResponse(ancestor))
+	}
+	c.JSON(http.StatusOK, listGroupsResponse{Items: items})
+}
+
+// loadAccessibleGroup loads the group named by the :id parameter and checks that the caller
+// may access its tenant, writing the error response otherwise.
+func (s *Server) loadAccessibleGroup(c *gin.Context, ctx *middleware.IdentityContext) (storage.Group, bool) {
+	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "group_id")
+		return storage.Group{}, false
+	}
+
+	group, err := s.groupRepo.GetGroup(c.Request.Context(), groupID)
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
+			return storage.Group{}, false
+		}
+		s.logger.Error("load group failed", zapError(err))
+		respondInternalError(c)
+		return storage.Group{}, false
+	}
+
+	if !s.ensureTenantAccess(c, ctx, group.TenantID) {
+		return storage.Group{}, false
+	}
+	return group, true
+}
+
 type createGroupMemberPayload struct {
+	IdentityID  string  `json:"identity_id"`
 	DisplayName string  `json:"display_name"`
 	Phone       string  `json:"phone"`
 	Password    string  `json:"password"`
 	Title       *string `json:"title"`
+	IsPrimary   *bool   `json:"is_primary"`
 }
 
 func (s *Server) handleCreateGroupMember(c *gin.Context) {
@@ -441,18 +968,18 @@ func (s *Server) handleCreateGroupMember(c *gin.Context) {
 
 	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		respondInvalidParam(c, "group_id")
 		return
 	}
 
 	group, err := s.groupRepo.GetGroup(c.Request.Context(), groupID)
 	if err != nil {
 		if errors.Is(err, storage.ErrGroupNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
 			return
 		}
 		s.logger.Error("load group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load group"})
+		respondInternalError(c)
 		return
 	}
 
@@ -462,37 +989,79 @@ func (s *Server) handleCreateGroupMember(c *gin.Context) {
 
 	var payload createGroupMemberPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	if rawID := strings.TrimSpace(payload.IdentityID); rawID != "" {
+		if _, err := uuid.Parse(rawID); err != nil {
+			respondInvalidParam(c, "identity_id")
+			return
+		}
+		identity, err := s.kratosClient.GetIdentity(c.Request.Context(), rawID)
+		if err != nil {
+			s.logger.Error("load kratos identity failed", zapError(err))
+			respondInternalError(c)
+			return
+		}
+		if identity == nil {
+			respondError(c, http.StatusNotFound, codeUserNotFound)
+			return
+		}
+		s.addExistingGroupMember(c, group, identity, payload)
 		return
 	}
 
 	displayName := strings.TrimSpace(payload.DisplayName)
-	phone := normalizePhone(payload.Phone)
+	phone := kratos.NormalizePhone(payload.Phone)
 	password := strings.TrimSpace(payload.Password)
 
-	if displayName == "" || phone == "" || password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "display name, phone and password are required"})
+	if phone == "" {
+		respondError(c, http.StatusBadRequest, codeIdentityOrPhoneRequired)
 		return
 	}
 
-	if len(password) < 6 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "password must be at least 6 characters"})
-		return
+	// A retry of a request that failed after creating the identity carries on with it.
+	if resourceID := idempotentResource(c); resourceID != "" {
+		created, err := s.kratosClient.GetIdentity(c.Request.Context(), resourceID)
+		if err != nil {
+			s.logger.Error("load kratos identity failed", zapError(err))
+			respondInternalError(c)
+			return
+		}
+		if created != nil {
+			s.addExistingGroupMember(c, group, created, payload)
+			return
+		}
 	}
 
 	existing, err := s.kratosClient.FindIdentityByIdentifier(c.Request.Context(), phone)
 	if err != nil {
 		s.logger.Error("kratos lookup failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check existing user"})
+		respondInternalError(c)
 		return
 	}
 	if existing != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "手机号已注册"})
+		// A password would overwrite the credentials of someone else's account.
+		if password != "" {
+			respondError(c, http.StatusConflict, codePhoneAlreadyRegistered)
+			return
+		}
+		s.addExistingGroupMember(c, group, existing, payload)
+		return
+	}
+
+	if displayName == "" || password == "" {
+		respondMissingFields(c, "display_name", "phone", "password")
+		return
+	}
+
+	if len(password) < minPasswordLength {
+		respondError(c, http.StatusBadRequest, codePasswordTooShort, minPasswordLength)
 		return
 	}
 
 	tenantUUIDStr := group.TenantID.String()
-	groupIDStr := group.ID.String()
 
 	identity, err := s.kratosClient.CreateIdentity(c.Request.Context(), kratos.CreateIdentityInput{
 		Phone:    phone,
@@ -503,55 +1072,231 @@ func (s *Server) handleCreateGroupMember(c *gin.Context) {
 		Password: password,
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		s.logger.Error("create kratos identity failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		s.respondKratosError(c, err, "create kratos identity failed")
+		return
+	}
+	s.recordIdempotentResource(c, identity.ID)
+
+	identityID, err := uuid.Parse(identity.ID)
+	if err != nil {
+		s.logger.Error("parse identity id failed", zap.String("identity", identity.ID), zap.Error(err))
+		respondInternalError(c)
+		return
+	}
+
+	member, err := s.groupRepo.CreateMember(c.Request.Context(), storage.GroupMember{
+		GroupID:     group.ID,
+		IdentityID:  identityID,
+		TenantID:    group.TenantID,
+		DisplayName: displayName,
+		Phone:       phone,
+		Title:       trimmedTitle(payload.Title),
+		IsPrimary:   payload.IsPrimary != nil && *payload.IsPrimary,
+	})
+	if err != nil {
+		s.logger.Error("create group member failed", zapError(err))
+		respondInternalError(c)
 		return
 	}
 
+	s.syncGroupMember(c.Request.Context(), identityID, group.ID)
+
+	c.JSON(http.StatusCreated, mapGroupMember(member))
+}
+
+// addExistingGroupMember adds an identity that already exists in Kratos to the group. An
+// identity without a tenant is adopted by the group's tenant; identities of any other
+// tenant are rejected.
+func (s *Server) addExistingGroupMember(c *gin.Context, group storage.Group, identity *kratos.Identity, payload createGroupMemberPayload) {
 	identityID, err := uuid.Parse(identity.ID)
 	if err != nil {
 		s.logger.Error("parse identity id failed", zap.String("identity", identity.ID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid identity id"})
+		respondInternalError(c)
 		return
 	}
 
-	var titlePtr *string
-	if payload.Title != nil {
-		trimmed := strings.TrimSpace(*payload.Title)
-		if trimmed != "" {
-			title := trimmed
-			titlePtr = &title
+	requestCtx := c.Request.Context()
+	memberships, err := s.groupRepo.ListGroupsForIdentity(requestCtx, group.TenantID, identityID)
+	if err != nil {
+		s.logger.Error("list member groups failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	for _, membership := range memberships {
+		if membership.GroupID == group.ID {
+			respondError(c, http.StatusConflict, codeMemberExists)
+			return
 		}
 	}
 
-	member, err := s.groupRepo.CreateMember(c.Request.Context(), storage.GroupMember{
+	if !s.checkAdoption(c, group.TenantID, identity) {
+		return
+	}
+
+	displayName := identity.TraitString("nickname")
+	phone := identity.TraitString("phone")
+	if len(memberships) > 0 {
+		displayName = memberships[0].DisplayName
+		phone = memberships[0].Phone
+	}
+
+	member, err := s.groupRepo.CreateMember(requestCtx, storage.GroupMember{
 		GroupID:     group.ID,
 		IdentityID:  identityID,
 		TenantID:    group.TenantID,
 		DisplayName: displayName,
 		Phone:       phone,
-		Title:       titlePtr,
-		IsPrimary:   false,
+		Title:       trimmedTitle(payload.Title),
+		IsPrimary:   payload.IsPrimary != nil && *payload.IsPrimary,
 	})
 	if err != nil {
 		s.logger.Error("create group member failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add member"})
+		respondInternalError(c)
 		return
 	}
 
-	if err := s.ketoClient.AssignGroupMember(c.Request.Context(), tenantUUIDStr, groupIDStr, identityID.String()); err != nil {
-		s.logger.Warn("assign group membership failed", zapError(err))
+	// The identity only moves into the tenant once it has a membership there; if the move
+	// fails, the membership is removed again.
+	if !s.adoptIdentity(c, group.TenantID, identity) {
+		if err := s.groupRepo.DeleteMember(requestCtx, group.ID, identityID); err != nil {
+			s.logger.Error("rollback group member failed", zapError(err), zap.String("identity", identity.ID))
+		}
+		return
 	}
 
+	s.syncGroupMember(requestCtx, identityID, group.ID)
+
 	c.JSON(http.StatusCreated, mapGroupMember(member))
 }
 
+// checkAdoption rejects identities that belong to a tenant other than tenantID. Identities
+// without a tenant can be adopted by it.
+func (s *Server) checkAdoption(c *gin.Context, tenantID uuid.UUID, identity *kratos.Identity) bool {
+	currentTenant := identity.TraitString("tenant_id")
+	if currentTenant == "" || currentTenant == tenantID.String() {
+		return true
+	}
+	if otherTenant, err := uuid.Parse(currentTenant); err == nil && otherTenant == s.platformTenantID {
+		respondError(c, http.StatusConflict, codePlatformUserJoin)
+		return false
+	}
+	respondError(c, http.StatusConflict, codeUserTenantMismatch)
+	return false
+}
+
+// adoptIdentity points the tenant_id trait at tenantID and marks the identity internal. The
+// caller checks the identity with checkAdoption first. An adopted identity loses its roles
+// trait and the global role tuples the registration hook wrote for it, since roles granted
+// outside the tenant do not carry over.
+func (s *Server) adoptIdentity(c *gin.Context, tenantID uuid.UUID, identity *kratos.Identity) bool {
+	requestCtx := c.Request.Context()
+	currentTenant := identity.TraitString("tenant_id")
+	if currentTenant == tenantID.String() && identity.TraitString("user_type") == "internal" {
+		return true
+	}
+
+	before := userSnapshot(identity)
+	traits := make(map[string]any, len(identity.Traits)+2)
+	for key, value := range identity.Traits {
+		traits[key] = value
+	}
+	if currentTenant != tenantID.String() {
+		// The tuples go first: if this fails the roles trait is still there, so a retry
+		// finds the same roles to remove.
+		for _, role := range identity.TraitStrings("roles") {
+			if role == "" {
+				continue
+			}
+			if err := s.ketoClient.RemoveRole(requestCtx, "", role, identity.ID); err != nil {
+				s.logger.Error("remove registration role failed", zapError(err), zap.String("identity", identity.ID), zap.String("role", role))
+				respondInternalError(c)
+				return false
+			}
+		}
+		traits["roles"] = []string{}
+	}
+	traits["tenant_id"] = tenantID.String()
+	traits["user_type"] = "internal"
+
+	updated := *identity
+	updated.Traits = traits
+	updated.State = identityState(identity)
+	result, err := s.kratosClient.UpdateIdentity(requestCtx, updated)
+	if err != nil {
+		s.logger.Error("update kratos identity failed", zapError(err))
+		respondInternalError(c)
+		return false
+	}
+
+	// The identity has moved, so the adoption stands even if its audit entry is lost.
+	if err := s.userRepo.RecordProfileUpdate(requestCtx, tenantID, before, userSnapshot(result)); err != nil {
+		s.logger.Error("record user update failed", zapError(err), zap.String("identity", identity.ID))
+	}
+	*identity = *result
+	return true
+}
+
+// handleListMemberGroups lists every group an identity belongs to within the tenant,
+// primary group first.
+func (s *Server) handleListMemberGroups(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	identityID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "member_id")
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, ctx, true)
+	if !ok {
+		return
+	}
+
+	requestCtx := c.Request.Context()
+	memberships, err := s.groupRepo.ListGroupsForIdentity(requestCtx, tenantID, identityID)
+	if err != nil {
+		s.logger.Error("list member groups failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	if len(memberships) == 0 {
+		respondError(c, http.StatusNotFound, codeMemberNotFound)
+		return
+	}
+
+	groups, err := s.groupRepo.ListGroups(requestCtx, &tenantID)
+	if err != nil {
+		s.logger.Error("list groups failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	byID := make(map[uuid.UUID]storage.Group, len(groups))
+	for _, group := range groups {
+		byID[group.ID] = group
+	}
+
+	items := make([]userGroupResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		group := byID[membership.GroupID]
+		items = append(items, userGroupResponse{
+			GroupID:   membership.GroupID,
+			GroupCode: group.Code,
+			GroupName: group.Name,
+			Title:     membership.Title,
+			IsPrimary: membership.IsPrimary,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
 type updateGroupMemberPayload struct {
-	TargetGroupID string `json:"target_group_id"`
+	TargetGroupID string  `json:"target_group_id"`
+	DisplayName   *string `json:"display_name"`
+	Title         *string `json:"title"`
+	IsPrimary     *bool   `json:"is_primary"`
 }
 
 func (s *Server) handleUpdateGroupMember(c *gin.Context) {
@@ -562,24 +1307,24 @@ func (s *Server) handleUpdateGroupMember(c *gin.Context) {
 
 	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		respondInvalidParam(c, "group_id")
 		return
 	}
 
 	identityID, err := uuid.Parse(strings.TrimSpace(c.Param("member")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid member id"})
+		respondInvalidParam(c, "member_id")
 		return
 	}
 
 	group, err := s.groupRepo.GetGroup(c.Request.Context(), groupID)
 	if err != nil {
 		if errors.Is(err, storage.ErrGroupNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
 			return
 		}
 		s.logger.Error("load group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load group"})
+		respondInternalError(c)
 		return
 	}
 
@@ -589,65 +1334,161 @@ func (s *Server) handleUpdateGroupMember(c *gin.Context) {
 
 	var payload updateGroupMemberPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
-	if strings.TrimSpace(payload.TargetGroupID) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "target group id is required"})
+	targetGroupID := strings.TrimSpace(payload.TargetGroupID)
+	if targetGroupID == "" && payload.DisplayName == nil && payload.Title == nil && payload.IsPrimary == nil {
+		respondError(c, http.StatusBadRequest, codeMemberUpdateEmpty)
 		return
 	}
 
-	targetUUID, err := uuid.Parse(strings.TrimSpace(payload.TargetGroupID))
+	var displayName string
+	if payload.DisplayName != nil {
+		displayName = strings.TrimSpace(*payload.DisplayName)
+		if displayName == "" || utf8.RuneCountInString(displayName) > maxNicknameLength {
+			respondError(c, http.StatusBadRequest, codeDisplayNameLength, maxNicknameLength)
+			return
+		}
+	}
+
+	requestCtx := c.Request.Context()
+	member, err := s.groupRepo.GetMember(requestCtx, group.ID, identityID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target group id"})
+		if errors.Is(err, storage.ErrGroupMemberNotFound) {
+			respondError(c, http.StatusNotFound, codeMemberNotFound)
+			return
+		}
+		s.logger.Error("load group member failed", zapError(err))
+		respondInternalError(c)
 		return
 	}
 
+	if targetGroupID != "" {
+		moved, ok := s.moveGroupMember(c, group, identityID, targetGroupID)
+		if !ok {
+			return
+		}
+		member = moved
+	}
+
+	if payload.DisplayName != nil && displayName != member.DisplayName {
+		if !s.renameMember(c, member, displayName) {
+			return
+		}
+		member.DisplayName = displayName
+	}
+
+	if payload.Title != nil || payload.IsPrimary != nil {
+		if payload.Title != nil {
+			member.Title = trimmedTitle(payload.Title)
+		}
+		if payload.IsPrimary != nil {
+			member.IsPrimary = *payload.IsPrimary
+		}
+		updated, err := s.groupRepo.UpdateMember(requestCtx, member)
+		if err != nil {
+			if errors.Is(err, storage.ErrPrimaryMembershipRequired) {
+				respondError(c, http.StatusBadRequest, codePrimaryRequired)
+				return
+			}
+			s.logger.Error("update group member failed", zapError(err))
+			respondInternalError(c)
+			return
+		}
+		member = updated
+	}
+
+	c.JSON(http.StatusOK, mapGroupMember(member))
+}
+
+// moveGroupMember moves the membership to the target group and mirrors the change in Keto.
+func (s *Server) moveGroupMember(c *gin.Context, group storage.Group, identityID uuid.UUID, targetGroupID string) (storage.GroupMember, bool) {
+	targetUUID, err := uuid.Parse(targetGroupID)
+	if err != nil {
+		respondInvalidParam(c, "target_group_id")
+		return storage.GroupMember{}, false
+	}
+
 	if targetUUID == group.ID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "target group must differ from source"})
-		return
+		respondError(c, http.StatusBadRequest, codeTargetGroupSame)
+		return storage.GroupMember{}, false
 	}
 
 	targetGroup, err := s.groupRepo.GetGroup(c.Request.Context(), targetUUID)
 	if err != nil {
 		if errors.Is(err, storage.ErrGroupNotFound) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "target group not found"})
-			return
+			respondError(c, http.StatusBadRequest, codeTargetGroupNotFound)
+			return storage.GroupMember{}, false
 		}
 		s.logger.Error("load target group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load target group"})
-		return
+		respondInternalError(c)
+		return storage.GroupMember{}, false
 	}
 
 	if targetGroup.TenantID != group.TenantID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "target group belongs to another tenant"})
-		return
+		respondError(c, http.StatusBadRequest, codeTargetGroupTenant)
+		return storage.GroupMember{}, false
 	}
 
-	tenantIDStr := group.TenantID.String()
-	identityIDStr := identityID.String()
-	groupIDStr := group.ID.String()
-
 	moved, err := s.groupRepo.MoveMember(c.Request.Context(), identityID, group.ID, targetUUID, group.TenantID)
 	if err != nil {
 		if errors.Is(err, storage.ErrGroupNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
-			return
+			respondError(c, http.StatusNotFound, codeMemberNotFound)
+			return storage.GroupMember{}, false
+		}
+		if errors.Is(err, storage.ErrGroupMemberExists) {
+			respondError(c, http.StatusConflict, codeTargetMemberExists)
+			return storage.GroupMember{}, false
 		}
 		s.logger.Error("move member failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to move member"})
-		return
+		respondInternalError(c)
+		return storage.GroupMember{}, false
+	}
+
+	s.syncGroupMember(c.Request.Context(), identityID, group.ID, moved.GroupID)
+	return moved, true
+}
+
+// renameMember changes the nickname trait of the member's identity. The display name is
+// shared by all memberships, so every membership of the tenant is updated with it.
+func (s *Server) renameMember(c *gin.Context, member storage.GroupMember, displayName string) bool {
+	requestCtx := c.Request.Context()
+	identity, err := s.kratosClient.GetIdentity(requestCtx, member.IdentityID.String())
+	if err != nil {
+		s.logger.Error("load kratos identity failed", zapError(err))
+		respondInternalError(c)
+		return false
+	}
+	if identity == nil {
+		respondError(c, http.StatusNotFound, codeUserNotFound)
+		return false
 	}
 
-	if err := s.ketoClient.RemoveGroupMember(c.Request.Context(), tenantIDStr, groupIDStr, identityIDStr); err != nil {
-		s.logger.Warn("remove old group membership failed", zapError(err))
+	before := userSnapshot(identity)
+	traits := make(map[string]any, len(identity.Traits)+1)
+	for key, value := range identity.Traits {
+		traits[key] = value
 	}
-	if err := s.ketoClient.AssignGroupMember(c.Request.Context(), tenantIDStr, moved.GroupID.String(), identityIDStr); err != nil {
-		s.logger.Warn("assign new group membership failed", zapError(err))
+	traits["nickname"] = displayName
+
+	updated := *identity
+	updated.Traits = traits
+	updated.State = identityState(identity)
+	result, err := s.kratosClient.UpdateIdentity(requestCtx, updated)
+	if err != nil {
+		s.logger.Error("update kratos identity failed", zapError(err))
+		respondInternalError(c)
+		return false
 	}
 
-	c.JSON(http.StatusOK, mapGroupMember(moved))
+	if err := s.userRepo.RecordProfileUpdate(requestCtx, member.TenantID, before, userSnapshot(result)); err != nil {
+		s.logger.Error("record user update failed", zapError(err), zap.String("identity", identity.ID))
+		respondInternalError(c)
+		return false
+	}
+	return true
 }
 
 func (s *Server) handleDeleteGroupMember(c *gin.Context) {
@@ -658,13 +1499,13 @@ func (s *Server) handleDeleteGroupMember(c *gin.Context) {
 
 	groupID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		respondInvalidParam(c, "group_id")
 		return
 	}
 
 	identityID, err := uuid.Parse(strings.TrimSpace(c.Param("member")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid member id"})
+		respondInvalidParam(c, "member_id")
 		return
 	}
 
@@ -675,7 +1516,7 @@ func (s *Server) handleDeleteGroupMember(c *gin.Context) {
 			return
 		}
 		s.logger.Error("load group failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load group"})
+		respondInternalError(c)
 		return
 	}
 
@@ -685,13 +1526,11 @@ func (s *Server) handleDeleteGroupMember(c *gin.Context) {
 
 	if err := s.groupRepo.DeleteMember(c.Request.Context(), groupID, identityID); err != nil {
 		s.logger.Error("delete group member failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete member"})
+		respondInternalError(c)
 		return
 	}
 
-	if err := s.ketoClient.RemoveGroupMember(c.Request.Context(), group.TenantID.String(), group.ID.String(), identityID.String()); err != nil {
-		s.logger.Warn("remove group membership failed", zapError(err))
-	}
+	s.syncGroupMember(c.Request.Context(), identityID, group.ID)
 
 	c.Status(http.StatusNoContent)
 }
@@ -699,7 +1538,7 @@ func (s *Server) handleDeleteGroupMember(c *gin.Context) {
 func (s *Server) requireOrgManager(c *gin.Context) (*middleware.IdentityContext, bool) {
 	ctx := middleware.IdentityFromContext(c)
 	if ctx == nil || ctx.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return nil, false
 	}
 
@@ -711,37 +1550,37 @@ func (s *Server) requireOrgManager(c *gin.Context) (*middleware.IdentityContext,
 		switch strings.ToLower(role) {
 		case "tenant_admin", "tenant-admin", "organization_manager", "organization-manager":
 			if strings.TrimSpace(ctx.TenantID) == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "tenant context missing"})
+				respondError(c, http.StatusBadRequest, codeTenantContextMissing)
 				return nil, false
 			}
 			return ctx, true
 		}
 	}
 
-	c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	respondError(c, http.StatusForbidden, codeForbidden)
 	return nil, false
 }
 
 func (s *Server) ensureTenantAccess(c *gin.Context, ctx *middleware.IdentityContext, tenantID uuid.UUID) bool {
 	if isPlatformAdmin(ctx) {
-		if tenantID != s.platformTenantID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for tenant"})
+		if tenantID != s.adminTenantScope(ctx) {
+			respondError(c, http.StatusForbidden, codeTenantForbidden)
 			return false
 		}
 		return true
 	}
 	if strings.TrimSpace(ctx.TenantID) == "" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "tenant context missing"})
+		respondError(c, http.StatusForbidden, codeTenantContextMissing)
 		return false
 	}
 	ctxTenant, err := uuid.Parse(ctx.TenantID)
 	if err != nil {
 		s.logger.Error("parse tenant context failed", zap.String("tenant_id", ctx.TenantID), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant context"})
+		respondError(c, http.StatusBadRequest, codeTenantContextInvalid)
 		return false
 	}
 	if ctxTenant != tenantID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for tenant"})
+		respondError(c, http.StatusForbidden, codeTenantForbidden)
 		return false
 	}
 	return true
@@ -753,27 +1592,27 @@ func (s *Server) resolveTenantID(c *gin.Context, ctx *middleware.IdentityContext
 			if tenantParam := strings.TrimSpace(c.Query("tenant_id")); tenantParam != "" {
 				tenantUUID, err := uuid.Parse(tenantParam)
 				if err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+					respondInvalidParam(c, "tenant_id")
 					return uuid.Nil, false
 				}
-				if tenantUUID != s.platformTenantID {
-					c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for tenant"})
+				if tenantUUID != s.adminTenantScope(ctx) {
+					respondError(c, http.StatusForbidden, codeTenantForbidden)
 					return uuid.Nil, false
 				}
 				return tenantUUID, true
 			}
 		}
-		return s.platformTenantID, true
+		return s.adminTenantScope(ctx), true
 	}
 
 	if strings.TrimSpace(ctx.TenantID) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "tenant_id is required"})
+		respondMissingFields(c, "tenant_id")
 		return uuid.Nil, false
 	}
 	tenantUUID, err := uuid.Parse(ctx.TenantID)
 	if err != nil {
 		s.logger.Error("parse tenant context failed", zap.String("tenant_id", ctx.TenantID), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant context"})
+		respondError(c, http.StatusBadRequest, codeTenantContextInvalid)
 		return uuid.Nil, false
 	}
 	return tenantUUID, true
@@ -783,12 +1622,12 @@ func (s *Server) resolveTenantIDForPayload(c *gin.Context, ctx *middleware.Ident
 	if trimmed := strings.TrimSpace(payloadTenant); trimmed != "" {
 		tenantUUID, err := uuid.Parse(trimmed)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+			respondInvalidParam(c, "tenant_id")
 			return uuid.Nil, false
 		}
 		if isPlatformAdmin(ctx) {
-			if tenantUUID != s.platformTenantID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for tenant"})
+			if tenantUUID != s.adminTenantScope(ctx) {
+				respondError(c, http.StatusForbidden, codeTenantForbidden)
 				return uuid.Nil, false
 			}
 			return tenantUUID, true
@@ -800,23 +1639,30 @@ func (s *Server) resolveTenantIDForPayload(c *gin.Context, ctx *middleware.Ident
 	}
 
 	if isPlatformAdmin(ctx) {
-		return s.platformTenantID, true
+		return s.adminTenantScope(ctx), true
 	}
 
 	if strings.TrimSpace(ctx.TenantID) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "tenant_id is required"})
+		respondMissingFields(c, "tenant_id")
 		return uuid.Nil, false
 	}
 	tenantUUID, err := uuid.Parse(ctx.TenantID)
 	if err != nil {
 		s.logger.Error("parse tenant context failed", zap.String("tenant_id", ctx.TenantID), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant context"})
+		respondError(c, http.StatusBadRequest, codeTenantContextInvalid)
 		return uuid.Nil, false
 	}
 	return tenantUUID, true
 }
 
+// buildGroupTree nests groups under their parents. Groups whose parent is not part of the
+// input, such as the root of a subtree, are returned at the top level.
 func buildGroupTree(groups []storage.Group) []groupResponse {
+	present := make(map[uuid.UUID]struct{}, len(groups))
+	for _, group := range groups {
+		present[group.ID] = struct{}{}
+	}
+
 	children := make(map[uuid.UUID][]storage.Group)
 	roots := make([]storage.Group, 0)
 	for _, group := range groups {
@@ -824,6 +1670,10 @@ func buildGroupTree(groups []storage.Group) []groupResponse {
 			roots = append(roots, group)
 			continue
 		}
+		if _, ok := present[*group.ParentID]; !ok {
+			roots = append(roots, group)
+			continue
+		}
 		children[*group.ParentID] = append(children[*group.ParentID], group)
 	}
 
@@ -861,14 +1711,29 @@ func mapGroupResponse(group storage.Group) groupResponse {
 		SortOrder:   group.SortOrder,
 		MemberCount: group.MemberCount,
 		Metadata:    metadata,
+		Version:     group.Version,
+		ETag:        versionETag(group.Version),
 		CreatedAt:   group.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   group.UpdatedAt.Format(time.RFC3339),
 	}
 	return resp
 }
 
+// trimmedTitle returns the trimmed title, or nil when it is missing or blank.
+func trimmedTitle(title *string) *string {
+	if title == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*title)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
 func mapGroupMember(member storage.GroupMember) groupMemberResponse {
 	return groupMemberResponse{
+		GroupID:     member.GroupID,
 		IdentityID:  member.IdentityID,
 		DisplayName: member.DisplayName,
 		Phone:       member.Phone,
@@ -878,17 +1743,3 @@ func mapGroupMember(member storage.GroupMember) groupMemberResponse {
 		UpdatedAt:   member.UpdatedAt.Format(time.RFC3339),
 	}
 }
-
-func normalizePhone(raw string) string {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return raw
-	}
-	if strings.HasPrefix(raw, "+") {
-		return raw
-	}
-	if strings.HasPrefix(raw, "86") {
-		return "+" + raw
-	}
-	return "+86" + raw
-}
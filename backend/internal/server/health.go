@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultHealthTimeout = 2 * time.Second
+
+var (
+	errSchemaDirty   = errors.New("schema is dirty")
+	errSchemaPending = errors.New("migrations pending")
+)
+
+// dependencyCheck probes one dependency of the readiness endpoint. It may return
+// details that are reported next to the status.
+type dependencyCheck struct {
+	name  string
+	probe func(ctx context.Context) (gin.H, error)
+}
+
+// handleLive answers the liveness probe. It only shows that the process still serves
+// requests and never checks dependencies, so an outage elsewhere does not get the pod
+// restarted.
+func (s *Server) handleLive(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleReady answers the readiness probe by checking Postgres, the schema version,
+// the Keto read and write APIs and the Kratos admin API concurrently. Every check
+// reports its status and latency; the response is 503 when any of them fails or the
+// server is shutting down.
+func (s *Server) handleReady(c *gin.Context) {
+	if s.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+
+	checks := []dependencyCheck{
+		{name: "postgres", probe: func(ctx context.Context) (gin.H, error) {
+			return nil, s.pool.Ping(ctx)
+		}},
+		{name: "schema", probe: s.checkSchema},
+		{name: "keto_read", probe: func(ctx context.Context) (gin.H, error) {
+			return nil, s.ketoClient.ReadReady(ctx)
+		}},
+		{name: "keto_write", probe: func(ctx context.Context) (gin.H, error) {
+			return nil, s.ketoClient.WriteReady(ctx)
+		}},
+		{name: "kratos_admin", probe: func(ctx context.Context) (gin.H, error) {
+			return nil, s.kratosClient.AdminReady(ctx)
+		}},
+	}
+
+	timeout := s.cfg.Server.HealthTimeout
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	results := make(gin.H, len(checks))
+	healthy := true
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			details, err := check.probe(ctx)
+			result := gin.H{"status": "ok", "latency_ms": time.Since(started).Milliseconds()}
+			for key, value := range details {
+				result[key] = value
+			}
+			if err != nil {
+				s.logger.Warn("readiness check failed", zap.String("dependency", check.name), zap.Error(err))
+				result["status"] = "down"
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.name] = result
+			if err != nil {
+				healthy = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !healthy {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": results})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": results})
+}
+
+// checkSchema fails when the schema version cannot be read, a migration is dirty or
+// embedded migrations have not been applied yet.
+func (s *Server) checkSchema(ctx context.Context) (gin.H, error) {
+	version, err := s.migrator.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details := gin.H{
+		"version": version.Version,
+		"latest":  version.Latest,
+		"dirty":   version.Dirty,
+		"pending": version.Pending(),
+	}
+	switch {
+	case version.Dirty:
+		return details, errSchemaDirty
+	case version.Pending():
+		return details, errSchemaPending
+	}
+	return details, nil
+}
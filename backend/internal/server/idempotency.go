@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultIdempotencyLockTTL = time.Minute
+	idempotencyRecordKey      = "idempotency.record"
+)
+
+// withIdempotency makes mutating requests sent with an Idempotency-Key header safe to retry.
+// The first request with a key runs normally and its response is stored; retries with the
+// same key and request get the stored response back, a key reused for a different request
+// is rejected with 422, and a retry racing the first request gets 409. Server errors are
+// not stored, so a request that failed with 5xx can be retried with the same key; handlers
+// that create something outside the database record it with recordIdempotentResource so
+// the retry resumes with it.
+func (s *Server) withIdempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, http.StatusBadRequest, codeIdempotencyKeyTooLong, maxIdempotencyKeyLength)
+			return
+		}
+		identity := middleware.IdentityFromContext(c)
+		if identity == nil || identity.Subject == "" {
+			// Anonymous requests are rejected by the handlers and have nothing to replay.
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidRequestBody)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		claim := storage.IdempotencyClaim{
+			Scope:       idempotencyScope(identity),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Fingerprint: requestFingerprint(c.Request, body),
+			TTL:         s.idempotencyTTL(),
+			LockTimeout: s.idempotencyLockTimeout(),
+		}
+		record, claimed, err := s.idempotencyRepo.Claim(c.Request.Context(), claim)
+		if err != nil {
+			s.logger.Error("claim idempotency key failed", zapError(err))
+			abortWithError(c, http.StatusInternalServerError, codeInternalError)
+			return
+		}
+		if !claimed {
+			respondIdempotentRetry(c, record, claim.Fingerprint)
+			return
+		}
+		c.Set(idempotencyRecordKey, record)
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		// Outlives a cancelled request so the key is never left pending by a client abort.
+		storeCtx := context.WithoutCancel(c.Request.Context())
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := s.idempotencyRepo.Release(storeCtx, record); err != nil {
+				s.logger.Warn("release idempotency key failed", zapError(err), zap.String("path", claim.Path))
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		if err := s.idempotencyRepo.Complete(storeCtx, record, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			if errors.Is(err, storage.ErrIdempotencyLockLost) {
+				// The key belongs to the request that took it over; leave it alone.
+				s.logger.Warn("idempotency key taken over before completion", zap.String("path", claim.Path))
+				completed = true
+				return
+			}
+			s.logger.Error("store idempotent response failed", zapError(err), zap.String("path", claim.Path))
+			return
+		}
+		completed = true
+	}
+}
+
+// idempotentResource returns the resource an earlier attempt of the request recorded under
+// its Idempotency-Key, or "" when there is none.
+func idempotentResource(c *gin.Context) string {
+	value, _ := c.Get(idempotencyRecordKey)
+	record, _ := value.(storage.IdempotencyRecord)
+	return record.ResourceID
+}
+
+// recordIdempotentResource remembers a resource the request created outside the database,
+// so a retry after a server error can pick it up through idempotentResource. It does
+// nothing for requests sent without an Idempotency-Key.
+func (s *Server) recordIdempotentResource(c *gin.Context, resourceID string) {
+	value, ok := c.Get(idempotencyRecordKey)
+	if !ok {
+		return
+	}
+	record, _ := value.(storage.IdempotencyRecord)
+	if err := s.idempotencyRepo.RecordResource(context.WithoutCancel(c.Request.Context()), record, resourceID); err != nil {
+		s.logger.Warn("record idempotency resource failed", zapError(err), zap.String("resource", resourceID))
+	}
+}
+
+// respondIdempotentRetry answers a request whose key is already held by an earlier request.
+func respondIdempotentRetry(c *gin.Context, record storage.IdempotencyRecord, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		abortWithError(c, http.StatusUnprocessableEntity, codeIdempotencyKeyReused)
+	case record.Status == storage.IdempotencyPending:
+		abortWithError(c, http.StatusConflict, codeIdempotencyKeyInProgress)
+	default:
+		c.Header(idempotentReplayedHeader, "true")
+		if len(record.ResponseBody) == 0 {
+			c.AbortWithStatus(record.ResponseStatus)
+			return
+		}
+		c.Data(record.ResponseStatus, record.ResponseContentType, record.ResponseBody)
+		c.Abort()
+	}
+}
+
+// idempotencyScope separates the keys of different callers, and of an administrator acting
+// inside an impersonation session from the same administrator acting as themselves.
+func idempotencyScope(identity *middleware.IdentityContext) string {
+	if identity.Impersonating() {
+		return identity.Subject + "|" + identity.ImpersonationID
+	}
+	return identity.Subject
+}
+
+// requestFingerprint hashes everything that identifies a request: method, path with query
+// and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n") // nolint:errcheck
+	hash.Write(body)                                           // nolint:errcheck
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (s *Server) idempotencyTTL() time.Duration {
+	if ttl := s.cfg.Idempotency.TTL; ttl > 0 {
+		return ttl
+	}
+	return defaultIdempotencyTTL
+}
+
+func (s *Server) idempotencyLockTimeout() time.Duration {
+	if timeout := s.cfg.Idempotency.LockTimeout; timeout > 0 {
+		return timeout
+	}
+	return defaultIdempotencyLockTTL
+}
+
+// responseRecorder keeps a copy of the response body written by a handler.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
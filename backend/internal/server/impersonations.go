@@ -0,0 +1,500 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	defaultImpersonationHeader      = "X-Impersonation-Session"
+	defaultImpersonationMaxDuration = time.Hour
+	defaultImpersonationDuration    = 30 * time.Minute
+)
+
+func (s *Server) registerImpersonationRoutes(group *gin.RouterGroup) {
+	group.GET("/impersonations", s.handleListImpersonations)
+	group.POST("/impersonations", s.handleCreateImpersonation)
+	group.GET("/impersonations/:id", s.handleGetImpersonation)
+	group.DELETE("/impersonations/:id", s.handleEndImpersonation)
+	group.GET("/impersonations/:id/requests", s.handleListImpersonationRequests)
+}
+
+type impersonationResponse struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	ActorSubject string    `json:"actor_subject"`
+	Reason       string    `json:"reason"`
+	Active       bool      `json:"active"`
+	ExpiresAt    string    `json:"expires_at"`
+	EndedAt      *string   `json:"ended_at,omitempty"`
+	CreatedAt    string    `json:"created_at"`
+	RequestCount int64     `json:"request_count"`
+	Header       string    `json:"header,omitempty"`
+}
+
+type listImpersonationsResponse struct {
+	Items    []impersonationResponse `json:"items"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"page_size"`
+}
+
+// impersonationRequestResponse is one entry of a session's request log. Status is null
+// while the request is in flight, or if it never completed.
+type impersonationRequestResponse struct {
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    *int32  `json:"status"`
+	RequestID *string `json:"request_id,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type listImpersonationRequestsResponse struct {
+	Items    []impersonationRequestResponse `json:"items"`
+	Total    int64                          `json:"total"`
+	Page     int                            `json:"page"`
+	PageSize int                            `json:"page_size"`
+}
+
+type createImpersonationPayload struct {
+	TenantID        string `json:"tenant_id"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+func (s *Server) handleCreateImpersonation(c *gin.Context) {
+	identity := middleware.IdentityFromContext(c)
+	if identity == nil || identity.Subject == "" {
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+	if !isPlatformAdmin(identity) {
+		respondError(c, http.StatusForbidden, codeImpersonationAdminOnly)
+		return
+	}
+	if identity.Impersonating() {
+		respondError(c, http.StatusBadRequest, codeImpersonationNested)
+		return
+	}
+
+	var payload createImpersonationPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	tenantID, err := uuid.Parse(strings.TrimSpace(payload.TenantID))
+	if err != nil {
+		respondInvalidParam(c, "tenant_id")
+		return
+	}
+	if tenantID == s.platformTenantID {
+		respondError(c, http.StatusBadRequest, codeImpersonationNotRequired)
+		return
+	}
+
+	reason := strings.TrimSpace(payload.Reason)
+	if reason == "" {
+		respondMissingFields(c, "reason")
+		return
+	}
+
+	duration := defaultImpersonationDuration
+	if payload.DurationMinutes > 0 {
+		duration = time.Duration(payload.DurationMinutes) * time.Minute
+	}
+	if maxDuration := s.impersonationMaxDuration(); duration > maxDuration {
+		duration = maxDuration
+	}
+
+	if _, err := s.tenantRepo.GetTenant(c.Request.Context(), tenantID); err != nil {
+		if errors.Is(err, storage.ErrTenantNotFound) {
+			respondError(c, http.StatusNotFound, codeTenantNotFound)
+			return
+		}
+		s.logger.Error("load tenant failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	session, err := s.impersonationRepo.CreateSession(c.Request.Context(), storage.ImpersonationSession{
+		TenantID:     tenantID,
+		ActorSubject: identity.Subject,
+		Reason:       reason,
+		ExpiresAt:    time.Now().Add(duration),
+	})
+	if err != nil {
+		s.logger.Error("create impersonation session failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	s.logger.Info("impersonation session started",
+		zap.String("session", session.ID.String()),
+		zap.String("actor", identity.Subject),
+		zap.String("tenant", tenantID.String()),
+		zap.String("reason", reason),
+		zap.Time("expires_at", session.ExpiresAt),
+	)
+
+	resp := mapImpersonation(session)
+	resp.Header = s.impersonationHeader()
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (s *Server) handleListImpersonations(c *gin.Context) {
+	identity := middleware.IdentityFromContext(c)
+	if identity == nil || identity.Subject == "" {
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	params := storage.ImpersonationListParams{
+		ActiveOnly: strings.EqualFold(strings.TrimSpace(c.Query("active")), "true"),
+		Limit:      int32(pageSize),
+		Offset:     int32((page - 1) * pageSize),
+	}
+
+	if isPlatformAdmin(identity) {
+		if tenantParam := strings.TrimSpace(c.Query("tenant_id")); tenantParam != "" {
+			tenantUUID, err := uuid.Parse(tenantParam)
+			if err != nil {
+				respondInvalidParam(c, "tenant_id")
+				return
+			}
+			params.TenantID = &tenantUUID
+		}
+		params.ActorSubject = strings.TrimSpace(c.Query("actor"))
+	} else {
+		tenantID, ok := s.tenantAdminScope(c, identity)
+		if !ok {
+			return
+		}
+		params.TenantID = &tenantID
+	}
+
+	sessions, total, err := s.impersonationRepo.ListSessions(c.Request.Context(), params)
+	if err != nil {
+		s.logger.Error("list impersonation sessions failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]impersonationResponse, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, mapImpersonation(session))
+	}
+
+	c.JSON(http.StatusOK, listImpersonationsResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+func (s *Server) handleGetImpersonation(c *gin.Context) {
+	session, ok := s.loadVisibleImpersonation(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, mapImpersonation(session))
+}
+
+func (s *Server) handleEndImpersonation(c *gin.Context) {
+	identity := middleware.IdentityFromContext(c)
+	if identity == nil || identity.Subject == "" {
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
+		return
+	}
+	if !isPlatformAdmin(identity) {
+		respondError(c, http.StatusForbidden, codeForbidden)
+		return
+	}
+
+	sessionID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "impersonation_id")
+		return
+	}
+
+	// Only the admin who started a session may end it; other admins get the same 404 as
+	// for an unknown session.
+	session, err := s.impersonationRepo.GetSession(c.Request.Context(), sessionID)
+	if err != nil && !errors.Is(err, storage.ErrImpersonationNotFound) {
+		s.logger.Error("load impersonation session failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	if err != nil || session.ActorSubject != identity.Subject {
+		respondError(c, http.StatusNotFound, codeImpersonationNotFound)
+		return
+	}
+
+	if _, err := s.impersonationRepo.EndSession(c.Request.Context(), sessionID); err != nil {
+		if errors.Is(err, storage.ErrImpersonationEnded) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		if errors.Is(err, storage.ErrImpersonationNotFound) {
+			respondError(c, http.StatusNotFound, codeImpersonationNotFound)
+			return
+		}
+		s.logger.Error("end impersonation session failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	s.logger.Info("impersonation session ended",
+		zap.String("session", sessionID.String()),
+		zap.String("actor", identity.Subject),
+	)
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) handleListImpersonationRequests(c *gin.Context) {
+	session, ok := s.loadVisibleImpersonation(c)
+	if !ok {
+		return
+	}
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	requests, total, err := s.impersonationRepo.ListRequests(c.Request.Context(), session.ID, int32(pageSize), int32((page-1)*pageSize))
+	if err != nil {
+		s.logger.Error("list impersonation requests failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]impersonationRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		items = append(items, impersonationRequestResponse{
+			Method:    request.Method,
+			Path:      request.Path,
+			Status:    request.Status,
+			RequestID: request.RequestID,
+			CreatedAt: request.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, listImpersonationRequestsResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// loadVisibleImpersonation loads the session in the path and checks that the caller may see it:
+// platform admins see every session, tenant admins only the ones targeting their tenant.
+func (s *Server) loadVisibleImpersonation(c *gin.Context) (storage.ImpersonationSession, bool) {
+	identity := middleware.IdentityFromContext(c)
+	if identity == nil || identity.Subject == "" {
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
+		return storage.ImpersonationSession{}, false
+	}
+
+	sessionID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "impersonation_id")
+		return storage.ImpersonationSession{}, false
+	}
+
+	session, err := s.impersonationRepo.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, storage.ErrImpersonationNotFound) {
+			respondError(c, http.StatusNotFound, codeImpersonationNotFound)
+			return storage.ImpersonationSession{}, false
+		}
+		s.logger.Error("get impersonation session failed", zapError(err))
+		respondInternalError(c)
+		return storage.ImpersonationSession{}, false
+	}
+
+	if isPlatformAdmin(identity) {
+		return session, true
+	}
+
+	tenantID, ok := s.tenantAdminScope(c, identity)
+	if !ok {
+		return storage.ImpersonationSession{}, false
+	}
+	if tenantID != session.TenantID {
+		respondError(c, http.StatusNotFound, codeImpersonationNotFound)
+		return storage.ImpersonationSession{}, false
+	}
+	return session, true
+}
+
+// tenantAdminScope returns the tenant of a tenant admin, writing an error response otherwise.
+func (s *Server) tenantAdminScope(c *gin.Context, identity *middleware.IdentityContext) (uuid.UUID, bool) {
+	if !hasAnyRole(identity, "tenant_admin", "tenant-admin") {
+		respondError(c, http.StatusForbidden, codeForbidden)
+		return uuid.Nil, false
+	}
+	if strings.TrimSpace(identity.TenantID) == "" {
+		respondError(c, http.StatusBadRequest, codeTenantContextMissing)
+		return uuid.Nil, false
+	}
+	tenantID, err := uuid.Parse(identity.TenantID)
+	if err != nil {
+		s.logger.Error("parse tenant context failed", zap.String("tenant_id", identity.TenantID), zap.Error(err))
+		respondError(c, http.StatusBadRequest, codeTenantContextInvalid)
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
+
+// withImpersonation resolves the impersonation header into the identity context and records
+// every request made under the session, completing the record with the status code once
+// the handler has run.
+func (s *Server) withImpersonation() gin.HandlerFunc {
+	header := s.impersonationHeader()
+
+	return func(c *gin.Context) {
+		raw := strings.TrimSpace(c.GetHeader(header))
+		if raw == "" {
+			c.Next()
+			return
+		}
+
+		identity := middleware.IdentityFromContext(c)
+		if !isPlatformAdmin(identity) {
+			abortWithError(c, http.StatusForbidden, codeImpersonationAdminOnly)
+			return
+		}
+
+		sessionID, err := uuid.Parse(raw)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeImpersonationInvalid)
+			return
+		}
+
+		session, err := s.impersonationRepo.GetSession(c.Request.Context(), sessionID)
+		if err != nil {
+			if errors.Is(err, storage.ErrImpersonationNotFound) {
+				abortWithError(c, http.StatusForbidden, codeImpersonationNotFound)
+				return
+			}
+			s.logger.Error("load impersonation session failed", zapError(err))
+			abortWithError(c, http.StatusInternalServerError, codeInternalError)
+			return
+		}
+
+		if session.ActorSubject != identity.Subject {
+			abortWithError(c, http.StatusForbidden, codeImpersonationForeign)
+			return
+		}
+		if !session.Active(time.Now()) {
+			abortWithError(c, http.StatusForbidden, codeImpersonationExpired)
+			return
+		}
+
+		identity.ImpersonationID = session.ID.String()
+		identity.ActingTenantID = session.TenantID.String()
+		c.Header(header, identity.ImpersonationID)
+
+		requestID := middleware.RequestIDFromContext(c)
+		var requestIDPtr *string
+		if requestID != "" {
+			requestIDPtr = &requestID
+		}
+
+		// The request is recorded before the handler runs so that nothing is done under
+		// the session without a trace; a request that cannot be recorded is refused.
+		recordID, err := s.impersonationRepo.RecordRequest(c.Request.Context(), storage.ImpersonationRequest{
+			SessionID: session.ID,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			RequestID: requestIDPtr,
+		})
+		if err != nil {
+			s.logger.Error("record impersonated request failed", zapError(err), zap.String("session", identity.ImpersonationID))
+			abortWithError(c, http.StatusInternalServerError, codeInternalError)
+			return
+		}
+
+		c.Next()
+
+		s.logger.Info("impersonated request",
+			zap.String("session", identity.ImpersonationID),
+			zap.String("actor", identity.Subject),
+			zap.String("tenant", identity.ActingTenantID),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", c.Writer.Status()),
+			zap.String("request_id", requestID),
+		)
+
+		// The client may have gone away; the status is still worth keeping.
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := s.impersonationRepo.CompleteRequest(ctx, recordID, int32(c.Writer.Status())); err != nil {
+			s.logger.Warn("record impersonated request status failed", zapError(err), zap.String("session", identity.ImpersonationID))
+		}
+	}
+}
+
+// adminTenantScope returns the tenant a platform admin currently operates in:
+// the impersonated tenant during an impersonation session, the platform tenant otherwise.
+func (s *Server) adminTenantScope(ctx *middleware.IdentityContext) uuid.UUID {
+	if ctx.Impersonating() {
+		if tenantID, err := uuid.Parse(ctx.ActingTenantID); err == nil {
+			return tenantID
+		}
+	}
+	return s.platformTenantID
+}
+
+func (s *Server) impersonationHeader() string {
+	if header := strings.TrimSpace(s.cfg.Impersonation.Header); header != "" {
+		return header
+	}
+	return defaultImpersonationHeader
+}
+
+func (s *Server) impersonationMaxDuration() time.Duration {
+	if s.cfg.Impersonation.MaxDuration > 0 {
+		return s.cfg.Impersonation.MaxDuration
+	}
+	return defaultImpersonationMaxDuration
+}
+
+func mapImpersonation(session storage.ImpersonationSession) impersonationResponse {
+	var endedAt *string
+	if session.EndedAt != nil {
+		formatted := session.EndedAt.Format(time.RFC3339)
+		endedAt = &formatted
+	}
+	return impersonationResponse{
+		ID:           session.ID,
+		TenantID:     session.TenantID,
+		ActorSubject: session.ActorSubject,
+		Reason:       session.Reason,
+		Active:       session.Active(time.Now()),
+		ExpiresAt:    session.ExpiresAt.Format(time.RFC3339),
+		EndedAt:      endedAt,
+		CreatedAt:    session.CreatedAt.Format(time.RFC3339),
+		RequestCount: session.RequestCount,
+	}
+}
@@ -0,0 +1,509 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	// defaultInvitationTTL applies when the invitations TTL is not configured.
+	defaultInvitationTTL = 72 * time.Hour
+	// maxInvitationRoles bounds the tenant roles loaded to resolve role codes.
+	maxInvitationRoles = 1000
+)
+
+func (s *Server) registerInvitationRoutes(group *gin.RouterGroup) {
+	group.GET("/invitations", s.handleListInvitations)
+	group.POST("/invitations", s.handleCreateInvitation)
+	group.GET("/invitations/:id", s.handleGetInvitation)
+	group.POST("/invitations/:id/resend", s.handleResendInvitation)
+	group.DELETE("/invitations/:id", s.handleRevokeInvitation)
+}
+
+type invitationResponse struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	GroupID     uuid.UUID `json:"group_id"`
+	IdentityID  uuid.UUID `json:"identity_id"`
+	Phone       string    `json:"phone"`
+	DisplayName string    `json:"display_name"`
+	Title       *string   `json:"title,omitempty"`
+	Roles       []string  `json:"roles"`
+	Status      string    `json:"status"`
+	SendCount   int32     `json:"send_count"`
+	LastSentAt  *string   `json:"last_sent_at,omitempty"`
+	LastError   *string   `json:"last_error,omitempty"`
+	ExpiresAt   string    `json:"expires_at"`
+	InvitedBy   *string   `json:"invited_by,omitempty"`
+	AcceptedAt  *string   `json:"accepted_at,omitempty"`
+	RevokedAt   *string   `json:"revoked_at,omitempty"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+}
+
+type listInvitationsResponse struct {
+	Items    []invitationResponse `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
+
+type createInvitationPayload struct {
+	GroupID     string   `json:"group_id"`
+	Phone       string   `json:"phone"`
+	DisplayName string   `json:"display_name"`
+	Title       *string  `json:"title"`
+	Roles       []string `json:"roles"`
+}
+
+func (s *Server) handleListInvitations(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, ctx, true)
+	if !ok {
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	switch status {
+	case "", storage.InvitationPending, storage.InvitationAccepted, storage.InvitationExpired, storage.InvitationRevoked:
+	default:
+		respondError(c, http.StatusBadRequest, codeInvitationStatusInvalid)
+		return
+	}
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	invitations, total, err := s.invitationRepo.ListInvitations(c.Request.Context(), tenantID, status, int32(pageSize), int32((page-1)*pageSize))
+	if err != nil {
+		s.logger.Error("list invitations failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]invitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		items = append(items, mapInvitation(s.refreshInvitation(c.Request.Context(), invitation)))
+	}
+	c.JSON(http.StatusOK, listInvitationsResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// handleCreateInvitation creates a passwordless identity in the group, assigns the
+// requested roles and texts the invitee a recovery code to set up the account.
+func (s *Server) handleCreateInvitation(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	var payload createInvitationPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	groupID, err := uuid.Parse(strings.TrimSpace(payload.GroupID))
+	if err != nil {
+		respondInvalidParam(c, "group_id")
+		return
+	}
+
+	displayName := strings.TrimSpace(payload.DisplayName)
+	phone := kratos.NormalizePhone(payload.Phone)
+	if displayName == "" || phone == "" {
+		respondMissingFields(c, "display_name", "phone")
+		return
+	}
+	if utf8.RuneCountInString(displayName) > maxNicknameLength {
+		respondError(c, http.StatusBadRequest, codeDisplayNameLength, maxNicknameLength)
+		return
+	}
+
+	requestCtx := c.Request.Context()
+	group, err := s.groupRepo.GetGroup(requestCtx, groupID)
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
+			return
+		}
+		s.logger.Error("load group failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	if !s.ensureTenantAccess(c, ctx, group.TenantID) {
+		return
+	}
+
+	roles, ok := s.resolveInvitationRoles(c, group.TenantID, payload.Roles)
+	if !ok {
+		return
+	}
+
+	tenant, err := s.tenantRepo.GetTenant(requestCtx, group.TenantID)
+	if err != nil {
+		s.logger.Error("load tenant failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	existing, err := s.kratosClient.FindIdentityByIdentifier(requestCtx, phone)
+	if err != nil {
+		s.logger.Error("kratos lookup failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	if existing != nil {
+		respondError(c, http.StatusConflict, codePhoneAlreadyRegistered)
+		return
+	}
+
+	identity, err := s.kratosClient.CreateIdentity(requestCtx, kratos.CreateIdentityInput{
+		Phone:    phone,
+		Nickname: displayName,
+		UserType: "internal",
+		TenantID: group.TenantID.String(),
+		Roles:    []string{},
+	})
+	if err != nil {
+		s.respondKratosError(c, err, "create kratos identity failed")
+		return
+	}
+
+	identityID, err := uuid.Parse(identity.ID)
+	if err != nil {
+		s.logger.Error("parse identity id failed", zap.String("identity", identity.ID), zap.Error(err))
+		respondInternalError(c)
+		return
+	}
+
+	var title *string
+	if payload.Title != nil {
+		if trimmed := strings.TrimSpace(*payload.Title); trimmed != "" {
+			title = &trimmed
+		}
+	}
+
+	invitedBy := ctx.Subject
+	invitation, err := s.invitationRepo.CreateInvitation(requestCtx, storage.Invitation{
+		TenantID:    group.TenantID,
+		GroupID:     group.ID,
+		IdentityID:  identityID,
+		Phone:       phone,
+		DisplayName: displayName,
+		Title:       title,
+		ExpiresAt:   time.Now().Add(s.invitationTTL()),
+		InvitedBy:   &invitedBy,
+	}, roles)
+	if err != nil {
+		s.logger.Error("create invitation failed", zapError(err))
+		if deleteErr := s.kratosClient.DeleteIdentity(requestCtx, identity.ID); deleteErr != nil {
+			s.logger.Warn("rollback kratos identity failed", zapError(deleteErr))
+		}
+		respondInternalError(c)
+		return
+	}
+	roleIDs := make([]uuid.UUID, 0, len(roles))
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.ID)
+	}
+	s.syncInvitationGrants(requestCtx, invitation, roleIDs...)
+
+	invitation, err = s.deliverInvitation(requestCtx, invitation, tenant.Name)
+	if err != nil {
+		s.logger.Error("record invitation delivery failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	c.JSON(http.StatusCreated, mapInvitation(invitation))
+}
+
+func (s *Server) handleGetInvitation(c *gin.Context) {
+	invitation, ok := s.loadInvitation(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, mapInvitation(s.refreshInvitation(c.Request.Context(), invitation)))
+}
+
+// handleResendInvitation issues a fresh recovery code, which also extends an expired
+// invitation by another TTL.
+func (s *Server) handleResendInvitation(c *gin.Context) {
+	invitation, ok := s.loadInvitation(c)
+	if !ok {
+		return
+	}
+
+	requestCtx := c.Request.Context()
+	invitation = s.refreshInvitation(requestCtx, invitation)
+	if invitation.Status != storage.InvitationPending && invitation.Status != storage.InvitationExpired {
+		respondError(c, http.StatusConflict, codeInvitationResendState)
+		return
+	}
+
+	tenant, err := s.tenantRepo.GetTenant(requestCtx, invitation.TenantID)
+	if err != nil {
+		s.logger.Error("load tenant failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	invitation, err = s.deliverInvitation(requestCtx, invitation, tenant.Name)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvitationState) {
+			respondError(c, http.StatusConflict, codeInvitationResendState)
+			return
+		}
+		s.logger.Error("record invitation delivery failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mapInvitation(invitation))
+}
+
+// handleRevokeInvitation withdraws an open invitation. The invitee never set up the
+// account, so the membership, roles and identity created for it are removed as well.
+func (s *Server) handleRevokeInvitation(c *gin.Context) {
+	invitation, ok := s.loadInvitation(c)
+	if !ok {
+		return
+	}
+
+	requestCtx := c.Request.Context()
+	invitation = s.refreshInvitation(requestCtx, invitation)
+	// The assignments are gone once the invitation is revoked, so collect the roles whose
+	// Keto tuples the revocation queues for removal first.
+	roles, err := s.roleRepo.ListIdentityRoles(requestCtx, invitation.TenantID, invitation.IdentityID)
+	if err != nil {
+		s.logger.Error("list invited roles failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	revoked, err := s.invitationRepo.RevokeInvitation(requestCtx, invitation.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvitationState) {
+			respondError(c, http.StatusConflict, codeInvitationRevokeState)
+			return
+		}
+		s.logger.Error("revoke invitation failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	roleIDs := make([]uuid.UUID, 0, len(roles))
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.RoleID)
+	}
+	s.syncInvitationGrants(requestCtx, revoked, roleIDs...)
+
+	// Keep the identity if it was added to other groups after the invitation was sent.
+	memberships, err := s.groupRepo.ListGroupsForIdentity(requestCtx, invitation.TenantID, invitation.IdentityID)
+	if err != nil {
+		s.logger.Warn("list invited memberships failed", zapError(err))
+	} else if len(memberships) == 0 {
+		if err := s.kratosClient.DeleteIdentity(requestCtx, invitation.IdentityID.String()); err != nil {
+			s.logger.Warn("delete invited identity failed", zapError(err))
+		}
+	}
+
+	c.JSON(http.StatusOK, mapInvitation(revoked))
+}
+
+// syncInvitationGrants applies the Keto changes queued for the membership and the role
+// assignments of an invitation. Entries that fail stay queued and are retried by the Keto
+// sync worker.
+func (s *Server) syncInvitationGrants(ctx context.Context, invitation storage.Invitation, roleIDs ...uuid.UUID) {
+	keys := make([]string, 0, len(roleIDs)+1)
+	keys = append(keys, storage.GroupMemberSyncKey(invitation.GroupID, invitation.IdentityID))
+	for _, roleID := range roleIDs {
+		keys = append(keys, storage.RoleMemberSyncKey(roleID, invitation.IdentityID))
+	}
+	if err := s.ketoSyncer.Sync(ctx, keys...); err != nil {
+		s.logger.Warn("keto sync of invitation grants deferred", zapError(err))
+	}
+}
+
+// resolveInvitationRoles maps role codes to roles of the tenant, rejecting unknown codes.
+func (s *Server) resolveInvitationRoles(c *gin.Context, tenantID uuid.UUID, codes []string) ([]storage.Role, bool) {
+	if len(codes) == 0 {
+		return nil, true
+	}
+
+	tenantRoles, _, err := s.roleRepo.ListRoles(c.Request.Context(), storage.RoleListParams{
+		Scope:    "tenant",
+		TenantID: &tenantID,
+		Limit:    maxInvitationRoles,
+	})
+	if err != nil {
+		s.logger.Error("list roles failed", zapError(err))
+		respondInternalError(c)
+		return nil, false
+	}
+	byCode := make(map[string]storage.Role, len(tenantRoles))
+	for _, role := range tenantRoles {
+		byCode[role.Code] = role
+	}
+
+	roles := make([]storage.Role, 0, len(codes))
+	seen := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		if _, dup := seen[code]; dup {
+			continue
+		}
+		seen[code] = struct{}{}
+		role, ok := byCode[code]
+		if !ok {
+			respondError(c, http.StatusBadRequest, codeUnknownRole, code)
+			return nil, false
+		}
+		roles = append(roles, role)
+	}
+	return roles, true
+}
+
+// deliverInvitation issues a recovery code valid for the invitation TTL and texts it to the
+// invitee. Delivery failures are stored on the invitation so the admin can resend.
+func (s *Server) deliverInvitation(ctx context.Context, invitation storage.Invitation, tenantName string) (storage.Invitation, error) {
+	ttl := s.invitationTTL()
+	expiresAt := time.Now().Add(ttl)
+
+	var deliveryErr *string
+	code, err := s.kratosClient.CreateRecoveryCode(ctx, invitation.IdentityID.String(), ttl)
+	if err == nil {
+		if !code.ExpiresAt.IsZero() {
+			expiresAt = code.ExpiresAt
+		}
+		err = s.smsSender.Send(ctx, invitation.Phone, invitationMessage(tenantName, code, expiresAt))
+	}
+	if err != nil {
+		s.logger.Warn("deliver invitation failed", zapError(err), zap.String("invitation", invitation.ID.String()))
+		message := err.Error()
+		deliveryErr = &message
+	}
+
+	return s.invitationRepo.RecordDelivery(ctx, invitation.ID, expiresAt, deliveryErr)
+}
+
+func invitationMessage(tenantName string, code *kratos.RecoveryCode, expiresAt time.Time) string {
+	return fmt.Sprintf("您已被邀请加入%s，验证码 %s，请于 %s 前打开 %s 设置登录密码。",
+		tenantName, code.Code, expiresAt.Local().Format("2006-01-02 15:04"), code.Link)
+}
+
+// refreshInvitation marks an open invitation accepted once the invitee has set a password.
+// Lookup failures leave the invitation unchanged.
+func (s *Server) refreshInvitation(ctx context.Context, invitation storage.Invitation) storage.Invitation {
+	if invitation.Status != storage.InvitationPending && invitation.Status != storage.InvitationExpired {
+		return invitation
+	}
+
+	identity, err := s.kratosClient.GetIdentity(ctx, invitation.IdentityID.String())
+	if err != nil {
+		s.logger.Warn("load invited identity failed", zapError(err))
+		return invitation
+	}
+	if !identity.HasCredential(kratos.CredentialPassword) {
+		return invitation
+	}
+
+	accepted, err := s.invitationRepo.AcceptInvitation(ctx, invitation.ID)
+	if err != nil {
+		s.logger.Warn("accept invitation failed", zapError(err))
+		return invitation
+	}
+	return accepted
+}
+
+func (s *Server) invitationTTL() time.Duration {
+	if ttl := s.cfg.Invitations.TTL; ttl > 0 {
+		return ttl
+	}
+	return defaultInvitationTTL
+}
+
+func (s *Server) loadInvitation(c *gin.Context) (storage.Invitation, bool) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return storage.Invitation{}, false
+	}
+
+	id, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "invitation_id")
+		return storage.Invitation{}, false
+	}
+
+	invitation, err := s.invitationRepo.GetInvitation(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvitationNotFound) {
+			respondError(c, http.StatusNotFound, codeInvitationNotFound)
+			return storage.Invitation{}, false
+		}
+		s.logger.Error("load invitation failed", zapError(err))
+		respondInternalError(c)
+		return storage.Invitation{}, false
+	}
+
+	if !s.ensureTenantAccess(c, ctx, invitation.TenantID) {
+		return storage.Invitation{}, false
+	}
+	return invitation, true
+}
+
+func mapInvitation(invitation storage.Invitation) invitationResponse {
+	resp := invitationResponse{
+		ID:          invitation.ID,
+		TenantID:    invitation.TenantID,
+		GroupID:     invitation.GroupID,
+		IdentityID:  invitation.IdentityID,
+		Phone:       invitation.Phone,
+		DisplayName: invitation.DisplayName,
+		Title:       invitation.Title,
+		Roles:       invitation.Roles,
+		Status:      invitation.Status,
+		SendCount:   invitation.SendCount,
+		LastError:   invitation.LastError,
+		ExpiresAt:   invitation.ExpiresAt.Format(time.RFC3339),
+		InvitedBy:   invitation.InvitedBy,
+		CreatedAt:   invitation.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   invitation.UpdatedAt.Format(time.RFC3339),
+	}
+	resp.LastSentAt = formatOptionalTime(invitation.LastSentAt)
+	resp.AcceptedAt = formatOptionalTime(invitation.AcceptedAt)
+	resp.RevokedAt = formatOptionalTime(invitation.RevokedAt)
+	return resp
+}
+
+func formatOptionalTime(value *time.Time) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := value.Format(time.RFC3339)
+	return &formatted
+}
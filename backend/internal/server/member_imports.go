@@ -0,0 +1,355 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/importer"
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+// maxImportFileSize bounds uploaded member spreadsheets.
+const maxImportFileSize = 10 << 20
+
+func (s *Server) registerMemberImportRoutes(group *gin.RouterGroup) {
+	group.GET("/member-imports", s.handleListMemberImports)
+	group.POST("/member-imports", s.handleCreateMemberImport)
+	group.GET("/member-imports/:id", s.handleGetMemberImport)
+	group.GET("/member-imports/:id/rows", s.handleListMemberImportRows)
+	group.POST("/member-imports/:id/commit", s.handleCommitMemberImport)
+	group.POST("/member-imports/:id/resume", s.handleResumeMemberImport)
+}
+
+type memberImportResponse struct {
+	ID          uuid.UUID               `json:"id"`
+	TenantID    uuid.UUID               `json:"tenant_id"`
+	Filename    string                  `json:"filename"`
+	Status      string                  `json:"status"`
+	TotalRows   int32                   `json:"total_rows"`
+	ValidRows   int32                   `json:"valid_rows"`
+	Counts      storage.ImportRowCounts `json:"counts"`
+	CreatedBy   *string                 `json:"created_by,omitempty"`
+	CommittedBy *string                 `json:"committed_by,omitempty"`
+	StartedAt   *string                 `json:"started_at,omitempty"`
+	FinishedAt  *string                 `json:"finished_at,omitempty"`
+	CreatedAt   string                  `json:"created_at"`
+	UpdatedAt   string                  `json:"updated_at"`
+}
+
+type memberImportRowResponse struct {
+	RowNumber   int32      `json:"row_number"`
+	DisplayName string     `json:"display_name"`
+	Phone       string     `json:"phone"`
+	GroupCode   string     `json:"group_code"`
+	Title       *string    `json:"title,omitempty"`
+	Roles       []string   `json:"roles"`
+	Status      string     `json:"status"`
+	Errors      []string   `json:"errors"`
+	IdentityID  *uuid.UUID `json:"identity_id,omitempty"`
+	ProcessedAt *string    `json:"processed_at,omitempty"`
+}
+
+type memberImportPreviewResponse struct {
+	memberImportResponse
+	Rows []memberImportRowResponse `json:"rows"`
+}
+
+type listMemberImportsResponse struct {
+	Items    []memberImportResponse `json:"items"`
+	Total    int64                  `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+}
+
+type listMemberImportRowsResponse struct {
+	Items    []memberImportRowResponse `json:"items"`
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+}
+
+func (s *Server) handleListMemberImports(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, ctx, true)
+	if !ok {
+		return
+	}
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	imports, total, err := s.importRepo.ListImports(c.Request.Context(), tenantID, int32(pageSize), int32((page-1)*pageSize))
+	if err != nil {
+		s.logger.Error("list member imports failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]memberImportResponse, 0, len(imports))
+	for _, memberImport := range imports {
+		items = append(items, mapMemberImport(memberImport))
+	}
+	c.JSON(http.StatusOK, listMemberImportsResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// handleCreateMemberImport parses an uploaded CSV or XLSX file and returns the validation
+// preview. The import is only processed once it is committed.
+func (s *Server) handleCreateMemberImport(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		respondMissingFields(c, "file")
+		return
+	}
+
+	tenantID, ok := s.resolveTenantIDForPayload(c, ctx, c.PostForm("tenant_id"))
+	if !ok {
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeImportFileUnreadable)
+		return
+	}
+	defer file.Close()
+
+	rows, err := importer.ParseFile(header.Filename, file, s.importer.MaxRows())
+	if err != nil {
+		s.respondImportFileError(c, err)
+		return
+	}
+
+	createdBy := ctx.Subject
+	memberImport, err := s.importer.Preview(c.Request.Context(), tenantID, header.Filename, rows, &createdBy)
+	if err != nil {
+		s.logger.Error("preview member import failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	previewRows, _, err := s.importRepo.ListRows(c.Request.Context(), memberImport.ID, "", int32(len(rows)), 0)
+	if err != nil {
+		s.logger.Error("list member import rows failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	response := memberImportPreviewResponse{
+		memberImportResponse: mapMemberImport(memberImport),
+		Rows:                 make([]memberImportRowResponse, 0, len(previewRows)),
+	}
+	for _, row := range previewRows {
+		response.Rows = append(response.Rows, mapMemberImportRow(row))
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+func (s *Server) handleGetMemberImport(c *gin.Context) {
+	memberImport, ok := s.loadMemberImport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, mapMemberImport(memberImport))
+}
+
+func (s *Server) handleListMemberImportRows(c *gin.Context) {
+	memberImport, ok := s.loadMemberImport(c)
+	if !ok {
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	switch status {
+	case "", storage.ImportRowInvalid, storage.ImportRowPending, storage.ImportRowSucceeded, storage.ImportRowFailed:
+	default:
+		respondError(c, http.StatusBadRequest, codeImportRowStatusInvalid)
+		return
+	}
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	rows, total, err := s.importRepo.ListRows(c.Request.Context(), memberImport.ID, status, int32(pageSize), int32((page-1)*pageSize))
+	if err != nil {
+		s.logger.Error("list member import rows failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]memberImportRowResponse, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, mapMemberImportRow(row))
+	}
+	c.JSON(http.StatusOK, listMemberImportRowsResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+func (s *Server) handleCommitMemberImport(c *gin.Context) {
+	memberImport, ok := s.loadMemberImport(c)
+	if !ok {
+		return
+	}
+	if memberImport.ValidRows == 0 {
+		respondError(c, http.StatusBadRequest, codeImportNoValidRows)
+		return
+	}
+
+	subject := middleware.IdentityFromContext(c).Subject
+	committed, err := s.importRepo.CommitImport(c.Request.Context(), memberImport.ID, &subject)
+	if !s.handleMemberImportTransition(c, err, "commit", codeImportCommitState) {
+		return
+	}
+	committed.Counts = memberImport.Counts
+	c.JSON(http.StatusAccepted, mapMemberImport(committed))
+}
+
+func (s *Server) handleResumeMemberImport(c *gin.Context) {
+	memberImport, ok := s.loadMemberImport(c)
+	if !ok {
+		return
+	}
+
+	subject := middleware.IdentityFromContext(c).Subject
+	resumed, err := s.importRepo.ResumeImport(c.Request.Context(), memberImport.ID, &subject)
+	if !s.handleMemberImportTransition(c, err, "resume", codeImportResumeState) {
+		return
+	}
+	c.JSON(http.StatusAccepted, mapMemberImport(resumed))
+}
+
+// respondImportFileError answers an upload the importer could not parse.
+func (s *Server) respondImportFileError(c *gin.Context, err error) {
+	var missing *importer.MissingColumnsError
+	switch {
+	case errors.Is(err, importer.ErrUnsupportedFormat):
+		respondError(c, http.StatusBadRequest, codeImportFileFormat)
+	case errors.Is(err, importer.ErrEmptyFile):
+		respondError(c, http.StatusBadRequest, codeImportFileEmpty)
+	case errors.As(err, &missing):
+		respondError(c, http.StatusBadRequest, codeImportFileColumns, strings.Join(missing.Columns, ", "))
+	case errors.Is(err, importer.ErrTooManyRows):
+		respondError(c, http.StatusBadRequest, codeImportFileTooManyRows, s.importer.MaxRows())
+	default:
+		s.logger.Warn("parse member import file failed", zapError(err))
+		respondError(c, http.StatusBadRequest, codeImportFileInvalid)
+	}
+}
+
+func (s *Server) handleMemberImportTransition(c *gin.Context, err error, action, conflictCode string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrMemberImportNotFound):
+		respondError(c, http.StatusNotFound, codeImportNotFound)
+	case errors.Is(err, storage.ErrMemberImportState):
+		respondError(c, http.StatusConflict, conflictCode)
+	default:
+		s.logger.Error(action+" member import failed", zapError(err))
+		respondInternalError(c)
+	}
+	return false
+}
+
+func (s *Server) loadMemberImport(c *gin.Context) (storage.MemberImport, bool) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return storage.MemberImport{}, false
+	}
+
+	id, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "member_import_id")
+		return storage.MemberImport{}, false
+	}
+
+	memberImport, err := s.importRepo.GetImport(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrMemberImportNotFound) {
+			respondError(c, http.StatusNotFound, codeImportNotFound)
+			return storage.MemberImport{}, false
+		}
+		s.logger.Error("load member import failed", zapError(err))
+		respondInternalError(c)
+		return storage.MemberImport{}, false
+	}
+
+	if !s.ensureTenantAccess(c, ctx, memberImport.TenantID) {
+		return storage.MemberImport{}, false
+	}
+	return memberImport, true
+}
+
+func mapMemberImport(memberImport storage.MemberImport) memberImportResponse {
+	resp := memberImportResponse{
+		ID:          memberImport.ID,
+		TenantID:    memberImport.TenantID,
+		Filename:    memberImport.Filename,
+		Status:      memberImport.Status,
+		TotalRows:   memberImport.TotalRows,
+		ValidRows:   memberImport.ValidRows,
+		Counts:      memberImport.Counts,
+		CreatedBy:   memberImport.CreatedBy,
+		CommittedBy: memberImport.CommittedBy,
+		CreatedAt:   memberImport.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   memberImport.UpdatedAt.Format(time.RFC3339),
+	}
+	if memberImport.StartedAt != nil {
+		started := memberImport.StartedAt.Format(time.RFC3339)
+		resp.StartedAt = &started
+	}
+	if memberImport.FinishedAt != nil {
+		finished := memberImport.FinishedAt.Format(time.RFC3339)
+		resp.FinishedAt = &finished
+	}
+	return resp
+}
+
+func mapMemberImportRow(row storage.MemberImportRow) memberImportRowResponse {
+	resp := memberImportRowResponse{
+		RowNumber:   row.RowNumber,
+		DisplayName: row.DisplayName,
+		Phone:       row.Phone,
+		GroupCode:   row.GroupCode,
+		Title:       row.Title,
+		Roles:       row.Roles,
+		Status:      row.Status,
+		Errors:      row.Errors,
+		IdentityID:  row.IdentityID,
+	}
+	if row.ProcessedAt != nil {
+		processed := row.ProcessedAt.Format(time.RFC3339)
+		resp.ProcessedAt = &processed
+	}
+	return resp
+}
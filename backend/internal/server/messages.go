@@ -0,0 +1,347 @@
+package server
+
+import "fmt"
+
+// Catalog languages.
+const (
+	langZH = "zh-CN"
+	langEN = "en"
+)
+
+// Error codes returned in the error envelope. They are part of the API and must not be
+// renamed; add new codes instead.
+const (
+	codeInternalError            = "internal_error"
+	codeInvalidRequestBody       = "invalid_request_body"
+	codeRequestInvalid           = "request_invalid"
+	codeInvalidParameter         = "invalid_parameter"
+	codeInvalidTimestamp         = "invalid_timestamp"
+	codeMissingFields            = "missing_fields"
+	codeFieldRequired            = "field_required"
+	codeMetadataInvalid          = "metadata_invalid"
+	codeUnauthorized             = "unauthorized"
+	codeForbidden                = "forbidden"
+	codeRoleForbidden            = "role_forbidden"
+	codeTenantForbidden          = "tenant_forbidden"
+	codeAuthorizationUnavailable = "authorization_unavailable"
+	codePreconditionFailed       = "precondition_failed"
+	codePreconditionRequired     = "precondition_required"
+	codeIdempotencyKeyTooLong    = "idempotency_key_too_long"
+	codeIdempotencyKeyReused     = "idempotency_key_reused"
+	codeIdempotencyKeyInProgress = "idempotency_key_in_progress"
+
+	codePhoneAlreadyRegistered = "phone_already_registered"
+	codeIdentityRejected       = "identity_rejected"
+	codeIdentityServiceError   = "identity_service_error"
+
+	codeTenantNotFound           = "tenant_not_found"
+	codeTenantCodeInvalid        = "tenant_code_invalid"
+	codeTenantCodeExists         = "tenant_code_exists"
+	codeTenantContextMissing     = "tenant_context_missing"
+	codeTenantContextInvalid     = "tenant_context_invalid"
+	codeUserNotFound             = "user_not_found"
+	codeUserTenantMismatch       = "user_tenant_mismatch"
+	codeUserStateInvalid         = "user_state_invalid"
+	codeSelfDisable              = "self_disable"
+	codePlatformUserJoin         = "platform_user_join"
+	codeDisplayNameLength        = "display_name_length"
+	codePasswordTooShort         = "password_too_short"
+	codeIdentityOrPhoneRequired  = "identity_or_phone_required"
+	codeIdentityIDsInvalid       = "identity_ids_invalid"
+	codeIdentityIDInvalid        = "identity_id_invalid"
+	codeImpersonationNotFound    = "impersonation_session_not_found"
+	codeImpersonationInvalid     = "impersonation_session_invalid"
+	codeImpersonationExpired     = "impersonation_session_expired"
+	codeImpersonationForeign     = "impersonation_session_foreign"
+	codeImpersonationNested      = "impersonation_nested"
+	codeImpersonationNotRequired = "impersonation_not_required"
+	codeImpersonationAdminOnly   = "impersonation_admin_only"
+
+	codeGroupNotFound           = "group_not_found"
+	codeGroupCodeInvalid        = "group_code_invalid"
+	codeGroupCodeExists         = "group_code_exists"
+	codeGroupSelfParent         = "group_self_parent"
+	codeGroupCycle              = "group_cycle"
+	codeGroupParentTenant       = "group_parent_tenant"
+	codeGroupHasChildren        = "group_has_children"
+	codeGroupHasMembers         = "group_has_members"
+	codeGroupVersionConflict    = "group_version_conflict"
+	codeGroupTreeCycle          = "group_tree_cycle"
+	codeGroupTreeDuplicate      = "group_tree_duplicate"
+	codeGroupTreeTooLarge       = "group_tree_too_large"
+	codeParentGroupNotFound     = "parent_group_not_found"
+	codeTargetGroupNotFound     = "target_group_not_found"
+	codeTargetGroupTenant       = "target_group_tenant"
+	codeTargetGroupInSubtree    = "target_group_in_subtree"
+	codeTargetGroupSame         = "target_group_same"
+	codeMemberNotFound          = "member_not_found"
+	codeMemberExists            = "member_exists"
+	codeTargetMemberExists      = "target_member_exists"
+	codeMemberUpdateEmpty       = "member_update_empty"
+	codePrimaryRequired         = "primary_membership_required"
+	codeOrgChartFormatInvalid   = "org_chart_format_invalid"
+	codeRoleNotFound            = "role_not_found"
+	codeRoleCodeExists          = "role_code_exists"
+	codeRoleScopeInvalid        = "role_scope_invalid"
+	codeRoleScopeImmutable      = "role_scope_immutable"
+	codeGlobalRoleTenant        = "global_role_tenant"
+	codeGlobalRoleAdminOnly     = "global_role_admin_only"
+	codeGlobalRoleUnavailable   = "global_role_unavailable"
+	codeUnknownRole             = "unknown_role"
+	codePermissionUnknown       = "permission_unknown"
+	codePermissionGlobalOnly    = "permission_scope_global"
+	codePermissionTenantOnly    = "permission_scope_tenant"
+	codeInvitationNotFound      = "invitation_not_found"
+	codeInvitationStatusInvalid = "invitation_status_invalid"
+	codeInvitationResendState   = "invitation_resend_state"
+	codeInvitationRevokeState   = "invitation_revoke_state"
+
+	codeWebhookNotFound        = "webhook_not_found"
+	codeWebhookURLInvalid      = "webhook_url_invalid"
+	codeWebhookURLForbidden    = "webhook_url_forbidden"
+	codeWebhookEventUnknown    = "webhook_event_unknown"
+	codeDeliveryNotFound       = "webhook_delivery_not_found"
+	codeDeliveryStatusInvalid  = "webhook_delivery_status_invalid"
+	codeScimTokenNotFound      = "scim_token_not_found"
+	codeImportNotFound         = "member_import_not_found"
+	codeImportRowStatusInvalid = "member_import_row_status_invalid"
+	codeImportNoValidRows      = "member_import_no_valid_rows"
+	codeImportCommitState      = "member_import_commit_state"
+	codeImportResumeState      = "member_import_resume_state"
+	codeImportFileUnreadable   = "import_file_unreadable"
+	codeImportFileFormat       = "import_file_format"
+	codeImportFileEmpty        = "import_file_empty"
+	codeImportFileColumns      = "import_file_columns"
+	codeImportFileTooManyRows  = "import_file_too_many_rows"
+	codeImportFileInvalid      = "import_file_invalid"
+)
+
+// messages holds the message templates of every error code per language. Templates use
+// fmt verbs filled from the arguments given with the code.
+var messages = map[string]map[string]string{
+	langZH: {
+		codeInternalError:            "服务器内部错误，请稍后重试",
+		codeInvalidRequestBody:       "请求体格式错误",
+		codeRequestInvalid:           "请求不符合接口定义",
+		codeInvalidParameter:         "参数 %s 无效",
+		codeInvalidTimestamp:         "%s 不是合法的 RFC3339 时间",
+		codeMissingFields:            "缺少必填字段：%s",
+		codeFieldRequired:            "%s 为必填项",
+		codeMetadataInvalid:          "metadata 必须是合法的 JSON",
+		codeUnauthorized:             "未登录或登录已失效",
+		codeForbidden:                "权限不足",
+		codeRoleForbidden:            "无权操作该角色",
+		codeTenantForbidden:          "无权访问该租户",
+		codeAuthorizationUnavailable: "鉴权服务暂不可用",
+		codePreconditionFailed:       "资源已被他人修改，请刷新后重试",
+		codePreconditionRequired:     "请求缺少 If-Match 请求头",
+		codeIdempotencyKeyTooLong:    "Idempotency-Key 长度不能超过 %d 个字符",
+		codeIdempotencyKeyReused:     "该 Idempotency-Key 已用于其他请求",
+		codeIdempotencyKeyInProgress: "使用该 Idempotency-Key 的请求仍在处理中",
+
+		codePhoneAlreadyRegistered: "手机号已注册",
+		codeIdentityRejected:       "身份信息不符合要求",
+		codeIdentityServiceError:   "身份服务暂不可用，请稍后重试",
+
+		codeTenantNotFound:           "租户不存在",
+		codeTenantCodeInvalid:        "租户编码须为 1-10 位字母或数字",
+		codeTenantCodeExists:         "租户编码已存在",
+		codeTenantContextMissing:     "缺少租户上下文",
+		codeTenantContextInvalid:     "租户上下文无效",
+		codeUserNotFound:             "用户不存在",
+		codeUserTenantMismatch:       "用户属于其他租户",
+		codeUserStateInvalid:         "状态必须为 active 或 inactive",
+		codeSelfDisable:              "不能停用自己的账号",
+		codePlatformUserJoin:         "平台用户不能加入租户",
+		codeDisplayNameLength:        "姓名长度须为 1-%d 个字符",
+		codePasswordTooShort:         "密码长度不能少于 %d 位",
+		codeIdentityOrPhoneRequired:  "请提供 identity_id 或 phone",
+		codeIdentityIDsInvalid:       "未提供有效的用户 ID",
+		codeIdentityIDInvalid:        "用户 ID 无效：%s",
+		codeImpersonationNotFound:    "代管会话不存在",
+		codeImpersonationInvalid:     "代管会话无效",
+		codeImpersonationExpired:     "代管会话已过期",
+		codeImpersonationForeign:     "代管会话属于其他管理员",
+		codeImpersonationNested:      "不能嵌套发起代管会话",
+		codeImpersonationNotRequired: "平台租户无需代管",
+		codeImpersonationAdminOnly:   "只有平台管理员可以代管租户",
+
+		codeGroupNotFound:           "部门不存在",
+		codeGroupCodeInvalid:        "部门编码须为 1-32 位字母、数字、- 或 _",
+		codeGroupCodeExists:         "部门编码已存在",
+		codeGroupSelfParent:         "部门不能作为自己的上级",
+		codeGroupCycle:              "部门不能移动到其下级部门中",
+		codeGroupParentTenant:       "上级部门属于其他租户",
+		codeGroupHasChildren:        "部门下仍有子部门",
+		codeGroupHasMembers:         "部门下仍有成员",
+		codeGroupVersionConflict:    "部门已被他人修改，请刷新后重试",
+		codeGroupTreeCycle:          "变更会形成循环的部门层级",
+		codeGroupTreeDuplicate:      "变更中包含重复的部门",
+		codeGroupTreeTooLarge:       "单次最多允许 %d 项变更",
+		codeParentGroupNotFound:     "上级部门不存在",
+		codeTargetGroupNotFound:     "目标部门不存在",
+		codeTargetGroupTenant:       "目标部门属于其他租户",
+		codeTargetGroupInSubtree:    "目标部门位于待删除的部门树中",
+		codeTargetGroupSame:         "目标部门不能与原部门相同",
+		codeMemberNotFound:          "成员不存在",
+		codeMemberExists:            "该用户已是部门成员",
+		codeTargetMemberExists:      "该用户已是目标部门成员",
+		codeMemberUpdateEmpty:       "请至少提供 target_group_id、display_name、title 或 is_primary 之一",
+		codePrimaryRequired:         "请先将其他部门设为主部门",
+		codeOrgChartFormatInvalid:   "格式必须为 csv、xlsx、graphml 或 dot",
+		codeRoleNotFound:            "角色不存在",
+		codeRoleCodeExists:          "角色编码已存在",
+		codeRoleScopeInvalid:        "范围必须为 global 或 tenant",
+		codeRoleScopeImmutable:      "角色范围不可修改",
+		codeGlobalRoleTenant:        "全局角色不能指定 tenant_id",
+		codeGlobalRoleAdminOnly:     "只有平台管理员可以创建全局角色",
+		codeGlobalRoleUnavailable:   "代管租户时不能使用全局角色",
+		codeUnknownRole:             "未知角色：%s",
+		codePermissionUnknown:       "未知的权限编码：%s",
+		codePermissionGlobalOnly:    "全局角色不能包含权限 %s",
+		codePermissionTenantOnly:    "租户角色不能包含权限 %s",
+		codeInvitationNotFound:      "邀请不存在",
+		codeInvitationStatusInvalid: "状态必须为 pending、accepted、expired 或 revoked",
+		codeInvitationResendState:   "只有待接受或已过期的邀请可以重新发送",
+		codeInvitationRevokeState:   "只有待接受或已过期的邀请可以撤销",
+
+		codeWebhookNotFound:        "Webhook 不存在",
+		codeWebhookURLInvalid:      "URL 必须是完整的 http 或 https 地址",
+		codeWebhookURLForbidden:    "URL 不能指向内网、本机或链路本地地址",
+		codeWebhookEventUnknown:    "未知的事件类型：%s",
+		codeDeliveryNotFound:       "Webhook 投递记录不存在",
+		codeDeliveryStatusInvalid:  "状态必须为 pending、succeeded 或 failed",
+		codeScimTokenNotFound:      "SCIM 令牌不存在",
+		codeImportNotFound:         "成员导入任务不存在",
+		codeImportRowStatusInvalid: "状态必须为 invalid、pending、succeeded 或 failed",
+		codeImportNoValidRows:      "导入任务中没有有效的数据行",
+		codeImportCommitState:      "只有已预览的导入任务可以提交",
+		codeImportResumeState:      "只有失败的导入任务可以继续",
+		codeImportFileUnreadable:   "无法读取上传的文件",
+		codeImportFileFormat:       "不支持的文件格式，请上传 .csv 或 .xlsx 文件",
+		codeImportFileEmpty:        "文件中没有成员数据",
+		codeImportFileColumns:      "缺少必需的列：%s",
+		codeImportFileTooManyRows:  "单次最多导入 %d 名成员",
+		codeImportFileInvalid:      "文件解析失败",
+	},
+	langEN: {
+		codeInternalError:            "Internal server error, please try again later",
+		codeInvalidRequestBody:       "Invalid request body",
+		codeRequestInvalid:           "The request does not match the API specification",
+		codeInvalidParameter:         "Invalid parameter %s",
+		codeInvalidTimestamp:         "%s must be an RFC3339 timestamp",
+		codeMissingFields:            "Missing required fields: %s",
+		codeFieldRequired:            "%s is required",
+		codeMetadataInvalid:          "Metadata must be valid JSON",
+		codeUnauthorized:             "Authentication required",
+		codeForbidden:                "Insufficient permissions",
+		codeRoleForbidden:            "Insufficient permissions for this role",
+		codeTenantForbidden:          "Insufficient permissions for this tenant",
+		codeAuthorizationUnavailable: "Authorization service unavailable",
+		codePreconditionFailed:       "The resource has been modified, reload it and try again",
+		codePreconditionRequired:     "The If-Match header is required",
+		codeIdempotencyKeyTooLong:    "Idempotency-Key must be at most %d characters",
+		codeIdempotencyKeyReused:     "Idempotency-Key was already used for a different request",
+		codeIdempotencyKeyInProgress: "A request with this Idempotency-Key is still in progress",
+
+		codePhoneAlreadyRegistered: "Phone number is already registered",
+		codeIdentityRejected:       "The identity data was rejected",
+		codeIdentityServiceError:   "Identity service unavailable, please try again later",
+
+		codeTenantNotFound:           "Tenant not found",
+		codeTenantCodeInvalid:        "Tenant code must be 1-10 letters or digits",
+		codeTenantCodeExists:         "Tenant code already exists",
+		codeTenantContextMissing:     "Tenant context missing",
+		codeTenantContextInvalid:     "Invalid tenant context",
+		codeUserNotFound:             "User not found",
+		codeUserTenantMismatch:       "User belongs to another tenant",
+		codeUserStateInvalid:         "State must be active or inactive",
+		codeSelfDisable:              "You cannot disable your own account",
+		codePlatformUserJoin:         "Platform users cannot join a tenant",
+		codeDisplayNameLength:        "Display name must be between 1 and %d characters",
+		codePasswordTooShort:         "Password must be at least %d characters",
+		codeIdentityOrPhoneRequired:  "identity_id or phone is required",
+		codeIdentityIDsInvalid:       "No valid identity IDs provided",
+		codeIdentityIDInvalid:        "Invalid identity ID: %s",
+		codeImpersonationNotFound:    "Impersonation session not found",
+		codeImpersonationInvalid:     "Invalid impersonation session",
+		codeImpersonationExpired:     "Impersonation session expired",
+		codeImpersonationForeign:     "Impersonation session belongs to another admin",
+		codeImpersonationNested:      "Impersonation sessions cannot be nested",
+		codeImpersonationNotRequired: "The platform tenant does not require impersonation",
+		codeImpersonationAdminOnly:   "Only platform admins can act as a tenant",
+
+		codeGroupNotFound:           "Group not found",
+		codeGroupCodeInvalid:        "Group code must be 1-32 letters, digits, - or _",
+		codeGroupCodeExists:         "Group code already exists",
+		codeGroupSelfParent:         "A group cannot be its own parent",
+		codeGroupCycle:              "A group cannot be moved under its own descendant",
+		codeGroupParentTenant:       "Parent group belongs to another tenant",
+		codeGroupHasChildren:        "Group still has child groups",
+		codeGroupHasMembers:         "Group still has members",
+		codeGroupVersionConflict:    "The group has been modified, reload it and try again",
+		codeGroupTreeCycle:          "The changes would create a cycle",
+		codeGroupTreeDuplicate:      "A group appears more than once in the changes",
+		codeGroupTreeTooLarge:       "At most %d changes are allowed",
+		codeParentGroupNotFound:     "Parent group not found",
+		codeTargetGroupNotFound:     "Target group not found",
+		codeTargetGroupTenant:       "Target group belongs to another tenant",
+		codeTargetGroupInSubtree:    "Target group lies within the deleted subtree",
+		codeTargetGroupSame:         "Target group must differ from the source group",
+		codeMemberNotFound:          "Member not found",
+		codeMemberExists:            "The user is already a member of the group",
+		codeTargetMemberExists:      "The user is already a member of the target group",
+		codeMemberUpdateEmpty:       "One of target_group_id, display_name, title or is_primary is required",
+		codePrimaryRequired:         "Mark another group as primary instead",
+		codeOrgChartFormatInvalid:   "Format must be csv, xlsx, graphml or dot",
+		codeRoleNotFound:            "Role not found",
+		codeRoleCodeExists:          "Role code already exists",
+		codeRoleScopeInvalid:        "Scope must be global or tenant",
+		codeRoleScopeImmutable:      "Role scope cannot be changed",
+		codeGlobalRoleTenant:        "Global roles must not include tenant_id",
+		codeGlobalRoleAdminOnly:     "Only platform admins can create global roles",
+		codeGlobalRoleUnavailable:   "Global roles are not available while acting as a tenant",
+		codeUnknownRole:             "Unknown role: %s",
+		codePermissionUnknown:       "Unknown permission code: %s",
+		codePermissionGlobalOnly:    "Permission %s is not allowed for global roles",
+		codePermissionTenantOnly:    "Permission %s is not allowed for tenant roles",
+		codeInvitationNotFound:      "Invitation not found",
+		codeInvitationStatusInvalid: "Status must be pending, accepted, expired or revoked",
+		codeInvitationResendState:   "Only pending or expired invitations can be resent",
+		codeInvitationRevokeState:   "Only pending or expired invitations can be revoked",
+
+		codeWebhookNotFound:        "Webhook not found",
+		codeWebhookURLInvalid:      "URL must be an absolute http or https URL",
+		codeWebhookURLForbidden:    "URL must not point to a private, loopback or link-local address",
+		codeWebhookEventUnknown:    "Unknown event type: %s",
+		codeDeliveryNotFound:       "Webhook delivery not found",
+		codeDeliveryStatusInvalid:  "Status must be pending, succeeded or failed",
+		codeScimTokenNotFound:      "SCIM token not found",
+		codeImportNotFound:         "Member import not found",
+		codeImportRowStatusInvalid: "Status must be invalid, pending, succeeded or failed",
+		codeImportNoValidRows:      "The member import has no valid rows",
+		codeImportCommitState:      "Only previewed imports can be committed",
+		codeImportResumeState:      "Only failed imports can be resumed",
+		codeImportFileUnreadable:   "The uploaded file could not be read",
+		codeImportFileFormat:       "Unsupported file format, expected .csv or .xlsx",
+		codeImportFileEmpty:        "The file contains no member rows",
+		codeImportFileColumns:      "Missing required columns: %s",
+		codeImportFileTooManyRows:  "At most %d members can be imported at once",
+		codeImportFileInvalid:      "The file could not be parsed",
+	},
+}
+
+// localize renders the message of code in lang, falling back to English and then to the
+// code itself.
+func localize(lang, code string, args ...any) string {
+	template, ok := messages[lang][code]
+	if !ok {
+		if template, ok = messages[langEN][code]; !ok {
+			return code
+		}
+	}
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
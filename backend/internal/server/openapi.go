@@ -0,0 +1,187 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/openapi"
+)
+
+// registerOpenAPIRoutes publishes the API contract at /api/openapi.yaml and
+// /api/openapi.json. Both are readable without a session.
+func (s *Server) registerOpenAPIRoutes(api *gin.RouterGroup) {
+	api.GET("/openapi.yaml", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/yaml", openapi.Document())
+	})
+	api.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.apiSpec)
+	})
+}
+
+// withContractValidation checks requests to operations described by the OpenAPI document
+// against it. With openapi.validate_requests a request that does not match is rejected
+// with 400 before it reaches the handler; with openapi.validate_responses a response that
+// does not match is logged, which is meant to catch drift during development. Operations
+// missing from the document pass through unchecked.
+func (s *Server) withContractValidation() gin.HandlerFunc {
+	validateRequests := s.cfg.OpenAPI.ValidateRequests
+	validateResponses := s.cfg.OpenAPI.ValidateResponses
+	return func(c *gin.Context) {
+		if !validateRequests && !validateResponses {
+			c.Next()
+			return
+		}
+		route, pathParams, err := s.apiRouter.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				// Oathkeeper authenticates the session before the request gets here.
+				AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+				SkipSettingDefaults: true,
+				MultiError:          true,
+			},
+		}
+		if validateRequests {
+			if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+				respondRequestInvalid(c, err)
+				c.Abort()
+				return
+			}
+		}
+		if !validateResponses {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		err = openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 recorder.Status(),
+			Header:                 recorder.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+			Options: &openapi3filter.Options{
+				IncludeResponseStatus: true,
+				MultiError:            true,
+			},
+		})
+		if err != nil {
+			s.logger.Warn("response does not match openapi document",
+				zap.String("method", c.Request.Method),
+				zap.String("route", route.Path),
+				zap.Int("status", recorder.Status()),
+				zap.Strings("violations", responseViolations(err)),
+			)
+		}
+	}
+}
+
+// respondRequestInvalid answers a request rejected by the OpenAPI document, listing the
+// offending parameters and body fields.
+func respondRequestInvalid(c *gin.Context, err error) {
+	lang := requestLanguage(c)
+	var fields []fieldError
+	malformedBody := false
+	for _, requestErr := range requestErrors(err) {
+		if requestErr.Parameter != nil {
+			fields = append(fields, fieldError{
+				Field:   requestErr.Parameter.Name,
+				Code:    fieldInvalid,
+				Message: localize(lang, codeInvalidParameter, requestErr.Parameter.Name),
+			})
+			continue
+		}
+		schemaErrs := schemaErrors(requestErr.Err)
+		if len(schemaErrs) == 0 {
+			malformedBody = true
+			continue
+		}
+		for _, schemaErr := range schemaErrs {
+			field := strings.Join(schemaErr.JSONPointer(), ".")
+			if schemaErr.SchemaField == "required" {
+				fields = append(fields, fieldError{
+					Field:   field,
+					Code:    fieldRequired,
+					Message: localize(lang, codeFieldRequired, field),
+				})
+				continue
+			}
+			fields = append(fields, fieldError{
+				Field:   field,
+				Code:    fieldInvalid,
+				Message: localize(lang, codeInvalidParameter, field),
+			})
+		}
+	}
+	if malformedBody && len(fields) == 0 {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	resp := newErrorResponse(c, codeRequestInvalid)
+	resp.Error.Fields = fields
+	c.JSON(http.StatusBadRequest, resp)
+}
+
+// responseViolations summarizes a failed response validation as one line per mismatched
+// field, without the schema dumps of the full error.
+func responseViolations(err error) []string {
+	var responseErr *openapi3filter.ResponseError
+	if !errors.As(err, &responseErr) {
+		return []string{err.Error()}
+	}
+	schemaErrs := schemaErrors(responseErr.Err)
+	if len(schemaErrs) == 0 {
+		return []string{responseErr.Error()}
+	}
+	violations := make([]string, 0, len(schemaErrs))
+	for _, schemaErr := range schemaErrs {
+		violations = append(violations, "/"+strings.Join(schemaErr.JSONPointer(), "/")+": "+schemaErr.Reason)
+	}
+	return violations
+}
+
+func requestErrors(err error) []*openapi3filter.RequestError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var result []*openapi3filter.RequestError
+		for _, item := range e {
+			result = append(result, requestErrors(item)...)
+		}
+		return result
+	case *openapi3filter.RequestError:
+		return []*openapi3filter.RequestError{e}
+	}
+	return nil
+}
+
+func schemaErrors(err error) []*openapi3.SchemaError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var result []*openapi3.SchemaError
+		for _, item := range e {
+			result = append(result, schemaErrors(item)...)
+		}
+		return result
+	case *openapi3.SchemaError:
+		return []*openapi3.SchemaError{e}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/orgchart"
+)
+
+// handleExportOrgChart renders the tenant's groups, managers and members as a file.
+// Query parameters: format (csv, xlsx, graphml or dot), root to export a subtree and
+// include_roles to add role assignments.
+func (s *Server) handleExportOrgChart(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, ctx, true)
+	if !ok {
+		return
+	}
+
+	formatName := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "csv")))
+	format, ok := orgchart.Formats[formatName]
+	if !ok {
+		respondError(c, http.StatusBadRequest, codeOrgChartFormatInvalid)
+		return
+	}
+
+	var rootID *uuid.UUID
+	if raw := strings.TrimSpace(c.Query("root")); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			respondInvalidParam(c, "root_group_id")
+			return
+		}
+		rootID = &parsed
+	}
+
+	includeRoles := false
+	if raw := strings.TrimSpace(c.Query("include_roles")); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondInvalidParam(c, "include_roles")
+			return
+		}
+		includeRoles = parsed
+	}
+
+	requestCtx := c.Request.Context()
+	groups, err := s.groupRepo.ListGroups(requestCtx, &tenantID)
+	if err != nil {
+		s.logger.Error("list groups failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	members, err := s.groupRepo.ListTenantMembers(requestCtx, tenantID)
+	if err != nil {
+		s.logger.Error("list tenant members failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	managers := make(map[uuid.UUID][]string, len(groups))
+	for _, group := range groups {
+		subjects, err := s.ketoClient.ListGroupManagers(requestCtx, tenantID.String(), group.ID.String())
+		if err != nil {
+			s.logger.Error("list group managers failed", zapError(err))
+			respondInternalError(c)
+			return
+		}
+		managers[group.ID] = subjects
+	}
+
+	input := orgchart.Input{
+		TenantID: tenantID,
+		Groups:   groups,
+		Members:  members,
+		Managers: managers,
+		RootID:   rootID,
+	}
+	if includeRoles {
+		assignments, err := s.roleRepo.ListTenantAssignments(requestCtx, tenantID)
+		if err != nil {
+			s.logger.Error("list role assignments failed", zapError(err))
+			respondInternalError(c)
+			return
+		}
+		input.Roles = make(map[uuid.UUID][]string, len(assignments))
+		for identityID, roles := range assignments {
+			for _, role := range roles {
+				input.Roles[identityID] = append(input.Roles[identityID], role.Code)
+			}
+		}
+	}
+
+	chart, err := orgchart.Build(input)
+	if err != nil {
+		if errors.Is(err, orgchart.ErrRootNotFound) {
+			respondError(c, http.StatusNotFound, codeGroupNotFound)
+			return
+		}
+		s.logger.Error("build org chart failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	// Render into a buffer so a failure can still be reported as a JSON error.
+	var buf bytes.Buffer
+	if err := format.Write(&buf, chart); err != nil {
+		s.logger.Error("render org chart failed", zapError(err), zap.String("format", format.Name))
+		respondInternalError(c)
+		return
+	}
+
+	filename := fmt.Sprintf("orgchart-%s%s", time.Now().UTC().Format("20060102"), format.Extension)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, format.ContentType, buf.Bytes())
+}
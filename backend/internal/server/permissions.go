@@ -21,18 +21,18 @@ func (s *Server) registerPermissionRoutes(group *gin.RouterGroup) {
 func (s *Server) handleListPermissions(c *gin.Context) {
 	identity := middleware.IdentityFromContext(c)
 	if identity == nil || identity.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 	if !isPlatformAdmin(identity) && !hasAnyRole(identity, "tenant_admin", "tenant-admin") {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		respondError(c, http.StatusForbidden, codeForbidden)
 		return
 	}
 
 	perms, err := s.permissionRepo.ListPermissions(c.Request.Context())
 	if err != nil {
 		s.logger.Error("list permissions failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load permissions"})
+		respondInternalError(c)
 		return
 	}
 
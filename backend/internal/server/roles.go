@@ -11,7 +11,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 
 	"github.com/laofa009/next-agent-portal/backend/internal/keto"
@@ -57,6 +56,9 @@ var permissionBindings = map[string][]permissionBinding{
 	"group.manage": {
 		{Scope: "tenant", Object: "api/v1/groups", Relation: "editors"},
 		{Scope: "tenant", Object: "api/v1/groups/:uuid", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/groups/tree", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/groups/:uuid/move", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/groups/:uuid/deletion-preview", Relation: "editors"},
 		{Scope: "tenant", Object: "api/v1/groups/:uuid/members", Relation: "editors"},
 		{Scope: "tenant", Object: "api/v1/groups/:uuid/members/:uuid", Relation: "editors"},
 	},
@@ -64,17 +66,56 @@ var permissionBindings = map[string][]permissionBinding{
 		{Scope: "tenant", Object: "api/v1/groups", Relation: "viewers"},
 		{Scope: "tenant", Object: "api/v1/groups/:uuid", Relation: "viewers"},
 		{Scope: "tenant", Object: "api/v1/groups/:uuid/members", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/groups/:uuid/subtree", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/groups/:uuid/ancestors", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/groups/export", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/members/:uuid/groups", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/directory", Relation: "viewers"},
 	},
 	"group.member.manage": {
 		{Scope: "tenant", Object: "api/v1/groups/:uuid/members", Relation: "editors"},
 		{Scope: "tenant", Object: "api/v1/groups/:uuid/members/:uuid", Relation: "editors"},
 	},
+	"audit.view": {
+		{Scope: "tenant", Object: "api/v1/audit-logs", Relation: "viewers"},
+		{Scope: "global", Object: "api/v1/audit-logs", Relation: "viewers"},
+	},
+	"webhook.manage": {
+		{Scope: "tenant", Object: "api/v1/webhooks", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/webhooks/event-types", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/webhooks/:uuid", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/webhooks/:uuid/rotate-secret", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/webhooks/:uuid/deliveries", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/webhooks/:uuid/deliveries/:uuid", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/webhooks/:uuid/deliveries/:uuid/replay", Relation: "editors"},
+	},
+	"scim.manage": {
+		{Scope: "tenant", Object: "api/v1/scim/tokens", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/scim/tokens/:uuid", Relation: "editors"},
+	},
+	"member.import": {
+		{Scope: "tenant", Object: "api/v1/member-imports", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/member-imports/:uuid", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/member-imports/:uuid/rows", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/member-imports/:uuid/commit", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/member-imports/:uuid/resume", Relation: "editors"},
+	},
+	"impersonation.view": {
+		{Scope: "tenant", Object: "api/v1/impersonations", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/impersonations/:uuid", Relation: "viewers"},
+		{Scope: "tenant", Object: "api/v1/impersonations/:uuid/requests", Relation: "viewers"},
+	},
 	"user.invite": {
 		{Scope: "tenant", Object: "api/v1/users", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/invitations", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/invitations/:uuid", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/invitations/:uuid/resend", Relation: "editors"},
 	},
 	"user.disable": {
 		{Scope: "tenant", Object: "api/v1/users", Relation: "editors"},
 		{Scope: "tenant", Object: "api/v1/users/:uuid", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/users/:uuid/disable", Relation: "editors"},
+		{Scope: "tenant", Object: "api/v1/users/:uuid/enable", Relation: "editors"},
 	},
 	"user.view": {
 		{Scope: "tenant", Object: "api/v1/users", Relation: "viewers"},
@@ -105,6 +146,7 @@ type roleResponse struct {
 	CreatedAt     string         `json:"createdAt"`
 	UpdatedAt     string         `json:"updatedAt"`
 	Version       int32          `json:"version"`
+	ETag          string         `json:"etag"`
 }
 
 type listRolesResponse struct {
@@ -139,18 +181,18 @@ type resolvedBinding struct {
 func (s *Server) handleListRoles(c *gin.Context) {
 	identity := middleware.IdentityFromContext(c)
 	if identity == nil || identity.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	if !isPlatformAdmin(identity) && !hasAnyRole(identity, "tenant_admin", "tenant-admin") {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		respondError(c, http.StatusForbidden, codeForbidden)
 		return
 	}
 
 	scope := strings.TrimSpace(strings.ToLower(c.Query("scope")))
 	if scope != "" && scope != "global" && scope != "tenant" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be global or tenant"})
+		respondError(c, http.StatusBadRequest, codeRoleScopeInvalid)
 		return
 	}
 
@@ -176,41 +218,59 @@ func (s *Server) handleListRoles(c *gin.Context) {
 		Offset: int32((page - 1) * pageSize),
 	}
 
-	if isPlatformAdmin(identity) {
+	if isPlatformAdmin(identity) && identity.Impersonating() {
+		if scope == "global" {
+			respondError(c, http.StatusForbidden, codeGlobalRoleUnavailable)
+			return
+		}
+		tenantUUID := s.adminTenantScope(identity)
+		params.Scope = "tenant"
+		params.TenantID = &tenantUUID
+	} else if isPlatformAdmin(identity) {
 		if scope == "" {
 			params.Scope = ""
 		}
 		if tenantParam := strings.TrimSpace(c.Query("tenant_id")); tenantParam != "" {
 			tenantUUID, err := uuid.Parse(tenantParam)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+				respondInvalidParam(c, "tenant_id")
 				return
 			}
 			params.TenantID = &tenantUUID
 		}
 	} else {
 		if scope != "" && scope != "tenant" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			respondError(c, http.StatusForbidden, codeForbidden)
 			return
 		}
 		if strings.TrimSpace(identity.TenantID) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "tenant context missing"})
+			respondError(c, http.StatusBadRequest, codeTenantContextMissing)
 			return
 		}
 		tenantUUID, err := uuid.Parse(identity.TenantID)
 		if err != nil {
 			s.logger.Error("parse tenant context failed", zapError(err))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant context"})
+			respondError(c, http.StatusBadRequest, codeTenantContextInvalid)
 			return
 		}
 		params.Scope = "tenant"
 		params.TenantID = &tenantUUID
 	}
 
-	roles, total, err := s.roleRepo.ListRoles(c.Request.Context(), params)
+	var (
+		roles  []storage.Role
+		total  int64
+		result storage.KeysetResult
+		err    error
+	)
+	keyset, cursorMode := parseKeysetPage(c, defaultRolePageSize, maxRolePageSize)
+	if cursorMode {
+		roles, result, err = s.roleRepo.ListRolesKeyset(c.Request.Context(), params, keyset)
+	} else {
+		roles, total, err = s.roleRepo.ListRoles(c.Request.Context(), params)
+	}
 	if err != nil {
-		s.logger.Error("list roles failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load roles"})
+		s.respondKeysetError(c, err, "list roles failed")
 		return
 	}
 
@@ -219,12 +279,22 @@ func (s *Server) handleListRoles(c *gin.Context) {
 		item, err := s.buildRoleResponse(c.Request.Context(), role, includePermissions)
 		if err != nil {
 			s.logger.Error("build role response failed", zapError(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build role response"})
+			respondInternalError(c)
 			return
 		}
 		response = append(response, item)
 	}
 
+	if cursorMode {
+		c.JSON(http.StatusOK, cursorPageResponse{
+			Items:      response,
+			NextCursor: result.NextCursor,
+			Total:      result.Total,
+			PageSize:   int(keyset.Limit),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, listRolesResponse{
 		Items:    response,
 		Total:    total,
@@ -236,29 +306,29 @@ func (s *Server) handleListRoles(c *gin.Context) {
 func (s *Server) handleListRoleMembers(c *gin.Context) {
 	identity := middleware.IdentityFromContext(c)
 	if identity == nil || identity.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	roleID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		respondInvalidParam(c, "role_id")
 		return
 	}
 
 	role, err := s.roleRepo.GetRole(c.Request.Context(), roleID)
 	if err != nil {
 		if errors.Is(err, storage.ErrRoleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			respondError(c, http.StatusNotFound, codeRoleNotFound)
 			return
 		}
 		s.logger.Error("get role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load role"})
+		respondInternalError(c)
 		return
 	}
 
 	if !s.canManageRole(identity, &role) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for role"})
+		respondError(c, http.StatusForbidden, codeRoleForbidden)
 		return
 	}
 
@@ -271,10 +341,19 @@ func (s *Server) handleListRoleMembers(c *gin.Context) {
 		page = defaultPage
 	}
 
-	assignments, total, err := s.roleRepo.ListAssignments(c.Request.Context(), roleID, int32(pageSize), int32((page-1)*pageSize))
+	var (
+		assignments []storage.RoleAssignment
+		total       int64
+		result      storage.KeysetResult
+	)
+	keyset, cursorMode := parseKeysetPage(c, defaultRolePageSize, maxRolePageSize)
+	if cursorMode {
+		assignments, result, err = s.roleRepo.ListAssignmentsKeyset(c.Request.Context(), roleID, keyset)
+	} else {
+		assignments, total, err = s.roleRepo.ListAssignments(c.Request.Context(), roleID, int32(pageSize), int32((page-1)*pageSize))
+	}
 	if err != nil {
-		s.logger.Error("list role assignments failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load role members"})
+		s.respondKeysetError(c, err, "list role assignments failed")
 		return
 	}
 
@@ -283,6 +362,16 @@ func (s *Server) handleListRoleMembers(c *gin.Context) {
 		items = append(items, mapRoleAssignment(assignment))
 	}
 
+	if cursorMode {
+		c.JSON(http.StatusOK, cursorPageResponse{
+			Items:      items,
+			NextCursor: result.NextCursor,
+			Total:      result.Total,
+			PageSize:   int(keyset.Limit),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, listRoleMembersResponse{
 		Items:    items,
 		Total:    total,
@@ -331,47 +420,47 @@ type rolePayload struct {
 func (s *Server) handleCreateRole(c *gin.Context) {
 	identity := middleware.IdentityFromContext(c)
 	if identity == nil || identity.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	if !isPlatformAdmin(identity) && !hasAnyRole(identity, "tenant_admin", "tenant-admin") {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		respondError(c, http.StatusForbidden, codeForbidden)
 		return
 	}
 
 	var payload rolePayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
 	scope := strings.ToLower(strings.TrimSpace(payload.Scope))
 	if scope != "global" && scope != "tenant" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be global or tenant"})
+		respondError(c, http.StatusBadRequest, codeRoleScopeInvalid)
 		return
 	}
 
 	code := strings.TrimSpace(payload.Code)
 	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		respondMissingFields(c, "code")
 		return
 	}
 
 	name := strings.TrimSpace(payload.Name)
 	if name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		respondMissingFields(c, "name")
 		return
 	}
 
 	var tenantID *uuid.UUID
 	if scope == "global" {
-		if !isPlatformAdmin(identity) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only platform admin can create global role"})
+		if !isPlatformAdmin(identity) || identity.Impersonating() {
+			respondError(c, http.StatusForbidden, codeGlobalRoleAdminOnly)
 			return
 		}
 		if payload.TenantID != nil && strings.TrimSpace(*payload.TenantID) != "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "global roles must not include tenant_id"})
+			respondError(c, http.StatusBadRequest, codeGlobalRoleTenant)
 			return
 		}
 	} else {
@@ -390,13 +479,13 @@ func (s *Server) handleCreateRole(c *gin.Context) {
 
 	perms, err := s.normalizePermissions(c.Request.Context(), payload.Permissions, scope)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		s.respondPermissionsError(c, err)
 		return
 	}
 
 	metadata, err := encodeMetadata(payload.Metadata)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "metadata must be valid JSON"})
+		respondError(c, http.StatusBadRequest, codeMetadataInvalid)
 		return
 	}
 
@@ -412,71 +501,72 @@ func (s *Server) handleCreateRole(c *gin.Context) {
 
 	created, err := s.roleRepo.CreateRole(c.Request.Context(), role)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "role code already exists"})
+		if isUniqueViolation(err) {
+			respondError(c, http.StatusBadRequest, codeRoleCodeExists)
 			return
 		}
 		s.logger.Error("create role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create role"})
+		respondInternalError(c)
 		return
 	}
 
 	if err := s.syncRolePermissionBindings(c.Request.Context(), created, nil); err != nil {
 		s.logger.Error("sync role permissions failed", zapError(err), zap.String("role", created.Code))
-		if delErr := s.roleRepo.DeleteRole(c.Request.Context(), created.ID); delErr != nil {
+		if delErr := s.roleRepo.DeleteRole(c.Request.Context(), created.ID, nil); delErr != nil {
 			s.logger.Error("rollback role creation failed", zapError(delErr), zap.String("role", created.Code))
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply role permissions"})
+		respondInternalError(c)
 		return
 	}
 
 	resp, err := s.buildRoleResponse(c.Request.Context(), created, true)
 	if err != nil {
 		s.logger.Error("build role response failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build response"})
+		respondInternalError(c)
 		return
 	}
 
+	setETag(c, int64(created.Version))
 	c.JSON(http.StatusCreated, resp)
 }
 
 func (s *Server) handleUpdateRole(c *gin.Context) {
 	identity := middleware.IdentityFromContext(c)
 	if identity == nil || identity.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	if !isPlatformAdmin(identity) && !hasAnyRole(identity, "tenant_admin", "tenant-admin") {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		respondError(c, http.StatusForbidden, codeForbidden)
 		return
 	}
 
 	roleID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		respondInvalidParam(c, "role_id")
 		return
 	}
 
 	existing, err := s.roleRepo.GetRole(c.Request.Context(), roleID)
 	if err != nil {
 		if errors.Is(err, storage.ErrRoleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			respondError(c, http.StatusNotFound, codeRoleNotFound)
 			return
 		}
 		s.logger.Error("get role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load role"})
+		respondInternalError(c)
 		return
 	}
 
 	if !s.canManageRole(identity, &existing) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for role"})
+		respondError(c, http.StatusForbidden, codeRoleForbidden)
 		return
 	}
 
 	var payload rolePayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
@@ -486,7 +576,7 @@ func (s *Server) handleUpdateRole(c *gin.Context) {
 	}
 
 	if scope != existing.Scope {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "role scope cannot be changed"})
+		respondError(c, http.StatusBadRequest, codeRoleScopeImmutable)
 		return
 	}
 
@@ -502,13 +592,18 @@ func (s *Server) handleUpdateRole(c *gin.Context) {
 
 	perms, err := s.normalizePermissions(c.Request.Context(), payload.Permissions, scope)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		s.respondPermissionsError(c, err)
 		return
 	}
 
 	metadata, err := encodeMetadata(payload.Metadata)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "metadata must be valid JSON"})
+		respondError(c, http.StatusBadRequest, codeMetadataInvalid)
+		return
+	}
+
+	expectedVersion, ok := s.checkRoleIfMatch(c, existing)
+	if !ok {
 		return
 	}
 
@@ -523,68 +618,78 @@ func (s *Server) handleUpdateRole(c *gin.Context) {
 		Permissions: perms,
 	}
 
-	updated, err := s.roleRepo.UpdateRole(c.Request.Context(), role, true)
+	updated, err := s.roleRepo.UpdateRole(c.Request.Context(), role, true, expectedVersion)
 	if err != nil {
 		if errors.Is(err, storage.ErrRoleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			respondError(c, http.StatusNotFound, codeRoleNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrRoleVersionConflict) {
+			s.respondRoleConflict(c, roleID)
 			return
 		}
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "role code already exists"})
+		if isUniqueViolation(err) {
+			respondError(c, http.StatusBadRequest, codeRoleCodeExists)
 			return
 		}
 		s.logger.Error("update role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+		respondInternalError(c)
 		return
 	}
 
 	if err := s.syncRolePermissionBindings(c.Request.Context(), updated, existing.Permissions); err != nil {
 		s.logger.Error("sync role permissions failed", zapError(err), zap.String("role", updated.Code))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply role permissions"})
+		respondInternalError(c)
 		return
 	}
 
 	resp, err := s.buildRoleResponse(c.Request.Context(), updated, true)
 	if err != nil {
 		s.logger.Error("build role response failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build response"})
+		respondInternalError(c)
 		return
 	}
 
+	setETag(c, int64(updated.Version))
 	c.JSON(http.StatusOK, resp)
 }
 
 func (s *Server) handleDeleteRole(c *gin.Context) {
 	identity := middleware.IdentityFromContext(c)
 	if identity == nil || identity.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	if !isPlatformAdmin(identity) && !hasAnyRole(identity, "tenant_admin", "tenant-admin") {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		respondError(c, http.StatusForbidden, codeForbidden)
 		return
 	}
 
 	roleID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		respondInvalidParam(c, "role_id")
 		return
 	}
 
 	existing, err := s.roleRepo.GetRole(c.Request.Context(), roleID)
 	if err != nil {
 		if errors.Is(err, storage.ErrRoleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			respondError(c, http.StatusNotFound, codeRoleNotFound)
 			return
 		}
 		s.logger.Error("get role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load role"})
+		respondInternalError(c)
 		return
 	}
 
 	if !s.canManageRole(identity, &existing) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for role"})
+		respondError(c, http.StatusForbidden, codeRoleForbidden)
+		return
+	}
+
+	expectedVersion, ok := s.checkRoleIfMatch(c, existing)
+	if !ok {
 		return
 	}
 
@@ -592,19 +697,28 @@ func (s *Server) handleDeleteRole(c *gin.Context) {
 	cleanupRole.Permissions = nil
 	if err := s.syncRolePermissionBindings(c.Request.Context(), cleanupRole, existing.Permissions); err != nil {
 		s.logger.Error("remove role permission bindings failed", zapError(err), zap.String("role", existing.Code))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove role permissions"})
+		respondInternalError(c)
 		return
 	}
 
 	if err := s.removeRoleMemberships(c.Request.Context(), existing); err != nil {
 		s.logger.Error("remove role memberships failed", zapError(err), zap.String("role", existing.Code))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove role memberships"})
+		respondInternalError(c)
 		return
 	}
 
-	if err := s.roleRepo.DeleteRole(c.Request.Context(), roleID); err != nil {
+	if err := s.roleRepo.DeleteRole(c.Request.Context(), roleID, expectedVersion); err != nil {
+		if errors.Is(err, storage.ErrRoleVersionConflict) {
+			// The role changed after the precondition check, so its Keto tuples were
+			// removed for nothing and are written again from the current role.
+			if err := s.restoreRoleBindings(c.Request.Context(), roleID); err != nil {
+				s.logger.Error("restore role bindings failed", zapError(err), zap.String("role", existing.Code))
+			}
+			s.respondRoleConflict(c, roleID)
+			return
+		}
 		s.logger.Error("delete role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete role"})
+		respondInternalError(c)
 		return
 	}
 
@@ -614,40 +728,40 @@ func (s *Server) handleDeleteRole(c *gin.Context) {
 func (s *Server) handleAddRoleMembers(c *gin.Context) {
 	identity := middleware.IdentityFromContext(c)
 	if identity == nil || identity.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	roleID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		respondInvalidParam(c, "role_id")
 		return
 	}
 
 	role, err := s.roleRepo.GetRole(c.Request.Context(), roleID)
 	if err != nil {
 		if errors.Is(err, storage.ErrRoleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			respondError(c, http.StatusNotFound, codeRoleNotFound)
 			return
 		}
 		s.logger.Error("get role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load role"})
+		respondInternalError(c)
 		return
 	}
 
 	if !s.canManageRole(identity, &role) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for role"})
+		respondError(c, http.StatusForbidden, codeRoleForbidden)
 		return
 	}
 
 	var payload assignRoleMembersPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
 	if len(payload.Identities) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "identities cannot be empty"})
+		respondMissingFields(c, "identities")
 		return
 	}
 
@@ -655,19 +769,19 @@ func (s *Server) handleAddRoleMembers(c *gin.Context) {
 	for _, raw := range payload.Identities {
 		trimmed := strings.TrimSpace(raw)
 		if trimmed == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "identity id cannot be empty"})
+			respondMissingFields(c, "identity_id")
 			return
 		}
 		memberID, parseErr := uuid.Parse(trimmed)
 		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid identity id: %s", raw)})
+			respondError(c, http.StatusBadRequest, codeIdentityIDInvalid, raw)
 			return
 		}
 		identitySet[memberID] = struct{}{}
 	}
 
 	if len(identitySet) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "no valid identity ids provided"})
+		respondError(c, http.StatusBadRequest, codeIdentityIDsInvalid)
 		return
 	}
 
@@ -680,13 +794,13 @@ func (s *Server) handleAddRoleMembers(c *gin.Context) {
 	for memberID := range identitySet {
 		if err := s.roleRepo.UpsertAssignment(c.Request.Context(), role.ID, memberID, role.TenantID); err != nil {
 			s.logger.Error("upsert role assignment failed", zapError(err), zap.String("role", role.Code), zap.String("member", memberID.String()))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+			respondInternalError(c)
 			return
 		}
 		if err := s.ketoClient.AssignRole(c.Request.Context(), tenantStr, role.Code, memberID.String()); err != nil {
 			s.logger.Error("assign role in keto failed", zapError(err), zap.String("role", role.Code), zap.String("member", memberID.String()))
 			_ = s.roleRepo.DeleteAssignment(c.Request.Context(), role.ID, memberID)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+			respondInternalError(c)
 			return
 		}
 		assigned++
@@ -698,41 +812,41 @@ func (s *Server) handleAddRoleMembers(c *gin.Context) {
 func (s *Server) handleDeleteRoleMember(c *gin.Context) {
 	requester := middleware.IdentityFromContext(c)
 	if requester == nil || requester.Subject == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return
 	}
 
 	roleID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		respondInvalidParam(c, "role_id")
 		return
 	}
 
 	memberID, err := uuid.Parse(strings.TrimSpace(c.Param("member")))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid member id"})
+		respondInvalidParam(c, "member_id")
 		return
 	}
 
 	role, err := s.roleRepo.GetRole(c.Request.Context(), roleID)
 	if err != nil {
 		if errors.Is(err, storage.ErrRoleNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			respondError(c, http.StatusNotFound, codeRoleNotFound)
 			return
 		}
 		s.logger.Error("get role failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load role"})
+		respondInternalError(c)
 		return
 	}
 
 	if !s.canManageRole(requester, &role) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions for role"})
+		respondError(c, http.StatusForbidden, codeRoleForbidden)
 		return
 	}
 
 	if err := s.roleRepo.DeleteAssignment(c.Request.Context(), role.ID, memberID); err != nil {
 		s.logger.Error("delete role assignment failed", zapError(err), zap.String("role", role.Code), zap.String("member", memberID.String()))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove role member"})
+		respondInternalError(c)
 		return
 	}
 
@@ -746,7 +860,7 @@ func (s *Server) handleDeleteRoleMember(c *gin.Context) {
 		if rollbackErr := s.roleRepo.UpsertAssignment(c.Request.Context(), role.ID, memberID, role.TenantID); rollbackErr != nil {
 			s.logger.Error("rollback role assignment failed", zapError(rollbackErr), zap.String("role", role.Code), zap.String("member", memberID.String()))
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove role member"})
+		respondInternalError(c)
 		return
 	}
 
@@ -766,6 +880,37 @@ func mapRoleAssignment(assignment storage.RoleAssignment) roleMemberResponse {
 	}
 }
 
+// checkRoleIfMatch evaluates the If-Match precondition of a write against the loaded role.
+// The check runs before any Keto bindings are touched, so a stale write changes nothing.
+func (s *Server) checkRoleIfMatch(c *gin.Context, existing storage.Role) (*int32, bool) {
+	current, err := s.buildRoleResponse(c.Request.Context(), existing, true)
+	if err != nil {
+		s.logger.Error("build role response failed", zapError(err))
+		respondInternalError(c)
+		return nil, false
+	}
+	expected, ok := s.checkIfMatch(c, int64(existing.Version), current)
+	if !ok || expected == nil {
+		return nil, ok
+	}
+	version := int32(*expected)
+	return &version, true
+}
+
+// respondRoleConflict answers a write that lost a race with a concurrent change.
+func (s *Server) respondRoleConflict(c *gin.Context, id uuid.UUID) {
+	role, err := s.roleRepo.GetRole(c.Request.Context(), id)
+	if err == nil {
+		var current roleResponse
+		if current, err = s.buildRoleResponse(c.Request.Context(), role, true); err == nil {
+			respondPreconditionFailed(c, int64(role.Version), current)
+			return
+		}
+	}
+	s.logger.Error("load role failed", zapError(err))
+	respondError(c, http.StatusPreconditionFailed, codePreconditionFailed)
+}
+
 func (s *Server) buildRoleResponse(ctx context.Context, role storage.Role, includePermissions bool) (roleResponse, error) {
 	var tenantStr *string
 	if role.TenantID != nil {
@@ -785,6 +930,7 @@ func (s *Server) buildRoleResponse(ctx context.Context, role storage.Role, inclu
 		CreatedAt:     role.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:     role.UpdatedAt.Format(time.RFC3339),
 		Version:       role.Version,
+		ETag:          versionETag(int64(role.Version)),
 	}
 
 	if includePermissions {
@@ -802,6 +948,28 @@ func (s *Server) buildRoleResponse(ctx context.Context, role storage.Role, inclu
 	return item, nil
 }
 
+// invalidPermissionError rejects a requested permission code that is unknown or does not
+// fit the scope of the role.
+type invalidPermissionError struct {
+	code       string
+	permission string
+}
+
+func (e *invalidPermissionError) Error() string {
+	return e.code + ": " + e.permission
+}
+
+// respondPermissionsError answers a failed normalizePermissions call.
+func (s *Server) respondPermissionsError(c *gin.Context, err error) {
+	var invalid *invalidPermissionError
+	if errors.As(err, &invalid) {
+		respondError(c, http.StatusBadRequest, invalid.code, invalid.permission)
+		return
+	}
+	s.logger.Error("normalize permissions failed", zapError(err))
+	respondInternalError(c)
+}
+
 func (s *Server) normalizePermissions(ctx context.Context, requested []string, scope string) ([]string, error) {
 	permissionList, err := s.permissionRepo.ListPermissions(ctx)
 	if err != nil {
@@ -825,13 +993,13 @@ func (s *Server) normalizePermissions(ctx context.Context, requested []string, s
 		}
 		scopeDef, ok := allowed[trimmed]
 		if !ok {
-			return nil, fmt.Errorf("unknown permission code: %s", code)
+			return nil, &invalidPermissionError{code: codePermissionUnknown, permission: code}
 		}
 		if scope == "global" && scopeDef == "tenant" {
-			return nil, fmt.Errorf("permission %s is not allowed for global roles", code)
+			return nil, &invalidPermissionError{code: codePermissionGlobalOnly, permission: code}
 		}
 		if scope == "tenant" && scopeDef == "global" {
-			return nil, fmt.Errorf("permission %s is not allowed for tenant roles", code)
+			return nil, &invalidPermissionError{code: codePermissionTenantOnly, permission: code}
 		}
 		seen[trimmed] = struct{}{}
 		unique = append(unique, trimmed)
@@ -843,14 +1011,14 @@ func (s *Server) normalizePermissions(ctx context.Context, requested []string, s
 func (s *Server) canManageRole(identity *middleware.IdentityContext, role *storage.Role) bool {
 	if isPlatformAdmin(identity) {
 		if role.Scope == "global" {
-			return true
+			return !identity.Impersonating()
 		}
 
-		// Platform admin is currently limited to platform tenant.
+		// Platform admin is limited to the platform tenant unless acting as a tenant.
 		if role.TenantID == nil {
 			return false
 		}
-		return strings.EqualFold(role.TenantID.String(), s.platformTenantID.String())
+		return *role.TenantID == s.adminTenantScope(identity)
 	}
 
 	if !hasAnyRole(identity, "tenant_admin", "tenant-admin") {
@@ -879,11 +1047,18 @@ func encodeMetadata(metadata map[string]any) (json.RawMessage, error) {
 }
 
 func (s *Server) syncRolePermissionBindings(ctx context.Context, role storage.Role, previous []string) error {
-	newBindings, err := s.buildResolvedBindings(role, role.Permissions)
+	return SyncRolePermissionBindings(ctx, s.ketoClient, s.namespacePrefix, role, previous)
+}
+
+// SyncRolePermissionBindings updates the Keto tuples that grant the members of role the
+// relations its permissions map to, given the permissions the role had before. A nil
+// previous writes every binding of the role.
+func SyncRolePermissionBindings(ctx context.Context, ketoClient *keto.Client, namespacePrefix string, role storage.Role, previous []string) error {
+	newBindings, err := buildResolvedBindings(role, role.Permissions)
 	if err != nil {
 		return err
 	}
-	oldBindings, err := s.buildResolvedBindings(role, previous)
+	oldBindings, err := buildResolvedBindings(role, previous)
 	if err != nil {
 		return err
 	}
@@ -892,7 +1067,7 @@ func (s *Server) syncRolePermissionBindings(ctx context.Context, role storage.Ro
 		return nil
 	}
 
-	namespace := s.bindingNamespace()
+	namespace := bindingNamespace(namespacePrefix)
 	subjectNamespace := namespace
 	scopeID, err := roleScopeIdentifier(role)
 	if err != nil {
@@ -902,16 +1077,15 @@ func (s *Server) syncRolePermissionBindings(ctx context.Context, role storage.Ro
 	subject := keto.SubjectSet{
 		Namespace: subjectNamespace,
 		Object:    subjectObject,
-		Relation:  s.ketoClient.MembershipRelation(),
+		Relation:  ketoClient.MembershipRelation(),
 	}
 
 	for key, binding := range oldBindings {
 		if _, exists := newBindings[key]; exists {
 			continue
 		}
-		if err := s.ketoClient.DeleteSubjectSetRelation(ctx, namespace, binding.Object, binding.Relation, subject); err != nil {
-			s.logger.Error("remove role permission binding failed", zapError(err), zap.String("role", role.Code), zap.String("object", binding.Object), zap.String("relation", binding.Relation))
-			return err
+		if err := ketoClient.DeleteSubjectSetRelation(ctx, namespace, binding.Object, binding.Relation, subject); err != nil {
+			return fmt.Errorf("remove binding %s#%s: %w", binding.Object, binding.Relation, err)
 		}
 	}
 
@@ -919,16 +1093,15 @@ func (s *Server) syncRolePermissionBindings(ctx context.Context, role storage.Ro
 		if _, exists := oldBindings[key]; exists {
 			continue
 		}
-		if err := s.ketoClient.UpsertSubjectSetRelation(ctx, namespace, binding.Object, binding.Relation, subject); err != nil {
-			s.logger.Error("add role permission binding failed", zapError(err), zap.String("role", role.Code), zap.String("object", binding.Object), zap.String("relation", binding.Relation))
-			return err
+		if err := ketoClient.UpsertSubjectSetRelation(ctx, namespace, binding.Object, binding.Relation, subject); err != nil {
+			return fmt.Errorf("add binding %s#%s: %w", binding.Object, binding.Relation, err)
 		}
 	}
 
 	return nil
 }
 
-func (s *Server) buildResolvedBindings(role storage.Role, permissions []string) (map[string]resolvedBinding, error) {
+func buildResolvedBindings(role storage.Role, permissions []string) (map[string]resolvedBinding, error) {
 	result := make(map[string]resolvedBinding)
 	if len(permissions) == 0 {
 		return result, nil
@@ -959,9 +1132,9 @@ func (s *Server) buildResolvedBindings(role storage.Role, permissions []string)
 	return result, nil
 }
 
-func (s *Server) bindingNamespace() string {
-	if s.namespacePrefix != "" {
-		return s.namespacePrefix
+func bindingNamespace(namespacePrefix string) string {
+	if namespacePrefix != "" {
+		return namespacePrefix
 	}
 	return "Tenant"
 }
@@ -980,6 +1153,38 @@ func roleScopeIdentifier(role storage.Role) (string, error) {
 	}
 }
 
+// restoreRoleBindings writes the permission bindings and memberships of a role to Keto
+// again, undoing removeRoleMemberships and the cleanup done before a deletion.
+func (s *Server) restoreRoleBindings(ctx context.Context, roleID uuid.UUID) error {
+	role, err := s.roleRepo.GetRole(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.syncRolePermissionBindings(ctx, role, nil); err != nil {
+		return err
+	}
+	scopeID, err := roleScopeIdentifier(role)
+	if err != nil {
+		return err
+	}
+
+	const pageSize int32 = 100
+	for offset := int32(0); ; offset += pageSize {
+		assignments, total, err := s.roleRepo.ListAssignments(ctx, role.ID, pageSize, offset)
+		if err != nil {
+			return fmt.Errorf("list role assignments: %w", err)
+		}
+		for _, assignment := range assignments {
+			if err := s.ketoClient.AssignRole(ctx, scopeID, role.Code, assignment.IdentityID.String()); err != nil {
+				return err
+			}
+		}
+		if len(assignments) == 0 || int64(offset+pageSize) >= total {
+			return nil
+		}
+	}
+}
+
 func (s *Server) removeRoleMemberships(ctx context.Context, role storage.Role) error {
 	scopeID, err := roleScopeIdentifier(role)
 	if err != nil {
@@ -0,0 +1,620 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/scim"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	scimTenantKey   = "scim.tenant_id"
+	scimUsers       = "Users"
+	scimGroups      = "Groups"
+	scimDefaultPage = 100
+)
+
+// registerScimRoutes mounts the SCIM 2.0 service provider. It lives outside /api because
+// identity providers authenticate with per-tenant bearer tokens instead of portal sessions.
+func (s *Server) registerScimRoutes() {
+	group := s.router.Group("/scim/v2")
+	group.Use(s.requireScimToken())
+
+	group.GET("/ServiceProviderConfig", func(c *gin.Context) {
+		writeScim(c, http.StatusOK, scim.ServiceProviderConfig(scimBaseURL(c)))
+	})
+	group.GET("/ResourceTypes", func(c *gin.Context) {
+		resources := scim.ResourceTypes(scimBaseURL(c))
+		writeScim(c, http.StatusOK, scim.NewListResponse(resources, len(resources), 1))
+	})
+	group.GET("/Schemas", func(c *gin.Context) {
+		resources := scim.Schemas(scimBaseURL(c))
+		writeScim(c, http.StatusOK, scim.NewListResponse(resources, len(resources), 1))
+	})
+
+	group.GET("/Users", s.handleScimListUsers)
+	group.GET("/Groups", s.handleScimListGroups)
+	for _, resourceType := range []string{scimUsers, scimGroups} {
+		group.POST("/"+resourceType, s.scimResourceHandler(resourceType))
+		group.GET("/"+resourceType+"/:id", s.scimResourceHandler(resourceType))
+		group.PUT("/"+resourceType+"/:id", s.scimResourceHandler(resourceType))
+		group.PATCH("/"+resourceType+"/:id", s.scimResourceHandler(resourceType))
+		group.DELETE("/"+resourceType+"/:id", s.scimResourceHandler(resourceType))
+	}
+	group.POST("/Bulk", s.handleScimBulk)
+}
+
+func (s *Server) registerScimTokenRoutes(group *gin.RouterGroup) {
+	group.GET("/scim/tokens", s.handleListScimTokens)
+	group.POST("/scim/tokens", s.handleCreateScimToken)
+	group.DELETE("/scim/tokens/:id", s.handleRevokeScimToken)
+}
+
+// requireScimToken authenticates the bearer token, pins the request to the token's tenant
+// and attributes subsequent audit entries to the token.
+func (s *Server) requireScimToken() gin.HandlerFunc {
+	const prefix = "bearer "
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader("Authorization"))
+		if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+			c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+			writeScim(c, http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", "missing bearer token"))
+			c.Abort()
+			return
+		}
+
+		token, err := s.scimRepo.Authenticate(c.Request.Context(), header[len(prefix):])
+		if err != nil {
+			if errors.Is(err, storage.ErrScimTokenNotFound) {
+				c.Header("WWW-Authenticate", `Bearer realm="scim", error="invalid_token"`)
+				writeScim(c, http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", "invalid bearer token"))
+				c.Abort()
+				return
+			}
+			s.logger.Error("authenticate scim token failed", zapError(err))
+			writeScim(c, http.StatusInternalServerError, scim.NewError(http.StatusInternalServerError, "", "authentication failed"))
+			c.Abort()
+			return
+		}
+
+		c.Set(scimTenantKey, token.TenantID)
+		actor := storage.Actor{
+			Subject:   "scim:" + token.ID.String(),
+			RequestID: middleware.RequestIDFromContext(c),
+		}
+		c.Request = c.Request.WithContext(storage.ContextWithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// scimResourceHandler adapts dispatchScim to a gin route so single requests and bulk
+// operations share one implementation.
+func (s *Server) scimResourceHandler(resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodDelete {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(c.Request.Body, scim.MaxBulkPayloadSize+1))
+			if err != nil {
+				writeScim(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, "failed to read request body"))
+				return
+			}
+			if len(body) > scim.MaxBulkPayloadSize {
+				writeScim(c, http.StatusRequestEntityTooLarge, scim.NewError(http.StatusRequestEntityTooLarge, "", "payload exceeds "+strconv.Itoa(scim.MaxBulkPayloadSize)+" bytes"))
+				return
+			}
+		}
+
+		baseURL := scimBaseURL(c)
+		status, resource, err := s.dispatchScim(c.Request.Context(), scimTenant(c), baseURL, c.Request.Method, resourceType, c.Param("id"), body)
+		if err != nil {
+			s.writeScimError(c, err)
+			return
+		}
+		if status == http.StatusNoContent {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		if status == http.StatusCreated {
+			if location := scimLocation(resource); location != "" {
+				c.Header("Location", location)
+			}
+		}
+		if c.Request.Method == http.MethodGet {
+			resource = applyExcludedAttributes(resource, c.Query("excludedAttributes"))
+		}
+		writeScim(c, status, resource)
+	}
+}
+
+func (s *Server) dispatchScim(ctx context.Context, tenantID uuid.UUID, baseURL, method, resourceType, id string, body []byte) (int, any, error) {
+	if method != http.MethodPost && strings.TrimSpace(id) == "" {
+		return 0, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidPath, "resource id is required")
+	}
+
+	switch resourceType {
+	case scimUsers:
+		switch method {
+		case http.MethodGet:
+			return s.scimGetUser(ctx, tenantID, baseURL, id)
+		case http.MethodPost:
+			return s.scimCreateUser(ctx, tenantID, baseURL, body)
+		case http.MethodPut:
+			return s.scimReplaceUser(ctx, tenantID, baseURL, id, body)
+		case http.MethodPatch:
+			return s.scimPatchUser(ctx, tenantID, baseURL, id, body)
+		case http.MethodDelete:
+			return s.scimDeleteUser(ctx, tenantID, id)
+		}
+	case scimGroups:
+		switch method {
+		case http.MethodGet:
+			return s.scimGetGroup(ctx, tenantID, baseURL, id)
+		case http.MethodPost:
+			return s.scimCreateGroup(ctx, tenantID, baseURL, body)
+		case http.MethodPut:
+			return s.scimReplaceGroup(ctx, tenantID, baseURL, id, body)
+		case http.MethodPatch:
+			return s.scimPatchGroup(ctx, tenantID, baseURL, id, body)
+		case http.MethodDelete:
+			return s.scimDeleteGroup(ctx, tenantID, id)
+		}
+	default:
+		return 0, nil, scim.NewError(http.StatusNotFound, "", "unknown resource type "+resourceType)
+	}
+	return 0, nil, scim.NewError(http.StatusMethodNotAllowed, "", "method not supported")
+}
+
+func (s *Server) handleScimBulk(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, scim.MaxBulkPayloadSize+1))
+	if err != nil {
+		writeScim(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, "failed to read request body"))
+		return
+	}
+	if len(body) > scim.MaxBulkPayloadSize {
+		writeScim(c, http.StatusRequestEntityTooLarge, scim.NewError(http.StatusRequestEntityTooLarge, "", "bulk payload exceeds "+strconv.Itoa(scim.MaxBulkPayloadSize)+" bytes"))
+		return
+	}
+
+	var request scim.BulkRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeScim(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, "invalid bulk request"))
+		return
+	}
+	if len(request.Operations) > scim.MaxBulkOperations {
+		writeScim(c, http.StatusRequestEntityTooLarge, scim.NewError(http.StatusRequestEntityTooLarge, "", "bulk request exceeds "+strconv.Itoa(scim.MaxBulkOperations)+" operations"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tenantID := scimTenant(c)
+	baseURL := scimBaseURL(c)
+
+	// Operations run in order, so a bulkId may reference any resource created earlier
+	// in the same request.
+	created := make(map[string]string)
+	results := make([]scim.BulkResult, 0, len(request.Operations))
+	failures := 0
+
+	for _, operation := range request.Operations {
+		if request.FailOnErrors > 0 && failures >= request.FailOnErrors {
+			break
+		}
+
+		method := strings.ToUpper(strings.TrimSpace(operation.Method))
+		result := scim.BulkResult{Method: method, BulkID: operation.BulkID}
+
+		status, resource, err := s.runScimBulkOperation(ctx, tenantID, baseURL, method, operation, created)
+		if err != nil {
+			var scimErr *scim.Error
+			if !errors.As(err, &scimErr) {
+				s.logger.Error("scim bulk operation failed", zap.String("method", method), zap.String("path", operation.Path), zapError(err))
+				scimErr = scim.NewError(http.StatusInternalServerError, "", "internal error")
+			}
+			result.Status = strconv.Itoa(scimErr.Status)
+			result.Response = scimErr
+			failures++
+			results = append(results, result)
+			continue
+		}
+
+		result.Status = strconv.Itoa(status)
+		result.Location = scimLocation(resource)
+		if method == http.MethodPost && operation.BulkID != "" {
+			created[operation.BulkID] = scimResourceID(resource)
+		}
+		results = append(results, result)
+	}
+
+	writeScim(c, http.StatusOK, scim.BulkResponse{
+		Schemas:    []string{scim.SchemaBulkResponse},
+		Operations: results,
+	})
+}
+
+func (s *Server) runScimBulkOperation(ctx context.Context, tenantID uuid.UUID, baseURL, method string, operation scim.BulkOperation, created map[string]string) (int, any, error) {
+	if method == http.MethodPost && strings.TrimSpace(operation.BulkID) == "" {
+		return 0, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "bulkId is required for POST operations")
+	}
+
+	path := resolveBulkReferences(operation.Path, created)
+	data := resolveBulkReferences(string(operation.Data), created)
+	if strings.Contains(path, "bulkId:") || strings.Contains(data, "bulkId:") {
+		return 0, nil, scim.NewError(http.StatusConflict, scim.ErrInvalidValue, "unresolved bulkId reference")
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 0 || len(segments) > 2 {
+		return 0, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidPath, "invalid operation path "+operation.Path)
+	}
+	resourceType := segments[0]
+	id := ""
+	if len(segments) == 2 {
+		id = segments[1]
+	}
+	if (method == http.MethodPost) != (id == "") {
+		return 0, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidPath, "invalid operation path "+operation.Path)
+	}
+
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return 0, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, "unsupported bulk method "+operation.Method)
+	}
+	return s.dispatchScim(ctx, tenantID, baseURL, method, resourceType, id, []byte(data))
+}
+
+func resolveBulkReferences(value string, created map[string]string) string {
+	for bulkID, id := range created {
+		value = strings.ReplaceAll(value, "bulkId:"+bulkID, id)
+	}
+	return value
+}
+
+func (s *Server) writeScimError(c *gin.Context, err error) {
+	var scimErr *scim.Error
+	if errors.As(err, &scimErr) {
+		writeScim(c, scimErr.Status, scimErr)
+		return
+	}
+	s.logger.Error("scim request failed", zap.String("path", c.Request.URL.Path), zapError(err))
+	writeScim(c, http.StatusInternalServerError, scim.NewError(http.StatusInternalServerError, "", "internal error"))
+}
+
+func writeScim(c *gin.Context, status int, body any) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, scim.ContentType, payload)
+}
+
+func scimTenant(c *gin.Context) uuid.UUID {
+	tenantID, _ := c.Get(scimTenantKey)
+	id, _ := tenantID.(uuid.UUID)
+	return id
+}
+
+// scimBaseURL reconstructs the externally visible SCIM root from forwarded headers so
+// meta.location points through the gateway rather than at the backend container.
+func scimBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := strings.TrimSpace(c.GetHeader("X-Forwarded-Proto")); forwarded != "" {
+		scheme = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host := c.Request.Host
+	if forwarded := strings.TrimSpace(c.GetHeader("X-Forwarded-Host")); forwarded != "" {
+		host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return scheme + "://" + host + "/scim/v2"
+}
+
+func scimLocation(resource any) string {
+	switch r := resource.(type) {
+	case scim.User:
+		if r.Meta != nil {
+			return r.Meta.Location
+		}
+	case scim.Group:
+		if r.Meta != nil {
+			return r.Meta.Location
+		}
+	}
+	return ""
+}
+
+func scimResourceID(resource any) string {
+	switch r := resource.(type) {
+	case scim.User:
+		return r.ID
+	case scim.Group:
+		return r.ID
+	}
+	return ""
+}
+
+func applyExcludedAttributes(resource any, excluded string) any {
+	group, ok := resource.(scim.Group)
+	if !ok {
+		return resource
+	}
+	for _, attribute := range strings.Split(excluded, ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			group.Members = nil
+		}
+	}
+	return group
+}
+
+// filterScimResources evaluates the filter against the JSON form of each resource and
+// returns the requested 1-based page together with the total match count.
+func filterScimResources[T any](c *gin.Context, resources []T) ([]any, int, int, bool) {
+	var filter scim.Filter
+	if expression := strings.TrimSpace(c.Query("filter")); expression != "" {
+		parsed, err := scim.ParseFilter(expression)
+		if err != nil {
+			var scimErr *scim.Error
+			if !errors.As(err, &scimErr) {
+				scimErr = scim.NewError(http.StatusBadRequest, scim.ErrInvalidFilter, err.Error())
+			}
+			writeScim(c, scimErr.Status, scimErr)
+			return nil, 0, 0, false
+		}
+		filter = parsed
+	}
+
+	matched := make([]any, 0, len(resources))
+	for _, resource := range resources {
+		if filter != nil {
+			document, err := scimDocument(resource)
+			if err != nil || !filter.Match(document) {
+				continue
+			}
+		}
+		matched = append(matched, resource)
+	}
+
+	startIndex := parsePositiveInt(c.Query("startIndex"), 1)
+	count := scimDefaultPage
+	if raw := strings.TrimSpace(c.Query("count")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 0 {
+			count = parsed
+		}
+	}
+	if count > scim.MaxResults {
+		count = scim.MaxResults
+	}
+
+	total := len(matched)
+	from := startIndex - 1
+	if from > total {
+		from = total
+	}
+	to := from + count
+	if to > total {
+		to = total
+	}
+	return matched[from:to], total, startIndex, true
+}
+
+func scimDocument(resource any) (map[string]any, error) {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]any
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// patchScimResource applies a PATCH body to the current representation and returns the
+// patched document encoded as JSON, ready to be processed like a PUT.
+func patchScimResource(current any, body []byte) ([]byte, error) {
+	var request scim.PatchRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, "invalid patch request")
+	}
+	document, err := scimDocument(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := scim.ApplyPatch(document, request.Operations); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// scimDirectory is a tenant-wide snapshot of groups and memberships used to render
+// the groups of a user and the members of a group.
+type scimDirectory struct {
+	groups      map[uuid.UUID]storage.Group
+	externalIDs map[uuid.UUID]string
+	byGroup     map[uuid.UUID][]storage.GroupMember
+	byIdentity  map[uuid.UUID][]storage.GroupMember
+}
+
+func (s *Server) loadScimDirectory(ctx context.Context, tenantID uuid.UUID) (*scimDirectory, error) {
+	groups, err := s.groupRepo.ListGroups(ctx, &tenantID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.groupRepo.ListTenantMembers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	externalIDs, err := s.scimRepo.GroupExternalIDs(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := &scimDirectory{
+		groups:      make(map[uuid.UUID]storage.Group, len(groups)),
+		externalIDs: externalIDs,
+		byGroup:     make(map[uuid.UUID][]storage.GroupMember),
+		byIdentity:  make(map[uuid.UUID][]storage.GroupMember),
+	}
+	for _, group := range groups {
+		dir.groups[group.ID] = group
+	}
+	for _, member := range members {
+		dir.byGroup[member.GroupID] = append(dir.byGroup[member.GroupID], member)
+		dir.byIdentity[member.IdentityID] = append(dir.byIdentity[member.IdentityID], member)
+	}
+	return dir, nil
+}
+
+func parseScimID(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(strings.TrimSpace(id))
+	if err != nil {
+		return uuid.Nil, scim.NewError(http.StatusNotFound, "", "resource "+id+" not found")
+	}
+	return parsed, nil
+}
+
+type scimTokenResponse struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	Name        string    `json:"name"`
+	TokenPrefix string    `json:"token_prefix"`
+	Token       string    `json:"token,omitempty"`
+	CreatedBy   *string   `json:"created_by,omitempty"`
+	LastUsedAt  *string   `json:"last_used_at,omitempty"`
+	RevokedAt   *string   `json:"revoked_at,omitempty"`
+	CreatedAt   string    `json:"created_at"`
+}
+
+type createScimTokenPayload struct {
+	TenantID string `json:"tenant_id"`
+	Name     string `json:"name"`
+}
+
+func (s *Server) handleListScimTokens(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, middleware.IdentityFromContext(c), true)
+	if !ok {
+		return
+	}
+
+	tokens, err := s.scimRepo.ListTokens(c.Request.Context(), tenantID)
+	if err != nil {
+		s.logger.Error("list scim tokens failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]scimTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		items = append(items, mapScimToken(token))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (s *Server) handleCreateScimToken(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+	identity := middleware.IdentityFromContext(c)
+
+	var payload createScimTokenPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	name := strings.TrimSpace(payload.Name)
+	if name == "" {
+		respondMissingFields(c, "name")
+		return
+	}
+
+	tenantID, ok := s.resolveTenantIDForPayload(c, identity, payload.TenantID)
+	if !ok {
+		return
+	}
+
+	var createdBy *string
+	if subject := strings.TrimSpace(identity.Subject); subject != "" {
+		createdBy = &subject
+	}
+
+	token, plaintext, err := s.scimRepo.CreateToken(c.Request.Context(), tenantID, name, createdBy)
+	if err != nil {
+		s.logger.Error("create scim token failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	response := mapScimToken(token)
+	response.Token = plaintext
+	c.JSON(http.StatusCreated, response)
+}
+
+func (s *Server) handleRevokeScimToken(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	tokenID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "token_id")
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, middleware.IdentityFromContext(c), true)
+	if !ok {
+		return
+	}
+
+	if err := s.scimRepo.RevokeToken(c.Request.Context(), tenantID, tokenID); err != nil {
+		if errors.Is(err, storage.ErrScimTokenNotFound) {
+			respondError(c, http.StatusNotFound, codeScimTokenNotFound)
+			return
+		}
+		s.logger.Error("revoke scim token failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func mapScimToken(token storage.ScimToken) scimTokenResponse {
+	response := scimTokenResponse{
+		ID:          token.ID,
+		TenantID:    token.TenantID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		CreatedBy:   token.CreatedBy,
+		CreatedAt:   token.CreatedAt.Format(time.RFC3339),
+	}
+	if token.LastUsedAt != nil {
+		value := token.LastUsedAt.Format(time.RFC3339)
+		response.LastUsedAt = &value
+	}
+	if token.RevokedAt != nil {
+		value := token.RevokedAt.Format(time.RFC3339)
+		response.RevokedAt = &value
+	}
+	return response
+}
@@ -0,0 +1,348 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/scim"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+// scimGroupCodePrefix marks groups created by an identity provider. Group codes must be
+// unique per tenant, so the suffix is derived from the generated group ID.
+const scimGroupCodePrefix = "scim-"
+
+func (s *Server) handleScimListGroups(c *gin.Context) {
+	ctx := c.Request.Context()
+	dir, err := s.loadScimDirectory(ctx, scimTenant(c))
+	if err != nil {
+		s.writeScimError(c, err)
+		return
+	}
+
+	groups, err := s.groupRepo.ListGroups(ctx, ptrUUID(scimTenant(c)))
+	if err != nil {
+		s.writeScimError(c, err)
+		return
+	}
+
+	baseURL := scimBaseURL(c)
+	resources := make([]scim.Group, 0, len(groups))
+	for _, group := range groups {
+		resources = append(resources, scimGroupResource(group, dir, baseURL))
+	}
+
+	page, total, startIndex, ok := filterScimResources(c, resources)
+	if !ok {
+		return
+	}
+	for i := range page {
+		page[i] = applyExcludedAttributes(page[i], c.Query("excludedAttributes"))
+	}
+	writeScim(c, http.StatusOK, scim.NewListResponse(page, total, startIndex))
+}
+
+func (s *Server) scimGetGroup(ctx context.Context, tenantID uuid.UUID, baseURL, id string) (int, any, error) {
+	group, err := s.loadScimGroup(ctx, tenantID, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	dir, err := s.loadScimDirectory(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, scimGroupResource(group, dir, baseURL), nil
+}
+
+func (s *Server) scimCreateGroup(ctx context.Context, tenantID uuid.UUID, baseURL string, body []byte) (int, any, error) {
+	resource, members, err := parseScimGroup(body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	dir, err := s.loadScimDirectory(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := ensureScimGroupUnique(dir, uuid.Nil, resource.ExternalID); err != nil {
+		return 0, nil, err
+	}
+
+	groupID := uuid.New()
+	group, err := s.groupRepo.CreateGroup(ctx, storage.Group{
+		ID:       groupID,
+		TenantID: tenantID,
+		Code:     scimGroupCodePrefix + strings.ReplaceAll(groupID.String(), "-", "")[:12],
+		Name:     resource.DisplayName,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.scimRepo.SetGroupExternalID(ctx, tenantID, group.ID, resource.ExternalID); err != nil {
+		return 0, nil, err
+	}
+	if err := s.syncScimGroupMembers(ctx, group, dir, members); err != nil {
+		return 0, nil, err
+	}
+
+	status, created, err := s.scimGetGroup(ctx, tenantID, baseURL, group.ID.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	if status == http.StatusOK {
+		status = http.StatusCreated
+	}
+	return status, created, nil
+}
+
+func (s *Server) scimReplaceGroup(ctx context.Context, tenantID uuid.UUID, baseURL, id string, body []byte) (int, any, error) {
+	group, err := s.loadScimGroup(ctx, tenantID, id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resource, members, err := parseScimGroup(body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	dir, err := s.loadScimDirectory(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := ensureScimGroupUnique(dir, group.ID, resource.ExternalID); err != nil {
+		return 0, nil, err
+	}
+
+	if group.Name != resource.DisplayName {
+		group.Name = resource.DisplayName
+		group.Metadata = nil
+		if group, err = s.groupRepo.UpdateGroup(ctx, group, nil); err != nil {
+			return 0, nil, err
+		}
+	}
+	if dir.externalIDs[group.ID] != resource.ExternalID {
+		if err := s.scimRepo.SetGroupExternalID(ctx, tenantID, group.ID, resource.ExternalID); err != nil {
+			return 0, nil, err
+		}
+	}
+	if err := s.syncScimGroupMembers(ctx, group, dir, members); err != nil {
+		return 0, nil, err
+	}
+
+	return s.scimGetGroup(ctx, tenantID, baseURL, group.ID.String())
+}
+
+func (s *Server) scimPatchGroup(ctx context.Context, tenantID uuid.UUID, baseURL, id string, body []byte) (int, any, error) {
+	_, current, err := s.scimGetGroup(ctx, tenantID, baseURL, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	patched, err := patchScimResource(current, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return s.scimReplaceGroup(ctx, tenantID, baseURL, id, patched)
+}
+
+func (s *Server) scimDeleteGroup(ctx context.Context, tenantID uuid.UUID, id string) (int, any, error) {
+	group, err := s.loadScimGroup(ctx, tenantID, id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	members, err := s.groupRepo.ListTenantMembers(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, member := range members {
+		if member.GroupID != group.ID {
+			continue
+		}
+		if err := s.removeScimMember(ctx, group.ID, member.IdentityID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if _, err := s.groupRepo.DeleteGroup(ctx, group.ID, storage.DeleteGroupOptions{}); err != nil {
+		if errors.Is(err, storage.ErrGroupHasChildren) {
+			return 0, nil, scim.NewError(http.StatusConflict, "", "group still has child groups")
+		}
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Server) loadScimGroup(ctx context.Context, tenantID uuid.UUID, id string) (storage.Group, error) {
+	groupID, err := parseScimID(id)
+	if err != nil {
+		return storage.Group{}, err
+	}
+	group, err := s.groupRepo.GetGroup(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			return storage.Group{}, scim.NewError(http.StatusNotFound, "", "group "+id+" not found")
+		}
+		return storage.Group{}, err
+	}
+	if group.TenantID != tenantID {
+		return storage.Group{}, scim.NewError(http.StatusNotFound, "", "group "+id+" not found")
+	}
+	return group, nil
+}
+
+// syncScimGroupMembers adds and removes memberships so the group contains exactly desired,
+// keeping the Keto membership tuples in step with the database.
+func (s *Server) syncScimGroupMembers(ctx context.Context, group storage.Group, dir *scimDirectory, desired []uuid.UUID) error {
+	current := make(map[uuid.UUID]struct{}, len(dir.byGroup[group.ID]))
+	for _, member := range dir.byGroup[group.ID] {
+		current[member.IdentityID] = struct{}{}
+	}
+	wanted := make(map[uuid.UUID]struct{}, len(desired))
+	for _, identityID := range desired {
+		wanted[identityID] = struct{}{}
+	}
+
+	for _, identityID := range desired {
+		if _, ok := current[identityID]; ok {
+			continue
+		}
+		if err := s.addScimMember(ctx, group, dir, identityID); err != nil {
+			return err
+		}
+	}
+	for identityID := range current {
+		if _, ok := wanted[identityID]; ok {
+			continue
+		}
+		if err := s.removeScimMember(ctx, group.ID, identityID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) addScimMember(ctx context.Context, group storage.Group, dir *scimDirectory, identityID uuid.UUID) error {
+	member := storage.GroupMember{
+		GroupID:    group.ID,
+		IdentityID: identityID,
+		TenantID:   group.TenantID,
+	}
+
+	// Prefer the provisioned profile, then an existing membership elsewhere in the tenant,
+	// and finally the Kratos traits for identities the portal created itself.
+	user, err := s.scimRepo.GetUser(ctx, group.TenantID, identityID)
+	switch {
+	case err == nil:
+		member.DisplayName = user.DisplayName
+		member.Phone = user.Phone
+		member.Title = user.Title
+	case !errors.Is(err, storage.ErrScimUserNotFound):
+		return err
+	case len(dir.byIdentity[identityID]) > 0:
+		existing := dir.byIdentity[identityID][0]
+		member.DisplayName = existing.DisplayName
+		member.Phone = existing.Phone
+		member.Title = existing.Title
+	default:
+		identity, err := s.kratosClient.GetIdentity(ctx, identityID.String())
+		if err != nil {
+			return err
+		}
+		if identity == nil || identity.TraitString("tenant_id") != group.TenantID.String() {
+			return scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "member "+identityID.String()+" does not exist")
+		}
+		member.DisplayName = identity.TraitString("nickname")
+		member.Phone = identity.TraitString("phone")
+	}
+
+	if _, err := s.groupRepo.CreateMember(ctx, member); err != nil {
+		return err
+	}
+	s.syncGroupMember(ctx, identityID, group.ID)
+	return nil
+}
+
+func (s *Server) removeScimMember(ctx context.Context, groupID, identityID uuid.UUID) error {
+	if err := s.groupRepo.DeleteMember(ctx, groupID, identityID); err != nil {
+		return err
+	}
+	s.syncGroupMember(ctx, identityID, groupID)
+	return nil
+}
+
+func ensureScimGroupUnique(dir *scimDirectory, self uuid.UUID, externalID string) error {
+	if externalID == "" {
+		return nil
+	}
+	for groupID, existing := range dir.externalIDs {
+		if groupID != self && existing == externalID {
+			return scim.NewError(http.StatusConflict, scim.ErrUniqueness, "externalId "+externalID+" already exists")
+		}
+	}
+	return nil
+}
+
+func parseScimGroup(body []byte) (scim.Group, []uuid.UUID, error) {
+	var resource scim.Group
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return scim.Group{}, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, "invalid group resource")
+	}
+
+	resource.DisplayName = strings.TrimSpace(resource.DisplayName)
+	resource.ExternalID = strings.TrimSpace(resource.ExternalID)
+	if resource.DisplayName == "" {
+		return scim.Group{}, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "displayName is required")
+	}
+
+	members := make([]uuid.UUID, 0, len(resource.Members))
+	seen := make(map[uuid.UUID]struct{}, len(resource.Members))
+	for _, member := range resource.Members {
+		identityID, err := uuid.Parse(strings.TrimSpace(member.Value))
+		if err != nil {
+			return scim.Group{}, nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "invalid member value "+member.Value)
+		}
+		if _, dup := seen[identityID]; dup {
+			continue
+		}
+		seen[identityID] = struct{}{}
+		members = append(members, identityID)
+	}
+	return resource, members, nil
+}
+
+func scimGroupResource(group storage.Group, dir *scimDirectory, baseURL string) scim.Group {
+	id := group.ID.String()
+	resource := scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          id,
+		ExternalID:  dir.externalIDs[group.ID],
+		DisplayName: group.Name,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+			Location:     baseURL + "/Groups/" + id,
+		},
+	}
+	for _, member := range dir.byGroup[group.ID] {
+		identityID := member.IdentityID.String()
+		resource.Members = append(resource.Members, scim.Reference{
+			Value:   identityID,
+			Ref:     baseURL + "/Users/" + identityID,
+			Display: member.DisplayName,
+		})
+	}
+	return resource
+}
+
+func ptrUUID(id uuid.UUID) *uuid.UUID {
+	return &id
+}
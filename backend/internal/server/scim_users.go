@@ -0,0 +1,374 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/scim"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+// maxNicknameLength mirrors the nickname trait limit in the Kratos identity schema.
+const maxNicknameLength = 32
+
+func (s *Server) handleScimListUsers(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := scimTenant(c)
+
+	users, err := s.scimRepo.ListUsers(ctx, tenantID)
+	if err != nil {
+		s.writeScimError(c, err)
+		return
+	}
+	dir, err := s.loadScimDirectory(ctx, tenantID)
+	if err != nil {
+		s.writeScimError(c, err)
+		return
+	}
+
+	baseURL := scimBaseURL(c)
+	resources := make([]scim.User, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, scimUserResource(user, dir, baseURL))
+	}
+
+	page, total, startIndex, ok := filterScimResources(c, resources)
+	if !ok {
+		return
+	}
+	writeScim(c, http.StatusOK, scim.NewListResponse(page, total, startIndex))
+}
+
+func (s *Server) scimGetUser(ctx context.Context, tenantID uuid.UUID, baseURL, id string) (int, any, error) {
+	user, err := s.loadScimUser(ctx, tenantID, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	dir, err := s.loadScimDirectory(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, scimUserResource(user, dir, baseURL), nil
+}
+
+func (s *Server) scimCreateUser(ctx context.Context, tenantID uuid.UUID, baseURL string, body []byte) (int, any, error) {
+	record, err := parseScimUser(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	record.TenantID = tenantID
+
+	if err := s.ensureScimUserUnique(ctx, record); err != nil {
+		return 0, nil, err
+	}
+
+	identity, err := s.kratosClient.FindIdentityByIdentifier(ctx, record.Phone)
+	if err != nil {
+		return 0, nil, err
+	}
+	if identity != nil {
+		// Adopt identities that already belong to this tenant, e.g. members created in the
+		// portal before the IdP took over, but never reach into another tenant.
+		if identity.TraitString("tenant_id") != tenantID.String() {
+			return 0, nil, scim.NewError(http.StatusConflict, scim.ErrUniqueness, "phone number is registered to another account")
+		}
+		identityID, err := uuid.Parse(identity.ID)
+		if err != nil {
+			return 0, nil, err
+		}
+		if _, err := s.scimRepo.GetUser(ctx, tenantID, identityID); err == nil {
+			return 0, nil, scim.NewError(http.StatusConflict, scim.ErrUniqueness, "user already provisioned")
+		} else if !errors.Is(err, storage.ErrScimUserNotFound) {
+			return 0, nil, err
+		}
+	} else {
+		identity, err = s.kratosClient.CreateIdentity(ctx, kratos.CreateIdentityInput{
+			Phone:    record.Phone,
+			Nickname: record.DisplayName,
+			UserType: "internal",
+			TenantID: tenantID.String(),
+			Roles:    []string{},
+		})
+		if err != nil {
+			if kratos.IsConflict(err) {
+				return 0, nil, scim.NewError(http.StatusConflict, scim.ErrUniqueness, err.Error())
+			}
+			return 0, nil, err
+		}
+	}
+
+	record.IdentityID, err = uuid.Parse(identity.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.syncScimIdentity(ctx, identity, record); err != nil {
+		return 0, nil, err
+	}
+
+	saved, err := s.scimRepo.SaveUser(ctx, record)
+	if err != nil {
+		if errors.Is(err, storage.ErrScimUserConflict) {
+			return 0, nil, scim.NewError(http.StatusConflict, scim.ErrUniqueness, "userName or externalId already exists")
+		}
+		return 0, nil, err
+	}
+
+	dir, err := s.loadScimDirectory(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, scimUserResource(saved, dir, baseURL), nil
+}
+
+func (s *Server) scimReplaceUser(ctx context.Context, tenantID uuid.UUID, baseURL, id string, body []byte) (int, any, error) {
+	existing, err := s.loadScimUser(ctx, tenantID, id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	record, err := parseScimUser(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	record.TenantID = tenantID
+	record.IdentityID = existing.IdentityID
+
+	if err := s.ensureScimUserUnique(ctx, record); err != nil {
+		return 0, nil, err
+	}
+
+	if record.Phone != existing.Phone {
+		other, err := s.kratosClient.FindIdentityByIdentifier(ctx, record.Phone)
+		if err != nil {
+			return 0, nil, err
+		}
+		if other != nil && other.ID != existing.IdentityID.String() {
+			return 0, nil, scim.NewError(http.StatusConflict, scim.ErrUniqueness, "phone number is registered to another account")
+		}
+	}
+
+	identity, err := s.kratosClient.GetIdentity(ctx, existing.IdentityID.String())
+	if err != nil {
+		return 0, nil, err
+	}
+	if identity == nil {
+		return 0, nil, scim.NewError(http.StatusNotFound, "", "identity of user "+id+" no longer exists")
+	}
+	if err := s.syncScimIdentity(ctx, identity, record); err != nil {
+		return 0, nil, err
+	}
+
+	saved, err := s.scimRepo.SaveUser(ctx, record)
+	if err != nil {
+		if errors.Is(err, storage.ErrScimUserConflict) {
+			return 0, nil, scim.NewError(http.StatusConflict, scim.ErrUniqueness, "userName or externalId already exists")
+		}
+		return 0, nil, err
+	}
+
+	dir, err := s.loadScimDirectory(ctx, tenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, scimUserResource(saved, dir, baseURL), nil
+}
+
+func (s *Server) scimPatchUser(ctx context.Context, tenantID uuid.UUID, baseURL, id string, body []byte) (int, any, error) {
+	_, current, err := s.scimGetUser(ctx, tenantID, baseURL, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	patched, err := patchScimResource(current, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return s.scimReplaceUser(ctx, tenantID, baseURL, id, patched)
+}
+
+func (s *Server) scimDeleteUser(ctx context.Context, tenantID uuid.UUID, id string) (int, any, error) {
+	user, err := s.loadScimUser(ctx, tenantID, id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	memberships, err := s.groupRepo.ListGroupsForIdentity(ctx, tenantID, user.IdentityID)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, membership := range memberships {
+		if err := s.removeScimMember(ctx, membership.GroupID, user.IdentityID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := s.scimRepo.DeleteUser(ctx, tenantID, user.IdentityID); err != nil {
+		return 0, nil, err
+	}
+	if err := s.kratosClient.DeleteIdentity(ctx, user.IdentityID.String()); err != nil {
+		s.logger.Warn("delete kratos identity failed", zap.String("identity", user.IdentityID.String()), zapError(err))
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Server) loadScimUser(ctx context.Context, tenantID uuid.UUID, id string) (storage.ScimUser, error) {
+	identityID, err := parseScimID(id)
+	if err != nil {
+		return storage.ScimUser{}, err
+	}
+	user, err := s.scimRepo.GetUser(ctx, tenantID, identityID)
+	if err != nil {
+		if errors.Is(err, storage.ErrScimUserNotFound) {
+			return storage.ScimUser{}, scim.NewError(http.StatusNotFound, "", "user "+id+" not found")
+		}
+		return storage.ScimUser{}, err
+	}
+	return user, nil
+}
+
+// ensureScimUserUnique rejects a userName or externalId already used by another user of the
+// tenant. userName comparisons are case-insensitive as required by the core schema.
+func (s *Server) ensureScimUserUnique(ctx context.Context, record storage.ScimUser) error {
+	users, err := s.scimRepo.ListUsers(ctx, record.TenantID)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.IdentityID == record.IdentityID {
+			continue
+		}
+		if strings.EqualFold(user.UserName, record.UserName) {
+			return scim.NewError(http.StatusConflict, scim.ErrUniqueness, "userName "+record.UserName+" already exists")
+		}
+		if record.ExternalID != nil && user.ExternalID != nil && *user.ExternalID == *record.ExternalID {
+			return scim.NewError(http.StatusConflict, scim.ErrUniqueness, "externalId "+*record.ExternalID+" already exists")
+		}
+	}
+	return nil
+}
+
+// syncScimIdentity copies the provisioned profile and active flag onto the Kratos identity.
+func (s *Server) syncScimIdentity(ctx context.Context, identity *kratos.Identity, record storage.ScimUser) error {
+	state := kratos.StateActive
+	if !record.Active {
+		state = kratos.StateInactive
+	}
+	if identity.TraitString("phone") == record.Phone &&
+		identity.TraitString("nickname") == record.DisplayName &&
+		(identity.State == state || (identity.State == "" && state == kratos.StateActive)) {
+		return nil
+	}
+
+	traits := make(map[string]any, len(identity.Traits)+2)
+	for key, value := range identity.Traits {
+		traits[key] = value
+	}
+	traits["phone"] = record.Phone
+	traits["nickname"] = record.DisplayName
+
+	updated := *identity
+	updated.Traits = traits
+	updated.State = state
+	if _, err := s.kratosClient.UpdateIdentity(ctx, updated); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseScimUser(body []byte) (storage.ScimUser, error) {
+	var resource scim.User
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return storage.ScimUser{}, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, "invalid user resource")
+	}
+
+	userName := strings.TrimSpace(resource.UserName)
+	if userName == "" {
+		return storage.ScimUser{}, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "userName is required")
+	}
+
+	phone := resource.PrimaryPhone()
+	if phone == "" && looksLikePhone(userName) {
+		phone = userName
+	}
+	if phone == "" {
+		return storage.ScimUser{}, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "a phone number is required in phoneNumbers or userName")
+	}
+
+	displayName := []rune(resource.ResolvedDisplayName())
+	if len(displayName) > maxNicknameLength {
+		displayName = displayName[:maxNicknameLength]
+	}
+
+	record := storage.ScimUser{
+		UserName:    userName,
+		DisplayName: string(displayName),
+		Phone:       kratos.NormalizePhone(phone),
+		Active:      resource.Active == nil || bool(*resource.Active),
+	}
+	if externalID := strings.TrimSpace(resource.ExternalID); externalID != "" {
+		record.ExternalID = &externalID
+	}
+	if title := strings.TrimSpace(resource.Title); title != "" {
+		record.Title = &title
+	}
+	return record, nil
+}
+
+func looksLikePhone(value string) bool {
+	digits := 0
+	for i, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-':
+		default:
+			return false
+		}
+	}
+	return digits >= 6
+}
+
+func scimUserResource(user storage.ScimUser, dir *scimDirectory, baseURL string) scim.User {
+	id := user.IdentityID.String()
+	resource := scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          id,
+		UserName:    user.UserName,
+		DisplayName: user.DisplayName,
+		Name:        &scim.Name{Formatted: user.DisplayName},
+		Active:      scim.BoolPtr(user.Active),
+		PhoneNumbers: []scim.MultiValue{
+			{Value: user.Phone, Type: "mobile", Primary: true},
+		},
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     baseURL + "/Users/" + id,
+		},
+	}
+	if user.ExternalID != nil {
+		resource.ExternalID = *user.ExternalID
+	}
+	if user.Title != nil {
+		resource.Title = *user.Title
+	}
+	for _, membership := range dir.byIdentity[user.IdentityID] {
+		groupID := membership.GroupID.String()
+		resource.Groups = append(resource.Groups, scim.Reference{
+			Value:   groupID,
+			Ref:     baseURL + "/Groups/" + groupID,
+			Display: dir.groups[membership.GroupID].Name,
+		})
+	}
+	return resource
+}
@@ -1,48 +1,107 @@
 package server
 
 import (
+	"context"
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
 	"regexp"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 
 	"github.com/laofa009/next-agent-portal/backend/internal/config"
+	"github.com/laofa009/next-agent-portal/backend/internal/importer"
 	"github.com/laofa009/next-agent-portal/backend/internal/keto"
+	"github.com/laofa009/next-agent-portal/backend/internal/ketosync"
 	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
 	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/openapi"
+	"github.com/laofa009/next-agent-portal/backend/internal/sms"
 	"github.com/laofa009/next-agent-portal/backend/internal/storage"
 )
 
 // Server bundles the HTTP router and supporting services.
 type Server struct {
-	router           *gin.Engine
-	cfg              *config.Config
-	logger           *zap.Logger
-	ketoClient       *keto.Client
-	kratosClient     *kratos.Client
-	tenantRepo       *storage.TenantRepository
-	groupRepo        *storage.GroupRepository
-	roleRepo         *storage.RoleRepository
-	permissionRepo   *storage.PermissionRepository
-	platformTenantID uuid.UUID
-	namespacePrefix  string
-	webhookUser      string
-	webhookPass      string
+	router            *gin.Engine
+	cfg               *config.Config
+	logger            *zap.Logger
+	ketoClient        *keto.Client
+	ketoSyncer        *ketosync.Syncer
+	kratosClient      *kratos.Client
+	tenantRepo        *storage.TenantRepository
+	groupRepo         *storage.GroupRepository
+	roleRepo          *storage.RoleRepository
+	permissionRepo    *storage.PermissionRepository
+	impersonationRepo *storage.ImpersonationRepository
+	auditRepo         *storage.AuditRepository
+	webhookRepo       *storage.WebhookRepository
+	scimRepo          *storage.ScimRepository
+	importRepo        *storage.MemberImportRepository
+	importer          *importer.Importer
+	userRepo          *storage.UserRepository
+	invitationRepo    *storage.InvitationRepository
+	idempotencyRepo   *storage.IdempotencyRepository
+	smsSender         *sms.Sender
+	migrator          *storage.Migrator
+	pool              *pgxpool.Pool
+	apiSpec           *openapi3.T
+	apiRouter         routers.Router
+	platformTenantID  uuid.UUID
+	namespacePrefix   string
+	webhookUser       string
+	webhookPass       string
+	// draining is set once shutdown starts so the readiness probe fails while
+	// in-flight requests finish.
+	draining atomic.Bool
+}
+
+const (
+	defaultShutdownTimeout   = 20 * time.Second
+	defaultReadHeaderTimeout = 10 * time.Second
+)
+
+// Deps holds the clients, repositories and services the server depends on.
+type Deps struct {
+	Keto           *keto.Client
+	KetoSync       *ketosync.Syncer
+	Kratos         *kratos.Client
+	Tenants        *storage.TenantRepository
+	Groups         *storage.GroupRepository
+	Roles          *storage.RoleRepository
+	Permissions    *storage.PermissionRepository
+	Impersonations *storage.ImpersonationRepository
+	Audit          *storage.AuditRepository
+	Webhooks       *storage.WebhookRepository
+	Scim           *storage.ScimRepository
+	MemberImports  *storage.MemberImportRepository
+	Importer       *importer.Importer
+	Users          *storage.UserRepository
+	Invitations    *storage.InvitationRepository
+	Idempotency    *storage.IdempotencyRepository
+	SMS            *sms.Sender
+	Migrator       *storage.Migrator
+	Pool           *pgxpool.Pool
 }
 
 // New constructs the HTTP server with middleware and routes.
-func New(cfg *config.Config, logger *zap.Logger, ketoClient *keto.Client, kratosClient *kratos.Client, tenantRepo *storage.TenantRepository, groupRepo *storage.GroupRepository, roleRepo *storage.RoleRepository, permissionRepo *storage.PermissionRepository) *Server {
+func New(cfg *config.Config, logger *zap.Logger, deps Deps) *Server {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.RequestLogger(logger))
 	router.Use(middleware.WithIdentity(
 		cfg.Oathkeeper.IdentityHeader,
@@ -56,20 +115,44 @@ func New(cfg *config.Config, logger *zap.Logger, ketoClient *keto.Client, kratos
 		logger.Fatal("invalid platform tenant id", zap.Error(err), zap.String("tenant_id", cfg.Platform.TenantID))
 	}
 
+	apiSpec, err := openapi.Load(context.Background())
+	if err != nil {
+		logger.Fatal("load openapi document failed", zap.Error(err))
+	}
+	apiRouter, err := gorillamux.NewRouter(apiSpec)
+	if err != nil {
+		logger.Fatal("build openapi router failed", zap.Error(err))
+	}
+
 	s := &Server{
-		router:           router,
-		cfg:              cfg,
-		logger:           logger,
-		ketoClient:       ketoClient,
-		kratosClient:     kratosClient,
-		tenantRepo:       tenantRepo,
-		groupRepo:        groupRepo,
-		roleRepo:         roleRepo,
-		permissionRepo:   permissionRepo,
-		platformTenantID: platformTenantID,
-		namespacePrefix:  cfg.Keto.NamespacePrefix,
-		webhookUser:      cfg.Kratos.Webhook.Username,
-		webhookPass:      cfg.Kratos.Webhook.Password,
+		router:            router,
+		cfg:               cfg,
+		logger:            logger,
+		ketoClient:        deps.Keto,
+		ketoSyncer:        deps.KetoSync,
+		kratosClient:      deps.Kratos,
+		tenantRepo:        deps.Tenants,
+		groupRepo:         deps.Groups,
+		roleRepo:          deps.Roles,
+		permissionRepo:    deps.Permissions,
+		impersonationRepo: deps.Impersonations,
+		auditRepo:         deps.Audit,
+		webhookRepo:       deps.Webhooks,
+		scimRepo:          deps.Scim,
+		importRepo:        deps.MemberImports,
+		importer:          deps.Importer,
+		userRepo:          deps.Users,
+		invitationRepo:    deps.Invitations,
+		idempotencyRepo:   deps.Idempotency,
+		smsSender:         deps.SMS,
+		migrator:          deps.Migrator,
+		pool:              deps.Pool,
+		apiSpec:           apiSpec,
+		apiRouter:         apiRouter,
+		platformTenantID:  platformTenantID,
+		namespacePrefix:   cfg.Keto.NamespacePrefix,
+		webhookUser:       cfg.Kratos.Webhook.Username,
+		webhookPass:       cfg.Kratos.Webhook.Password,
 	}
 
 	s.registerRoutes()
@@ -77,29 +160,88 @@ func New(cfg *config.Config, logger *zap.Logger, ketoClient *keto.Client, kratos
 	return s
 }
 
-// Run starts listening for HTTP requests.
-func (s *Server) Run() error {
-	s.logger.Info("starting http server", zap.String("address", s.cfg.Server.Address))
-	return s.router.Run(s.cfg.Server.Address)
+// Handler returns the handler serving every route, for mounting the server on a listener
+// other than the one Run opens.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Run serves HTTP requests until ctx is cancelled, then stops accepting connections
+// and waits up to the configured shutdown timeout for in-flight requests to finish.
+func (s *Server) Run(ctx context.Context) error {
+	readHeaderTimeout := s.cfg.Server.ReadHeaderTimeout
+	if readHeaderTimeout <= 0 {
+		readHeaderTimeout = defaultReadHeaderTimeout
+	}
+	httpServer := &http.Server{
+		Addr:              s.cfg.Server.Address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       s.cfg.Server.ReadTimeout,
+		WriteTimeout:      s.cfg.Server.WriteTimeout,
+		IdleTimeout:       s.cfg.Server.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("starting http server", zap.String("address", httpServer.Addr))
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("serve http: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.draining.Store(true)
+	timeout := s.cfg.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	s.logger.Info("shutting down http server", zap.Duration("timeout", timeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown http server: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve http: %w", err)
+	}
+	s.logger.Info("http server stopped")
+	return nil
 }
 
 func (s *Server) registerRoutes() {
 	api := s.router.Group("/api")
 
-	api.GET("/healthz", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	api.GET("/livez", s.handleLive)
+	api.GET("/readyz", s.handleReady)
+	api.GET("/healthz", s.handleReady)
+	s.registerOpenAPIRoutes(api)
 
 	v1 := api.Group("/v1")
+	v1.Use(s.withImpersonation())
+	v1.Use(s.withAuditActor())
+	v1.Use(s.withContractValidation())
+	v1.Use(s.withIdempotency())
 
 	v1.GET("/me", func(c *gin.Context) {
 		ctx := middleware.IdentityFromContext(c)
-		c.JSON(http.StatusOK, gin.H{
+		response := gin.H{
 			"subject":   ctx.Subject,
 			"user_type": ctx.UserType,
 			"tenant_id": ctx.TenantID,
 			"roles":     ctx.Roles,
-		})
+		}
+		if ctx.Impersonating() {
+			response["impersonation"] = gin.H{
+				"session_id": ctx.ImpersonationID,
+				"tenant_id":  ctx.ActingTenantID,
+			}
+		}
+		c.JSON(http.StatusOK, response)
 	})
 
 	v1.POST("/authorize", func(c *gin.Context) {
@@ -150,7 +292,7 @@ func (s *Server) registerRoutes() {
 		ok, err := s.ketoClient.Check(c.Request.Context(), namespace, object, payload.Action, identity.Subject)
 		if err != nil {
 			s.logger.Error("keto check failed", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "authorization service unavailable"})
+			respondError(c, http.StatusInternalServerError, codeAuthorizationUnavailable)
 			return
 		}
 
@@ -166,6 +308,16 @@ func (s *Server) registerRoutes() {
 	s.registerGroupRoutes(v1)
 	s.registerRoleRoutes(v1)
 	s.registerPermissionRoutes(v1)
+	s.registerImpersonationRoutes(v1)
+	s.registerAuditRoutes(v1)
+	s.registerWebhookRoutes(v1)
+	s.registerScimTokenRoutes(v1)
+	s.registerMemberImportRoutes(v1)
+	s.registerUserRoutes(v1)
+	s.registerInvitationRoutes(v1)
+	s.registerDirectoryRoutes(v1)
+
+	s.registerScimRoutes()
 }
 
 func resolveNamespaceAndObject(prefix, tenantID, overrideNamespace, object string) (string, string) {
@@ -309,12 +461,12 @@ func (s *Server) handleRegistrationHook(c *gin.Context) {
 	var payload registrationHookPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		s.logger.Warn("invalid registration payload", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
 	if payload.Identity.ID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing identity id"})
+		respondMissingFields(c, "identity_id")
 		return
 	}
 
@@ -335,7 +487,7 @@ func (s *Server) handleRegistrationHook(c *gin.Context) {
 				zap.String("role", role),
 				zap.Error(err),
 			)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+			respondInternalError(c)
 			return
 		}
 	}
@@ -11,7 +11,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 
 	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
@@ -44,6 +43,8 @@ type tenantResponse struct {
 	Metadata     map[string]any `json:"metadata"`
 	CreatedAt    time.Time      `json:"createdAt"`
 	UpdatedAt    time.Time      `json:"updatedAt"`
+	Version      int64          `json:"version"`
+	ETag         string         `json:"etag"`
 }
 
 type listTenantsResponse struct {
@@ -79,6 +80,21 @@ func (s *Server) handleListTenants(c *gin.Context) {
 		statusParam = ""
 	}
 
+	if keyset, ok := parseKeysetPage(c, defaultPageSize, maxPageSize); ok {
+		items, result, err := s.tenantRepo.ListTenantsKeyset(c.Request.Context(), search, statusParam, keyset)
+		if err != nil {
+			s.respondKeysetError(c, err, "list tenants failed")
+			return
+		}
+		c.JSON(http.StatusOK, cursorPageResponse{
+			Items:      mapTenants(items),
+			NextCursor: result.NextCursor,
+			Total:      result.Total,
+			PageSize:   int(keyset.Limit),
+		})
+		return
+	}
+
 	items, total, err := s.tenantRepo.ListTenants(
 		c.Request.Context(),
 		search,
@@ -88,7 +104,7 @@ func (s *Server) handleListTenants(c *gin.Context) {
 	)
 	if err != nil {
 		s.logger.Error("list tenants failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tenants"})
+		respondInternalError(c)
 		return
 	}
 
@@ -107,19 +123,19 @@ func (s *Server) handleCreateTenant(c *gin.Context) {
 
 	var payload tenantPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
 	code := strings.TrimSpace(payload.Code)
 	if !tenantCodePattern.MatchString(code) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "tenant code must be 1-10 alphanumeric characters"})
+		respondError(c, http.StatusBadRequest, codeTenantCodeInvalid)
 		return
 	}
 
 	name := strings.TrimSpace(payload.Name)
 	if name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "tenant name is required"})
+		respondMissingFields(c, "name")
 		return
 	}
 
@@ -134,7 +150,7 @@ func (s *Server) handleCreateTenant(c *gin.Context) {
 	if len(payload.Metadata) > 0 {
 		raw, err := json.Marshal(payload.Metadata)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "metadata must be valid JSON"})
+			respondError(c, http.StatusBadRequest, codeMetadataInvalid)
 			return
 		}
 		tenant.Metadata = raw
@@ -142,15 +158,16 @@ func (s *Server) handleCreateTenant(c *gin.Context) {
 
 	created, err := s.tenantRepo.CreateTenant(c.Request.Context(), tenant)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "tenant code already exists"})
+		if isUniqueViolation(err) {
+			respondError(c, http.StatusBadRequest, codeTenantCodeExists)
 			return
 		}
 		s.logger.Error("create tenant failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tenant"})
+		respondInternalError(c)
 		return
 	}
 
+	setETag(c, created.Version)
 	c.JSON(http.StatusCreated, mapTenant(created))
 }
 
@@ -161,21 +178,22 @@ func (s *Server) handleGetTenant(c *gin.Context) {
 
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant id"})
+		respondInvalidParam(c, "tenant_id")
 		return
 	}
 
 	tenant, err := s.tenantRepo.GetTenant(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, storage.ErrTenantNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			respondError(c, http.StatusNotFound, codeTenantNotFound)
 			return
 		}
 		s.logger.Error("get tenant failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tenant"})
+		respondInternalError(c)
 		return
 	}
 
+	setETag(c, tenant.Version)
 	c.JSON(http.StatusOK, mapTenant(tenant))
 }
 
@@ -186,25 +204,30 @@ func (s *Server) handleUpdateTenant(c *gin.Context) {
 
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant id"})
+		respondInvalidParam(c, "tenant_id")
 		return
 	}
 
 	var payload tenantPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
 		return
 	}
 
 	code := strings.TrimSpace(payload.Code)
 	if !tenantCodePattern.MatchString(code) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "tenant code must be 1-10 alphanumeric characters"})
+		respondError(c, http.StatusBadRequest, codeTenantCodeInvalid)
 		return
 	}
 
 	name := strings.TrimSpace(payload.Name)
 	if name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "tenant name is required"})
+		respondMissingFields(c, "name")
+		return
+	}
+
+	expectedVersion, ok := s.checkTenantIfMatch(c, id)
+	if !ok {
 		return
 	}
 
@@ -220,27 +243,32 @@ func (s *Server) handleUpdateTenant(c *gin.Context) {
 	if len(payload.Metadata) > 0 {
 		raw, err := json.Marshal(payload.Metadata)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "metadata must be valid JSON"})
+			respondError(c, http.StatusBadRequest, codeMetadataInvalid)
 			return
 		}
 		tenant.Metadata = raw
 	}
 
-	updated, err := s.tenantRepo.UpdateTenant(c.Request.Context(), tenant)
+	updated, err := s.tenantRepo.UpdateTenant(c.Request.Context(), tenant, expectedVersion)
 	if err != nil {
 		if errors.Is(err, storage.ErrTenantNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			respondError(c, http.StatusNotFound, codeTenantNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrTenantVersionConflict) {
+			s.respondTenantConflict(c, id)
 			return
 		}
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "tenant code already exists"})
+		if isUniqueViolation(err) {
+			respondError(c, http.StatusBadRequest, codeTenantCodeExists)
 			return
 		}
 		s.logger.Error("update tenant failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tenant"})
+		respondInternalError(c)
 		return
 	}
 
+	setETag(c, updated.Version)
 	c.JSON(http.StatusOK, mapTenant(updated))
 }
 
@@ -251,27 +279,63 @@ func (s *Server) handleDeleteTenant(c *gin.Context) {
 
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant id"})
+		respondInvalidParam(c, "tenant_id")
 		return
 	}
 
-	if err := s.tenantRepo.DeleteTenant(c.Request.Context(), id); err != nil {
+	expectedVersion, ok := s.checkTenantIfMatch(c, id)
+	if !ok {
+		return
+	}
+
+	if err := s.tenantRepo.DeleteTenant(c.Request.Context(), id, expectedVersion); err != nil {
 		if errors.Is(err, storage.ErrTenantNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			respondError(c, http.StatusNotFound, codeTenantNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrTenantVersionConflict) {
+			s.respondTenantConflict(c, id)
 			return
 		}
 		s.logger.Error("delete tenant failed", zapError(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tenant"})
+		respondInternalError(c)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// checkTenantIfMatch loads the tenant addressed by a write and evaluates the If-Match
+// precondition against it.
+func (s *Server) checkTenantIfMatch(c *gin.Context, id uuid.UUID) (*int64, bool) {
+	tenant, err := s.tenantRepo.GetTenant(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrTenantNotFound) {
+			respondError(c, http.StatusNotFound, codeTenantNotFound)
+			return nil, false
+		}
+		s.logger.Error("get tenant failed", zapError(err))
+		respondInternalError(c)
+		return nil, false
+	}
+	return s.checkIfMatch(c, tenant.Version, mapTenant(tenant))
+}
+
+// respondTenantConflict answers a write that lost a race with a concurrent change.
+func (s *Server) respondTenantConflict(c *gin.Context, id uuid.UUID) {
+	tenant, err := s.tenantRepo.GetTenant(c.Request.Context(), id)
+	if err != nil {
+		s.logger.Error("get tenant failed", zapError(err))
+		respondError(c, http.StatusPreconditionFailed, codePreconditionFailed)
+		return
+	}
+	respondPreconditionFailed(c, tenant.Version, mapTenant(tenant))
+}
+
 func (s *Server) requireAdmin(c *gin.Context) bool {
 	ctx := middleware.IdentityFromContext(c)
 	if ctx == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondError(c, http.StatusUnauthorized, codeUnauthorized)
 		return false
 	}
 	for _, role := range ctx.Roles {
@@ -280,7 +344,7 @@ func (s *Server) requireAdmin(c *gin.Context) bool {
 			return true
 		}
 	}
-	c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	respondError(c, http.StatusForbidden, codeForbidden)
 	return false
 }
 
@@ -295,6 +359,44 @@ func parsePositiveInt(value string, fallback int) int {
 	return v
 }
 
+// cursorPageResponse is returned by list endpoints in cursor mode. Total is only present
+// when the request asked for it with include_total=true.
+type cursorPageResponse struct {
+	Items      any    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+	PageSize   int    `json:"page_size"`
+}
+
+// parseKeysetPage reports whether a list request uses cursor pagination, which is selected
+// by the presence of the cursor parameter (empty for the first page), and builds the page
+// from cursor, page_size and include_total.
+func parseKeysetPage(c *gin.Context, defaultSize, maxSize int) (storage.KeysetPage, bool) {
+	cursor, ok := c.GetQuery("cursor")
+	if !ok {
+		return storage.KeysetPage{}, false
+	}
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultSize)
+	if pageSize > maxSize {
+		pageSize = maxSize
+	}
+	return storage.KeysetPage{
+		Cursor:    strings.TrimSpace(cursor),
+		Limit:     int32(pageSize),
+		WithTotal: strings.EqualFold(strings.TrimSpace(c.Query("include_total")), "true"),
+	}, true
+}
+
+// respondKeysetError writes the response for a failed cursor mode listing.
+func (s *Server) respondKeysetError(c *gin.Context, err error, logMessage string) {
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		respondInvalidParam(c, "cursor")
+		return
+	}
+	s.logger.Error(logMessage, zapError(err))
+	respondInternalError(c)
+}
+
 func normalizeStatus(status string) string {
 	switch strings.ToLower(strings.TrimSpace(status)) {
 	case "inactive":
@@ -332,6 +434,8 @@ func mapTenant(t storage.Tenant) tenantResponse {
 		Metadata:     metadata,
 		CreatedAt:    t.CreatedAt,
 		UpdatedAt:    t.UpdatedAt,
+		Version:      t.Version,
+		ETag:         versionETag(t.Version),
 	}
 }
 
@@ -0,0 +1,481 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/kratos"
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+// kratosPageSize is the page size used when scanning Kratos for tenant identities.
+const kratosPageSize = 250
+
+func (s *Server) registerUserRoutes(group *gin.RouterGroup) {
+	group.GET("/users", s.handleListUsers)
+	group.GET("/users/:id", s.handleGetUser)
+	group.PATCH("/users/:id", s.handleUpdateUser)
+	group.POST("/users/:id/disable", s.handleDisableUser)
+	group.POST("/users/:id/enable", s.handleEnableUser)
+}
+
+type userGroupResponse struct {
+	GroupID   uuid.UUID `json:"group_id"`
+	GroupCode string    `json:"group_code"`
+	GroupName string    `json:"group_name"`
+	Title     *string   `json:"title,omitempty"`
+	IsPrimary bool      `json:"is_primary"`
+}
+
+type userRoleResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Code string    `json:"code"`
+	Name string    `json:"name"`
+}
+
+type userResponse struct {
+	ID        string              `json:"id"`
+	TenantID  string              `json:"tenant_id"`
+	Phone     string              `json:"phone"`
+	Nickname  string              `json:"nickname"`
+	Username  string              `json:"username,omitempty"`
+	UserType  string              `json:"user_type"`
+	State     string              `json:"state"`
+	Groups    []userGroupResponse `json:"groups"`
+	Roles     []userRoleResponse  `json:"roles"`
+	CreatedAt string              `json:"created_at,omitempty"`
+	UpdatedAt string              `json:"updated_at,omitempty"`
+}
+
+type listUsersResponse struct {
+	Items    []userResponse `json:"items"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+type updateUserPayload struct {
+	Nickname *string `json:"nickname"`
+	Phone    *string `json:"phone"`
+	Username *string `json:"username"`
+}
+
+// userDirectory joins identities with the memberships and roles stored by the portal.
+type userDirectory struct {
+	groups      map[uuid.UUID]storage.Group
+	memberships map[uuid.UUID][]storage.GroupMember
+	roles       map[uuid.UUID][]storage.IdentityRole
+}
+
+func (s *Server) handleListUsers(c *gin.Context) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, ctx, true)
+	if !ok {
+		return
+	}
+
+	state := strings.ToLower(strings.TrimSpace(c.Query("state")))
+	switch state {
+	case "", kratos.StateActive, kratos.StateInactive:
+	default:
+		respondError(c, http.StatusBadRequest, codeUserStateInvalid)
+		return
+	}
+	search := strings.ToLower(strings.TrimSpace(c.Query("search")))
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	requestCtx := c.Request.Context()
+	identities, err := s.listTenantIdentities(requestCtx, tenantID)
+	if err != nil {
+		s.logger.Error("list kratos identities failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	filtered := identities[:0]
+	for _, identity := range identities {
+		if state != "" && identityState(&identity) != state {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(identity.TraitString("nickname")), search) &&
+			!strings.Contains(identity.TraitString("phone"), search) &&
+			!strings.Contains(strings.ToLower(identity.TraitString("username")), search) {
+			continue
+		}
+		filtered = append(filtered, identity)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].TraitString("nickname") < filtered[j].TraitString("nickname")
+	})
+
+	total := len(filtered)
+	start := (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+
+	dir, err := s.loadUserDirectory(requestCtx, tenantID)
+	if err != nil {
+		s.logger.Error("load user directory failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]userResponse, 0, end-start)
+	for i := start; i < end; i++ {
+		items = append(items, mapUser(&filtered[i], dir))
+	}
+	c.JSON(http.StatusOK, listUsersResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+func (s *Server) handleGetUser(c *gin.Context) {
+	identity, tenantID, ok := s.loadTenantUser(c)
+	if !ok {
+		return
+	}
+
+	dir, err := s.loadUserDirectory(c.Request.Context(), tenantID)
+	if err != nil {
+		s.logger.Error("load user directory failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mapUser(identity, dir))
+}
+
+func (s *Server) handleUpdateUser(c *gin.Context) {
+	identity, tenantID, ok := s.loadTenantUser(c)
+	if !ok {
+		return
+	}
+
+	var payload updateUserPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	before := userSnapshot(identity)
+	traits := make(map[string]any, len(identity.Traits)+3)
+	for key, value := range identity.Traits {
+		traits[key] = value
+	}
+
+	if payload.Nickname != nil {
+		nickname := strings.TrimSpace(*payload.Nickname)
+		if nickname == "" || utf8.RuneCountInString(nickname) > maxNicknameLength {
+			respondError(c, http.StatusBadRequest, codeDisplayNameLength, maxNicknameLength)
+			return
+		}
+		traits["nickname"] = nickname
+	}
+
+	if payload.Username != nil {
+		username := strings.TrimSpace(*payload.Username)
+		if username == "" {
+			delete(traits, "username")
+		} else {
+			traits["username"] = username
+		}
+	}
+
+	requestCtx := c.Request.Context()
+	if payload.Phone != nil {
+		phone := kratos.NormalizePhone(*payload.Phone)
+		if phone == "" {
+			respondMissingFields(c, "phone")
+			return
+		}
+		if phone != before.Phone {
+			existing, err := s.kratosClient.FindIdentityByIdentifier(requestCtx, phone)
+			if err != nil {
+				s.logger.Error("kratos lookup failed", zapError(err))
+				respondInternalError(c)
+				return
+			}
+			if existing != nil && existing.ID != identity.ID {
+				respondError(c, http.StatusConflict, codePhoneAlreadyRegistered)
+				return
+			}
+		}
+		traits["phone"] = phone
+	}
+
+	updated := *identity
+	updated.Traits = traits
+	updated.State = identityState(identity)
+	result, err := s.kratosClient.UpdateIdentity(requestCtx, updated)
+	if err != nil {
+		s.respondKratosError(c, err, "update kratos identity failed")
+		return
+	}
+
+	if err := s.userRepo.RecordProfileUpdate(requestCtx, tenantID, before, userSnapshot(result)); err != nil {
+		s.logger.Error("record user update failed", zapError(err), zap.String("identity", identity.ID))
+		respondInternalError(c)
+		return
+	}
+
+	dir, err := s.loadUserDirectory(requestCtx, tenantID)
+	if err != nil {
+		s.logger.Error("load user directory failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mapUser(result, dir))
+}
+
+// handleDisableUser deactivates the identity in Kratos, revokes its sessions and queues
+// the removal of its Keto tuples. Memberships and role assignments stay in the database so
+// enabling the user restores the same access. Repeating the call queues the sync again.
+func (s *Server) handleDisableUser(c *gin.Context) {
+	s.setUserState(c, kratos.StateInactive)
+}
+
+// handleEnableUser reactivates the identity and queues the restore of its Keto tuples.
+func (s *Server) handleEnableUser(c *gin.Context) {
+	s.setUserState(c, kratos.StateActive)
+}
+
+func (s *Server) setUserState(c *gin.Context, state string) {
+	identity, tenantID, ok := s.loadTenantUser(c)
+	if !ok {
+		return
+	}
+
+	disable := state == kratos.StateInactive
+	if disable && middleware.IdentityFromContext(c).Subject == identity.ID {
+		respondError(c, http.StatusBadRequest, codeSelfDisable)
+		return
+	}
+
+	requestCtx := c.Request.Context()
+	before := userSnapshot(identity)
+	identityID := before.IdentityID
+
+	result := identity
+	var keys []string
+	if identityState(identity) != state {
+		updated := *identity
+		updated.State = state
+		var err error
+		if result, err = s.kratosClient.UpdateIdentity(requestCtx, updated); err != nil {
+			s.logger.Error("update kratos identity state failed", zapError(err), zap.String("identity", identity.ID))
+			respondInternalError(c)
+			return
+		}
+		if keys, err = s.userRepo.RecordStateChange(requestCtx, tenantID, before, userSnapshot(result), disable); err != nil {
+			s.logger.Error("record user state change failed", zapError(err), zap.String("identity", identity.ID))
+			respondInternalError(c)
+			return
+		}
+	} else {
+		var err error
+		if keys, err = s.userRepo.QueueAccessSync(requestCtx, tenantID, identityID); err != nil {
+			s.logger.Error("queue user keto sync failed", zapError(err), zap.String("identity", identity.ID))
+			respondInternalError(c)
+			return
+		}
+	}
+
+	if disable {
+		if err := s.kratosClient.DeleteSessions(requestCtx, identity.ID); err != nil {
+			s.logger.Error("revoke kratos sessions failed", zapError(err), zap.String("identity", identity.ID))
+			respondInternalError(c)
+			return
+		}
+	}
+	if err := s.ketoSyncer.Sync(requestCtx, keys...); err != nil {
+		s.logger.Warn("keto sync of user access deferred", zapError(err), zap.String("identity", identity.ID))
+	}
+
+	dir, err := s.loadUserDirectory(requestCtx, tenantID)
+	if err != nil {
+		s.logger.Error("load user directory failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+	c.JSON(http.StatusOK, mapUser(result, dir))
+}
+
+// loadTenantUser resolves the identity in the path and checks it belongs to a tenant the
+// caller may manage.
+func (s *Server) loadTenantUser(c *gin.Context) (*kratos.Identity, uuid.UUID, bool) {
+	ctx, ok := s.requireOrgManager(c)
+	if !ok {
+		return nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "user_id")
+		return nil, uuid.Nil, false
+	}
+
+	identity, err := s.kratosClient.GetIdentity(c.Request.Context(), id.String())
+	if err != nil {
+		s.logger.Error("get kratos identity failed", zapError(err))
+		respondInternalError(c)
+		return nil, uuid.Nil, false
+	}
+	if identity == nil {
+		respondError(c, http.StatusNotFound, codeUserNotFound)
+		return nil, uuid.Nil, false
+	}
+
+	tenantID, err := uuid.Parse(identity.TraitString("tenant_id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, codeUserNotFound)
+		return nil, uuid.Nil, false
+	}
+	if !s.ensureTenantAccess(c, ctx, tenantID) {
+		return nil, uuid.Nil, false
+	}
+	return identity, tenantID, true
+}
+
+// listTenantIdentities scans Kratos for identities whose tenant_id trait matches. Kratos
+// cannot filter on traits, so every page is read.
+func (s *Server) listTenantIdentities(ctx context.Context, tenantID uuid.UUID) ([]kratos.Identity, error) {
+	tenant := tenantID.String()
+	var (
+		result    []kratos.Identity
+		pageToken string
+	)
+	for {
+		identities, next, err := s.kratosClient.ListIdentities(ctx, kratosPageSize, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, identity := range identities {
+			if identity.TraitString("tenant_id") == tenant {
+				result = append(result, identity)
+			}
+		}
+		if next == "" || len(identities) == 0 {
+			return result, nil
+		}
+		pageToken = next
+	}
+}
+
+func (s *Server) loadUserDirectory(ctx context.Context, tenantID uuid.UUID) (*userDirectory, error) {
+	groups, err := s.groupRepo.ListGroups(ctx, &tenantID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.groupRepo.ListTenantMembers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.roleRepo.ListTenantAssignments(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := &userDirectory{
+		groups:      make(map[uuid.UUID]storage.Group, len(groups)),
+		memberships: make(map[uuid.UUID][]storage.GroupMember),
+		roles:       roles,
+	}
+	for _, group := range groups {
+		dir.groups[group.ID] = group
+	}
+	for _, member := range members {
+		dir.memberships[member.IdentityID] = append(dir.memberships[member.IdentityID], member)
+	}
+	return dir, nil
+}
+
+func identityState(identity *kratos.Identity) string {
+	if identity.State == "" {
+		return kratos.StateActive
+	}
+	return identity.State
+}
+
+func userSnapshot(identity *kratos.Identity) storage.UserSnapshot {
+	snapshot := storage.UserSnapshot{
+		DisplayName: identity.TraitString("nickname"),
+		Phone:       identity.TraitString("phone"),
+		Username:    identity.TraitString("username"),
+		UserType:    identity.TraitString("user_type"),
+		TenantID:    identity.TraitString("tenant_id"),
+		State:       identityState(identity),
+	}
+	if id, err := uuid.Parse(identity.ID); err == nil {
+		snapshot.IdentityID = id
+	}
+	return snapshot
+}
+
+func mapUser(identity *kratos.Identity, dir *userDirectory) userResponse {
+	resp := userResponse{
+		ID:       identity.ID,
+		TenantID: identity.TraitString("tenant_id"),
+		Phone:    identity.TraitString("phone"),
+		Nickname: identity.TraitString("nickname"),
+		Username: identity.TraitString("username"),
+		UserType: identity.TraitString("user_type"),
+		State:    identityState(identity),
+		Groups:   []userGroupResponse{},
+		Roles:    []userRoleResponse{},
+	}
+	if !identity.CreatedAt.IsZero() {
+		resp.CreatedAt = identity.CreatedAt.Format(time.RFC3339)
+	}
+	if !identity.UpdatedAt.IsZero() {
+		resp.UpdatedAt = identity.UpdatedAt.Format(time.RFC3339)
+	}
+
+	identityID, err := uuid.Parse(identity.ID)
+	if err != nil {
+		return resp
+	}
+	for _, membership := range dir.memberships[identityID] {
+		group := dir.groups[membership.GroupID]
+		resp.Groups = append(resp.Groups, userGroupResponse{
+			GroupID:   membership.GroupID,
+			GroupCode: group.Code,
+			GroupName: group.Name,
+			Title:     membership.Title,
+			IsPrimary: membership.IsPrimary,
+		})
+	}
+	for _, role := range dir.roles[identityID] {
+		resp.Roles = append(resp.Roles, userRoleResponse{
+			ID:   role.RoleID,
+			Code: role.Code,
+			Name: role.Name,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,483 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/middleware"
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+	"github.com/laofa009/next-agent-portal/backend/internal/webhook"
+)
+
+func (s *Server) registerWebhookRoutes(group *gin.RouterGroup) {
+	group.GET("/webhooks/event-types", s.handleListWebhookEventTypes)
+	group.GET("/webhooks", s.handleListWebhooks)
+	group.POST("/webhooks", s.handleCreateWebhook)
+	group.GET("/webhooks/:id", s.handleGetWebhook)
+	group.PUT("/webhooks/:id", s.handleUpdateWebhook)
+	group.DELETE("/webhooks/:id", s.handleDeleteWebhook)
+	group.POST("/webhooks/:id/rotate-secret", s.handleRotateWebhookSecret)
+	group.GET("/webhooks/:id/deliveries", s.handleListWebhookDeliveries)
+	group.GET("/webhooks/:id/deliveries/:delivery", s.handleGetWebhookDelivery)
+	group.POST("/webhooks/:id/deliveries/:delivery/replay", s.handleReplayWebhookDelivery)
+}
+
+type webhookResponse struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	Active      bool      `json:"active"`
+	Description *string   `json:"description,omitempty"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+}
+
+type webhookPayload struct {
+	TenantID    string   `json:"tenant_id"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Active      *bool    `json:"active"`
+	Description *string  `json:"description"`
+}
+
+type listWebhooksResponse struct {
+	Items []webhookResponse `json:"items"`
+}
+
+type webhookDeliveryResponse struct {
+	ID             uuid.UUID                        `json:"id"`
+	EventID        uuid.UUID                        `json:"event_id"`
+	EventType      string                           `json:"event_type"`
+	Status         string                           `json:"status"`
+	Attempts       int32                            `json:"attempts"`
+	NextAttemptAt  *string                          `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int32                           `json:"last_status_code,omitempty"`
+	LastError      *string                          `json:"last_error,omitempty"`
+	DeliveredAt    *string                          `json:"delivered_at,omitempty"`
+	CreatedAt      string                           `json:"created_at"`
+	AttemptLog     []webhookDeliveryAttemptResponse `json:"attempt_log,omitempty"`
+}
+
+type webhookDeliveryAttemptResponse struct {
+	StatusCode *int32  `json:"status_code,omitempty"`
+	Error      *string `json:"error,omitempty"`
+	DurationMS int32   `json:"duration_ms"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type listWebhookDeliveriesResponse struct {
+	Items    []webhookDeliveryResponse `json:"items"`
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+}
+
+func (s *Server) handleListWebhookEventTypes(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": storage.EventTypes})
+}
+
+func (s *Server) handleListWebhooks(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	tenantID, ok := s.resolveTenantID(c, middleware.IdentityFromContext(c), true)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := s.webhookRepo.ListSubscriptions(c.Request.Context(), &tenantID)
+	if err != nil {
+		s.logger.Error("list webhooks failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]webhookResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		items = append(items, mapWebhook(subscription))
+	}
+	c.JSON(http.StatusOK, listWebhooksResponse{Items: items})
+}
+
+func (s *Server) handleCreateWebhook(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	var payload webhookPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	tenantID, ok := s.resolveTenantIDForPayload(c, middleware.IdentityFromContext(c), payload.TenantID)
+	if !ok {
+		return
+	}
+
+	endpoint, eventTypes, ok := s.validateWebhookPayload(c, payload)
+	if !ok {
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		s.logger.Error("generate webhook secret failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	active := true
+	if payload.Active != nil {
+		active = *payload.Active
+	}
+
+	created, err := s.webhookRepo.CreateSubscription(c.Request.Context(), storage.WebhookSubscription{
+		TenantID:    tenantID,
+		URL:         endpoint,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Active:      active,
+		Description: payload.Description,
+	})
+	if err != nil {
+		s.logger.Error("create webhook failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	response := mapWebhook(created)
+	response.Secret = created.Secret
+	c.JSON(http.StatusCreated, response)
+}
+
+func (s *Server) handleGetWebhook(c *gin.Context) {
+	subscription, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, mapWebhook(subscription))
+}
+
+func (s *Server) handleUpdateWebhook(c *gin.Context) {
+	existing, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var payload webhookPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequestBody)
+		return
+	}
+
+	endpoint, eventTypes, ok := s.validateWebhookPayload(c, payload)
+	if !ok {
+		return
+	}
+
+	active := existing.Active
+	if payload.Active != nil {
+		active = *payload.Active
+	}
+
+	updated, err := s.webhookRepo.UpdateSubscription(c.Request.Context(), storage.WebhookSubscription{
+		ID:          existing.ID,
+		URL:         endpoint,
+		EventTypes:  eventTypes,
+		Active:      active,
+		Description: payload.Description,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			respondError(c, http.StatusNotFound, codeWebhookNotFound)
+			return
+		}
+		s.logger.Error("update webhook failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, mapWebhook(updated))
+}
+
+func (s *Server) handleDeleteWebhook(c *gin.Context) {
+	existing, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := s.webhookRepo.DeleteSubscription(c.Request.Context(), existing.ID); err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			respondError(c, http.StatusNotFound, codeWebhookNotFound)
+			return
+		}
+		s.logger.Error("delete webhook failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) handleRotateWebhookSecret(c *gin.Context) {
+	existing, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		s.logger.Error("generate webhook secret failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	existing.Secret = secret
+	updated, err := s.webhookRepo.UpdateSubscription(c.Request.Context(), existing)
+	if err != nil {
+		s.logger.Error("rotate webhook secret failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	response := mapWebhook(updated)
+	response.Secret = updated.Secret
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) handleListWebhookDeliveries(c *gin.Context) {
+	subscription, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	status := strings.ToLower(strings.TrimSpace(c.Query("status")))
+	switch status {
+	case "", storage.DeliveryPending, storage.DeliverySucceeded, storage.DeliveryFailed:
+	default:
+		respondError(c, http.StatusBadRequest, codeDeliveryStatusInvalid)
+		return
+	}
+
+	page := parsePositiveInt(c.Query("page"), defaultPage)
+	pageSize := parsePositiveInt(c.Query("page_size"), defaultPageSize)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	deliveries, total, err := s.webhookRepo.ListDeliveries(
+		c.Request.Context(),
+		subscription.ID,
+		status,
+		int32(pageSize),
+		int32((page-1)*pageSize),
+	)
+	if err != nil {
+		s.logger.Error("list webhook deliveries failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	items := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, mapWebhookDelivery(delivery))
+	}
+
+	c.JSON(http.StatusOK, listWebhookDeliveriesResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+func (s *Server) handleGetWebhookDelivery(c *gin.Context) {
+	delivery, ok := s.loadWebhookDelivery(c)
+	if !ok {
+		return
+	}
+
+	attempts, err := s.webhookRepo.ListAttempts(c.Request.Context(), delivery.ID)
+	if err != nil {
+		s.logger.Error("list webhook delivery attempts failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	response := mapWebhookDelivery(delivery)
+	response.AttemptLog = make([]webhookDeliveryAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		response.AttemptLog = append(response.AttemptLog, webhookDeliveryAttemptResponse{
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMS: attempt.DurationMS,
+			CreatedAt:  attempt.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) handleReplayWebhookDelivery(c *gin.Context) {
+	delivery, ok := s.loadWebhookDelivery(c)
+	if !ok {
+		return
+	}
+
+	if err := s.webhookRepo.ReplayDelivery(c.Request.Context(), delivery.ID); err != nil {
+		if errors.Is(err, storage.ErrWebhookDeliveryNotFound) {
+			respondError(c, http.StatusNotFound, codeDeliveryNotFound)
+			return
+		}
+		s.logger.Error("replay webhook delivery failed", zapError(err))
+		respondInternalError(c)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// loadWebhook resolves the :id subscription and verifies the caller may manage its tenant.
+func (s *Server) loadWebhook(c *gin.Context) (storage.WebhookSubscription, bool) {
+	if !s.requireAdmin(c) {
+		return storage.WebhookSubscription{}, false
+	}
+
+	id, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+	if err != nil {
+		respondInvalidParam(c, "webhook_id")
+		return storage.WebhookSubscription{}, false
+	}
+
+	subscription, err := s.webhookRepo.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			respondError(c, http.StatusNotFound, codeWebhookNotFound)
+			return storage.WebhookSubscription{}, false
+		}
+		s.logger.Error("load webhook failed", zapError(err))
+		respondInternalError(c)
+		return storage.WebhookSubscription{}, false
+	}
+
+	if !s.ensureTenantAccess(c, middleware.IdentityFromContext(c), subscription.TenantID) {
+		return storage.WebhookSubscription{}, false
+	}
+	return subscription, true
+}
+
+func (s *Server) loadWebhookDelivery(c *gin.Context) (storage.WebhookDelivery, bool) {
+	subscription, ok := s.loadWebhook(c)
+	if !ok {
+		return storage.WebhookDelivery{}, false
+	}
+
+	deliveryID, err := uuid.Parse(strings.TrimSpace(c.Param("delivery")))
+	if err != nil {
+		respondInvalidParam(c, "delivery_id")
+		return storage.WebhookDelivery{}, false
+	}
+
+	delivery, err := s.webhookRepo.GetDelivery(c.Request.Context(), deliveryID)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookDeliveryNotFound) {
+			respondError(c, http.StatusNotFound, codeDeliveryNotFound)
+			return storage.WebhookDelivery{}, false
+		}
+		s.logger.Error("load webhook delivery failed", zapError(err))
+		respondInternalError(c)
+		return storage.WebhookDelivery{}, false
+	}
+
+	if delivery.SubscriptionID != subscription.ID {
+		respondError(c, http.StatusNotFound, codeDeliveryNotFound)
+		return storage.WebhookDelivery{}, false
+	}
+	return delivery, true
+}
+
+// validateWebhookPayload checks the endpoint URL and the event types of a subscription.
+// Endpoints that resolve to internal addresses are refused unless
+// webhooks.allow_private_endpoints is set.
+func (s *Server) validateWebhookPayload(c *gin.Context, payload webhookPayload) (string, []string, bool) {
+	endpoint := strings.TrimSpace(payload.URL)
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		respondError(c, http.StatusBadRequest, codeWebhookURLInvalid)
+		return "", nil, false
+	}
+	if err := webhook.CheckEndpoint(c.Request.Context(), parsed, s.cfg.Webhooks.AllowPrivateEndpoints); err != nil {
+		if errors.Is(err, webhook.ErrEndpointNotAllowed) {
+			respondError(c, http.StatusBadRequest, codeWebhookURLForbidden)
+			return "", nil, false
+		}
+		respondError(c, http.StatusBadRequest, codeWebhookURLInvalid)
+		return "", nil, false
+	}
+
+	eventTypes := make([]string, 0, len(payload.EventTypes))
+	seen := make(map[string]struct{}, len(payload.EventTypes))
+	for _, eventType := range payload.EventTypes {
+		trimmed := strings.TrimSpace(eventType)
+		if trimmed == "" {
+			continue
+		}
+		if !storage.IsEventType(trimmed) {
+			respondError(c, http.StatusBadRequest, codeWebhookEventUnknown, trimmed)
+			return "", nil, false
+		}
+		if _, ok := seen[trimmed]; ok {
+			continue
+		}
+		seen[trimmed] = struct{}{}
+		eventTypes = append(eventTypes, trimmed)
+	}
+	return endpoint, eventTypes, true
+}
+
+func mapWebhook(subscription storage.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		ID:          subscription.ID,
+		TenantID:    subscription.TenantID,
+		URL:         subscription.URL,
+		EventTypes:  subscription.EventTypes,
+		Active:      subscription.Active,
+		Description: subscription.Description,
+		CreatedAt:   subscription.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   subscription.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func mapWebhookDelivery(delivery storage.WebhookDelivery) webhookDeliveryResponse {
+	response := webhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+	}
+	if delivery.Status == storage.DeliveryPending {
+		next := delivery.NextAttemptAt.Format(time.RFC3339)
+		response.NextAttemptAt = &next
+	}
+	if delivery.DeliveredAt != nil {
+		delivered := delivery.DeliveredAt.Format(time.RFC3339)
+		response.DeliveredAt = &delivered
+	}
+	return response
+}
@@ -0,0 +1,84 @@
+// Package sms delivers text messages through the HTTP gateway used by the Kratos courier.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Options configures the sender.
+type Options struct {
+	Endpoint string
+	Timeout  time.Duration
+}
+
+// Sender posts messages to the SMS gateway.
+type Sender struct {
+	endpoint   string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// NewSender constructs a sender, filling unset options with defaults.
+func NewSender(opts Options, logger *zap.Logger) (*Sender, error) {
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("sms endpoint is required")
+	}
+	if _, err := url.Parse(opts.Endpoint); err != nil {
+		return nil, fmt.Errorf("parse sms endpoint: %w", err)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	return &Sender{
+		endpoint: opts.Endpoint,
+		httpClient: &http.Client{
+			Timeout: opts.Timeout,
+		},
+		logger: logger.Named("sms"),
+	}, nil
+}
+
+// message mirrors the body the Kratos courier sends, so both share one gateway contract.
+type message struct {
+	To   string `json:"To"`
+	Body string `json:"Body"`
+}
+
+// Send delivers body to the phone number to.
+func (s *Sender) Send(ctx context.Context, to, body string) error {
+	payload, err := json.Marshal(message{To: to, Body: body})
+	if err != nil {
+		return fmt.Errorf("marshal sms payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("build sms request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("exec sms request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway responded %s", resp.Status)
+	}
+	s.logger.Debug("sms sent", zap.String("to", to))
+	return nil
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// Actor describes who triggered a mutation. It travels on the request context so
+// repositories can attribute audit entries without changing every method signature.
+type Actor struct {
+	Subject         string
+	ImpersonationID *uuid.UUID
+	RequestID       string
+}
+
+type actorContextKey struct{}
+
+// ContextWithActor attaches the acting identity to ctx.
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the acting identity stored on ctx, if any.
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(Actor)
+	return actor, ok
+}
+
+// AuditLog is a single recorded administrative mutation.
+type AuditLog struct {
+	ID              int64           `json:"id"`
+	TenantID        *uuid.UUID      `json:"tenant_id,omitempty"`
+	ActorSubject    *string         `json:"actor_subject,omitempty"`
+	ImpersonationID *uuid.UUID      `json:"impersonation_id,omitempty"`
+	Action          string          `json:"action"`
+	TargetType      string          `json:"target_type"`
+	TargetID        string          `json:"target_id"`
+	Before          json.RawMessage `json:"before,omitempty"`
+	After           json.RawMessage `json:"after,omitempty"`
+	RequestID       *string         `json:"request_id,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// AuditListParams captures filters used when querying the audit log.
+type AuditListParams struct {
+	TenantID     *uuid.UUID
+	ActorSubject string
+	Action       string
+	TargetType   string
+	TargetID     string
+	From         *time.Time
+	To           *time.Time
+	Cursor       string
+	Limit        int32
+}
+
+// changeEntry describes a mutation handed to recordChange by repositories.
+type changeEntry struct {
+	TenantID   *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// recordAudit writes an audit row using q, which is expected to be bound to the
+// transaction that performs the mutation.
+func recordAudit(ctx context.Context, q *sqldb.Queries, entry changeEntry) error {
+	before, err := auditSnapshot(entry.Before)
+	if err != nil {
+		return fmt.Errorf("encode audit before state: %w", err)
+	}
+	after, err := auditSnapshot(entry.After)
+	if err != nil {
+		return fmt.Errorf("encode audit after state: %w", err)
+	}
+
+	params := sqldb.InsertAuditLogParams{
+		TenantID:    uuidToNullablePg(entry.TenantID),
+		Action:      entry.Action,
+		TargetType:  entry.TargetType,
+		TargetID:    entry.TargetID,
+		BeforeState: before,
+		AfterState:  after,
+	}
+	if actor, ok := ActorFromContext(ctx); ok {
+		if subject := strings.TrimSpace(actor.Subject); subject != "" {
+			params.ActorSubject = stringPtr(subject)
+		}
+		params.ImpersonationID = uuidToNullablePg(actor.ImpersonationID)
+		if requestID := strings.TrimSpace(actor.RequestID); requestID != "" {
+			params.RequestID = stringPtr(requestID)
+		}
+	}
+
+	if err := q.InsertAuditLog(ctx, params); err != nil {
+		return fmt.Errorf("insert audit log: %w", err)
+	}
+	return nil
+}
+
+func auditSnapshot(value any) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// AuditRepository exposes read access to the audit log.
+type AuditRepository struct {
+	queries *sqldb.Queries
+}
+
+// NewAuditRepository constructs a repository backed by sqlc queries.
+func NewAuditRepository(queries *sqldb.Queries) *AuditRepository {
+	return &AuditRepository{queries: queries}
+}
+
+// ListAuditLogs returns one page of audit entries, newest first, and the cursor
+// for the following page (empty when there are no more rows).
+func (r *AuditRepository) ListAuditLogs(ctx context.Context, params AuditListParams) ([]AuditLog, string, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	args := sqldb.ListAuditLogsParams{
+		TenantFilter:     uuidToNullablePg(params.TenantID),
+		ActorFilter:      optionalString(params.ActorSubject),
+		ActionFilter:     optionalString(params.Action),
+		TargetTypeFilter: optionalString(params.TargetType),
+		TargetIDFilter:   optionalString(params.TargetID),
+		CreatedFrom:      optionalTimestamptz(params.From),
+		CreatedTo:        optionalTimestamptz(params.To),
+		LimitValue:       limit + 1,
+	}
+
+	if strings.TrimSpace(params.Cursor) != "" {
+		cursor, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		id, err := strconv.ParseInt(cursor.ID, 10, 64)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		args.CursorCreatedAt = pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}
+		args.CursorID = id
+	}
+
+	rows, err := r.queries.ListAuditLogs(ctx, args)
+	if err != nil {
+		return nil, "", fmt.Errorf("list audit logs: %w", err)
+	}
+
+	var next string
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		next = encodeCursor(keysetCursor{CreatedAt: last.CreatedAt.Time, ID: strconv.FormatInt(last.ID, 10)})
+	}
+
+	result := make([]AuditLog, 0, len(rows))
+	for _, row := range rows {
+		entry, err := mapAuditLogRow(row)
+		if err != nil {
+			return nil, "", err
+		}
+		result = append(result, entry)
+	}
+	return result, next, nil
+}
+
+func mapAuditLogRow(row sqldb.AuditLog) (AuditLog, error) {
+	var tenantID *uuid.UUID
+	if value, ok, err := pgUUIDToUUID(row.TenantID); err != nil {
+		return AuditLog{}, fmt.Errorf("parse audit tenant id: %w", err)
+	} else if ok {
+		tenantID = &value
+	}
+
+	var impersonationID *uuid.UUID
+	if value, ok, err := pgUUIDToUUID(row.ImpersonationID); err != nil {
+		return AuditLog{}, fmt.Errorf("parse audit impersonation id: %w", err)
+	} else if ok {
+		impersonationID = &value
+	}
+
+	var before, after json.RawMessage
+	if len(row.BeforeState) > 0 {
+		before = json.RawMessage(row.BeforeState)
+	}
+	if len(row.AfterState) > 0 {
+		after = json.RawMessage(row.AfterState)
+	}
+
+	return AuditLog{
+		ID:              row.ID,
+		TenantID:        tenantID,
+		ActorSubject:    row.ActorSubject,
+		ImpersonationID: impersonationID,
+		Action:          row.Action,
+		TargetType:      row.TargetType,
+		TargetID:        row.TargetID,
+		Before:          before,
+		After:           after,
+		RequestID:       row.RequestID,
+		CreatedAt:       row.CreatedAt.Time,
+	}, nil
+}
+
+func optionalString(value string) *string {
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		return stringPtr(trimmed)
+	}
+	return nil
+}
+
+func optionalTimestamptz(value *time.Time) pgtype.Timestamptz {
+	if value == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *value, Valid: true}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// KeysetPage requests a page of rows ordered by (created_at DESC, id DESC) that follows
+// Cursor, or the first page when Cursor is empty. Counting the matching rows needs an extra
+// query and is only done when WithTotal is set.
+type KeysetPage struct {
+	Cursor    string
+	Limit     int32
+	WithTotal bool
+}
+
+// KeysetResult carries the cursor of the following page, empty on the last page, and the
+// total when it was requested.
+type KeysetResult struct {
+	NextCursor string
+	Total      *int64
+}
+
+// keysetCursor identifies the last row of a page ordered by (created_at DESC, id DESC).
+type keysetCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+func encodeCursor(cursor keysetCursor) string {
+	raw := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(value string) (keysetCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return keysetCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return keysetCursor{}, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return keysetCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return keysetCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: parts[1]}, nil
+}
+
+// keysetArgs decodes a cursor over a UUID keyed table into query arguments. An empty cursor
+// yields NULL arguments, which select the first page.
+func keysetArgs(value string) (pgtype.Timestamptz, pgtype.UUID, error) {
+	if strings.TrimSpace(value) == "" {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, nil
+	}
+	cursor, err := decodeCursor(value)
+	if err != nil {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, err
+	}
+	id, err := uuid.Parse(cursor.ID)
+	if err != nil {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true}, uuidToPg(id), nil
+}
+
+// keysetLimit returns the page size to query for: one row more than requested, so that the
+// presence of a following page can be detected.
+func keysetLimit(page KeysetPage) int32 {
+	if page.Limit <= 0 {
+		return 51
+	}
+	return page.Limit + 1
+}
+
+// nextKeysetCursor trims the extra row fetched by keysetLimit and returns the cursor that
+// continues after the last kept row, or an empty string when there is no following page.
+func nextKeysetCursor(page KeysetPage, count int, last func(i int) (time.Time, uuid.UUID)) (int, string) {
+	limit := int(keysetLimit(page)) - 1
+	if count <= limit {
+		return count, ""
+	}
+	createdAt, id := last(limit - 1)
+	return limit, encodeCursor(keysetCursor{CreatedAt: createdAt, ID: id.String()})
+}
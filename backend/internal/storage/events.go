@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// Domain event types emitted for administrative mutations. The same names are used as
+// audit log actions.
+const (
+	EventTenantCreated      = "tenant.created"
+	EventTenantUpdated      = "tenant.updated"
+	EventTenantDeleted      = "tenant.deleted"
+	EventGroupCreated       = "group.created"
+	EventGroupUpdated       = "group.updated"
+	EventGroupMoved         = "group.moved"
+	EventGroupDeleted       = "group.deleted"
+	EventGroupMemberAdded   = "group.member.added"
+	EventGroupMemberUpdated = "group.member.updated"
+	EventGroupMemberMoved   = "group.member.moved"
+	EventGroupMemberRemoved = "group.member.removed"
+	EventRoleCreated        = "role.created"
+	EventRoleUpdated        = "role.updated"
+	EventRoleDeleted        = "role.deleted"
+	EventRoleAssigned       = "role.assigned"
+	EventRoleUnassigned     = "role.unassigned"
+	EventUserProvisioned    = "user.provisioned"
+	EventUserUpdated        = "user.updated"
+	EventUserDeprovisioned  = "user.deprovisioned"
+	EventUserDisabled       = "user.disabled"
+	EventUserEnabled        = "user.enabled"
+	EventUserInvited        = "user.invited"
+	EventInvitationAccepted = "invitation.accepted"
+	EventInvitationRevoked  = "invitation.revoked"
+)
+
+// EventTypes lists every domain event a webhook subscription can filter on.
+var EventTypes = []string{
+	EventTenantCreated,
+	EventTenantUpdated,
+	EventTenantDeleted,
+	EventGroupCreated,
+	EventGroupUpdated,
+	EventGroupMoved,
+	EventGroupDeleted,
+	EventGroupMemberAdded,
+	EventGroupMemberUpdated,
+	EventGroupMemberMoved,
+	EventGroupMemberRemoved,
+	EventRoleCreated,
+	EventRoleUpdated,
+	EventRoleDeleted,
+	EventRoleAssigned,
+	EventRoleUnassigned,
+	EventUserProvisioned,
+	EventUserUpdated,
+	EventUserDeprovisioned,
+	EventUserDisabled,
+	EventUserEnabled,
+	EventUserInvited,
+	EventInvitationAccepted,
+	EventInvitationRevoked,
+}
+
+// IsEventType reports whether name is a known domain event type.
+func IsEventType(name string) bool {
+	for _, eventType := range EventTypes {
+		if eventType == name {
+			return true
+		}
+	}
+	return false
+}
+
+// eventPayload is the data stored with a domain event and delivered to webhooks.
+type eventPayload struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Before     any    `json:"before,omitempty"`
+	After      any    `json:"after,omitempty"`
+}
+
+// recordChange writes the audit row and the domain event for a mutation using q, which must
+// be bound to the transaction performing the change so all three commit or roll back together.
+func recordChange(ctx context.Context, q *sqldb.Queries, entry changeEntry) error {
+	if err := recordAudit(ctx, q, entry); err != nil {
+		return err
+	}
+	return publishEvent(ctx, q, entry)
+}
+
+// publishEvent stores the domain event and queues one delivery per matching tenant webhook.
+func publishEvent(ctx context.Context, q *sqldb.Queries, entry changeEntry) error {
+	payload, err := json.Marshal(eventPayload{
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+	})
+	if err != nil {
+		return fmt.Errorf("encode event payload: %w", err)
+	}
+
+	eventID := uuid.New()
+	params := sqldb.InsertDomainEventParams{
+		ID:        uuidToPg(eventID),
+		TenantID:  uuidToNullablePg(entry.TenantID),
+		EventType: entry.Action,
+		Payload:   payload,
+	}
+	if actor, ok := ActorFromContext(ctx); ok {
+		if requestID := strings.TrimSpace(actor.RequestID); requestID != "" {
+			params.RequestID = stringPtr(requestID)
+		}
+	}
+	if err := q.InsertDomainEvent(ctx, params); err != nil {
+		return fmt.Errorf("insert domain event: %w", err)
+	}
+
+	if entry.TenantID == nil {
+		return nil
+	}
+	if _, err := q.EnqueueWebhookDeliveries(ctx, sqldb.EnqueueWebhookDeliveriesParams{
+		EventID:   uuidToPg(eventID),
+		TenantID:  uuidToPg(*entry.TenantID),
+		EventType: entry.Action,
+	}); err != nil {
+		return fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
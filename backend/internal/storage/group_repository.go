@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
 )
@@ -25,6 +28,7 @@ type Group struct {
 	SortOrder   int32           `json:"sort_order"`
 	MemberCount int64           `json:"member_count"`
 	Metadata    json.RawMessage `json:"metadata"`
+	Version     int64           `json:"version"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
 }
@@ -49,16 +53,38 @@ var (
 	ErrGroupHasChildren = errors.New("group still has child groups")
 	// ErrGroupHasMembers prevents deleting groups that still have members.
 	ErrGroupHasMembers = errors.New("group still has members")
+	// ErrGroupCycle prevents moving a group below itself or one of its descendants.
+	ErrGroupCycle = errors.New("group cannot be moved into its own subtree")
+	// ErrGroupParentTenant prevents linking groups of different tenants.
+	ErrGroupParentTenant = errors.New("parent group belongs to another tenant")
+	// ErrGroupMemberNotFound indicates the identity is not a member of the group.
+	ErrGroupMemberNotFound = errors.New("group member not found")
+	// ErrGroupMemberExists indicates the identity already belongs to the target group.
+	ErrGroupMemberExists = errors.New("identity is already a member of the group")
+	// ErrPrimaryMembershipRequired prevents leaving an identity without a primary group.
+	ErrPrimaryMembershipRequired = errors.New("identity must keep one primary group")
+	// ErrGroupVersionConflict indicates the group changed since the client read it.
+	ErrGroupVersionConflict = errors.New("group has been modified")
+	// ErrGroupTreeChange indicates an invalid entry in a batch tree update.
+	ErrGroupTreeChange = errors.New("invalid group tree change")
+	// ErrGroupDeleteMode indicates an unknown group deletion mode.
+	ErrGroupDeleteMode = errors.New("unknown group deletion mode")
+	// ErrGroupDeleteTarget indicates a missing or unknown target group for reassignment.
+	ErrGroupDeleteTarget = errors.New("reassignment target group not found")
 )
 
 // GroupRepository exposes data-access helpers for groups and memberships.
 type GroupRepository struct {
+	pool    *pgxpool.Pool
 	queries *sqldb.Queries
 }
 
 // NewGroupRepository constructs a repository using sqlc generated queries.
-func NewGroupRepository(queries *sqldb.Queries) *GroupRepository {
-	return &GroupRepository{queries: queries}
+func NewGroupRepository(pool *pgxpool.Pool, queries *sqldb.Queries) *GroupRepository {
+	return &GroupRepository{
+		pool:    pool,
+		queries: queries,
+	}
 }
 
 // ListGroups returns groups either for a specific tenant or across all tenants when tenantID is nil.
@@ -121,7 +147,11 @@ func (r *GroupRepository) ListGroups(ctx context.Context, tenantID *uuid.UUID) (
 
 // GetGroup fetches a group by ID.
 func (r *GroupRepository) GetGroup(ctx context.Context, id uuid.UUID) (Group, error) {
-	row, err := r.queries.GetTenantGroup(ctx, uuidToPg(id))
+	return getGroup(ctx, r.queries, id)
+}
+
+func getGroup(ctx context.Context, q *sqldb.Queries, id uuid.UUID) (Group, error) {
+	row, err := q.GetTenantGroup(ctx, uuidToPg(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Group{}, ErrGroupNotFound
@@ -139,202 +169,1570 @@ func (r *GroupRepository) CreateGroup(ctx context.Context, group Group) (Group,
 
 	metadata := defaultMetadata(group.Metadata)
 
-	result, err := r.queries.CreateTenantGroup(ctx, sqldb.CreateTenantGroupParams{
-		ID:          uuidToPg(group.ID),
-		TenantID:    uuidToPg(group.TenantID),
-		Code:        strings.TrimSpace(group.Code),
-		Name:        strings.TrimSpace(group.Name),
-		Description: group.Description,
-		ParentID:    uuidToNullablePg(group.ParentID),
-		SortOrder:   group.SortOrder,
-		Metadata:    metadata,
+	var created Group
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		// The closure rows are copied from the parent's ancestors, so a concurrent move
+		// of one of them must not interleave with the copy.
+		if group.ParentID != nil {
+			if err := qtx.LockTenantGroupTree(ctx, uuidToPg(group.TenantID)); err != nil {
+				return fmt.Errorf("lock group tree: %w", err)
+			}
+		}
+		result, err := qtx.CreateTenantGroup(ctx, sqldb.CreateTenantGroupParams{
+			ID:          uuidToPg(group.ID),
+			TenantID:    uuidToPg(group.TenantID),
+			Code:        strings.TrimSpace(group.Code),
+			Name:        strings.TrimSpace(group.Name),
+			Description: group.Description,
+			ParentID:    uuidToNullablePg(group.ParentID),
+			SortOrder:   group.SortOrder,
+			Metadata:    metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("create group: %w", err)
+		}
+		if created, err = mapGroupRow(result); err != nil {
+			return err
+		}
+		if err := qtx.InsertGroupClosure(ctx, sqldb.InsertGroupClosureParams{
+			TenantID: uuidToPg(created.TenantID),
+			GroupID:  uuidToPg(created.ID),
+			ParentID: uuidToNullablePg(created.ParentID),
+		}); err != nil {
+			return fmt.Errorf("insert group closure: %w", err)
+		}
+		if created.ParentID != nil {
+			if err := enqueueGroupSync(ctx, qtx, created.TenantID, created.ID); err != nil {
+				return err
+			}
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &created.TenantID,
+			Action:     EventGroupCreated,
+			TargetType: "group",
+			TargetID:   created.ID.String(),
+			After:      created,
+		})
 	})
 	if err != nil {
-		return Group{}, fmt.Errorf("create group: %w", err)
+		return Group{}, err
 	}
-	return mapGroupRow(result)
+	return created, nil
 }
 
-// UpdateGroup updates an existing group.
-func (r *GroupRepository) UpdateGroup(ctx context.Context, group Group) (Group, error) {
+// UpdateGroup updates an existing group. A non-nil expectedVersion makes the update fail
+// with ErrGroupVersionConflict unless the stored group still has that version.
+func (r *GroupRepository) UpdateGroup(ctx context.Context, group Group, expectedVersion *int64) (Group, error) {
 	var metadata []byte
 	if group.Metadata != nil {
 		metadata = metadataOrNil(group.Metadata)
 	}
 
-	result, err := r.queries.UpdateTenantGroup(ctx, sqldb.UpdateTenantGroupParams{
-		Code:        strings.TrimSpace(group.Code),
-		Name:        strings.TrimSpace(group.Name),
-		Description: group.Description,
-		ParentID:    uuidToNullablePg(group.ParentID),
-		SortOrder:   group.SortOrder,
-		Metadata:    metadata,
-		ID:          uuidToPg(group.ID),
+	var updated Group
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		before, err := getGroup(ctx, qtx, group.ID)
+		if err != nil {
+			return err
+		}
+		if !sameParent(before.ParentID, group.ParentID) {
+			if err := qtx.LockTenantGroupTree(ctx, uuidToPg(before.TenantID)); err != nil {
+				return fmt.Errorf("lock group tree: %w", err)
+			}
+			if err := checkGroupParent(ctx, qtx, before, group.ParentID); err != nil {
+				return err
+			}
+		}
+		if err := checkGroupVersion(ctx, qtx, group.ID, expectedVersion); err != nil {
+			return err
+		}
+
+		result, err := qtx.UpdateTenantGroup(ctx, sqldb.UpdateTenantGroupParams{
+			Code:        strings.TrimSpace(group.Code),
+			Name:        strings.TrimSpace(group.Name),
+			Description: group.Description,
+			ParentID:    uuidToNullablePg(group.ParentID),
+			SortOrder:   group.SortOrder,
+			Metadata:    metadata,
+			ID:          uuidToPg(group.ID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrGroupNotFound
+			}
+			return fmt.Errorf("update group: %w", err)
+		}
+		if updated, err = mapGroupRow(result); err != nil {
+			return err
+		}
+		if !sameParent(before.ParentID, updated.ParentID) {
+			if err := reattachGroupSubtree(ctx, qtx, updated.ID, updated.ParentID); err != nil {
+				return err
+			}
+			if err := enqueueGroupSync(ctx, qtx, updated.TenantID, updated.ID); err != nil {
+				return err
+			}
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &updated.TenantID,
+			Action:     EventGroupUpdated,
+			TargetType: "group",
+			TargetID:   updated.ID.String(),
+			Before:     before,
+			After:      updated,
+		})
 	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return Group{}, ErrGroupNotFound
-		}
-		return Group{}, fmt.Errorf("update group: %w", err)
+		return Group{}, err
 	}
-	return mapGroupRow(result)
+	return updated, nil
 }
 
-// DeleteGroup removes a group after verifying there are no child groups or members.
-func (r *GroupRepository) DeleteGroup(ctx context.Context, id uuid.UUID) error {
-	childCount, err := r.queries.CountChildGroups(ctx, uuidToPg(id))
+// MoveGroup re-parents a group together with its subtree. A nil parentID moves the group
+// to the top level, and a nil sortOrder appends it after its new siblings. Moves within a
+// tenant are serialised so concurrent moves cannot form a cycle. A non-nil expectedVersion
+// rejects the move with ErrGroupVersionConflict if the group changed in the meantime.
+func (r *GroupRepository) MoveGroup(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, sortOrder *int32, expectedVersion *int64) (Group, Group, error) {
+	var before, moved Group
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		var err error
+		if before, err = getGroup(ctx, qtx, id); err != nil {
+			return err
+		}
+		if err := qtx.LockTenantGroupTree(ctx, uuidToPg(before.TenantID)); err != nil {
+			return fmt.Errorf("lock group tree: %w", err)
+		}
+		if err := checkGroupVersion(ctx, qtx, id, expectedVersion); err != nil {
+			return err
+		}
+		if err := checkGroupParent(ctx, qtx, before, parentID); err != nil {
+			return err
+		}
+
+		order := int32(0)
+		if sortOrder != nil {
+			order = *sortOrder
+		} else if sameParent(before.ParentID, parentID) {
+			order = before.SortOrder
+		} else {
+			next, err := qtx.NextGroupSortOrder(ctx, sqldb.NextGroupSortOrderParams{
+				TenantID: uuidToPg(before.TenantID),
+				ParentID: uuidToNullablePg(parentID),
+			})
+			if err != nil {
+				return fmt.Errorf("next group sort order: %w", err)
+			}
+			order = next
+		}
+
+		result, err := qtx.MoveTenantGroup(ctx, sqldb.MoveTenantGroupParams{
+			ID:        uuidToPg(id),
+			ParentID:  uuidToNullablePg(parentID),
+			SortOrder: order,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrGroupNotFound
+			}
+			return fmt.Errorf("move group: %w", err)
+		}
+		if moved, err = mapGroupRow(result); err != nil {
+			return err
+		}
+		if !sameParent(before.ParentID, moved.ParentID) {
+			if err := reattachGroupSubtree(ctx, qtx, moved.ID, moved.ParentID); err != nil {
+				return err
+			}
+			if err := enqueueGroupSync(ctx, qtx, moved.TenantID, moved.ID); err != nil {
+				return err
+			}
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &moved.TenantID,
+			Action:     EventGroupMoved,
+			TargetType: "group",
+			TargetID:   moved.ID.String(),
+			Before:     before,
+			After:      moved,
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("count child groups: %w", err)
-	}
-	if childCount > 0 {
-		return ErrGroupHasChildren
+		return Group{}, Group{}, err
 	}
+	return before, moved, nil
+}
 
-	memberCount, err := r.queries.CountMembersInGroup(ctx, uuidToPg(id))
+// checkGroupParent verifies that parentID may become the parent of group: it must exist in
+// the same tenant and lie outside the group's subtree.
+func checkGroupParent(ctx context.Context, q *sqldb.Queries, group Group, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+	parent, err := getGroup(ctx, q, *parentID)
 	if err != nil {
-		return fmt.Errorf("count group members: %w", err)
+		return err
 	}
-	if memberCount > 0 {
-		return ErrGroupHasMembers
+	if parent.TenantID != group.TenantID {
+		return ErrGroupParentTenant
 	}
-
-	if err := r.queries.DeleteTenantGroup(ctx, uuidToPg(id)); err != nil {
-		return fmt.Errorf("delete group: %w", err)
+	inSubtree, err := q.IsGroupInSubtree(ctx, sqldb.IsGroupInSubtreeParams{
+		RootID:  uuidToPg(group.ID),
+		GroupID: uuidToPg(*parentID),
+	})
+	if err != nil {
+		return fmt.Errorf("check group subtree: %w", err)
+	}
+	if inSubtree {
+		return ErrGroupCycle
 	}
 	return nil
 }
 
-// ListMembers returns paginated members of a group along with total count.
-func (r *GroupRepository) ListMembers(ctx context.Context, groupID uuid.UUID, search string, limit, offset int32) ([]GroupMember, int64, error) {
-	var searchArg *string
-	if trimmed := strings.TrimSpace(search); trimmed != "" {
-		searchArg = stringPtr(trimmed)
+// reattachGroupSubtree rewrites the closure rows linking the subtree rooted at groupID to
+// its former ancestors so that they point at the ancestors of parentID instead.
+func reattachGroupSubtree(ctx context.Context, q *sqldb.Queries, groupID uuid.UUID, parentID *uuid.UUID) error {
+	if err := q.DetachGroupSubtree(ctx, uuidToPg(groupID)); err != nil {
+		return fmt.Errorf("detach group subtree: %w", err)
 	}
-
-	var limitPtr *int32
-	if limit > 0 {
-		limitPtr = int32Ptr(limit)
+	if parentID == nil {
+		return nil
 	}
-	var offsetPtr *int32
-	if offset > 0 {
-		offsetPtr = int32Ptr(offset)
+	if err := q.AttachGroupSubtree(ctx, sqldb.AttachGroupSubtreeParams{
+		GroupID:  uuidToPg(groupID),
+		ParentID: uuidToPg(*parentID),
+	}); err != nil {
+		return fmt.Errorf("attach group subtree: %w", err)
 	}
+	return nil
+}
 
-	params := sqldb.ListGroupMembersParams{
-		GroupID:     uuidToPg(groupID),
-		Search:      searchArg,
-		OffsetValue: offsetPtr,
-		LimitValue:  limitPtr,
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
 	}
+	return *a == *b
+}
 
-	rows, err := r.queries.ListGroupMembers(ctx, params)
-	if err != nil {
-		return nil, 0, fmt.Errorf("list group members: %w", err)
-	}
+// GroupTreeChange places a group under ParentID (nil for the top level) at SortOrder.
+// Version must match the group's current version.
+type GroupTreeChange struct {
+	ID        uuid.UUID
+	ParentID  *uuid.UUID
+	SortOrder int32
+	Version   int64
+}
 
-	total, err := r.queries.CountGroupMembersWithSearch(ctx, sqldb.CountGroupMembersWithSearchParams{
-		GroupID: uuidToPg(groupID),
-		Search:  searchArg,
+// GroupTreeChangeError identifies the change of a batch tree update that was rejected.
+type GroupTreeChangeError struct {
+	GroupID uuid.UUID
+	Err     error
+}
+
+func (e *GroupTreeChangeError) Error() string {
+	return fmt.Sprintf("group %s: %v", e.GroupID, e.Err)
+}
+
+func (e *GroupTreeChangeError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyTreeChanges re-parents and reorders several groups of a tenant in one transaction.
+// Every change is checked against the group's version and the resulting hierarchy must
+// remain a tree; otherwise nothing is applied. It returns the groups before and after the
+// update, limited to those that actually changed.
+func (r *GroupRepository) ApplyTreeChanges(ctx context.Context, tenantID uuid.UUID, changes []GroupTreeChange) ([]Group, []Group, error) {
+	var before, after []Group
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		if err := qtx.LockTenantGroupTree(ctx, uuidToPg(tenantID)); err != nil {
+			return fmt.Errorf("lock group tree: %w", err)
+		}
+		rows, err := qtx.ListTenantGroups(ctx, uuidToPg(tenantID))
+		if err != nil {
+			return fmt.Errorf("list tenant groups: %w", err)
+		}
+		groups := make(map[uuid.UUID]Group, len(rows))
+		parents := make(map[uuid.UUID]*uuid.UUID, len(rows))
+		for _, row := range rows {
+			group, err := mapGroupRow(row)
+			if err != nil {
+				return err
+			}
+			groups[group.ID] = group
+			parents[group.ID] = group.ParentID
+		}
+
+		pending := make([]GroupTreeChange, 0, len(changes))
+		seen := make(map[uuid.UUID]struct{}, len(changes))
+		for _, change := range changes {
+			if _, dup := seen[change.ID]; dup {
+				return &GroupTreeChangeError{GroupID: change.ID, Err: ErrGroupTreeChange}
+			}
+			seen[change.ID] = struct{}{}
+
+			group, ok := groups[change.ID]
+			if !ok {
+				return &GroupTreeChangeError{GroupID: change.ID, Err: ErrGroupNotFound}
+			}
+			if group.Version != change.Version {
+				return &GroupTreeChangeError{GroupID: change.ID, Err: ErrGroupVersionConflict}
+			}
+			if change.ParentID != nil {
+				if _, ok := groups[*change.ParentID]; !ok {
+					return &GroupTreeChangeError{GroupID: change.ID, Err: ErrGroupParentTenant}
+				}
+			}
+			if sameParent(group.ParentID, change.ParentID) && group.SortOrder == change.SortOrder {
+				continue
+			}
+			parents[change.ID] = change.ParentID
+			pending = append(pending, change)
+		}
+
+		depths, err := groupTreeDepths(parents)
+		if err != nil {
+			return err
+		}
+
+		// Detach every re-parented subtree first and attach them top-down afterwards, so the
+		// closure never reflects an intermediate hierarchy that may contain a cycle.
+		reparented := make([]GroupTreeChange, 0, len(pending))
+		for _, change := range pending {
+			if sameParent(groups[change.ID].ParentID, change.ParentID) {
+				continue
+			}
+			if err := qtx.DetachGroupSubtree(ctx, uuidToPg(change.ID)); err != nil {
+				return fmt.Errorf("detach group subtree: %w", err)
+			}
+			if err := enqueueGroupSync(ctx, qtx, tenantID, change.ID); err != nil {
+				return err
+			}
+			reparented = append(reparented, change)
+		}
+		sort.SliceStable(reparented, func(i, j int) bool {
+			return depths[reparented[i].ID] < depths[reparented[j].ID]
+		})
+		for _, change := range reparented {
+			if change.ParentID == nil {
+				continue
+			}
+			if err := qtx.AttachGroupSubtree(ctx, sqldb.AttachGroupSubtreeParams{
+				GroupID:  uuidToPg(change.ID),
+				ParentID: uuidToPg(*change.ParentID),
+			}); err != nil {
+				return fmt.Errorf("attach group subtree: %w", err)
+			}
+		}
+
+		for _, change := range pending {
+			result, err := qtx.MoveTenantGroup(ctx, sqldb.MoveTenantGroupParams{
+				ID:        uuidToPg(change.ID),
+				ParentID:  uuidToNullablePg(change.ParentID),
+				SortOrder: change.SortOrder,
+			})
+			if err != nil {
+				return fmt.Errorf("move group: %w", err)
+			}
+			moved, err := mapGroupRow(result)
+			if err != nil {
+				return err
+			}
+			previous := groups[change.ID]
+			if err := recordChange(ctx, qtx, changeEntry{
+				TenantID:   &moved.TenantID,
+				Action:     EventGroupMoved,
+				TargetType: "group",
+				TargetID:   moved.ID.String(),
+				Before:     previous,
+				After:      moved,
+			}); err != nil {
+				return err
+			}
+			before = append(before, previous)
+			after = append(after, moved)
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("count group members: %w", err)
+		return nil, nil, err
 	}
+	return before, after, nil
+}
 
-	members := make([]GroupMember, 0, len(rows))
-	for _, row := range rows {
-		member, err := mapGroupMemberRow(row)
-		if err != nil {
-			return nil, 0, err
+// groupTreeDepths returns the depth of every group in the hierarchy described by parents,
+// failing with ErrGroupCycle when a group is its own ancestor.
+func groupTreeDepths(parents map[uuid.UUID]*uuid.UUID) (map[uuid.UUID]int, error) {
+	depths := make(map[uuid.UUID]int, len(parents))
+	var depthOf func(id uuid.UUID, visiting map[uuid.UUID]struct{}) (int, error)
+	depthOf = func(id uuid.UUID, visiting map[uuid.UUID]struct{}) (int, error) {
+		if depth, ok := depths[id]; ok {
+			return depth, nil
 		}
-		members = append(members, member)
+		if _, ok := visiting[id]; ok {
+			return 0, &GroupTreeChangeError{GroupID: id, Err: ErrGroupCycle}
+		}
+		visiting[id] = struct{}{}
+
+		depth := 0
+		if parent := parents[id]; parent != nil {
+			parentDepth, err := depthOf(*parent, visiting)
+			if err != nil {
+				return 0, err
+			}
+			depth = parentDepth + 1
+		}
+		depths[id] = depth
+		return depth, nil
 	}
 
-	return members, total, nil
+	for id := range parents {
+		if _, err := depthOf(id, make(map[uuid.UUID]struct{})); err != nil {
+			return nil, err
+		}
+	}
+	return depths, nil
 }
 
-// CreateMember adds or updates a member record for a group.
-func (r *GroupRepository) CreateMember(ctx context.Context, member GroupMember) (GroupMember, error) {
-	result, err := r.queries.CreateGroupMember(ctx, sqldb.CreateGroupMemberParams{
-		GroupID:     uuidToPg(member.GroupID),
-		IdentityID:  uuidToPg(member.IdentityID),
-		TenantID:    uuidToPg(member.TenantID),
-		DisplayName: strings.TrimSpace(member.DisplayName),
-		Phone:       strings.TrimSpace(member.Phone),
-		Title:       member.Title,
-		IsPrimary:   member.IsPrimary,
+// Group deletion modes. Restrict refuses to delete groups that still have children or
+// members, reassign hands them over to a target group, and cascade deletes the whole
+// subtree together with its memberships.
+const (
+	GroupDeleteRestrict = "restrict"
+	GroupDeleteReassign = "reassign"
+	GroupDeleteCascade  = "cascade"
+)
+
+// DeleteGroupOptions selects how a group with children or members is deleted.
+type DeleteGroupOptions struct {
+	Mode     string
+	TargetID *uuid.UUID
+	// ExpectedVersion, when set, rejects the deletion with ErrGroupVersionConflict if the
+	// group changed since the client read it.
+	ExpectedVersion *int64
+}
+
+// GroupDeletionPlan describes the changes a group deletion makes.
+type GroupDeletionPlan struct {
+	Mode   string `json:"mode"`
+	Group  Group  `json:"group"`
+	Target *Group `json:"target,omitempty"`
+	// DeletedGroups lists the removed groups, deepest first.
+	DeletedGroups []Group `json:"deleted_groups"`
+	// MovedGroups lists the direct children re-parented under the target.
+	MovedGroups []Group `json:"moved_groups"`
+	// MovedMembers lists the memberships transferred to the target.
+	MovedMembers []GroupMember `json:"moved_members"`
+	// RemovedMembers lists the memberships deleted, including those of identities that
+	// already belong to the target.
+	RemovedMembers []GroupMember `json:"removed_members"`
+	// ReleasedIdentities lists the identities left without any group in the tenant.
+	ReleasedIdentities []uuid.UUID `json:"released_identities"`
+}
+
+// PreviewGroupDeletion returns the changes DeleteGroup would make without applying them.
+func (r *GroupRepository) PreviewGroupDeletion(ctx context.Context, id uuid.UUID, opts DeleteGroupOptions) (GroupDeletionPlan, error) {
+	return planGroupDeletion(ctx, r.queries, id, opts)
+}
+
+// DeleteGroup removes a group according to opts and returns the applied plan. The default
+// restrict mode fails with ErrGroupHasChildren or ErrGroupHasMembers for non-empty groups.
+func (r *GroupRepository) DeleteGroup(ctx context.Context, id uuid.UUID, opts DeleteGroupOptions) (GroupDeletionPlan, error) {
+	var plan GroupDeletionPlan
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		group, err := getGroup(ctx, qtx, id)
+		if err != nil {
+			return err
+		}
+		if err := qtx.LockTenantGroupTree(ctx, uuidToPg(group.TenantID)); err != nil {
+			return fmt.Errorf("lock group tree: %w", err)
+		}
+		if err := checkGroupVersion(ctx, qtx, id, opts.ExpectedVersion); err != nil {
+			return err
+		}
+		if plan, err = planGroupDeletion(ctx, qtx, id, opts); err != nil {
+			return err
+		}
+
+		switch plan.Mode {
+		case GroupDeleteReassign:
+			if err := applyGroupReassignment(ctx, qtx, plan); err != nil {
+				return err
+			}
+		case GroupDeleteCascade:
+			if err := removeMemberships(ctx, qtx, plan.RemovedMembers); err != nil {
+				return err
+			}
+		}
+
+		for _, deleted := range plan.DeletedGroups {
+			if err := qtx.DeleteTenantGroup(ctx, uuidToPg(deleted.ID)); err != nil {
+				return fmt.Errorf("delete group: %w", err)
+			}
+			if err := recordChange(ctx, qtx, changeEntry{
+				TenantID:   &deleted.TenantID,
+				Action:     EventGroupDeleted,
+				TargetType: "group",
+				TargetID:   deleted.ID.String(),
+				Before:     deleted,
+			}); err != nil {
+				return err
+			}
+		}
+		return enqueueDeletionSync(ctx, qtx, plan)
 	})
 	if err != nil {
-		return GroupMember{}, fmt.Errorf("create group member: %w", err)
+		return GroupDeletionPlan{}, err
 	}
-	return mapGroupMemberRow(result)
+	return plan, nil
 }
 
-// UpdateMember updates mutable fields of an existing member.
-func (r *GroupRepository) UpdateMember(ctx context.Context, member GroupMember) (GroupMember, error) {
-	result, err := r.queries.UpdateGroupMember(ctx, sqldb.UpdateGroupMemberParams{
-		DisplayName: strings.TrimSpace(member.DisplayName),
-		Phone:       strings.TrimSpace(member.Phone),
-		Title:       member.Title,
-		IsPrimary:   member.IsPrimary,
-		GroupID:     uuidToPg(member.GroupID),
-		IdentityID:  uuidToPg(member.IdentityID),
-	})
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return GroupMember{}, fmt.Errorf("update group member: %w", ErrGroupNotFound)
+// enqueueDeletionSync queues the Keto changes of an applied deletion plan: the tuples of
+// the deleted groups are removed, and moved children and members are linked to the target.
+func enqueueDeletionSync(ctx context.Context, q *sqldb.Queries, plan GroupDeletionPlan) error {
+	tenantID := plan.Group.TenantID
+	for _, deleted := range plan.DeletedGroups {
+		if err := enqueueGroupSync(ctx, q, tenantID, deleted.ID); err != nil {
+			return err
+		}
+	}
+	for _, child := range plan.MovedGroups {
+		if err := enqueueGroupSync(ctx, q, tenantID, child.ID); err != nil {
+			return err
+		}
+	}
+	if plan.Target != nil {
+		for _, member := range plan.MovedMembers {
+			if err := enqueueGroupMemberSync(ctx, q, tenantID, plan.Target.ID, member.IdentityID); err != nil {
+				return err
+			}
 		}
-		return GroupMember{}, fmt.Errorf("update group member: %w", err)
 	}
-	return mapGroupMemberRow(result)
+	return nil
 }
 
-// MoveMember changes the group association for an identity.
-func (r *GroupRepository) MoveMember(ctx context.Context, identityID, currentGroupID, newGroupID, tenantID uuid.UUID) (GroupMember, error) {
-	result, err := r.queries.MoveGroupMember(ctx, sqldb.MoveGroupMemberParams{
-		NewGroupID: uuidToPg(newGroupID),
-		TenantID:   uuidToPg(tenantID),
-		GroupID:    uuidToPg(currentGroupID),
-		IdentityID: uuidToPg(identityID),
-	})
+// DeletionSyncKeys returns the Keto sync queue keys that DeleteGroup enqueued for plan.
+func DeletionSyncKeys(plan GroupDeletionPlan) []string {
+	keys := make([]string, 0, len(plan.DeletedGroups)+len(plan.MovedGroups)+len(plan.MovedMembers))
+	for _, deleted := range plan.DeletedGroups {
+		keys = append(keys, GroupSyncKey(deleted.ID))
+	}
+	for _, child := range plan.MovedGroups {
+		keys = append(keys, GroupSyncKey(child.ID))
+	}
+	if plan.Target != nil {
+		for _, member := range plan.MovedMembers {
+			keys = append(keys, GroupMemberSyncKey(plan.Target.ID, member.IdentityID))
+		}
+	}
+	return keys
+}
+
+// checkGroupVersion locks the group row for the rest of the transaction and compares its
+// version with expected. A nil expected version skips the comparison.
+func checkGroupVersion(ctx context.Context, q *sqldb.Queries, id uuid.UUID, expected *int64) error {
+	if expected == nil {
+		return nil
+	}
+	version, err := q.LockTenantGroupVersion(ctx, uuidToPg(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return GroupMember{}, fmt.Errorf("move group member: %w", ErrGroupNotFound)
+			return ErrGroupNotFound
 		}
-		return GroupMember{}, fmt.Errorf("move group member: %w", err)
+		return fmt.Errorf("lock group: %w", err)
 	}
-	return mapGroupMemberRow(result)
+	if version != *expected {
+		return ErrGroupVersionConflict
+	}
+	return nil
 }
 
-// DeleteMember removes a member from a group.
-func (r *GroupRepository) DeleteMember(ctx context.Context, groupID, identityID uuid.UUID) error {
-	if err := r.queries.DeleteGroupMember(ctx, sqldb.DeleteGroupMemberParams{
-		GroupID:    uuidToPg(groupID),
-		IdentityID: uuidToPg(identityID),
-	}); err != nil {
-		return fmt.Errorf("delete group member: %w", err)
+func planGroupDeletion(ctx context.Context, q *sqldb.Queries, id uuid.UUID, opts DeleteGroupOptions) (GroupDeletionPlan, error) {
+	group, err := getGroup(ctx, q, id)
+	if err != nil {
+		return GroupDeletionPlan{}, err
+	}
+
+	plan := GroupDeletionPlan{
+		Mode:               opts.Mode,
+		Group:              group,
+		DeletedGroups:      []Group{group},
+		MovedGroups:        []Group{},
+		MovedMembers:       []GroupMember{},
+		RemovedMembers:     []GroupMember{},
+		ReleasedIdentities: []uuid.UUID{},
+	}
+	if plan.Mode == "" {
+		plan.Mode = GroupDeleteRestrict
+	}
+
+	switch plan.Mode {
+	case GroupDeleteRestrict:
+		childCount, err := q.CountChildGroups(ctx, uuidToPg(id))
+		if err != nil {
+			return GroupDeletionPlan{}, fmt.Errorf("count child groups: %w", err)
+		}
+		if childCount > 0 {
+			return GroupDeletionPlan{}, ErrGroupHasChildren
+		}
+		memberCount, err := q.CountMembersInGroup(ctx, uuidToPg(id))
+		if err != nil {
+			return GroupDeletionPlan{}, fmt.Errorf("count group members: %w", err)
+		}
+		if memberCount > 0 {
+			return GroupDeletionPlan{}, ErrGroupHasMembers
+		}
+		return plan, nil
+	case GroupDeleteReassign:
+		return planGroupReassignment(ctx, q, plan, opts.TargetID)
+	case GroupDeleteCascade:
+		return planGroupCascade(ctx, q, plan)
+	default:
+		return GroupDeletionPlan{}, ErrGroupDeleteMode
 	}
-	return nil
 }
 
-// ListGroupsForIdentity returns all group memberships for a given identity within a tenant.
-func (r *GroupRepository) ListGroupsForIdentity(ctx context.Context, tenantID, identityID uuid.UUID) ([]GroupMember, error) {
-	rows, err := r.queries.ListGroupsForIdentity(ctx, sqldb.ListGroupsForIdentityParams{
-		TenantID:   uuidToPg(tenantID),
-		IdentityID: uuidToPg(identityID),
+func planGroupReassignment(ctx context.Context, q *sqldb.Queries, plan GroupDeletionPlan, targetID *uuid.UUID) (GroupDeletionPlan, error) {
+	if targetID == nil {
+		return GroupDeletionPlan{}, ErrGroupDeleteTarget
+	}
+	target, err := getGroup(ctx, q, *targetID)
+	if err != nil {
+		if errors.Is(err, ErrGroupNotFound) {
+			return GroupDeletionPlan{}, ErrGroupDeleteTarget
+		}
+		return GroupDeletionPlan{}, err
+	}
+	if target.TenantID != plan.Group.TenantID {
+		return GroupDeletionPlan{}, ErrGroupParentTenant
+	}
+	inSubtree, err := q.IsGroupInSubtree(ctx, sqldb.IsGroupInSubtreeParams{
+		RootID:  uuidToPg(plan.Group.ID),
+		GroupID: uuidToPg(target.ID),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("list groups for identity: %w", err)
+		return GroupDeletionPlan{}, fmt.Errorf("check group subtree: %w", err)
+	}
+	if inSubtree {
+		return GroupDeletionPlan{}, ErrGroupCycle
 	}
+	plan.Target = &target
 
-	memberships := make([]GroupMember, 0, len(rows))
+	children, err := q.ListChildGroups(ctx, uuidToPg(plan.Group.ID))
+	if err != nil {
+		return GroupDeletionPlan{}, fmt.Errorf("list child groups: %w", err)
+	}
+	for _, row := range children {
+		child, err := mapGroupRow(row)
+		if err != nil {
+			return GroupDeletionPlan{}, err
+		}
+		plan.MovedGroups = append(plan.MovedGroups, child)
+	}
+
+	rows, err := q.ListAllGroupMembers(ctx, uuidToPg(plan.Group.ID))
+	if err != nil {
+		return GroupDeletionPlan{}, fmt.Errorf("list group members: %w", err)
+	}
 	for _, row := range rows {
 		member, err := mapGroupMemberRow(row)
 		if err != nil {
-			return nil, err
+			return GroupDeletionPlan{}, err
 		}
-		memberships = append(memberships, member)
+		if _, err := getGroupMember(ctx, q, target.ID, member.IdentityID); err == nil {
+			plan.RemovedMembers = append(plan.RemovedMembers, member)
+			continue
+		} else if !errors.Is(err, ErrGroupMemberNotFound) {
+			return GroupDeletionPlan{}, err
+		}
+		plan.MovedMembers = append(plan.MovedMembers, member)
 	}
-	return memberships, nil
+	return plan, nil
+}
+
+func planGroupCascade(ctx context.Context, q *sqldb.Queries, plan GroupDeletionPlan) (GroupDeletionPlan, error) {
+	subtree, err := q.ListGroupSubtree(ctx, sqldb.ListGroupSubtreeParams{GroupID: uuidToPg(plan.Group.ID)})
+	if err != nil {
+		return GroupDeletionPlan{}, fmt.Errorf("list group subtree: %w", err)
+	}
+	// Rows come ordered by depth; children must be deleted before their parents.
+	plan.DeletedGroups = make([]Group, 0, len(subtree))
+	for i := len(subtree) - 1; i >= 0; i-- {
+		group, err := mapGroupRow(subtree[i])
+		if err != nil {
+			return GroupDeletionPlan{}, err
+		}
+		plan.DeletedGroups = append(plan.DeletedGroups, group)
+	}
+
+	memberships, err := q.ListSubtreeMemberships(ctx, uuidToPg(plan.Group.ID))
+	if err != nil {
+		return GroupDeletionPlan{}, fmt.Errorf("list subtree memberships: %w", err)
+	}
+	for _, row := range memberships {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return GroupDeletionPlan{}, err
+		}
+		plan.RemovedMembers = append(plan.RemovedMembers, member)
+	}
+
+	released, err := q.ListSubtreeOnlyIdentities(ctx, uuidToPg(plan.Group.ID))
+	if err != nil {
+		return GroupDeletionPlan{}, fmt.Errorf("list released identities: %w", err)
+	}
+	for _, value := range released {
+		identityID, err := uuid.FromBytes(value.Bytes[:])
+		if err != nil {
+			return GroupDeletionPlan{}, fmt.Errorf("parse released identity id: %w", err)
+		}
+		plan.ReleasedIdentities = append(plan.ReleasedIdentities, identityID)
+	}
+	return plan, nil
+}
+
+// applyGroupReassignment hands the children, members and invitations of the deleted group
+// over to the plan's target.
+func applyGroupReassignment(ctx context.Context, q *sqldb.Queries, plan GroupDeletionPlan) error {
+	target := *plan.Target
+
+	for _, child := range plan.MovedGroups {
+		order, err := q.NextGroupSortOrder(ctx, sqldb.NextGroupSortOrderParams{
+			TenantID: uuidToPg(target.TenantID),
+			ParentID: uuidToNullablePg(&target.ID),
+		})
+		if err != nil {
+			return fmt.Errorf("next group sort order: %w", err)
+		}
+		result, err := q.MoveTenantGroup(ctx, sqldb.MoveTenantGroupParams{
+			ID:        uuidToPg(child.ID),
+			ParentID:  uuidToNullablePg(&target.ID),
+			SortOrder: order,
+		})
+		if err != nil {
+			return fmt.Errorf("move group: %w", err)
+		}
+		moved, err := mapGroupRow(result)
+		if err != nil {
+			return err
+		}
+		if err := reattachGroupSubtree(ctx, q, moved.ID, moved.ParentID); err != nil {
+			return err
+		}
+		if err := recordChange(ctx, q, changeEntry{
+			TenantID:   &moved.TenantID,
+			Action:     EventGroupMoved,
+			TargetType: "group",
+			TargetID:   moved.ID.String(),
+			Before:     child,
+			After:      moved,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, member := range plan.MovedMembers {
+		result, err := q.MoveGroupMember(ctx, sqldb.MoveGroupMemberParams{
+			NewGroupID: uuidToPg(target.ID),
+			TenantID:   uuidToPg(target.TenantID),
+			GroupID:    uuidToPg(member.GroupID),
+			IdentityID: uuidToPg(member.IdentityID),
+		})
+		if err != nil {
+			return fmt.Errorf("move group member: %w", err)
+		}
+		moved, err := mapGroupMemberRow(result)
+		if err != nil {
+			return err
+		}
+		if err := recordChange(ctx, q, changeEntry{
+			TenantID:   &moved.TenantID,
+			Action:     EventGroupMemberMoved,
+			TargetType: "member",
+			TargetID:   moved.IdentityID.String(),
+			Before:     member,
+			After:      moved,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Identities already in the target keep that membership, which inherits the primary
+	// flag from the removed one.
+	for _, member := range plan.RemovedMembers {
+		if err := deleteMembership(ctx, q, member); err != nil {
+			return err
+		}
+		if !member.IsPrimary {
+			continue
+		}
+		existing, err := getGroupMember(ctx, q, target.ID, member.IdentityID)
+		if err != nil {
+			return err
+		}
+		result, err := q.UpdateGroupMember(ctx, sqldb.UpdateGroupMemberParams{
+			DisplayName:  existing.DisplayName,
+			Phone:        existing.Phone,
+			Title:        existing.Title,
+			IsPrimary:    true,
+			NameInitials: nameInitials(existing.DisplayName),
+			GroupID:      uuidToPg(existing.GroupID),
+			IdentityID:   uuidToPg(existing.IdentityID),
+		})
+		if err != nil {
+			return fmt.Errorf("update group member: %w", err)
+		}
+		promoted, err := mapGroupMemberRow(result)
+		if err != nil {
+			return err
+		}
+		if err := recordChange(ctx, q, changeEntry{
+			TenantID:   &promoted.TenantID,
+			Action:     EventGroupMemberUpdated,
+			TargetType: "member",
+			TargetID:   promoted.IdentityID.String(),
+			Before:     existing,
+			After:      promoted,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := q.ReassignGroupInvitations(ctx, sqldb.ReassignGroupInvitationsParams{
+		NewGroupID: uuidToPg(target.ID),
+		GroupID:    uuidToPg(plan.Group.ID),
+	}); err != nil {
+		return fmt.Errorf("reassign group invitations: %w", err)
+	}
+	return nil
+}
+
+// removeMemberships deletes the given memberships and promotes another primary group for
+// identities that lost theirs but still belong to the tenant.
+func removeMemberships(ctx context.Context, q *sqldb.Queries, members []GroupMember) error {
+	lostPrimary := make([]GroupMember, 0)
+	for _, member := range members {
+		if err := deleteMembership(ctx, q, member); err != nil {
+			return err
+		}
+		if member.IsPrimary {
+			lostPrimary = append(lostPrimary, member)
+		}
+	}
+	for _, member := range lostPrimary {
+		if err := promotePrimary(ctx, q, member.TenantID, member.IdentityID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteMembership(ctx context.Context, q *sqldb.Queries, member GroupMember) error {
+	if err := q.DeleteGroupMember(ctx, sqldb.DeleteGroupMemberParams{
+		GroupID:    uuidToPg(member.GroupID),
+		IdentityID: uuidToPg(member.IdentityID),
+	}); err != nil {
+		return fmt.Errorf("delete group member: %w", err)
+	}
+	return recordChange(ctx, q, changeEntry{
+		TenantID:   &member.TenantID,
+		Action:     EventGroupMemberRemoved,
+		TargetType: "member",
+		TargetID:   member.IdentityID.String(),
+		Before:     member,
+	})
+}
+
+// ListSubtree returns a group and its descendants with their direct member counts, ordered
+// by depth. A non-nil maxDepth limits how many levels below the group are included.
+func (r *GroupRepository) ListSubtree(ctx context.Context, groupID uuid.UUID, maxDepth *int32) ([]Group, error) {
+	rows, err := r.queries.ListGroupSubtree(ctx, sqldb.ListGroupSubtreeParams{
+		GroupID:  uuidToPg(groupID),
+		MaxDepth: maxDepth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list group subtree: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	countRows, err := r.queries.ListSubtreeMemberCounts(ctx, uuidToPg(groupID))
+	if err != nil {
+		return nil, fmt.Errorf("list subtree member counts: %w", err)
+	}
+	memberCounts := make(map[uuid.UUID]int64, len(countRows))
+	for _, row := range countRows {
+		id, err := uuid.FromBytes(row.GroupID.Bytes[:])
+		if err != nil {
+			return nil, fmt.Errorf("parse group id for count: %w", err)
+		}
+		memberCounts[id] = row.MemberCount
+	}
+
+	groups := make([]Group, 0, len(rows))
+	for _, row := range rows {
+		group, err := mapGroupRow(row)
+		if err != nil {
+			return nil, err
+		}
+		group.MemberCount = memberCounts[group.ID]
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// ListAncestors returns the path from the top-level group down to the group itself.
+func (r *GroupRepository) ListAncestors(ctx context.Context, groupID uuid.UUID) ([]Group, error) {
+	rows, err := r.queries.ListGroupAncestors(ctx, uuidToPg(groupID))
+	if err != nil {
+		return nil, fmt.Errorf("list group ancestors: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	groups := make([]Group, 0, len(rows))
+	for _, row := range rows {
+		group, err := mapGroupRow(row)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// ListSubtreeMembers returns paginated members of a group and all of its descendants along
+// with the total count. Identities belonging to several groups of the subtree appear once.
+func (r *GroupRepository) ListSubtreeMembers(ctx context.Context, groupID uuid.UUID, search string, limit, offset int32) ([]GroupMember, int64, error) {
+	var searchArg *string
+	if trimmed := strings.TrimSpace(search); trimmed != "" {
+		searchArg = stringPtr(trimmed)
+	}
+
+	var limitPtr *int32
+	if limit > 0 {
+		limitPtr = int32Ptr(limit)
+	}
+	var offsetPtr *int32
+	if offset > 0 {
+		offsetPtr = int32Ptr(offset)
+	}
+
+	rows, err := r.queries.ListSubtreeMembers(ctx, sqldb.ListSubtreeMembersParams{
+		GroupID:     uuidToPg(groupID),
+		Search:      searchArg,
+		OffsetValue: offsetPtr,
+		LimitValue:  limitPtr,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list subtree members: %w", err)
+	}
+
+	total, err := r.queries.CountSubtreeMembers(ctx, sqldb.CountSubtreeMembersParams{
+		GroupID: uuidToPg(groupID),
+		Search:  searchArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("count subtree members: %w", err)
+	}
+
+	members := make([]GroupMember, 0, len(rows))
+	for _, row := range rows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		members = append(members, member)
+	}
+	return members, total, nil
+}
+
+// CountSubtreeMembers returns the number of distinct identities in a group and its descendants.
+func (r *GroupRepository) CountSubtreeMembers(ctx context.Context, groupID uuid.UUID) (int64, error) {
+	total, err := r.queries.CountSubtreeMembers(ctx, sqldb.CountSubtreeMembersParams{
+		GroupID: uuidToPg(groupID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("count subtree members: %w", err)
+	}
+	return total, nil
+}
+
+// GroupPathNode is one group on the path from the top level to a member's group.
+type GroupPathNode struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// DirectoryEntry is a tenant member found by directory search. Member is the identity's
+// primary membership, or its oldest one without a primary group; Memberships lists all of
+// them, and Paths maps each of their groups to its path from the top level.
+type DirectoryEntry struct {
+	Member      GroupMember
+	Memberships []GroupMember
+	Paths       map[uuid.UUID][]GroupPathNode
+}
+
+// SearchDirectory finds the members of a tenant whose name, phone, title or pinyin initials
+// in any group contain search, returning one entry per identity ordered by name together
+// with the total count.
+func (r *GroupRepository) SearchDirectory(ctx context.Context, tenantID uuid.UUID, search string, limit, offset int32) ([]DirectoryEntry, int64, error) {
+	var searchArg *string
+	if trimmed := strings.TrimSpace(search); trimmed != "" {
+		searchArg = stringPtr(trimmed)
+	}
+
+	rows, err := r.queries.SearchDirectoryMembers(ctx, sqldb.SearchDirectoryMembersParams{
+		TenantID:    uuidToPg(tenantID),
+		Search:      searchArg,
+		LimitValue:  limit,
+		OffsetValue: offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("search directory members: %w", err)
+	}
+	total, err := r.queries.CountDirectoryMembers(ctx, sqldb.CountDirectoryMembersParams{
+		TenantID: uuidToPg(tenantID),
+		Search:   searchArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("count directory members: %w", err)
+	}
+	if len(rows) == 0 {
+		return []DirectoryEntry{}, total, nil
+	}
+
+	entries := make([]DirectoryEntry, 0, len(rows))
+	index := make(map[uuid.UUID]int, len(rows))
+	identityIDs := make([]pgtype.UUID, 0, len(rows))
+	for _, row := range rows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		index[member.IdentityID] = len(entries)
+		identityIDs = append(identityIDs, row.IdentityID)
+		entries = append(entries, DirectoryEntry{Member: member, Paths: map[uuid.UUID][]GroupPathNode{}})
+	}
+
+	membershipRows, err := r.queries.ListMembershipsForIdentities(ctx, sqldb.ListMembershipsForIdentitiesParams{
+		TenantID:    uuidToPg(tenantID),
+		IdentityIds: identityIDs,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list directory memberships: %w", err)
+	}
+	groupIDs := make([]pgtype.UUID, 0, len(membershipRows))
+	seenGroups := make(map[uuid.UUID]struct{})
+	for _, row := range membershipRows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		entry := &entries[index[member.IdentityID]]
+		entry.Memberships = append(entry.Memberships, member)
+		if _, ok := seenGroups[member.GroupID]; !ok {
+			seenGroups[member.GroupID] = struct{}{}
+			groupIDs = append(groupIDs, row.GroupID)
+		}
+	}
+
+	pathRows, err := r.queries.ListGroupPaths(ctx, groupIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list group paths: %w", err)
+	}
+	paths := make(map[uuid.UUID][]GroupPathNode, len(groupIDs))
+	for _, row := range pathRows {
+		groupID, err := uuid.FromBytes(row.GroupID.Bytes[:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse path group id: %w", err)
+		}
+		ancestorID, err := uuid.FromBytes(row.AncestorID.Bytes[:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse path ancestor id: %w", err)
+		}
+		paths[groupID] = append(paths[groupID], GroupPathNode{ID: ancestorID, Name: row.AncestorName})
+	}
+	for i := range entries {
+		for _, membership := range entries[i].Memberships {
+			entries[i].Paths[membership.GroupID] = paths[membership.GroupID]
+		}
+	}
+	return entries, total, nil
+}
+
+// BackfillNameInitials computes the pinyin initials of memberships stored before directory
+// search existed and returns how many rows were updated.
+func (r *GroupRepository) BackfillNameInitials(ctx context.Context) (int, error) {
+	const batchSize = 500
+	updated := 0
+	for {
+		rows, err := r.queries.ListMembersMissingInitials(ctx, batchSize)
+		if err != nil {
+			return updated, fmt.Errorf("list members missing initials: %w", err)
+		}
+		for _, row := range rows {
+			if err := r.queries.SetMemberNameInitials(ctx, sqldb.SetMemberNameInitialsParams{
+				NameInitials: nameInitials(row.DisplayName),
+				GroupID:      row.GroupID,
+				IdentityID:   row.IdentityID,
+			}); err != nil {
+				return updated, fmt.Errorf("set member name initials: %w", err)
+			}
+			updated++
+		}
+		if len(rows) < batchSize {
+			return updated, nil
+		}
+	}
+}
+
+// ListMembersKeyset returns one page of a group's members, newest first, using keyset
+// pagination over (created_at, identity_id).
+func (r *GroupRepository) ListMembersKeyset(ctx context.Context, groupID uuid.UUID, search string, page KeysetPage) ([]GroupMember, KeysetResult, error) {
+	cursorCreatedAt, cursorID, err := keysetArgs(page.Cursor)
+	if err != nil {
+		return nil, KeysetResult{}, err
+	}
+
+	var searchArg *string
+	if trimmed := strings.TrimSpace(search); trimmed != "" {
+		searchArg = stringPtr(trimmed)
+	}
+
+	rows, err := r.queries.ListGroupMembers(ctx, sqldb.ListGroupMembersParams{
+		GroupID:         uuidToPg(groupID),
+		Search:          searchArg,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		LimitValue:      int32Ptr(keysetLimit(page)),
+	})
+	if err != nil {
+		return nil, KeysetResult{}, fmt.Errorf("list group members: %w", err)
+	}
+
+	members := make([]GroupMember, 0, len(rows))
+	for _, row := range rows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return nil, KeysetResult{}, err
+		}
+		members = append(members, member)
+	}
+
+	var pageResult KeysetResult
+	count, next := nextKeysetCursor(page, len(members), func(i int) (time.Time, uuid.UUID) {
+		return members[i].CreatedAt, members[i].IdentityID
+	})
+	members, pageResult.NextCursor = members[:count], next
+
+	if page.WithTotal {
+		total, err := r.queries.CountGroupMembersWithSearch(ctx, sqldb.CountGroupMembersWithSearchParams{
+			GroupID: uuidToPg(groupID),
+			Search:  searchArg,
+		})
+		if err != nil {
+			return nil, KeysetResult{}, fmt.Errorf("count group members: %w", err)
+		}
+		pageResult.Total = &total
+	}
+	return members, pageResult, nil
+}
+
+// ListMembers returns paginated members of a group along with total count.
+func (r *GroupRepository) ListMembers(ctx context.Context, groupID uuid.UUID, search string, limit, offset int32) ([]GroupMember, int64, error) {
+	var searchArg *string
+	if trimmed := strings.TrimSpace(search); trimmed != "" {
+		searchArg = stringPtr(trimmed)
+	}
+
+	var limitPtr *int32
+	if limit > 0 {
+		limitPtr = int32Ptr(limit)
+	}
+	var offsetPtr *int32
+	if offset > 0 {
+		offsetPtr = int32Ptr(offset)
+	}
+
+	params := sqldb.ListGroupMembersParams{
+		GroupID:     uuidToPg(groupID),
+		Search:      searchArg,
+		OffsetValue: offsetPtr,
+		LimitValue:  limitPtr,
+	}
+
+	rows, err := r.queries.ListGroupMembers(ctx, params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list group members: %w", err)
+	}
+
+	total, err := r.queries.CountGroupMembersWithSearch(ctx, sqldb.CountGroupMembersWithSearchParams{
+		GroupID: uuidToPg(groupID),
+		Search:  searchArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("count group members: %w", err)
+	}
+
+	members := make([]GroupMember, 0, len(rows))
+	for _, row := range rows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		members = append(members, member)
+	}
+
+	return members, total, nil
+}
+
+// CreateMember adds or updates a member record for a group. Each identity has exactly one
+// primary group per tenant: the first membership becomes primary, and a membership marked
+// primary takes the flag from the identity's other groups. The Keto membership tuple is
+// queued for the sync worker in the same transaction.
+func (r *GroupRepository) CreateMember(ctx context.Context, member GroupMember) (GroupMember, error) {
+	var created GroupMember
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		var err error
+		created, err = createMember(ctx, qtx, member)
+		return err
+	})
+	if err != nil {
+		return GroupMember{}, err
+	}
+	return created, nil
+}
+
+func createMember(ctx context.Context, q *sqldb.Queries, member GroupMember) (GroupMember, error) {
+	before, err := getGroupMember(ctx, q, member.GroupID, member.IdentityID)
+	if err != nil && !errors.Is(err, ErrGroupMemberNotFound) {
+		return GroupMember{}, err
+	}
+
+	if !member.IsPrimary {
+		hasPrimary, err := hasOtherPrimary(ctx, q, member.TenantID, member.IdentityID, member.GroupID)
+		if err != nil {
+			return GroupMember{}, err
+		}
+		member.IsPrimary = !hasPrimary
+	}
+	if member.IsPrimary {
+		if err := clearOtherPrimaries(ctx, q, member.TenantID, member.IdentityID, member.GroupID); err != nil {
+			return GroupMember{}, err
+		}
+	}
+
+	result, err := q.CreateGroupMember(ctx, sqldb.CreateGroupMemberParams{
+		GroupID:      uuidToPg(member.GroupID),
+		IdentityID:   uuidToPg(member.IdentityID),
+		TenantID:     uuidToPg(member.TenantID),
+		DisplayName:  strings.TrimSpace(member.DisplayName),
+		Phone:        strings.TrimSpace(member.Phone),
+		Title:        member.Title,
+		IsPrimary:    member.IsPrimary,
+		NameInitials: nameInitials(member.DisplayName),
+	})
+	if err != nil {
+		return GroupMember{}, fmt.Errorf("create group member: %w", err)
+	}
+	created, err := mapGroupMemberRow(result)
+	if err != nil {
+		return GroupMember{}, err
+	}
+	if err := enqueueGroupMemberSync(ctx, q, created.TenantID, created.GroupID, created.IdentityID); err != nil {
+		return GroupMember{}, err
+	}
+
+	entry := changeEntry{
+		TenantID:   &created.TenantID,
+		Action:     EventGroupMemberAdded,
+		TargetType: "member",
+		TargetID:   created.IdentityID.String(),
+		After:      created,
+	}
+	if before != nil {
+		entry.Action = EventGroupMemberUpdated
+		entry.Before = before
+	}
+	if err := recordChange(ctx, q, entry); err != nil {
+		return GroupMember{}, err
+	}
+	return created, nil
+}
+
+// UpdateMember updates mutable fields of an existing member. Marking the membership
+// primary demotes the identity's other groups; the primary flag can only move by marking
+// another membership primary.
+func (r *GroupRepository) UpdateMember(ctx context.Context, member GroupMember) (GroupMember, error) {
+	var updated GroupMember
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		before, err := getGroupMember(ctx, qtx, member.GroupID, member.IdentityID)
+		if err != nil {
+			if errors.Is(err, ErrGroupMemberNotFound) {
+				return fmt.Errorf("update group member: %w", ErrGroupNotFound)
+			}
+			return err
+		}
+
+		switch {
+		case member.IsPrimary && !before.IsPrimary:
+			if err := clearOtherPrimaries(ctx, qtx, before.TenantID, member.IdentityID, member.GroupID); err != nil {
+				return err
+			}
+		case !member.IsPrimary && before.IsPrimary:
+			return ErrPrimaryMembershipRequired
+		}
+
+		result, err := qtx.UpdateGroupMember(ctx, sqldb.UpdateGroupMemberParams{
+			DisplayName:  strings.TrimSpace(member.DisplayName),
+			Phone:        strings.TrimSpace(member.Phone),
+			Title:        member.Title,
+			IsPrimary:    member.IsPrimary,
+			NameInitials: nameInitials(member.DisplayName),
+			GroupID:      uuidToPg(member.GroupID),
+			IdentityID:   uuidToPg(member.IdentityID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("update group member: %w", ErrGroupNotFound)
+			}
+			return fmt.Errorf("update group member: %w", err)
+		}
+		if updated, err = mapGroupMemberRow(result); err != nil {
+			return err
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &updated.TenantID,
+			Action:     EventGroupMemberUpdated,
+			TargetType: "member",
+			TargetID:   updated.IdentityID.String(),
+			Before:     before,
+			After:      updated,
+		})
+	})
+	if err != nil {
+		return GroupMember{}, err
+	}
+	return updated, nil
+}
+
+// MoveMember changes the group association for an identity and queues the Keto update of
+// both memberships.
+func (r *GroupRepository) MoveMember(ctx context.Context, identityID, currentGroupID, newGroupID, tenantID uuid.UUID) (GroupMember, error) {
+	var moved GroupMember
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		before, err := getGroupMember(ctx, qtx, currentGroupID, identityID)
+		if err != nil {
+			if errors.Is(err, ErrGroupMemberNotFound) {
+				return fmt.Errorf("move group member: %w", ErrGroupNotFound)
+			}
+			return err
+		}
+		if _, err := getGroupMember(ctx, qtx, newGroupID, identityID); err == nil {
+			return ErrGroupMemberExists
+		} else if !errors.Is(err, ErrGroupMemberNotFound) {
+			return err
+		}
+
+		result, err := qtx.MoveGroupMember(ctx, sqldb.MoveGroupMemberParams{
+			NewGroupID: uuidToPg(newGroupID),
+			TenantID:   uuidToPg(tenantID),
+			GroupID:    uuidToPg(currentGroupID),
+			IdentityID: uuidToPg(identityID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("move group member: %w", ErrGroupNotFound)
+			}
+			return fmt.Errorf("move group member: %w", err)
+		}
+		if moved, err = mapGroupMemberRow(result); err != nil {
+			return err
+		}
+		for _, groupID := range []uuid.UUID{currentGroupID, newGroupID} {
+			if err := enqueueGroupMemberSync(ctx, qtx, tenantID, groupID, identityID); err != nil {
+				return err
+			}
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &moved.TenantID,
+			Action:     EventGroupMemberMoved,
+			TargetType: "member",
+			TargetID:   moved.IdentityID.String(),
+			Before:     before,
+			After:      moved,
+		})
+	})
+	if err != nil {
+		return GroupMember{}, err
+	}
+	return moved, nil
+}
+
+// DeleteMember removes a member from a group. Removing the primary membership promotes
+// the identity's oldest remaining membership in the tenant. The Keto tuple is queued for
+// removal.
+func (r *GroupRepository) DeleteMember(ctx context.Context, groupID, identityID uuid.UUID) error {
+	return runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		return deleteMember(ctx, qtx, groupID, identityID)
+	})
+}
+
+func deleteMember(ctx context.Context, q *sqldb.Queries, groupID, identityID uuid.UUID) error {
+	before, err := getGroupMember(ctx, q, groupID, identityID)
+	if err != nil {
+		if errors.Is(err, ErrGroupMemberNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := q.DeleteGroupMember(ctx, sqldb.DeleteGroupMemberParams{
+		GroupID:    uuidToPg(groupID),
+		IdentityID: uuidToPg(identityID),
+	}); err != nil {
+		return fmt.Errorf("delete group member: %w", err)
+	}
+	if err := enqueueGroupMemberSync(ctx, q, before.TenantID, groupID, identityID); err != nil {
+		return err
+	}
+	if err := recordChange(ctx, q, changeEntry{
+		TenantID:   &before.TenantID,
+		Action:     EventGroupMemberRemoved,
+		TargetType: "member",
+		TargetID:   before.IdentityID.String(),
+		Before:     before,
+	}); err != nil {
+		return err
+	}
+	if before.IsPrimary {
+		return promotePrimary(ctx, q, before.TenantID, before.IdentityID)
+	}
+	return nil
+}
+
+// hasOtherPrimary reports whether the identity has a primary membership in another group
+// of the tenant.
+func hasOtherPrimary(ctx context.Context, q *sqldb.Queries, tenantID, identityID, groupID uuid.UUID) (bool, error) {
+	rows, err := q.ListGroupsForIdentity(ctx, sqldb.ListGroupsForIdentityParams{
+		TenantID:   uuidToPg(tenantID),
+		IdentityID: uuidToPg(identityID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("list groups for identity: %w", err)
+	}
+	for _, row := range rows {
+		if row.IsPrimary && row.GroupID != uuidToPg(groupID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func clearOtherPrimaries(ctx context.Context, q *sqldb.Queries, tenantID, identityID, keepGroupID uuid.UUID) error {
+	if err := q.ClearPrimaryMemberships(ctx, sqldb.ClearPrimaryMembershipsParams{
+		TenantID:    uuidToPg(tenantID),
+		IdentityID:  uuidToPg(identityID),
+		KeepGroupID: uuidToPg(keepGroupID),
+	}); err != nil {
+		return fmt.Errorf("clear primary memberships: %w", err)
+	}
+	return nil
+}
+
+// promotePrimary marks the identity's oldest membership primary when none is, recording
+// the change. It is a no-op when the identity has no memberships left.
+func promotePrimary(ctx context.Context, q *sqldb.Queries, tenantID, identityID uuid.UUID) error {
+	row, err := q.PromotePrimaryMembership(ctx, sqldb.PromotePrimaryMembershipParams{
+		TenantID:   uuidToPg(tenantID),
+		IdentityID: uuidToPg(identityID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("promote primary membership: %w", err)
+	}
+	promoted, err := mapGroupMemberRow(row)
+	if err != nil {
+		return err
+	}
+	before := promoted
+	before.IsPrimary = false
+	return recordChange(ctx, q, changeEntry{
+		TenantID:   &promoted.TenantID,
+		Action:     EventGroupMemberUpdated,
+		TargetType: "member",
+		TargetID:   promoted.IdentityID.String(),
+		Before:     before,
+		After:      promoted,
+	})
+}
+
+// GetMember fetches the membership of an identity in a group.
+func (r *GroupRepository) GetMember(ctx context.Context, groupID, identityID uuid.UUID) (GroupMember, error) {
+	member, err := getGroupMember(ctx, r.queries, groupID, identityID)
+	if err != nil {
+		return GroupMember{}, err
+	}
+	return *member, nil
+}
+
+func getGroupMember(ctx context.Context, q *sqldb.Queries, groupID, identityID uuid.UUID) (*GroupMember, error) {
+	row, err := q.GetGroupMember(ctx, sqldb.GetGroupMemberParams{
+		GroupID:    uuidToPg(groupID),
+		IdentityID: uuidToPg(identityID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGroupMemberNotFound
+		}
+		return nil, fmt.Errorf("get group member: %w", err)
+	}
+	member, err := mapGroupMemberRow(row)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListGroupsForIdentity returns all group memberships for a given identity within a tenant.
+func (r *GroupRepository) ListGroupsForIdentity(ctx context.Context, tenantID, identityID uuid.UUID) ([]GroupMember, error) {
+	rows, err := r.queries.ListGroupsForIdentity(ctx, sqldb.ListGroupsForIdentityParams{
+		TenantID:   uuidToPg(tenantID),
+		IdentityID: uuidToPg(identityID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list groups for identity: %w", err)
+	}
+
+	memberships := make([]GroupMember, 0, len(rows))
+	for _, row := range rows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, member)
+	}
+	return memberships, nil
+}
+
+// ListTenantMembers returns every group membership within a tenant ordered by group.
+func (r *GroupRepository) ListTenantMembers(ctx context.Context, tenantID uuid.UUID) ([]GroupMember, error) {
+	rows, err := r.queries.ListTenantMembers(ctx, uuidToPg(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list tenant members: %w", err)
+	}
+
+	members := make([]GroupMember, 0, len(rows))
+	for _, row := range rows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, nil
 }
 
 func mapGroupRow(row sqldb.TenantGroup) (Group, error) {
@@ -369,6 +1767,7 @@ func mapGroupRow(row sqldb.TenantGroup) (Group, error) {
 		SortOrder:   row.SortOrder,
 		MemberCount: 0,
 		Metadata:    metadata,
+		Version:     row.Version,
 		CreatedAt:   row.CreatedAt.Time,
 		UpdatedAt:   row.UpdatedAt.Time,
 	}, nil
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// Idempotency key states. A pending key belongs to a request that is still running.
+const (
+	IdempotencyPending   = "pending"
+	IdempotencyCompleted = "completed"
+)
+
+// ErrIdempotencyLockLost is returned when a request no longer holds the key it claimed,
+// because the key was taken over after its lock timed out.
+var ErrIdempotencyLockLost = errors.New("idempotency key is held by another request")
+
+// IdempotencyRecord is a stored idempotency key together with the response it produced.
+// LockToken identifies the request holding a pending key, and ResourceID is what an earlier
+// attempt of the same request recorded before it failed.
+type IdempotencyRecord struct {
+	Scope               string
+	Key                 string
+	Method              string
+	Path                string
+	Fingerprint         string
+	Status              string
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+	LockToken           uuid.UUID
+	ResourceID          string
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+}
+
+// IdempotencyClaim identifies the request that wants to use a key. Keys are kept for TTL,
+// and a pending key older than LockTimeout is considered abandoned and can be taken over.
+type IdempotencyClaim struct {
+	Scope       string
+	Key         string
+	Method      string
+	Path        string
+	Fingerprint string
+	TTL         time.Duration
+	LockTimeout time.Duration
+}
+
+// IdempotencyRepository stores idempotency keys and the responses replayed to retries.
+type IdempotencyRepository struct {
+	queries *sqldb.Queries
+}
+
+// NewIdempotencyRepository constructs a repository using sqlc generated queries.
+func NewIdempotencyRepository(queries *sqldb.Queries) *IdempotencyRepository {
+	return &IdempotencyRepository{queries: queries}
+}
+
+// Claim reserves the key for the request. It reports true when the caller owns the key and
+// must run the request, and the returned record carries the lock token for Complete and
+// Release; otherwise it returns the record of the request that holds the key.
+func (r *IdempotencyRepository) Claim(ctx context.Context, claim IdempotencyClaim) (IdempotencyRecord, bool, error) {
+	now := time.Now()
+	token := uuid.New()
+	// The key can be released between a failed claim and the lookup, so claim once more.
+	for attempt := 0; attempt < 2; attempt++ {
+		row, err := r.queries.ClaimIdempotencyKey(ctx, sqldb.ClaimIdempotencyKeyParams{
+			Scope:          claim.Scope,
+			IdempotencyKey: claim.Key,
+			Method:         claim.Method,
+			Path:           claim.Path,
+			Fingerprint:    claim.Fingerprint,
+			ExpiresAt:      pgtype.Timestamptz{Time: now.Add(claim.TTL), Valid: true},
+			LockToken:      uuidToPg(token),
+			StaleBefore:    pgtype.Timestamptz{Time: now.Add(-claim.LockTimeout), Valid: true},
+		})
+		if err == nil {
+			return mapIdempotencyRow(row), true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return IdempotencyRecord{}, false, fmt.Errorf("claim idempotency key: %w", err)
+		}
+
+		existing, err := r.queries.GetIdempotencyKey(ctx, sqldb.GetIdempotencyKeyParams{
+			Scope:          claim.Scope,
+			IdempotencyKey: claim.Key,
+		})
+		if err == nil {
+			if existing.Status == IdempotencyPending && !existing.LockToken.Valid {
+				// Released after the failed claim.
+				continue
+			}
+			return mapIdempotencyRow(existing), false, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return IdempotencyRecord{}, false, fmt.Errorf("get idempotency key: %w", err)
+		}
+	}
+	return IdempotencyRecord{}, false, fmt.Errorf("claim idempotency key: key %q keeps changing", claim.Key)
+}
+
+// Complete stores the response of the request that holds the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, record IdempotencyRecord, status int, contentType string, body []byte) error {
+	statusArg := int32(status)
+	updated, err := r.queries.CompleteIdempotencyKey(ctx, sqldb.CompleteIdempotencyKeyParams{
+		ResponseStatus:      &statusArg,
+		ResponseContentType: optionalString(contentType),
+		ResponseBody:        body,
+		Scope:               record.Scope,
+		IdempotencyKey:      record.Key,
+		LockToken:           uuidToPg(record.LockToken),
+	})
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	if updated == 0 {
+		return ErrIdempotencyLockLost
+	}
+	return nil
+}
+
+// Release unlocks a pending key so the request can be retried with it. The key keeps its
+// recorded resource for that retry.
+func (r *IdempotencyRepository) Release(ctx context.Context, record IdempotencyRecord) error {
+	updated, err := r.queries.ReleaseIdempotencyKey(ctx, sqldb.ReleaseIdempotencyKeyParams{
+		Scope:          record.Scope,
+		IdempotencyKey: record.Key,
+		LockToken:      uuidToPg(record.LockToken),
+	})
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	if updated == 0 {
+		return ErrIdempotencyLockLost
+	}
+	return nil
+}
+
+// RecordResource remembers the resource a request created before it finished, so a retry
+// after a failure can resume from it instead of creating another one.
+func (r *IdempotencyRepository) RecordResource(ctx context.Context, record IdempotencyRecord, resourceID string) error {
+	updated, err := r.queries.SetIdempotencyResource(ctx, sqldb.SetIdempotencyResourceParams{
+		ResourceID:     optionalString(resourceID),
+		Scope:          record.Scope,
+		IdempotencyKey: record.Key,
+		LockToken:      uuidToPg(record.LockToken),
+	})
+	if err != nil {
+		return fmt.Errorf("record idempotency resource: %w", err)
+	}
+	if updated == 0 {
+		return ErrIdempotencyLockLost
+	}
+	return nil
+}
+
+// PurgeExpired deletes keys past their retention and returns how many were removed.
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context) (int64, error) {
+	removed, err := r.queries.PurgeExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+	return removed, nil
+}
+
+func mapIdempotencyRow(row sqldb.IdempotencyKey) IdempotencyRecord {
+	record := IdempotencyRecord{
+		Scope:        row.Scope,
+		Key:          row.IdempotencyKey,
+		Method:       row.Method,
+		Path:         row.Path,
+		Fingerprint:  row.Fingerprint,
+		Status:       row.Status,
+		ResponseBody: row.ResponseBody,
+		CreatedAt:    row.CreatedAt.Time,
+		ExpiresAt:    row.ExpiresAt.Time,
+	}
+	if row.LockToken.Valid {
+		record.LockToken = uuid.UUID(row.LockToken.Bytes)
+	}
+	if row.ResourceID != nil {
+		record.ResourceID = *row.ResourceID
+	}
+	if row.ResponseStatus != nil {
+		record.ResponseStatus = int(*row.ResponseStatus)
+	}
+	if row.ResponseContentType != nil {
+		record.ResponseContentType = *row.ResponseContentType
+	}
+	return record
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// ImpersonationSession is a time-limited elevation that lets a platform admin act inside one tenant.
+type ImpersonationSession struct {
+	ID           uuid.UUID  `json:"id"`
+	TenantID     uuid.UUID  `json:"tenant_id"`
+	ActorSubject string     `json:"actor_subject"`
+	Reason       string     `json:"reason"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RequestCount int64      `json:"request_count"`
+}
+
+// Active reports whether the session can still be used at the given instant.
+func (s ImpersonationSession) Active(now time.Time) bool {
+	return s.EndedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// ImpersonationRequest records a single API call made under an impersonation session.
+type ImpersonationRequest struct {
+	ID        int64     `json:"id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    *int32    `json:"status"`
+	RequestID *string   `json:"request_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ImpersonationListParams captures filters used when listing impersonation sessions.
+type ImpersonationListParams struct {
+	TenantID     *uuid.UUID
+	ActorSubject string
+	ActiveOnly   bool
+	Limit        int32
+	Offset       int32
+}
+
+var (
+	// ErrImpersonationNotFound indicates the requested impersonation session does not exist.
+	ErrImpersonationNotFound = errors.New("impersonation session not found")
+	// ErrImpersonationEnded is returned when ending a session that has already been closed.
+	ErrImpersonationEnded = errors.New("impersonation session already ended")
+)
+
+// ImpersonationRepository persists impersonation sessions and the requests made under them.
+type ImpersonationRepository struct {
+	queries *sqldb.Queries
+}
+
+// NewImpersonationRepository constructs a repository backed by sqlc queries.
+func NewImpersonationRepository(queries *sqldb.Queries) *ImpersonationRepository {
+	return &ImpersonationRepository{queries: queries}
+}
+
+// CreateSession starts a new impersonation session.
+func (r *ImpersonationRepository) CreateSession(ctx context.Context, session ImpersonationSession) (ImpersonationSession, error) {
+	if session.ID == uuid.Nil {
+		session.ID = uuid.New()
+	}
+
+	row, err := r.queries.CreateImpersonationSession(ctx, sqldb.CreateImpersonationSessionParams{
+		ID:           uuidToPg(session.ID),
+		TenantID:     uuidToPg(session.TenantID),
+		ActorSubject: strings.TrimSpace(session.ActorSubject),
+		Reason:       strings.TrimSpace(session.Reason),
+		ExpiresAt:    pgtype.Timestamptz{Time: session.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return ImpersonationSession{}, fmt.Errorf("create impersonation session: %w", err)
+	}
+	return mapImpersonationSession(row)
+}
+
+// GetSession fetches a session by ID.
+func (r *ImpersonationRepository) GetSession(ctx context.Context, id uuid.UUID) (ImpersonationSession, error) {
+	row, err := r.queries.GetImpersonationSession(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ImpersonationSession{}, ErrImpersonationNotFound
+		}
+		return ImpersonationSession{}, fmt.Errorf("get impersonation session: %w", err)
+	}
+	return mapImpersonationSession(row)
+}
+
+// ListSessions returns paginated sessions together with the total count.
+func (r *ImpersonationRepository) ListSessions(ctx context.Context, params ImpersonationListParams) ([]ImpersonationSession, int64, error) {
+	var tenantArg pgtype.UUID
+	if params.TenantID != nil {
+		tenantArg = uuidToPg(*params.TenantID)
+	}
+
+	var actorArg *string
+	if trimmed := strings.TrimSpace(params.ActorSubject); trimmed != "" {
+		actorArg = stringPtr(trimmed)
+	}
+
+	var offsetArg *int32
+	if params.Offset > 0 {
+		offsetArg = int32Ptr(params.Offset)
+	}
+
+	var limitArg *int32
+	if params.Limit > 0 {
+		limitArg = int32Ptr(params.Limit)
+	}
+
+	rows, err := r.queries.ListImpersonationSessions(ctx, sqldb.ListImpersonationSessionsParams{
+		TenantFilter: tenantArg,
+		ActorFilter:  actorArg,
+		ActiveOnly:   params.ActiveOnly,
+		OffsetValue:  offsetArg,
+		LimitValue:   limitArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list impersonation sessions: %w", err)
+	}
+
+	total, err := r.queries.CountImpersonationSessions(ctx, sqldb.CountImpersonationSessionsParams{
+		TenantFilter: tenantArg,
+		ActorFilter:  actorArg,
+		ActiveOnly:   params.ActiveOnly,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("count impersonation sessions: %w", err)
+	}
+
+	result := make([]ImpersonationSession, 0, len(rows))
+	for _, row := range rows {
+		session, err := mapImpersonationSession(sqldb.ImpersonationSession{
+			ID:           row.ID,
+			TenantID:     row.TenantID,
+			ActorSubject: row.ActorSubject,
+			Reason:       row.Reason,
+			ExpiresAt:    row.ExpiresAt,
+			EndedAt:      row.EndedAt,
+			CreatedAt:    row.CreatedAt,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		session.RequestCount = row.RequestCount
+		result = append(result, session)
+	}
+	return result, total, nil
+}
+
+// EndSession closes an active session before it expires. It returns
+// ErrImpersonationNotFound for unknown sessions and ErrImpersonationEnded for sessions
+// that were already closed.
+func (r *ImpersonationRepository) EndSession(ctx context.Context, id uuid.UUID) (ImpersonationSession, error) {
+	row, err := r.queries.EndImpersonationSession(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := r.GetSession(ctx, id); err != nil {
+				return ImpersonationSession{}, err
+			}
+			return ImpersonationSession{}, ErrImpersonationEnded
+		}
+		return ImpersonationSession{}, fmt.Errorf("end impersonation session: %w", err)
+	}
+	return mapImpersonationSession(row)
+}
+
+// RecordRequest stores a request made under the session before it is handled and
+// returns its ID. The status stays empty until CompleteRequest records it.
+func (r *ImpersonationRepository) RecordRequest(ctx context.Context, request ImpersonationRequest) (int64, error) {
+	id, err := r.queries.InsertImpersonationRequest(ctx, sqldb.InsertImpersonationRequestParams{
+		SessionID: uuidToPg(request.SessionID),
+		Method:    request.Method,
+		Path:      request.Path,
+		RequestID: request.RequestID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("insert impersonation request: %w", err)
+	}
+	return id, nil
+}
+
+// CompleteRequest records the response status of a request stored with RecordRequest.
+func (r *ImpersonationRepository) CompleteRequest(ctx context.Context, id int64, status int32) error {
+	if err := r.queries.CompleteImpersonationRequest(ctx, sqldb.CompleteImpersonationRequestParams{
+		Status: &status,
+		ID:     id,
+	}); err != nil {
+		return fmt.Errorf("complete impersonation request: %w", err)
+	}
+	return nil
+}
+
+// ListRequests returns the paginated request log of a session.
+func (r *ImpersonationRepository) ListRequests(ctx context.Context, sessionID uuid.UUID, limit, offset int32) ([]ImpersonationRequest, int64, error) {
+	var offsetArg *int32
+	if offset > 0 {
+		offsetArg = int32Ptr(offset)
+	}
+
+	var limitArg *int32
+	if limit > 0 {
+		limitArg = int32Ptr(limit)
+	}
+
+	rows, err := r.queries.ListImpersonationRequests(ctx, sqldb.ListImpersonationRequestsParams{
+		SessionID:   uuidToPg(sessionID),
+		OffsetValue: offsetArg,
+		LimitValue:  limitArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list impersonation requests: %w", err)
+	}
+
+	total, err := r.queries.CountImpersonationRequests(ctx, uuidToPg(sessionID))
+	if err != nil {
+		return nil, 0, fmt.Errorf("count impersonation requests: %w", err)
+	}
+
+	result := make([]ImpersonationRequest, 0, len(rows))
+	for _, row := range rows {
+		sessionUUID, _, err := pgUUIDToUUID(row.SessionID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse session id: %w", err)
+		}
+		result = append(result, ImpersonationRequest{
+			ID:        row.ID,
+			SessionID: sessionUUID,
+			Method:    row.Method,
+			Path:      row.Path,
+			Status:    row.Status,
+			RequestID: row.RequestID,
+			CreatedAt: row.CreatedAt.Time,
+		})
+	}
+	return result, total, nil
+}
+
+func mapImpersonationSession(row sqldb.ImpersonationSession) (ImpersonationSession, error) {
+	id, ok, err := pgUUIDToUUID(row.ID)
+	if err != nil {
+		return ImpersonationSession{}, fmt.Errorf("parse impersonation id: %w", err)
+	}
+	if !ok {
+		return ImpersonationSession{}, fmt.Errorf("impersonation id invalid")
+	}
+	tenantID, _, err := pgUUIDToUUID(row.TenantID)
+	if err != nil {
+		return ImpersonationSession{}, fmt.Errorf("parse impersonation tenant id: %w", err)
+	}
+
+	var endedAt *time.Time
+	if row.EndedAt.Valid {
+		ended := row.EndedAt.Time
+		endedAt = &ended
+	}
+
+	return ImpersonationSession{
+		ID:           id,
+		TenantID:     tenantID,
+		ActorSubject: row.ActorSubject,
+		Reason:       row.Reason,
+		ExpiresAt:    row.ExpiresAt.Time,
+		EndedAt:      endedAt,
+		CreatedAt:    row.CreatedAt.Time,
+	}, nil
+}
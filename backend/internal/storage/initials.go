@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+var initialsArgs = func() pinyin.Args {
+	args := pinyin.NewArgs()
+	args.Style = pinyin.FirstLetter
+	return args
+}()
+
+// nameInitials returns the lowercase initials used to find members by abbreviation: the
+// pinyin initial of each Chinese character and the first letter or digit of every other
+// word, so "张三" becomes "zs" and "Li Lei" becomes "ll".
+func nameInitials(name string) *string {
+	var b strings.Builder
+	inWord := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if letters := pinyin.SinglePinyin(r, initialsArgs); len(letters) > 0 && letters[0] != "" {
+				b.WriteString(letters[0][:1])
+			}
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				b.WriteRune(r)
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	initials := b.String()
+	return &initials
+}
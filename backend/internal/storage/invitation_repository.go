@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// Invitation states. Expired invitations can be resent, which makes them pending again.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+var (
+	// ErrInvitationNotFound indicates the requested invitation does not exist.
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationState indicates the invitation is already accepted or revoked.
+	ErrInvitationState = errors.New("invitation state does not allow this operation")
+)
+
+// Invitation tracks a member invited by phone until they set up their account.
+type Invitation struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	GroupID     uuid.UUID  `json:"group_id"`
+	IdentityID  uuid.UUID  `json:"identity_id"`
+	Phone       string     `json:"phone"`
+	DisplayName string     `json:"display_name"`
+	Title       *string    `json:"title,omitempty"`
+	Roles       []string   `json:"roles"`
+	Status      string     `json:"status"`
+	SendCount   int32      `json:"send_count"`
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+	LastError   *string    `json:"last_error,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	InvitedBy   *string    `json:"invited_by,omitempty"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// InvitationRepository persists invitations and records their lifecycle.
+type InvitationRepository struct {
+	pool    *pgxpool.Pool
+	queries *sqldb.Queries
+}
+
+// NewInvitationRepository constructs a repository using sqlc generated queries.
+func NewInvitationRepository(pool *pgxpool.Pool, queries *sqldb.Queries) *InvitationRepository {
+	return &InvitationRepository{
+		pool:    pool,
+		queries: queries,
+	}
+}
+
+// CreateInvitation stores a pending invitation together with the membership and role
+// assignments it grants, queues their Keto tuples and records the user as invited. The
+// invitation lists the codes of roles.
+func (r *InvitationRepository) CreateInvitation(ctx context.Context, invitation Invitation, roles []Role) (Invitation, error) {
+	if invitation.ID == uuid.Nil {
+		invitation.ID = uuid.New()
+	}
+	invitation.Roles = make([]string, 0, len(roles))
+	for _, role := range roles {
+		invitation.Roles = append(invitation.Roles, role.Code)
+	}
+
+	var created Invitation
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		if _, err := createMember(ctx, qtx, GroupMember{
+			GroupID:     invitation.GroupID,
+			IdentityID:  invitation.IdentityID,
+			TenantID:    invitation.TenantID,
+			DisplayName: invitation.DisplayName,
+			Phone:       invitation.Phone,
+			Title:       invitation.Title,
+		}); err != nil {
+			return err
+		}
+		for _, role := range roles {
+			if err := upsertAssignment(ctx, qtx, role.ID, invitation.IdentityID, role.TenantID); err != nil {
+				return err
+			}
+		}
+
+		row, err := qtx.CreateInvitation(ctx, sqldb.CreateInvitationParams{
+			ID:          uuidToPg(invitation.ID),
+			TenantID:    uuidToPg(invitation.TenantID),
+			GroupID:     uuidToPg(invitation.GroupID),
+			IdentityID:  uuidToPg(invitation.IdentityID),
+			Phone:       strings.TrimSpace(invitation.Phone),
+			DisplayName: strings.TrimSpace(invitation.DisplayName),
+			Title:       invitation.Title,
+			Roles:       nonNilStrings(invitation.Roles),
+			ExpiresAt:   pgtype.Timestamptz{Time: invitation.ExpiresAt, Valid: true},
+			InvitedBy:   invitation.InvitedBy,
+		})
+		if err != nil {
+			return fmt.Errorf("create invitation: %w", err)
+		}
+		if created, err = mapInvitationRow(row); err != nil {
+			return err
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &created.TenantID,
+			Action:     EventUserInvited,
+			TargetType: "invitation",
+			TargetID:   created.ID.String(),
+			After:      created,
+		})
+	})
+	if err != nil {
+		return Invitation{}, err
+	}
+	return created, nil
+}
+
+// GetInvitation fetches an invitation by ID.
+func (r *InvitationRepository) GetInvitation(ctx context.Context, id uuid.UUID) (Invitation, error) {
+	row, err := r.queries.GetInvitation(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Invitation{}, ErrInvitationNotFound
+		}
+		return Invitation{}, fmt.Errorf("get invitation: %w", err)
+	}
+	return mapInvitationRow(row)
+}
+
+// ListInvitations returns the invitations of a tenant, newest first, optionally filtered by
+// state, with the total count. Pending invitations past their expiry are marked expired first.
+func (r *InvitationRepository) ListInvitations(ctx context.Context, tenantID uuid.UUID, status string, limit, offset int32) ([]Invitation, int64, error) {
+	if _, err := r.queries.ExpireInvitations(ctx, uuidToPg(tenantID)); err != nil {
+		return nil, 0, fmt.Errorf("expire invitations: %w", err)
+	}
+
+	var statusArg *string
+	if trimmed := strings.TrimSpace(status); trimmed != "" {
+		statusArg = stringPtr(trimmed)
+	}
+
+	rows, err := r.queries.ListInvitations(ctx, sqldb.ListInvitationsParams{
+		TenantID:    uuidToPg(tenantID),
+		Status:      statusArg,
+		LimitValue:  limit,
+		OffsetValue: offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list invitations: %w", err)
+	}
+	total, err := r.queries.CountInvitations(ctx, sqldb.CountInvitationsParams{
+		TenantID: uuidToPg(tenantID),
+		Status:   statusArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("count invitations: %w", err)
+	}
+
+	invitations := make([]Invitation, 0, len(rows))
+	for _, row := range rows {
+		invitation, err := mapInvitationRow(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, total, nil
+}
+
+// RecordDelivery counts a delivery attempt, storing the delivery error if any, and moves
+// the expiry to the lifetime of the newly issued code.
+func (r *InvitationRepository) RecordDelivery(ctx context.Context, id uuid.UUID, expiresAt time.Time, deliveryErr *string) (Invitation, error) {
+	row, err := r.queries.RecordInvitationDelivery(ctx, sqldb.RecordInvitationDeliveryParams{
+		ID:        uuidToPg(id),
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		LastError: deliveryErr,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Invitation{}, r.stateError(ctx, id)
+		}
+		return Invitation{}, fmt.Errorf("record invitation delivery: %w", err)
+	}
+	return mapInvitationRow(row)
+}
+
+// AcceptInvitation marks an open invitation as accepted.
+func (r *InvitationRepository) AcceptInvitation(ctx context.Context, id uuid.UUID) (Invitation, error) {
+	return r.close(ctx, id, EventInvitationAccepted, func(qtx *sqldb.Queries) (sqldb.Invitation, error) {
+		return qtx.AcceptInvitation(ctx, uuidToPg(id))
+	})
+}
+
+// RevokeInvitation marks an open invitation as revoked. The invitee never set up the
+// account, so the membership and the tenant role assignments of the identity are removed
+// in the same transaction and the removal of their Keto tuples is queued.
+func (r *InvitationRepository) RevokeInvitation(ctx context.Context, id uuid.UUID) (Invitation, error) {
+	return r.close(ctx, id, EventInvitationRevoked, func(qtx *sqldb.Queries) (sqldb.Invitation, error) {
+		row, err := qtx.RevokeInvitation(ctx, uuidToPg(id))
+		if err != nil {
+			return sqldb.Invitation{}, err
+		}
+		invitation, err := mapInvitationRow(row)
+		if err != nil {
+			return sqldb.Invitation{}, err
+		}
+		if err := revokeInvitationGrants(ctx, qtx, invitation); err != nil {
+			return sqldb.Invitation{}, err
+		}
+		return row, nil
+	})
+}
+
+func revokeInvitationGrants(ctx context.Context, q *sqldb.Queries, invitation Invitation) error {
+	if err := deleteMember(ctx, q, invitation.GroupID, invitation.IdentityID); err != nil {
+		return err
+	}
+	roles, err := q.ListIdentityRoleAssignments(ctx, sqldb.ListIdentityRoleAssignmentsParams{
+		IdentityID: uuidToPg(invitation.IdentityID),
+		TenantID:   uuidToPg(invitation.TenantID),
+	})
+	if err != nil {
+		return fmt.Errorf("list identity role assignments: %w", err)
+	}
+	for _, row := range roles {
+		roleID, _, err := pgUUIDToUUID(row.RoleID)
+		if err != nil {
+			return fmt.Errorf("parse role id: %w", err)
+		}
+		if err := deleteAssignment(ctx, q, roleID, invitation.IdentityID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *InvitationRepository) close(ctx context.Context, id uuid.UUID, action string, update func(qtx *sqldb.Queries) (sqldb.Invitation, error)) (Invitation, error) {
+	var closed Invitation
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		before, err := qtx.GetInvitation(ctx, uuidToPg(id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvitationNotFound
+			}
+			return fmt.Errorf("get invitation: %w", err)
+		}
+		beforeInvitation, err := mapInvitationRow(before)
+		if err != nil {
+			return err
+		}
+
+		row, err := update(qtx)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvitationState
+			}
+			return fmt.Errorf("update invitation: %w", err)
+		}
+		if closed, err = mapInvitationRow(row); err != nil {
+			return err
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &closed.TenantID,
+			Action:     action,
+			TargetType: "invitation",
+			TargetID:   closed.ID.String(),
+			Before:     beforeInvitation,
+			After:      closed,
+		})
+	})
+	if err != nil {
+		return Invitation{}, err
+	}
+	return closed, nil
+}
+
+// stateError distinguishes a missing invitation from one in the wrong state after a
+// conditional update matched no rows.
+func (r *InvitationRepository) stateError(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.GetInvitation(ctx, id); err != nil {
+		return err
+	}
+	return ErrInvitationState
+}
+
+func mapInvitationRow(row sqldb.Invitation) (Invitation, error) {
+	id, err := uuid.FromBytes(row.ID.Bytes[:])
+	if err != nil {
+		return Invitation{}, fmt.Errorf("parse invitation id: %w", err)
+	}
+	tenantID, err := uuid.FromBytes(row.TenantID.Bytes[:])
+	if err != nil {
+		return Invitation{}, fmt.Errorf("parse invitation tenant id: %w", err)
+	}
+	groupID, err := uuid.FromBytes(row.GroupID.Bytes[:])
+	if err != nil {
+		return Invitation{}, fmt.Errorf("parse invitation group id: %w", err)
+	}
+	identityID, err := uuid.FromBytes(row.IdentityID.Bytes[:])
+	if err != nil {
+		return Invitation{}, fmt.Errorf("parse invitation identity id: %w", err)
+	}
+
+	status := row.Status
+	// Listing expires stale invitations in bulk; single reads report the effective state.
+	if status == InvitationPending && row.ExpiresAt.Valid && row.ExpiresAt.Time.Before(time.Now()) {
+		status = InvitationExpired
+	}
+
+	return Invitation{
+		ID:          id,
+		TenantID:    tenantID,
+		GroupID:     groupID,
+		IdentityID:  identityID,
+		Phone:       row.Phone,
+		DisplayName: row.DisplayName,
+		Title:       row.Title,
+		Roles:       nonNilStrings(row.Roles),
+		Status:      status,
+		SendCount:   row.SendCount,
+		LastSentAt:  timestamptzPtr(row.LastSentAt),
+		LastError:   row.LastError,
+		ExpiresAt:   row.ExpiresAt.Time,
+		InvitedBy:   row.InvitedBy,
+		AcceptedAt:  timestamptzPtr(row.AcceptedAt),
+		RevokedAt:   timestamptzPtr(row.RevokedAt),
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}, nil
+}
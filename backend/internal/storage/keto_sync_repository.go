@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// Kinds of Keto sync entries. A group entry reconciles the parent link of a group, or
+// removes every tuple of a deleted group; a member entry reconciles one group membership
+// and a role member entry one role assignment.
+const (
+	KetoSyncGroup       = "group"
+	KetoSyncGroupMember = "group_member"
+	KetoSyncRoleMember  = "role_member"
+)
+
+// KetoSync is a claimed entry of the Keto sync queue. Entries name what to reconcile,
+// not the tuples to write, so the worker always applies the current database state.
+// GroupID is only set for group kinds and RoleID only for role members.
+type KetoSync struct {
+	ID         int64
+	Kind       string
+	TenantID   uuid.UUID
+	GroupID    uuid.UUID
+	RoleID     uuid.UUID
+	IdentityID *uuid.UUID
+	Generation int64
+	Attempts   int32
+}
+
+// GroupSyncKey is the queue key of the parent link and tuples of a group.
+func GroupSyncKey(groupID uuid.UUID) string {
+	return KetoSyncGroup + ":" + groupID.String()
+}
+
+// GroupMemberSyncKey is the queue key of the membership of an identity in a group.
+func GroupMemberSyncKey(groupID, identityID uuid.UUID) string {
+	return KetoSyncGroupMember + ":" + groupID.String() + ":" + identityID.String()
+}
+
+// RoleMemberSyncKey is the queue key of the assignment of a role to an identity.
+func RoleMemberSyncKey(roleID, identityID uuid.UUID) string {
+	return KetoSyncRoleMember + ":" + roleID.String() + ":" + identityID.String()
+}
+
+// KetoSyncRepository manages the queue of Keto tuples that still have to be reconciled
+// with the database.
+type KetoSyncRepository struct {
+	queries *sqldb.Queries
+}
+
+// NewKetoSyncRepository constructs a repository backed by sqlc queries.
+func NewKetoSyncRepository(queries *sqldb.Queries) *KetoSyncRepository {
+	return &KetoSyncRepository{queries: queries}
+}
+
+// Claim leases up to limit due entries for the given duration. When keys is not empty
+// only entries with those keys are claimed.
+func (r *KetoSyncRepository) Claim(ctx context.Context, limit int32, lease time.Duration, keys []string) ([]KetoSync, error) {
+	rows, err := r.queries.ClaimKetoSync(ctx, sqldb.ClaimKetoSyncParams{
+		LeaseSeconds: int32(lease / time.Second),
+		SyncKeys:     keys,
+		BatchSize:    limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim keto sync: %w", err)
+	}
+
+	result := make([]KetoSync, 0, len(rows))
+	for _, row := range rows {
+		tenantID, _, err := pgUUIDToUUID(row.TenantID)
+		if err != nil {
+			return nil, fmt.Errorf("parse keto sync tenant id: %w", err)
+		}
+		groupID, _, err := pgUUIDToUUID(row.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("parse keto sync group id: %w", err)
+		}
+		roleID, _, err := pgUUIDToUUID(row.RoleID)
+		if err != nil {
+			return nil, fmt.Errorf("parse keto sync role id: %w", err)
+		}
+		var identityID *uuid.UUID
+		if value, ok, err := pgUUIDToUUID(row.IdentityID); err != nil {
+			return nil, fmt.Errorf("parse keto sync identity id: %w", err)
+		} else if ok {
+			identityID = &value
+		}
+
+		result = append(result, KetoSync{
+			ID:         row.ID,
+			Kind:       row.Kind,
+			TenantID:   tenantID,
+			GroupID:    groupID,
+			RoleID:     roleID,
+			IdentityID: identityID,
+			Generation: row.Generation,
+			Attempts:   row.Attempts,
+		})
+	}
+	return result, nil
+}
+
+// Complete removes an entry once Keto matches the database. An entry that was enqueued
+// again while it was being applied is released instead, so the newer change is applied too.
+func (r *KetoSyncRepository) Complete(ctx context.Context, entry KetoSync) error {
+	deleted, err := r.queries.DeleteKetoSync(ctx, sqldb.DeleteKetoSyncParams{
+		ID:         entry.ID,
+		Generation: entry.Generation,
+	})
+	if err != nil {
+		return fmt.Errorf("delete keto sync: %w", err)
+	}
+	if deleted > 0 {
+		return nil
+	}
+	if err := r.queries.RetryKetoSync(ctx, sqldb.RetryKetoSyncParams{
+		Generation: entry.Generation,
+		ID:         entry.ID,
+	}); err != nil {
+		return fmt.Errorf("release keto sync: %w", err)
+	}
+	return nil
+}
+
+// Fail releases an entry after a failed attempt and schedules the next one.
+func (r *KetoSyncRepository) Fail(ctx context.Context, entry KetoSync, retryAt time.Time, cause error) error {
+	if err := r.queries.RetryKetoSync(ctx, sqldb.RetryKetoSyncParams{
+		LastError:     stringPtr(cause.Error()),
+		Generation:    entry.Generation,
+		NextAttemptAt: pgtype.Timestamptz{Time: retryAt, Valid: true},
+		ID:            entry.ID,
+	}); err != nil {
+		return fmt.Errorf("retry keto sync: %w", err)
+	}
+	return nil
+}
+
+// enqueueGroupSync queues the reconciliation of a group's parent link, or of all its
+// tuples when the group no longer exists.
+func enqueueGroupSync(ctx context.Context, q *sqldb.Queries, tenantID, groupID uuid.UUID) error {
+	if err := q.EnqueueKetoSync(ctx, sqldb.EnqueueKetoSyncParams{
+		SyncKey:  GroupSyncKey(groupID),
+		Kind:     KetoSyncGroup,
+		TenantID: uuidToPg(tenantID),
+		GroupID:  uuidToPg(groupID),
+	}); err != nil {
+		return fmt.Errorf("enqueue group keto sync: %w", err)
+	}
+	return nil
+}
+
+// enqueueGroupMemberSync queues the reconciliation of the membership of identityID in
+// groupID.
+func enqueueGroupMemberSync(ctx context.Context, q *sqldb.Queries, tenantID, groupID, identityID uuid.UUID) error {
+	if err := q.EnqueueKetoSync(ctx, sqldb.EnqueueKetoSyncParams{
+		SyncKey:    GroupMemberSyncKey(groupID, identityID),
+		Kind:       KetoSyncGroupMember,
+		TenantID:   uuidToPg(tenantID),
+		GroupID:    uuidToPg(groupID),
+		IdentityID: uuidToPg(identityID),
+	}); err != nil {
+		return fmt.Errorf("enqueue group member keto sync: %w", err)
+	}
+	return nil
+}
+
+// enqueueRoleMemberSync queues the reconciliation of the assignment of roleID to
+// identityID. Global roles have no tenant.
+func enqueueRoleMemberSync(ctx context.Context, q *sqldb.Queries, tenantID *uuid.UUID, roleID, identityID uuid.UUID) error {
+	if err := q.EnqueueKetoSync(ctx, sqldb.EnqueueKetoSyncParams{
+		SyncKey:    RoleMemberSyncKey(roleID, identityID),
+		Kind:       KetoSyncRoleMember,
+		TenantID:   uuidToNullablePg(tenantID),
+		IdentityID: uuidToPg(identityID),
+		RoleID:     uuidToPg(roleID),
+	}); err != nil {
+		return fmt.Errorf("enqueue role member keto sync: %w", err)
+	}
+	return nil
+}
+
+// enqueueIdentitySync queues the reconciliation of every membership and role assignment
+// of an identity in a tenant and returns the queued keys. The worker only grants tuples
+// to identities that are not disabled, so this is how disabling or enabling a user
+// reaches Keto.
+func enqueueIdentitySync(ctx context.Context, q *sqldb.Queries, tenantID, identityID uuid.UUID) ([]string, error) {
+	memberships, err := q.ListGroupsForIdentity(ctx, sqldb.ListGroupsForIdentityParams{
+		TenantID:   uuidToPg(tenantID),
+		IdentityID: uuidToPg(identityID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list groups for identity: %w", err)
+	}
+	roles, err := q.ListIdentityRoleAssignments(ctx, sqldb.ListIdentityRoleAssignmentsParams{
+		IdentityID: uuidToPg(identityID),
+		TenantID:   uuidToPg(tenantID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list identity role assignments: %w", err)
+	}
+
+	keys := make([]string, 0, len(memberships)+len(roles))
+	for _, membership := range memberships {
+		groupID, _, err := pgUUIDToUUID(membership.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("parse group id: %w", err)
+		}
+		if err := enqueueGroupMemberSync(ctx, q, tenantID, groupID, identityID); err != nil {
+			return nil, err
+		}
+		keys = append(keys, GroupMemberSyncKey(groupID, identityID))
+	}
+	for _, row := range roles {
+		role, err := mapIdentityRole(row.RoleID, row.RoleTenantID, row.RoleCode, row.RoleName)
+		if err != nil {
+			return nil, err
+		}
+		if err := enqueueRoleMemberSync(ctx, q, role.TenantID, role.RoleID, identityID); err != nil {
+			return nil, err
+		}
+		keys = append(keys, RoleMemberSyncKey(role.RoleID, identityID))
+	}
+	return keys, nil
+}
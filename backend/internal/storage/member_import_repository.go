@@ -0,0 +1,412 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// Member import states.
+const (
+	ImportPreviewed = "previewed"
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// Member import row states. Invalid rows failed validation during the preview and are
+// never processed.
+const (
+	ImportRowInvalid   = "invalid"
+	ImportRowPending   = "pending"
+	ImportRowSucceeded = "succeeded"
+	ImportRowFailed    = "failed"
+)
+
+var (
+	// ErrMemberImportNotFound indicates the requested import does not exist.
+	ErrMemberImportNotFound = errors.New("member import not found")
+	// ErrMemberImportState indicates the import is not in a state that allows the operation.
+	ErrMemberImportState = errors.New("member import state does not allow this operation")
+)
+
+// MemberImport is an uploaded member spreadsheet together with its processing progress.
+type MemberImport struct {
+	ID          uuid.UUID       `json:"id"`
+	TenantID    uuid.UUID       `json:"tenant_id"`
+	Filename    string          `json:"filename"`
+	Status      string          `json:"status"`
+	TotalRows   int32           `json:"total_rows"`
+	ValidRows   int32           `json:"valid_rows"`
+	Counts      ImportRowCounts `json:"counts"`
+	CreatedBy   *string         `json:"created_by,omitempty"`
+	CommittedBy *string         `json:"committed_by,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ImportRowCounts summarises rows of an import by state.
+type ImportRowCounts struct {
+	Invalid   int64 `json:"invalid"`
+	Pending   int64 `json:"pending"`
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+}
+
+// MemberImportRow is one spreadsheet row with its validation errors or processing result.
+type MemberImportRow struct {
+	RowNumber   int32      `json:"row_number"`
+	DisplayName string     `json:"display_name"`
+	Phone       string     `json:"phone"`
+	GroupCode   string     `json:"group_code"`
+	Title       *string    `json:"title,omitempty"`
+	Roles       []string   `json:"roles"`
+	Status      string     `json:"status"`
+	Errors      []string   `json:"errors"`
+	IdentityID  *uuid.UUID `json:"identity_id,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+// MemberImportRepository persists member imports and their rows.
+type MemberImportRepository struct {
+	pool    *pgxpool.Pool
+	queries *sqldb.Queries
+}
+
+// NewMemberImportRepository constructs a repository using sqlc generated queries.
+func NewMemberImportRepository(pool *pgxpool.Pool, queries *sqldb.Queries) *MemberImportRepository {
+	return &MemberImportRepository{
+		pool:    pool,
+		queries: queries,
+	}
+}
+
+// CreateImport stores a validated import in the previewed state together with all rows.
+func (r *MemberImportRepository) CreateImport(ctx context.Context, memberImport MemberImport, rows []MemberImportRow) (MemberImport, error) {
+	if memberImport.ID == uuid.Nil {
+		memberImport.ID = uuid.New()
+	}
+
+	var validRows int32
+	params := make([]sqldb.InsertMemberImportRowsParams, 0, len(rows))
+	for _, row := range rows {
+		if row.Status != ImportRowInvalid {
+			validRows++
+		}
+		params = append(params, sqldb.InsertMemberImportRowsParams{
+			ImportID:    uuidToPg(memberImport.ID),
+			RowNumber:   row.RowNumber,
+			DisplayName: row.DisplayName,
+			Phone:       row.Phone,
+			GroupCode:   row.GroupCode,
+			Title:       row.Title,
+			Roles:       nonNilStrings(row.Roles),
+			Status:      row.Status,
+			Errors:      nonNilStrings(row.Errors),
+		})
+	}
+
+	var created MemberImport
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		result, err := qtx.CreateMemberImport(ctx, sqldb.CreateMemberImportParams{
+			ID:        uuidToPg(memberImport.ID),
+			TenantID:  uuidToPg(memberImport.TenantID),
+			Filename:  strings.TrimSpace(memberImport.Filename),
+			TotalRows: int32(len(rows)),
+			ValidRows: validRows,
+			CreatedBy: memberImport.CreatedBy,
+		})
+		if err != nil {
+			return fmt.Errorf("create member import: %w", err)
+		}
+		if len(params) > 0 {
+			if _, err := qtx.InsertMemberImportRows(ctx, params); err != nil {
+				return fmt.Errorf("insert member import rows: %w", err)
+			}
+		}
+		created, err = mapMemberImportRow(result)
+		return err
+	})
+	if err != nil {
+		return MemberImport{}, err
+	}
+	created.Counts.Invalid = int64(created.TotalRows - created.ValidRows)
+	created.Counts.Pending = int64(created.ValidRows)
+	return created, nil
+}
+
+// GetImport fetches an import with up-to-date row counts.
+func (r *MemberImportRepository) GetImport(ctx context.Context, id uuid.UUID) (MemberImport, error) {
+	row, err := r.queries.GetMemberImport(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return MemberImport{}, ErrMemberImportNotFound
+		}
+		return MemberImport{}, fmt.Errorf("get member import: %w", err)
+	}
+	memberImport, err := mapMemberImportRow(row)
+	if err != nil {
+		return MemberImport{}, err
+	}
+	if memberImport.Counts, err = r.countRows(ctx, id); err != nil {
+		return MemberImport{}, err
+	}
+	return memberImport, nil
+}
+
+// ListImports returns the imports of a tenant, newest first, with the total count.
+func (r *MemberImportRepository) ListImports(ctx context.Context, tenantID uuid.UUID, limit, offset int32) ([]MemberImport, int64, error) {
+	rows, err := r.queries.ListMemberImports(ctx, sqldb.ListMemberImportsParams{
+		TenantID:    uuidToPg(tenantID),
+		LimitValue:  limit,
+		OffsetValue: offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list member imports: %w", err)
+	}
+	total, err := r.queries.CountMemberImports(ctx, uuidToPg(tenantID))
+	if err != nil {
+		return nil, 0, fmt.Errorf("count member imports: %w", err)
+	}
+
+	imports := make([]MemberImport, 0, len(rows))
+	for _, row := range rows {
+		memberImport, err := mapMemberImportRow(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		if memberImport.Counts, err = r.countRows(ctx, memberImport.ID); err != nil {
+			return nil, 0, err
+		}
+		imports = append(imports, memberImport)
+	}
+	return imports, total, nil
+}
+
+// ListRows returns rows of an import, optionally filtered by state, with the total count.
+func (r *MemberImportRepository) ListRows(ctx context.Context, importID uuid.UUID, status string, limit, offset int32) ([]MemberImportRow, int64, error) {
+	var statusArg *string
+	if trimmed := strings.TrimSpace(status); trimmed != "" {
+		statusArg = stringPtr(trimmed)
+	}
+
+	rows, err := r.queries.ListMemberImportRows(ctx, sqldb.ListMemberImportRowsParams{
+		ImportID:    uuidToPg(importID),
+		Status:      statusArg,
+		LimitValue:  limit,
+		OffsetValue: offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list member import rows: %w", err)
+	}
+	total, err := r.queries.CountMemberImportRows(ctx, sqldb.CountMemberImportRowsParams{
+		ImportID: uuidToPg(importID),
+		Status:   statusArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("count member import rows: %w", err)
+	}
+
+	result := make([]MemberImportRow, 0, len(rows))
+	for _, row := range rows {
+		mapped, err := mapMemberImportRowRow(row)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, mapped)
+	}
+	return result, total, nil
+}
+
+// CommitImport queues a previewed import for asynchronous processing.
+func (r *MemberImportRepository) CommitImport(ctx context.Context, id uuid.UUID, committedBy *string) (MemberImport, error) {
+	return r.queue(ctx, r.queries, id, ImportPreviewed, committedBy)
+}
+
+// ResumeImport re-queues a failed import, retrying only the rows that failed.
+func (r *MemberImportRepository) ResumeImport(ctx context.Context, id uuid.UUID, committedBy *string) (MemberImport, error) {
+	var resumed MemberImport
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		var err error
+		if resumed, err = r.queue(ctx, qtx, id, ImportFailed, committedBy); err != nil {
+			return err
+		}
+		if _, err := qtx.ResetFailedMemberImportRows(ctx, uuidToPg(id)); err != nil {
+			return fmt.Errorf("reset failed member import rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return MemberImport{}, err
+	}
+	return r.GetImport(ctx, resumed.ID)
+}
+
+func (r *MemberImportRepository) queue(ctx context.Context, q *sqldb.Queries, id uuid.UUID, from string, committedBy *string) (MemberImport, error) {
+	row, err := q.QueueMemberImport(ctx, sqldb.QueueMemberImportParams{
+		ID:          uuidToPg(id),
+		FromStatus:  from,
+		CommittedBy: committedBy,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, getErr := q.GetMemberImport(ctx, uuidToPg(id)); errors.Is(getErr, pgx.ErrNoRows) {
+				return MemberImport{}, ErrMemberImportNotFound
+			}
+			return MemberImport{}, ErrMemberImportState
+		}
+		return MemberImport{}, fmt.Errorf("queue member import: %w", err)
+	}
+	return mapMemberImportRow(row)
+}
+
+// ClaimImport leases the oldest queued import, or a running one whose worker stopped
+// renewing its lease. It returns nil when there is nothing to process.
+func (r *MemberImportRepository) ClaimImport(ctx context.Context, lease time.Duration) (*MemberImport, error) {
+	row, err := r.queries.ClaimMemberImport(ctx, int32(lease/time.Second))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim member import: %w", err)
+	}
+	memberImport, err := mapMemberImportRow(row)
+	if err != nil {
+		return nil, err
+	}
+	return &memberImport, nil
+}
+
+// ExtendLease keeps a running import claimed by the current worker.
+func (r *MemberImportRepository) ExtendLease(ctx context.Context, id uuid.UUID, lease time.Duration) error {
+	if err := r.queries.ExtendMemberImportLease(ctx, sqldb.ExtendMemberImportLeaseParams{
+		ID:           uuidToPg(id),
+		LeaseSeconds: int32(lease / time.Second),
+	}); err != nil {
+		return fmt.Errorf("extend member import lease: %w", err)
+	}
+	return nil
+}
+
+// PendingRows returns the next rows awaiting processing in file order.
+func (r *MemberImportRepository) PendingRows(ctx context.Context, importID uuid.UUID, limit int32) ([]MemberImportRow, error) {
+	rows, err := r.queries.ListPendingMemberImportRows(ctx, sqldb.ListPendingMemberImportRowsParams{
+		ImportID:   uuidToPg(importID),
+		LimitValue: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pending member import rows: %w", err)
+	}
+	result := make([]MemberImportRow, 0, len(rows))
+	for _, row := range rows {
+		mapped, err := mapMemberImportRowRow(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, mapped)
+	}
+	return result, nil
+}
+
+// CompleteRow records the outcome of processing one row.
+func (r *MemberImportRepository) CompleteRow(ctx context.Context, importID uuid.UUID, rowNumber int32, status string, rowErrors []string, identityID *uuid.UUID) error {
+	if err := r.queries.CompleteMemberImportRow(ctx, sqldb.CompleteMemberImportRowParams{
+		ImportID:   uuidToPg(importID),
+		RowNumber:  rowNumber,
+		Status:     status,
+		Errors:     nonNilStrings(rowErrors),
+		IdentityID: uuidToNullablePg(identityID),
+	}); err != nil {
+		return fmt.Errorf("complete member import row: %w", err)
+	}
+	return nil
+}
+
+// FinishImport moves a running import to its final state and releases the lease.
+func (r *MemberImportRepository) FinishImport(ctx context.Context, id uuid.UUID, status string) error {
+	if err := r.queries.FinishMemberImport(ctx, sqldb.FinishMemberImportParams{
+		ID:     uuidToPg(id),
+		Status: status,
+	}); err != nil {
+		return fmt.Errorf("finish member import: %w", err)
+	}
+	return nil
+}
+
+func (r *MemberImportRepository) countRows(ctx context.Context, importID uuid.UUID) (ImportRowCounts, error) {
+	rows, err := r.queries.CountMemberImportRowsByStatus(ctx, uuidToPg(importID))
+	if err != nil {
+		return ImportRowCounts{}, fmt.Errorf("count member import rows: %w", err)
+	}
+	var counts ImportRowCounts
+	for _, row := range rows {
+		switch row.Status {
+		case ImportRowInvalid:
+			counts.Invalid = row.RowCount
+		case ImportRowPending:
+			counts.Pending = row.RowCount
+		case ImportRowSucceeded:
+			counts.Succeeded = row.RowCount
+		case ImportRowFailed:
+			counts.Failed = row.RowCount
+		}
+	}
+	return counts, nil
+}
+
+func mapMemberImportRow(row sqldb.MemberImport) (MemberImport, error) {
+	id, err := uuid.FromBytes(row.ID.Bytes[:])
+	if err != nil {
+		return MemberImport{}, fmt.Errorf("parse member import id: %w", err)
+	}
+	tenantID, err := uuid.FromBytes(row.TenantID.Bytes[:])
+	if err != nil {
+		return MemberImport{}, fmt.Errorf("parse member import tenant id: %w", err)
+	}
+	return MemberImport{
+		ID:          id,
+		TenantID:    tenantID,
+		Filename:    row.Filename,
+		Status:      row.Status,
+		TotalRows:   row.TotalRows,
+		ValidRows:   row.ValidRows,
+		CreatedBy:   row.CreatedBy,
+		CommittedBy: row.CommittedBy,
+		StartedAt:   timestamptzPtr(row.StartedAt),
+		FinishedAt:  timestamptzPtr(row.FinishedAt),
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}, nil
+}
+
+func mapMemberImportRowRow(row sqldb.MemberImportRow) (MemberImportRow, error) {
+	var identityID *uuid.UUID
+	if id, ok, err := pgUUIDToUUID(row.IdentityID); err != nil {
+		return MemberImportRow{}, fmt.Errorf("parse member import identity id: %w", err)
+	} else if ok {
+		identityID = &id
+	}
+	return MemberImportRow{
+		RowNumber:   row.RowNumber,
+		DisplayName: row.DisplayName,
+		Phone:       row.Phone,
+		GroupCode:   row.GroupCode,
+		Title:       row.Title,
+		Roles:       nonNilStrings(row.Roles),
+		Status:      row.Status,
+		Errors:      nonNilStrings(row.Errors),
+		IdentityID:  identityID,
+		ProcessedAt: timestamptzPtr(row.ProcessedAt),
+	}, nil
+}
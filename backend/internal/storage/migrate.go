@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock held while migrations run, so replicas that
+// start together apply them one at a time.
+const migrationLockKey int64 = 7_301_845_602_918_004
+
+// NoVersion is the schema version of a database without any applied migration.
+const NoVersion = -1
+
+var (
+	// ErrSchemaDirty is returned when a previous migration failed halfway. The schema has
+	// to be repaired by hand and the version set with Force.
+	ErrSchemaDirty = errors.New("schema is dirty")
+	// ErrUnknownVersion is returned when the database is at a version this binary has no
+	// migration for, usually because a newer release already migrated it.
+	ErrUnknownVersion = errors.New("schema version is unknown to this build")
+)
+
+// Migration is one embedded schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// SchemaVersion describes the migration state of the database.
+type SchemaVersion struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+	Latest  int64 `json:"latest"`
+}
+
+// Pending reports whether embedded migrations have not been applied yet.
+func (v SchemaVersion) Pending() bool {
+	return v.Version < v.Latest
+}
+
+// Migrator applies the embedded migrations. The version is kept in the
+// schema_migrations table used by golang-migrate, so databases migrated by the
+// standalone tool carry on where it stopped.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator loads the embedded migrations.
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Migrations lists the embedded migrations in order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest returns the version of the newest embedded migration.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return NoVersion
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version reads the current schema version.
+func (m *Migrator) Version(ctx context.Context) (SchemaVersion, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return SchemaVersion{}, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	version, dirty, err := readVersion(ctx, conn.Conn())
+	if err != nil {
+		return SchemaVersion{}, err
+	}
+	return SchemaVersion{Version: version, Dirty: dirty, Latest: m.Latest()}, nil
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *pgx.Conn, version int64) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err := apply(ctx, conn, migration.up, migration.Version); err != nil {
+				return fmt.Errorf("apply %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts up to steps migrations, newest first, and returns the ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *pgx.Conn, version int64) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			previous := int64(NoVersion)
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := apply(ctx, conn, migration.down, previous); err != nil {
+				return fmt.Errorf("revert %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records version as applied and clears the dirty flag without running any
+// migration. NoVersion marks the database as empty.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != NoVersion && m.find(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if err := lockSchema(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	defer unlockSchema(conn.Conn())
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck
+
+	if err := writeVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// locked runs fn on a dedicated connection holding the migration lock, after checking
+// that the schema is clean and at a version this build knows.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgx.Conn, version int64) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if err := lockSchema(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	defer unlockSchema(conn.Conn())
+
+	version, dirty, err := readVersion(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrSchemaDirty, version)
+	}
+	if version != NoVersion && m.find(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return fn(conn.Conn(), version)
+}
+
+func (m *Migrator) find(version int64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// apply runs one migration script and records the resulting version in the same
+// transaction, so a failed script leaves the schema untouched.
+func apply(ctx context.Context, conn *pgx.Conn, script string, version int64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if err := writeVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func lockSchema(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`); err != nil {
+		unlockSchema(conn)
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// unlockSchema releases the migration lock. It does not use the caller's context so
+// the lock is released even after a cancellation.
+func unlockSchema(conn *pgx.Conn) {
+	_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+}
+
+func readVersion(ctx context.Context, conn *pgx.Conn) (int64, bool, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("look up schema_migrations: %w", err)
+	}
+	if !exists {
+		return NoVersion, false, nil
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NoVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+func writeVersion(ctx context.Context, tx pgx.Tx, version int64) error {
+	if _, err := tx.Exec(ctx, `TRUNCATE schema_migrations`); err != nil {
+		return fmt.Errorf("clear schema version: %w", err)
+	}
+	if version == NoVersion {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version); err != nil {
+		return fmt.Errorf("write schema version: %w", err)
+	}
+	return nil
+}
+
+// loadMigrations pairs the NNNNNN_name.up.sql and .down.sql files in dir.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if entry.IsDir() || !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("unexpected migration file %q", entry.Name())
+		}
+		rawVersion, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(rawVersion, 10, 64)
+		if err != nil || version < 0 {
+			return nil, fmt.Errorf("migration file %q has no version", entry.Name())
+		}
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.up = string(script)
+		} else {
+			migration.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission_code = 'impersonation.view';
+DELETE FROM permissions WHERE code = 'impersonation.view';
+DROP TABLE IF EXISTS impersonation_requests;
+DROP TABLE IF EXISTS impersonation_sessions;
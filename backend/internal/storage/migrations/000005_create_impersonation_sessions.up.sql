@@ -0,0 +1,32 @@
+CREATE TABLE impersonation_sessions (
+    id            UUID PRIMARY KEY,
+    tenant_id     UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    actor_subject TEXT NOT NULL,
+    reason        TEXT NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    ended_at      TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX impersonation_sessions_tenant_idx
+    ON impersonation_sessions (tenant_id, created_at DESC);
+
+CREATE INDEX impersonation_sessions_actor_idx
+    ON impersonation_sessions (actor_subject, created_at DESC);
+
+CREATE TABLE impersonation_requests (
+    id          BIGSERIAL PRIMARY KEY,
+    session_id  UUID NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+    method      TEXT NOT NULL,
+    path        TEXT NOT NULL,
+    status      INTEGER NOT NULL,
+    request_id  TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX impersonation_requests_session_idx
+    ON impersonation_requests (session_id, created_at DESC);
+
+INSERT INTO permissions (code, scope, description) VALUES
+    ('impersonation.view', 'tenant', '查看平台运维代管本租户的记录')
+ON CONFLICT (code) DO NOTHING;
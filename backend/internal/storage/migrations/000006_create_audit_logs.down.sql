@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission_code = 'audit.view';
+DELETE FROM permissions WHERE code = 'audit.view';
+DROP TABLE IF EXISTS audit_logs;
@@ -0,0 +1,29 @@
+CREATE TABLE audit_logs (
+    id               BIGSERIAL PRIMARY KEY,
+    tenant_id        UUID,
+    actor_subject    TEXT,
+    impersonation_id UUID,
+    action           TEXT NOT NULL,
+    target_type      TEXT NOT NULL,
+    target_id        TEXT NOT NULL,
+    before_state     JSONB,
+    after_state      JSONB,
+    request_id       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_logs_created_idx
+    ON audit_logs (created_at DESC, id DESC);
+
+CREATE INDEX audit_logs_tenant_idx
+    ON audit_logs (tenant_id, created_at DESC, id DESC);
+
+CREATE INDEX audit_logs_actor_idx
+    ON audit_logs (actor_subject, created_at DESC, id DESC);
+
+CREATE INDEX audit_logs_target_idx
+    ON audit_logs (target_type, target_id, created_at DESC, id DESC);
+
+INSERT INTO permissions (code, scope, description) VALUES
+    ('audit.view', 'any', '查看操作审计日志')
+ON CONFLICT (code) DO NOTHING;
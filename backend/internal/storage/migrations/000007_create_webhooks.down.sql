@@ -0,0 +1,6 @@
+DELETE FROM role_permissions WHERE permission_code = 'webhook.manage';
+DELETE FROM permissions WHERE code = 'webhook.manage';
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS domain_events;
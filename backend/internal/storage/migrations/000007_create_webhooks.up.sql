@@ -0,0 +1,64 @@
+CREATE TABLE domain_events (
+    id          UUID PRIMARY KEY,
+    tenant_id   UUID,
+    event_type  TEXT NOT NULL,
+    payload     JSONB NOT NULL,
+    request_id  TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX domain_events_tenant_idx
+    ON domain_events (tenant_id, occurred_at DESC);
+
+CREATE TABLE webhook_subscriptions (
+    id          UUID PRIMARY KEY,
+    tenant_id   UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    url         TEXT NOT NULL,
+    secret      TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_subscriptions_tenant_idx
+    ON webhook_subscriptions (tenant_id);
+
+CREATE TABLE webhook_deliveries (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id  UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id         UUID NOT NULL REFERENCES domain_events(id) ON DELETE CASCADE,
+    status           TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER,
+    last_error       TEXT,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx
+    ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'pending';
+
+CREATE INDEX webhook_deliveries_subscription_idx
+    ON webhook_deliveries (subscription_id, created_at DESC);
+
+CREATE TABLE webhook_delivery_attempts (
+    id          BIGSERIAL PRIMARY KEY,
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    status_code INTEGER,
+    error       TEXT,
+    duration_ms INTEGER NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_delivery_attempts_delivery_idx
+    ON webhook_delivery_attempts (delivery_id, created_at DESC);
+
+INSERT INTO permissions (code, scope, description) VALUES
+    ('webhook.manage', 'tenant', '管理租户事件订阅与投递')
+ON CONFLICT (code) DO NOTHING;
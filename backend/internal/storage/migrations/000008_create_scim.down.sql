@@ -0,0 +1,5 @@
+DELETE FROM role_permissions WHERE permission_code = 'scim.manage';
+DELETE FROM permissions WHERE code = 'scim.manage';
+DROP TABLE IF EXISTS scim_groups;
+DROP TABLE IF EXISTS scim_users;
+DROP TABLE IF EXISTS scim_tokens;
@@ -0,0 +1,49 @@
+CREATE TABLE scim_tokens (
+    id           UUID PRIMARY KEY,
+    tenant_id    UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    token_hash   TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    created_by   TEXT,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX scim_tokens_tenant_idx
+    ON scim_tokens (tenant_id, created_at DESC);
+
+CREATE TABLE scim_users (
+    tenant_id    UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    identity_id  UUID NOT NULL,
+    external_id  TEXT,
+    user_name    TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    phone        TEXT NOT NULL,
+    title        TEXT,
+    active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, identity_id),
+    UNIQUE (tenant_id, user_name)
+);
+
+CREATE UNIQUE INDEX scim_users_external_id_idx
+    ON scim_users (tenant_id, external_id)
+    WHERE external_id IS NOT NULL;
+
+CREATE TRIGGER trigger_set_scim_users_updated_at
+BEFORE UPDATE ON scim_users
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE scim_groups (
+    group_id    UUID PRIMARY KEY REFERENCES tenant_groups(id) ON DELETE CASCADE,
+    tenant_id   UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    external_id TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, external_id)
+);
+
+INSERT INTO permissions (code, scope, description) VALUES
+    ('scim.manage', 'tenant', '管理租户 SCIM 同步令牌')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission_code = 'member.import';
+DELETE FROM permissions WHERE code = 'member.import';
+DROP TABLE IF EXISTS member_import_rows;
+DROP TABLE IF EXISTS member_imports;
@@ -0,0 +1,48 @@
+CREATE TABLE member_imports (
+    id               UUID PRIMARY KEY,
+    tenant_id        UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    filename         TEXT NOT NULL,
+    status           TEXT NOT NULL DEFAULT 'previewed' CHECK (status IN ('previewed', 'queued', 'running', 'completed', 'failed')),
+    total_rows       INTEGER NOT NULL DEFAULT 0,
+    valid_rows       INTEGER NOT NULL DEFAULT 0,
+    created_by       TEXT,
+    committed_by     TEXT,
+    lease_expires_at TIMESTAMPTZ,
+    started_at       TIMESTAMPTZ,
+    finished_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX member_imports_tenant_idx
+    ON member_imports (tenant_id, created_at DESC);
+
+CREATE INDEX member_imports_queue_idx
+    ON member_imports (created_at)
+    WHERE status IN ('queued', 'running');
+
+CREATE TRIGGER trigger_set_member_imports_updated_at
+BEFORE UPDATE ON member_imports
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE member_import_rows (
+    import_id    UUID NOT NULL REFERENCES member_imports(id) ON DELETE CASCADE,
+    row_number   INTEGER NOT NULL,
+    display_name TEXT NOT NULL,
+    phone        TEXT NOT NULL,
+    group_code   TEXT NOT NULL,
+    title        TEXT,
+    roles        TEXT[] NOT NULL DEFAULT '{}',
+    status       TEXT NOT NULL CHECK (status IN ('invalid', 'pending', 'succeeded', 'failed')),
+    errors       TEXT[] NOT NULL DEFAULT '{}',
+    identity_id  UUID,
+    processed_at TIMESTAMPTZ,
+    PRIMARY KEY (import_id, row_number)
+);
+
+CREATE INDEX member_import_rows_status_idx
+    ON member_import_rows (import_id, status, row_number);
+
+INSERT INTO permissions (code, scope, description) VALUES
+    ('member.import', 'tenant', '批量导入租户成员')
+ON CONFLICT (code) DO NOTHING;
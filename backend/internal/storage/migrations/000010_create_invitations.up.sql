@@ -0,0 +1,31 @@
+CREATE TABLE invitations (
+    id           UUID PRIMARY KEY,
+    tenant_id    UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    group_id     UUID NOT NULL REFERENCES tenant_groups(id) ON DELETE CASCADE,
+    identity_id  UUID NOT NULL,
+    phone        TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    title        TEXT,
+    roles        TEXT[] NOT NULL DEFAULT '{}',
+    status       TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'expired', 'revoked')),
+    send_count   INTEGER NOT NULL DEFAULT 0,
+    last_sent_at TIMESTAMPTZ,
+    last_error   TEXT,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    invited_by   TEXT,
+    accepted_at  TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX invitations_tenant_idx
+    ON invitations (tenant_id, created_at DESC);
+
+CREATE UNIQUE INDEX invitations_open_identity_idx
+    ON invitations (identity_id)
+    WHERE status IN ('pending', 'expired');
+
+CREATE TRIGGER trigger_set_invitations_updated_at
+BEFORE UPDATE ON invitations
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
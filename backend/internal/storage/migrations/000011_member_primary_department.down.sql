@@ -0,0 +1 @@
+DROP INDEX IF EXISTS group_members_primary_idx;
@@ -0,0 +1,28 @@
+-- Keep the oldest primary membership when an identity has several in a tenant.
+UPDATE group_members gm
+SET is_primary = FALSE
+WHERE gm.is_primary
+  AND EXISTS (
+    SELECT 1
+    FROM group_members other
+    WHERE other.tenant_id = gm.tenant_id
+      AND other.identity_id = gm.identity_id
+      AND other.is_primary
+      AND (other.created_at, other.group_id) < (gm.created_at, gm.group_id)
+  );
+
+-- Promote the oldest membership of identities without a primary department.
+UPDATE group_members gm
+SET is_primary = TRUE
+WHERE NOT gm.is_primary
+  AND NOT EXISTS (
+    SELECT 1
+    FROM group_members other
+    WHERE other.tenant_id = gm.tenant_id
+      AND other.identity_id = gm.identity_id
+      AND (other.is_primary OR (other.created_at, other.group_id) < (gm.created_at, gm.group_id))
+  );
+
+CREATE UNIQUE INDEX group_members_primary_idx
+    ON group_members (tenant_id, identity_id)
+    WHERE is_primary;
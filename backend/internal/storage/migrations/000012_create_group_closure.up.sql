@@ -0,0 +1,24 @@
+-- Every ancestor/descendant pair of the group tree, including each group paired with itself
+-- at depth 0. Rows are maintained by the group repository on create and move and removed
+-- together with the groups they reference.
+CREATE TABLE group_closure (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    ancestor_id UUID NOT NULL REFERENCES tenant_groups(id) ON DELETE CASCADE,
+    descendant_id UUID NOT NULL REFERENCES tenant_groups(id) ON DELETE CASCADE,
+    depth INT NOT NULL CHECK (depth >= 0),
+    PRIMARY KEY (ancestor_id, descendant_id)
+);
+
+CREATE INDEX group_closure_descendant_idx ON group_closure (descendant_id, depth);
+
+WITH RECURSIVE paths AS (
+    SELECT g.tenant_id, g.id AS ancestor_id, g.id AS descendant_id, 0 AS depth
+    FROM tenant_groups g
+    UNION ALL
+    SELECT child.tenant_id, p.ancestor_id, child.id, p.depth + 1
+    FROM paths p
+    JOIN tenant_groups child ON child.parent_id = p.descendant_id
+)
+INSERT INTO group_closure (tenant_id, ancestor_id, descendant_id, depth)
+SELECT tenant_id, ancestor_id, descendant_id, depth
+FROM paths;
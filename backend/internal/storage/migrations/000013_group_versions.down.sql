@@ -0,0 +1,2 @@
+ALTER TABLE tenant_groups
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+-- Incremented on every change of a group so clients can detect concurrent edits.
+ALTER TABLE tenant_groups
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS group_members_name_initials_trgm_idx;
+DROP INDEX IF EXISTS group_members_title_trgm_idx;
+DROP INDEX IF EXISTS group_members_phone_trgm_idx;
+DROP INDEX IF EXISTS group_members_display_name_trgm_idx;
+
+ALTER TABLE group_members
+    DROP COLUMN IF EXISTS name_initials;
@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Lowercase pinyin initials of display_name (e.g. "zs" for 张三), maintained by the
+-- application. NULL marks rows that still need to be computed.
+ALTER TABLE group_members
+    ADD COLUMN name_initials TEXT;
+
+CREATE INDEX group_members_display_name_trgm_idx ON group_members USING gin (display_name gin_trgm_ops);
+CREATE INDEX group_members_phone_trgm_idx ON group_members USING gin (phone gin_trgm_ops);
+CREATE INDEX group_members_title_trgm_idx ON group_members USING gin (title gin_trgm_ops);
+CREATE INDEX group_members_name_initials_trgm_idx ON group_members USING gin (name_initials gin_trgm_ops);
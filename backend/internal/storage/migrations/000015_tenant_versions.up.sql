@@ -0,0 +1,3 @@
+-- Incremented on every change of a tenant so clients can detect concurrent edits.
+ALTER TABLE tenants
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,19 @@
+-- Responses of mutating requests sent with an Idempotency-Key header, replayed to retries.
+CREATE TABLE idempotency_keys (
+    scope                 TEXT NOT NULL,
+    idempotency_key       TEXT NOT NULL,
+    method                TEXT NOT NULL,
+    path                  TEXT NOT NULL,
+    fingerprint           TEXT NOT NULL,
+    status                TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed')),
+    response_status       INTEGER,
+    response_content_type TEXT,
+    response_body         BYTEA,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at          TIMESTAMPTZ,
+    expires_at            TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX idempotency_keys_expires_idx
+    ON idempotency_keys (expires_at);
@@ -0,0 +1,4 @@
+-- Impersonated requests are recorded before the handler runs; the status stays NULL
+-- until the handler has produced one.
+ALTER TABLE impersonation_requests
+    ALTER COLUMN status DROP NOT NULL;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS keto_sync_queue;
@@ -0,0 +1,21 @@
+-- Keto tuples that must be reconciled with the database. Rows are written in the same
+-- transaction as the change and removed once Keto matches, so a failed write is retried
+-- instead of leaving stale grants behind.
+CREATE TABLE keto_sync_queue (
+    id              BIGSERIAL PRIMARY KEY,
+    sync_key        TEXT NOT NULL UNIQUE,
+    kind            TEXT NOT NULL CHECK (kind IN ('group', 'group_member')),
+    tenant_id       UUID NOT NULL,
+    group_id        UUID NOT NULL,
+    identity_id     UUID,
+    generation      BIGINT NOT NULL DEFAULT 1,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX keto_sync_queue_due_idx
+    ON keto_sync_queue (next_attempt_at);
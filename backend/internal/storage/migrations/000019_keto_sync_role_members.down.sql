@@ -0,0 +1,9 @@
+DELETE FROM keto_sync_queue WHERE kind = 'role_member';
+
+ALTER TABLE keto_sync_queue
+    DROP CONSTRAINT keto_sync_queue_kind_check,
+    ADD CONSTRAINT keto_sync_queue_kind_check
+        CHECK (kind IN ('group', 'group_member')),
+    ALTER COLUMN group_id SET NOT NULL,
+    ALTER COLUMN tenant_id SET NOT NULL,
+    DROP COLUMN role_id;
@@ -0,0 +1,9 @@
+-- Role assignments are reconciled through the Keto sync queue as well. Their entries
+-- name a role instead of a group, and assignments of global roles have no tenant.
+ALTER TABLE keto_sync_queue
+    ADD COLUMN role_id UUID,
+    ALTER COLUMN tenant_id DROP NOT NULL,
+    ALTER COLUMN group_id DROP NOT NULL,
+    DROP CONSTRAINT keto_sync_queue_kind_check,
+    ADD CONSTRAINT keto_sync_queue_kind_check
+        CHECK (kind IN ('group', 'group_member', 'role_member'));
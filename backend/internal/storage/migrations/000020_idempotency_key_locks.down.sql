@@ -0,0 +1,5 @@
+DELETE FROM idempotency_keys WHERE status = 'pending' AND lock_token IS NULL;
+
+ALTER TABLE idempotency_keys
+    DROP COLUMN resource_id,
+    DROP COLUMN lock_token;
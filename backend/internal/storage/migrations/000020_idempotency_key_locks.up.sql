@@ -0,0 +1,7 @@
+-- lock_token identifies the request holding a pending key, so a request whose lock was
+-- taken over can no longer complete or release it. A pending key without a token has
+-- been released and can be claimed at once. resource_id remembers what a request that
+-- failed part way created, so a retry with the same key can resume from it.
+ALTER TABLE idempotency_keys
+    ADD COLUMN lock_token UUID,
+    ADD COLUMN resource_id TEXT;
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
 )
 
 // PoolConfig describes database connection settings.
@@ -44,3 +47,21 @@ func NewPool(ctx context.Context, cfg PoolConfig) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// runInTx executes fn with queries bound to a new transaction and commits when fn succeeds.
+func runInTx(ctx context.Context, pool *pgxpool.Pool, queries *sqldb.Queries, fn func(qtx *sqldb.Queries) error) error {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck
+
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+-- name: InsertAuditLog :exec
+INSERT INTO audit_logs (
+    tenant_id,
+    actor_subject,
+    impersonation_id,
+    action,
+    target_type,
+    target_id,
+    before_state,
+    after_state,
+    request_id
+) VALUES (
+    sqlc.narg(tenant_id),
+    sqlc.narg(actor_subject),
+    sqlc.narg(impersonation_id),
+    sqlc.arg(action),
+    sqlc.arg(target_type),
+    sqlc.arg(target_id),
+    sqlc.narg(before_state),
+    sqlc.narg(after_state),
+    sqlc.narg(request_id)
+);
+
+-- name: ListAuditLogs :many
+SELECT
+    id,
+    tenant_id,
+    actor_subject,
+    impersonation_id,
+    action,
+    target_type,
+    target_id,
+    before_state,
+    after_state,
+    request_id,
+    created_at
+FROM audit_logs
+WHERE
+    (sqlc.narg(tenant_filter)::uuid IS NULL OR tenant_id = sqlc.narg(tenant_filter)::uuid)
+    AND (sqlc.narg(actor_filter)::text IS NULL OR actor_subject = sqlc.narg(actor_filter)::text)
+    AND (sqlc.narg(action_filter)::text IS NULL OR action LIKE sqlc.narg(action_filter)::text || '%')
+    AND (sqlc.narg(target_type_filter)::text IS NULL OR target_type = sqlc.narg(target_type_filter)::text)
+    AND (sqlc.narg(target_id_filter)::text IS NULL OR target_id = sqlc.narg(target_id_filter)::text)
+    AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)::timestamptz)
+    AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)::timestamptz)
+    AND (
+        sqlc.narg(cursor_created_at)::timestamptz IS NULL
+        OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(limit_value)::int;
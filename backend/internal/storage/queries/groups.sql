@@ -9,7 +9,8 @@ SELECT
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenant_groups
 WHERE tenant_id = sqlc.arg(tenant_id)
 ORDER BY sort_order ASC, name ASC;
@@ -25,7 +26,8 @@ SELECT
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenant_groups
 ORDER BY tenant_id, sort_order ASC, name ASC;
 
@@ -40,7 +42,8 @@ SELECT
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenant_groups
 WHERE id = sqlc.arg(id);
 
@@ -74,7 +77,8 @@ RETURNING
     sort_order,
     metadata,
     created_at,
-    updated_at;
+    updated_at,
+    version;
 
 -- name: UpdateTenantGroup :one
 UPDATE tenant_groups
@@ -85,6 +89,7 @@ SET
     parent_id = sqlc.arg(parent_id),
     sort_order = COALESCE(sqlc.arg(sort_order)::int, sort_order),
     metadata = COALESCE(sqlc.arg(metadata)::jsonb, metadata),
+    version = version + 1,
     updated_at = NOW()
 WHERE id = sqlc.arg(id)
 RETURNING
@@ -97,7 +102,14 @@ RETURNING
     sort_order,
     metadata,
     created_at,
-    updated_at;
+    updated_at,
+    version;
+
+-- name: LockTenantGroupVersion :one
+SELECT version
+FROM tenant_groups
+WHERE id = sqlc.arg(id)
+FOR UPDATE;
 
 -- name: DeleteTenantGroup :exec
 DELETE FROM tenant_groups
@@ -138,7 +150,8 @@ SELECT
     title,
     is_primary,
     created_at,
-    updated_at
+    updated_at,
+    name_initials
 FROM group_members
 WHERE group_id = sqlc.arg(group_id)
   AND (
@@ -146,7 +159,11 @@ WHERE group_id = sqlc.arg(group_id)
       OR display_name ILIKE '%' || sqlc.narg(search)::text || '%'
       OR phone ILIKE '%' || sqlc.narg(search)::text || '%'
   )
-ORDER BY created_at DESC
+  AND (
+      sqlc.narg(cursor_created_at)::timestamptz IS NULL
+      OR (created_at, identity_id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+  )
+ORDER BY created_at DESC, identity_id DESC
 LIMIT COALESCE(sqlc.narg(limit_value)::int, 50)
 OFFSET COALESCE(sqlc.narg(offset_value)::int, 0);
 
@@ -168,7 +185,8 @@ INSERT INTO group_members (
     display_name,
     phone,
     title,
-    is_primary
+    is_primary,
+    name_initials
 ) VALUES (
     sqlc.arg(group_id),
     sqlc.arg(identity_id),
@@ -176,7 +194,8 @@ INSERT INTO group_members (
     sqlc.arg(display_name),
     sqlc.arg(phone),
     sqlc.arg(title),
-    COALESCE(sqlc.arg(is_primary)::boolean, FALSE)
+    COALESCE(sqlc.arg(is_primary)::boolean, FALSE),
+    sqlc.arg(name_initials)
 )
 ON CONFLICT (group_id, identity_id) DO UPDATE
 SET
@@ -184,6 +203,7 @@ SET
     phone = EXCLUDED.phone,
     title = EXCLUDED.title,
     is_primary = EXCLUDED.is_primary,
+    name_initials = EXCLUDED.name_initials,
     updated_at = NOW()
 RETURNING
     group_id,
@@ -194,7 +214,8 @@ RETURNING
     title,
     is_primary,
     created_at,
-    updated_at;
+    updated_at,
+    name_initials;
 
 -- name: UpdateGroupMember :one
 UPDATE group_members
@@ -203,6 +224,7 @@ SET
     phone = sqlc.arg(phone),
     title = sqlc.arg(title),
     is_primary = COALESCE(sqlc.arg(is_primary)::boolean, is_primary),
+    name_initials = sqlc.arg(name_initials),
     updated_at = NOW()
 WHERE group_id = sqlc.arg(group_id)
   AND identity_id = sqlc.arg(identity_id)
@@ -215,7 +237,8 @@ RETURNING
     title,
     is_primary,
     created_at,
-    updated_at;
+    updated_at,
+    name_initials;
 
 -- name: MoveGroupMember :one
 UPDATE group_members
@@ -234,7 +257,8 @@ RETURNING
     title,
     is_primary,
     created_at,
-    updated_at;
+    updated_at,
+    name_initials;
 
 -- name: DeleteGroupMember :exec
 DELETE FROM group_members
@@ -251,7 +275,445 @@ SELECT
     title,
     is_primary,
     created_at,
-    updated_at
+    updated_at,
+    name_initials
+FROM group_members
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND identity_id = sqlc.arg(identity_id)
+ORDER BY is_primary DESC, created_at ASC, group_id ASC;
+
+-- name: ListTenantMembers :many
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
 FROM group_members
 WHERE tenant_id = sqlc.arg(tenant_id)
+ORDER BY group_id, created_at ASC;
+
+-- name: GetGroupMember :one
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE group_id = sqlc.arg(group_id)
   AND identity_id = sqlc.arg(identity_id);
+
+-- name: UpdateMemberContact :many
+UPDATE group_members
+SET
+    display_name = sqlc.arg(display_name),
+    phone = sqlc.arg(phone),
+    name_initials = sqlc.arg(name_initials),
+    updated_at = NOW()
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND identity_id = sqlc.arg(identity_id)
+RETURNING
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials;
+
+-- name: ClearPrimaryMemberships :exec
+UPDATE group_members
+SET
+    is_primary = FALSE,
+    updated_at = NOW()
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND identity_id = sqlc.arg(identity_id)
+  AND group_id <> sqlc.arg(keep_group_id)
+  AND is_primary;
+
+-- name: PromotePrimaryMembership :one
+UPDATE group_members
+SET
+    is_primary = TRUE,
+    updated_at = NOW()
+WHERE (group_id, identity_id) = (
+    SELECT gm.group_id, gm.identity_id
+    FROM group_members gm
+    WHERE gm.tenant_id = sqlc.arg(tenant_id)
+      AND gm.identity_id = sqlc.arg(identity_id)
+    ORDER BY gm.created_at ASC, gm.group_id ASC
+    LIMIT 1
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM group_members p
+    WHERE p.tenant_id = sqlc.arg(tenant_id)
+      AND p.identity_id = sqlc.arg(identity_id)
+      AND p.is_primary
+)
+RETURNING
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials;
+
+-- name: LockTenantGroupTree :exec
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg(tenant_id)::uuid::text, 0));
+
+-- name: IsGroupInSubtree :one
+SELECT EXISTS (
+    SELECT 1
+    FROM group_closure
+    WHERE ancestor_id = sqlc.arg(root_id)::uuid
+      AND descendant_id = sqlc.arg(group_id)::uuid
+);
+
+-- name: NextGroupSortOrder :one
+SELECT (COALESCE(MAX(sort_order), -1) + 1)::int AS next_sort_order
+FROM tenant_groups
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND parent_id IS NOT DISTINCT FROM sqlc.narg(parent_id)::uuid;
+
+-- name: MoveTenantGroup :one
+UPDATE tenant_groups
+SET
+    parent_id = sqlc.narg(parent_id),
+    sort_order = sqlc.arg(sort_order),
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING
+    id,
+    tenant_id,
+    code,
+    name,
+    description,
+    parent_id,
+    sort_order,
+    metadata,
+    created_at,
+    updated_at,
+    version;
+
+-- name: InsertGroupClosure :exec
+INSERT INTO group_closure (tenant_id, ancestor_id, descendant_id, depth)
+SELECT sqlc.arg(tenant_id)::uuid, sqlc.arg(group_id)::uuid, sqlc.arg(group_id)::uuid, 0
+UNION ALL
+SELECT c.tenant_id, c.ancestor_id, sqlc.arg(group_id)::uuid, c.depth + 1
+FROM group_closure c
+WHERE c.descendant_id = sqlc.narg(parent_id)::uuid;
+
+-- name: DetachGroupSubtree :exec
+DELETE FROM group_closure
+WHERE descendant_id IN (
+    SELECT sub.descendant_id
+    FROM group_closure sub
+    WHERE sub.ancestor_id = sqlc.arg(group_id)::uuid
+)
+AND ancestor_id NOT IN (
+    SELECT sub.descendant_id
+    FROM group_closure sub
+    WHERE sub.ancestor_id = sqlc.arg(group_id)::uuid
+);
+
+-- name: AttachGroupSubtree :exec
+INSERT INTO group_closure (tenant_id, ancestor_id, descendant_id, depth)
+SELECT sub.tenant_id, super.ancestor_id, sub.descendant_id, super.depth + sub.depth + 1
+FROM group_closure super
+CROSS JOIN group_closure sub
+WHERE super.descendant_id = sqlc.arg(parent_id)::uuid
+  AND sub.ancestor_id = sqlc.arg(group_id)::uuid;
+
+-- name: ListGroupSubtree :many
+SELECT
+    g.id,
+    g.tenant_id,
+    g.code,
+    g.name,
+    g.description,
+    g.parent_id,
+    g.sort_order,
+    g.metadata,
+    g.created_at,
+    g.updated_at,
+    g.version
+FROM group_closure c
+JOIN tenant_groups g ON g.id = c.descendant_id
+WHERE c.ancestor_id = sqlc.arg(group_id)::uuid
+  AND (sqlc.narg(max_depth)::int IS NULL OR c.depth <= sqlc.narg(max_depth)::int)
+ORDER BY c.depth ASC, g.sort_order ASC, g.name ASC;
+
+-- name: ListGroupAncestors :many
+SELECT
+    g.id,
+    g.tenant_id,
+    g.code,
+    g.name,
+    g.description,
+    g.parent_id,
+    g.sort_order,
+    g.metadata,
+    g.created_at,
+    g.updated_at,
+    g.version
+FROM group_closure c
+JOIN tenant_groups g ON g.id = c.ancestor_id
+WHERE c.descendant_id = sqlc.arg(group_id)::uuid
+ORDER BY c.depth DESC;
+
+-- name: ListSubtreeMemberCounts :many
+SELECT
+    gm.group_id,
+    COUNT(*) AS member_count
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = sqlc.arg(group_id)::uuid
+GROUP BY gm.group_id;
+
+-- name: ListSubtreeMembers :many
+-- Lists each identity of the subtree once, through its primary membership when that lies
+-- in the subtree and otherwise through the membership closest to the root.
+SELECT
+    m.group_id,
+    m.identity_id,
+    m.tenant_id,
+    m.display_name,
+    m.phone,
+    m.title,
+    m.is_primary,
+    m.created_at,
+    m.updated_at,
+    m.name_initials
+FROM (
+    SELECT DISTINCT ON (gm.identity_id)
+        gm.group_id,
+        gm.identity_id,
+        gm.tenant_id,
+        gm.display_name,
+        gm.phone,
+        gm.title,
+        gm.is_primary,
+        gm.created_at,
+        gm.updated_at,
+        gm.name_initials
+    FROM group_closure c
+    JOIN group_members gm ON gm.group_id = c.descendant_id
+    WHERE c.ancestor_id = sqlc.arg(group_id)::uuid
+      AND (
+          sqlc.narg(search)::text IS NULL
+          OR gm.display_name ILIKE '%' || sqlc.narg(search)::text || '%'
+          OR gm.phone ILIKE '%' || sqlc.narg(search)::text || '%'
+      )
+    ORDER BY gm.identity_id, gm.is_primary DESC, c.depth ASC, gm.created_at ASC
+) m
+ORDER BY m.created_at DESC, m.identity_id ASC
+LIMIT COALESCE(sqlc.narg(limit_value)::int, 50)
+OFFSET COALESCE(sqlc.narg(offset_value)::int, 0);
+
+-- name: CountSubtreeMembers :one
+SELECT COUNT(DISTINCT gm.identity_id)
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = sqlc.arg(group_id)::uuid
+  AND (
+      sqlc.narg(search)::text IS NULL
+      OR gm.display_name ILIKE '%' || sqlc.narg(search)::text || '%'
+      OR gm.phone ILIKE '%' || sqlc.narg(search)::text || '%'
+  );
+
+-- name: ListChildGroups :many
+SELECT
+    id,
+    tenant_id,
+    code,
+    name,
+    description,
+    parent_id,
+    sort_order,
+    metadata,
+    created_at,
+    updated_at,
+    version
+FROM tenant_groups
+WHERE parent_id = sqlc.arg(parent_id)
+ORDER BY sort_order ASC, name ASC;
+
+-- name: ListAllGroupMembers :many
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE group_id = sqlc.arg(group_id)
+ORDER BY created_at ASC, identity_id ASC;
+
+-- name: ListSubtreeMemberships :many
+SELECT
+    gm.group_id,
+    gm.identity_id,
+    gm.tenant_id,
+    gm.display_name,
+    gm.phone,
+    gm.title,
+    gm.is_primary,
+    gm.created_at,
+    gm.updated_at,
+    gm.name_initials
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = sqlc.arg(group_id)::uuid
+ORDER BY c.depth ASC, gm.group_id ASC, gm.created_at ASC;
+
+-- name: ListSubtreeOnlyIdentities :many
+-- Identities whose memberships in the tenant all lie within the subtree of the group.
+SELECT DISTINCT gm.identity_id
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = sqlc.arg(group_id)::uuid
+  AND NOT EXISTS (
+      SELECT 1
+      FROM group_members other
+      WHERE other.tenant_id = gm.tenant_id
+        AND other.identity_id = gm.identity_id
+        AND other.group_id NOT IN (
+            SELECT sub.descendant_id
+            FROM group_closure sub
+            WHERE sub.ancestor_id = sqlc.arg(group_id)::uuid
+        )
+  )
+ORDER BY gm.identity_id;
+
+-- name: ReassignGroupInvitations :exec
+UPDATE invitations
+SET
+    group_id = sqlc.arg(new_group_id),
+    updated_at = NOW()
+WHERE group_id = sqlc.arg(group_id);
+
+-- name: ListMembersMissingInitials :many
+SELECT
+    group_id,
+    identity_id,
+    display_name
+FROM group_members
+WHERE name_initials IS NULL
+ORDER BY group_id, identity_id
+LIMIT sqlc.arg(limit_value)::int;
+
+-- name: SetMemberNameInitials :exec
+UPDATE group_members
+SET name_initials = sqlc.arg(name_initials)
+WHERE group_id = sqlc.arg(group_id)
+  AND identity_id = sqlc.arg(identity_id);
+
+-- name: SearchDirectoryMembers :many
+-- One row per identity matching the search in any of its memberships, represented by its
+-- primary membership when it has one.
+SELECT
+    m.group_id,
+    m.identity_id,
+    m.tenant_id,
+    m.display_name,
+    m.phone,
+    m.title,
+    m.is_primary,
+    m.created_at,
+    m.updated_at,
+    m.name_initials
+FROM (
+    SELECT DISTINCT ON (gm.identity_id)
+        gm.group_id,
+        gm.identity_id,
+        gm.tenant_id,
+        gm.display_name,
+        gm.phone,
+        gm.title,
+        gm.is_primary,
+        gm.created_at,
+        gm.updated_at,
+        gm.name_initials
+    FROM group_members gm
+    WHERE gm.tenant_id = sqlc.arg(tenant_id)
+      AND gm.identity_id IN (
+          SELECT hit.identity_id
+          FROM group_members hit
+          WHERE hit.tenant_id = sqlc.arg(tenant_id)
+            AND (
+                sqlc.narg(search)::text IS NULL
+                OR hit.display_name ILIKE '%' || sqlc.narg(search)::text || '%'
+                OR hit.phone ILIKE '%' || sqlc.narg(search)::text || '%'
+                OR hit.title ILIKE '%' || sqlc.narg(search)::text || '%'
+                OR hit.name_initials ILIKE '%' || sqlc.narg(search)::text || '%'
+            )
+      )
+    ORDER BY gm.identity_id, gm.is_primary DESC, gm.created_at ASC
+) m
+ORDER BY m.display_name ASC, m.identity_id ASC
+LIMIT sqlc.arg(limit_value)::int
+OFFSET sqlc.arg(offset_value)::int;
+
+-- name: CountDirectoryMembers :one
+SELECT COUNT(DISTINCT hit.identity_id)
+FROM group_members hit
+WHERE hit.tenant_id = sqlc.arg(tenant_id)
+  AND (
+      sqlc.narg(search)::text IS NULL
+      OR hit.display_name ILIKE '%' || sqlc.narg(search)::text || '%'
+      OR hit.phone ILIKE '%' || sqlc.narg(search)::text || '%'
+      OR hit.title ILIKE '%' || sqlc.narg(search)::text || '%'
+      OR hit.name_initials ILIKE '%' || sqlc.narg(search)::text || '%'
+  );
+
+-- name: ListMembershipsForIdentities :many
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND identity_id = ANY(sqlc.arg(identity_ids)::uuid[])
+ORDER BY identity_id, is_primary DESC, created_at ASC;
+
+-- name: ListGroupPaths :many
+SELECT
+    c.descendant_id AS group_id,
+    g.id AS ancestor_id,
+    g.name AS ancestor_name
+FROM group_closure c
+JOIN tenant_groups g ON g.id = c.ancestor_id
+WHERE c.descendant_id = ANY(sqlc.arg(group_ids)::uuid[])
+ORDER BY c.descendant_id, c.depth DESC;
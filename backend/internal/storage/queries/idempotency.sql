@@ -0,0 +1,117 @@
+-- name: ClaimIdempotencyKey :one
+-- Inserts a pending key, or takes over an expired key, a released key or one whose request
+-- was abandoned while pending. The resource recorded against a pending key is kept for a
+-- retry of the same request. Returns no row when the key is held by another request.
+INSERT INTO idempotency_keys (
+    scope,
+    idempotency_key,
+    method,
+    path,
+    fingerprint,
+    expires_at,
+    lock_token
+) VALUES (
+    sqlc.arg(scope),
+    sqlc.arg(idempotency_key),
+    sqlc.arg(method),
+    sqlc.arg(path),
+    sqlc.arg(fingerprint),
+    sqlc.arg(expires_at),
+    sqlc.arg(lock_token)
+)
+ON CONFLICT (scope, idempotency_key) DO UPDATE
+SET
+    method = EXCLUDED.method,
+    path = EXCLUDED.path,
+    fingerprint = EXCLUDED.fingerprint,
+    status = 'pending',
+    response_status = NULL,
+    response_content_type = NULL,
+    response_body = NULL,
+    created_at = NOW(),
+    completed_at = NULL,
+    expires_at = EXCLUDED.expires_at,
+    lock_token = EXCLUDED.lock_token,
+    resource_id = CASE
+        WHEN idempotency_keys.status = 'pending'
+         AND idempotency_keys.expires_at >= NOW()
+         AND idempotency_keys.fingerprint = EXCLUDED.fingerprint
+        THEN idempotency_keys.resource_id
+    END
+WHERE idempotency_keys.expires_at < NOW()
+   OR (
+       idempotency_keys.status = 'pending'
+       AND (
+           idempotency_keys.lock_token IS NULL
+           OR idempotency_keys.created_at < sqlc.arg(stale_before)::timestamptz
+       )
+   )
+RETURNING
+    scope,
+    idempotency_key,
+    method,
+    path,
+    fingerprint,
+    status,
+    response_status,
+    response_content_type,
+    response_body,
+    created_at,
+    completed_at,
+    expires_at,
+    lock_token,
+    resource_id;
+
+-- name: GetIdempotencyKey :one
+SELECT
+    scope,
+    idempotency_key,
+    method,
+    path,
+    fingerprint,
+    status,
+    response_status,
+    response_content_type,
+    response_body,
+    created_at,
+    completed_at,
+    expires_at,
+    lock_token,
+    resource_id
+FROM idempotency_keys
+WHERE scope = sqlc.arg(scope)
+  AND idempotency_key = sqlc.arg(idempotency_key);
+
+-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET
+    status = 'completed',
+    response_status = sqlc.arg(response_status),
+    response_content_type = sqlc.narg(response_content_type),
+    response_body = sqlc.arg(response_body),
+    completed_at = NOW()
+WHERE scope = sqlc.arg(scope)
+  AND idempotency_key = sqlc.arg(idempotency_key)
+  AND status = 'pending'
+  AND lock_token = sqlc.arg(lock_token);
+
+-- name: ReleaseIdempotencyKey :execrows
+-- Unlocks a pending key, keeping its row so a recorded resource survives for the retry.
+UPDATE idempotency_keys
+SET lock_token = NULL
+WHERE scope = sqlc.arg(scope)
+  AND idempotency_key = sqlc.arg(idempotency_key)
+  AND status = 'pending'
+  AND lock_token = sqlc.arg(lock_token);
+
+-- name: SetIdempotencyResource :execrows
+UPDATE idempotency_keys
+SET resource_id = sqlc.arg(resource_id)
+WHERE scope = sqlc.arg(scope)
+  AND idempotency_key = sqlc.arg(idempotency_key)
+  AND status = 'pending'
+  AND lock_token = sqlc.arg(lock_token);
+
+-- name: PurgeExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < NOW();
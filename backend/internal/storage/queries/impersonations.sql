@@ -0,0 +1,125 @@
+-- name: CreateImpersonationSession :one
+INSERT INTO impersonation_sessions (
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at
+) VALUES (
+    sqlc.arg(id),
+    sqlc.arg(tenant_id),
+    sqlc.arg(actor_subject),
+    sqlc.arg(reason),
+    sqlc.arg(expires_at)
+)
+RETURNING
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at,
+    ended_at,
+    created_at;
+
+-- name: GetImpersonationSession :one
+SELECT
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at,
+    ended_at,
+    created_at
+FROM impersonation_sessions
+WHERE id = sqlc.arg(id);
+
+-- name: ListImpersonationSessions :many
+SELECT
+    s.id,
+    s.tenant_id,
+    s.actor_subject,
+    s.reason,
+    s.expires_at,
+    s.ended_at,
+    s.created_at,
+    COALESCE(r.request_count, 0)::bigint AS request_count
+FROM impersonation_sessions s
+LEFT JOIN (
+    SELECT session_id, COUNT(*) AS request_count
+    FROM impersonation_requests
+    GROUP BY session_id
+) r ON r.session_id = s.id
+WHERE
+    (sqlc.narg(tenant_filter)::uuid IS NULL OR s.tenant_id = sqlc.narg(tenant_filter)::uuid)
+    AND (sqlc.narg(actor_filter)::text IS NULL OR s.actor_subject = sqlc.narg(actor_filter)::text)
+    AND (
+        NOT sqlc.arg(active_only)::boolean
+        OR (s.ended_at IS NULL AND s.expires_at > NOW())
+    )
+ORDER BY s.created_at DESC
+LIMIT COALESCE(sqlc.narg(limit_value)::int, 50)
+OFFSET COALESCE(sqlc.narg(offset_value)::int, 0);
+
+-- name: CountImpersonationSessions :one
+SELECT COUNT(*) AS total
+FROM impersonation_sessions s
+WHERE
+    (sqlc.narg(tenant_filter)::uuid IS NULL OR s.tenant_id = sqlc.narg(tenant_filter)::uuid)
+    AND (sqlc.narg(actor_filter)::text IS NULL OR s.actor_subject = sqlc.narg(actor_filter)::text)
+    AND (
+        NOT sqlc.arg(active_only)::boolean
+        OR (s.ended_at IS NULL AND s.expires_at > NOW())
+    );
+
+-- name: EndImpersonationSession :one
+UPDATE impersonation_sessions
+SET ended_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND ended_at IS NULL
+RETURNING
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at,
+    ended_at,
+    created_at;
+
+-- name: InsertImpersonationRequest :one
+INSERT INTO impersonation_requests (
+    session_id,
+    method,
+    path,
+    request_id
+) VALUES (
+    sqlc.arg(session_id),
+    sqlc.arg(method),
+    sqlc.arg(path),
+    sqlc.narg(request_id)
+)
+RETURNING id;
+
+-- name: CompleteImpersonationRequest :exec
+UPDATE impersonation_requests
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id);
+
+-- name: ListImpersonationRequests :many
+SELECT
+    id,
+    session_id,
+    method,
+    path,
+    status,
+    request_id,
+    created_at
+FROM impersonation_requests
+WHERE session_id = sqlc.arg(session_id)
+ORDER BY created_at DESC, id DESC
+LIMIT COALESCE(sqlc.narg(limit_value)::int, 50)
+OFFSET COALESCE(sqlc.narg(offset_value)::int, 0);
+
+-- name: CountImpersonationRequests :one
+SELECT COUNT(*) AS total
+FROM impersonation_requests
+WHERE session_id = sqlc.arg(session_id);
@@ -0,0 +1,195 @@
+-- name: CreateInvitation :one
+INSERT INTO invitations (
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    expires_at,
+    invited_by
+) VALUES (
+    sqlc.arg(id),
+    sqlc.arg(tenant_id),
+    sqlc.arg(group_id),
+    sqlc.arg(identity_id),
+    sqlc.arg(phone),
+    sqlc.arg(display_name),
+    sqlc.narg(title),
+    sqlc.arg(roles),
+    sqlc.arg(expires_at),
+    sqlc.narg(invited_by)
+)
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at;
+
+-- name: GetInvitation :one
+SELECT
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+FROM invitations
+WHERE id = sqlc.arg(id);
+
+-- name: ListInvitations :many
+SELECT
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+FROM invitations
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(limit_value)::int
+OFFSET sqlc.arg(offset_value)::int;
+
+-- name: CountInvitations :one
+SELECT COUNT(*)
+FROM invitations
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text);
+
+-- name: ExpireInvitations :execrows
+UPDATE invitations
+SET
+    status = 'expired',
+    updated_at = NOW()
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND status = 'pending'
+  AND expires_at < NOW();
+
+-- name: RecordInvitationDelivery :one
+UPDATE invitations
+SET
+    status = 'pending',
+    send_count = send_count + 1,
+    last_sent_at = NOW(),
+    last_error = sqlc.narg(last_error),
+    expires_at = sqlc.arg(expires_at),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status IN ('pending', 'expired')
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at;
+
+-- name: AcceptInvitation :one
+UPDATE invitations
+SET
+    status = 'accepted',
+    accepted_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status IN ('pending', 'expired')
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at;
+
+-- name: RevokeInvitation :one
+UPDATE invitations
+SET
+    status = 'revoked',
+    revoked_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status IN ('pending', 'expired')
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at;
@@ -0,0 +1,68 @@
+-- name: EnqueueKetoSync :exec
+INSERT INTO keto_sync_queue (
+    sync_key,
+    kind,
+    tenant_id,
+    group_id,
+    identity_id,
+    role_id
+) VALUES (
+    sqlc.arg(sync_key),
+    sqlc.arg(kind),
+    sqlc.narg(tenant_id),
+    sqlc.narg(group_id),
+    sqlc.narg(identity_id),
+    sqlc.narg(role_id)
+)
+ON CONFLICT (sync_key) DO UPDATE
+SET
+    generation = keto_sync_queue.generation + 1,
+    attempts = 0,
+    next_attempt_at = NOW(),
+    updated_at = NOW();
+
+-- name: ClaimKetoSync :many
+UPDATE keto_sync_queue q
+SET
+    locked_until = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second'),
+    attempts = q.attempts + 1,
+    updated_at = NOW()
+WHERE q.id IN (
+    SELECT p.id
+    FROM keto_sync_queue p
+    WHERE p.next_attempt_at <= NOW()
+      AND (p.locked_until IS NULL OR p.locked_until <= NOW())
+      AND (
+          sqlc.narg(sync_keys)::text[] IS NULL
+          OR p.sync_key = ANY(sqlc.narg(sync_keys)::text[])
+      )
+    ORDER BY p.next_attempt_at, p.id
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING
+    q.id,
+    q.kind,
+    q.tenant_id,
+    q.group_id,
+    q.identity_id,
+    q.role_id,
+    q.generation,
+    q.attempts;
+
+-- name: DeleteKetoSync :execrows
+DELETE FROM keto_sync_queue
+WHERE id = sqlc.arg(id)
+  AND generation = sqlc.arg(generation);
+
+-- name: RetryKetoSync :exec
+UPDATE keto_sync_queue
+SET
+    locked_until = NULL,
+    last_error = sqlc.narg(last_error),
+    next_attempt_at = CASE
+        WHEN generation = sqlc.arg(generation) THEN sqlc.arg(next_attempt_at)::timestamptz
+        ELSE next_attempt_at
+    END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
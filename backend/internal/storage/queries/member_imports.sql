@@ -0,0 +1,241 @@
+-- name: CreateMemberImport :one
+INSERT INTO member_imports (
+    id,
+    tenant_id,
+    filename,
+    total_rows,
+    valid_rows,
+    created_by
+) VALUES (
+    sqlc.arg(id),
+    sqlc.arg(tenant_id),
+    sqlc.arg(filename),
+    sqlc.arg(total_rows),
+    sqlc.arg(valid_rows),
+    sqlc.narg(created_by)
+)
+RETURNING
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at;
+
+-- name: InsertMemberImportRows :copyfrom
+INSERT INTO member_import_rows (
+    import_id,
+    row_number,
+    display_name,
+    phone,
+    group_code,
+    title,
+    roles,
+    status,
+    errors
+) VALUES (
+    sqlc.arg(import_id),
+    sqlc.arg(row_number),
+    sqlc.arg(display_name),
+    sqlc.arg(phone),
+    sqlc.arg(group_code),
+    sqlc.arg(title),
+    sqlc.arg(roles),
+    sqlc.arg(status),
+    sqlc.arg(errors)
+);
+
+-- name: GetMemberImport :one
+SELECT
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at
+FROM member_imports
+WHERE id = sqlc.arg(id);
+
+-- name: ListMemberImports :many
+SELECT
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at
+FROM member_imports
+WHERE tenant_id = sqlc.arg(tenant_id)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(limit_value)::int
+OFFSET sqlc.arg(offset_value)::int;
+
+-- name: CountMemberImports :one
+SELECT COUNT(*)
+FROM member_imports
+WHERE tenant_id = sqlc.arg(tenant_id);
+
+-- name: CountMemberImportRowsByStatus :many
+SELECT
+    status,
+    COUNT(*) AS row_count
+FROM member_import_rows
+WHERE import_id = sqlc.arg(import_id)
+GROUP BY status;
+
+-- name: ListMemberImportRows :many
+SELECT
+    import_id,
+    row_number,
+    display_name,
+    phone,
+    group_code,
+    title,
+    roles,
+    status,
+    errors,
+    identity_id,
+    processed_at
+FROM member_import_rows
+WHERE import_id = sqlc.arg(import_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY row_number ASC
+LIMIT sqlc.arg(limit_value)::int
+OFFSET sqlc.arg(offset_value)::int;
+
+-- name: CountMemberImportRows :one
+SELECT COUNT(*)
+FROM member_import_rows
+WHERE import_id = sqlc.arg(import_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text);
+
+-- name: QueueMemberImport :one
+UPDATE member_imports
+SET
+    status = 'queued',
+    committed_by = sqlc.narg(committed_by),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status = sqlc.arg(from_status)
+RETURNING
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at;
+
+-- name: ResetFailedMemberImportRows :execrows
+UPDATE member_import_rows
+SET
+    status = 'pending',
+    errors = '{}',
+    processed_at = NULL
+WHERE import_id = sqlc.arg(import_id)
+  AND status = 'failed';
+
+-- name: ClaimMemberImport :one
+UPDATE member_imports
+SET
+    status = 'running',
+    started_at = COALESCE(started_at, NOW()),
+    finished_at = NULL,
+    lease_expires_at = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE id = (
+    SELECT q.id
+    FROM member_imports q
+    WHERE q.status = 'queued'
+       OR (q.status = 'running' AND q.lease_expires_at < NOW())
+    ORDER BY q.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at;
+
+-- name: ExtendMemberImportLease :exec
+UPDATE member_imports
+SET
+    lease_expires_at = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status = 'running';
+
+-- name: ListPendingMemberImportRows :many
+SELECT
+    import_id,
+    row_number,
+    display_name,
+    phone,
+    group_code,
+    title,
+    roles,
+    status,
+    errors,
+    identity_id,
+    processed_at
+FROM member_import_rows
+WHERE import_id = sqlc.arg(import_id)
+  AND status = 'pending'
+ORDER BY row_number ASC
+LIMIT sqlc.arg(limit_value)::int;
+
+-- name: CompleteMemberImportRow :exec
+UPDATE member_import_rows
+SET
+    status = sqlc.arg(status),
+    errors = sqlc.arg(errors),
+    identity_id = sqlc.narg(identity_id),
+    processed_at = NOW()
+WHERE import_id = sqlc.arg(import_id)
+  AND row_number = sqlc.arg(row_number);
+
+-- name: FinishMemberImport :exec
+UPDATE member_imports
+SET
+    status = sqlc.arg(status),
+    lease_expires_at = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
@@ -28,7 +28,11 @@ WHERE
         OR r.code ILIKE '%' || sqlc.narg(search)::text || '%'
         OR r.name ILIKE '%' || sqlc.narg(search)::text || '%'
     )
-ORDER BY r.created_at DESC
+    AND (
+        sqlc.narg(cursor_created_at)::timestamptz IS NULL
+        OR (r.created_at, r.id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+    )
+ORDER BY r.created_at DESC, r.id DESC
 LIMIT COALESCE(sqlc.narg(limit_value)::int, 50)
 OFFSET COALESCE(sqlc.narg(offset_value)::int, 0);
 
@@ -119,6 +123,12 @@ RETURNING
     updated_at,
     version;
 
+-- name: LockRoleVersion :one
+SELECT version
+FROM roles
+WHERE id = sqlc.arg(id)
+FOR UPDATE;
+
 -- name: DeleteRole :exec
 DELETE FROM roles
 WHERE id = sqlc.arg(id);
@@ -146,7 +156,11 @@ SELECT
     ra.created_at
 FROM role_assignments ra
 WHERE ra.role_id = sqlc.arg(role_id)
-ORDER BY ra.created_at DESC
+  AND (
+      sqlc.narg(cursor_created_at)::timestamptz IS NULL
+      OR (ra.created_at, ra.identity_id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+  )
+ORDER BY ra.created_at DESC, ra.identity_id DESC
 LIMIT COALESCE(sqlc.narg(limit_value)::int, 50)
 OFFSET COALESCE(sqlc.narg(offset_value)::int, 0);
 
@@ -161,7 +175,41 @@ VALUES (sqlc.arg(role_id), sqlc.arg(identity_id), sqlc.narg(tenant_id))
 ON CONFLICT (role_id, identity_id)
 DO UPDATE SET tenant_id = EXCLUDED.tenant_id;
 
--- name: DeleteRoleAssignment :exec
+-- name: DeleteRoleAssignment :execrows
 DELETE FROM role_assignments
 WHERE role_id = sqlc.arg(role_id)
   AND identity_id = sqlc.arg(identity_id);
+
+-- name: RoleAssignmentExists :one
+SELECT EXISTS (
+    SELECT 1
+    FROM role_assignments
+    WHERE role_id = sqlc.arg(role_id)
+      AND identity_id = sqlc.arg(identity_id)
+) AS assigned;
+
+-- name: ListTenantRoleAssignments :many
+SELECT
+    ra.identity_id,
+    r.id AS role_id,
+    r.tenant_id AS role_tenant_id,
+    r.code AS role_code,
+    r.name AS role_name
+FROM role_assignments ra
+JOIN roles r ON r.id = ra.role_id
+WHERE ra.tenant_id = sqlc.arg(tenant_id)
+   OR r.tenant_id = sqlc.arg(tenant_id)
+ORDER BY ra.identity_id, r.code;
+
+-- name: ListIdentityRoleAssignments :many
+SELECT
+    ra.identity_id,
+    r.id AS role_id,
+    r.tenant_id AS role_tenant_id,
+    r.code AS role_code,
+    r.name AS role_name
+FROM role_assignments ra
+JOIN roles r ON r.id = ra.role_id
+WHERE ra.identity_id = sqlc.arg(identity_id)
+  AND (ra.tenant_id = sqlc.arg(tenant_id) OR r.tenant_id = sqlc.arg(tenant_id))
+ORDER BY r.code;
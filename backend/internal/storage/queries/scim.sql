@@ -0,0 +1,190 @@
+-- name: CreateScimToken :one
+INSERT INTO scim_tokens (
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by
+) VALUES (
+    sqlc.arg(id),
+    sqlc.arg(tenant_id),
+    sqlc.arg(name),
+    sqlc.arg(token_hash),
+    sqlc.arg(token_prefix),
+    sqlc.narg(created_by)
+)
+RETURNING
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by,
+    last_used_at,
+    revoked_at,
+    created_at;
+
+-- name: ListScimTokens :many
+SELECT
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by,
+    last_used_at,
+    revoked_at,
+    created_at
+FROM scim_tokens
+WHERE tenant_id = sqlc.arg(tenant_id)
+ORDER BY created_at DESC;
+
+-- name: RevokeScimToken :execrows
+UPDATE scim_tokens
+SET revoked_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND tenant_id = sqlc.arg(tenant_id)
+  AND revoked_at IS NULL;
+
+-- name: UseScimToken :one
+UPDATE scim_tokens
+SET last_used_at = NOW()
+WHERE token_hash = sqlc.arg(token_hash)
+  AND revoked_at IS NULL
+RETURNING
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by,
+    last_used_at,
+    revoked_at,
+    created_at;
+
+-- name: ListScimUsers :many
+SELECT
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active,
+    created_at,
+    updated_at
+FROM scim_users
+WHERE tenant_id = sqlc.arg(tenant_id)
+ORDER BY created_at ASC, identity_id ASC;
+
+-- name: GetScimUser :one
+SELECT
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active,
+    created_at,
+    updated_at
+FROM scim_users
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND identity_id = sqlc.arg(identity_id);
+
+-- name: UpsertScimUser :one
+INSERT INTO scim_users (
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active
+) VALUES (
+    sqlc.arg(tenant_id),
+    sqlc.arg(identity_id),
+    sqlc.narg(external_id),
+    sqlc.arg(user_name),
+    sqlc.arg(display_name),
+    sqlc.arg(phone),
+    sqlc.narg(title),
+    sqlc.arg(active)
+)
+ON CONFLICT (tenant_id, identity_id) DO UPDATE
+SET
+    external_id = EXCLUDED.external_id,
+    user_name = EXCLUDED.user_name,
+    display_name = EXCLUDED.display_name,
+    phone = EXCLUDED.phone,
+    title = EXCLUDED.title,
+    active = EXCLUDED.active,
+    updated_at = NOW()
+RETURNING
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active,
+    created_at,
+    updated_at;
+
+-- name: DeleteScimUser :execrows
+DELETE FROM scim_users
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND identity_id = sqlc.arg(identity_id);
+
+-- name: UpdateMemberProfiles :many
+UPDATE group_members
+SET
+    display_name = sqlc.arg(display_name),
+    phone = sqlc.arg(phone),
+    title = sqlc.narg(title),
+    name_initials = sqlc.arg(name_initials),
+    updated_at = NOW()
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND identity_id = sqlc.arg(identity_id)
+RETURNING
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials;
+
+-- name: ListScimGroups :many
+SELECT
+    group_id,
+    tenant_id,
+    external_id,
+    created_at
+FROM scim_groups
+WHERE tenant_id = sqlc.arg(tenant_id);
+
+-- name: UpsertScimGroup :exec
+INSERT INTO scim_groups (
+    group_id,
+    tenant_id,
+    external_id
+) VALUES (
+    sqlc.arg(group_id),
+    sqlc.arg(tenant_id),
+    sqlc.arg(external_id)
+)
+ON CONFLICT (group_id) DO UPDATE
+SET external_id = EXCLUDED.external_id;
+
+-- name: DeleteScimGroup :exec
+DELETE FROM scim_groups
+WHERE group_id = sqlc.arg(group_id);
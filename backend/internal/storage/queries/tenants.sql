@@ -8,7 +8,8 @@ SELECT
     contact_phone,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenants
 WHERE (
     sqlc.narg(search)::text IS NULL
@@ -19,7 +20,11 @@ AND (
     sqlc.narg(status_filter)::text IS NULL
     OR status = sqlc.narg(status_filter)::text
 )
-ORDER BY created_at DESC
+AND (
+    sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+)
+ORDER BY created_at DESC, id DESC
 LIMIT sqlc.narg(limit_value) OFFSET sqlc.narg(offset_value);
 
 -- name: CountTenants :one
@@ -45,7 +50,8 @@ SELECT
     contact_phone,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenants
 WHERE id = $1;
 
@@ -76,7 +82,8 @@ RETURNING
     contact_phone,
     metadata,
     created_at,
-    updated_at;
+    updated_at,
+    version;
 
 -- name: UpdateTenant :one
 UPDATE tenants
@@ -87,7 +94,8 @@ SET
     contact_name = $5,
     contact_phone = $6,
     metadata = COALESCE($7, metadata),
-    updated_at = NOW()
+    updated_at = NOW(),
+    version = version + 1
 WHERE id = $1
 RETURNING
     id,
@@ -98,7 +106,14 @@ RETURNING
     contact_phone,
     metadata,
     created_at,
-    updated_at;
+    updated_at,
+    version;
+
+-- name: LockTenantVersion :one
+SELECT version
+FROM tenants
+WHERE id = $1
+FOR UPDATE;
 
 -- name: DeleteTenant :exec
 DELETE FROM tenants WHERE id = $1;
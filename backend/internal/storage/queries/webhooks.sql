@@ -0,0 +1,235 @@
+-- name: InsertDomainEvent :exec
+INSERT INTO domain_events (
+    id,
+    tenant_id,
+    event_type,
+    payload,
+    request_id
+) VALUES (
+    sqlc.arg(id),
+    sqlc.narg(tenant_id),
+    sqlc.arg(event_type),
+    sqlc.arg(payload),
+    sqlc.narg(request_id)
+);
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (subscription_id, event_id)
+SELECT s.id, sqlc.arg(event_id)::uuid
+FROM webhook_subscriptions s
+WHERE s.tenant_id = sqlc.arg(tenant_id)::uuid
+  AND s.active
+  AND (
+      cardinality(s.event_types) = 0
+      OR sqlc.arg(event_type)::text = ANY(s.event_types)
+  );
+
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description
+) VALUES (
+    sqlc.arg(id),
+    sqlc.arg(tenant_id),
+    sqlc.arg(url),
+    sqlc.arg(secret),
+    sqlc.arg(event_types)::text[],
+    sqlc.arg(active),
+    sqlc.narg(description)
+)
+RETURNING
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at;
+
+-- name: GetWebhookSubscription :one
+SELECT
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at
+FROM webhook_subscriptions
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookSubscriptions :many
+SELECT
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at
+FROM webhook_subscriptions
+WHERE sqlc.narg(tenant_filter)::uuid IS NULL OR tenant_id = sqlc.narg(tenant_filter)::uuid
+ORDER BY created_at DESC;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET
+    url = sqlc.arg(url),
+    event_types = sqlc.arg(event_types)::text[],
+    active = sqlc.arg(active),
+    description = sqlc.narg(description),
+    secret = COALESCE(sqlc.narg(secret)::text, secret),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = sqlc.arg(id);
+
+-- name: ClaimWebhookDeliveries :many
+WITH claimed AS (
+    UPDATE webhook_deliveries d
+    SET
+        next_attempt_at = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second'),
+        updated_at = NOW()
+    WHERE d.id IN (
+        SELECT p.id
+        FROM webhook_deliveries p
+        WHERE p.status = 'pending'
+          AND p.next_attempt_at <= NOW()
+        ORDER BY p.next_attempt_at
+        LIMIT sqlc.arg(batch_size)::int
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING d.id, d.subscription_id, d.event_id, d.attempts
+)
+SELECT
+    c.id,
+    c.subscription_id,
+    c.event_id,
+    c.attempts,
+    s.url,
+    s.secret,
+    e.tenant_id,
+    e.event_type,
+    e.payload,
+    e.occurred_at
+FROM claimed c
+JOIN webhook_subscriptions s ON s.id = c.subscription_id
+JOIN domain_events e ON e.id = c.event_id;
+
+-- name: CompleteWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET
+    status = sqlc.arg(status),
+    attempts = attempts + 1,
+    next_attempt_at = COALESCE(sqlc.narg(next_attempt_at)::timestamptz, next_attempt_at),
+    last_status_code = sqlc.narg(last_status_code),
+    last_error = sqlc.narg(last_error),
+    delivered_at = CASE WHEN sqlc.arg(status) = 'succeeded' THEN NOW() ELSE delivered_at END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: InsertWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (
+    delivery_id,
+    status_code,
+    error,
+    duration_ms
+) VALUES (
+    sqlc.arg(delivery_id),
+    sqlc.narg(status_code),
+    sqlc.narg(error),
+    sqlc.arg(duration_ms)
+);
+
+-- name: ListWebhookDeliveries :many
+SELECT
+    d.id,
+    d.subscription_id,
+    d.event_id,
+    d.status,
+    d.attempts,
+    d.next_attempt_at,
+    d.last_status_code,
+    d.last_error,
+    d.delivered_at,
+    d.created_at,
+    d.updated_at,
+    e.event_type
+FROM webhook_deliveries d
+JOIN domain_events e ON e.id = d.event_id
+WHERE d.subscription_id = sqlc.arg(subscription_id)
+  AND (sqlc.narg(status_filter)::text IS NULL OR d.status = sqlc.narg(status_filter)::text)
+ORDER BY d.created_at DESC, d.id
+LIMIT COALESCE(sqlc.narg(limit_value)::int, 50)
+OFFSET COALESCE(sqlc.narg(offset_value)::int, 0);
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) AS total
+FROM webhook_deliveries
+WHERE subscription_id = sqlc.arg(subscription_id)
+  AND (sqlc.narg(status_filter)::text IS NULL OR status = sqlc.narg(status_filter)::text);
+
+-- name: GetWebhookDelivery :one
+SELECT
+    d.id,
+    d.subscription_id,
+    d.event_id,
+    d.status,
+    d.attempts,
+    d.next_attempt_at,
+    d.last_status_code,
+    d.last_error,
+    d.delivered_at,
+    d.created_at,
+    d.updated_at,
+    e.event_type
+FROM webhook_deliveries d
+JOIN domain_events e ON e.id = d.event_id
+WHERE d.id = sqlc.arg(id);
+
+-- name: ReplayWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET
+    status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW(),
+    last_error = NULL,
+    last_status_code = NULL,
+    delivered_at = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveryAttempts :many
+SELECT
+    id,
+    delivery_id,
+    status_code,
+    error,
+    duration_ms,
+    created_at
+FROM webhook_delivery_attempts
+WHERE delivery_id = sqlc.arg(delivery_id)
+ORDER BY created_at DESC, id DESC;
@@ -40,8 +40,20 @@ type RoleAssignment struct {
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
-// ErrRoleNotFound indicates the requested role was not located.
-var ErrRoleNotFound = errors.New("role not found")
+// IdentityRole is a role held by an identity.
+type IdentityRole struct {
+	RoleID   uuid.UUID  `json:"role_id"`
+	TenantID *uuid.UUID `json:"tenant_id,omitempty"`
+	Code     string     `json:"code"`
+	Name     string     `json:"name"`
+}
+
+var (
+	// ErrRoleNotFound indicates the requested role was not located.
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleVersionConflict indicates the role changed since the client read it.
+	ErrRoleVersionConflict = errors.New("role has been modified")
+)
 
 // RoleListParams captures filters used when listing roles.
 type RoleListParams struct {
@@ -124,9 +136,75 @@ func (r *RoleRepository) ListRoles(ctx context.Context, params RoleListParams) (
 	return result, total, nil
 }
 
+// ListRolesKeyset returns one page of roles matching params, newest first, using keyset
+// pagination over (created_at, id). The offset and limit of params are ignored.
+func (r *RoleRepository) ListRolesKeyset(ctx context.Context, params RoleListParams, page KeysetPage) ([]Role, KeysetResult, error) {
+	cursorCreatedAt, cursorID, err := keysetArgs(page.Cursor)
+	if err != nil {
+		return nil, KeysetResult{}, err
+	}
+
+	var scopeArg *string
+	if trimmed := strings.TrimSpace(params.Scope); trimmed != "" {
+		scopeArg = stringPtr(trimmed)
+	}
+	var tenantArg pgtype.UUID
+	if params.TenantID != nil {
+		tenantArg = uuidToPg(*params.TenantID)
+	}
+	var searchArg *string
+	if trimmed := strings.TrimSpace(params.Search); trimmed != "" {
+		searchArg = stringPtr(trimmed)
+	}
+
+	rows, err := r.queries.ListRoles(ctx, sqldb.ListRolesParams{
+		ScopeFilter:     scopeArg,
+		TenantFilter:    tenantArg,
+		Search:          searchArg,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		LimitValue:      int32Ptr(keysetLimit(page)),
+	})
+	if err != nil {
+		return nil, KeysetResult{}, fmt.Errorf("list roles: %w", err)
+	}
+
+	result := make([]Role, 0, len(rows))
+	for _, row := range rows {
+		role, err := mapRoleListRow(row)
+		if err != nil {
+			return nil, KeysetResult{}, err
+		}
+		result = append(result, role)
+	}
+
+	var pageResult KeysetResult
+	count, next := nextKeysetCursor(page, len(result), func(i int) (time.Time, uuid.UUID) {
+		return result[i].CreatedAt, result[i].ID
+	})
+	result, pageResult.NextCursor = result[:count], next
+
+	if page.WithTotal {
+		total, err := r.queries.CountRoles(ctx, sqldb.CountRolesParams{
+			ScopeFilter:  scopeArg,
+			TenantFilter: tenantArg,
+			Search:       searchArg,
+		})
+		if err != nil {
+			return nil, KeysetResult{}, fmt.Errorf("count roles: %w", err)
+		}
+		pageResult.Total = &total
+	}
+	return result, pageResult, nil
+}
+
 // GetRole retrieves a single role by ID along with its aggregate metadata.
 func (r *RoleRepository) GetRole(ctx context.Context, id uuid.UUID) (Role, error) {
-	row, err := r.queries.GetRole(ctx, uuidToPg(id))
+	return getRole(ctx, r.queries, id)
+}
+
+func getRole(ctx context.Context, q *sqldb.Queries, id uuid.UUID) (Role, error) {
+	row, err := q.GetRole(ctx, uuidToPg(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Role{}, ErrRoleNotFound
@@ -139,7 +217,7 @@ func (r *RoleRepository) GetRole(ctx context.Context, id uuid.UUID) (Role, error
 		return Role{}, err
 	}
 
-	perms, err := r.queries.ListRolePermissions(ctx, uuidToPg(role.ID))
+	perms, err := q.ListRolePermissions(ctx, uuidToPg(role.ID))
 	if err != nil {
 		return Role{}, fmt.Errorf("list role permissions: %w", err)
 	}
@@ -192,20 +270,32 @@ func (r *RoleRepository) CreateRole(ctx context.Context, role Role) (Role, error
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return Role{}, fmt.Errorf("commit role tx: %w", err)
-	}
-
 	created, err := mapRole(result)
 	if err != nil {
 		return Role{}, err
 	}
 	created.Permissions = append([]string(nil), role.Permissions...)
+
+	if err := recordChange(ctx, qtx, changeEntry{
+		TenantID:   created.TenantID,
+		Action:     EventRoleCreated,
+		TargetType: "role",
+		TargetID:   created.ID.String(),
+		After:      created,
+	}); err != nil {
+		return Role{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Role{}, fmt.Errorf("commit role tx: %w", err)
+	}
 	return created, nil
 }
 
-// UpdateRole updates the main role fields and optionally replaces the permission set.
-func (r *RoleRepository) UpdateRole(ctx context.Context, role Role, replacePermissions bool) (Role, error) {
+// UpdateRole updates the main role fields and optionally replaces the permission set. A
+// non-nil expectedVersion makes the update fail with ErrRoleVersionConflict unless the
+// stored role still has that version.
+func (r *RoleRepository) UpdateRole(ctx context.Context, role Role, replacePermissions bool, expectedVersion *int32) (Role, error) {
 	if role.Metadata == nil {
 		role.Metadata = json.RawMessage(`{}`)
 	}
@@ -217,6 +307,14 @@ func (r *RoleRepository) UpdateRole(ctx context.Context, role Role, replacePermi
 	defer tx.Rollback(ctx) // nolint:errcheck
 
 	qtx := r.queries.WithTx(tx)
+	if err := checkRoleVersion(ctx, qtx, role.ID, expectedVersion); err != nil {
+		return Role{}, err
+	}
+	before, err := getRole(ctx, qtx, role.ID)
+	if err != nil {
+		return Role{}, err
+	}
+
 	result, err := qtx.UpdateRole(ctx, sqldb.UpdateRoleParams{
 		Code:        strings.TrimSpace(role.Code),
 		Name:        strings.TrimSpace(role.Name),
@@ -249,10 +347,6 @@ func (r *RoleRepository) UpdateRole(ctx context.Context, role Role, replacePermi
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return Role{}, fmt.Errorf("commit role update: %w", err)
-	}
-
 	updated, err := mapRole(result)
 	if err != nil {
 		return Role{}, err
@@ -260,19 +354,73 @@ func (r *RoleRepository) UpdateRole(ctx context.Context, role Role, replacePermi
 	if replacePermissions {
 		updated.Permissions = append([]string(nil), role.Permissions...)
 	} else {
-		perms, err := r.queries.ListRolePermissions(ctx, uuidToPg(role.ID))
+		perms, err := qtx.ListRolePermissions(ctx, uuidToPg(role.ID))
 		if err != nil {
 			return Role{}, fmt.Errorf("list role permissions: %w", err)
 		}
 		updated.Permissions = perms
 	}
+	updated.AssignedCount = before.AssignedCount
+
+	if err := recordChange(ctx, qtx, changeEntry{
+		TenantID:   updated.TenantID,
+		Action:     EventRoleUpdated,
+		TargetType: "role",
+		TargetID:   updated.ID.String(),
+		Before:     before,
+		After:      updated,
+	}); err != nil {
+		return Role{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Role{}, fmt.Errorf("commit role update: %w", err)
+	}
 	return updated, nil
 }
 
-// DeleteRole removes a role and its associated permissions/assignments.
-func (r *RoleRepository) DeleteRole(ctx context.Context, id uuid.UUID) error {
-	if err := r.queries.DeleteRole(ctx, uuidToPg(id)); err != nil {
-		return fmt.Errorf("delete role: %w", err)
+// DeleteRole removes a role and its associated permissions/assignments, optionally only
+// while it still has expectedVersion.
+func (r *RoleRepository) DeleteRole(ctx context.Context, id uuid.UUID, expectedVersion *int32) error {
+	return runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		if err := checkRoleVersion(ctx, qtx, id, expectedVersion); err != nil {
+			return err
+		}
+		before, err := getRole(ctx, qtx, id)
+		if err != nil {
+			if errors.Is(err, ErrRoleNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := qtx.DeleteRole(ctx, uuidToPg(id)); err != nil {
+			return fmt.Errorf("delete role: %w", err)
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   before.TenantID,
+			Action:     EventRoleDeleted,
+			TargetType: "role",
+			TargetID:   before.ID.String(),
+			Before:     before,
+		})
+	})
+}
+
+// checkRoleVersion locks the role row for the rest of the transaction and compares its
+// version with expected. A nil expected version skips the comparison.
+func checkRoleVersion(ctx context.Context, q *sqldb.Queries, id uuid.UUID, expected *int32) error {
+	if expected == nil {
+		return nil
+	}
+	version, err := q.LockRoleVersion(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRoleNotFound
+		}
+		return fmt.Errorf("lock role: %w", err)
+	}
+	if version != *expected {
+		return ErrRoleVersionConflict
 	}
 	return nil
 }
@@ -314,52 +462,215 @@ func (r *RoleRepository) ListAssignments(ctx context.Context, roleID uuid.UUID,
 
 	result := make([]RoleAssignment, 0, len(rows))
 	for _, row := range rows {
-		roleIDVal, _, err := pgUUIDToUUID(row.RoleID)
+		assignment, err := mapRoleAssignmentRow(row)
 		if err != nil {
-			return nil, 0, fmt.Errorf("parse role id: %w", err)
+			return nil, 0, err
 		}
+		result = append(result, assignment)
+	}
+
+	return result, total, nil
+}
+
+// ListAssignmentsKeyset returns one page of a role's assignments, newest first, using
+// keyset pagination over (created_at, identity_id).
+func (r *RoleRepository) ListAssignmentsKeyset(ctx context.Context, roleID uuid.UUID, page KeysetPage) ([]RoleAssignment, KeysetResult, error) {
+	cursorCreatedAt, cursorID, err := keysetArgs(page.Cursor)
+	if err != nil {
+		return nil, KeysetResult{}, err
+	}
+
+	rows, err := r.queries.ListRoleAssignments(ctx, sqldb.ListRoleAssignmentsParams{
+		RoleID:          uuidToPg(roleID),
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		LimitValue:      int32Ptr(keysetLimit(page)),
+	})
+	if err != nil {
+		return nil, KeysetResult{}, fmt.Errorf("list role assignments: %w", err)
+	}
+
+	result := make([]RoleAssignment, 0, len(rows))
+	for _, row := range rows {
+		assignment, err := mapRoleAssignmentRow(row)
+		if err != nil {
+			return nil, KeysetResult{}, err
+		}
+		result = append(result, assignment)
+	}
+
+	var pageResult KeysetResult
+	count, next := nextKeysetCursor(page, len(result), func(i int) (time.Time, uuid.UUID) {
+		return result[i].CreatedAt, result[i].IdentityID
+	})
+	result, pageResult.NextCursor = result[:count], next
+
+	if page.WithTotal {
+		total, err := r.queries.CountRoleAssignments(ctx, uuidToPg(roleID))
+		if err != nil {
+			return nil, KeysetResult{}, fmt.Errorf("count role assignments: %w", err)
+		}
+		pageResult.Total = &total
+	}
+	return result, pageResult, nil
+}
+
+func mapRoleAssignmentRow(row sqldb.RoleAssignment) (RoleAssignment, error) {
+	roleID, _, err := pgUUIDToUUID(row.RoleID)
+	if err != nil {
+		return RoleAssignment{}, fmt.Errorf("parse role id: %w", err)
+	}
+	identityID, _, err := pgUUIDToUUID(row.IdentityID)
+	if err != nil {
+		return RoleAssignment{}, fmt.Errorf("parse identity id: %w", err)
+	}
+	var tenantID *uuid.UUID
+	if tenantUUID, ok, err := pgUUIDToUUID(row.TenantID); err != nil {
+		return RoleAssignment{}, fmt.Errorf("parse tenant id: %w", err)
+	} else if ok {
+		tenantID = &tenantUUID
+	}
+	return RoleAssignment{
+		RoleID:     roleID,
+		IdentityID: identityID,
+		TenantID:   tenantID,
+		CreatedAt:  row.CreatedAt.Time,
+	}, nil
+}
+
+// ListTenantAssignments returns the roles assigned to each identity within a tenant.
+func (r *RoleRepository) ListTenantAssignments(ctx context.Context, tenantID uuid.UUID) (map[uuid.UUID][]IdentityRole, error) {
+	rows, err := r.queries.ListTenantRoleAssignments(ctx, uuidToPg(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list tenant role assignments: %w", err)
+	}
+
+	result := make(map[uuid.UUID][]IdentityRole)
+	for _, row := range rows {
 		identityID, _, err := pgUUIDToUUID(row.IdentityID)
 		if err != nil {
-			return nil, 0, fmt.Errorf("parse identity id: %w", err)
+			return nil, fmt.Errorf("parse identity id: %w", err)
 		}
-		var tenantID *uuid.UUID
-		if tenantUUID, ok, err := pgUUIDToUUID(row.TenantID); err != nil {
-			return nil, 0, fmt.Errorf("parse tenant id: %w", err)
-		} else if ok {
-			tenantID = &tenantUUID
+		role, err := mapIdentityRole(row.RoleID, row.RoleTenantID, row.RoleCode, row.RoleName)
+		if err != nil {
+			return nil, err
 		}
-		result = append(result, RoleAssignment{
-			RoleID:     roleIDVal,
-			IdentityID: identityID,
-			TenantID:   tenantID,
-			CreatedAt:  row.CreatedAt.Time,
-		})
+		result[identityID] = append(result[identityID], role)
 	}
+	return result, nil
+}
 
-	return result, total, nil
+// ListIdentityRoles returns the roles assigned to one identity within a tenant.
+func (r *RoleRepository) ListIdentityRoles(ctx context.Context, tenantID, identityID uuid.UUID) ([]IdentityRole, error) {
+	rows, err := r.queries.ListIdentityRoleAssignments(ctx, sqldb.ListIdentityRoleAssignmentsParams{
+		IdentityID: uuidToPg(identityID),
+		TenantID:   uuidToPg(tenantID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list identity role assignments: %w", err)
+	}
+
+	result := make([]IdentityRole, 0, len(rows))
+	for _, row := range rows {
+		role, err := mapIdentityRole(row.RoleID, row.RoleTenantID, row.RoleCode, row.RoleName)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, role)
+	}
+	return result, nil
+}
+
+// HasAssignment reports whether the identity holds the role.
+func (r *RoleRepository) HasAssignment(ctx context.Context, roleID, identityID uuid.UUID) (bool, error) {
+	assigned, err := r.queries.RoleAssignmentExists(ctx, sqldb.RoleAssignmentExistsParams{
+		RoleID:     uuidToPg(roleID),
+		IdentityID: uuidToPg(identityID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("check role assignment: %w", err)
+	}
+	return assigned, nil
 }
 
-// UpsertAssignment associates an identity with a role.
+// UpsertAssignment associates an identity with a role and queues the Keto tuple.
 func (r *RoleRepository) UpsertAssignment(ctx context.Context, roleID, identityID uuid.UUID, tenantID *uuid.UUID) error {
-	if err := r.queries.UpsertRoleAssignment(ctx, sqldb.UpsertRoleAssignmentParams{
+	return runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		return upsertAssignment(ctx, qtx, roleID, identityID, tenantID)
+	})
+}
+
+func upsertAssignment(ctx context.Context, q *sqldb.Queries, roleID, identityID uuid.UUID, tenantID *uuid.UUID) error {
+	if err := q.UpsertRoleAssignment(ctx, sqldb.UpsertRoleAssignmentParams{
 		RoleID:     uuidToPg(roleID),
 		IdentityID: uuidToPg(identityID),
 		TenantID:   uuidToNullablePg(tenantID),
 	}); err != nil {
 		return fmt.Errorf("upsert role assignment: %w", err)
 	}
-	return nil
+	if err := enqueueRoleMemberSync(ctx, q, tenantID, roleID, identityID); err != nil {
+		return err
+	}
+	return recordChange(ctx, q, changeEntry{
+		TenantID:   tenantID,
+		Action:     EventRoleAssigned,
+		TargetType: "member",
+		TargetID:   identityID.String(),
+		After:      RoleAssignment{RoleID: roleID, IdentityID: identityID, TenantID: tenantID},
+	})
 }
 
-// DeleteAssignment removes an identity from the role.
+// DeleteAssignment removes an identity from the role and queues the removal of the Keto
+// tuple.
 func (r *RoleRepository) DeleteAssignment(ctx context.Context, roleID, identityID uuid.UUID) error {
-	if err := r.queries.DeleteRoleAssignment(ctx, sqldb.DeleteRoleAssignmentParams{
+	return runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		return deleteAssignment(ctx, qtx, roleID, identityID)
+	})
+}
+
+func deleteAssignment(ctx context.Context, q *sqldb.Queries, roleID, identityID uuid.UUID) error {
+	role, err := getRole(ctx, q, roleID)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	affected, err := q.DeleteRoleAssignment(ctx, sqldb.DeleteRoleAssignmentParams{
 		RoleID:     uuidToPg(roleID),
 		IdentityID: uuidToPg(identityID),
-	}); err != nil {
+	})
+	if err != nil {
 		return fmt.Errorf("delete role assignment: %w", err)
 	}
-	return nil
+	if affected == 0 {
+		return nil
+	}
+	if err := enqueueRoleMemberSync(ctx, q, role.TenantID, roleID, identityID); err != nil {
+		return err
+	}
+	return recordChange(ctx, q, changeEntry{
+		TenantID:   role.TenantID,
+		Action:     EventRoleUnassigned,
+		TargetType: "member",
+		TargetID:   identityID.String(),
+		Before:     RoleAssignment{RoleID: roleID, IdentityID: identityID, TenantID: role.TenantID},
+	})
+}
+
+func mapIdentityRole(roleID, tenantID pgtype.UUID, code, name string) (IdentityRole, error) {
+	id, _, err := pgUUIDToUUID(roleID)
+	if err != nil {
+		return IdentityRole{}, fmt.Errorf("parse role id: %w", err)
+	}
+	role := IdentityRole{RoleID: id, Code: code, Name: name}
+	if tenantUUID, ok, err := pgUUIDToUUID(tenantID); err != nil {
+		return IdentityRole{}, fmt.Errorf("parse role tenant id: %w", err)
+	} else if ok {
+		role.TenantID = &tenantUUID
+	}
+	return role, nil
 }
 
 func mapRoleListRow(row sqldb.ListRolesRow) (Role, error) {
@@ -0,0 +1,414 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+const (
+	scimTokenPrefix       = "scim_"
+	scimTokenDisplayChars = 12
+)
+
+var (
+	// ErrScimTokenNotFound indicates the token does not exist or was already revoked.
+	ErrScimTokenNotFound = errors.New("scim token not found")
+	// ErrScimUserNotFound indicates the identity is not provisioned through SCIM for the tenant.
+	ErrScimUserNotFound = errors.New("scim user not found")
+	// ErrScimUserConflict indicates the userName or externalId is already used within the tenant.
+	ErrScimUserConflict = errors.New("scim user already exists")
+)
+
+// ScimToken is a per-tenant bearer token accepted by the SCIM endpoints.
+type ScimToken struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	CreatedBy   *string    `json:"created_by,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ScimUser is the provisioning record kept for an identity managed by a tenant IdP.
+type ScimUser struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	IdentityID  uuid.UUID `json:"identity_id"`
+	ExternalID  *string   `json:"external_id,omitempty"`
+	UserName    string    `json:"user_name"`
+	DisplayName string    `json:"display_name"`
+	Phone       string    `json:"phone"`
+	Title       *string   `json:"title,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ScimRepository stores SCIM tokens, provisioned users and group external IDs.
+type ScimRepository struct {
+	pool    *pgxpool.Pool
+	queries *sqldb.Queries
+}
+
+// NewScimRepository constructs a repository using sqlc generated queries.
+func NewScimRepository(pool *pgxpool.Pool, queries *sqldb.Queries) *ScimRepository {
+	return &ScimRepository{
+		pool:    pool,
+		queries: queries,
+	}
+}
+
+// CreateToken issues a new bearer token for the tenant. The plaintext token is only
+// returned here; the database keeps its SHA-256 hash.
+func (r *ScimRepository) CreateToken(ctx context.Context, tenantID uuid.UUID, name string, createdBy *string) (ScimToken, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return ScimToken{}, "", fmt.Errorf("generate scim token: %w", err)
+	}
+	plaintext := scimTokenPrefix + hex.EncodeToString(buf)
+
+	row, err := r.queries.CreateScimToken(ctx, sqldb.CreateScimTokenParams{
+		ID:          uuidToPg(uuid.New()),
+		TenantID:    uuidToPg(tenantID),
+		Name:        strings.TrimSpace(name),
+		TokenHash:   hashScimToken(plaintext),
+		TokenPrefix: plaintext[:scimTokenDisplayChars],
+		CreatedBy:   createdBy,
+	})
+	if err != nil {
+		return ScimToken{}, "", fmt.Errorf("create scim token: %w", err)
+	}
+	token, err := mapScimTokenRow(row)
+	if err != nil {
+		return ScimToken{}, "", err
+	}
+	return token, plaintext, nil
+}
+
+// ListTokens returns every token of the tenant, including revoked ones.
+func (r *ScimRepository) ListTokens(ctx context.Context, tenantID uuid.UUID) ([]ScimToken, error) {
+	rows, err := r.queries.ListScimTokens(ctx, uuidToPg(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list scim tokens: %w", err)
+	}
+	tokens := make([]ScimToken, 0, len(rows))
+	for _, row := range rows {
+		token, err := mapScimTokenRow(row)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// RevokeToken disables a token so it can no longer authenticate.
+func (r *ScimRepository) RevokeToken(ctx context.Context, tenantID, id uuid.UUID) error {
+	affected, err := r.queries.RevokeScimToken(ctx, sqldb.RevokeScimTokenParams{
+		ID:       uuidToPg(id),
+		TenantID: uuidToPg(tenantID),
+	})
+	if err != nil {
+		return fmt.Errorf("revoke scim token: %w", err)
+	}
+	if affected == 0 {
+		return ErrScimTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a plaintext bearer token to its active token record and records its use.
+func (r *ScimRepository) Authenticate(ctx context.Context, plaintext string) (ScimToken, error) {
+	plaintext = strings.TrimSpace(plaintext)
+	if !strings.HasPrefix(plaintext, scimTokenPrefix) {
+		return ScimToken{}, ErrScimTokenNotFound
+	}
+	row, err := r.queries.UseScimToken(ctx, hashScimToken(plaintext))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ScimToken{}, ErrScimTokenNotFound
+		}
+		return ScimToken{}, fmt.Errorf("authenticate scim token: %w", err)
+	}
+	return mapScimTokenRow(row)
+}
+
+// ListUsers returns every SCIM-provisioned user of the tenant ordered by creation time.
+func (r *ScimRepository) ListUsers(ctx context.Context, tenantID uuid.UUID) ([]ScimUser, error) {
+	rows, err := r.queries.ListScimUsers(ctx, uuidToPg(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list scim users: %w", err)
+	}
+	users := make([]ScimUser, 0, len(rows))
+	for _, row := range rows {
+		user, err := mapScimUserRow(row)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// GetUser fetches the provisioning record for an identity.
+func (r *ScimRepository) GetUser(ctx context.Context, tenantID, identityID uuid.UUID) (ScimUser, error) {
+	user, err := getScimUser(ctx, r.queries, tenantID, identityID)
+	if err != nil {
+		return ScimUser{}, err
+	}
+	return *user, nil
+}
+
+func getScimUser(ctx context.Context, q *sqldb.Queries, tenantID, identityID uuid.UUID) (*ScimUser, error) {
+	row, err := q.GetScimUser(ctx, sqldb.GetScimUserParams{
+		TenantID:   uuidToPg(tenantID),
+		IdentityID: uuidToPg(identityID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrScimUserNotFound
+		}
+		return nil, fmt.Errorf("get scim user: %w", err)
+	}
+	user, err := mapScimUserRow(row)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SaveUser creates or replaces the provisioning record and copies the profile onto every
+// group membership of the identity in the same transaction.
+func (r *ScimRepository) SaveUser(ctx context.Context, user ScimUser) (ScimUser, error) {
+	var saved ScimUser
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		before, err := getScimUser(ctx, qtx, user.TenantID, user.IdentityID)
+		if err != nil && !errors.Is(err, ErrScimUserNotFound) {
+			return err
+		}
+
+		row, err := qtx.UpsertScimUser(ctx, sqldb.UpsertScimUserParams{
+			TenantID:    uuidToPg(user.TenantID),
+			IdentityID:  uuidToPg(user.IdentityID),
+			ExternalID:  user.ExternalID,
+			UserName:    strings.TrimSpace(user.UserName),
+			DisplayName: strings.TrimSpace(user.DisplayName),
+			Phone:       strings.TrimSpace(user.Phone),
+			Title:       user.Title,
+			Active:      user.Active,
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrScimUserConflict
+			}
+			return fmt.Errorf("save scim user: %w", err)
+		}
+		if saved, err = mapScimUserRow(row); err != nil {
+			return err
+		}
+
+		entry := changeEntry{
+			TenantID:   &saved.TenantID,
+			Action:     EventUserProvisioned,
+			TargetType: "user",
+			TargetID:   saved.IdentityID.String(),
+			After:      saved,
+		}
+		if before != nil {
+			entry.Action = EventUserUpdated
+			entry.Before = before
+		}
+		if err := recordChange(ctx, qtx, entry); err != nil {
+			return err
+		}
+
+		if before != nil && before.DisplayName == saved.DisplayName && before.Phone == saved.Phone &&
+			equalStringPtr(before.Title, saved.Title) {
+			return nil
+		}
+		return syncMemberProfiles(ctx, qtx, saved)
+	})
+	if err != nil {
+		return ScimUser{}, err
+	}
+	return saved, nil
+}
+
+// DeleteUser removes the provisioning record. Memberships are expected to be removed by the caller.
+func (r *ScimRepository) DeleteUser(ctx context.Context, tenantID, identityID uuid.UUID) error {
+	return runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		before, err := getScimUser(ctx, qtx, tenantID, identityID)
+		if err != nil {
+			return err
+		}
+		if _, err := qtx.DeleteScimUser(ctx, sqldb.DeleteScimUserParams{
+			TenantID:   uuidToPg(tenantID),
+			IdentityID: uuidToPg(identityID),
+		}); err != nil {
+			return fmt.Errorf("delete scim user: %w", err)
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &before.TenantID,
+			Action:     EventUserDeprovisioned,
+			TargetType: "user",
+			TargetID:   before.IdentityID.String(),
+			Before:     before,
+		})
+	})
+}
+
+// GroupExternalIDs maps group IDs of the tenant to the externalId assigned by the IdP.
+func (r *ScimRepository) GroupExternalIDs(ctx context.Context, tenantID uuid.UUID) (map[uuid.UUID]string, error) {
+	rows, err := r.queries.ListScimGroups(ctx, uuidToPg(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list scim groups: %w", err)
+	}
+	result := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		groupID, err := uuid.FromBytes(row.GroupID.Bytes[:])
+		if err != nil {
+			return nil, fmt.Errorf("parse scim group id: %w", err)
+		}
+		result[groupID] = row.ExternalID
+	}
+	return result, nil
+}
+
+// SetGroupExternalID stores or clears the externalId of a group.
+func (r *ScimRepository) SetGroupExternalID(ctx context.Context, tenantID, groupID uuid.UUID, externalID string) error {
+	externalID = strings.TrimSpace(externalID)
+	if externalID == "" {
+		if err := r.queries.DeleteScimGroup(ctx, uuidToPg(groupID)); err != nil {
+			return fmt.Errorf("delete scim group: %w", err)
+		}
+		return nil
+	}
+	if err := r.queries.UpsertScimGroup(ctx, sqldb.UpsertScimGroupParams{
+		GroupID:    uuidToPg(groupID),
+		TenantID:   uuidToPg(tenantID),
+		ExternalID: externalID,
+	}); err != nil {
+		return fmt.Errorf("save scim group: %w", err)
+	}
+	return nil
+}
+
+func syncMemberProfiles(ctx context.Context, q *sqldb.Queries, user ScimUser) error {
+	beforeRows, err := q.ListGroupsForIdentity(ctx, sqldb.ListGroupsForIdentityParams{
+		TenantID:   uuidToPg(user.TenantID),
+		IdentityID: uuidToPg(user.IdentityID),
+	})
+	if err != nil {
+		return fmt.Errorf("list groups for identity: %w", err)
+	}
+	if len(beforeRows) == 0 {
+		return nil
+	}
+	previous := make(map[uuid.UUID]GroupMember, len(beforeRows))
+	for _, row := range beforeRows {
+		member, err := mapGroupMemberRow(row)
+		if err != nil {
+			return err
+		}
+		previous[member.GroupID] = member
+	}
+
+	rows, err := q.UpdateMemberProfiles(ctx, sqldb.UpdateMemberProfilesParams{
+		DisplayName:  user.DisplayName,
+		Phone:        user.Phone,
+		Title:        user.Title,
+		NameInitials: nameInitials(user.DisplayName),
+		TenantID:     uuidToPg(user.TenantID),
+		IdentityID:   uuidToPg(user.IdentityID),
+	})
+	if err != nil {
+		return fmt.Errorf("update member profiles: %w", err)
+	}
+	for _, row := range rows {
+		updated, err := mapGroupMemberRow(row)
+		if err != nil {
+			return err
+		}
+		before := previous[updated.GroupID]
+		if err := recordChange(ctx, q, changeEntry{
+			TenantID:   &updated.TenantID,
+			Action:     EventGroupMemberUpdated,
+			TargetType: "member",
+			TargetID:   updated.IdentityID.String(),
+			Before:     before,
+			After:      updated,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashScimToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func mapScimTokenRow(row sqldb.ScimToken) (ScimToken, error) {
+	id, err := uuid.FromBytes(row.ID.Bytes[:])
+	if err != nil {
+		return ScimToken{}, fmt.Errorf("parse scim token id: %w", err)
+	}
+	tenantID, err := uuid.FromBytes(row.TenantID.Bytes[:])
+	if err != nil {
+		return ScimToken{}, fmt.Errorf("parse scim token tenant id: %w", err)
+	}
+	return ScimToken{
+		ID:          id,
+		TenantID:    tenantID,
+		Name:        row.Name,
+		TokenPrefix: row.TokenPrefix,
+		CreatedBy:   row.CreatedBy,
+		LastUsedAt:  timestamptzPtr(row.LastUsedAt),
+		RevokedAt:   timestamptzPtr(row.RevokedAt),
+		CreatedAt:   row.CreatedAt.Time,
+	}, nil
+}
+
+func mapScimUserRow(row sqldb.ScimUser) (ScimUser, error) {
+	tenantID, err := uuid.FromBytes(row.TenantID.Bytes[:])
+	if err != nil {
+		return ScimUser{}, fmt.Errorf("parse scim user tenant id: %w", err)
+	}
+	identityID, err := uuid.FromBytes(row.IdentityID.Bytes[:])
+	if err != nil {
+		return ScimUser{}, fmt.Errorf("parse scim user identity id: %w", err)
+	}
+	return ScimUser{
+		TenantID:    tenantID,
+		IdentityID:  identityID,
+		ExternalID:  row.ExternalID,
+		UserName:    row.UserName,
+		DisplayName: row.DisplayName,
+		Phone:       row.Phone,
+		Title:       row.Title,
+		Active:      row.Active,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}, nil
+}
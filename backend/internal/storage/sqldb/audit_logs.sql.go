@@ -0,0 +1,149 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: audit_logs.sql
+
+package sqldb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const insertAuditLog = `-- name: InsertAuditLog :exec
+INSERT INTO audit_logs (
+    tenant_id,
+    actor_subject,
+    impersonation_id,
+    action,
+    target_type,
+    target_id,
+    before_state,
+    after_state,
+    request_id
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+`
+
+type InsertAuditLogParams struct {
+	TenantID        pgtype.UUID `json:"tenant_id"`
+	ActorSubject    *string     `json:"actor_subject"`
+	ImpersonationID pgtype.UUID `json:"impersonation_id"`
+	Action          string      `json:"action"`
+	TargetType      string      `json:"target_type"`
+	TargetID        string      `json:"target_id"`
+	BeforeState     []byte      `json:"before_state"`
+	AfterState      []byte      `json:"after_state"`
+	RequestID       *string     `json:"request_id"`
+}
+
+func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) error {
+	_, err := q.db.Exec(ctx, insertAuditLog,
+		arg.TenantID,
+		arg.ActorSubject,
+		arg.ImpersonationID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.BeforeState,
+		arg.AfterState,
+		arg.RequestID,
+	)
+	return err
+}
+
+const listAuditLogs = `-- name: ListAuditLogs :many
+SELECT
+    id,
+    tenant_id,
+    actor_subject,
+    impersonation_id,
+    action,
+    target_type,
+    target_id,
+    before_state,
+    after_state,
+    request_id,
+    created_at
+FROM audit_logs
+WHERE
+    ($1::uuid IS NULL OR tenant_id = $1::uuid)
+    AND ($2::text IS NULL OR actor_subject = $2::text)
+    AND ($3::text IS NULL OR action LIKE $3::text || '%')
+    AND ($4::text IS NULL OR target_type = $4::text)
+    AND ($5::text IS NULL OR target_id = $5::text)
+    AND ($6::timestamptz IS NULL OR created_at >= $6::timestamptz)
+    AND ($7::timestamptz IS NULL OR created_at < $7::timestamptz)
+    AND (
+        $8::timestamptz IS NULL
+        OR (created_at, id) < ($8::timestamptz, $9::bigint)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $10::int
+`
+
+type ListAuditLogsParams struct {
+	TenantFilter     pgtype.UUID        `json:"tenant_filter"`
+	ActorFilter      *string            `json:"actor_filter"`
+	ActionFilter     *string            `json:"action_filter"`
+	TargetTypeFilter *string            `json:"target_type_filter"`
+	TargetIDFilter   *string            `json:"target_id_filter"`
+	CreatedFrom      pgtype.Timestamptz `json:"created_from"`
+	CreatedTo        pgtype.Timestamptz `json:"created_to"`
+	CursorCreatedAt  pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID         int64              `json:"cursor_id"`
+	LimitValue       int32              `json:"limit_value"`
+}
+
+func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditLogs,
+		arg.TenantFilter,
+		arg.ActorFilter,
+		arg.ActionFilter,
+		arg.TargetTypeFilter,
+		arg.TargetIDFilter,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.LimitValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ActorSubject,
+			&i.ImpersonationID,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.BeforeState,
+			&i.AfterState,
+			&i.RequestID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
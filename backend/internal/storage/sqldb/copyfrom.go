@@ -0,0 +1,50 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: copyfrom.go
+
+package sqldb
+
+import (
+	"context"
+)
+
+// iteratorForInsertMemberImportRows implements pgx.CopyFromSource.
+type iteratorForInsertMemberImportRows struct {
+	rows                 []InsertMemberImportRowsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertMemberImportRows) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertMemberImportRows) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ImportID,
+		r.rows[0].RowNumber,
+		r.rows[0].DisplayName,
+		r.rows[0].Phone,
+		r.rows[0].GroupCode,
+		r.rows[0].Title,
+		r.rows[0].Roles,
+		r.rows[0].Status,
+		r.rows[0].Errors,
+	}, nil
+}
+
+func (r iteratorForInsertMemberImportRows) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertMemberImportRows(ctx context.Context, arg []InsertMemberImportRowsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"member_import_rows"}, []string{"import_id", "row_number", "display_name", "phone", "group_code", "title", "roles", "status", "errors"}, &iteratorForInsertMemberImportRows{rows: arg})
+}
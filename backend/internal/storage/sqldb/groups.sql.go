@@ -11,6 +11,47 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const attachGroupSubtree = `-- name: AttachGroupSubtree :exec
+INSERT INTO group_closure (tenant_id, ancestor_id, descendant_id, depth)
+SELECT sub.tenant_id, super.ancestor_id, sub.descendant_id, super.depth + sub.depth + 1
+FROM group_closure super
+CROSS JOIN group_closure sub
+WHERE super.descendant_id = $1::uuid
+  AND sub.ancestor_id = $2::uuid
+`
+
+type AttachGroupSubtreeParams struct {
+	ParentID pgtype.UUID `json:"parent_id"`
+	GroupID  pgtype.UUID `json:"group_id"`
+}
+
+func (q *Queries) AttachGroupSubtree(ctx context.Context, arg AttachGroupSubtreeParams) error {
+	_, err := q.db.Exec(ctx, attachGroupSubtree, arg.ParentID, arg.GroupID)
+	return err
+}
+
+const clearPrimaryMemberships = `-- name: ClearPrimaryMemberships :exec
+UPDATE group_members
+SET
+    is_primary = FALSE,
+    updated_at = NOW()
+WHERE tenant_id = $1
+  AND identity_id = $2
+  AND group_id <> $3
+  AND is_primary
+`
+
+type ClearPrimaryMembershipsParams struct {
+	TenantID    pgtype.UUID `json:"tenant_id"`
+	IdentityID  pgtype.UUID `json:"identity_id"`
+	KeepGroupID pgtype.UUID `json:"keep_group_id"`
+}
+
+func (q *Queries) ClearPrimaryMemberships(ctx context.Context, arg ClearPrimaryMembershipsParams) error {
+	_, err := q.db.Exec(ctx, clearPrimaryMemberships, arg.TenantID, arg.IdentityID, arg.KeepGroupID)
+	return err
+}
+
 const countChildGroups = `-- name: CountChildGroups :one
 SELECT COUNT(*)
 FROM tenant_groups
@@ -24,6 +65,31 @@ func (q *Queries) CountChildGroups(ctx context.Context, parentID pgtype.UUID) (i
 	return count, err
 }
 
+const countDirectoryMembers = `-- name: CountDirectoryMembers :one
+SELECT COUNT(DISTINCT hit.identity_id)
+FROM group_members hit
+WHERE hit.tenant_id = $1
+  AND (
+      $2::text IS NULL
+      OR hit.display_name ILIKE '%' || $2::text || '%'
+      OR hit.phone ILIKE '%' || $2::text || '%'
+      OR hit.title ILIKE '%' || $2::text || '%'
+      OR hit.name_initials ILIKE '%' || $2::text || '%'
+  )
+`
+
+type CountDirectoryMembersParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	Search   *string     `json:"search"`
+}
+
+func (q *Queries) CountDirectoryMembers(ctx context.Context, arg CountDirectoryMembersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countDirectoryMembers, arg.TenantID, arg.Search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countGroupMembersWithSearch = `-- name: CountGroupMembersWithSearch :one
 SELECT COUNT(*)
 FROM group_members
@@ -60,6 +126,30 @@ func (q *Queries) CountMembersInGroup(ctx context.Context, groupID pgtype.UUID)
 	return count, err
 }
 
+const countSubtreeMembers = `-- name: CountSubtreeMembers :one
+SELECT COUNT(DISTINCT gm.identity_id)
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = $1::uuid
+  AND (
+      $2::text IS NULL
+      OR gm.display_name ILIKE '%' || $2::text || '%'
+      OR gm.phone ILIKE '%' || $2::text || '%'
+  )
+`
+
+type CountSubtreeMembersParams struct {
+	GroupID pgtype.UUID `json:"group_id"`
+	Search  *string     `json:"search"`
+}
+
+func (q *Queries) CountSubtreeMembers(ctx context.Context, arg CountSubtreeMembersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSubtreeMembers, arg.GroupID, arg.Search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createGroupMember = `-- name: CreateGroupMember :one
 INSERT INTO group_members (
     group_id,
@@ -68,7 +158,8 @@ INSERT INTO group_members (
     display_name,
     phone,
     title,
-    is_primary
+    is_primary,
+    name_initials
 ) VALUES (
     $1,
     $2,
@@ -76,7 +167,8 @@ INSERT INTO group_members (
     $4,
     $5,
     $6,
-    COALESCE($7::boolean, FALSE)
+    COALESCE($7::boolean, FALSE),
+    $8
 )
 ON CONFLICT (group_id, identity_id) DO UPDATE
 SET
@@ -84,6 +176,7 @@ SET
     phone = EXCLUDED.phone,
     title = EXCLUDED.title,
     is_primary = EXCLUDED.is_primary,
+    name_initials = EXCLUDED.name_initials,
     updated_at = NOW()
 RETURNING
     group_id,
@@ -94,17 +187,19 @@ RETURNING
     title,
     is_primary,
     created_at,
-    updated_at
+    updated_at,
+    name_initials
 `
 
 type CreateGroupMemberParams struct {
-	GroupID     pgtype.UUID `json:"group_id"`
-	IdentityID  pgtype.UUID `json:"identity_id"`
-	TenantID    pgtype.UUID `json:"tenant_id"`
-	DisplayName string      `json:"display_name"`
-	Phone       string      `json:"phone"`
-	Title       *string     `json:"title"`
-	IsPrimary   bool        `json:"is_primary"`
+	GroupID      pgtype.UUID `json:"group_id"`
+	IdentityID   pgtype.UUID `json:"identity_id"`
+	TenantID     pgtype.UUID `json:"tenant_id"`
+	DisplayName  string      `json:"display_name"`
+	Phone        string      `json:"phone"`
+	Title        *string     `json:"title"`
+	IsPrimary    bool        `json:"is_primary"`
+	NameInitials *string     `json:"name_initials"`
 }
 
 func (q *Queries) CreateGroupMember(ctx context.Context, arg CreateGroupMemberParams) (GroupMember, error) {
@@ -116,6 +211,7 @@ func (q *Queries) CreateGroupMember(ctx context.Context, arg CreateGroupMemberPa
 		arg.Phone,
 		arg.Title,
 		arg.IsPrimary,
+		arg.NameInitials,
 	)
 	var i GroupMember
 	err := row.Scan(
@@ -128,6 +224,7 @@ func (q *Queries) CreateGroupMember(ctx context.Context, arg CreateGroupMemberPa
 		&i.IsPrimary,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NameInitials,
 	)
 	return i, err
 }
@@ -162,7 +259,8 @@ RETURNING
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 `
 
 type CreateTenantGroupParams struct {
@@ -199,6 +297,7 @@ func (q *Queries) CreateTenantGroup(ctx context.Context, arg CreateTenantGroupPa
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -229,6 +328,65 @@ func (q *Queries) DeleteTenantGroup(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const detachGroupSubtree = `-- name: DetachGroupSubtree :exec
+DELETE FROM group_closure
+WHERE descendant_id IN (
+    SELECT sub.descendant_id
+    FROM group_closure sub
+    WHERE sub.ancestor_id = $1::uuid
+)
+AND ancestor_id NOT IN (
+    SELECT sub.descendant_id
+    FROM group_closure sub
+    WHERE sub.ancestor_id = $1::uuid
+)
+`
+
+func (q *Queries) DetachGroupSubtree(ctx context.Context, groupID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, detachGroupSubtree, groupID)
+	return err
+}
+
+const getGroupMember = `-- name: GetGroupMember :one
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE group_id = $1
+  AND identity_id = $2
+`
+
+type GetGroupMemberParams struct {
+	GroupID    pgtype.UUID `json:"group_id"`
+	IdentityID pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) GetGroupMember(ctx context.Context, arg GetGroupMemberParams) (GroupMember, error) {
+	row := q.db.QueryRow(ctx, getGroupMember, arg.GroupID, arg.IdentityID)
+	var i GroupMember
+	err := row.Scan(
+		&i.GroupID,
+		&i.IdentityID,
+		&i.TenantID,
+		&i.DisplayName,
+		&i.Phone,
+		&i.Title,
+		&i.IsPrimary,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NameInitials,
+	)
+	return i, err
+}
+
 const getTenantGroup = `-- name: GetTenantGroup :one
 SELECT
     id,
@@ -240,7 +398,8 @@ SELECT
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenant_groups
 WHERE id = $1
 `
@@ -259,10 +418,100 @@ func (q *Queries) GetTenantGroup(ctx context.Context, id pgtype.UUID) (TenantGro
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
+const insertGroupClosure = `-- name: InsertGroupClosure :exec
+INSERT INTO group_closure (tenant_id, ancestor_id, descendant_id, depth)
+SELECT $1::uuid, $2::uuid, $2::uuid, 0
+UNION ALL
+SELECT c.tenant_id, c.ancestor_id, $2::uuid, c.depth + 1
+FROM group_closure c
+WHERE c.descendant_id = $3::uuid
+`
+
+type InsertGroupClosureParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	GroupID  pgtype.UUID `json:"group_id"`
+	ParentID pgtype.UUID `json:"parent_id"`
+}
+
+func (q *Queries) InsertGroupClosure(ctx context.Context, arg InsertGroupClosureParams) error {
+	_, err := q.db.Exec(ctx, insertGroupClosure, arg.TenantID, arg.GroupID, arg.ParentID)
+	return err
+}
+
+const isGroupInSubtree = `-- name: IsGroupInSubtree :one
+SELECT EXISTS (
+    SELECT 1
+    FROM group_closure
+    WHERE ancestor_id = $1::uuid
+      AND descendant_id = $2::uuid
+)
+`
+
+type IsGroupInSubtreeParams struct {
+	RootID  pgtype.UUID `json:"root_id"`
+	GroupID pgtype.UUID `json:"group_id"`
+}
+
+func (q *Queries) IsGroupInSubtree(ctx context.Context, arg IsGroupInSubtreeParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isGroupInSubtree, arg.RootID, arg.GroupID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listAllGroupMembers = `-- name: ListAllGroupMembers :many
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE group_id = $1
+ORDER BY created_at ASC, identity_id ASC
+`
+
+func (q *Queries) ListAllGroupMembers(ctx context.Context, groupID pgtype.UUID) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, listAllGroupMembers, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAllGroups = `-- name: ListAllGroups :many
 SELECT
     id,
@@ -274,7 +523,8 @@ SELECT
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenant_groups
 ORDER BY tenant_id, sort_order ASC, name ASC
 `
@@ -299,6 +549,108 @@ func (q *Queries) ListAllGroups(ctx context.Context) ([]TenantGroup, error) {
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChildGroups = `-- name: ListChildGroups :many
+SELECT
+    id,
+    tenant_id,
+    code,
+    name,
+    description,
+    parent_id,
+    sort_order,
+    metadata,
+    created_at,
+    updated_at,
+    version
+FROM tenant_groups
+WHERE parent_id = $1
+ORDER BY sort_order ASC, name ASC
+`
+
+func (q *Queries) ListChildGroups(ctx context.Context, parentID pgtype.UUID) ([]TenantGroup, error) {
+	rows, err := q.db.Query(ctx, listChildGroups, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TenantGroup
+	for rows.Next() {
+		var i TenantGroup
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Code,
+			&i.Name,
+			&i.Description,
+			&i.ParentID,
+			&i.SortOrder,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupAncestors = `-- name: ListGroupAncestors :many
+SELECT
+    g.id,
+    g.tenant_id,
+    g.code,
+    g.name,
+    g.description,
+    g.parent_id,
+    g.sort_order,
+    g.metadata,
+    g.created_at,
+    g.updated_at,
+    g.version
+FROM group_closure c
+JOIN tenant_groups g ON g.id = c.ancestor_id
+WHERE c.descendant_id = $1::uuid
+ORDER BY c.depth DESC
+`
+
+func (q *Queries) ListGroupAncestors(ctx context.Context, groupID pgtype.UUID) ([]TenantGroup, error) {
+	rows, err := q.db.Query(ctx, listGroupAncestors, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TenantGroup
+	for rows.Next() {
+		var i TenantGroup
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Code,
+			&i.Name,
+			&i.Description,
+			&i.ParentID,
+			&i.SortOrder,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -320,7 +672,8 @@ SELECT
     title,
     is_primary,
     created_at,
-    updated_at
+    updated_at,
+    name_initials
 FROM group_members
 WHERE group_id = $1
   AND (
@@ -328,22 +681,30 @@ WHERE group_id = $1
       OR display_name ILIKE '%' || $2::text || '%'
       OR phone ILIKE '%' || $2::text || '%'
   )
-ORDER BY created_at DESC
-LIMIT COALESCE($4::int, 50)
-OFFSET COALESCE($3::int, 0)
+  AND (
+      $3::timestamptz IS NULL
+      OR (created_at, identity_id) < ($3::timestamptz, $4::uuid)
+  )
+ORDER BY created_at DESC, identity_id DESC
+LIMIT COALESCE($6::int, 50)
+OFFSET COALESCE($5::int, 0)
 `
 
 type ListGroupMembersParams struct {
-	GroupID     pgtype.UUID `json:"group_id"`
-	Search      *string     `json:"search"`
-	OffsetValue *int32      `json:"offset_value"`
-	LimitValue  *int32      `json:"limit_value"`
+	GroupID         pgtype.UUID        `json:"group_id"`
+	Search          *string            `json:"search"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	OffsetValue     *int32             `json:"offset_value"`
+	LimitValue      *int32             `json:"limit_value"`
 }
 
 func (q *Queries) ListGroupMembers(ctx context.Context, arg ListGroupMembersParams) ([]GroupMember, error) {
 	rows, err := q.db.Query(ctx, listGroupMembers,
 		arg.GroupID,
 		arg.Search,
+		arg.CursorCreatedAt,
+		arg.CursorID,
 		arg.OffsetValue,
 		arg.LimitValue,
 	)
@@ -364,6 +725,7 @@ func (q *Queries) ListGroupMembers(ctx context.Context, arg ListGroupMembersPara
 			&i.IsPrimary,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.NameInitials,
 		); err != nil {
 			return nil, err
 		}
@@ -375,46 +737,143 @@ func (q *Queries) ListGroupMembers(ctx context.Context, arg ListGroupMembersPara
 	return items, nil
 }
 
-const listGroupsForIdentity = `-- name: ListGroupsForIdentity :many
+const listGroupPaths = `-- name: ListGroupPaths :many
 SELECT
-    group_id,
-    identity_id,
-    tenant_id,
-    display_name,
-    phone,
-    title,
-    is_primary,
-    created_at,
-    updated_at
-FROM group_members
-WHERE tenant_id = $1
-  AND identity_id = $2
+    c.descendant_id AS group_id,
+    g.id AS ancestor_id,
+    g.name AS ancestor_name
+FROM group_closure c
+JOIN tenant_groups g ON g.id = c.ancestor_id
+WHERE c.descendant_id = ANY($1::uuid[])
+ORDER BY c.descendant_id, c.depth DESC
 `
 
-type ListGroupsForIdentityParams struct {
-	TenantID   pgtype.UUID `json:"tenant_id"`
-	IdentityID pgtype.UUID `json:"identity_id"`
+type ListGroupPathsRow struct {
+	GroupID      pgtype.UUID `json:"group_id"`
+	AncestorID   pgtype.UUID `json:"ancestor_id"`
+	AncestorName string      `json:"ancestor_name"`
 }
 
-func (q *Queries) ListGroupsForIdentity(ctx context.Context, arg ListGroupsForIdentityParams) ([]GroupMember, error) {
-	rows, err := q.db.Query(ctx, listGroupsForIdentity, arg.TenantID, arg.IdentityID)
+func (q *Queries) ListGroupPaths(ctx context.Context, groupIds []pgtype.UUID) ([]ListGroupPathsRow, error) {
+	rows, err := q.db.Query(ctx, listGroupPaths, groupIds)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GroupMember
+	var items []ListGroupPathsRow
 	for rows.Next() {
-		var i GroupMember
-		if err := rows.Scan(
-			&i.GroupID,
-			&i.IdentityID,
-			&i.TenantID,
-			&i.DisplayName,
+		var i ListGroupPathsRow
+		if err := rows.Scan(&i.GroupID, &i.AncestorID, &i.AncestorName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupSubtree = `-- name: ListGroupSubtree :many
+SELECT
+    g.id,
+    g.tenant_id,
+    g.code,
+    g.name,
+    g.description,
+    g.parent_id,
+    g.sort_order,
+    g.metadata,
+    g.created_at,
+    g.updated_at,
+    g.version
+FROM group_closure c
+JOIN tenant_groups g ON g.id = c.descendant_id
+WHERE c.ancestor_id = $1::uuid
+  AND ($2::int IS NULL OR c.depth <= $2::int)
+ORDER BY c.depth ASC, g.sort_order ASC, g.name ASC
+`
+
+type ListGroupSubtreeParams struct {
+	GroupID  pgtype.UUID `json:"group_id"`
+	MaxDepth *int32      `json:"max_depth"`
+}
+
+func (q *Queries) ListGroupSubtree(ctx context.Context, arg ListGroupSubtreeParams) ([]TenantGroup, error) {
+	rows, err := q.db.Query(ctx, listGroupSubtree, arg.GroupID, arg.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TenantGroup
+	for rows.Next() {
+		var i TenantGroup
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Code,
+			&i.Name,
+			&i.Description,
+			&i.ParentID,
+			&i.SortOrder,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupsForIdentity = `-- name: ListGroupsForIdentity :many
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE tenant_id = $1
+  AND identity_id = $2
+ORDER BY is_primary DESC, created_at ASC, group_id ASC
+`
+
+type ListGroupsForIdentityParams struct {
+	TenantID   pgtype.UUID `json:"tenant_id"`
+	IdentityID pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) ListGroupsForIdentity(ctx context.Context, arg ListGroupsForIdentityParams) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, listGroupsForIdentity, arg.TenantID, arg.IdentityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
 			&i.Phone,
 			&i.Title,
 			&i.IsPrimary,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.NameInitials,
 		); err != nil {
 			return nil, err
 		}
@@ -493,6 +952,305 @@ func (q *Queries) ListMemberCountsForTenant(ctx context.Context, tenantID pgtype
 	return items, nil
 }
 
+const listMembersMissingInitials = `-- name: ListMembersMissingInitials :many
+SELECT
+    group_id,
+    identity_id,
+    display_name
+FROM group_members
+WHERE name_initials IS NULL
+ORDER BY group_id, identity_id
+LIMIT $1::int
+`
+
+type ListMembersMissingInitialsRow struct {
+	GroupID     pgtype.UUID `json:"group_id"`
+	IdentityID  pgtype.UUID `json:"identity_id"`
+	DisplayName string      `json:"display_name"`
+}
+
+func (q *Queries) ListMembersMissingInitials(ctx context.Context, limitValue int32) ([]ListMembersMissingInitialsRow, error) {
+	rows, err := q.db.Query(ctx, listMembersMissingInitials, limitValue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMembersMissingInitialsRow
+	for rows.Next() {
+		var i ListMembersMissingInitialsRow
+		if err := rows.Scan(&i.GroupID, &i.IdentityID, &i.DisplayName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMembershipsForIdentities = `-- name: ListMembershipsForIdentities :many
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE tenant_id = $1
+  AND identity_id = ANY($2::uuid[])
+ORDER BY identity_id, is_primary DESC, created_at ASC
+`
+
+type ListMembershipsForIdentitiesParams struct {
+	TenantID    pgtype.UUID   `json:"tenant_id"`
+	IdentityIds []pgtype.UUID `json:"identity_ids"`
+}
+
+func (q *Queries) ListMembershipsForIdentities(ctx context.Context, arg ListMembershipsForIdentitiesParams) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, listMembershipsForIdentities, arg.TenantID, arg.IdentityIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubtreeMemberCounts = `-- name: ListSubtreeMemberCounts :many
+SELECT
+    gm.group_id,
+    COUNT(*) AS member_count
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = $1::uuid
+GROUP BY gm.group_id
+`
+
+type ListSubtreeMemberCountsRow struct {
+	GroupID     pgtype.UUID `json:"group_id"`
+	MemberCount int64       `json:"member_count"`
+}
+
+func (q *Queries) ListSubtreeMemberCounts(ctx context.Context, groupID pgtype.UUID) ([]ListSubtreeMemberCountsRow, error) {
+	rows, err := q.db.Query(ctx, listSubtreeMemberCounts, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubtreeMemberCountsRow
+	for rows.Next() {
+		var i ListSubtreeMemberCountsRow
+		if err := rows.Scan(&i.GroupID, &i.MemberCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubtreeMembers = `-- name: ListSubtreeMembers :many
+SELECT
+    m.group_id,
+    m.identity_id,
+    m.tenant_id,
+    m.display_name,
+    m.phone,
+    m.title,
+    m.is_primary,
+    m.created_at,
+    m.updated_at,
+    m.name_initials
+FROM (
+    SELECT DISTINCT ON (gm.identity_id)
+        gm.group_id,
+        gm.identity_id,
+        gm.tenant_id,
+        gm.display_name,
+        gm.phone,
+        gm.title,
+        gm.is_primary,
+        gm.created_at,
+        gm.updated_at,
+        gm.name_initials
+    FROM group_closure c
+    JOIN group_members gm ON gm.group_id = c.descendant_id
+    WHERE c.ancestor_id = $1::uuid
+      AND (
+          $2::text IS NULL
+          OR gm.display_name ILIKE '%' || $2::text || '%'
+          OR gm.phone ILIKE '%' || $2::text || '%'
+      )
+    ORDER BY gm.identity_id, gm.is_primary DESC, c.depth ASC, gm.created_at ASC
+) m
+ORDER BY m.created_at DESC, m.identity_id ASC
+LIMIT COALESCE($4::int, 50)
+OFFSET COALESCE($3::int, 0)
+`
+
+type ListSubtreeMembersParams struct {
+	GroupID     pgtype.UUID `json:"group_id"`
+	Search      *string     `json:"search"`
+	OffsetValue *int32      `json:"offset_value"`
+	LimitValue  *int32      `json:"limit_value"`
+}
+
+// Lists each identity of the subtree once, through its primary membership when that lies
+// in the subtree and otherwise through the membership closest to the root.
+func (q *Queries) ListSubtreeMembers(ctx context.Context, arg ListSubtreeMembersParams) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, listSubtreeMembers,
+		arg.GroupID,
+		arg.Search,
+		arg.OffsetValue,
+		arg.LimitValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubtreeMemberships = `-- name: ListSubtreeMemberships :many
+SELECT
+    gm.group_id,
+    gm.identity_id,
+    gm.tenant_id,
+    gm.display_name,
+    gm.phone,
+    gm.title,
+    gm.is_primary,
+    gm.created_at,
+    gm.updated_at,
+    gm.name_initials
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = $1::uuid
+ORDER BY c.depth ASC, gm.group_id ASC, gm.created_at ASC
+`
+
+func (q *Queries) ListSubtreeMemberships(ctx context.Context, groupID pgtype.UUID) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, listSubtreeMemberships, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubtreeOnlyIdentities = `-- name: ListSubtreeOnlyIdentities :many
+SELECT DISTINCT gm.identity_id
+FROM group_closure c
+JOIN group_members gm ON gm.group_id = c.descendant_id
+WHERE c.ancestor_id = $1::uuid
+  AND NOT EXISTS (
+      SELECT 1
+      FROM group_members other
+      WHERE other.tenant_id = gm.tenant_id
+        AND other.identity_id = gm.identity_id
+        AND other.group_id NOT IN (
+            SELECT sub.descendant_id
+            FROM group_closure sub
+            WHERE sub.ancestor_id = $1::uuid
+        )
+  )
+ORDER BY gm.identity_id
+`
+
+// Identities whose memberships in the tenant all lie within the subtree of the group.
+func (q *Queries) ListSubtreeOnlyIdentities(ctx context.Context, groupID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listSubtreeOnlyIdentities, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var identity_id pgtype.UUID
+		if err := rows.Scan(&identity_id); err != nil {
+			return nil, err
+		}
+		items = append(items, identity_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTenantGroups = `-- name: ListTenantGroups :many
 SELECT
     id,
@@ -504,7 +1262,8 @@ SELECT
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenant_groups
 WHERE tenant_id = $1
 ORDER BY sort_order ASC, name ASC
@@ -530,6 +1289,7 @@ func (q *Queries) ListTenantGroups(ctx context.Context, tenantID pgtype.UUID) ([
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -541,6 +1301,77 @@ func (q *Queries) ListTenantGroups(ctx context.Context, tenantID pgtype.UUID) ([
 	return items, nil
 }
 
+const listTenantMembers = `-- name: ListTenantMembers :many
+SELECT
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+FROM group_members
+WHERE tenant_id = $1
+ORDER BY group_id, created_at ASC
+`
+
+func (q *Queries) ListTenantMembers(ctx context.Context, tenantID pgtype.UUID) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, listTenantMembers, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockTenantGroupTree = `-- name: LockTenantGroupTree :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::uuid::text, 0))
+`
+
+func (q *Queries) LockTenantGroupTree(ctx context.Context, tenantID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, lockTenantGroupTree, tenantID)
+	return err
+}
+
+const lockTenantGroupVersion = `-- name: LockTenantGroupVersion :one
+SELECT version
+FROM tenant_groups
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockTenantGroupVersion(ctx context.Context, id pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, lockTenantGroupVersion, id)
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
+
 const moveGroupMember = `-- name: MoveGroupMember :one
 UPDATE group_members
 SET
@@ -558,7 +1389,8 @@ RETURNING
     title,
     is_primary,
     created_at,
-    updated_at
+    updated_at,
+    name_initials
 `
 
 type MoveGroupMemberParams struct {
@@ -586,10 +1418,259 @@ func (q *Queries) MoveGroupMember(ctx context.Context, arg MoveGroupMemberParams
 		&i.IsPrimary,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NameInitials,
+	)
+	return i, err
+}
+
+const moveTenantGroup = `-- name: MoveTenantGroup :one
+UPDATE tenant_groups
+SET
+    parent_id = $1,
+    sort_order = $2,
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = $3
+RETURNING
+    id,
+    tenant_id,
+    code,
+    name,
+    description,
+    parent_id,
+    sort_order,
+    metadata,
+    created_at,
+    updated_at,
+    version
+`
+
+type MoveTenantGroupParams struct {
+	ParentID  pgtype.UUID `json:"parent_id"`
+	SortOrder int32       `json:"sort_order"`
+	ID        pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) MoveTenantGroup(ctx context.Context, arg MoveTenantGroupParams) (TenantGroup, error) {
+	row := q.db.QueryRow(ctx, moveTenantGroup, arg.ParentID, arg.SortOrder, arg.ID)
+	var i TenantGroup
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Code,
+		&i.Name,
+		&i.Description,
+		&i.ParentID,
+		&i.SortOrder,
+		&i.Metadata,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const nextGroupSortOrder = `-- name: NextGroupSortOrder :one
+SELECT (COALESCE(MAX(sort_order), -1) + 1)::int AS next_sort_order
+FROM tenant_groups
+WHERE tenant_id = $1
+  AND parent_id IS NOT DISTINCT FROM $2::uuid
+`
+
+type NextGroupSortOrderParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	ParentID pgtype.UUID `json:"parent_id"`
+}
+
+func (q *Queries) NextGroupSortOrder(ctx context.Context, arg NextGroupSortOrderParams) (int32, error) {
+	row := q.db.QueryRow(ctx, nextGroupSortOrder, arg.TenantID, arg.ParentID)
+	var next_sort_order int32
+	err := row.Scan(&next_sort_order)
+	return next_sort_order, err
+}
+
+const promotePrimaryMembership = `-- name: PromotePrimaryMembership :one
+UPDATE group_members
+SET
+    is_primary = TRUE,
+    updated_at = NOW()
+WHERE (group_id, identity_id) = (
+    SELECT gm.group_id, gm.identity_id
+    FROM group_members gm
+    WHERE gm.tenant_id = $1
+      AND gm.identity_id = $2
+    ORDER BY gm.created_at ASC, gm.group_id ASC
+    LIMIT 1
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM group_members p
+    WHERE p.tenant_id = $1
+      AND p.identity_id = $2
+      AND p.is_primary
+)
+RETURNING
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+`
+
+type PromotePrimaryMembershipParams struct {
+	TenantID   pgtype.UUID `json:"tenant_id"`
+	IdentityID pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) PromotePrimaryMembership(ctx context.Context, arg PromotePrimaryMembershipParams) (GroupMember, error) {
+	row := q.db.QueryRow(ctx, promotePrimaryMembership, arg.TenantID, arg.IdentityID)
+	var i GroupMember
+	err := row.Scan(
+		&i.GroupID,
+		&i.IdentityID,
+		&i.TenantID,
+		&i.DisplayName,
+		&i.Phone,
+		&i.Title,
+		&i.IsPrimary,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NameInitials,
 	)
 	return i, err
 }
 
+const reassignGroupInvitations = `-- name: ReassignGroupInvitations :exec
+UPDATE invitations
+SET
+    group_id = $1,
+    updated_at = NOW()
+WHERE group_id = $2
+`
+
+type ReassignGroupInvitationsParams struct {
+	NewGroupID pgtype.UUID `json:"new_group_id"`
+	GroupID    pgtype.UUID `json:"group_id"`
+}
+
+func (q *Queries) ReassignGroupInvitations(ctx context.Context, arg ReassignGroupInvitationsParams) error {
+	_, err := q.db.Exec(ctx, reassignGroupInvitations, arg.NewGroupID, arg.GroupID)
+	return err
+}
+
+const searchDirectoryMembers = `-- name: SearchDirectoryMembers :many
+SELECT
+    m.group_id,
+    m.identity_id,
+    m.tenant_id,
+    m.display_name,
+    m.phone,
+    m.title,
+    m.is_primary,
+    m.created_at,
+    m.updated_at,
+    m.name_initials
+FROM (
+    SELECT DISTINCT ON (gm.identity_id)
+        gm.group_id,
+        gm.identity_id,
+        gm.tenant_id,
+        gm.display_name,
+        gm.phone,
+        gm.title,
+        gm.is_primary,
+        gm.created_at,
+        gm.updated_at,
+        gm.name_initials
+    FROM group_members gm
+    WHERE gm.tenant_id = $1
+      AND gm.identity_id IN (
+          SELECT hit.identity_id
+          FROM group_members hit
+          WHERE hit.tenant_id = $1
+            AND (
+                $2::text IS NULL
+                OR hit.display_name ILIKE '%' || $2::text || '%'
+                OR hit.phone ILIKE '%' || $2::text || '%'
+                OR hit.title ILIKE '%' || $2::text || '%'
+                OR hit.name_initials ILIKE '%' || $2::text || '%'
+            )
+      )
+    ORDER BY gm.identity_id, gm.is_primary DESC, gm.created_at ASC
+) m
+ORDER BY m.display_name ASC, m.identity_id ASC
+LIMIT $4::int
+OFFSET $3::int
+`
+
+type SearchDirectoryMembersParams struct {
+	TenantID    pgtype.UUID `json:"tenant_id"`
+	Search      *string     `json:"search"`
+	OffsetValue int32       `json:"offset_value"`
+	LimitValue  int32       `json:"limit_value"`
+}
+
+// One row per identity matching the search in any of its memberships, represented by its
+// primary membership when it has one.
+func (q *Queries) SearchDirectoryMembers(ctx context.Context, arg SearchDirectoryMembersParams) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, searchDirectoryMembers,
+		arg.TenantID,
+		arg.Search,
+		arg.OffsetValue,
+		arg.LimitValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setMemberNameInitials = `-- name: SetMemberNameInitials :exec
+UPDATE group_members
+SET name_initials = $1
+WHERE group_id = $2
+  AND identity_id = $3
+`
+
+type SetMemberNameInitialsParams struct {
+	NameInitials *string     `json:"name_initials"`
+	GroupID      pgtype.UUID `json:"group_id"`
+	IdentityID   pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) SetMemberNameInitials(ctx context.Context, arg SetMemberNameInitialsParams) error {
+	_, err := q.db.Exec(ctx, setMemberNameInitials, arg.NameInitials, arg.GroupID, arg.IdentityID)
+	return err
+}
+
 const updateGroupMember = `-- name: UpdateGroupMember :one
 UPDATE group_members
 SET
@@ -597,9 +1678,10 @@ SET
     phone = $2,
     title = $3,
     is_primary = COALESCE($4::boolean, is_primary),
+    name_initials = $5,
     updated_at = NOW()
-WHERE group_id = $5
-  AND identity_id = $6
+WHERE group_id = $6
+  AND identity_id = $7
 RETURNING
     group_id,
     identity_id,
@@ -609,16 +1691,18 @@ RETURNING
     title,
     is_primary,
     created_at,
-    updated_at
+    updated_at,
+    name_initials
 `
 
 type UpdateGroupMemberParams struct {
-	DisplayName string      `json:"display_name"`
-	Phone       string      `json:"phone"`
-	Title       *string     `json:"title"`
-	IsPrimary   bool        `json:"is_primary"`
-	GroupID     pgtype.UUID `json:"group_id"`
-	IdentityID  pgtype.UUID `json:"identity_id"`
+	DisplayName  string      `json:"display_name"`
+	Phone        string      `json:"phone"`
+	Title        *string     `json:"title"`
+	IsPrimary    bool        `json:"is_primary"`
+	NameInitials *string     `json:"name_initials"`
+	GroupID      pgtype.UUID `json:"group_id"`
+	IdentityID   pgtype.UUID `json:"identity_id"`
 }
 
 func (q *Queries) UpdateGroupMember(ctx context.Context, arg UpdateGroupMemberParams) (GroupMember, error) {
@@ -627,6 +1711,7 @@ func (q *Queries) UpdateGroupMember(ctx context.Context, arg UpdateGroupMemberPa
 		arg.Phone,
 		arg.Title,
 		arg.IsPrimary,
+		arg.NameInitials,
 		arg.GroupID,
 		arg.IdentityID,
 	)
@@ -641,10 +1726,78 @@ func (q *Queries) UpdateGroupMember(ctx context.Context, arg UpdateGroupMemberPa
 		&i.IsPrimary,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NameInitials,
 	)
 	return i, err
 }
 
+const updateMemberContact = `-- name: UpdateMemberContact :many
+UPDATE group_members
+SET
+    display_name = $1,
+    phone = $2,
+    name_initials = $3,
+    updated_at = NOW()
+WHERE tenant_id = $4
+  AND identity_id = $5
+RETURNING
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+`
+
+type UpdateMemberContactParams struct {
+	DisplayName  string      `json:"display_name"`
+	Phone        string      `json:"phone"`
+	NameInitials *string     `json:"name_initials"`
+	TenantID     pgtype.UUID `json:"tenant_id"`
+	IdentityID   pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) UpdateMemberContact(ctx context.Context, arg UpdateMemberContactParams) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, updateMemberContact,
+		arg.DisplayName,
+		arg.Phone,
+		arg.NameInitials,
+		arg.TenantID,
+		arg.IdentityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateTenantGroup = `-- name: UpdateTenantGroup :one
 UPDATE tenant_groups
 SET
@@ -654,6 +1807,7 @@ SET
     parent_id = $4,
     sort_order = COALESCE($5::int, sort_order),
     metadata = COALESCE($6::jsonb, metadata),
+    version = version + 1,
     updated_at = NOW()
 WHERE id = $7
 RETURNING
@@ -666,7 +1820,8 @@ RETURNING
     sort_order,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 `
 
 type UpdateTenantGroupParams struct {
@@ -701,6 +1856,7 @@ func (q *Queries) UpdateTenantGroup(ctx context.Context, arg UpdateTenantGroupPa
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
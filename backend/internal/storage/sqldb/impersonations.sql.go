@@ -0,0 +1,343 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: impersonations.sql
+
+package sqldb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const completeImpersonationRequest = `-- name: CompleteImpersonationRequest :exec
+UPDATE impersonation_requests
+SET status = $1
+WHERE id = $2
+`
+
+type CompleteImpersonationRequestParams struct {
+	Status *int32 `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) CompleteImpersonationRequest(ctx context.Context, arg CompleteImpersonationRequestParams) error {
+	_, err := q.db.Exec(ctx, completeImpersonationRequest, arg.Status, arg.ID)
+	return err
+}
+
+const countImpersonationRequests = `-- name: CountImpersonationRequests :one
+SELECT COUNT(*) AS total
+FROM impersonation_requests
+WHERE session_id = $1
+`
+
+func (q *Queries) CountImpersonationRequests(ctx context.Context, sessionID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countImpersonationRequests, sessionID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const countImpersonationSessions = `-- name: CountImpersonationSessions :one
+SELECT COUNT(*) AS total
+FROM impersonation_sessions s
+WHERE
+    ($1::uuid IS NULL OR s.tenant_id = $1::uuid)
+    AND ($2::text IS NULL OR s.actor_subject = $2::text)
+    AND (
+        NOT $3::boolean
+        OR (s.ended_at IS NULL AND s.expires_at > NOW())
+    )
+`
+
+type CountImpersonationSessionsParams struct {
+	TenantFilter pgtype.UUID `json:"tenant_filter"`
+	ActorFilter  *string     `json:"actor_filter"`
+	ActiveOnly   bool        `json:"active_only"`
+}
+
+func (q *Queries) CountImpersonationSessions(ctx context.Context, arg CountImpersonationSessionsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countImpersonationSessions, arg.TenantFilter, arg.ActorFilter, arg.ActiveOnly)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const createImpersonationSession = `-- name: CreateImpersonationSession :one
+INSERT INTO impersonation_sessions (
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at,
+    ended_at,
+    created_at
+`
+
+type CreateImpersonationSessionParams struct {
+	ID           pgtype.UUID        `json:"id"`
+	TenantID     pgtype.UUID        `json:"tenant_id"`
+	ActorSubject string             `json:"actor_subject"`
+	Reason       string             `json:"reason"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateImpersonationSession(ctx context.Context, arg CreateImpersonationSessionParams) (ImpersonationSession, error) {
+	row := q.db.QueryRow(ctx, createImpersonationSession,
+		arg.ID,
+		arg.TenantID,
+		arg.ActorSubject,
+		arg.Reason,
+		arg.ExpiresAt,
+	)
+	var i ImpersonationSession
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ActorSubject,
+		&i.Reason,
+		&i.ExpiresAt,
+		&i.EndedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const endImpersonationSession = `-- name: EndImpersonationSession :one
+UPDATE impersonation_sessions
+SET ended_at = NOW()
+WHERE id = $1
+  AND ended_at IS NULL
+RETURNING
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at,
+    ended_at,
+    created_at
+`
+
+func (q *Queries) EndImpersonationSession(ctx context.Context, id pgtype.UUID) (ImpersonationSession, error) {
+	row := q.db.QueryRow(ctx, endImpersonationSession, id)
+	var i ImpersonationSession
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ActorSubject,
+		&i.Reason,
+		&i.ExpiresAt,
+		&i.EndedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getImpersonationSession = `-- name: GetImpersonationSession :one
+SELECT
+    id,
+    tenant_id,
+    actor_subject,
+    reason,
+    expires_at,
+    ended_at,
+    created_at
+FROM impersonation_sessions
+WHERE id = $1
+`
+
+func (q *Queries) GetImpersonationSession(ctx context.Context, id pgtype.UUID) (ImpersonationSession, error) {
+	row := q.db.QueryRow(ctx, getImpersonationSession, id)
+	var i ImpersonationSession
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.ActorSubject,
+		&i.Reason,
+		&i.ExpiresAt,
+		&i.EndedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertImpersonationRequest = `-- name: InsertImpersonationRequest :one
+INSERT INTO impersonation_requests (
+    session_id,
+    method,
+    path,
+    request_id
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING id
+`
+
+type InsertImpersonationRequestParams struct {
+	SessionID pgtype.UUID `json:"session_id"`
+	Method    string      `json:"method"`
+	Path      string      `json:"path"`
+	RequestID *string     `json:"request_id"`
+}
+
+func (q *Queries) InsertImpersonationRequest(ctx context.Context, arg InsertImpersonationRequestParams) (int64, error) {
+	row := q.db.QueryRow(ctx, insertImpersonationRequest,
+		arg.SessionID,
+		arg.Method,
+		arg.Path,
+		arg.RequestID,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const listImpersonationRequests = `-- name: ListImpersonationRequests :many
+SELECT
+    id,
+    session_id,
+    method,
+    path,
+    status,
+    request_id,
+    created_at
+FROM impersonation_requests
+WHERE session_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT COALESCE($3::int, 50)
+OFFSET COALESCE($2::int, 0)
+`
+
+type ListImpersonationRequestsParams struct {
+	SessionID   pgtype.UUID `json:"session_id"`
+	OffsetValue *int32      `json:"offset_value"`
+	LimitValue  *int32      `json:"limit_value"`
+}
+
+func (q *Queries) ListImpersonationRequests(ctx context.Context, arg ListImpersonationRequestsParams) ([]ImpersonationRequest, error) {
+	rows, err := q.db.Query(ctx, listImpersonationRequests, arg.SessionID, arg.OffsetValue, arg.LimitValue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImpersonationRequest
+	for rows.Next() {
+		var i ImpersonationRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Method,
+			&i.Path,
+			&i.Status,
+			&i.RequestID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImpersonationSessions = `-- name: ListImpersonationSessions :many
+SELECT
+    s.id,
+    s.tenant_id,
+    s.actor_subject,
+    s.reason,
+    s.expires_at,
+    s.ended_at,
+    s.created_at,
+    COALESCE(r.request_count, 0)::bigint AS request_count
+FROM impersonation_sessions s
+LEFT JOIN (
+    SELECT session_id, COUNT(*) AS request_count
+    FROM impersonation_requests
+    GROUP BY session_id
+) r ON r.session_id = s.id
+WHERE
+    ($1::uuid IS NULL OR s.tenant_id = $1::uuid)
+    AND ($2::text IS NULL OR s.actor_subject = $2::text)
+    AND (
+        NOT $3::boolean
+        OR (s.ended_at IS NULL AND s.expires_at > NOW())
+    )
+ORDER BY s.created_at DESC
+LIMIT COALESCE($5::int, 50)
+OFFSET COALESCE($4::int, 0)
+`
+
+type ListImpersonationSessionsParams struct {
+	TenantFilter pgtype.UUID `json:"tenant_filter"`
+	ActorFilter  *string     `json:"actor_filter"`
+	ActiveOnly   bool        `json:"active_only"`
+	OffsetValue  *int32      `json:"offset_value"`
+	LimitValue   *int32      `json:"limit_value"`
+}
+
+type ListImpersonationSessionsRow struct {
+	ID           pgtype.UUID        `json:"id"`
+	TenantID     pgtype.UUID        `json:"tenant_id"`
+	ActorSubject string             `json:"actor_subject"`
+	Reason       string             `json:"reason"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	EndedAt      pgtype.Timestamptz `json:"ended_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	RequestCount int64              `json:"request_count"`
+}
+
+func (q *Queries) ListImpersonationSessions(ctx context.Context, arg ListImpersonationSessionsParams) ([]ListImpersonationSessionsRow, error) {
+	rows, err := q.db.Query(ctx, listImpersonationSessions,
+		arg.TenantFilter,
+		arg.ActorFilter,
+		arg.ActiveOnly,
+		arg.OffsetValue,
+		arg.LimitValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListImpersonationSessionsRow
+	for rows.Next() {
+		var i ListImpersonationSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.ActorSubject,
+			&i.Reason,
+			&i.ExpiresAt,
+			&i.EndedAt,
+			&i.CreatedAt,
+			&i.RequestCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,447 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: invitations.sql
+
+package sqldb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const acceptInvitation = `-- name: AcceptInvitation :one
+UPDATE invitations
+SET
+    status = 'accepted',
+    accepted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND status IN ('pending', 'expired')
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+`
+
+func (q *Queries) AcceptInvitation(ctx context.Context, id pgtype.UUID) (Invitation, error) {
+	row := q.db.QueryRow(ctx, acceptInvitation, id)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.GroupID,
+		&i.IdentityID,
+		&i.Phone,
+		&i.DisplayName,
+		&i.Title,
+		&i.Roles,
+		&i.Status,
+		&i.SendCount,
+		&i.LastSentAt,
+		&i.LastError,
+		&i.ExpiresAt,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const countInvitations = `-- name: CountInvitations :one
+SELECT COUNT(*)
+FROM invitations
+WHERE tenant_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+`
+
+type CountInvitationsParams struct {
+	TenantID pgtype.UUID `json:"tenant_id"`
+	Status   *string     `json:"status"`
+}
+
+func (q *Queries) CountInvitations(ctx context.Context, arg CountInvitationsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countInvitations, arg.TenantID, arg.Status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createInvitation = `-- name: CreateInvitation :one
+INSERT INTO invitations (
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    expires_at,
+    invited_by
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
+)
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+`
+
+type CreateInvitationParams struct {
+	ID          pgtype.UUID        `json:"id"`
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	GroupID     pgtype.UUID        `json:"group_id"`
+	IdentityID  pgtype.UUID        `json:"identity_id"`
+	Phone       string             `json:"phone"`
+	DisplayName string             `json:"display_name"`
+	Title       *string            `json:"title"`
+	Roles       []string           `json:"roles"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	InvitedBy   *string            `json:"invited_by"`
+}
+
+func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error) {
+	row := q.db.QueryRow(ctx, createInvitation,
+		arg.ID,
+		arg.TenantID,
+		arg.GroupID,
+		arg.IdentityID,
+		arg.Phone,
+		arg.DisplayName,
+		arg.Title,
+		arg.Roles,
+		arg.ExpiresAt,
+		arg.InvitedBy,
+	)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.GroupID,
+		&i.IdentityID,
+		&i.Phone,
+		&i.DisplayName,
+		&i.Title,
+		&i.Roles,
+		&i.Status,
+		&i.SendCount,
+		&i.LastSentAt,
+		&i.LastError,
+		&i.ExpiresAt,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const expireInvitations = `-- name: ExpireInvitations :execrows
+UPDATE invitations
+SET
+    status = 'expired',
+    updated_at = NOW()
+WHERE tenant_id = $1
+  AND status = 'pending'
+  AND expires_at < NOW()
+`
+
+func (q *Queries) ExpireInvitations(ctx context.Context, tenantID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, expireInvitations, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getInvitation = `-- name: GetInvitation :one
+SELECT
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+FROM invitations
+WHERE id = $1
+`
+
+func (q *Queries) GetInvitation(ctx context.Context, id pgtype.UUID) (Invitation, error) {
+	row := q.db.QueryRow(ctx, getInvitation, id)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.GroupID,
+		&i.IdentityID,
+		&i.Phone,
+		&i.DisplayName,
+		&i.Title,
+		&i.Roles,
+		&i.Status,
+		&i.SendCount,
+		&i.LastSentAt,
+		&i.LastError,
+		&i.ExpiresAt,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listInvitations = `-- name: ListInvitations :many
+SELECT
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+FROM invitations
+WHERE tenant_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+ORDER BY created_at DESC
+LIMIT $4::int
+OFFSET $3::int
+`
+
+type ListInvitationsParams struct {
+	TenantID    pgtype.UUID `json:"tenant_id"`
+	Status      *string     `json:"status"`
+	OffsetValue int32       `json:"offset_value"`
+	LimitValue  int32       `json:"limit_value"`
+}
+
+func (q *Queries) ListInvitations(ctx context.Context, arg ListInvitationsParams) ([]Invitation, error) {
+	rows, err := q.db.Query(ctx, listInvitations,
+		arg.TenantID,
+		arg.Status,
+		arg.OffsetValue,
+		arg.LimitValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invitation
+	for rows.Next() {
+		var i Invitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.GroupID,
+			&i.IdentityID,
+			&i.Phone,
+			&i.DisplayName,
+			&i.Title,
+			&i.Roles,
+			&i.Status,
+			&i.SendCount,
+			&i.LastSentAt,
+			&i.LastError,
+			&i.ExpiresAt,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordInvitationDelivery = `-- name: RecordInvitationDelivery :one
+UPDATE invitations
+SET
+    status = 'pending',
+    send_count = send_count + 1,
+    last_sent_at = NOW(),
+    last_error = $1,
+    expires_at = $2,
+    updated_at = NOW()
+WHERE id = $3
+  AND status IN ('pending', 'expired')
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+`
+
+type RecordInvitationDeliveryParams struct {
+	LastError *string            `json:"last_error"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	ID        pgtype.UUID        `json:"id"`
+}
+
+func (q *Queries) RecordInvitationDelivery(ctx context.Context, arg RecordInvitationDeliveryParams) (Invitation, error) {
+	row := q.db.QueryRow(ctx, recordInvitationDelivery, arg.LastError, arg.ExpiresAt, arg.ID)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.GroupID,
+		&i.IdentityID,
+		&i.Phone,
+		&i.DisplayName,
+		&i.Title,
+		&i.Roles,
+		&i.Status,
+		&i.SendCount,
+		&i.LastSentAt,
+		&i.LastError,
+		&i.ExpiresAt,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const revokeInvitation = `-- name: RevokeInvitation :one
+UPDATE invitations
+SET
+    status = 'revoked',
+    revoked_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND status IN ('pending', 'expired')
+RETURNING
+    id,
+    tenant_id,
+    group_id,
+    identity_id,
+    phone,
+    display_name,
+    title,
+    roles,
+    status,
+    send_count,
+    last_sent_at,
+    last_error,
+    expires_at,
+    invited_by,
+    accepted_at,
+    revoked_at,
+    created_at,
+    updated_at
+`
+
+func (q *Queries) RevokeInvitation(ctx context.Context, id pgtype.UUID) (Invitation, error) {
+	row := q.db.QueryRow(ctx, revokeInvitation, id)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.GroupID,
+		&i.IdentityID,
+		&i.Phone,
+		&i.DisplayName,
+		&i.Title,
+		&i.Roles,
+		&i.Status,
+		&i.SendCount,
+		&i.LastSentAt,
+		&i.LastError,
+		&i.ExpiresAt,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
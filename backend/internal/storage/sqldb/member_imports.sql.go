@@ -0,0 +1,575 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: member_imports.sql
+
+package sqldb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimMemberImport = `-- name: ClaimMemberImport :one
+UPDATE member_imports
+SET
+    status = 'running',
+    started_at = COALESCE(started_at, NOW()),
+    finished_at = NULL,
+    lease_expires_at = NOW() + ($1::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE id = (
+    SELECT q.id
+    FROM member_imports q
+    WHERE q.status = 'queued'
+       OR (q.status = 'running' AND q.lease_expires_at < NOW())
+    ORDER BY q.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at
+`
+
+func (q *Queries) ClaimMemberImport(ctx context.Context, leaseSeconds int32) (MemberImport, error) {
+	row := q.db.QueryRow(ctx, claimMemberImport, leaseSeconds)
+	var i MemberImport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Filename,
+		&i.Status,
+		&i.TotalRows,
+		&i.ValidRows,
+		&i.CreatedBy,
+		&i.CommittedBy,
+		&i.LeaseExpiresAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const completeMemberImportRow = `-- name: CompleteMemberImportRow :exec
+UPDATE member_import_rows
+SET
+    status = $1,
+    errors = $2,
+    identity_id = $3,
+    processed_at = NOW()
+WHERE import_id = $4
+  AND row_number = $5
+`
+
+type CompleteMemberImportRowParams struct {
+	Status     string      `json:"status"`
+	Errors     []string    `json:"errors"`
+	IdentityID pgtype.UUID `json:"identity_id"`
+	ImportID   pgtype.UUID `json:"import_id"`
+	RowNumber  int32       `json:"row_number"`
+}
+
+func (q *Queries) CompleteMemberImportRow(ctx context.Context, arg CompleteMemberImportRowParams) error {
+	_, err := q.db.Exec(ctx, completeMemberImportRow,
+		arg.Status,
+		arg.Errors,
+		arg.IdentityID,
+		arg.ImportID,
+		arg.RowNumber,
+	)
+	return err
+}
+
+const countMemberImportRows = `-- name: CountMemberImportRows :one
+SELECT COUNT(*)
+FROM member_import_rows
+WHERE import_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+`
+
+type CountMemberImportRowsParams struct {
+	ImportID pgtype.UUID `json:"import_id"`
+	Status   *string     `json:"status"`
+}
+
+func (q *Queries) CountMemberImportRows(ctx context.Context, arg CountMemberImportRowsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countMemberImportRows, arg.ImportID, arg.Status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countMemberImportRowsByStatus = `-- name: CountMemberImportRowsByStatus :many
+SELECT
+    status,
+    COUNT(*) AS row_count
+FROM member_import_rows
+WHERE import_id = $1
+GROUP BY status
+`
+
+type CountMemberImportRowsByStatusRow struct {
+	Status   string `json:"status"`
+	RowCount int64  `json:"row_count"`
+}
+
+func (q *Queries) CountMemberImportRowsByStatus(ctx context.Context, importID pgtype.UUID) ([]CountMemberImportRowsByStatusRow, error) {
+	rows, err := q.db.Query(ctx, countMemberImportRowsByStatus, importID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountMemberImportRowsByStatusRow
+	for rows.Next() {
+		var i CountMemberImportRowsByStatusRow
+		if err := rows.Scan(&i.Status, &i.RowCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countMemberImports = `-- name: CountMemberImports :one
+SELECT COUNT(*)
+FROM member_imports
+WHERE tenant_id = $1
+`
+
+func (q *Queries) CountMemberImports(ctx context.Context, tenantID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countMemberImports, tenantID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createMemberImport = `-- name: CreateMemberImport :one
+INSERT INTO member_imports (
+    id,
+    tenant_id,
+    filename,
+    total_rows,
+    valid_rows,
+    created_by
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at
+`
+
+type CreateMemberImportParams struct {
+	ID        pgtype.UUID `json:"id"`
+	TenantID  pgtype.UUID `json:"tenant_id"`
+	Filename  string      `json:"filename"`
+	TotalRows int32       `json:"total_rows"`
+	ValidRows int32       `json:"valid_rows"`
+	CreatedBy *string     `json:"created_by"`
+}
+
+func (q *Queries) CreateMemberImport(ctx context.Context, arg CreateMemberImportParams) (MemberImport, error) {
+	row := q.db.QueryRow(ctx, createMemberImport,
+		arg.ID,
+		arg.TenantID,
+		arg.Filename,
+		arg.TotalRows,
+		arg.ValidRows,
+		arg.CreatedBy,
+	)
+	var i MemberImport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Filename,
+		&i.Status,
+		&i.TotalRows,
+		&i.ValidRows,
+		&i.CreatedBy,
+		&i.CommittedBy,
+		&i.LeaseExpiresAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const extendMemberImportLease = `-- name: ExtendMemberImportLease :exec
+UPDATE member_imports
+SET
+    lease_expires_at = NOW() + ($1::int * INTERVAL '1 second'),
+    updated_at = NOW()
+WHERE id = $2
+  AND status = 'running'
+`
+
+type ExtendMemberImportLeaseParams struct {
+	LeaseSeconds int32       `json:"lease_seconds"`
+	ID           pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) ExtendMemberImportLease(ctx context.Context, arg ExtendMemberImportLeaseParams) error {
+	_, err := q.db.Exec(ctx, extendMemberImportLease, arg.LeaseSeconds, arg.ID)
+	return err
+}
+
+const finishMemberImport = `-- name: FinishMemberImport :exec
+UPDATE member_imports
+SET
+    status = $1,
+    lease_expires_at = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = $2
+`
+
+type FinishMemberImportParams struct {
+	Status string      `json:"status"`
+	ID     pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) FinishMemberImport(ctx context.Context, arg FinishMemberImportParams) error {
+	_, err := q.db.Exec(ctx, finishMemberImport, arg.Status, arg.ID)
+	return err
+}
+
+const getMemberImport = `-- name: GetMemberImport :one
+SELECT
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at
+FROM member_imports
+WHERE id = $1
+`
+
+func (q *Queries) GetMemberImport(ctx context.Context, id pgtype.UUID) (MemberImport, error) {
+	row := q.db.QueryRow(ctx, getMemberImport, id)
+	var i MemberImport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Filename,
+		&i.Status,
+		&i.TotalRows,
+		&i.ValidRows,
+		&i.CreatedBy,
+		&i.CommittedBy,
+		&i.LeaseExpiresAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+type InsertMemberImportRowsParams struct {
+	ImportID    pgtype.UUID `json:"import_id"`
+	RowNumber   int32       `json:"row_number"`
+	DisplayName string      `json:"display_name"`
+	Phone       string      `json:"phone"`
+	GroupCode   string      `json:"group_code"`
+	Title       *string     `json:"title"`
+	Roles       []string    `json:"roles"`
+	Status      string      `json:"status"`
+	Errors      []string    `json:"errors"`
+}
+
+const listMemberImportRows = `-- name: ListMemberImportRows :many
+SELECT
+    import_id,
+    row_number,
+    display_name,
+    phone,
+    group_code,
+    title,
+    roles,
+    status,
+    errors,
+    identity_id,
+    processed_at
+FROM member_import_rows
+WHERE import_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+ORDER BY row_number ASC
+LIMIT $4::int
+OFFSET $3::int
+`
+
+type ListMemberImportRowsParams struct {
+	ImportID    pgtype.UUID `json:"import_id"`
+	Status      *string     `json:"status"`
+	OffsetValue int32       `json:"offset_value"`
+	LimitValue  int32       `json:"limit_value"`
+}
+
+func (q *Queries) ListMemberImportRows(ctx context.Context, arg ListMemberImportRowsParams) ([]MemberImportRow, error) {
+	rows, err := q.db.Query(ctx, listMemberImportRows,
+		arg.ImportID,
+		arg.Status,
+		arg.OffsetValue,
+		arg.LimitValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MemberImportRow
+	for rows.Next() {
+		var i MemberImportRow
+		if err := rows.Scan(
+			&i.ImportID,
+			&i.RowNumber,
+			&i.DisplayName,
+			&i.Phone,
+			&i.GroupCode,
+			&i.Title,
+			&i.Roles,
+			&i.Status,
+			&i.Errors,
+			&i.IdentityID,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberImports = `-- name: ListMemberImports :many
+SELECT
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at
+FROM member_imports
+WHERE tenant_id = $1
+ORDER BY created_at DESC
+LIMIT $3::int
+OFFSET $2::int
+`
+
+type ListMemberImportsParams struct {
+	TenantID    pgtype.UUID `json:"tenant_id"`
+	OffsetValue int32       `json:"offset_value"`
+	LimitValue  int32       `json:"limit_value"`
+}
+
+func (q *Queries) ListMemberImports(ctx context.Context, arg ListMemberImportsParams) ([]MemberImport, error) {
+	rows, err := q.db.Query(ctx, listMemberImports, arg.TenantID, arg.OffsetValue, arg.LimitValue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MemberImport
+	for rows.Next() {
+		var i MemberImport
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Filename,
+			&i.Status,
+			&i.TotalRows,
+			&i.ValidRows,
+			&i.CreatedBy,
+			&i.CommittedBy,
+			&i.LeaseExpiresAt,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingMemberImportRows = `-- name: ListPendingMemberImportRows :many
+SELECT
+    import_id,
+    row_number,
+    display_name,
+    phone,
+    group_code,
+    title,
+    roles,
+    status,
+    errors,
+    identity_id,
+    processed_at
+FROM member_import_rows
+WHERE import_id = $1
+  AND status = 'pending'
+ORDER BY row_number ASC
+LIMIT $2::int
+`
+
+type ListPendingMemberImportRowsParams struct {
+	ImportID   pgtype.UUID `json:"import_id"`
+	LimitValue int32       `json:"limit_value"`
+}
+
+func (q *Queries) ListPendingMemberImportRows(ctx context.Context, arg ListPendingMemberImportRowsParams) ([]MemberImportRow, error) {
+	rows, err := q.db.Query(ctx, listPendingMemberImportRows, arg.ImportID, arg.LimitValue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MemberImportRow
+	for rows.Next() {
+		var i MemberImportRow
+		if err := rows.Scan(
+			&i.ImportID,
+			&i.RowNumber,
+			&i.DisplayName,
+			&i.Phone,
+			&i.GroupCode,
+			&i.Title,
+			&i.Roles,
+			&i.Status,
+			&i.Errors,
+			&i.IdentityID,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queueMemberImport = `-- name: QueueMemberImport :one
+UPDATE member_imports
+SET
+    status = 'queued',
+    committed_by = $1,
+    updated_at = NOW()
+WHERE id = $2
+  AND status = $3
+RETURNING
+    id,
+    tenant_id,
+    filename,
+    status,
+    total_rows,
+    valid_rows,
+    created_by,
+    committed_by,
+    lease_expires_at,
+    started_at,
+    finished_at,
+    created_at,
+    updated_at
+`
+
+type QueueMemberImportParams struct {
+	CommittedBy *string     `json:"committed_by"`
+	ID          pgtype.UUID `json:"id"`
+	FromStatus  string      `json:"from_status"`
+}
+
+func (q *Queries) QueueMemberImport(ctx context.Context, arg QueueMemberImportParams) (MemberImport, error) {
+	row := q.db.QueryRow(ctx, queueMemberImport, arg.CommittedBy, arg.ID, arg.FromStatus)
+	var i MemberImport
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Filename,
+		&i.Status,
+		&i.TotalRows,
+		&i.ValidRows,
+		&i.CreatedBy,
+		&i.CommittedBy,
+		&i.LeaseExpiresAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const resetFailedMemberImportRows = `-- name: ResetFailedMemberImportRows :execrows
+UPDATE member_import_rows
+SET
+    status = 'pending',
+    errors = '{}',
+    processed_at = NULL
+WHERE import_id = $1
+  AND status = 'failed'
+`
+
+func (q *Queries) ResetFailedMemberImportRows(ctx context.Context, importID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, resetFailedMemberImportRows, importID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -8,18 +8,154 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditLog struct {
+	ID              int64              `json:"id"`
+	TenantID        pgtype.UUID        `json:"tenant_id"`
+	ActorSubject    *string            `json:"actor_subject"`
+	ImpersonationID pgtype.UUID        `json:"impersonation_id"`
+	Action          string             `json:"action"`
+	TargetType      string             `json:"target_type"`
+	TargetID        string             `json:"target_id"`
+	BeforeState     []byte             `json:"before_state"`
+	AfterState      []byte             `json:"after_state"`
+	RequestID       *string            `json:"request_id"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
+type DomainEvent struct {
+	ID         pgtype.UUID        `json:"id"`
+	TenantID   pgtype.UUID        `json:"tenant_id"`
+	EventType  string             `json:"event_type"`
+	Payload    []byte             `json:"payload"`
+	RequestID  *string            `json:"request_id"`
+	OccurredAt pgtype.Timestamptz `json:"occurred_at"`
+}
+
+type GroupClosure struct {
+	TenantID     pgtype.UUID `json:"tenant_id"`
+	AncestorID   pgtype.UUID `json:"ancestor_id"`
+	DescendantID pgtype.UUID `json:"descendant_id"`
+	Depth        int32       `json:"depth"`
+}
+
 type GroupMember struct {
+	GroupID      pgtype.UUID        `json:"group_id"`
+	IdentityID   pgtype.UUID        `json:"identity_id"`
+	TenantID     pgtype.UUID        `json:"tenant_id"`
+	DisplayName  string             `json:"display_name"`
+	Phone        string             `json:"phone"`
+	Title        *string            `json:"title"`
+	IsPrimary    bool               `json:"is_primary"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	NameInitials *string            `json:"name_initials"`
+}
+
+type IdempotencyKey struct {
+	Scope               string             `json:"scope"`
+	IdempotencyKey      string             `json:"idempotency_key"`
+	Method              string             `json:"method"`
+	Path                string             `json:"path"`
+	Fingerprint         string             `json:"fingerprint"`
+	Status              string             `json:"status"`
+	ResponseStatus      *int32             `json:"response_status"`
+	ResponseContentType *string            `json:"response_content_type"`
+	ResponseBody        []byte             `json:"response_body"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	CompletedAt         pgtype.Timestamptz `json:"completed_at"`
+	ExpiresAt           pgtype.Timestamptz `json:"expires_at"`
+	LockToken           pgtype.UUID        `json:"lock_token"`
+	ResourceID          *string            `json:"resource_id"`
+}
+
+type ImpersonationRequest struct {
+	ID        int64              `json:"id"`
+	SessionID pgtype.UUID        `json:"session_id"`
+	Method    string             `json:"method"`
+	Path      string             `json:"path"`
+	Status    *int32             `json:"status"`
+	RequestID *string            `json:"request_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type ImpersonationSession struct {
+	ID           pgtype.UUID        `json:"id"`
+	TenantID     pgtype.UUID        `json:"tenant_id"`
+	ActorSubject string             `json:"actor_subject"`
+	Reason       string             `json:"reason"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	EndedAt      pgtype.Timestamptz `json:"ended_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type Invitation struct {
+	ID          pgtype.UUID        `json:"id"`
+	TenantID    pgtype.UUID        `json:"tenant_id"`
 	GroupID     pgtype.UUID        `json:"group_id"`
 	IdentityID  pgtype.UUID        `json:"identity_id"`
-	TenantID    pgtype.UUID        `json:"tenant_id"`
-	DisplayName string             `json:"display_name"`
 	Phone       string             `json:"phone"`
+	DisplayName string             `json:"display_name"`
 	Title       *string            `json:"title"`
-	IsPrimary   bool               `json:"is_primary"`
+	Roles       []string           `json:"roles"`
+	Status      string             `json:"status"`
+	SendCount   int32              `json:"send_count"`
+	LastSentAt  pgtype.Timestamptz `json:"last_sent_at"`
+	LastError   *string            `json:"last_error"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	InvitedBy   *string            `json:"invited_by"`
+	AcceptedAt  pgtype.Timestamptz `json:"accepted_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
 
+type KetoSyncQueue struct {
+	ID            int64              `json:"id"`
+	SyncKey       string             `json:"sync_key"`
+	Kind          string             `json:"kind"`
+	TenantID      pgtype.UUID        `json:"tenant_id"`
+	GroupID       pgtype.UUID        `json:"group_id"`
+	IdentityID    pgtype.UUID        `json:"identity_id"`
+	Generation    int64              `json:"generation"`
+	Attempts      int32              `json:"attempts"`
+	LastError     *string            `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LockedUntil   pgtype.Timestamptz `json:"locked_until"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	RoleID        pgtype.UUID        `json:"role_id"`
+}
+
+type MemberImport struct {
+	ID             pgtype.UUID        `json:"id"`
+	TenantID       pgtype.UUID        `json:"tenant_id"`
+	Filename       string             `json:"filename"`
+	Status         string             `json:"status"`
+	TotalRows      int32              `json:"total_rows"`
+	ValidRows      int32              `json:"valid_rows"`
+	CreatedBy      *string            `json:"created_by"`
+	CommittedBy    *string            `json:"committed_by"`
+	LeaseExpiresAt pgtype.Timestamptz `json:"lease_expires_at"`
+	StartedAt      pgtype.Timestamptz `json:"started_at"`
+	FinishedAt     pgtype.Timestamptz `json:"finished_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+type MemberImportRow struct {
+	ImportID    pgtype.UUID        `json:"import_id"`
+	RowNumber   int32              `json:"row_number"`
+	DisplayName string             `json:"display_name"`
+	Phone       string             `json:"phone"`
+	GroupCode   string             `json:"group_code"`
+	Title       *string            `json:"title"`
+	Roles       []string           `json:"roles"`
+	Status      string             `json:"status"`
+	Errors      []string           `json:"errors"`
+	IdentityID  pgtype.UUID        `json:"identity_id"`
+	ProcessedAt pgtype.Timestamptz `json:"processed_at"`
+}
+
 type Permission struct {
 	Code        string             `json:"code"`
 	Scope       string             `json:"scope"`
@@ -54,6 +190,38 @@ type RolePermission struct {
 	CreatedAt      pgtype.Timestamptz `json:"created_at"`
 }
 
+type ScimGroup struct {
+	GroupID    pgtype.UUID        `json:"group_id"`
+	TenantID   pgtype.UUID        `json:"tenant_id"`
+	ExternalID string             `json:"external_id"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type ScimToken struct {
+	ID          pgtype.UUID        `json:"id"`
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	Name        string             `json:"name"`
+	TokenHash   string             `json:"token_hash"`
+	TokenPrefix string             `json:"token_prefix"`
+	CreatedBy   *string            `json:"created_by"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type ScimUser struct {
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	IdentityID  pgtype.UUID        `json:"identity_id"`
+	ExternalID  *string            `json:"external_id"`
+	UserName    string             `json:"user_name"`
+	DisplayName string             `json:"display_name"`
+	Phone       string             `json:"phone"`
+	Title       *string            `json:"title"`
+	Active      bool               `json:"active"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Tenant struct {
 	ID           pgtype.UUID        `json:"id"`
 	Code         string             `json:"code"`
@@ -64,6 +232,7 @@ type Tenant struct {
 	Metadata     []byte             `json:"metadata"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	Version      int64              `json:"version"`
 }
 
 type TenantGroup struct {
@@ -77,4 +246,40 @@ type TenantGroup struct {
 	Metadata    []byte             `json:"metadata"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	Version     int64              `json:"version"`
+}
+
+type WebhookDelivery struct {
+	ID             pgtype.UUID        `json:"id"`
+	SubscriptionID pgtype.UUID        `json:"subscription_id"`
+	EventID        pgtype.UUID        `json:"event_id"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `json:"next_attempt_at"`
+	LastStatusCode *int32             `json:"last_status_code"`
+	LastError      *string            `json:"last_error"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID         int64              `json:"id"`
+	DeliveryID pgtype.UUID        `json:"delivery_id"`
+	StatusCode *int32             `json:"status_code"`
+	Error      *string            `json:"error"`
+	DurationMs int32              `json:"duration_ms"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type WebhookSubscription struct {
+	ID          pgtype.UUID        `json:"id"`
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	Url         string             `json:"url"`
+	Secret      string             `json:"secret"`
+	EventTypes  []string           `json:"event_types"`
+	Active      bool               `json:"active"`
+	Description *string            `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
@@ -129,7 +129,7 @@ func (q *Queries) DeleteRole(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
-const deleteRoleAssignment = `-- name: DeleteRoleAssignment :exec
+const deleteRoleAssignment = `-- name: DeleteRoleAssignment :execrows
 DELETE FROM role_assignments
 WHERE role_id = $1
   AND identity_id = $2
@@ -140,9 +140,12 @@ type DeleteRoleAssignmentParams struct {
 	IdentityID pgtype.UUID `json:"identity_id"`
 }
 
-func (q *Queries) DeleteRoleAssignment(ctx context.Context, arg DeleteRoleAssignmentParams) error {
-	_, err := q.db.Exec(ctx, deleteRoleAssignment, arg.RoleID, arg.IdentityID)
-	return err
+func (q *Queries) DeleteRoleAssignment(ctx context.Context, arg DeleteRoleAssignmentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRoleAssignment, arg.RoleID, arg.IdentityID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const deleteRolePermissions = `-- name: DeleteRolePermissions :exec
@@ -226,6 +229,59 @@ func (q *Queries) InsertRolePermission(ctx context.Context, arg InsertRolePermis
 	return err
 }
 
+const listIdentityRoleAssignments = `-- name: ListIdentityRoleAssignments :many
+SELECT
+    ra.identity_id,
+    r.id AS role_id,
+    r.tenant_id AS role_tenant_id,
+    r.code AS role_code,
+    r.name AS role_name
+FROM role_assignments ra
+JOIN roles r ON r.id = ra.role_id
+WHERE ra.identity_id = $1
+  AND (ra.tenant_id = $2 OR r.tenant_id = $2)
+ORDER BY r.code
+`
+
+type ListIdentityRoleAssignmentsParams struct {
+	IdentityID pgtype.UUID `json:"identity_id"`
+	TenantID   pgtype.UUID `json:"tenant_id"`
+}
+
+type ListIdentityRoleAssignmentsRow struct {
+	IdentityID   pgtype.UUID `json:"identity_id"`
+	RoleID       pgtype.UUID `json:"role_id"`
+	RoleTenantID pgtype.UUID `json:"role_tenant_id"`
+	RoleCode     string      `json:"role_code"`
+	RoleName     string      `json:"role_name"`
+}
+
+func (q *Queries) ListIdentityRoleAssignments(ctx context.Context, arg ListIdentityRoleAssignmentsParams) ([]ListIdentityRoleAssignmentsRow, error) {
+	rows, err := q.db.Query(ctx, listIdentityRoleAssignments, arg.IdentityID, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIdentityRoleAssignmentsRow
+	for rows.Next() {
+		var i ListIdentityRoleAssignmentsRow
+		if err := rows.Scan(
+			&i.IdentityID,
+			&i.RoleID,
+			&i.RoleTenantID,
+			&i.RoleCode,
+			&i.RoleName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRoleAssignments = `-- name: ListRoleAssignments :many
 SELECT
     ra.role_id,
@@ -234,19 +290,31 @@ SELECT
     ra.created_at
 FROM role_assignments ra
 WHERE ra.role_id = $1
-ORDER BY ra.created_at DESC
-LIMIT COALESCE($3::int, 50)
-OFFSET COALESCE($2::int, 0)
+  AND (
+      $2::timestamptz IS NULL
+      OR (ra.created_at, ra.identity_id) < ($2::timestamptz, $3::uuid)
+  )
+ORDER BY ra.created_at DESC, ra.identity_id DESC
+LIMIT COALESCE($5::int, 50)
+OFFSET COALESCE($4::int, 0)
 `
 
 type ListRoleAssignmentsParams struct {
-	RoleID      pgtype.UUID `json:"role_id"`
-	OffsetValue *int32      `json:"offset_value"`
-	LimitValue  *int32      `json:"limit_value"`
+	RoleID          pgtype.UUID        `json:"role_id"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	OffsetValue     *int32             `json:"offset_value"`
+	LimitValue      *int32             `json:"limit_value"`
 }
 
 func (q *Queries) ListRoleAssignments(ctx context.Context, arg ListRoleAssignmentsParams) ([]RoleAssignment, error) {
-	rows, err := q.db.Query(ctx, listRoleAssignments, arg.RoleID, arg.OffsetValue, arg.LimitValue)
+	rows, err := q.db.Query(ctx, listRoleAssignments,
+		arg.RoleID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.OffsetValue,
+		arg.LimitValue,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -327,17 +395,23 @@ WHERE
         OR r.code ILIKE '%' || $3::text || '%'
         OR r.name ILIKE '%' || $3::text || '%'
     )
-ORDER BY r.created_at DESC
-LIMIT COALESCE($5::int, 50)
-OFFSET COALESCE($4::int, 0)
+    AND (
+        $4::timestamptz IS NULL
+        OR (r.created_at, r.id) < ($4::timestamptz, $5::uuid)
+    )
+ORDER BY r.created_at DESC, r.id DESC
+LIMIT COALESCE($7::int, 50)
+OFFSET COALESCE($6::int, 0)
 `
 
 type ListRolesParams struct {
-	ScopeFilter  *string     `json:"scope_filter"`
-	TenantFilter pgtype.UUID `json:"tenant_filter"`
-	Search       *string     `json:"search"`
-	OffsetValue  *int32      `json:"offset_value"`
-	LimitValue   *int32      `json:"limit_value"`
+	ScopeFilter     *string            `json:"scope_filter"`
+	TenantFilter    pgtype.UUID        `json:"tenant_filter"`
+	Search          *string            `json:"search"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	OffsetValue     *int32             `json:"offset_value"`
+	LimitValue      *int32             `json:"limit_value"`
 }
 
 type ListRolesRow struct {
@@ -359,6 +433,8 @@ func (q *Queries) ListRoles(ctx context.Context, arg ListRolesParams) ([]ListRol
 		arg.ScopeFilter,
 		arg.TenantFilter,
 		arg.Search,
+		arg.CursorCreatedAt,
+		arg.CursorID,
 		arg.OffsetValue,
 		arg.LimitValue,
 	)
@@ -392,6 +468,89 @@ func (q *Queries) ListRoles(ctx context.Context, arg ListRolesParams) ([]ListRol
 	return items, nil
 }
 
+const listTenantRoleAssignments = `-- name: ListTenantRoleAssignments :many
+SELECT
+    ra.identity_id,
+    r.id AS role_id,
+    r.tenant_id AS role_tenant_id,
+    r.code AS role_code,
+    r.name AS role_name
+FROM role_assignments ra
+JOIN roles r ON r.id = ra.role_id
+WHERE ra.tenant_id = $1
+   OR r.tenant_id = $1
+ORDER BY ra.identity_id, r.code
+`
+
+type ListTenantRoleAssignmentsRow struct {
+	IdentityID   pgtype.UUID `json:"identity_id"`
+	RoleID       pgtype.UUID `json:"role_id"`
+	RoleTenantID pgtype.UUID `json:"role_tenant_id"`
+	RoleCode     string      `json:"role_code"`
+	RoleName     string      `json:"role_name"`
+}
+
+func (q *Queries) ListTenantRoleAssignments(ctx context.Context, tenantID pgtype.UUID) ([]ListTenantRoleAssignmentsRow, error) {
+	rows, err := q.db.Query(ctx, listTenantRoleAssignments, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTenantRoleAssignmentsRow
+	for rows.Next() {
+		var i ListTenantRoleAssignmentsRow
+		if err := rows.Scan(
+			&i.IdentityID,
+			&i.RoleID,
+			&i.RoleTenantID,
+			&i.RoleCode,
+			&i.RoleName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockRoleVersion = `-- name: LockRoleVersion :one
+SELECT version
+FROM roles
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockRoleVersion(ctx context.Context, id pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, lockRoleVersion, id)
+	var version int32
+	err := row.Scan(&version)
+	return version, err
+}
+
+const roleAssignmentExists = `-- name: RoleAssignmentExists :one
+SELECT EXISTS (
+    SELECT 1
+    FROM role_assignments
+    WHERE role_id = $1
+      AND identity_id = $2
+) AS assigned
+`
+
+type RoleAssignmentExistsParams struct {
+	RoleID     pgtype.UUID `json:"role_id"`
+	IdentityID pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) RoleAssignmentExists(ctx context.Context, arg RoleAssignmentExistsParams) (bool, error) {
+	row := q.db.QueryRow(ctx, roleAssignmentExists, arg.RoleID, arg.IdentityID)
+	var assigned bool
+	err := row.Scan(&assigned)
+	return assigned, err
+}
+
 const updateRole = `-- name: UpdateRole :one
 UPDATE roles
 SET
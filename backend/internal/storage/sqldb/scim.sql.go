@@ -0,0 +1,501 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: scim.sql
+
+package sqldb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createScimToken = `-- name: CreateScimToken :one
+INSERT INTO scim_tokens (
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by,
+    last_used_at,
+    revoked_at,
+    created_at
+`
+
+type CreateScimTokenParams struct {
+	ID          pgtype.UUID `json:"id"`
+	TenantID    pgtype.UUID `json:"tenant_id"`
+	Name        string      `json:"name"`
+	TokenHash   string      `json:"token_hash"`
+	TokenPrefix string      `json:"token_prefix"`
+	CreatedBy   *string     `json:"created_by"`
+}
+
+func (q *Queries) CreateScimToken(ctx context.Context, arg CreateScimTokenParams) (ScimToken, error) {
+	row := q.db.QueryRow(ctx, createScimToken,
+		arg.ID,
+		arg.TenantID,
+		arg.Name,
+		arg.TokenHash,
+		arg.TokenPrefix,
+		arg.CreatedBy,
+	)
+	var i ScimToken
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Name,
+		&i.TokenHash,
+		&i.TokenPrefix,
+		&i.CreatedBy,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteScimGroup = `-- name: DeleteScimGroup :exec
+DELETE FROM scim_groups
+WHERE group_id = $1
+`
+
+func (q *Queries) DeleteScimGroup(ctx context.Context, groupID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteScimGroup, groupID)
+	return err
+}
+
+const deleteScimUser = `-- name: DeleteScimUser :execrows
+DELETE FROM scim_users
+WHERE tenant_id = $1
+  AND identity_id = $2
+`
+
+type DeleteScimUserParams struct {
+	TenantID   pgtype.UUID `json:"tenant_id"`
+	IdentityID pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) DeleteScimUser(ctx context.Context, arg DeleteScimUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteScimUser, arg.TenantID, arg.IdentityID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getScimUser = `-- name: GetScimUser :one
+SELECT
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active,
+    created_at,
+    updated_at
+FROM scim_users
+WHERE tenant_id = $1
+  AND identity_id = $2
+`
+
+type GetScimUserParams struct {
+	TenantID   pgtype.UUID `json:"tenant_id"`
+	IdentityID pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) GetScimUser(ctx context.Context, arg GetScimUserParams) (ScimUser, error) {
+	row := q.db.QueryRow(ctx, getScimUser, arg.TenantID, arg.IdentityID)
+	var i ScimUser
+	err := row.Scan(
+		&i.TenantID,
+		&i.IdentityID,
+		&i.ExternalID,
+		&i.UserName,
+		&i.DisplayName,
+		&i.Phone,
+		&i.Title,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listScimGroups = `-- name: ListScimGroups :many
+SELECT
+    group_id,
+    tenant_id,
+    external_id,
+    created_at
+FROM scim_groups
+WHERE tenant_id = $1
+`
+
+func (q *Queries) ListScimGroups(ctx context.Context, tenantID pgtype.UUID) ([]ScimGroup, error) {
+	rows, err := q.db.Query(ctx, listScimGroups, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScimGroup
+	for rows.Next() {
+		var i ScimGroup
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.TenantID,
+			&i.ExternalID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScimTokens = `-- name: ListScimTokens :many
+SELECT
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by,
+    last_used_at,
+    revoked_at,
+    created_at
+FROM scim_tokens
+WHERE tenant_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListScimTokens(ctx context.Context, tenantID pgtype.UUID) ([]ScimToken, error) {
+	rows, err := q.db.Query(ctx, listScimTokens, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScimToken
+	for rows.Next() {
+		var i ScimToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Name,
+			&i.TokenHash,
+			&i.TokenPrefix,
+			&i.CreatedBy,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScimUsers = `-- name: ListScimUsers :many
+SELECT
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active,
+    created_at,
+    updated_at
+FROM scim_users
+WHERE tenant_id = $1
+ORDER BY created_at ASC, identity_id ASC
+`
+
+func (q *Queries) ListScimUsers(ctx context.Context, tenantID pgtype.UUID) ([]ScimUser, error) {
+	rows, err := q.db.Query(ctx, listScimUsers, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScimUser
+	for rows.Next() {
+		var i ScimUser
+		if err := rows.Scan(
+			&i.TenantID,
+			&i.IdentityID,
+			&i.ExternalID,
+			&i.UserName,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeScimToken = `-- name: RevokeScimToken :execrows
+UPDATE scim_tokens
+SET revoked_at = NOW()
+WHERE id = $1
+  AND tenant_id = $2
+  AND revoked_at IS NULL
+`
+
+type RevokeScimTokenParams struct {
+	ID       pgtype.UUID `json:"id"`
+	TenantID pgtype.UUID `json:"tenant_id"`
+}
+
+func (q *Queries) RevokeScimToken(ctx context.Context, arg RevokeScimTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeScimToken, arg.ID, arg.TenantID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateMemberProfiles = `-- name: UpdateMemberProfiles :many
+UPDATE group_members
+SET
+    display_name = $1,
+    phone = $2,
+    title = $3,
+    name_initials = $4,
+    updated_at = NOW()
+WHERE tenant_id = $5
+  AND identity_id = $6
+RETURNING
+    group_id,
+    identity_id,
+    tenant_id,
+    display_name,
+    phone,
+    title,
+    is_primary,
+    created_at,
+    updated_at,
+    name_initials
+`
+
+type UpdateMemberProfilesParams struct {
+	DisplayName  string      `json:"display_name"`
+	Phone        string      `json:"phone"`
+	Title        *string     `json:"title"`
+	NameInitials *string     `json:"name_initials"`
+	TenantID     pgtype.UUID `json:"tenant_id"`
+	IdentityID   pgtype.UUID `json:"identity_id"`
+}
+
+func (q *Queries) UpdateMemberProfiles(ctx context.Context, arg UpdateMemberProfilesParams) ([]GroupMember, error) {
+	rows, err := q.db.Query(ctx, updateMemberProfiles,
+		arg.DisplayName,
+		arg.Phone,
+		arg.Title,
+		arg.NameInitials,
+		arg.TenantID,
+		arg.IdentityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GroupMember
+	for rows.Next() {
+		var i GroupMember
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.IdentityID,
+			&i.TenantID,
+			&i.DisplayName,
+			&i.Phone,
+			&i.Title,
+			&i.IsPrimary,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NameInitials,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertScimGroup = `-- name: UpsertScimGroup :exec
+INSERT INTO scim_groups (
+    group_id,
+    tenant_id,
+    external_id
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (group_id) DO UPDATE
+SET external_id = EXCLUDED.external_id
+`
+
+type UpsertScimGroupParams struct {
+	GroupID    pgtype.UUID `json:"group_id"`
+	TenantID   pgtype.UUID `json:"tenant_id"`
+	ExternalID string      `json:"external_id"`
+}
+
+func (q *Queries) UpsertScimGroup(ctx context.Context, arg UpsertScimGroupParams) error {
+	_, err := q.db.Exec(ctx, upsertScimGroup, arg.GroupID, arg.TenantID, arg.ExternalID)
+	return err
+}
+
+const upsertScimUser = `-- name: UpsertScimUser :one
+INSERT INTO scim_users (
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT (tenant_id, identity_id) DO UPDATE
+SET
+    external_id = EXCLUDED.external_id,
+    user_name = EXCLUDED.user_name,
+    display_name = EXCLUDED.display_name,
+    phone = EXCLUDED.phone,
+    title = EXCLUDED.title,
+    active = EXCLUDED.active,
+    updated_at = NOW()
+RETURNING
+    tenant_id,
+    identity_id,
+    external_id,
+    user_name,
+    display_name,
+    phone,
+    title,
+    active,
+    created_at,
+    updated_at
+`
+
+type UpsertScimUserParams struct {
+	TenantID    pgtype.UUID `json:"tenant_id"`
+	IdentityID  pgtype.UUID `json:"identity_id"`
+	ExternalID  *string     `json:"external_id"`
+	UserName    string      `json:"user_name"`
+	DisplayName string      `json:"display_name"`
+	Phone       string      `json:"phone"`
+	Title       *string     `json:"title"`
+	Active      bool        `json:"active"`
+}
+
+func (q *Queries) UpsertScimUser(ctx context.Context, arg UpsertScimUserParams) (ScimUser, error) {
+	row := q.db.QueryRow(ctx, upsertScimUser,
+		arg.TenantID,
+		arg.IdentityID,
+		arg.ExternalID,
+		arg.UserName,
+		arg.DisplayName,
+		arg.Phone,
+		arg.Title,
+		arg.Active,
+	)
+	var i ScimUser
+	err := row.Scan(
+		&i.TenantID,
+		&i.IdentityID,
+		&i.ExternalID,
+		&i.UserName,
+		&i.DisplayName,
+		&i.Phone,
+		&i.Title,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const useScimToken = `-- name: UseScimToken :one
+UPDATE scim_tokens
+SET last_used_at = NOW()
+WHERE token_hash = $1
+  AND revoked_at IS NULL
+RETURNING
+    id,
+    tenant_id,
+    name,
+    token_hash,
+    token_prefix,
+    created_by,
+    last_used_at,
+    revoked_at,
+    created_at
+`
+
+func (q *Queries) UseScimToken(ctx context.Context, tokenHash string) (ScimToken, error) {
+	row := q.db.QueryRow(ctx, useScimToken, tokenHash)
+	var i ScimToken
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Name,
+		&i.TokenHash,
+		&i.TokenPrefix,
+		&i.CreatedBy,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
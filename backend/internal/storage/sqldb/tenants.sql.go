@@ -64,7 +64,8 @@ RETURNING
     contact_phone,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 `
 
 type CreateTenantParams struct {
@@ -98,6 +99,7 @@ func (q *Queries) CreateTenant(ctx context.Context, arg CreateTenantParams) (Ten
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -121,7 +123,8 @@ SELECT
     contact_phone,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenants
 WHERE id = $1
 `
@@ -139,6 +142,7 @@ func (q *Queries) GetTenant(ctx context.Context, id pgtype.UUID) (Tenant, error)
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -153,7 +157,8 @@ SELECT
     contact_phone,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 FROM tenants
 WHERE (
     $1::text IS NULL
@@ -164,21 +169,29 @@ AND (
     $2::text IS NULL
     OR status = $2::text
 )
-ORDER BY created_at DESC
-LIMIT $4 OFFSET $3
+AND (
+    $3::timestamptz IS NULL
+    OR (created_at, id) < ($3::timestamptz, $4::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $6 OFFSET $5
 `
 
 type ListTenantsParams struct {
-	Search       *string `json:"search"`
-	StatusFilter *string `json:"status_filter"`
-	OffsetValue  *int32  `json:"offset_value"`
-	LimitValue   *int32  `json:"limit_value"`
+	Search          *string            `json:"search"`
+	StatusFilter    *string            `json:"status_filter"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	OffsetValue     *int32             `json:"offset_value"`
+	LimitValue      *int32             `json:"limit_value"`
 }
 
 func (q *Queries) ListTenants(ctx context.Context, arg ListTenantsParams) ([]Tenant, error) {
 	rows, err := q.db.Query(ctx, listTenants,
 		arg.Search,
 		arg.StatusFilter,
+		arg.CursorCreatedAt,
+		arg.CursorID,
 		arg.OffsetValue,
 		arg.LimitValue,
 	)
@@ -199,6 +212,7 @@ func (q *Queries) ListTenants(ctx context.Context, arg ListTenantsParams) ([]Ten
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -210,6 +224,20 @@ func (q *Queries) ListTenants(ctx context.Context, arg ListTenantsParams) ([]Ten
 	return items, nil
 }
 
+const lockTenantVersion = `-- name: LockTenantVersion :one
+SELECT version
+FROM tenants
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockTenantVersion(ctx context.Context, id pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, lockTenantVersion, id)
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
+
 const updateTenant = `-- name: UpdateTenant :one
 UPDATE tenants
 SET
@@ -219,7 +247,8 @@ SET
     contact_name = $5,
     contact_phone = $6,
     metadata = COALESCE($7, metadata),
-    updated_at = NOW()
+    updated_at = NOW(),
+    version = version + 1
 WHERE id = $1
 RETURNING
     id,
@@ -230,7 +259,8 @@ RETURNING
     contact_phone,
     metadata,
     created_at,
-    updated_at
+    updated_at,
+    version
 `
 
 type UpdateTenantParams struct {
@@ -264,6 +294,7 @@ func (q *Queries) UpdateTenant(ctx context.Context, arg UpdateTenantParams) (Ten
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -0,0 +1,646 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: webhooks.sql
+
+package sqldb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+WITH claimed AS (
+    UPDATE webhook_deliveries d
+    SET
+        next_attempt_at = NOW() + ($1::int * INTERVAL '1 second'),
+        updated_at = NOW()
+    WHERE d.id IN (
+        SELECT p.id
+        FROM webhook_deliveries p
+        WHERE p.status = 'pending'
+          AND p.next_attempt_at <= NOW()
+        ORDER BY p.next_attempt_at
+        LIMIT $2::int
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING d.id, d.subscription_id, d.event_id, d.attempts
+)
+SELECT
+    c.id,
+    c.subscription_id,
+    c.event_id,
+    c.attempts,
+    s.url,
+    s.secret,
+    e.tenant_id,
+    e.event_type,
+    e.payload,
+    e.occurred_at
+FROM claimed c
+JOIN webhook_subscriptions s ON s.id = c.subscription_id
+JOIN domain_events e ON e.id = c.event_id
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseSeconds int32 `json:"lease_seconds"`
+	BatchSize    int32 `json:"batch_size"`
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	SubscriptionID pgtype.UUID        `json:"subscription_id"`
+	EventID        pgtype.UUID        `json:"event_id"`
+	Attempts       int32              `json:"attempts"`
+	Url            string             `json:"url"`
+	Secret         string             `json:"secret"`
+	TenantID       pgtype.UUID        `json:"tenant_id"`
+	EventType      string             `json:"event_type"`
+	Payload        []byte             `json:"payload"`
+	OccurredAt     pgtype.Timestamptz `json:"occurred_at"`
+}
+
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimWebhookDeliveries, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+			&i.TenantID,
+			&i.EventType,
+			&i.Payload,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeWebhookDelivery = `-- name: CompleteWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET
+    status = $1,
+    attempts = attempts + 1,
+    next_attempt_at = COALESCE($2::timestamptz, next_attempt_at),
+    last_status_code = $3,
+    last_error = $4,
+    delivered_at = CASE WHEN $1 = 'succeeded' THEN NOW() ELSE delivered_at END,
+    updated_at = NOW()
+WHERE id = $5
+`
+
+type CompleteWebhookDeliveryParams struct {
+	Status         string             `json:"status"`
+	NextAttemptAt  pgtype.Timestamptz `json:"next_attempt_at"`
+	LastStatusCode *int32             `json:"last_status_code"`
+	LastError      *string            `json:"last_error"`
+	ID             pgtype.UUID        `json:"id"`
+}
+
+func (q *Queries) CompleteWebhookDelivery(ctx context.Context, arg CompleteWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, completeWebhookDelivery,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const countWebhookDeliveries = `-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) AS total
+FROM webhook_deliveries
+WHERE subscription_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+`
+
+type CountWebhookDeliveriesParams struct {
+	SubscriptionID pgtype.UUID `json:"subscription_id"`
+	StatusFilter   *string     `json:"status_filter"`
+}
+
+func (q *Queries) CountWebhookDeliveries(ctx context.Context, arg CountWebhookDeliveriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countWebhookDeliveries, arg.SubscriptionID, arg.StatusFilter)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5::text[],
+    $6,
+    $7
+)
+RETURNING
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at
+`
+
+type CreateWebhookSubscriptionParams struct {
+	ID          pgtype.UUID `json:"id"`
+	TenantID    pgtype.UUID `json:"tenant_id"`
+	Url         string      `json:"url"`
+	Secret      string      `json:"secret"`
+	EventTypes  []string    `json:"event_types"`
+	Active      bool        `json:"active"`
+	Description *string     `json:"description"`
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, createWebhookSubscription,
+		arg.ID,
+		arg.TenantID,
+		arg.Url,
+		arg.Secret,
+		arg.EventTypes,
+		arg.Active,
+		arg.Description,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Active,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookSubscription, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (subscription_id, event_id)
+SELECT s.id, $1::uuid
+FROM webhook_subscriptions s
+WHERE s.tenant_id = $2::uuid
+  AND s.active
+  AND (
+      cardinality(s.event_types) = 0
+      OR $3::text = ANY(s.event_types)
+  )
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	EventID   pgtype.UUID `json:"event_id"`
+	TenantID  pgtype.UUID `json:"tenant_id"`
+	EventType string      `json:"event_type"`
+}
+
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueWebhookDeliveries, arg.EventID, arg.TenantID, arg.EventType)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT
+    d.id,
+    d.subscription_id,
+    d.event_id,
+    d.status,
+    d.attempts,
+    d.next_attempt_at,
+    d.last_status_code,
+    d.last_error,
+    d.delivered_at,
+    d.created_at,
+    d.updated_at,
+    e.event_type
+FROM webhook_deliveries d
+JOIN domain_events e ON e.id = d.event_id
+WHERE d.id = $1
+`
+
+type GetWebhookDeliveryRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	SubscriptionID pgtype.UUID        `json:"subscription_id"`
+	EventID        pgtype.UUID        `json:"event_id"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `json:"next_attempt_at"`
+	LastStatusCode *int32             `json:"last_status_code"`
+	LastError      *string            `json:"last_error"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	EventType      string             `json:"event_type"`
+}
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id pgtype.UUID) (GetWebhookDeliveryRow, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, id)
+	var i GetWebhookDeliveryRow
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EventType,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+SELECT
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at
+FROM webhook_subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id pgtype.UUID) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, getWebhookSubscription, id)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Active,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const insertDomainEvent = `-- name: InsertDomainEvent :exec
+INSERT INTO domain_events (
+    id,
+    tenant_id,
+    event_type,
+    payload,
+    request_id
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+`
+
+type InsertDomainEventParams struct {
+	ID        pgtype.UUID `json:"id"`
+	TenantID  pgtype.UUID `json:"tenant_id"`
+	EventType string      `json:"event_type"`
+	Payload   []byte      `json:"payload"`
+	RequestID *string     `json:"request_id"`
+}
+
+func (q *Queries) InsertDomainEvent(ctx context.Context, arg InsertDomainEventParams) error {
+	_, err := q.db.Exec(ctx, insertDomainEvent,
+		arg.ID,
+		arg.TenantID,
+		arg.EventType,
+		arg.Payload,
+		arg.RequestID,
+	)
+	return err
+}
+
+const insertWebhookDeliveryAttempt = `-- name: InsertWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (
+    delivery_id,
+    status_code,
+    error,
+    duration_ms
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+`
+
+type InsertWebhookDeliveryAttemptParams struct {
+	DeliveryID pgtype.UUID `json:"delivery_id"`
+	StatusCode *int32      `json:"status_code"`
+	Error      *string     `json:"error"`
+	DurationMs int32       `json:"duration_ms"`
+}
+
+func (q *Queries) InsertWebhookDeliveryAttempt(ctx context.Context, arg InsertWebhookDeliveryAttemptParams) error {
+	_, err := q.db.Exec(ctx, insertWebhookDeliveryAttempt,
+		arg.DeliveryID,
+		arg.StatusCode,
+		arg.Error,
+		arg.DurationMs,
+	)
+	return err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT
+    d.id,
+    d.subscription_id,
+    d.event_id,
+    d.status,
+    d.attempts,
+    d.next_attempt_at,
+    d.last_status_code,
+    d.last_error,
+    d.delivered_at,
+    d.created_at,
+    d.updated_at,
+    e.event_type
+FROM webhook_deliveries d
+JOIN domain_events e ON e.id = d.event_id
+WHERE d.subscription_id = $1
+  AND ($2::text IS NULL OR d.status = $2::text)
+ORDER BY d.created_at DESC, d.id
+LIMIT COALESCE($4::int, 50)
+OFFSET COALESCE($3::int, 0)
+`
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID pgtype.UUID `json:"subscription_id"`
+	StatusFilter   *string     `json:"status_filter"`
+	OffsetValue    *int32      `json:"offset_value"`
+	LimitValue     *int32      `json:"limit_value"`
+}
+
+type ListWebhookDeliveriesRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	SubscriptionID pgtype.UUID        `json:"subscription_id"`
+	EventID        pgtype.UUID        `json:"event_id"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `json:"next_attempt_at"`
+	LastStatusCode *int32             `json:"last_status_code"`
+	LastError      *string            `json:"last_error"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	EventType      string             `json:"event_type"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries,
+		arg.SubscriptionID,
+		arg.StatusFilter,
+		arg.OffsetValue,
+		arg.LimitValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhookDeliveriesRow
+	for rows.Next() {
+		var i ListWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EventType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveryAttempts = `-- name: ListWebhookDeliveryAttempts :many
+SELECT
+    id,
+    delivery_id,
+    status_code,
+    error,
+    duration_ms,
+    created_at
+FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID pgtype.UUID) ([]WebhookDeliveryAttempt, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveryAttempts, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDeliveryAttempt
+	for rows.Next() {
+		var i WebhookDeliveryAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.StatusCode,
+			&i.Error,
+			&i.DurationMs,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at
+FROM webhook_subscriptions
+WHERE $1::uuid IS NULL OR tenant_id = $1::uuid
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context, tenantFilter pgtype.UUID) ([]WebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptions, tenantFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookSubscription
+	for rows.Next() {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.Active,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const replayWebhookDelivery = `-- name: ReplayWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET
+    status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW(),
+    last_error = NULL,
+    last_status_code = NULL,
+    delivered_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) ReplayWebhookDelivery(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, replayWebhookDelivery, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateWebhookSubscription = `-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET
+    url = $1,
+    event_types = $2::text[],
+    active = $3,
+    description = $4,
+    secret = COALESCE($5::text, secret),
+    updated_at = NOW()
+WHERE id = $6
+RETURNING
+    id,
+    tenant_id,
+    url,
+    secret,
+    event_types,
+    active,
+    description,
+    created_at,
+    updated_at
+`
+
+type UpdateWebhookSubscriptionParams struct {
+	Url         string      `json:"url"`
+	EventTypes  []string    `json:"event_types"`
+	Active      bool        `json:"active"`
+	Description *string     `json:"description"`
+	Secret      *string     `json:"secret"`
+	ID          pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, updateWebhookSubscription,
+		arg.Url,
+		arg.EventTypes,
+		arg.Active,
+		arg.Description,
+		arg.Secret,
+		arg.ID,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.TenantID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Active,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
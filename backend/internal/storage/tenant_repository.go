@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
 )
@@ -25,19 +26,28 @@ type Tenant struct {
 	Metadata     json.RawMessage `json:"metadata"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
+	Version      int64           `json:"version"`
 }
 
-// ErrTenantNotFound is returned when a tenant cannot be located.
-var ErrTenantNotFound = errors.New("tenant not found")
+var (
+	// ErrTenantNotFound is returned when a tenant cannot be located.
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrTenantVersionConflict indicates the tenant changed since the client read it.
+	ErrTenantVersionConflict = errors.New("tenant has been modified")
+)
 
 // TenantRepository provides data access backed by sqlc generated queries.
 type TenantRepository struct {
+	pool    *pgxpool.Pool
 	queries *sqldb.Queries
 }
 
 // NewTenantRepository builds a repository using sqlc queries.
-func NewTenantRepository(queries *sqldb.Queries) *TenantRepository {
-	return &TenantRepository{queries: queries}
+func NewTenantRepository(pool *pgxpool.Pool, queries *sqldb.Queries) *TenantRepository {
+	return &TenantRepository{
+		pool:    pool,
+		queries: queries,
+	}
 }
 
 // ListTenants retrieves paginated tenants with optional search and status filters.
@@ -82,6 +92,62 @@ func (r *TenantRepository) ListTenants(ctx context.Context, search string, statu
 	return result, total, nil
 }
 
+// ListTenantsKeyset returns one page of tenants, newest first, using keyset pagination over
+// (created_at, id).
+func (r *TenantRepository) ListTenantsKeyset(ctx context.Context, search string, status string, page KeysetPage) ([]Tenant, KeysetResult, error) {
+	cursorCreatedAt, cursorID, err := keysetArgs(page.Cursor)
+	if err != nil {
+		return nil, KeysetResult{}, err
+	}
+
+	var searchArg *string
+	if trimmed := strings.TrimSpace(search); trimmed != "" {
+		searchArg = stringPtr(trimmed)
+	}
+	var statusArg *string
+	if trimmed := strings.ToLower(strings.TrimSpace(status)); trimmed != "" {
+		statusArg = stringPtr(trimmed)
+	}
+
+	items, err := r.queries.ListTenants(ctx, sqldb.ListTenantsParams{
+		Search:          searchArg,
+		StatusFilter:    statusArg,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		LimitValue:      int32Ptr(keysetLimit(page)),
+	})
+	if err != nil {
+		return nil, KeysetResult{}, fmt.Errorf("list tenants: %w", err)
+	}
+
+	result := make([]Tenant, 0, len(items))
+	for _, row := range items {
+		tenant, err := mapTenantRow(row)
+		if err != nil {
+			return nil, KeysetResult{}, err
+		}
+		result = append(result, tenant)
+	}
+
+	var pageResult KeysetResult
+	count, next := nextKeysetCursor(page, len(result), func(i int) (time.Time, uuid.UUID) {
+		return result[i].CreatedAt, result[i].ID
+	})
+	result, pageResult.NextCursor = result[:count], next
+
+	if page.WithTotal {
+		total, err := r.queries.CountTenants(ctx, sqldb.CountTenantsParams{
+			Search:       searchArg,
+			StatusFilter: statusArg,
+		})
+		if err != nil {
+			return nil, KeysetResult{}, fmt.Errorf("count tenants: %w", err)
+		}
+		pageResult.Total = &total
+	}
+	return result, pageResult, nil
+}
+
 // CreateTenant persists a new tenant record.
 func (r *TenantRepository) CreateTenant(ctx context.Context, tenant Tenant) (Tenant, error) {
 	if tenant.ID == uuid.Nil {
@@ -91,25 +157,44 @@ func (r *TenantRepository) CreateTenant(ctx context.Context, tenant Tenant) (Ten
 		tenant.Metadata = json.RawMessage(`{}`)
 	}
 
-	result, err := r.queries.CreateTenant(ctx, sqldb.CreateTenantParams{
-		ID:           uuidToPg(tenant.ID),
-		Code:         tenant.Code,
-		Name:         tenant.Name,
-		Column4:      statusOrNil(tenant.Status),
-		ContactName:  tenant.ContactName,
-		ContactPhone: tenant.ContactPhone,
-		Column7:      metadataOrNil(tenant.Metadata),
+	var created Tenant
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		result, err := qtx.CreateTenant(ctx, sqldb.CreateTenantParams{
+			ID:           uuidToPg(tenant.ID),
+			Code:         tenant.Code,
+			Name:         tenant.Name,
+			Column4:      statusOrNil(tenant.Status),
+			ContactName:  tenant.ContactName,
+			ContactPhone: tenant.ContactPhone,
+			Column7:      metadataOrNil(tenant.Metadata),
+		})
+		if err != nil {
+			return fmt.Errorf("create tenant: %w", err)
+		}
+		if created, err = mapTenantRow(result); err != nil {
+			return err
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &created.ID,
+			Action:     EventTenantCreated,
+			TargetType: "tenant",
+			TargetID:   created.ID.String(),
+			After:      created,
+		})
 	})
 	if err != nil {
-		return Tenant{}, fmt.Errorf("create tenant: %w", err)
+		return Tenant{}, err
 	}
-
-	return mapTenantRow(result)
+	return created, nil
 }
 
 // GetTenant fetches a tenant by ID.
 func (r *TenantRepository) GetTenant(ctx context.Context, id uuid.UUID) (Tenant, error) {
-	result, err := r.queries.GetTenant(ctx, uuidToPg(id))
+	return getTenant(ctx, r.queries, id)
+}
+
+func getTenant(ctx context.Context, q *sqldb.Queries, id uuid.UUID) (Tenant, error) {
+	result, err := q.GetTenant(ctx, uuidToPg(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Tenant{}, ErrTenantNotFound
@@ -119,8 +204,9 @@ func (r *TenantRepository) GetTenant(ctx context.Context, id uuid.UUID) (Tenant,
 	return mapTenantRow(result)
 }
 
-// UpdateTenant updates an existing tenant.
-func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant Tenant) (Tenant, error) {
+// UpdateTenant updates an existing tenant. A non-nil expectedVersion makes the update fail
+// with ErrTenantVersionConflict unless the stored tenant still has that version.
+func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant Tenant, expectedVersion *int64) (Tenant, error) {
 	var metadataArg []byte
 	if tenant.Metadata != nil {
 		if len(tenant.Metadata) == 0 {
@@ -130,28 +216,87 @@ func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant Tenant) (Ten
 		}
 	}
 
-	result, err := r.queries.UpdateTenant(ctx, sqldb.UpdateTenantParams{
-		ID:           uuidToPg(tenant.ID),
-		Code:         tenant.Code,
-		Name:         tenant.Name,
-		Status:       tenant.Status,
-		ContactName:  tenant.ContactName,
-		ContactPhone: tenant.ContactPhone,
-		Metadata:     metadataArg,
+	var updated Tenant
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		if err := checkTenantVersion(ctx, qtx, tenant.ID, expectedVersion); err != nil {
+			return err
+		}
+		before, err := getTenant(ctx, qtx, tenant.ID)
+		if err != nil {
+			return err
+		}
+
+		result, err := qtx.UpdateTenant(ctx, sqldb.UpdateTenantParams{
+			ID:           uuidToPg(tenant.ID),
+			Code:         tenant.Code,
+			Name:         tenant.Name,
+			Status:       tenant.Status,
+			ContactName:  tenant.ContactName,
+			ContactPhone: tenant.ContactPhone,
+			Metadata:     metadataArg,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrTenantNotFound
+			}
+			return fmt.Errorf("update tenant: %w", err)
+		}
+		if updated, err = mapTenantRow(result); err != nil {
+			return err
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &updated.ID,
+			Action:     EventTenantUpdated,
+			TargetType: "tenant",
+			TargetID:   updated.ID.String(),
+			Before:     before,
+			After:      updated,
+		})
 	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return Tenant{}, ErrTenantNotFound
-		}
-		return Tenant{}, fmt.Errorf("update tenant: %w", err)
+		return Tenant{}, err
 	}
-	return mapTenantRow(result)
+	return updated, nil
+}
+
+// DeleteTenant removes a tenant, optionally only while it still has expectedVersion.
+func (r *TenantRepository) DeleteTenant(ctx context.Context, id uuid.UUID, expectedVersion *int64) error {
+	return runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		if err := checkTenantVersion(ctx, qtx, id, expectedVersion); err != nil {
+			return err
+		}
+		before, err := getTenant(ctx, qtx, id)
+		if err != nil {
+			return err
+		}
+		if err := qtx.DeleteTenant(ctx, uuidToPg(id)); err != nil {
+			return fmt.Errorf("delete tenant: %w", err)
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &before.ID,
+			Action:     EventTenantDeleted,
+			TargetType: "tenant",
+			TargetID:   before.ID.String(),
+			Before:     before,
+		})
+	})
 }
 
-// DeleteTenant removes a tenant.
-func (r *TenantRepository) DeleteTenant(ctx context.Context, id uuid.UUID) error {
-	if err := r.queries.DeleteTenant(ctx, uuidToPg(id)); err != nil {
-		return fmt.Errorf("delete tenant: %w", err)
+// checkTenantVersion locks the tenant row for the rest of the transaction and compares its
+// version with expected. A nil expected version skips the comparison.
+func checkTenantVersion(ctx context.Context, q *sqldb.Queries, id uuid.UUID, expected *int64) error {
+	if expected == nil {
+		return nil
+	}
+	version, err := q.LockTenantVersion(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTenantNotFound
+		}
+		return fmt.Errorf("lock tenant: %w", err)
+	}
+	if version != *expected {
+		return ErrTenantVersionConflict
 	}
 	return nil
 }
@@ -183,6 +328,7 @@ func mapTenantRow(row sqldb.Tenant) (Tenant, error) {
 		Metadata:     metadata,
 		CreatedAt:    created,
 		UpdatedAt:    updated,
+		Version:      row.Version,
 	}, nil
 }
 
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// UserSnapshot is the audited view of a tenant user. Accounts live in Kratos, so the
+// caller supplies the state before and after a change.
+type UserSnapshot struct {
+	IdentityID  uuid.UUID `json:"identity_id"`
+	DisplayName string    `json:"display_name"`
+	Phone       string    `json:"phone"`
+	Username    string    `json:"username,omitempty"`
+	UserType    string    `json:"user_type,omitempty"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+	State       string    `json:"state"`
+}
+
+// UserRepository records changes to tenant users and keeps the member profiles copied
+// into group memberships in step with the identity traits.
+type UserRepository struct {
+	pool    *pgxpool.Pool
+	queries *sqldb.Queries
+}
+
+// NewUserRepository constructs a repository using sqlc generated queries.
+func NewUserRepository(pool *pgxpool.Pool, queries *sqldb.Queries) *UserRepository {
+	return &UserRepository{
+		pool:    pool,
+		queries: queries,
+	}
+}
+
+// RecordProfileUpdate copies the new name and phone to every membership of the user and
+// records the change.
+func (r *UserRepository) RecordProfileUpdate(ctx context.Context, tenantID uuid.UUID, before, after UserSnapshot) error {
+	return runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		if before.DisplayName != after.DisplayName || before.Phone != after.Phone {
+			if _, err := qtx.UpdateMemberContact(ctx, sqldb.UpdateMemberContactParams{
+				DisplayName:  after.DisplayName,
+				Phone:        after.Phone,
+				NameInitials: nameInitials(after.DisplayName),
+				TenantID:     uuidToPg(tenantID),
+				IdentityID:   uuidToPg(after.IdentityID),
+			}); err != nil {
+				return fmt.Errorf("update member contact: %w", err)
+			}
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &tenantID,
+			Action:     EventUserUpdated,
+			TargetType: "user",
+			TargetID:   after.IdentityID.String(),
+			Before:     before,
+			After:      after,
+		})
+	})
+}
+
+// RecordStateChange records a user being disabled or enabled and queues the Keto sync of
+// all the user's memberships and role assignments. It returns the queued keys.
+func (r *UserRepository) RecordStateChange(ctx context.Context, tenantID uuid.UUID, before, after UserSnapshot, disabled bool) ([]string, error) {
+	action := EventUserEnabled
+	if disabled {
+		action = EventUserDisabled
+	}
+	var keys []string
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		var err error
+		if keys, err = enqueueIdentitySync(ctx, qtx, tenantID, after.IdentityID); err != nil {
+			return err
+		}
+		return recordChange(ctx, qtx, changeEntry{
+			TenantID:   &tenantID,
+			Action:     action,
+			TargetType: "user",
+			TargetID:   after.IdentityID.String(),
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// QueueAccessSync queues the Keto sync of all memberships and role assignments of a user
+// again, so that repeating a disable or enable retries it. It returns the queued keys.
+func (r *UserRepository) QueueAccessSync(ctx context.Context, tenantID, identityID uuid.UUID) ([]string, error) {
+	var keys []string
+	err := runInTx(ctx, r.pool, r.queries, func(qtx *sqldb.Queries) error {
+		var err error
+		keys, err = enqueueIdentitySync(ctx, qtx, tenantID, identityID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
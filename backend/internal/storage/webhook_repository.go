@@ -0,0 +1,416 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage/sqldb"
+)
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookSubscription registers a tenant endpoint that receives signed domain events.
+type WebhookSubscription struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"-"`
+	EventTypes  []string  `json:"event_types"`
+	Active      bool      `json:"active"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery tracks the delivery of one event to one subscription.
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode *int32     `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookDeliveryAttempt is the log entry of a single HTTP call made for a delivery.
+type WebhookDeliveryAttempt struct {
+	ID         int64     `json:"id"`
+	DeliveryID uuid.UUID `json:"delivery_id"`
+	StatusCode *int32    `json:"status_code,omitempty"`
+	Error      *string   `json:"error,omitempty"`
+	DurationMS int32     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PendingDelivery is a claimed delivery together with everything needed to send it.
+type PendingDelivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	Attempts       int32
+	URL            string
+	Secret         string
+	TenantID       *uuid.UUID
+	EventType      string
+	Payload        json.RawMessage
+	OccurredAt     time.Time
+}
+
+// DeliveryResult records the outcome of one delivery attempt.
+type DeliveryResult struct {
+	StatusCode    *int32
+	Error         *string
+	Duration      time.Duration
+	Status        string
+	NextAttemptAt *time.Time
+}
+
+var (
+	// ErrWebhookNotFound indicates the requested webhook subscription does not exist.
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	// ErrWebhookDeliveryNotFound indicates the requested delivery does not exist.
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookRepository manages webhook subscriptions and the delivery outbox.
+type WebhookRepository struct {
+	queries *sqldb.Queries
+}
+
+// NewWebhookRepository constructs a repository backed by sqlc queries.
+func NewWebhookRepository(queries *sqldb.Queries) *WebhookRepository {
+	return &WebhookRepository{queries: queries}
+}
+
+// ListSubscriptions returns subscriptions of a tenant, or all subscriptions when tenantID is nil.
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context, tenantID *uuid.UUID) ([]WebhookSubscription, error) {
+	rows, err := r.queries.ListWebhookSubscriptions(ctx, uuidToNullablePg(tenantID))
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+
+	result := make([]WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		subscription, err := mapWebhookSubscription(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, subscription)
+	}
+	return result, nil
+}
+
+// GetSubscription fetches a subscription by ID.
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (WebhookSubscription, error) {
+	row, err := r.queries.GetWebhookSubscription(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WebhookSubscription{}, ErrWebhookNotFound
+		}
+		return WebhookSubscription{}, fmt.Errorf("get webhook subscription: %w", err)
+	}
+	return mapWebhookSubscription(row)
+}
+
+// CreateSubscription persists a new subscription.
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription WebhookSubscription) (WebhookSubscription, error) {
+	if subscription.ID == uuid.Nil {
+		subscription.ID = uuid.New()
+	}
+
+	row, err := r.queries.CreateWebhookSubscription(ctx, sqldb.CreateWebhookSubscriptionParams{
+		ID:          uuidToPg(subscription.ID),
+		TenantID:    uuidToPg(subscription.TenantID),
+		Url:         strings.TrimSpace(subscription.URL),
+		Secret:      subscription.Secret,
+		EventTypes:  nonNilStrings(subscription.EventTypes),
+		Active:      subscription.Active,
+		Description: subscription.Description,
+	})
+	if err != nil {
+		return WebhookSubscription{}, fmt.Errorf("create webhook subscription: %w", err)
+	}
+	return mapWebhookSubscription(row)
+}
+
+// UpdateSubscription updates a subscription. An empty Secret keeps the current one.
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, subscription WebhookSubscription) (WebhookSubscription, error) {
+	var secretArg *string
+	if subscription.Secret != "" {
+		secretArg = stringPtr(subscription.Secret)
+	}
+
+	row, err := r.queries.UpdateWebhookSubscription(ctx, sqldb.UpdateWebhookSubscriptionParams{
+		Url:         strings.TrimSpace(subscription.URL),
+		EventTypes:  nonNilStrings(subscription.EventTypes),
+		Active:      subscription.Active,
+		Description: subscription.Description,
+		Secret:      secretArg,
+		ID:          uuidToPg(subscription.ID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WebhookSubscription{}, ErrWebhookNotFound
+		}
+		return WebhookSubscription{}, fmt.Errorf("update webhook subscription: %w", err)
+	}
+	return mapWebhookSubscription(row)
+}
+
+// DeleteSubscription removes a subscription and its delivery history.
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	affected, err := r.queries.DeleteWebhookSubscription(ctx, uuidToPg(id))
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns paginated deliveries of a subscription, optionally filtered by status.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit, offset int32) ([]WebhookDelivery, int64, error) {
+	statusArg := optionalString(status)
+
+	var offsetArg *int32
+	if offset > 0 {
+		offsetArg = int32Ptr(offset)
+	}
+
+	var limitArg *int32
+	if limit > 0 {
+		limitArg = int32Ptr(limit)
+	}
+
+	rows, err := r.queries.ListWebhookDeliveries(ctx, sqldb.ListWebhookDeliveriesParams{
+		SubscriptionID: uuidToPg(subscriptionID),
+		StatusFilter:   statusArg,
+		OffsetValue:    offsetArg,
+		LimitValue:     limitArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+
+	total, err := r.queries.CountWebhookDeliveries(ctx, sqldb.CountWebhookDeliveriesParams{
+		SubscriptionID: uuidToPg(subscriptionID),
+		StatusFilter:   statusArg,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("count webhook deliveries: %w", err)
+	}
+
+	result := make([]WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		delivery, err := mapWebhookDelivery(sqldb.GetWebhookDeliveryRow(row))
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, delivery)
+	}
+	return result, total, nil
+}
+
+// GetDelivery fetches a delivery by ID.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (WebhookDelivery, error) {
+	row, err := r.queries.GetWebhookDelivery(ctx, uuidToPg(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WebhookDelivery{}, ErrWebhookDeliveryNotFound
+		}
+		return WebhookDelivery{}, fmt.Errorf("get webhook delivery: %w", err)
+	}
+	return mapWebhookDelivery(row)
+}
+
+// ListAttempts returns the attempt log of a delivery, newest first.
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]WebhookDeliveryAttempt, error) {
+	rows, err := r.queries.ListWebhookDeliveryAttempts(ctx, uuidToPg(deliveryID))
+	if err != nil {
+		return nil, fmt.Errorf("list webhook delivery attempts: %w", err)
+	}
+
+	result := make([]WebhookDeliveryAttempt, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, WebhookDeliveryAttempt{
+			ID:         row.ID,
+			DeliveryID: deliveryID,
+			StatusCode: row.StatusCode,
+			Error:      row.Error,
+			DurationMS: row.DurationMs,
+			CreatedAt:  row.CreatedAt.Time,
+		})
+	}
+	return result, nil
+}
+
+// ReplayDelivery resets a delivery so the dispatcher sends it again immediately.
+func (r *WebhookRepository) ReplayDelivery(ctx context.Context, id uuid.UUID) error {
+	affected, err := r.queries.ReplayWebhookDelivery(ctx, uuidToPg(id))
+	if err != nil {
+		return fmt.Errorf("replay webhook delivery: %w", err)
+	}
+	if affected == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// ClaimDeliveries leases up to batchSize due deliveries for the given duration so that
+// concurrent dispatchers do not send the same delivery twice.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, batchSize int32, lease time.Duration) ([]PendingDelivery, error) {
+	rows, err := r.queries.ClaimWebhookDeliveries(ctx, sqldb.ClaimWebhookDeliveriesParams{
+		LeaseSeconds: int32(lease / time.Second),
+		BatchSize:    batchSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+
+	result := make([]PendingDelivery, 0, len(rows))
+	for _, row := range rows {
+		id, _, err := pgUUIDToUUID(row.ID)
+		if err != nil {
+			return nil, fmt.Errorf("parse delivery id: %w", err)
+		}
+		subscriptionID, _, err := pgUUIDToUUID(row.SubscriptionID)
+		if err != nil {
+			return nil, fmt.Errorf("parse subscription id: %w", err)
+		}
+		eventID, _, err := pgUUIDToUUID(row.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("parse event id: %w", err)
+		}
+		var tenantID *uuid.UUID
+		if value, ok, err := pgUUIDToUUID(row.TenantID); err != nil {
+			return nil, fmt.Errorf("parse event tenant id: %w", err)
+		} else if ok {
+			tenantID = &value
+		}
+
+		result = append(result, PendingDelivery{
+			ID:             id,
+			SubscriptionID: subscriptionID,
+			EventID:        eventID,
+			Attempts:       row.Attempts,
+			URL:            row.Url,
+			Secret:         row.Secret,
+			TenantID:       tenantID,
+			EventType:      row.EventType,
+			Payload:        json.RawMessage(row.Payload),
+			OccurredAt:     row.OccurredAt.Time,
+		})
+	}
+	return result, nil
+}
+
+// CompleteDelivery logs an attempt and moves the delivery to its next state.
+func (r *WebhookRepository) CompleteDelivery(ctx context.Context, id uuid.UUID, result DeliveryResult) error {
+	if err := r.queries.InsertWebhookDeliveryAttempt(ctx, sqldb.InsertWebhookDeliveryAttemptParams{
+		DeliveryID: uuidToPg(id),
+		StatusCode: result.StatusCode,
+		Error:      result.Error,
+		DurationMs: int32(result.Duration / time.Millisecond),
+	}); err != nil {
+		return fmt.Errorf("insert webhook delivery attempt: %w", err)
+	}
+
+	if err := r.queries.CompleteWebhookDelivery(ctx, sqldb.CompleteWebhookDeliveryParams{
+		Status:         result.Status,
+		NextAttemptAt:  optionalTimestamptz(result.NextAttemptAt),
+		LastStatusCode: result.StatusCode,
+		LastError:      result.Error,
+		ID:             uuidToPg(id),
+	}); err != nil {
+		return fmt.Errorf("complete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func mapWebhookSubscription(row sqldb.WebhookSubscription) (WebhookSubscription, error) {
+	id, _, err := pgUUIDToUUID(row.ID)
+	if err != nil {
+		return WebhookSubscription{}, fmt.Errorf("parse webhook id: %w", err)
+	}
+	tenantID, _, err := pgUUIDToUUID(row.TenantID)
+	if err != nil {
+		return WebhookSubscription{}, fmt.Errorf("parse webhook tenant id: %w", err)
+	}
+
+	return WebhookSubscription{
+		ID:          id,
+		TenantID:    tenantID,
+		URL:         row.Url,
+		Secret:      row.Secret,
+		EventTypes:  nonNilStrings(row.EventTypes),
+		Active:      row.Active,
+		Description: row.Description,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}, nil
+}
+
+func mapWebhookDelivery(row sqldb.GetWebhookDeliveryRow) (WebhookDelivery, error) {
+	id, _, err := pgUUIDToUUID(row.ID)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("parse delivery id: %w", err)
+	}
+	subscriptionID, _, err := pgUUIDToUUID(row.SubscriptionID)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("parse subscription id: %w", err)
+	}
+	eventID, _, err := pgUUIDToUUID(row.EventID)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("parse event id: %w", err)
+	}
+
+	return WebhookDelivery{
+		ID:             id,
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      row.EventType,
+		Status:         row.Status,
+		Attempts:       row.Attempts,
+		NextAttemptAt:  row.NextAttemptAt.Time,
+		LastStatusCode: row.LastStatusCode,
+		LastError:      row.LastError,
+		DeliveredAt:    timestamptzPtr(row.DeliveredAt),
+		CreatedAt:      row.CreatedAt.Time,
+		UpdatedAt:      row.UpdatedAt.Time,
+	}, nil
+}
+
+func timestamptzPtr(value pgtype.Timestamptz) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t := value.Time
+	return &t
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/laofa009/next-agent-portal/backend/internal/storage"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultBatchSize    = 20
+	defaultMaxAttempts  = 8
+	defaultTimeout      = 10 * time.Second
+	baseBackoff         = 30 * time.Second
+	maxBackoff          = 6 * time.Hour
+	maxErrorLength      = 512
+)
+
+// Options configures the dispatcher.
+type Options struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	Timeout      time.Duration
+	// AllowPrivateEndpoints permits deliveries to loopback and private addresses, for
+	// local development only.
+	AllowPrivateEndpoints bool
+}
+
+// Envelope is the JSON body delivered to subscribers.
+type Envelope struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	TenantID   *uuid.UUID      `json:"tenant_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Dispatcher polls the delivery outbox and posts signed events to subscriber endpoints.
+type Dispatcher struct {
+	repo       *storage.WebhookRepository
+	httpClient *http.Client
+	logger     *zap.Logger
+	opts       Options
+}
+
+// NewDispatcher constructs a dispatcher, filling unset options with defaults.
+func NewDispatcher(repo *storage.WebhookRepository, opts Options, logger *zap.Logger) *Dispatcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	return &Dispatcher{
+		repo: repo,
+		httpClient: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: newTransport(opts.Timeout, opts.AllowPrivateEndpoints),
+		},
+		logger: logger.Named("webhook"),
+		opts:   opts,
+	}
+}
+
+// Run processes due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchBatch(ctx context.Context) {
+	// The lease must outlive the HTTP call so no other dispatcher picks the delivery up
+	// while it is still in flight.
+	lease := d.opts.Timeout + time.Minute
+
+	deliveries, err := d.repo.ClaimDeliveries(ctx, int32(d.opts.BatchSize), lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Error("claim webhook deliveries failed", zap.Error(err))
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery storage.PendingDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery storage.PendingDelivery) {
+	started := time.Now()
+	statusCode, sendErr := d.send(ctx, delivery)
+
+	result := storage.DeliveryResult{
+		Duration: time.Since(started),
+		Status:   storage.DeliverySucceeded,
+	}
+	if statusCode > 0 {
+		code := int32(statusCode)
+		result.StatusCode = &code
+	}
+
+	if sendErr != nil {
+		message := sendErr.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+		result.Error = &message
+
+		attempts := int(delivery.Attempts) + 1
+		if attempts >= d.opts.MaxAttempts {
+			result.Status = storage.DeliveryFailed
+		} else {
+			result.Status = storage.DeliveryPending
+			next := time.Now().Add(backoff(attempts))
+			result.NextAttemptAt = &next
+		}
+
+		d.logger.Warn("webhook delivery failed",
+			zap.String("delivery", delivery.ID.String()),
+			zap.String("event", delivery.EventType),
+			zap.Int("attempt", attempts),
+			zap.String("status", result.Status),
+			zap.Error(sendErr),
+		)
+	}
+
+	// Persist the outcome even if the dispatcher is shutting down.
+	if err := d.repo.CompleteDelivery(context.WithoutCancel(ctx), delivery.ID, result); err != nil {
+		d.logger.Error("record webhook delivery failed", zap.String("delivery", delivery.ID.String()), zap.Error(err))
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery storage.PendingDelivery) (int, error) {
+	body, err := json.Marshal(Envelope{
+		ID:         delivery.EventID,
+		Type:       delivery.EventType,
+		TenantID:   delivery.TenantID,
+		OccurredAt: delivery.OccurredAt,
+		Data:       delivery.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("encode envelope: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "next-agent-portal-webhook/1")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, fmt.Sprintf("%d", now.Unix()))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, now, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait before retry number attempts, doubling from baseBackoff.
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrEndpointNotAllowed is returned for endpoints that resolve to loopback, private,
+// link-local, multicast or unspecified addresses, which would let a subscriber reach
+// services inside the cluster.
+var ErrEndpointNotAllowed = errors.New("webhook endpoint address not allowed")
+
+// CheckEndpoint resolves the host of endpoint and rejects it when any of its addresses
+// is internal. The dispatcher checks the dialled address again, so a host that later
+// resolves elsewhere is still refused.
+func CheckEndpoint(ctx context.Context, endpoint *url.URL, allowPrivate bool) error {
+	if allowPrivate {
+		return nil
+	}
+
+	host := endpoint.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrEndpointNotAllowed, addr)
+	}
+	return nil
+}
+
+// newTransport returns a transport that refuses to connect to internal addresses unless
+// allowPrivate is set. The check runs on the address actually dialled, which covers
+// redirects and DNS answers that changed since the endpoint was registered. Proxies
+// are not used, since the proxy address would be checked instead of the endpoint.
+func newTransport(timeout time.Duration, allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("parse dialled address: %w", err)
+			}
+			return checkAddr(addrPort.Addr())
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
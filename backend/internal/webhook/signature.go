@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every webhook delivery.
+const (
+	HeaderEvent     = "X-Portal-Event"
+	HeaderDelivery  = "X-Portal-Delivery"
+	HeaderTimestamp = "X-Portal-Timestamp"
+	HeaderSignature = "X-Portal-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// ErrInvalidSignature is returned by Verify when the signature does not match the payload.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign computes the signature header value for body sent at timestamp.
+// The signed message is "<unix timestamp>.<body>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a received delivery. Deliveries older
+// than tolerance are rejected to limit replay; a zero tolerance disables the check.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrInvalidSignature)
+	}
+	sentAt := time.Unix(unix, 0)
+	if tolerance > 0 {
+		if age := time.Since(sentAt); age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+		}
+	}
+
+	expected := Sign(secret, sentAt, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// NewSecret generates a random signing secret for a subscription.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -1,5 +0,0 @@
-DROP TRIGGER IF EXISTS trigger_set_group_members_updated_at ON group_members;
-DROP TABLE IF EXISTS group_members;
-
-DROP TRIGGER IF EXISTS trigger_set_tenant_groups_updated_at ON tenant_groups;
-DROP TABLE IF EXISTS tenant_groups;
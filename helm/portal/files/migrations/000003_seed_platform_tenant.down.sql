@@ -1,2 +0,0 @@
-DELETE FROM tenant_groups WHERE tenant_id = '00000000-0000-0000-0000-000000000001';
-DELETE FROM tenants WHERE id = '00000000-0000-0000-0000-000000000001';
@@ -1,6 +0,0 @@
-DROP TRIGGER IF EXISTS trigger_set_roles_updated_at ON roles;
-DROP TABLE IF EXISTS role_assignments;
-DROP TABLE IF EXISTS role_permissions;
-DROP TABLE IF EXISTS roles;
-DROP TRIGGER IF EXISTS trigger_set_permissions_updated_at ON permissions;
-DROP TABLE IF EXISTS permissions;